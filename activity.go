@@ -0,0 +1,421 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kinds of activity events
+const (
+	activityFileAdded       = "file_added"       // A source showed up with results for the first time
+	activityRecordsAppended = "records_appended" // A known source gained results
+	activityRunCompleted    = "run_completed"    // A run stopped receiving results, see activityRunIdle
+)
+
+// activityRunIdle is how long a run has to go without new results before it counts as completed
+const activityRunIdle = 5 * time.Minute
+
+// activityPollInterval is how often the tracker looks for new results
+const activityPollInterval = 5 * time.Second
+
+// activityPageSize bounds the events listed on /activity, the API returns all of them
+const activityPageSize = 200
+
+// ActivityEvent is one entry of the audit trail: results showing up in a source, or a run ending
+type ActivityEvent struct {
+	Time     time.Time `json:"time"`
+	Kind     string    `json:"kind"`
+	Source   string    `json:"source,omitempty"` // Not set for run_completed
+	Runs     []string  `json:"runs,omitempty"`   // Runs the results belong to, see runName
+	Models   []string  `json:"models,omitempty"`
+	Results  int       `json:"results"`             // New results, or all results of a completed run
+	AvgScore float64   `json:"avg_score,omitempty"` // Of a completed run, over its results without an error
+}
+
+// RunURL is the dashboard filtered to one of the event's runs; runs named after their source
+// have no run_id
+func (e ActivityEvent) RunURL(run string) string {
+	if e.Source != "" && run == e.Source {
+		return "/?" + url.Values{"source": {run}}.Encode()
+	}
+	return "/?" + url.Values{"run_id": {run}}.Encode()
+}
+
+// activityLog keeps the audit trail in an append-only JSONL file, see --activity-file
+// An empty path keeps it in memory only
+type activityLog struct {
+	path string
+
+	mu      sync.Mutex
+	events  []ActivityEvent // Oldest first
+	sources map[string]int  // Results recorded per source, to catch up on what arrived while stopped
+}
+
+// activity is the audit trail, see activityFor for those of --config and --project dashboards
+var activity *activityLog
+
+// newActivityLog loads the events in path, a missing file means none yet
+func newActivityLog(path string) (*activityLog, error) {
+	al := &activityLog{path: path, sources: make(map[string]int)}
+	if path == "" {
+		return al, nil
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return al, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read activity log: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var e ActivityEvent
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			return nil, fmt.Errorf("invalid activity log %s line %d: %w", path, lineNum, err)
+		}
+		al.add(e)
+	}
+	return al, scanner.Err()
+}
+
+// add indexes an event, must be called with al.mu held (or before the log is shared)
+func (al *activityLog) add(e ActivityEvent) {
+	if e.Kind == activityFileAdded || e.Kind == activityRecordsAppended {
+		al.sources[e.Source] += e.Results
+	}
+	al.events = append(al.events, e)
+}
+
+// Record appends events to the file in one write and indexes them
+func (al *activityLog) Record(events []ActivityEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	if al.path != "" {
+		var lines []byte
+		for _, e := range events {
+			line, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			lines = append(append(lines, line...), '\n')
+		}
+		unlock, err := lockFile(al.path)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+		f, err := os.OpenFile(al.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to write activity log: %w", err)
+		}
+		if _, err := f.Write(lines); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to write activity log: %w", err)
+		}
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("failed to write activity log: %w", err)
+		}
+	}
+	for _, e := range events {
+		al.add(e)
+	}
+	return nil
+}
+
+// sourceResults returns how many results of source the log has recorded, ok is false when none
+func (al *activityLog) sourceResults(source string) (n int, ok bool) {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	n, ok = al.sources[source]
+	return n, ok
+}
+
+// List returns the events of kind (all kinds when empty) involving source and run (any when
+// empty), newest first
+func (al *activityLog) List(kind, source, run string) []ActivityEvent {
+	if al == nil {
+		return nil
+	}
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	list := []ActivityEvent{}
+	for i := len(al.events) - 1; i >= 0; i-- {
+		e := al.events[i]
+		switch {
+		case kind != "" && e.Kind != kind:
+		case source != "" && e.Source != source:
+		case run != "" && !slices.Contains(e.Runs, run):
+		default:
+			list = append(list, e)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Time.After(list[j].Time) })
+	return list
+}
+
+// runTally is what the tracker knows about a run: its results so far and when the last arrived
+type runTally struct {
+	results, scored int
+	sum             float64
+	models          map[string]bool
+	lastNew         time.Time // Zero for runs that got no results since the tracker started
+}
+
+// activityTracker turns the store's stream of new results into activity events
+type activityTracker struct {
+	log  *activityLog
+	runs map[string]*runTally
+	idle time.Duration
+}
+
+func newActivityTracker(al *activityLog) *activityTracker {
+	return &activityTracker{log: al, runs: make(map[string]*runTally), idle: activityRunIdle}
+}
+
+// Run records events for the store's new results and idle runs until the process exits
+func (t *activityTracker) Run(s Store, interval time.Duration) {
+	updates := s.Watch(context.Background(), interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	first := true
+	for {
+		var events []ActivityEvent
+		select {
+		case batch, ok := <-updates:
+			if !ok {
+				return
+			}
+			events = t.Observe(batch, time.Now(), first)
+			first = false
+		case <-ticker.C:
+		}
+		events = append(events, t.Complete(time.Now())...)
+		if err := t.log.Record(events); err != nil {
+			log.Printf("Warning: Failed to record activity: %v", err)
+		}
+	}
+}
+
+// Observe returns the events for a batch from Store.Watch, one per source
+// The first batch replays everything already stored: only sources the log has never seen, and
+// results beyond what it recorded for the others (appended while goevals was stopped), are new;
+// of those, only the results appended while stopped make their runs eligible for completion
+func (t *activityTracker) Observe(batch []EvalResult, now time.Time, first bool) []ActivityEvent {
+	bySource := make(map[string][]EvalResult)
+	var sources []string
+	for _, result := range batch {
+		if _, ok := bySource[result.Origin]; !ok {
+			sources = append(sources, result.Origin)
+		}
+		bySource[result.Origin] = append(bySource[result.Origin], result)
+	}
+
+	var events []ActivityEvent
+	for _, source := range sources {
+		results := bySource[source]
+		recorded, known := t.log.sourceResults(source)
+		fresh := results
+		if first && known {
+			// Sources keep their order, what arrived while stopped is at the end
+			fresh = results[min(recorded, len(results)):]
+		}
+		for i, result := range results {
+			t.tally(result, now, !first || known && i >= len(results)-len(fresh))
+		}
+		if len(fresh) == 0 {
+			continue
+		}
+		kind := activityRecordsAppended
+		if !known {
+			kind = activityFileAdded
+		}
+		runs, models := make(map[string]bool), make(map[string]bool)
+		for _, result := range fresh {
+			runs[runName(runOf(result))] = true
+			models[result.Model] = true
+		}
+		events = append(events, ActivityEvent{
+			Time:    now,
+			Kind:    kind,
+			Source:  source,
+			Runs:    sortedKeys(runs, ""),
+			Models:  sortedKeys(models, ""),
+			Results: len(fresh),
+		})
+	}
+	return events
+}
+
+// tally adds a result to its run; isNew marks results that arrived since the tracker started,
+// only their runs can complete
+func (t *activityTracker) tally(result EvalResult, now time.Time, isNew bool) {
+	name := runName(runOf(result))
+	run := t.runs[name]
+	if run == nil {
+		run = &runTally{models: make(map[string]bool)}
+		t.runs[name] = run
+	}
+	run.results++
+	run.models[result.Model] = true
+	if result.Error == "" {
+		run.scored++
+		run.sum += result.Scores.Combined
+	}
+	if isNew {
+		run.lastNew = now
+	}
+}
+
+// Complete returns a run_completed event, dated at its last new result, for every run that got
+// new results but none for the idle time
+func (t *activityTracker) Complete(now time.Time) []ActivityEvent {
+	var events []ActivityEvent
+	for name, run := range t.runs {
+		if run.lastNew.IsZero() || now.Sub(run.lastNew) < t.idle {
+			continue
+		}
+		e := ActivityEvent{Time: run.lastNew, Kind: activityRunCompleted, Runs: []string{name}, Models: sortedKeys(run.models, ""), Results: run.results}
+		run.lastNew = time.Time{} // Completes again if it gets more results
+		if run.scored > 0 {
+			e.AvgScore = run.sum / float64(run.scored)
+		}
+		events = append(events, e)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Runs[0] < events[j].Runs[0] })
+	return events
+}
+
+// activityHandler renders the audit trail, newest first
+func activityHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	al := activityFor(r.Context())
+	events := al.List(q.Get("kind"), q.Get("source"), q.Get("run"))
+	data := struct {
+		Title    string
+		Subtitle string
+		Latest   *ActivityEvent // Latest change to the numbers
+		Events   []ActivityEvent
+		Total    int
+		Kind     string
+		Run      string
+		Source   string
+		Idle     string
+	}{
+		Title:    "Activity",
+		Subtitle: "When results were added and which runs they came from",
+		Events:   events[:min(len(events), activityPageSize)],
+		Total:    len(events),
+		Kind:     q.Get("kind"),
+		Run:      q.Get("run"),
+		Source:   q.Get("source"),
+		Idle:     formatAge(activityRunIdle),
+	}
+	for _, e := range al.List("", "", "") {
+		if e.Kind != activityRunCompleted {
+			data.Latest = &e
+			break
+		}
+	}
+	renderPage(w, r, "activity", activityTemplate, data)
+}
+
+// activityAPIHandler returns the audit trail as JSON, newest first, filtered by ?kind=, ?source= and ?run=
+func activityAPIHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if kind := q.Get("kind"); kind != "" && kind != activityFileAdded && kind != activityRecordsAppended && kind != activityRunCompleted {
+		http.Error(w, fmt.Sprintf("invalid kind %q: use file_added, records_appended or run_completed", kind), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(activityFor(r.Context()).List(q.Get("kind"), q.Get("source"), q.Get("run"))); err != nil {
+		log.Printf("Error encoding JSON: %v", err)
+	}
+}
+
+const activityTemplate = `
+{{ define "style" }}
+        td.num {
+            font-family: monospace;
+            text-align: right;
+            white-space: nowrap;
+        }
+        td a.mono {
+            color: var(--accent);
+            text-decoration: none;
+        }
+        .activity-kind {
+            font-size: 0.75rem;
+            font-weight: 600;
+            white-space: nowrap;
+        }
+{{ end }}
+
+{{ define "content" }}
+        {{ with .Latest }}
+        <div class="panel">
+            <p>Current numbers last changed <span class="mono">{{ .Time.Format "2006-01-02 15:04:05" }}</span>: {{ human .Results }} result(s) {{ if eq .Kind "file_added" }}in new source{{ else }}appended to{{ end }} <span class="mono">{{ .Source }}</span>{{ if .Runs }}, from {{ $e := . }}{{ range $i, $run := .Runs }}{{ if $i }}, {{ end }}<a class="mono" href="{{ $e.RunURL $run }}">{{ $run }}</a>{{ end }}{{ end }}.</p>
+        </div>
+        {{ end }}
+
+        <div class="panel">
+            {{ if .Events }}
+            {{ if or .Kind .Run .Source }}<p class="muted" style="margin-bottom: 1rem;">Filtered{{ with .Kind }} to {{ . }}{{ end }}{{ with .Run }} for run <span class="mono">{{ . }}</span>{{ end }}{{ with .Source }} for source <span class="mono">{{ . }}</span>{{ end }} - <a href="/activity">show all</a></p>{{ end }}
+            <div style="overflow-x: auto;">
+            <table>
+                <thead>
+                    <tr>
+                        <th>Time</th>
+                        <th>Event</th>
+                        <th>Source</th>
+                        <th>Runs</th>
+                        <th>Models</th>
+                        <th>Results</th>
+                        <th title="Of completed runs, over their results without an error">Avg Score</th>
+                    </tr>
+                </thead>
+                <tbody>
+                    {{ range .Events }}
+                    {{ $e := . }}
+                    <tr>
+                        <td class="mono muted">{{ .Time.Format "2006-01-02 15:04:05" }}</td>
+                        <td class="activity-kind">{{ if eq .Kind "file_added" }}Source added{{ else if eq .Kind "records_appended" }}Results appended{{ else }}Run completed{{ end }}</td>
+                        <td>{{ with .Source }}<a class="mono" href="/activity?source={{ . }}">{{ . }}</a>{{ else }}-{{ end }}</td>
+                        <td>{{ range $i, $run := .Runs }}{{ if $i }}, {{ end }}<a class="mono" href="{{ $e.RunURL $run }}">{{ $run }}</a>{{ end }}</td>
+                        <td>{{ range $i, $m := .Models }}{{ if $i }}, {{ end }}{{ $m }}{{ end }}</td>
+                        <td class="num">{{ human .Results }}</td>
+                        <td>{{ if eq .Kind "run_completed" }}<span class="score-badge {{ scoreClass .AvgScore }}">{{ printf "%.3f" .AvgScore }}</span>{{ end }}</td>
+                    </tr>
+                    {{ end }}
+                </tbody>
+            </table>
+            </div>
+            {{ if gt .Total (len .Events) }}<p class="muted" style="margin-top: 1rem; font-size: 0.8125rem;">Showing the latest {{ len .Events }} of {{ human .Total }} events, <a href="/api/activity">/api/activity</a> has all of them.</p>{{ end }}
+            <p class="muted" style="margin-top: 1rem; font-size: 0.8125rem;">A run counts as completed once it went {{ .Idle }} without new results.</p>
+            {{ else }}
+            <p class="muted">No activity recorded yet.</p>
+            {{ end }}
+        </div>
+{{ end }}`
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestActivityTracker(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "activity.jsonl")
+	al, err := newActivityLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := func(origin, run string, score float64) EvalResult {
+		return EvalResult{Model: "a", Origin: origin, Metadata: map[string]any{"run_id": run}, Scores: ScoreBreakdown{Combined: score}}
+	}
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	tracker := newActivityTracker(al)
+
+	// Startup replays what's stored: a new source, runs that don't complete
+	events := tracker.Observe([]EvalResult{result("a.jsonl", "r1", 0.5), result("a.jsonl", "r1", 0.7)}, start, true)
+	if len(events) != 1 || events[0].Kind != activityFileAdded || events[0].Results != 2 || events[0].Runs[0] != "r1" {
+		t.Fatalf("startup = %+v", events)
+	}
+	al.Record(events)
+	if done := tracker.Complete(start.Add(time.Hour)); len(done) != 0 {
+		t.Errorf("replayed run completed: %+v", done)
+	}
+
+	events = tracker.Observe([]EvalResult{result("a.jsonl", "r2", 0.8), {Model: "b", Origin: "b.jsonl", Scores: ScoreBreakdown{Combined: 1}}}, start.Add(time.Minute), false)
+	if len(events) != 2 || events[0].Kind != activityRecordsAppended || events[0].Runs[0] != "r2" || events[1].Kind != activityFileAdded || events[1].Runs[0] != "b.jsonl" {
+		t.Fatalf("new results = %+v", events)
+	}
+	al.Record(events)
+	if done := tracker.Complete(start.Add(3 * time.Minute)); len(done) != 0 {
+		t.Errorf("completed too early: %+v", done)
+	}
+	done := tracker.Complete(start.Add(10 * time.Minute))
+	if len(done) != 2 || done[1].Runs[0] != "r2" || done[1].Results != 1 || done[1].AvgScore != 0.8 || !done[1].Time.Equal(start.Add(time.Minute)) {
+		t.Fatalf("completed = %+v", done)
+	}
+	al.Record(done)
+	if again := tracker.Complete(start.Add(time.Hour)); len(again) != 0 {
+		t.Errorf("completed twice: %+v", again)
+	}
+
+	// After a restart, results appended while stopped are caught up on
+	reloaded, err := newActivityLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, ok := reloaded.sourceResults("a.jsonl"); !ok || n != 3 {
+		t.Errorf("a.jsonl = %d, %v", n, ok)
+	}
+	tracker = newActivityTracker(reloaded)
+	events = tracker.Observe([]EvalResult{result("a.jsonl", "r1", 0.5), result("a.jsonl", "r1", 0.7), result("a.jsonl", "r2", 0.8), result("a.jsonl", "r3", 0.9), {Model: "b", Origin: "b.jsonl"}}, start.Add(2*time.Hour), true)
+	if len(events) != 1 || events[0].Results != 1 || events[0].Runs[0] != "r3" {
+		t.Errorf("catch up = %+v", events)
+	}
+	if list := reloaded.List(activityRunCompleted, "", "r2"); len(list) != 1 {
+		t.Errorf("r2 completions = %+v", list)
+	}
+}
+
+func TestActivityHandlers(t *testing.T) {
+	al, _ := newActivityLog("")
+	al.Record([]ActivityEvent{{Time: time.Now(), Kind: activityRecordsAppended, Source: "evals.jsonl", Runs: []string{"nightly-42"}, Results: 12}})
+	activity = al
+	defer func() { activity = nil }()
+
+	rec := httptest.NewRecorder()
+	activityHandler(rec, httptest.NewRequest(http.MethodGet, "/activity", nil))
+	if body := rec.Body.String(); !strings.Contains(body, "Current numbers last changed") || !strings.Contains(body, `href="/?run_id=nightly-42"`) {
+		t.Errorf("activity page = %s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	activityAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/api/activity?kind=run_completed", nil))
+	if strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Errorf("run_completed = %s", rec.Body)
+	}
+	rec = httptest.NewRecorder()
+	activityAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/api/activity?kind=deleted", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid kind: %d", rec.Code)
+	}
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Alert states a user can record, an alert without one is active
+const (
+	alertAcknowledged = "acknowledged" // Seen, kept in /api/alerts but off the dashboard banner
+	alertDismissed    = "dismissed"    // Hidden everywhere unless asked for with ?all=1
+)
+
+// alertDrop is the drop in a config's average score between consecutive runs that raises an alert,
+// see --alert-drop; 0 disables alerts
+var alertDrop = 0.1
+
+// maxBannerAlerts bounds the alerts listed in the dashboard banner, the rest are counted
+const maxBannerAlerts = 5
+
+// ScoreAlert is a config whose average score dropped by more than alertDrop from one run to the next
+type ScoreAlert struct {
+	ID          string    `json:"id"`     // Stable over reloads, see alertID
+	Config      string    `json:"config"` // Full config key, see buildConfigKey
+	Run         string    `json:"run"`    // Run ID, or the source for results without one
+	PreviousRun string    `json:"previous_run"`
+	Start       string    `json:"start"` // First timestamp of Run
+	Average     float64   `json:"average"`
+	Previous    float64   `json:"previous"`
+	Drop        float64   `json:"drop"` // Previous - Average
+	State       string    `json:"state,omitempty"`
+	Updated     time.Time `json:"updated,omitzero"` // When State was set
+
+	URL string `json:"-"` // Tests of the config in Run
+}
+
+// alertID identifies an alert by its config and the two runs it compares
+func alertID(config, run, previous string) string {
+	sum := sha256.Sum256([]byte(config + "\x00" + run + "\x00" + previous))
+	return hex.EncodeToString(sum[:8])
+}
+
+// BuildScoreAlerts compares each config's average score over consecutive runs, ordered by their
+// first timestamp, and returns the drops larger than delta, newest first
+// Errored results don't count towards averages and runs without a timestamp can't be ordered, so are skipped
+func BuildScoreAlerts(results []EvalResult, delta float64) []ScoreAlert {
+	if delta <= 0 {
+		return nil
+	}
+	type runAcc struct {
+		runID, source string
+		start         time.Time
+		startRaw      string
+		sum           float64
+		count         int
+	}
+	byConfig := make(map[string]map[string]*runAcc)
+	for _, result := range scored(results) {
+		ts, ok := parseTimestamp(result.Timestamp)
+		if !ok {
+			continue
+		}
+		config := buildConfigKey(result)
+		runID, source := runOf(result)
+		runs := byConfig[config]
+		if runs == nil {
+			runs = make(map[string]*runAcc)
+			byConfig[config] = runs
+		}
+		key := runID + "\x00" + source
+		a := runs[key]
+		if a == nil {
+			a = &runAcc{runID: runID, source: source}
+			runs[key] = a
+		}
+		if a.start.IsZero() || ts.Before(a.start) {
+			a.start, a.startRaw = ts, result.Timestamp
+		}
+		a.sum += result.Scores.Combined
+		a.count++
+	}
+
+	var alerts []ScoreAlert
+	for config, runs := range byConfig {
+		ordered := make([]*runAcc, 0, len(runs))
+		for _, a := range runs {
+			ordered = append(ordered, a)
+		}
+		sort.Slice(ordered, func(i, j int) bool {
+			if !ordered[i].start.Equal(ordered[j].start) {
+				return ordered[i].start.Before(ordered[j].start)
+			}
+			return ordered[i].runID+ordered[i].source < ordered[j].runID+ordered[j].source
+		})
+		for i := 1; i < len(ordered); i++ {
+			prev, cur := ordered[i-1], ordered[i]
+			previous, average := prev.sum/float64(prev.count), cur.sum/float64(cur.count)
+			if previous-average <= delta {
+				continue
+			}
+			run, previousRun := runName(cur.runID, cur.source), runName(prev.runID, prev.source)
+			params := url.Values{"model": {config}}
+			if cur.runID != "" {
+				params.Set("run_id", cur.runID)
+			} else if cur.source != "" {
+				params.Set("source", cur.source)
+			}
+			alerts = append(alerts, ScoreAlert{
+				ID:          alertID(config, run, previousRun),
+				Config:      config,
+				Run:         run,
+				PreviousRun: previousRun,
+				Start:       cur.startRaw,
+				Average:     average,
+				Previous:    previous,
+				Drop:        previous - average,
+				URL:         "/tests?" + params.Encode(),
+			})
+		}
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		if alerts[i].Start != alerts[j].Start {
+			return alerts[i].Start > alerts[j].Start
+		}
+		return alerts[i].Config < alerts[j].Config
+	})
+	return alerts
+}
+
+// AlertState is the user's decision on one alert
+type AlertState struct {
+	ID      string    `json:"id"`
+	State   string    `json:"state"` // alertAcknowledged or alertDismissed
+	Updated time.Time `json:"updated"`
+}
+
+// alertStore keeps alert states in a JSON file, like labelStore
+type alertStore struct {
+	path string
+
+	mu     sync.Mutex
+	states map[string]AlertState
+}
+
+// alertStates is the alert state registry, see --alerts-file; nil = every alert is active
+var alertStates *alertStore
+
+// newAlertStore loads alert states from path, a missing file means none yet
+func newAlertStore(path string) (*alertStore, error) {
+	as := &alertStore{path: path, states: make(map[string]AlertState)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return as, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alerts: %w", err)
+	}
+
+	var list []AlertState
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("invalid alerts file %s: %w", path, err)
+	}
+	for _, state := range list {
+		as.states[state.ID] = state
+	}
+	return as, nil
+}
+
+// Apply fills in the recorded state of each alert
+func (as *alertStore) Apply(alerts []ScoreAlert) {
+	if as == nil {
+		return
+	}
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	for i := range alerts {
+		if state, ok := as.states[alerts[i].ID]; ok {
+			alerts[i].State, alerts[i].Updated = state.State, state.Updated
+		}
+	}
+}
+
+// Set records a state and writes the file
+func (as *alertStore) Set(state AlertState) error {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	as.states[state.ID] = state
+	return as.write()
+}
+
+// Delete makes an alert active again, reports whether it had a state
+func (as *alertStore) Delete(id string) (bool, error) {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	if _, ok := as.states[id]; !ok {
+		return false, nil
+	}
+	delete(as.states, id)
+	return true, as.write()
+}
+
+// write replaces the file atomically, must be called with as.mu held
+func (as *alertStore) write() error {
+	list := make([]AlertState, 0, len(as.states))
+	for _, state := range as.states {
+		list = append(list, state)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := as.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write alerts: %w", err)
+	}
+	return replaceFile(tmp, as.path)
+}
+
+// AlertBanner is what the dashboard shows of the active alerts
+type AlertBanner struct {
+	Alerts []ScoreAlert // Up to maxBannerAlerts, newest first
+	More   int          // Active alerts not listed
+}
+
+// activeAlerts builds the dashboard banner: the alerts over results nobody acknowledged or dismissed
+func activeAlerts(states *alertStore, results []EvalResult) AlertBanner {
+	alerts := BuildScoreAlerts(results, alertDrop)
+	states.Apply(alerts)
+	alerts = slices.DeleteFunc(alerts, func(a ScoreAlert) bool { return a.State != "" })
+	if len(alerts) > maxBannerAlerts {
+		return AlertBanner{Alerts: alerts[:maxBannerAlerts], More: len(alerts) - maxBannerAlerts}
+	}
+	return AlertBanner{Alerts: alerts}
+}
+
+// alertsAPIHandler lists score drop alerts over the dashboard filters (GET, dismissed ones only
+// with ?all=1), records a decision (POST {"id","state"}) and makes an alert active again (DELETE ?id=)
+func alertsAPIHandler(w http.ResponseWriter, r *http.Request) {
+	alertStates := alertStatesFor(r.Context())
+	switch r.Method {
+	case http.MethodGet:
+		results, err := store.Query(r.Context(), dashboardFilter(r))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+			return
+		}
+		results, ok := reweightRequest(w, r, results)
+		if !ok {
+			return
+		}
+		alerts := BuildScoreAlerts(results, alertDrop)
+		alertStates.Apply(alerts)
+		if r.URL.Query().Get("all") == "" {
+			alerts = slices.DeleteFunc(alerts, func(a ScoreAlert) bool { return a.State == alertDismissed })
+		}
+		if alerts == nil {
+			alerts = []ScoreAlert{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(alerts); err != nil {
+			log.Printf("Error encoding JSON: %v", err)
+		}
+
+	case http.MethodPost:
+		var state AlertState
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&state); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+			return
+		}
+		if state.ID == "" || (state.State != alertAcknowledged && state.State != alertDismissed) {
+			http.Error(w, fmt.Sprintf("id is required and state must be %s or %s", alertAcknowledged, alertDismissed), http.StatusBadRequest)
+			return
+		}
+		state.Updated = time.Now().UTC()
+		if err := alertStates.Set(state); err != nil {
+			log.Printf("Error saving alert state: %v", err)
+			http.Error(w, "Failed to save alert state", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(state)
+
+	case http.MethodDelete:
+		found, err := alertStates.Delete(r.URL.Query().Get("id"))
+		if err != nil {
+			log.Printf("Error deleting alert state: %v", err)
+			http.Error(w, "Failed to delete alert state", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
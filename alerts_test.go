@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildScoreAlerts(t *testing.T) {
+	run := func(id string) map[string]any { return map[string]any{"run_id": id} }
+	results := []EvalResult{
+		{Timestamp: "2025-01-01T10:00:00Z", Model: "a", Scores: ScoreBreakdown{Combined: 0.9}, Metadata: run("r1")},
+		{Timestamp: "2025-01-01T10:00:00Z", Model: "a", Scores: ScoreBreakdown{Combined: 0.7}, Metadata: run("r1")},
+		{Timestamp: "2025-01-02T10:00:00Z", Model: "a", Scores: ScoreBreakdown{Combined: 0.5}, Metadata: run("r2")},
+		{Timestamp: "2025-01-02T10:00:00Z", Model: "a", Error: "timeout", Metadata: run("r2")},
+		{Timestamp: "2025-01-03T10:00:00Z", Model: "a", Scores: ScoreBreakdown{Combined: 0.55}, Metadata: run("r3")},
+		// b only dips within the delta
+		{Timestamp: "2025-01-01T10:00:00Z", Model: "b", Scores: ScoreBreakdown{Combined: 0.8}, Metadata: run("r1")},
+		{Timestamp: "2025-01-02T10:00:00Z", Model: "b", Scores: ScoreBreakdown{Combined: 0.75}, Metadata: run("r2")},
+		// Untimed results can't be ordered
+		{Model: "c", Scores: ScoreBreakdown{Combined: 0.1}, Metadata: run("r9")},
+	}
+
+	alerts := BuildScoreAlerts(results, 0.1)
+	if len(alerts) != 1 {
+		t.Fatalf("alerts = %+v", alerts)
+	}
+	a := alerts[0]
+	if a.Config != "a" || a.Run != "r2" || a.PreviousRun != "r1" || a.Average != 0.5 || math.Abs(a.Drop-0.3) > 1e-9 {
+		t.Errorf("alert = %+v", a)
+	}
+	if a.ID != alertID("a", "r2", "r1") || a.URL != "/tests?model=a&run_id=r2" {
+		t.Errorf("id = %s, url = %s", a.ID, a.URL)
+	}
+	if got := BuildScoreAlerts(results, 0.01); len(got) != 2 || got[1].Config != "b" {
+		t.Errorf("alerts at 0.01 = %+v", got)
+	}
+	if got := BuildScoreAlerts(results, 0); got != nil {
+		t.Errorf("alerts with no delta = %+v", got)
+	}
+}
+
+func TestAlertsAPI(t *testing.T) {
+	dir := t.TempDir()
+	withStore(t, `{"timestamp":"2025-01-01T10:00:00Z","model":"a","scores":{"combined":0.9},"metadata":{"run_id":"r1"}}
+{"timestamp":"2025-01-02T10:00:00Z","model":"a","scores":{"combined":0.4},"metadata":{"run_id":"r2"}}
+{"timestamp":"2025-01-01T10:00:00Z","model":"b","scores":{"combined":0.9},"metadata":{"run_id":"r1"}}
+{"timestamp":"2025-01-02T10:00:00Z","model":"b","scores":{"combined":0.2},"metadata":{"run_id":"r2"}}`)
+	as, err := newAlertStore(filepath.Join(dir, "alerts.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	alertStates = as
+	defer func() { alertStates = nil }()
+
+	list := func(target string) []ScoreAlert {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		alertsAPIHandler(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		var alerts []ScoreAlert
+		if err := json.Unmarshal(rec.Body.Bytes(), &alerts); err != nil {
+			t.Fatalf("%s = %s (%v)", target, rec.Body, err)
+		}
+		return alerts
+	}
+	alerts := list("/api/alerts")
+	if len(alerts) != 2 || alerts[0].State != "" {
+		t.Fatalf("alerts = %+v", alerts)
+	}
+
+	post := func(body string) int {
+		rec := httptest.NewRecorder()
+		alertsAPIHandler(rec, httptest.NewRequest(http.MethodPost, "/api/alerts", strings.NewReader(body)))
+		return rec.Code
+	}
+	if code := post(`{"id":"` + alerts[0].ID + `","state":"dismissed"}`); code != http.StatusOK {
+		t.Fatalf("dismiss = %d", code)
+	}
+	if code := post(`{"id":"` + alerts[1].ID + `","state":"acknowledged"}`); code != http.StatusOK {
+		t.Fatalf("acknowledge = %d", code)
+	}
+	if code := post(`{"id":"x","state":"snoozed"}`); code != http.StatusBadRequest {
+		t.Errorf("invalid state = %d", code)
+	}
+	if got := list("/api/alerts"); len(got) != 1 || got[0].State != alertAcknowledged {
+		t.Errorf("after dismiss = %+v", got)
+	}
+	if got := list("/api/alerts?all=1"); len(got) != 2 {
+		t.Errorf("all = %+v", got)
+	}
+
+	// States survive a restart, and neither alert is on the dashboard banner
+	reloaded, err := newAlertStore(filepath.Join(dir, "alerts.json"))
+	if err != nil || len(reloaded.states) != 2 {
+		t.Fatalf("reloaded = %+v (%v)", reloaded, err)
+	}
+	results, _ := store.Query(t.Context(), Query{})
+	if banner := activeAlerts(alertStates, results); len(banner.Alerts) != 0 {
+		t.Errorf("banner = %+v", banner)
+	}
+
+	rec := httptest.NewRecorder()
+	alertsAPIHandler(rec, httptest.NewRequest(http.MethodDelete, "/api/alerts?id="+alerts[0].ID, nil))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("delete = %d", rec.Code)
+	}
+	if banner := activeAlerts(alertStates, results); len(banner.Alerts) != 1 || banner.Alerts[0].ID != alerts[0].ID {
+		t.Errorf("banner after delete = %+v", banner)
+	}
+}
@@ -0,0 +1,501 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Review decisions a reviewer can record for a result
+var reviewDecisions = []string{"accept", "reject", "unsure"}
+
+// Annotation is one human review of a result: a decision, free-form labels and/or a note
+// A result can have any number of annotations (several reviewers, changed minds)
+type Annotation struct {
+	ID       string    `json:"id"`
+	ResultID string    `json:"result_id"` // See resultID
+	Decision string    `json:"decision,omitempty"`
+	Labels   []string  `json:"labels,omitempty"`
+	Note     string    `json:"note,omitempty"`
+	Reviewer string    `json:"reviewer,omitempty"`
+	Created  time.Time `json:"created"`
+}
+
+// maxNoteLength bounds free-form annotation notes
+const maxNoteLength = 10000
+
+// maxBulkAnnotations bounds the results one bulk annotation applies to
+const maxBulkAnnotations = 5000
+
+// BulkAnnotation applies the same decision, labels and note to several results at once
+type BulkAnnotation struct {
+	ResultIDs []string `json:"result_ids"`
+	Decision  string   `json:"decision,omitempty"`
+	Labels    []string `json:"labels,omitempty"`
+	Note      string   `json:"note,omitempty"`
+	Reviewer  string   `json:"reviewer,omitempty"`
+}
+
+// resultID is a short stable identifier for a result, derived from resultKey
+// Unlike resultKey it is safe to put in URLs and files
+func resultID(result EvalResult) string {
+	sum := sha256.Sum256([]byte(resultKey(result)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// annotationStore keeps annotations in an append-only JSONL file
+type annotationStore struct {
+	path string
+
+	mu       sync.Mutex
+	list     []Annotation
+	byResult map[string][]int // result ID -> indexes into list
+}
+
+// annotations is the annotation store, see --annotations-file
+var annotations *annotationStore
+
+// newAnnotationStore loads annotations from path, a missing file means none yet
+func newAnnotationStore(path string) (*annotationStore, error) {
+	as := &annotationStore{path: path, byResult: make(map[string][]int)}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return as, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read annotations: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var a Annotation
+		if err := json.Unmarshal([]byte(line), &a); err != nil {
+			return nil, fmt.Errorf("invalid annotations file %s line %d: %w", path, lineNum, err)
+		}
+		as.add(a)
+	}
+	return as, scanner.Err()
+}
+
+// add indexes an annotation, must be called with as.mu held (or before the store is shared)
+func (as *annotationStore) add(a Annotation) {
+	as.byResult[a.ResultID] = append(as.byResult[a.ResultID], len(as.list))
+	as.list = append(as.list, a)
+}
+
+// Add validates an annotation, fills in its ID and creation time and appends it to the file
+func (as *annotationStore) Add(a Annotation) (Annotation, error) {
+	a, err := prepareAnnotation(a)
+	if err != nil {
+		return a, err
+	}
+	return a, as.write([]Annotation{a})
+}
+
+// AddBulk records one annotation per result of b, all of them or none when one is invalid
+func (as *annotationStore) AddBulk(b BulkAnnotation) ([]Annotation, error) {
+	switch {
+	case len(b.ResultIDs) == 0:
+		return nil, errors.New("result_ids is required")
+	case len(b.ResultIDs) > maxBulkAnnotations:
+		return nil, fmt.Errorf("at most %d result_ids at once", maxBulkAnnotations)
+	}
+	list := make([]Annotation, 0, len(b.ResultIDs))
+	seen := make(map[string]bool, len(b.ResultIDs))
+	for _, id := range b.ResultIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		a, err := prepareAnnotation(Annotation{ResultID: id, Decision: b.Decision, Labels: b.Labels, Note: b.Note, Reviewer: b.Reviewer})
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	return list, as.write(list)
+}
+
+// prepareAnnotation normalizes and validates an annotation and fills in its ID and creation time
+func prepareAnnotation(a Annotation) (Annotation, error) {
+	a.Decision = strings.ToLower(strings.TrimSpace(a.Decision))
+	a.Reviewer = strings.TrimSpace(a.Reviewer)
+	var labels []string
+	for _, label := range a.Labels {
+		if label = strings.TrimSpace(label); label != "" {
+			labels = append(labels, label)
+		}
+	}
+	a.Labels = labels
+	switch {
+	case a.ResultID == "":
+		return a, errors.New("result_id is required")
+	case a.Decision != "" && !slices.Contains(reviewDecisions, a.Decision):
+		return a, fmt.Errorf("invalid decision %q: use accept, reject or unsure", a.Decision)
+	case a.Decision == "" && len(a.Labels) == 0 && strings.TrimSpace(a.Note) == "":
+		return a, errors.New("an annotation needs a decision, labels or a note")
+	case len(a.Note) > maxNoteLength:
+		return a, fmt.Errorf("note is longer than %d bytes", maxNoteLength)
+	}
+	var id [8]byte
+	rand.Read(id[:])
+	a.ID = hex.EncodeToString(id[:])
+	a.Created = time.Now().UTC()
+	return a, nil
+}
+
+// write appends annotations to the file in one write and indexes them
+func (as *annotationStore) write(list []Annotation) error {
+	var lines []byte
+	for _, a := range list {
+		line, err := json.Marshal(a)
+		if err != nil {
+			return err
+		}
+		lines = append(append(lines, line...), '\n')
+	}
+
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	unlock, err := lockFile(as.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	f, err := os.OpenFile(as.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write annotations: %w", err)
+	}
+	if _, err := f.Write(lines); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write annotations: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write annotations: %w", err)
+	}
+	for _, a := range list {
+		as.add(a)
+	}
+	return nil
+}
+
+// ForResult returns the annotations of one result, oldest first
+func (as *annotationStore) ForResult(id string) []Annotation {
+	if as == nil {
+		return nil
+	}
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	var list []Annotation
+	for _, i := range as.byResult[id] {
+		list = append(list, as.list[i])
+	}
+	return list
+}
+
+// List returns all annotations, oldest first
+func (as *annotationStore) List() []Annotation {
+	if as == nil {
+		return nil
+	}
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	return append([]Annotation(nil), as.list...)
+}
+
+// Labels returns every label used in an annotation, sorted
+func (as *annotationStore) Labels() []string {
+	var labels []string
+	for _, a := range as.List() {
+		labels = append(labels, a.Labels...)
+	}
+	slices.Sort(labels)
+	return slices.Compact(labels)
+}
+
+// Annotated returns the IDs of the results with an annotation matching keep
+func (as *annotationStore) Annotated(keep func(Annotation) bool) map[string]bool {
+	ids := make(map[string]bool)
+	for _, a := range as.List() {
+		if keep(a) {
+			ids[a.ResultID] = true
+		}
+	}
+	return ids
+}
+
+// ReviewedResult is an annotation joined with the result it refers to
+// Result is nil when the result is no longer loaded (archived, deleted, source down)
+type ReviewedResult struct {
+	Annotation
+	Result *EvalResult `json:"result"`
+}
+
+// joinAnnotations pairs every annotation matching keep with its result
+func joinAnnotations(list []Annotation, results []EvalResult, keep func(Annotation) bool) []ReviewedResult {
+	byID := make(map[string]int, len(results))
+	for i, result := range results {
+		byID[resultID(result)] = i
+	}
+	joined := []ReviewedResult{}
+	for _, a := range list {
+		if keep != nil && !keep(a) {
+			continue
+		}
+		reviewed := ReviewedResult{Annotation: a}
+		if i, ok := byID[a.ResultID]; ok {
+			reviewed.Result = &results[i]
+		}
+		joined = append(joined, reviewed)
+	}
+	return joined
+}
+
+// annotationFilter keeps annotations by decision, reviewer and label ("" = any)
+func annotationFilter(decision, reviewer, label string) func(Annotation) bool {
+	return func(a Annotation) bool {
+		if decision != "" && a.Decision != decision {
+			return false
+		}
+		if reviewer != "" && a.Reviewer != reviewer {
+			return false
+		}
+		if label != "" && !slices.Contains(a.Labels, label) {
+			return false
+		}
+		return true
+	}
+}
+
+// exportColumns is the CSV header of writeReviewedCSV
+var exportColumns = []string{
+	"annotation_id", "result_id", "decision", "labels", "note", "reviewer", "created",
+	"timestamp", "model", "config", "test_id", "run_id", "combined", "question", "response", "expected",
+}
+
+// writeReviewedCSV writes one row per annotation, result columns are empty for missing results
+func writeReviewedCSV(w io.Writer, joined []ReviewedResult) error {
+	cw := csv.NewWriter(w)
+	cw.Write(exportColumns)
+	for _, r := range joined {
+		row := []string{
+			r.ID, r.ResultID, r.Decision, strings.Join(r.Labels, ";"), r.Note, r.Reviewer, r.Created.Format(time.RFC3339),
+		}
+		if res := r.Result; res != nil {
+			runID, _ := res.Metadata["run_id"].(string)
+			row = append(row, res.Timestamp, res.Model, buildConfigKey(*res), res.TestID, runID,
+				strconv.FormatFloat(res.Scores.Combined, 'f', -1, 64), res.Question, res.Response, res.Expected)
+		} else {
+			row = append(row, make([]string, len(exportColumns)-len(row))...)
+		}
+		cw.Write(row)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeReviewedJSONL writes one joined annotation per line
+func writeReviewedJSONL(w io.Writer, joined []ReviewedResult) error {
+	enc := json.NewEncoder(w)
+	for _, r := range joined {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// annotationsAPIHandler lists (GET ?result_id=) and records (POST) annotations
+func annotationsAPIHandler(w http.ResponseWriter, r *http.Request) {
+	annotations := annotationsFor(r.Context())
+	switch r.Method {
+	case http.MethodGet:
+		list := annotations.List()
+		if id := r.URL.Query().Get("result_id"); id != "" {
+			list = annotations.ForResult(id)
+		}
+		if list == nil {
+			list = []Annotation{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+
+	case http.MethodPost:
+		var a Annotation
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&a); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+			return
+		}
+		a, err := annotations.Add(a)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(a)
+
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// annotationsBulkHandler records the same annotation for several results (POST), e.g. to label
+// every selected test on the tests page at once
+func annotationsBulkHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var b BulkAnnotation
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&b); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+	list, err := annotationsFor(r.Context()).AddBulk(b)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(list)
+}
+
+// annotationsExportHandler returns every annotation joined with its result
+// ?format=json (default), jsonl or csv, filtered by decision, reviewer and label
+func annotationsExportHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	format := params.Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "jsonl" && format != "csv" {
+		http.Error(w, "format must be json, jsonl or csv", http.StatusBadRequest)
+		return
+	}
+
+	results, err := store.Query(r.Context(), Query{})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	joined := joinAnnotations(annotationsFor(r.Context()).List(), results,
+		annotationFilter(params.Get("decision"), params.Get("reviewer"), params.Get("label")))
+
+	switch format {
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="goevals-annotations.csv"`)
+		err = writeReviewedCSV(w, joined)
+	case "jsonl":
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		err = writeReviewedJSONL(w, joined)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(joined)
+	}
+	if err != nil {
+		log.Printf("Error encoding annotations: %v", err)
+	}
+}
+
+// runExportAnnotations implements "goevals export-annotations", the offline version of
+// /api/annotations/export for scripts and notebooks
+func runExportAnnotations(args []string) int {
+	fs := newFlagSet("export-annotations", "[flags] <source1> [source2] [...]",
+		"Writes every annotation joined with the result it refers to")
+	file := fs.String("annotations-file", "goevals-annotations.jsonl", "JSONL `file` where annotations are stored")
+	format := fs.String("format", "jsonl", "output `format`: jsonl or csv")
+	output := fs.String("o", "", "write to `file` instead of stdout")
+	decision := fs.String("decision", "", "only annotations with this `decision` (accept, reject, unsure)")
+	reviewer := fs.String("reviewer", "", "only annotations by this `reviewer`")
+	label := fs.String("label", "", "only annotations with this `label`")
+	sources := parseFlags(fs, args)
+	if *format != "jsonl" && *format != "csv" {
+		fmt.Fprintln(os.Stderr, "Error: --format must be jsonl or csv")
+		return 2
+	}
+	results, err := commandResults(sources, "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	as, err := newAnnotationStore(*file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	w := io.Writer(os.Stdout)
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		defer f.Close()
+		w = f
+	}
+
+	joined := joinAnnotations(as.List(), results, annotationFilter(*decision, *reviewer, *label))
+	if *format == "csv" {
+		err = writeReviewedCSV(w, joined)
+	} else {
+		err = writeReviewedJSONL(w, joined)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	missing := 0
+	for _, r := range joined {
+		if r.Result == nil {
+			missing++
+		}
+	}
+	if missing > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d of %d annotations refer to results not found in the given sources\n", missing, len(joined))
+	}
+	return 0
+}
+
+// reviewSummary counts decisions for display, e.g. "2 accept, 1 reject"
+func reviewSummary(list []Annotation) string {
+	counts := make(map[string]int)
+	for _, a := range list {
+		if a.Decision != "" {
+			counts[a.Decision]++
+		}
+	}
+	var parts []string
+	for _, decision := range reviewDecisions {
+		if counts[decision] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[decision], decision))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAnnotationStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "annotations.jsonl")
+	as, err := newAnnotationStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := as.Add(Annotation{ResultID: "r1", Decision: " Reject ", Labels: []string{"hallucination", " "}, Reviewer: "ana"})
+	if err != nil || a.ID == "" || a.Decision != "reject" || len(a.Labels) != 1 {
+		t.Fatalf("Add = %+v, %v", a, err)
+	}
+	as.Add(Annotation{ResultID: "r1", Note: "second look: fine"})
+	for _, bad := range []Annotation{{Decision: "accept"}, {ResultID: "r1", Decision: "maybe"}, {ResultID: "r1"}} {
+		if _, err := as.Add(bad); err == nil {
+			t.Errorf("Add(%+v): expected error", bad)
+		}
+	}
+
+	// Reloading from the file keeps everything
+	reloaded, err := newAnnotationStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list := reloaded.ForResult("r1"); len(list) != 2 || list[0].ID != a.ID || list[1].Note != "second look: fine" {
+		t.Errorf("reloaded = %+v", list)
+	}
+	if got := reviewSummary(reloaded.ForResult("r1")); got != "1 reject" {
+		t.Errorf("reviewSummary = %q", got)
+	}
+}
+
+func TestJoinAnnotations(t *testing.T) {
+	results := []EvalResult{
+		{Timestamp: "2025-01-01T10:00:00Z", Model: "a", TestID: "t1", Question: "Q1", Scores: ScoreBreakdown{Combined: 0.4}},
+		{Timestamp: "2025-01-01T11:00:00Z", Model: "b", TestID: "t1"},
+	}
+	list := []Annotation{
+		{ID: "1", ResultID: resultID(results[0]), Decision: "reject", Labels: []string{"wrong", "rude"}},
+		{ID: "2", ResultID: "gone", Decision: "accept"},
+	}
+
+	joined := joinAnnotations(list, results, nil)
+	if len(joined) != 2 || joined[0].Result == nil || joined[0].Result.Model != "a" || joined[1].Result != nil {
+		t.Fatalf("joined = %+v", joined)
+	}
+	if joined := joinAnnotations(list, results, annotationFilter("", "", "rude")); len(joined) != 1 || joined[0].ID != "1" {
+		t.Errorf("label filter = %+v", joined)
+	}
+
+	var buf bytes.Buffer
+	if err := writeReviewedCSV(&buf, joined); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil || len(rows) != 3 {
+		t.Fatalf("csv = %v, %v", rows, err)
+	}
+	if rows[1][3] != "wrong;rude" || rows[1][8] != "a" || rows[1][13] != "Q1" || rows[2][8] != "" {
+		t.Errorf("csv rows = %q", rows[1:])
+	}
+}
+
+func TestAnnotationsAPI(t *testing.T) {
+	useStore(t, NewMemoryStore([]Source{&fileSource{path: "evals.jsonl"}}))
+	annotations, _ = newAnnotationStore(filepath.Join(t.TempDir(), "annotations.jsonl"))
+	defer func() { annotations = nil }()
+
+	results, _ := store.Query(t.Context(), Query{})
+	id := resultID(results[0])
+	rec := httptest.NewRecorder()
+	annotationsAPIHandler(rec, httptest.NewRequest(http.MethodPost, "/api/annotations",
+		strings.NewReader(`{"result_id":"`+id+`","decision":"accept","reviewer":"ana"}`)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST: %d %s", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	annotationsExportHandler(rec, httptest.NewRequest(http.MethodGet, "/api/annotations/export?reviewer=ana", nil))
+	var joined []ReviewedResult
+	if err := json.NewDecoder(rec.Body).Decode(&joined); err != nil {
+		t.Fatal(err)
+	}
+	if len(joined) != 1 || joined[0].Result == nil || joined[0].Result.TestID != results[0].TestID {
+		t.Errorf("export = %+v", joined)
+	}
+
+	rec = httptest.NewRecorder()
+	testsHandler(rec, httptest.NewRequest(http.MethodGet, "/tests", nil))
+	if !strings.Contains(rec.Body.String(), "Review (1 accept)") {
+		t.Error("tests page does not show the review")
+	}
+}
+
+func TestBulkAnnotations(t *testing.T) {
+	useStore(t, NewMemoryStore([]Source{&fileSource{path: "evals.jsonl"}}))
+	path := filepath.Join(t.TempDir(), "annotations.jsonl")
+	annotations, _ = newAnnotationStore(path)
+	defer func() { annotations = nil }()
+
+	results, _ := store.Query(t.Context(), Query{})
+	ids := []string{resultID(results[0]), resultID(results[1]), resultID(results[0])}
+	body, _ := json.Marshal(BulkAnnotation{ResultIDs: ids, Decision: "reject", Labels: []string{"hallucination"}, Reviewer: "ana"})
+	rec := httptest.NewRecorder()
+	annotationsBulkHandler(rec, httptest.NewRequest(http.MethodPost, "/api/annotations/bulk", bytes.NewReader(body)))
+	var list []Annotation
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil || rec.Code != http.StatusCreated {
+		t.Fatalf("POST: %d, %v", rec.Code, err)
+	}
+	if len(list) != 2 || list[1].ResultID != ids[1] || list[1].Labels[0] != "hallucination" {
+		t.Errorf("bulk = %+v", list)
+	}
+
+	// Nothing is written when one of them is invalid
+	for _, bad := range []string{`{"result_ids":[]}`, `{"result_ids":["a"],"decision":"maybe"}`, `{"result_ids":["a",""],"decision":"accept"}`} {
+		rec = httptest.NewRecorder()
+		annotationsBulkHandler(rec, httptest.NewRequest(http.MethodPost, "/api/annotations/bulk", strings.NewReader(bad)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: %d", bad, rec.Code)
+		}
+	}
+	reloaded, _ := newAnnotationStore(path)
+	if got := len(reloaded.List()); got != 2 {
+		t.Errorf("%d annotations in the file, want 2", got)
+	}
+	if labels := reloaded.Labels(); len(labels) != 1 || labels[0] != "hallucination" {
+		t.Errorf("labels = %v", labels)
+	}
+
+	// The tests page filters by label and decision
+	for target, want := range map[string]int{"/tests?label=hallucination": 2, "/tests?review=reject": 2, "/tests?review=accept": 0} {
+		rec = httptest.NewRecorder()
+		testsHandler(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if got := strings.Count(rec.Body.String(), `class="select-row"`); got != want {
+			t.Errorf("%s: %d rows, want %d", target, got, want)
+		}
+	}
+}
@@ -0,0 +1,463 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Archive moves old results from the hot sources to gzipped JSONL objects in S3/GCS or a local directory
+// Objects are laid out as <prefix><YYYY-MM-DD>/<archived-at>-<random>.jsonl.gz, grouped by result day.
+// Archived results can be loaded back on demand (rehydrated); the Archive is itself a Source,
+// so rehydrated results show up in every view until they are evicted again
+type Archive struct {
+	client   objectStore
+	location string // s3://bucket/prefix, gs://bucket/prefix or the directory, for messages
+	bucket   string // Empty for a directory
+	prefix   string // Always ends with "/" unless empty
+	keep     Retention
+
+	mu         sync.Mutex
+	rehydrated map[string][]EvalResult // Object key -> results
+}
+
+// objectStore is where archive objects are kept: an S3/GCS bucket or a local directory
+type objectStore interface {
+	PutObject(bucket, key string, body []byte, contentType string) error
+	GetObject(bucket, key string) ([]byte, error)
+	ListObjects(bucket, prefix string) ([]s3Object, error)
+}
+
+// NewArchive creates an archive from an s3://bucket/prefix or gs://bucket/prefix URL, or a
+// directory path, that keeps the hot sources within keep
+// Credentials come from AWS_ACCESS_KEY_ID / AWS_SECRET_ACCESS_KEY (HMAC keys for GCS)
+func NewArchive(rawURL string, keep Retention) (*Archive, error) {
+	if keep.MaxAge < 0 || keep.MaxResults < 0 {
+		return nil, fmt.Errorf("retention limits can't be negative, got %s and %d results", keep.MaxAge, keep.MaxResults)
+	}
+	if dir, ok := strings.CutPrefix(rawURL, "file://"); ok || !strings.Contains(rawURL, "://") {
+		if !ok {
+			dir = rawURL
+		}
+		if dir == "" {
+			return nil, errors.New("archive directory is empty")
+		}
+		return &Archive{
+			client:     dirStore{dir: dir},
+			location:   dir,
+			keep:       keep,
+			rehydrated: make(map[string][]EvalResult),
+		}, nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive URL: %w", err)
+	}
+	if (u.Scheme != "s3" && u.Scheme != "gs") || u.Host == "" {
+		return nil, fmt.Errorf("archive URL must look like s3://bucket/prefix or gs://bucket/prefix (or be a directory), got %q", rawURL)
+	}
+
+	client, err := newS3ClientFromEnv(u.Scheme == "gs")
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := strings.TrimPrefix(u.Path, "/")
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return &Archive{
+		client:     client,
+		location:   fmt.Sprintf("%s://%s/%s", u.Scheme, u.Host, prefix),
+		bucket:     u.Host,
+		prefix:     prefix,
+		keep:       keep,
+		rehydrated: make(map[string][]EvalResult),
+	}, nil
+}
+
+func (a *Archive) Name() string {
+	return fmt.Sprintf("archive %s (rehydrated)", a.location)
+}
+
+// Load returns the currently rehydrated results
+func (a *Archive) Load() ([]EvalResult, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	keys := make([]string, 0, len(a.rehydrated))
+	for key := range a.rehydrated {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var results []EvalResult
+	for _, key := range keys {
+		results = append(results, a.rehydrated[key]...)
+	}
+	return results, nil
+}
+
+// Run archives old results every interval until the process exits
+func (a *Archive) Run(sources []Source, interval time.Duration) {
+	log.Printf("Archiving %s to %s", a.keep, a.location)
+	for {
+		n, err := a.ArchiveOnce(sources, time.Now())
+		if err != nil {
+			log.Printf("Warning: Archiving failed: %v", err)
+		}
+		if n > 0 {
+			log.Printf("Archived %d results", n)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// ArchiveOnce uploads the results outside the retention policy from every removable source,
+// then deletes them from that source. Results are only removed after their object
+// was written, so a failed upload leaves them in the hot store for the next run
+func (a *Archive) ArchiveOnce(sources []Source, now time.Time) (int, error) {
+	expired, loaded, err := a.Expired(sources, now)
+	if err != nil {
+		return 0, err
+	}
+	archived := 0
+
+	for i, source := range sources {
+		remover, ok := source.(Remover)
+		if !ok {
+			continue
+		}
+
+		byDay := make(map[string][]EvalResult)
+		for _, result := range loaded[i] {
+			if !expired[resultKey(result)] {
+				continue
+			}
+			ts, _ := parseTimestamp(result.Timestamp) // Results without a readable timestamp never expire
+			day := ts.UTC().Format("2006-01-02")
+			byDay[day] = append(byDay[day], result)
+		}
+		if len(byDay) == 0 {
+			continue
+		}
+
+		days := make([]string, 0, len(byDay))
+		for day := range byDay {
+			days = append(days, day)
+		}
+		sort.Strings(days)
+
+		uploaded := make(map[string]bool)
+		var uploadErr error
+		for _, day := range days {
+			if err := a.upload(day, byDay[day], now); err != nil {
+				uploadErr = err
+				break
+			}
+			for _, result := range byDay[day] {
+				uploaded[resultKey(result)] = true
+			}
+		}
+
+		if len(uploaded) > 0 {
+			n, err := remover.Remove(uploaded)
+			archived += n
+			if err != nil {
+				// The objects exist, so the results will be archived again next run; rehydration dedupes them
+				return archived, fmt.Errorf("archived results but failed to remove them from %s: %w", source.Name(), err)
+			}
+		}
+		if uploadErr != nil {
+			return archived, uploadErr
+		}
+	}
+	return archived, nil
+}
+
+// upload writes one gzipped JSONL object for results of a single day
+func (a *Archive) upload(day string, results []EvalResult, now time.Time) error {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(zw)
+	for _, result := range results {
+		if err := enc.Encode(result); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	body := buf.Bytes()
+	if atRest != nil {
+		// Sealed after compression, ciphertext doesn't compress
+		body = append(append([]byte{}, sealedPrefix...), atRest.Seal(body)...)
+	}
+
+	// Random suffix keeps objects from several instances sharing a bucket apart
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	key := fmt.Sprintf("%s%s/%s-%s.jsonl.gz", a.prefix, day, now.UTC().Format("20060102T150405Z"), hex.EncodeToString(suffix))
+	if err := a.client.PutObject(a.bucket, key, body, "application/gzip"); err != nil {
+		return fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	// Checksum sidecar, verified on rehydration and by `goevals verify --archive-to`
+	sum := sha256.Sum256(body)
+	if err := a.client.PutObject(a.bucket, key+".sha256", []byte(hex.EncodeToString(sum[:])+"\n"), "text/plain"); err != nil {
+		return fmt.Errorf("failed to upload checksum for %s: %w", key, err)
+	}
+	return nil
+}
+
+// errNoChecksum means an archive object has no .sha256 sidecar (archived before checksums were recorded)
+var errNoChecksum = errors.New("no checksum recorded")
+
+// fetchVerified downloads an object and checks it against its checksum sidecar
+// Returns the data together with errNoChecksum when there is no sidecar
+func (a *Archive) fetchVerified(key string) ([]byte, error) {
+	data, err := a.client.GetObject(a.bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	want, err := a.client.GetObject(a.bucket, key+".sha256")
+	if errors.Is(err, errObjectNotFound) {
+		return data, errNoChecksum
+	}
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	if strings.TrimSpace(string(want)) != hex.EncodeToString(sum[:]) {
+		return nil, errors.New("checksum mismatch - object was modified after archiving")
+	}
+	return data, nil
+}
+
+// verifyObject checks one archive object against its checksum
+func (a *Archive) verifyObject(key string) error {
+	_, err := a.fetchVerified(key)
+	return err
+}
+
+// ArchivedObject describes one archive object
+type ArchivedObject struct {
+	Key        string `json:"key"`
+	Day        string `json:"day"`
+	Size       int64  `json:"size"`
+	Rehydrated bool   `json:"rehydrated"`
+}
+
+// Objects lists archive objects whose day lies within [from, to] (YYYY-MM-DD, empty = unbounded)
+func (a *Archive) Objects(from, to string) ([]ArchivedObject, error) {
+	listed, err := a.client.ListObjects(a.bucket, a.prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var objects []ArchivedObject
+	for _, obj := range listed {
+		day, _, ok := strings.Cut(strings.TrimPrefix(obj.Key, a.prefix), "/")
+		if !ok || !strings.HasSuffix(obj.Key, ".jsonl.gz") {
+			continue // Not written by goevals
+		}
+		if (from != "" && day < from) || (to != "" && day > to) {
+			continue
+		}
+		_, loaded := a.rehydrated[obj.Key]
+		objects = append(objects, ArchivedObject{Key: obj.Key, Day: day, Size: obj.Size, Rehydrated: loaded})
+	}
+	return objects, nil
+}
+
+// Rehydrate downloads the objects for [from, to] that aren't loaded yet
+// Returns how many objects and results were added
+func (a *Archive) Rehydrate(from, to string) (int, int, error) {
+	objects, err := a.Objects(from, to)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	loadedObjects, loadedResults := 0, 0
+	for _, obj := range objects {
+		if obj.Rehydrated {
+			continue
+		}
+		data, err := a.fetchVerified(obj.Key)
+		if err == errNoChecksum {
+			log.Printf("Warning: Archive object %s has no checksum, loading unverified", obj.Key)
+		} else if err != nil {
+			return loadedObjects, loadedResults, fmt.Errorf("%s: %w", obj.Key, err)
+		}
+		results, err := decodeArchiveObject(data)
+		if err != nil {
+			return loadedObjects, loadedResults, fmt.Errorf("invalid archive object %s: %w", obj.Key, err)
+		}
+
+		a.mu.Lock()
+		fresh := dedupeAgainst(results, a.rehydrated)
+		a.rehydrated[obj.Key] = fresh
+		a.mu.Unlock()
+		loadedObjects++
+		loadedResults += len(fresh)
+	}
+	return loadedObjects, loadedResults, nil
+}
+
+// Evict drops all rehydrated results from memory, returns how many were dropped
+func (a *Archive) Evict() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	n := 0
+	for _, results := range a.rehydrated {
+		n += len(results)
+	}
+	a.rehydrated = make(map[string][]EvalResult)
+	return n
+}
+
+// decodeArchiveObject decrypts (if sealed), gunzips and parses one JSONL archive object
+func decodeArchiveObject(data []byte) ([]EvalResult, error) {
+	if bytes.HasPrefix(data, sealedPrefix) {
+		if atRest == nil {
+			return nil, errors.New("object is encrypted but no key is configured (set GOEVALS_ENCRYPTION_KEY)")
+		}
+		var err error
+		if data, err = atRest.Open(data[len(sealedPrefix):]); err != nil {
+			return nil, err
+		}
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	var results []EvalResult
+	scanner := bufio.NewScanner(zr)
+	scanner.Buffer(make([]byte, 64*1024), maxIngestBytes)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var result EvalResult
+		if err := json.Unmarshal(line, &result); err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, scanner.Err()
+}
+
+// dedupeAgainst drops results already present in loaded
+// The same result can end up in two objects when removal failed after an upload
+func dedupeAgainst(results []EvalResult, loaded map[string][]EvalResult) []EvalResult {
+	seen := make(map[string]bool)
+	for _, existing := range loaded {
+		for _, result := range existing {
+			seen[resultKey(result)] = true
+		}
+	}
+	var fresh []EvalResult
+	for _, result := range results {
+		key := resultKey(result)
+		if !seen[key] {
+			seen[key] = true
+			fresh = append(fresh, result)
+		}
+	}
+	return fresh
+}
+
+// parseTimestamp accepts RFC3339 timestamps, with or without a zone (treated as UTC)
+func parseTimestamp(ts string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, ts); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// archive is set when --archive-to is given, nil otherwise
+var archive *Archive
+
+// archiveHandler lists archive objects (GET) and rehydrates or evicts them (POST)
+// GET  /api/archive?from=YYYY-MM-DD&to=YYYY-MM-DD
+// POST /api/archive/rehydrate?from=YYYY-MM-DD&to=YYYY-MM-DD
+// POST /api/archive/evict
+func archiveHandler(w http.ResponseWriter, r *http.Request) {
+	if archive == nil {
+		http.Error(w, "Archiving is disabled - start goevals with --archive-to", http.StatusNotFound)
+		return
+	}
+
+	from, to := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+	for _, day := range []string{from, to} {
+		if _, err := time.Parse("2006-01-02", day); day != "" && err != nil {
+			http.Error(w, "from and to must be dates (YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+	}
+
+	action := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/api/archive"), "/")
+	if action != "" && r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var response any
+	switch action {
+	case "":
+		objects, err := archive.Objects(from, to)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error listing archive: %v", err), http.StatusBadGateway)
+			return
+		}
+		rehydrated, _ := archive.Load()
+		response = map[string]any{
+			"location":           archive.location,
+			"bucket":             archive.bucket,
+			"prefix":             archive.prefix,
+			"archive_after_days": archive.keep.MaxAge.Hours() / 24,
+			"max_results":        archive.keep.MaxResults,
+			"objects":            objects,
+			"rehydrated_results": len(rehydrated),
+		}
+	case "rehydrate":
+		objects, results, err := archive.Rehydrate(from, to)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error rehydrating archive: %v", err), http.StatusBadGateway)
+			return
+		}
+		log.Printf("Rehydrated %d archived results from %d objects", results, objects)
+		response = map[string]int{"objects": objects, "results": results}
+	case "evict":
+		response = map[string]int{"evicted": archive.Evict()}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
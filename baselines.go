@@ -0,0 +1,307 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Baseline is a named snapshot of the dashboard's aggregate stats, e.g. the last release, that
+// later results are compared against
+type Baseline struct {
+	Name       string                  `json:"name"`
+	Query      string                  `json:"query,omitempty"` // Dashboard filters and weights it was taken with
+	Created    time.Time               `json:"created"`
+	TotalTests int                     `json:"total_tests"`
+	AvgScore   float64                 `json:"avg_score"`
+	Configs    map[string]BaselineStat `json:"configs"` // Config key -> stats
+}
+
+// BaselineStat is the snapshot of one config's averages
+type BaselineStat struct {
+	Model        string             `json:"model"`
+	TestCount    int                `json:"test_count"`
+	AvgScore     float64            `json:"avg_score"`
+	CustomScores map[string]float64 `json:"custom_scores,omitempty"`
+	AvgTimeMS    float64            `json:"avg_time_ms"`
+}
+
+// newBaseline snapshots the averages of data
+func newBaseline(name, query string, data DashboardData) Baseline {
+	b := Baseline{
+		Name:       name,
+		Query:      query,
+		Created:    time.Now().UTC(),
+		TotalTests: data.TotalTests,
+		AvgScore:   data.AvgScore,
+		Configs:    make(map[string]BaselineStat, len(data.ModelStats)),
+	}
+	for key, stat := range data.ModelStats {
+		b.Configs[key] = BaselineStat{
+			Model:        stat.ActualModelName,
+			TestCount:    stat.TestCount,
+			AvgScore:     stat.AvgScore,
+			CustomScores: stat.CustomScores,
+			AvgTimeMS:    stat.AvgTimeMS,
+		}
+	}
+	return b
+}
+
+// BaselineDelta is the change of an average since the baseline
+type BaselineDelta struct {
+	Change float64
+	Was    float64 // Value in the baseline
+	Time   bool    // A latency in ms, where lower is better
+}
+
+// String is the signed change, scores to 2 decimals and times in whole ms
+func (d BaselineDelta) String() string {
+	if d.Time {
+		return fmt.Sprintf("%+.0f", d.Change)
+	}
+	return fmt.Sprintf("%+.2f", d.Change)
+}
+
+// Class colors the change: delta-better, delta-worse, or delta-flat when it rounds to nothing
+func (d BaselineDelta) Class() string {
+	flat, better := 0.005, d.Change > 0
+	if d.Time {
+		flat, better = 1, d.Change < 0
+	}
+	switch {
+	case math.Abs(d.Change) < flat:
+		return "delta-flat"
+	case better:
+		return "delta-better"
+	}
+	return "delta-worse"
+}
+
+// Delta compares current with the baseline's value of metric for config: "combined", "time" or
+// a custom score column ID (score:<name>); config "" is the overall average score
+// Nil when the baseline has no such value
+func (b *Baseline) Delta(config, metric string, current float64) *BaselineDelta {
+	if b == nil {
+		return nil
+	}
+	if config == "" {
+		return &BaselineDelta{Change: current - b.AvgScore, Was: b.AvgScore}
+	}
+	stat, ok := b.Configs[config]
+	if !ok {
+		return nil
+	}
+	switch metric {
+	case "combined":
+		return &BaselineDelta{Change: current - stat.AvgScore, Was: stat.AvgScore}
+	case "time":
+		if stat.AvgTimeMS == 0 {
+			return nil
+		}
+		return &BaselineDelta{Change: current - stat.AvgTimeMS, Was: stat.AvgTimeMS, Time: true}
+	}
+	was, ok := stat.CustomScores[strings.TrimPrefix(metric, "score:")]
+	if !ok {
+		return nil
+	}
+	return &BaselineDelta{Change: current - was, Was: was}
+}
+
+// requestBaseline returns the baseline named by the baseline URL parameter, nil when none is
+// asked for or it no longer exists
+func requestBaseline(r *http.Request) *Baseline {
+	name := r.URL.Query().Get("baseline")
+	if name == "" {
+		return nil
+	}
+	if baseline, ok := baselinesFor(r.Context()).Get(name); ok {
+		return &baseline
+	}
+	return nil
+}
+
+// baselineStore keeps baselines in a JSON file
+type baselineStore struct {
+	path string
+
+	mu        sync.Mutex
+	baselines map[string]Baseline
+}
+
+// baselines is the baseline registry, see --baselines-file
+var baselines *baselineStore
+
+// newBaselineStore loads baselines from path, a missing file means no baselines yet
+func newBaselineStore(path string) (*baselineStore, error) {
+	bs := &baselineStore{path: path, baselines: make(map[string]Baseline)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return bs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baselines: %w", err)
+	}
+
+	var list []Baseline
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("invalid baselines file %s: %w", path, err)
+	}
+	for _, baseline := range list {
+		bs.baselines[baseline.Name] = baseline
+	}
+	return bs, nil
+}
+
+// List returns all baselines sorted by name, none when the store isn't set up (tests, tools)
+func (bs *baselineStore) List() []Baseline {
+	if bs == nil {
+		return nil
+	}
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	return bs.list()
+}
+
+// list must be called with bs.mu held
+func (bs *baselineStore) list() []Baseline {
+	list := make([]Baseline, 0, len(bs.baselines))
+	for _, baseline := range bs.baselines {
+		list = append(list, baseline)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Get returns the baseline called name
+func (bs *baselineStore) Get(name string) (Baseline, bool) {
+	if bs == nil {
+		return Baseline{}, false
+	}
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	baseline, ok := bs.baselines[name]
+	return baseline, ok
+}
+
+// Save adds or replaces a baseline and writes the file
+func (bs *baselineStore) Save(baseline Baseline) error {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	bs.baselines[baseline.Name] = baseline
+	return bs.write()
+}
+
+// Delete removes a baseline, reports whether it existed
+func (bs *baselineStore) Delete(name string) (bool, error) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	if _, ok := bs.baselines[name]; !ok {
+		return false, nil
+	}
+	delete(bs.baselines, name)
+	return true, bs.write()
+}
+
+// write replaces the file atomically, must be called with bs.mu held
+func (bs *baselineStore) write() error {
+	data, err := json.MarshalIndent(bs.list(), "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := bs.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write baselines: %w", err)
+	}
+	return replaceFile(tmp, bs.path)
+}
+
+// baselinesAPIHandler lists (GET), snapshots (POST {"name","query"}) and deletes (DELETE ?name=)
+// baselines; query holds the dashboard filters and weights to snapshot, empty for everything
+func baselinesAPIHandler(w http.ResponseWriter, r *http.Request) {
+	baselines := baselinesFor(r.Context())
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(baselines.List()); err != nil {
+			log.Printf("Error encoding JSON: %v", err)
+		}
+
+	case http.MethodPost:
+		var req struct {
+			Name  string `json:"name"`
+			Query string `json:"query"`
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+			return
+		}
+		name := strings.TrimSpace(req.Name)
+		if name == "" || len(name) > maxViewNameLength {
+			http.Error(w, fmt.Sprintf("name must be 1-%d characters", maxViewNameLength), http.StatusBadRequest)
+			return
+		}
+		params, err := url.ParseQuery(strings.TrimPrefix(req.Query, "?"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid query: %v", err), http.StatusBadRequest)
+			return
+		}
+		// Only what shapes the stats is kept, not the baseline being compared against or the sort
+		kept := url.Values{}
+		for _, key := range []string{"model", "run_id", "test_id", "source", "dataset", "weights"} {
+			if params.Has(key) {
+				kept.Set(key, params.Get(key))
+			}
+		}
+
+		snapshot := r.Clone(r.Context())
+		snapshot.URL = &url.URL{Path: "/", RawQuery: kept.Encode()}
+		results, err := store.Query(r.Context(), dashboardFilter(snapshot))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+			return
+		}
+		results, ok := reweightRequest(w, snapshot, results)
+		if !ok {
+			return
+		}
+		baseline := newBaseline(name, kept.Encode(), CalculateStats(results, slasFor(r.Context())))
+		if err := baselines.Save(baseline); err != nil {
+			log.Printf("Error saving baseline: %v", err)
+			http.Error(w, "Failed to save baseline", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(baseline); err != nil {
+			log.Printf("Error encoding JSON: %v", err)
+		}
+
+	case http.MethodDelete:
+		found, err := baselines.Delete(r.URL.Query().Get("name"))
+		if err != nil {
+			log.Printf("Error deleting baseline: %v", err)
+			http.Error(w, "Failed to delete baseline", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
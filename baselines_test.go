@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBaselines(t *testing.T) {
+	dir := t.TempDir()
+	evals := withStore(t, `{"timestamp":"2025-01-01T10:00:00Z","model":"a","response_time_ms":100,"scores":{"combined":0.9,"accuracy":0.8}}`,
+		`{"timestamp":"2025-01-01T10:01:00Z","model":"b","response_time_ms":200,"scores":{"combined":0.5,"accuracy":0.4}}`)
+
+	path := filepath.Join(dir, "baselines.json")
+	bs, err := newBaselineStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	baselines = bs
+	defer func() { baselines = nil }()
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		baselinesAPIHandler(rec, httptest.NewRequest(http.MethodPost, "/api/baselines", strings.NewReader(body)))
+		return rec
+	}
+	if rec := post(`{"name":" release-1 ","query":"?model=a&sort=-combined&baseline=old"}`); rec.Code != http.StatusOK {
+		t.Fatalf("save: %d %s", rec.Code, rec.Body)
+	}
+	if rec := post(`{"name":""}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("empty name: %d", rec.Code)
+	}
+	if rec := post(`{"name":"bad","query":"weights=nope"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid weights: %d", rec.Code)
+	}
+
+	// Baselines survive a restart
+	reloaded, err := newBaselineStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	baselines = reloaded
+
+	rec := httptest.NewRecorder()
+	baselinesAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/api/baselines", nil))
+	var list []Baseline
+	json.Unmarshal(rec.Body.Bytes(), &list)
+	if len(list) != 1 || list[0].Name != "release-1" || list[0].Query != "model=a" {
+		t.Fatalf("list = %+v", list)
+	}
+	if stat := list[0].Configs["a"]; len(list[0].Configs) != 1 || stat.AvgScore != 0.9 || stat.CustomScores["accuracy"] != 0.8 || stat.AvgTimeMS != 100 {
+		t.Errorf("snapshot = %+v", list[0].Configs)
+	}
+
+	// The dashboard compares against it
+	os.WriteFile(evals, []byte(`{"timestamp":"2025-01-02T10:00:00Z","model":"a","response_time_ms":50,"scores":{"combined":0.7,"accuracy":0.9}}
+`), 0o644)
+	rec = httptest.NewRecorder()
+	dashboardHandler(rec, httptest.NewRequest(http.MethodGet, "/?baseline=release-1", nil))
+	body := rec.Body.String()
+	for _, want := range []string{"vs baseline <strong>release-1</strong>", `delta delta-worse" title="Baseline: 0.90">-0.20`, `delta delta-better" title="Baseline: 0.80">&#43;0.10`, `delta delta-better" title="Baseline: 100ms">-50`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("dashboard is missing %q", want)
+		}
+	}
+	rec = httptest.NewRecorder()
+	dashboardHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if strings.Contains(rec.Body.String(), `class="delta `) {
+		t.Error("deltas shown without a baseline")
+	}
+
+	rec = httptest.NewRecorder()
+	baselinesAPIHandler(rec, httptest.NewRequest(http.MethodDelete, "/api/baselines?name=release-1", nil))
+	if rec.Code != http.StatusNoContent || len(baselines.List()) != 0 {
+		t.Errorf("delete = %d, %d baselines left", rec.Code, len(baselines.List()))
+	}
+}
+
+func TestBaselineDelta(t *testing.T) {
+	b := &Baseline{AvgScore: 0.6, Configs: map[string]BaselineStat{
+		"a": {AvgScore: 0.8, CustomScores: map[string]float64{"accuracy": 0.5}, AvgTimeMS: 100},
+	}}
+	tests := []struct {
+		config, metric string
+		current        float64
+		want           string // String, then Class
+	}{
+		{"", "combined", 0.7, "+0.10 delta-better"},
+		{"a", "combined", 0.798, "-0.00 delta-flat"},
+		{"a", "score:accuracy", 0.3, "-0.20 delta-worse"},
+		{"a", "time", 150, "+50 delta-worse"},
+		{"a", "time", 80, "-20 delta-better"},
+	}
+	for _, tt := range tests {
+		d := b.Delta(tt.config, tt.metric, tt.current)
+		if d == nil {
+			t.Errorf("Delta(%q, %q) = nil", tt.config, tt.metric)
+			continue
+		}
+		if got := d.String() + " " + d.Class(); got != tt.want {
+			t.Errorf("Delta(%q, %q, %g) = %q, want %q", tt.config, tt.metric, tt.current, got, tt.want)
+		}
+	}
+	if d := b.Delta("b", "combined", 0.5); d != nil {
+		t.Errorf("Delta for a config missing from the baseline = %v", d)
+	}
+	if d := b.Delta("a", "score:tone", 0.5); d != nil {
+		t.Errorf("Delta for a score missing from the baseline = %v", d)
+	}
+	if d := (*Baseline)(nil).Delta("a", "combined", 0.5); d != nil {
+		t.Errorf("Delta without a baseline = %v", d)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"sync"
+)
+
+const (
+	bootstrapIterations = 1000
+	bootstrapCacheLimit = 4096
+	// minTestsForCI is the sample size below which intervals are flagged as unreliable
+	minTestsForCI = 10
+)
+
+// ciCache keeps bootstrap intervals keyed by a fingerprint of the input scores
+// Every page load recalculates stats, but the scores rarely change between loads
+var ciCache = struct {
+	sync.Mutex
+	m map[uint64][2]float64
+}{m: make(map[uint64][2]float64)}
+
+// bootstrapCI returns the 95% percentile bootstrap interval for the mean of values
+// Resampling is seeded from the data, so the same scores always produce the same interval
+func bootstrapCI(values []float64) (low, high float64) {
+	switch len(values) {
+	case 0:
+		return 0, 0
+	case 1:
+		return values[0], values[0]
+	}
+
+	key := fingerprint(values)
+	ciCache.Lock()
+	ci, ok := ciCache.m[key]
+	ciCache.Unlock()
+	if ok {
+		return ci[0], ci[1]
+	}
+
+	rng := rand.New(rand.NewPCG(key, uint64(len(values))))
+	means := make([]float64, bootstrapIterations)
+	for i := range means {
+		sum := 0.0
+		for range values {
+			sum += values[rng.IntN(len(values))]
+		}
+		means[i] = sum / float64(len(values))
+	}
+	sort.Float64s(means)
+	low = means[int(0.025*float64(bootstrapIterations))]
+	high = means[int(0.975*float64(bootstrapIterations))-1]
+
+	ciCache.Lock()
+	if len(ciCache.m) >= bootstrapCacheLimit {
+		ciCache.m = make(map[uint64][2]float64)
+	}
+	ciCache.m[key] = [2]float64{low, high}
+	ciCache.Unlock()
+
+	return low, high
+}
+
+// fingerprint hashes the exact score sequence
+func fingerprint(values []float64) uint64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	for _, v := range values {
+		binary.LittleEndian.PutUint64(buf[:], math.Float64bits(v))
+		h.Write(buf[:])
+	}
+	return h.Sum64()
+}
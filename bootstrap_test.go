@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestBootstrapCI(t *testing.T) {
+	values := []float64{0.2, 0.4, 0.5, 0.6, 0.9, 0.7, 0.3, 0.8}
+	avg := mean(values)
+
+	low, high := bootstrapCI(values)
+	if !(low < avg && avg < high) {
+		t.Errorf("interval [%f, %f] does not contain mean %f", low, high, avg)
+	}
+	if low < 0.2 || high > 0.9 {
+		t.Errorf("interval [%f, %f] outside data range", low, high)
+	}
+
+	// Deterministic for the same input (and served from cache)
+	if l2, h2 := bootstrapCI(append([]float64(nil), values...)); l2 != low || h2 != high {
+		t.Errorf("second call = [%f, %f], want [%f, %f]", l2, h2, low, high)
+	}
+
+	if l, h := bootstrapCI([]float64{0.5}); l != 0.5 || h != 0.5 {
+		t.Errorf("single value = [%f, %f]", l, h)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+// Branding customizes the dashboard for a team: title, logo, accent color and footer
+// Loaded from the JSON file given with --theme; the zero value is the stock GoEvals look
+type Branding struct {
+	Title  string `json:"title"`  // Replaces "GoEvals" in headings and browser tabs
+	Logo   string `json:"logo"`   // Image URL shown next to headings, e.g. /static/logo.svg with --static-dir
+	Accent string `json:"accent"` // Hex color for links, buttons and highlights, e.g. #e11d48
+	Footer string `json:"footer"` // Text shown at the bottom of every page
+}
+
+// branding is the active theme from --theme
+var branding Branding
+
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// loadBranding reads a theme file, an empty path means the default theme
+func loadBranding(path string) (Branding, error) {
+	var b Branding
+	if path == "" {
+		return b, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return b, fmt.Errorf("failed to read theme: %w", err)
+	}
+	if err := json.Unmarshal(data, &b); err != nil {
+		return b, fmt.Errorf("invalid theme file %s: %w", path, err)
+	}
+	if b.Accent != "" && !hexColor.MatchString(b.Accent) {
+		return b, fmt.Errorf("invalid theme file %s: accent %q must be a hex color like #e11d48", path, b.Accent)
+	}
+	return b, nil
+}
+
+// Name is the product name for browser tabs
+func (b Branding) Name() string {
+	if b.Title != "" {
+		return b.Title
+	}
+	return "GoEvals"
+}
+
+// Heading is the dashboard heading
+func (b Branding) Heading() string {
+	if b.Title != "" {
+		return b.Title
+	}
+	return "GoEvals Dashboard"
+}
+
+// AccentCSS is the validated accent color, safe to place in a style sheet
+func (b Branding) AccentCSS() template.CSS {
+	return template.CSS(b.Accent)
+}
+
+// AccentHoverCSS darkens the accent by 15% for hover states
+func (b Branding) AccentHoverCSS() template.CSS {
+	hex := b.Accent[1:]
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	rgb, _ := strconv.ParseUint(hex, 16, 32)
+	channel := func(shift uint) uint64 { return (rgb >> shift & 0xff) * 85 / 100 }
+	return template.CSS(fmt.Sprintf("#%02x%02x%02x", channel(16), channel(8), channel(0)))
+}
+
+// brandingTemplates are parsed into the dashboard, tests page and every pageLayout page
+//
+//	{{ template "brand-style" }}   accent overrides, inside <style> after the theme variables
+//	{{ template "brand-logo" }}    logo image, inside the page heading
+//	{{ template "brand-footer" }}  footer text
+const brandingTemplates = `
+{{ define "brand-style" }}{{ with brand }}{{ if .Accent }}
+        :root, [data-theme="dark"] {
+            --accent: {{ .AccentCSS }};
+            --accent-hover: {{ .AccentHoverCSS }};
+        }
+{{ end }}{{ if .Logo }}
+        .brand-logo {
+            height: 1.5em;
+            width: auto;
+            margin-right: 0.5rem;
+            vertical-align: middle;
+        }
+{{ end }}{{ if .Footer }}
+        .brand-footer {
+            text-align: center;
+            color: var(--text-tertiary);
+            font-size: 0.875rem;
+            margin: 1.5rem 0 0.5rem;
+        }
+{{ end }}{{ end }}{{ end }}
+
+{{ define "brand-logo" }}{{ with brand.Logo }}<img class="brand-logo" src="{{ . }}" alt="">{{ end }}{{ end }}
+
+{{ define "brand-footer" }}{{ with brand.Footer }}<div class="brand-footer">{{ . }}</div>{{ end }}{{ end }}`
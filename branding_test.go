@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadBranding(t *testing.T) {
+	if b, err := loadBranding(""); err != nil || b != (Branding{}) {
+		t.Errorf("empty path = %+v, %v", b, err)
+	}
+	dir := t.TempDir()
+	good := filepath.Join(dir, "theme.json")
+	os.WriteFile(good, []byte(`{"title":"Acme Evals","logo":"/static/logo.svg","accent":"#e11d48","footer":"Internal use only"}`), 0o644)
+	b, err := loadBranding(good)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Name() != "Acme Evals" || b.Heading() != "Acme Evals" || b.Logo != "/static/logo.svg" {
+		t.Errorf("branding = %+v", b)
+	}
+	if got := b.AccentHoverCSS(); got != "#bf183d" {
+		t.Errorf("AccentHoverCSS = %q", got)
+	}
+	if got := (Branding{Accent: "#fff"}).AccentHoverCSS(); got != "#d8d8d8" {
+		t.Errorf("short AccentHoverCSS = %q", got)
+	}
+	if (Branding{}).Name() != "GoEvals" || (Branding{}).Heading() != "GoEvals Dashboard" {
+		t.Error("default names changed")
+	}
+
+	bad := filepath.Join(dir, "bad.json")
+	for _, content := range []string{`{"accent":"red; background:url(x)"}`, `{"title":`} {
+		os.WriteFile(bad, []byte(content), 0o644)
+		if _, err := loadBranding(bad); err == nil {
+			t.Errorf("loadBranding accepted %s", content)
+		}
+	}
+	if _, err := loadBranding(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("loadBranding accepted a missing file")
+	}
+}
+
+func TestBrandedPages(t *testing.T) {
+	defer func(old Branding) { branding = old }(branding)
+	branding = Branding{Title: "Acme Evals", Logo: "/static/logo.svg", Accent: "#e11d48", Footer: "Internal use only"}
+
+	withStore(t, `{"timestamp":"2025-01-01T10:00:00Z","model":"gpt-4","scores":{"combined":0.9}}`)
+
+	for _, target := range []string{"/", "/tests", "/runs"} {
+		rec := httptest.NewRecorder()
+		http.HandlerFunc(map[string]http.HandlerFunc{"/": dashboardHandler, "/tests": testsHandler, "/runs": runsHandler}[target]).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		body := rec.Body.String()
+		title, _, _ := strings.Cut(body[strings.Index(body, "<title>"):], "</title>")
+		if !strings.Contains(title, "Acme Evals") || strings.Contains(title, "GoEvals") {
+			t.Errorf("%s title = %q", target, title)
+		}
+		for _, want := range []string{`src="/static/logo.svg"`, "--accent: #e11d48", `<div class="brand-footer">Internal use only</div>`} {
+			if !strings.Contains(body, want) {
+				t.Errorf("%s is missing %q", target, want)
+			}
+		}
+	}
+}
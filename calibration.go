@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+)
+
+// calibrationBins is the number of equal-width judge score bins of the calibration plot
+const calibrationBins = 10
+
+// humanVerdicts reads accept/reject annotations as human pass/fail verdicts, keyed by result ID
+// Each reviewer's latest decision counts once; results the reviewers split evenly on, or only
+// marked unsure, have no verdict
+func humanVerdicts(list []Annotation) map[string]bool {
+	latest := make(map[[2]string]string) // (result ID, reviewer) -> decision
+	for _, a := range list {
+		if a.Decision == "accept" || a.Decision == "reject" {
+			latest[[2]string{a.ResultID, a.Reviewer}] = a.Decision
+		}
+	}
+	votes := make(map[string]int)
+	for key, decision := range latest {
+		if decision == "accept" {
+			votes[key[0]]++
+		} else {
+			votes[key[0]]--
+		}
+	}
+	verdicts := make(map[string]bool, len(votes))
+	for id, v := range votes {
+		if v != 0 {
+			verdicts[id] = v > 0
+		}
+	}
+	return verdicts
+}
+
+// CalibrationBin is how often humans accepted the results a judge scored in one score range
+type CalibrationBin struct {
+	Low        float64 `json:"low"`
+	High       float64 `json:"high"`
+	MeanScore  float64 `json:"mean_score"`
+	AcceptRate float64 `json:"accept_rate"`
+	Count      int     `json:"count"`
+
+	X, Y float64 `json:"-"` // Position in the chart
+}
+
+// JudgeCalibration compares one judge's pass/fail verdicts with the human ones
+// Accepted by humans is the positive class
+type JudgeCalibration struct {
+	Judge          string           `json:"judge"`
+	Results        int              `json:"results"`
+	TruePositives  int              `json:"true_positives"`  // Judge passed, human accepted
+	FalsePositives int              `json:"false_positives"` // Judge passed, human rejected
+	FalseNegatives int              `json:"false_negatives"` // Judge failed, human accepted
+	TrueNegatives  int              `json:"true_negatives"`  // Judge failed, human rejected
+	Kappa          float64          `json:"-"`               // Cohen's kappa of judge and human verdicts
+	Bins           []CalibrationBin `json:"bins"`
+	Color          string           `json:"-"`
+	Path           string           `json:"-"`
+}
+
+// Accuracy is the share of results where judge and humans agree
+func (c JudgeCalibration) Accuracy() float64 {
+	return ratio(c.TruePositives+c.TrueNegatives, c.Results)
+}
+
+// Precision is the share of the judge's passes that humans accepted, NaN when it passed none
+func (c JudgeCalibration) Precision() float64 {
+	return ratio(c.TruePositives, c.TruePositives+c.FalsePositives)
+}
+
+// Recall is the share of human accepts that the judge passed, NaN when humans accepted none
+func (c JudgeCalibration) Recall() float64 {
+	return ratio(c.TruePositives, c.TruePositives+c.FalseNegatives)
+}
+
+// ratio is n/d, NaN when d is 0
+func ratio(n, d int) float64 {
+	if d == 0 {
+		return math.NaN()
+	}
+	return float64(n) / float64(d)
+}
+
+// MarshalJSON writes undefined metrics as null
+func (c JudgeCalibration) MarshalJSON() ([]byte, error) {
+	type plain JudgeCalibration
+	return json.Marshal(struct {
+		plain
+		Accuracy  any `json:"accuracy"`
+		Precision any `json:"precision"`
+		Recall    any `json:"recall"`
+		Kappa     any `json:"kappa"`
+	}{plain(c), jsonNumber(c.Accuracy()), jsonNumber(c.Precision()), jsonNumber(c.Recall()), jsonNumber(c.Kappa)})
+}
+
+// Calibration is the judge vs human section of the judges page
+type Calibration struct {
+	Score     string             `json:"score"`
+	Threshold float64            `json:"threshold"`
+	Reviewed  int                `json:"reviewed"` // Results with a human verdict
+	Judges    []JudgeCalibration `json:"judges"`
+
+	Frame    *ChartFrame `json:"-"`
+	Diagonal string      `json:"-"` // Perfect calibration line
+}
+
+// BuildCalibration compares the judges' scores of one score with the human accept/reject
+// annotations: a calibration curve (human accept rate per judge score bin) and the confusion
+// matrix of the judge verdicts, pass = score ≥ threshold
+func BuildCalibration(results []EvalResult, list []Annotation, score string, threshold float64) Calibration {
+	cal := Calibration{Score: score, Threshold: threshold, Judges: []JudgeCalibration{}}
+	verdicts := humanVerdicts(list)
+	if len(verdicts) == 0 {
+		return cal
+	}
+	type pair struct {
+		score float64
+		human bool
+	}
+	byJudge := make(map[string][]pair)
+	reviewed := make(map[string]bool)
+	for _, result := range scored(results) {
+		id := resultID(result)
+		human, ok := verdicts[id]
+		if !ok {
+			continue
+		}
+		for judge, scores := range judgeScoresOf(result) {
+			if v, ok := scores[score]; ok {
+				byJudge[judge] = append(byJudge[judge], pair{v, human})
+				reviewed[id] = true
+			}
+		}
+	}
+	cal.Reviewed = len(reviewed)
+
+	for _, judge := range slices.Sorted(maps.Keys(byJudge)) {
+		c := JudgeCalibration{Judge: judge, Results: len(byJudge[judge])}
+		var judged, humans []bool
+		bins := make([]CalibrationBin, calibrationBins)
+		for _, p := range byJudge[judge] {
+			pass := p.score >= threshold
+			switch {
+			case pass && p.human:
+				c.TruePositives++
+			case pass:
+				c.FalsePositives++
+			case p.human:
+				c.FalseNegatives++
+			default:
+				c.TrueNegatives++
+			}
+			judged, humans = append(judged, pass), append(humans, p.human)
+
+			b := &bins[min(max(int(p.score*calibrationBins), 0), calibrationBins-1)]
+			b.MeanScore += p.score
+			if p.human {
+				b.AcceptRate++
+			}
+			b.Count++
+		}
+		c.Kappa = cohensKappa(judged, humans)
+		for i, b := range bins {
+			if b.Count == 0 {
+				continue
+			}
+			b.Low, b.High = float64(i)/calibrationBins, float64(i+1)/calibrationBins
+			b.MeanScore /= float64(b.Count)
+			b.AcceptRate /= float64(b.Count)
+			c.Bins = append(c.Bins, b)
+		}
+		cal.Judges = append(cal.Judges, c)
+	}
+	layoutCalibration(&cal)
+	return cal
+}
+
+// layoutCalibration places the calibration curves on a 0..1 × 0..1 chart
+func layoutCalibration(cal *Calibration) {
+	if len(cal.Judges) == 0 {
+		return
+	}
+	frame := newChartFrame(judgeBiasWidth, judgeBiasHeight, 0, 1, 1)
+	frame.tickX([]float64{0, 0.25, 0.5, 0.75, 1}, func(v float64) string { return fmt.Sprintf("%.2f", v) })
+	cal.Diagonal = polyline([]float64{frame.X(0), frame.X(1)}, []float64{frame.Y(0), frame.Y(1)})
+	for i := range cal.Judges {
+		c := &cal.Judges[i]
+		c.Color = seriesColor(i)
+		xs, ys := make([]float64, len(c.Bins)), make([]float64, len(c.Bins))
+		for j := range c.Bins {
+			b := &c.Bins[j]
+			// Scores above 1 are drawn at the right edge, the bins already hold them there
+			b.X, b.Y = frame.X(math.Max(0, math.Min(1, b.MeanScore))), frame.Y(b.AcceptRate)
+			xs[j], ys[j] = b.X, b.Y
+		}
+		c.Path = polyline(xs, ys)
+	}
+	cal.Frame = frame
+}
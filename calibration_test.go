@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHumanVerdicts(t *testing.T) {
+	verdicts := humanVerdicts([]Annotation{
+		{ResultID: "a", Reviewer: "ann", Decision: "reject"},
+		{ResultID: "a", Reviewer: "ann", Decision: "accept"}, // Changed their mind
+		{ResultID: "b", Reviewer: "ann", Decision: "accept"},
+		{ResultID: "b", Reviewer: "bob", Decision: "reject"},
+		{ResultID: "c", Reviewer: "ann", Decision: "unsure"},
+		{ResultID: "d", Reviewer: "ann", Decision: "reject"},
+		{ResultID: "d", Reviewer: "bob", Decision: "reject"},
+		{ResultID: "d", Reviewer: "eve", Decision: "accept"},
+	})
+	if len(verdicts) != 2 || !verdicts["a"] || verdicts["d"] {
+		t.Errorf("verdicts = %v", verdicts)
+	}
+}
+
+func TestBuildCalibration(t *testing.T) {
+	var results []EvalResult
+	var list []Annotation
+	// The judge passes 0.2, 0.4, 0.6, 0.8; humans accept the top three
+	for i, score := range []float64{0.2, 0.4, 0.6, 0.8} {
+		result := EvalResult{Model: "m", TestID: fmt.Sprint("t", i), JudgeModel: "gpt-4o", Scores: ScoreBreakdown{Combined: score}}
+		results = append(results, result)
+		decision := "accept"
+		if i == 0 {
+			decision = "reject"
+		}
+		list = append(list, Annotation{ResultID: resultID(result), Decision: decision})
+	}
+	results = append(results, EvalResult{Model: "m", TestID: "unreviewed", JudgeModel: "gpt-4o"})
+
+	cal := BuildCalibration(results, list, "combined", 0.5)
+	if cal.Reviewed != 4 || len(cal.Judges) != 1 {
+		t.Fatalf("calibration = %+v", cal)
+	}
+	c := cal.Judges[0]
+	if c.TruePositives != 2 || c.FalseNegatives != 1 || c.TrueNegatives != 1 || c.FalsePositives != 0 {
+		t.Errorf("confusion = %+v", c)
+	}
+	if c.Accuracy() != 0.75 || c.Precision() != 1 || math.Abs(c.Recall()-2.0/3) > 1e-9 {
+		t.Errorf("accuracy %v, precision %v, recall %v", c.Accuracy(), c.Precision(), c.Recall())
+	}
+	if len(c.Bins) != 4 || c.Bins[0].AcceptRate != 0 || c.Bins[3].AcceptRate != 1 || cal.Frame == nil || c.Path == "" {
+		t.Errorf("bins = %+v", c.Bins)
+	}
+
+	// Nothing passes: precision is undefined
+	data, err := json.Marshal(BuildCalibration(results, list, "combined", 0.9).Judges[0])
+	if err != nil || !strings.Contains(string(data), `"precision":null`) {
+		t.Errorf("JSON = %s, %v", data, err)
+	}
+	if cal := BuildCalibration(results, nil, "combined", 0.5); len(cal.Judges) != 0 || cal.Frame != nil {
+		t.Errorf("no annotations: %+v", cal)
+	}
+}
+
+func TestJudgesPageCalibration(t *testing.T) {
+	var lines []string
+	for i := range 4 {
+		lines = append(lines, fmt.Sprintf(`{"timestamp":"2025-01-01T10:00:0%dZ","model":"m","test_id":"t%d","judge_model":"gpt-4o","scores":{"combined":0.%d}}`, i, i, 2*i+1))
+	}
+	withStore(t, lines...)
+	annotations, _ = newAnnotationStore(filepath.Join(t.TempDir(), "annotations.jsonl"))
+	defer func() { annotations = nil }()
+
+	results, _ := store.Query(t.Context(), Query{})
+	for _, result := range results {
+		decision := "reject"
+		if result.Scores.Combined >= 0.5 {
+			decision = "accept"
+		}
+		if _, err := annotations.Add(Annotation{ResultID: resultID(result), Decision: decision}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	judgesHandler(rec, httptest.NewRequest(http.MethodGet, "/judges", nil))
+	if body := rec.Body.String(); !strings.Contains(body, "Calibration vs Humans") || !strings.Contains(body, "100.0%") {
+		t.Error("judges page has no calibration")
+	}
+
+	rec = httptest.NewRecorder()
+	judgesAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/api/judges", nil))
+	var report struct {
+		Calibration struct {
+			Reviewed int `json:"reviewed"`
+			Judges   []struct {
+				Accuracy float64 `json:"accuracy"`
+			} `json:"judges"`
+		} `json:"calibration"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil || report.Calibration.Reviewed != 4 || report.Calibration.Judges[0].Accuracy != 1 {
+		t.Errorf("API = %+v, %v", report, err)
+	}
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Shared SVG chart layer for the sweep, latency and run trend views
+//
+// Charts are laid out in Go and drawn by the templates in chartTemplates, which every
+// pageLayout page can use. Colors come from CSS classes (series-N, axis, chart text) backed by
+// the layout's light/dark variables, so no chart carries a hard-coded color.
+
+// ChartTick is an axis label at a chart position
+type ChartTick struct {
+	Pos   float64
+	Label string
+}
+
+// Chart margins in SVG user units
+const (
+	chartLeft     = 48
+	chartRight    = 16
+	chartTop      = 16
+	chartBottom   = 40
+	chartMaxTicks = 8 // Label every distinct x value up to this many, otherwise evenly spaced ticks
+	chartPalette  = 8 // Number of series-N classes in pageLayout
+)
+
+// seriesColor returns the CSS class of the i-th series color; SVG elements draw with currentColor
+func seriesColor(i int) string {
+	return "series-" + strconv.Itoa(i%chartPalette+1)
+}
+
+// ChartFrame maps data values into the plot area of a Width × Height chart and holds its axis ticks
+type ChartFrame struct {
+	Width, Height int
+	LogX          bool
+	XTicks        []ChartTick
+	YTicks        []ChartTick
+
+	minX, maxX, maxY float64
+}
+
+// newChartFrame sets up a frame for x in [minX, maxX] and y in [0, maxY]; a log x axis is used when
+// the values span two or more orders of magnitude
+func newChartFrame(width, height int, minX, maxX, maxY float64) *ChartFrame {
+	frame := &ChartFrame{Width: width, Height: height, maxY: maxY}
+	frame.LogX = minX > 0 && maxX/minX >= 100
+	if maxX == minX { // Single distinct value, center it
+		if minX > 0 {
+			minX, maxX = minX/2, maxX*1.5
+		} else {
+			minX, maxX = minX-1, maxX+1
+		}
+	}
+	frame.minX, frame.maxX = minX, maxX
+	for i := 0; i <= 4; i++ {
+		v := maxY * float64(i) / 4
+		frame.YTicks = append(frame.YTicks, ChartTick{Pos: frame.Y(v), Label: strconv.FormatFloat(v, 'f', 2, 64)})
+	}
+	return frame
+}
+
+// X is the horizontal position of value v
+func (f *ChartFrame) X(v float64) float64 {
+	plotW := float64(f.Width - chartLeft - chartRight)
+	if f.LogX {
+		return chartLeft + (math.Log10(v)-math.Log10(f.minX))/(math.Log10(f.maxX)-math.Log10(f.minX))*plotW
+	}
+	return chartLeft + (v-f.minX)/(f.maxX-f.minX)*plotW
+}
+
+// Y is the vertical position of value v
+func (f *ChartFrame) Y(v float64) float64 {
+	plotH := float64(f.Height - chartTop - chartBottom)
+	return chartTop + (1-v/f.maxY)*plotH
+}
+
+// tickX labels the x axis at each of the sorted values, or at five evenly spaced points when
+// values is nil or there are too many to label
+func (f *ChartFrame) tickX(values []float64, format func(float64) string) {
+	if values == nil || len(values) > chartMaxTicks {
+		values = nil
+		for i := 0; i < 5; i++ {
+			t := float64(i) / 4
+			v := f.minX + t*(f.maxX-f.minX)
+			if f.LogX {
+				v = math.Pow(10, math.Log10(f.minX)+t*(math.Log10(f.maxX)-math.Log10(f.minX)))
+			}
+			values = append(values, v)
+		}
+	}
+	for _, v := range values {
+		f.XTicks = append(f.XTicks, ChartTick{Pos: f.X(v), Label: format(v)})
+	}
+}
+
+// Plot edges for the templates
+func (f *ChartFrame) Left() int   { return chartLeft }
+func (f *ChartFrame) Right() int  { return f.Width - chartRight }
+func (f *ChartFrame) Bottom() int { return f.Height - chartBottom }
+func (f *ChartFrame) LabelX() int { return chartLeft - 6 }
+
+// polyline formats points for an SVG polyline
+func polyline(xs, ys []float64) string {
+	points := make([]string, len(xs))
+	for i := range xs {
+		points[i] = fmt.Sprintf("%.1f,%.1f", xs[i], ys[i])
+	}
+	return strings.Join(points, " ")
+}
+
+// ChartBar is one bar of a bar chart or histogram
+type ChartBar struct {
+	X, Y, W, H float64
+	Label      string // Tooltip
+}
+
+// BarChart is a small axis-less bar chart, e.g. a histogram in a table cell
+type BarChart struct {
+	Width, Height int
+	Bars          []ChartBar
+}
+
+// newBarChart scales counts to the tallest bar, one label per count
+func newBarChart(width, height int, counts []int, labels []string) BarChart {
+	chart := BarChart{Width: width, Height: height}
+	peak := 0
+	for _, n := range counts {
+		peak = max(peak, n)
+	}
+	barW := float64(width) / float64(len(counts))
+	for i, n := range counts {
+		h := 0.0
+		if peak > 0 {
+			h = float64(n) / float64(peak) * float64(height)
+		}
+		chart.Bars = append(chart.Bars, ChartBar{X: float64(i) * barW, Y: float64(height) - h, W: math.Max(1, barW-1), H: h, Label: labels[i]})
+	}
+	return chart
+}
+
+// RadarAxis is one spoke of a radar chart, with its label placed just outside the outer ring
+type RadarAxis struct {
+	X, Y           float64 // Outer end of the spoke
+	LabelX, LabelY float64
+	Anchor         string // text-anchor of the label
+	Label          string
+}
+
+// RadarSeries is one polygon of a radar chart
+type RadarSeries struct {
+	Name   string
+	Color  string // Series class, see seriesColor
+	Points string // SVG polygon points
+	Dots   []TrendPoint
+}
+
+// RadarChart plots several 0-1 values of each series on spokes around a center
+type RadarChart struct {
+	Size   int
+	Center float64
+	Axes   []RadarAxis
+	Rings  []string // Polygons at 25, 50, 75 and 100%
+	Series []RadarSeries
+}
+
+// radarMargin leaves room for the axis labels around the outer ring
+const radarMargin = 90
+
+// newRadarChart lays out one spoke per label, clockwise from the top; values are clamped to [0, 1]
+// and each series has one value per label
+func newRadarChart(size int, labels []string, names []string, values [][]float64) RadarChart {
+	chart := RadarChart{Size: size, Center: float64(size) / 2}
+	radius := chart.Center - radarMargin
+	point := func(i int, v float64) (float64, float64) {
+		angle := -math.Pi/2 + 2*math.Pi*float64(i)/float64(len(labels))
+		return chart.Center + v*radius*math.Cos(angle), chart.Center + v*radius*math.Sin(angle)
+	}
+	for i, label := range labels {
+		x, y := point(i, 1)
+		lx, ly := point(i, 1+14/radius)
+		anchor := "middle"
+		if lx < chart.Center-1 {
+			anchor = "end"
+		} else if lx > chart.Center+1 {
+			anchor = "start"
+		}
+		chart.Axes = append(chart.Axes, RadarAxis{X: x, Y: y, LabelX: lx, LabelY: ly, Anchor: anchor, Label: label})
+	}
+	ring := func(at func(i int) float64) string {
+		xs, ys := make([]float64, len(labels)), make([]float64, len(labels))
+		for i := range labels {
+			xs[i], ys[i] = point(i, at(i))
+		}
+		return polyline(xs, ys)
+	}
+	for _, level := range []float64{0.25, 0.5, 0.75, 1} {
+		chart.Rings = append(chart.Rings, ring(func(int) float64 { return level }))
+	}
+	for s, name := range names {
+		series := RadarSeries{Name: name, Color: seriesColor(s)}
+		clamped := func(i int) float64 { return math.Max(0, math.Min(1, values[s][i])) }
+		series.Points = ring(clamped)
+		for i, label := range labels {
+			x, y := point(i, clamped(i))
+			series.Dots = append(series.Dots, TrendPoint{X: x, Y: y, Run: label, Score: values[s][i]})
+		}
+		chart.Series = append(chart.Series, series)
+	}
+	return chart
+}
+
+// chartTemplates are parsed into every pageLayout page
+//
+//	{{ template "chart-axes" .Frame }}   grid lines, y labels and the x axis of a ChartFrame
+//	{{ template "bar-chart" .Chart }}    a BarChart as an inline SVG
+//	{{ template "radar-chart" .Radar }}  a RadarChart with its legend
+const chartTemplates = `
+{{ define "chart-axes" }}
+    {{ $f := . }}
+    {{ range .YTicks }}
+    <line class="axis" x1="{{ $f.Left }}" x2="{{ $f.Right }}" y1="{{ .Pos }}" y2="{{ .Pos }}" stroke-dasharray="2,4"/>
+    <text x="{{ $f.LabelX }}" y="{{ .Pos }}" text-anchor="end" dominant-baseline="middle">{{ .Label }}</text>
+    {{ end }}
+    <line class="axis" x1="{{ $f.Left }}" x2="{{ $f.Right }}" y1="{{ $f.Bottom }}" y2="{{ $f.Bottom }}"/>
+    {{ range .XTicks }}
+    <text x="{{ .Pos }}" y="{{ $f.Bottom }}" dy="18" text-anchor="middle">{{ .Label }}</text>
+    {{ end }}
+{{ end }}
+
+{{ define "bar-chart" }}<svg class="bar-chart" width="{{ .Width }}" height="{{ .Height }}" viewBox="0 0 {{ .Width }} {{ .Height }}">{{ range .Bars }}<rect x="{{ .X }}" y="{{ .Y }}" width="{{ .W }}" height="{{ .H }}"><title>{{ .Label }}</title></rect>{{ end }}</svg>{{ end }}
+
+{{ define "radar-chart" }}
+    <svg class="radar-chart" viewBox="0 0 {{ .Size }} {{ .Size }}" role="img" aria-label="score profile">
+        {{ range .Rings }}<polygon class="axis" points="{{ . }}" fill="none" stroke-dasharray="2,4"/>{{ end }}
+        {{ $c := .Center }}
+        {{ range .Axes }}
+        <line class="axis" x1="{{ $c }}" y1="{{ $c }}" x2="{{ .X }}" y2="{{ .Y }}"/>
+        <text x="{{ .LabelX }}" y="{{ .LabelY }}" text-anchor="{{ .Anchor }}" dominant-baseline="middle">{{ .Label }}</text>
+        {{ end }}
+        {{ range .Series }}
+        {{ $series := . }}
+        <g class="{{ .Color }}">
+            <polygon points="{{ .Points }}" fill="currentColor" fill-opacity="0.15" stroke="currentColor" stroke-width="2"/>
+            {{ range .Dots }}<circle cx="{{ .X }}" cy="{{ .Y }}" r="3.5" fill="currentColor"><title>{{ $series.Name }} · {{ .Run }}: {{ printf "%.3f" .Score }}</title></circle>{{ end }}
+        </g>
+        {{ end }}
+    </svg>
+    <div class="legend">
+        {{ range .Series }}<span><span class="legend-swatch {{ .Color }}"></span>{{ .Name }}</span>{{ end }}
+    </div>
+{{ end }}`
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestChartFrame(t *testing.T) {
+	frame := newChartFrame(200, 100, 10, 20, 1)
+	if frame.X(10) != chartLeft || frame.X(20) != 200-chartRight || frame.Y(0) != 100-chartBottom || frame.Y(1) != chartTop {
+		t.Errorf("linear frame edges: x %v..%v, y %v..%v", frame.X(10), frame.X(20), frame.Y(0), frame.Y(1))
+	}
+	if frame.LogX || len(frame.YTicks) != 5 || frame.YTicks[4].Label != "1.00" {
+		t.Errorf("frame = %+v", frame)
+	}
+
+	frame = newChartFrame(200, 100, 1e-5, 1e-2, 1)
+	if !frame.LogX || math.Abs((frame.X(1e-4)-frame.X(1e-5))-(frame.X(1e-3)-frame.X(1e-4))) > 1e-9 {
+		t.Error("log frame does not space decades evenly")
+	}
+
+	frame.tickX([]float64{1e-5, 1e-4}, formatSweepValue)
+	if len(frame.XTicks) != 2 || frame.XTicks[1].Label != "0.0001" {
+		t.Errorf("ticks = %+v", frame.XTicks)
+	}
+	frame = newChartFrame(200, 100, 5, 5, 1) // Single value, centered
+	if x := frame.X(5); x <= chartLeft || x >= 200-chartRight {
+		t.Errorf("single value at %v", x)
+	}
+	if frame.tickX(nil, formatSweepValue); len(frame.XTicks) != 5 {
+		t.Errorf("nil values gave %d ticks, want 5 evenly spaced", len(frame.XTicks))
+	}
+}
+
+func TestBarChart(t *testing.T) {
+	chart := newBarChart(40, 10, []int{1, 4, 0, 2}, []string{"a", "b", "c", "d"})
+	if len(chart.Bars) != 4 || chart.Bars[1].H != 10 || chart.Bars[1].Y != 0 || chart.Bars[0].H != 2.5 || chart.Bars[2].H != 0 {
+		t.Errorf("bars = %+v", chart.Bars)
+	}
+	if chart.Bars[3].X != 30 || chart.Bars[3].Label != "d" {
+		t.Errorf("last bar = %+v", chart.Bars[3])
+	}
+	if seriesColor(0) != "series-1" || seriesColor(chartPalette) != "series-1" {
+		t.Error("series colors don't cycle through the palette")
+	}
+}
+
+func TestChartTemplates(t *testing.T) {
+	data := struct {
+		Title, Subtitle string
+		Frame           *ChartFrame
+		Chart           BarChart
+	}{Frame: newChartFrame(200, 100, 0, 1, 1), Chart: newBarChart(20, 10, []int{1, 2}, []string{"x", "y"})}
+	rec := httptest.NewRecorder()
+	renderPage(rec, httptest.NewRequest(http.MethodGet, "/", nil), "charts", `{{ define "content" }}<svg>{{ template "chart-axes" .Frame }}</svg>{{ template "bar-chart" .Chart }}{{ end }}`, data)
+	if body := rec.Body.String(); strings.Count(body, `class="axis"`) != 6 || !strings.Contains(body, `<svg class="bar-chart"`) || strings.Contains(body, "ZgotmplZ") {
+		t.Errorf("chart templates rendered badly:\n%s", body)
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// command is a goevals subcommand
+type command struct {
+	name     string
+	synopsis string // Arguments after the name in usage lines
+	summary  string // One line description for goevals help
+	run      func(args []string) int
+}
+
+// commands are the goevals subcommands, in help order
+var commands = []command{
+	{"serve", "[flags] <source1> [source2] [...]", "Serve the dashboard (the default: goevals <sources> is goevals serve <sources>)", runServe},
+	{"summary", "[--by model] [--sort score] [--format table|plain|json] <source1> [...]", "Print the comparison table", runSummary},
+	{"compare", "[--by model] <baseline> <candidate>", "Compare two sets of results group by group", runCompare},
+	{"check", "--min-score 0.7 [--by model] <source1> [...]", "Fail (exit 1) when a group misses a score or latency threshold", runCheck},
+	{"validate", "[--strict] <file1> [file2] [...]", "Check JSONL files against the result format, line by line", runValidate},
+	{"score", "--wasm [name=]scorer.wasm [-o file] <source1> [...]", "Add custom scores computed by WebAssembly scorer modules", runScore},
+	{"import", "--to <target> <source1> [...]", "Copy results into a JSONL file or postgres:// store, skipping ones it has", runImport},
+	{"export", "[--format pdf|parquet] [-o file] <source1> [...]", "Write a PDF report or a Parquet file of all results", runExport},
+	{"export-annotations", "[--format jsonl|csv] [-o file] <source1> [...]", "Write annotations joined with their results", runExportAnnotations},
+	{"archive", "--to <dir|url> [--max-age 30d] [--max-results n] <source1> [...]", "Move old results out of the sources into compressed archives", runArchive},
+	{"verify", "[--archive-to dir|url] <source1> [...]", "Check the integrity hashes of stored results", runVerify},
+	{"notify", "slack [--webhook url] [--baseline source] <source1> [...]", "Post a run summary to Slack", runNotify},
+}
+
+// usage prints the command overview for goevals help
+func usage() {
+	fmt.Println("Usage: goevals <command> [flags] [arguments]")
+	fmt.Println("       goevals [serve flags] <source1> [source2] [...]")
+	fmt.Println("\nCommands:")
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, c := range commands {
+		fmt.Fprintf(tw, "  %s\t%s\n", c.name, c.summary)
+	}
+	tw.Flush()
+	fmt.Println("\nRun 'goevals <command> -h' for the flags of a command")
+	fmt.Println("\nSources can be JSONL files, directories or wildcards (runs/*.jsonl), redis:// stream URLs or postgres:// database URLs")
+	fmt.Println("\nExamples:")
+	fmt.Println("  goevals evals.jsonl")
+	fmt.Println("  goevals serve --config dashboards.json")
+	fmt.Println("  goevals summary results.jsonl --by model --sort score")
+	fmt.Println("  goevals compare baseline.jsonl candidate.jsonl")
+	fmt.Println("  goevals check --min-score 0.7 results.jsonl")
+	fmt.Println("  goevals validate results.jsonl")
+	fmt.Println("  goevals score --wasm toxicity=toxicity.wasm -o scored.jsonl results.jsonl")
+	fmt.Println("  goevals import --to postgres://goevals@db/goevals \"runs/*.jsonl\"")
+	fmt.Println("  goevals export -o report.pdf evals.jsonl")
+	fmt.Println("  goevals notify slack --webhook $SLACK_WEBHOOK_URL results.jsonl")
+}
+
+// runCLI dispatches args (without the program name) to a command and returns the exit code
+// Arguments that don't start with a command name are served, so goevals evals.jsonl keeps working
+func runCLI(args []string) int {
+	if len(args) == 0 {
+		usage()
+		return 2
+	}
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		usage()
+		return 0
+	}
+	for _, c := range commands {
+		if c.name == args[0] {
+			return c.run(args[1:])
+		}
+	}
+	// A bare word that isn't a file is more likely a mistyped command than a new log
+	if !strings.HasPrefix(args[0], "-") && !strings.ContainsAny(args[0], "./\\*?[:") {
+		if _, err := os.Stat(args[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: unknown command %q, run 'goevals help' for the list\n", args[0])
+			return 2
+		}
+	}
+	return runServe(args)
+}
+
+// newFlagSet creates the flag set of a command with the standard usage text
+func newFlagSet(name, synopsis string, description ...string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Printf("Usage: goevals %s %s\n\n", name, synopsis)
+		for _, line := range description {
+			fmt.Println(line)
+		}
+		fmt.Println("\nFlags:")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// weightsFlag registers the --weights flag shared by commands that read results
+func weightsFlag(fs *flag.FlagSet) *string {
+	return fs.String("weights", "", "recompute combined scores from custom score `weights` (name:weight,...)")
+}
+
+// parseFlags parses fs allowing flags after the positional arguments
+// (goevals summary results.jsonl --by model) and returns the positional arguments
+func parseFlags(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		if args[0] == "--" {
+			return append(positional, args[1:]...)
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// commandResults loads the results of specs for a command, reweighted when weights is set
+func commandResults(specs []string, weights string) ([]EvalResult, error) {
+	parsed, err := parseWeights(weights)
+	if err != nil {
+		return nil, err
+	}
+	if err := loadEncryptionKey(); err != nil {
+		return nil, err
+	}
+	results, err := loadResults(specs)
+	if err != nil {
+		return nil, err
+	}
+	if parsed != nil {
+		results = reweight(results, parsed)
+	}
+	return results, nil
+}
+
+// writeTable prints lines (the first is the header) as an aligned table with a rule under the
+// header, or as plain tab separated lines for cut and awk
+func writeTable(w io.Writer, lines [][]string, format string) error {
+	if format == "plain" {
+		for _, line := range lines {
+			if _, err := fmt.Fprintln(w, strings.Join(line, "\t")); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, line := range lines {
+		fmt.Fprintln(tw, strings.Join(line, "\t"))
+		if i == 0 {
+			rule := make([]string, len(line))
+			for j, cell := range line {
+				rule[j] = strings.Repeat("-", len(cell))
+			}
+			fmt.Fprintln(tw, strings.Join(rule, "\t"))
+		}
+	}
+	return tw.Flush()
+}
+
+// writeJSON prints v as indented JSON
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"slices"
+	"testing"
+)
+
+func TestParseFlags(t *testing.T) {
+	fs := flag.NewFlagSet("summary", flag.ContinueOnError)
+	by := fs.String("by", "model", "")
+	args := parseFlags(fs, []string{"a.jsonl", "--by", "run", "b.jsonl", "--", "--c.jsonl"})
+	if *by != "run" || !slices.Equal(args, []string{"a.jsonl", "b.jsonl", "--c.jsonl"}) {
+		t.Errorf("by = %s, args = %v", *by, args)
+	}
+}
+
+func TestRunCLIRejectsUnknownCommands(t *testing.T) {
+	if code := runCLI([]string{"sumary"}); code != 2 {
+		t.Errorf("mistyped command exit code = %d, want 2", code)
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	lines := [][]string{{"model", "avg"}, {"gpt-4o", "0.900"}}
+	var buf bytes.Buffer
+	writeTable(&buf, lines, "table")
+	if want := "model   avg\n-----   ---\ngpt-4o  0.900\n"; buf.String() != want {
+		t.Errorf("table =\n%q\nwant\n%q", buf.String(), want)
+	}
+	buf.Reset()
+	writeTable(&buf, lines, "plain")
+	if want := "model\tavg\ngpt-4o\t0.900\n"; buf.String() != want {
+		t.Errorf("plain = %q, want %q", buf.String(), want)
+	}
+}
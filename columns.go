@@ -0,0 +1,60 @@
+package main
+
+import "strings"
+
+// DashboardColumn is one custom field or custom score column of the model comparison table
+type DashboardColumn struct {
+	ID     string // "field:<name>" or "score:<name>", used as data-col and in saved preferences
+	Name   string
+	Kind   string // "field" or "score"
+	Hidden bool   // Hidden by default, users can still enable it in the Columns panel
+}
+
+// defaultColumns is the server-side column order from --columns, empty = show everything
+var defaultColumns []string
+
+// parseColumns splits a --columns value ("accuracy,chunk_size,field:top_k")
+func parseColumns(value string) []string {
+	var columns []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			columns = append(columns, name)
+		}
+	}
+	return columns
+}
+
+// orderColumns lists custom fields then custom scores, or follows prefs when given
+// Names in prefs match either kind; use a "field:" or "score:" prefix when both exist.
+// Columns not listed in prefs are moved to the end and hidden
+func orderColumns(fields, scores, prefs []string) []DashboardColumn {
+	var all []DashboardColumn
+	for _, name := range fields {
+		all = append(all, DashboardColumn{ID: "field:" + name, Name: name, Kind: "field"})
+	}
+	for _, name := range scores {
+		all = append(all, DashboardColumn{ID: "score:" + name, Name: name, Kind: "score"})
+	}
+	if len(prefs) == 0 {
+		return all
+	}
+
+	used := make([]bool, len(all))
+	var ordered []DashboardColumn
+	for _, pref := range prefs {
+		for i, col := range all {
+			if !used[i] && (pref == col.ID || pref == col.Name) {
+				used[i] = true
+				ordered = append(ordered, col)
+				break
+			}
+		}
+	}
+	for i, col := range all {
+		if !used[i] {
+			col.Hidden = true
+			ordered = append(ordered, col)
+		}
+	}
+	return ordered
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOrderColumns(t *testing.T) {
+	fields := []string{"chunk_size", "accuracy", "top_k"}
+	scores := []string{"accuracy", "fluency"}
+
+	ids := func(cols []DashboardColumn) (shown, hidden []string) {
+		for _, col := range cols {
+			if col.Hidden {
+				hidden = append(hidden, col.ID)
+			} else {
+				shown = append(shown, col.ID)
+			}
+		}
+		return shown, hidden
+	}
+
+	shown, hidden := ids(orderColumns(fields, scores, nil))
+	if len(shown) != 5 || hidden != nil || shown[0] != "field:chunk_size" || shown[3] != "score:accuracy" {
+		t.Errorf("defaults: shown %v hidden %v", shown, hidden)
+	}
+
+	shown, hidden = ids(orderColumns(fields, scores, parseColumns(" fluency, score:accuracy ,chunk_size,,missing")))
+	if !reflect.DeepEqual(shown, []string{"score:fluency", "score:accuracy", "field:chunk_size"}) {
+		t.Errorf("shown = %v", shown)
+	}
+	if !reflect.DeepEqual(hidden, []string{"field:accuracy", "field:top_k"}) {
+		t.Errorf("hidden = %v", hidden)
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// ComparisonColumn is one model config's answer to a single test
+type ComparisonColumn struct {
+	Config string // Full config key (model + params)
+	Model  string // Just the model name (for display)
+	Result EvalResult
+	Runs   int // How many results this config has for the test (latest one is shown)
+}
+
+// Comparison holds everything the side-by-side view needs for one test_id
+type Comparison struct {
+	TestID       string
+	Question     string
+	Expected     string
+	Columns      []ComparisonColumn
+	CustomScores []string // Union of custom score names across the columns
+}
+
+// TestIDs returns all distinct test IDs in sorted order
+func TestIDs(results []EvalResult) []string {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, result := range results {
+		if result.TestID == "" || seen[result.TestID] {
+			continue
+		}
+		seen[result.TestID] = true
+		ids = append(ids, result.TestID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// BuildComparison collects each config's latest result for testID, one column per config
+// Columns are ordered by combined score (best first) so the spread is visible at a glance
+func BuildComparison(results []EvalResult, testID string) Comparison {
+	cmp := Comparison{TestID: testID}
+	columns := make(map[string]*ComparisonColumn)
+	scoreSet := make(map[string]bool)
+
+	for _, result := range results {
+		if result.TestID != testID {
+			continue
+		}
+		configKey := buildConfigKey(result)
+		col, exists := columns[configKey]
+		if !exists {
+			col = &ComparisonColumn{Config: configKey, Model: result.Model}
+			columns[configKey] = col
+		}
+		col.Runs++
+		if !exists || result.Timestamp >= col.Result.Timestamp {
+			col.Result = result
+		}
+
+		if cmp.Question == "" {
+			cmp.Question = result.Question
+		}
+		if cmp.Expected == "" {
+			cmp.Expected = result.Expected
+		}
+		for scoreType := range result.Scores.Custom {
+			scoreSet[scoreType] = true
+		}
+	}
+
+	for _, col := range columns {
+		cmp.Columns = append(cmp.Columns, *col)
+	}
+	sort.Slice(cmp.Columns, func(i, j int) bool {
+		a, b := cmp.Columns[i], cmp.Columns[j]
+		if a.Result.Scores.Combined != b.Result.Scores.Combined {
+			return a.Result.Scores.Combined > b.Result.Scores.Combined
+		}
+		return a.Config < b.Config
+	})
+
+	for scoreType := range scoreSet {
+		cmp.CustomScores = append(cmp.CustomScores, scoreType)
+	}
+	sort.Strings(cmp.CustomScores)
+
+	return cmp
+}
+
+// compareHandler renders the side-by-side view of every model's answer for one test_id
+func compareHandler(w http.ResponseWriter, r *http.Request) {
+	results, err := store.Query(r.Context(), Query{})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	results, ok := reweightRequest(w, r, results)
+	if !ok {
+		return
+	}
+
+	testID := r.URL.Query().Get("test_id")
+	data := struct {
+		Title      string
+		Subtitle   string
+		TestIDs    []string
+		Comparison Comparison
+	}{
+		Title:    "Compare Responses",
+		Subtitle: "Every model's answer to the same test, side by side",
+		TestIDs:  TestIDs(results),
+	}
+	if testID != "" {
+		data.Comparison = BuildComparison(results, testID)
+	}
+
+	renderPage(w, r, "compare", compareTemplate, data)
+}
+
+const compareTemplate = `
+{{ define "style" }}
+        .compare-grid {
+            display: grid;
+            grid-auto-flow: column;
+            grid-auto-columns: minmax(320px, 1fr);
+            gap: 1rem;
+            overflow-x: auto;
+            padding-bottom: 0.5rem;
+        }
+        .compare-col {
+            background: var(--bg-primary);
+            border: 1px solid var(--border-color);
+            border-radius: 12px;
+            padding: 1.25rem;
+            display: flex;
+            flex-direction: column;
+            gap: 1rem;
+        }
+        .compare-model {
+            font-weight: 700;
+            font-size: 1rem;
+        }
+        .compare-label {
+            font-size: 0.75rem;
+            font-weight: 600;
+            text-transform: uppercase;
+            letter-spacing: 0.05em;
+            color: var(--text-tertiary);
+            margin-bottom: 0.25rem;
+        }
+        .compare-response {
+            background: var(--bg-secondary);
+            border-radius: 8px;
+            padding: 0.75rem;
+            white-space: pre-wrap;
+            line-height: 1.5;
+            font-size: 0.875rem;
+        }
+        .compare-scores td {
+            padding: 0.35rem 0.5rem;
+        }
+{{ end }}
+
+{{ define "content" }}
+        <div class="panel">
+            <form method="get" action="/compare" style="display: flex; gap: 0.75rem; align-items: center;">
+                <label for="test-select" class="muted">Test ID</label>
+                <select id="test-select" name="test_id" onchange="this.form.submit()">
+                    <option value="">Select a test...</option>
+                    {{ range .TestIDs }}
+                    <option value="{{ . }}" {{ if eq . $.Comparison.TestID }}selected{{ end }}>{{ . }}</option>
+                    {{ end }}
+                </select>
+            </form>
+        </div>
+
+        {{ with .Comparison }}{{ if .TestID }}
+        <div class="panel">
+            <h2 class="mono">{{ .TestID }}</h2>
+            {{ if .Question }}
+            <div class="compare-label">Question</div>
+            <div class="compare-response" style="margin-bottom: 1rem;">{{ display .Question }}</div>
+            {{ end }}
+            {{ if .Expected }}
+            <div class="compare-label">Expected</div>
+            <div class="compare-response">{{ display .Expected }}</div>
+            {{ end }}
+        </div>
+
+        {{ if .Columns }}
+        <div class="compare-grid">
+            {{ range .Columns }}
+            <div class="compare-col">
+                <div>
+                    <div class="compare-model">{{ .Model }}</div>
+                    <div class="mono muted">{{ configLabel .Config }}</div>
+                </div>
+                <div style="display: flex; gap: 1rem; align-items: center;">
+                    <span class="score-badge {{ scoreClass .Result.Scores.Combined }}">{{ printf "%.2f" .Result.Scores.Combined }}</span>
+                    <span class="mono muted">{{ .Result.ResponseTimeMS }}ms</span>
+                    {{ if gt .Runs 1 }}<span class="muted" style="font-size: 0.75rem;">latest of {{ .Runs }} runs</span>{{ end }}
+                </div>
+                <div>
+                    <div class="compare-label">Response</div>
+                    <div class="compare-response">{{ if .Result.Response }}{{ display .Result.Response }}{{ else }}<em class="muted">No response recorded</em>{{ end }}</div>
+                </div>
+                {{ $scores := .Result.Scores.Custom }}
+                {{ if $.Comparison.CustomScores }}
+                <table class="compare-scores">
+                    {{ range $.Comparison.CustomScores }}
+                    <tr>
+                        <td class="muted">{{ . }}</td>
+                        <td>{{ if hasScore $scores . }}{{ $v := index $scores . }}<span class="score-badge {{ scoreClass $v }}">{{ printf "%.2f" $v }}</span>{{ else }}<span class="muted">-</span>{{ end }}</td>
+                    </tr>
+                    {{ end }}
+                </table>
+                {{ end }}
+            </div>
+            {{ end }}
+        </div>
+        {{ else }}
+        <div class="panel muted">No results found for this test.</div>
+        {{ end }}
+        {{ end }}{{ end }}
+{{ end }}`
@@ -0,0 +1,33 @@
+package main
+
+import "testing"
+
+func TestBuildComparison(t *testing.T) {
+	results := []EvalResult{
+		{Timestamp: "2025-01-01T10:00:00Z", Model: "a", TestID: "q1", Question: "2+2?", Response: "4", Scores: ScoreBreakdown{Combined: 0.9}},
+		{Timestamp: "2025-01-01T09:00:00Z", Model: "b", TestID: "q1", Response: "5 (old)", Scores: ScoreBreakdown{Combined: 0.1}},
+		{Timestamp: "2025-01-01T11:00:00Z", Model: "b", TestID: "q1", Response: "four", Scores: ScoreBreakdown{Combined: 0.6, Custom: map[string]float64{"fluency": 0.8}}},
+		{Timestamp: "2025-01-01T12:00:00Z", Model: "a", TestID: "q2", Scores: ScoreBreakdown{Combined: 0.2}},
+	}
+
+	cmp := BuildComparison(results, "q1")
+	if cmp.Question != "2+2?" {
+		t.Errorf("Question = %q", cmp.Question)
+	}
+	if len(cmp.Columns) != 2 {
+		t.Fatalf("got %d columns, want 2", len(cmp.Columns))
+	}
+	if cmp.Columns[0].Model != "a" || cmp.Columns[1].Model != "b" {
+		t.Errorf("columns not ordered by score: %s, %s", cmp.Columns[0].Model, cmp.Columns[1].Model)
+	}
+	if cmp.Columns[1].Result.Response != "four" || cmp.Columns[1].Runs != 2 {
+		t.Errorf("expected latest of 2 runs for b, got %q (%d runs)", cmp.Columns[1].Result.Response, cmp.Columns[1].Runs)
+	}
+	if len(cmp.CustomScores) != 1 || cmp.CustomScores[0] != "fluency" {
+		t.Errorf("CustomScores = %v", cmp.CustomScores)
+	}
+
+	if ids := TestIDs(results); len(ids) != 2 || ids[0] != "q1" || ids[1] != "q2" {
+		t.Errorf("TestIDs = %v", ids)
+	}
+}
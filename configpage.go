@@ -0,0 +1,342 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+)
+
+// configTrendRuns is how many of the latest runs the config page plots
+const configTrendRuns = 20
+
+// ConfigQuestion is one test of a config, over every result the config has for it
+type ConfigQuestion struct {
+	TestID    string  `json:"test_id"`
+	Question  string  `json:"question,omitempty"` // From the most recent result
+	Results   int     `json:"results"`
+	Errors    int     `json:"errors"`
+	AvgScore  float64 `json:"avg_score"`  // Over the results without an error
+	LastScore float64 `json:"last_score"` // Combined score of the most recent result
+	LastSeen  string  `json:"last_seen"`
+}
+
+// ConfigReport is everything the config page shows about one config key
+type ConfigReport struct {
+	Config    string           `json:"config"`
+	Label     string           `json:"label"` // See labelStore.Label
+	Summary   ConfigSummary    `json:"summary"`
+	Questions []ConfigQuestion `json:"questions"` // Lowest average score first
+	Runs      []RunStat        `json:"runs"`      // Most recent first, up to configTrendRuns
+}
+
+// BuildConfigReport summarizes the results of one config, ok is false when there are none
+func BuildConfigReport(results []EvalResult, config string, slas latencyBudgets, labels *labelStore) (ConfigReport, bool) {
+	report := ConfigReport{Config: config, Label: labels.Label(config), Questions: []ConfigQuestion{}}
+	var own []EvalResult
+	for _, result := range results {
+		if buildConfigKey(result) == config {
+			own = append(own, result)
+		}
+	}
+	if len(own) == 0 {
+		return report, false
+	}
+	for _, summary := range BuildStatsReport(own, slas, "pearson").Configs {
+		if summary.Config == config {
+			report.Summary = summary
+		}
+	}
+
+	type acc struct {
+		question ConfigQuestion
+		sum      float64
+	}
+	byTest := make(map[string]*acc)
+	for _, result := range own {
+		if result.TestID == "" {
+			continue
+		}
+		a := byTest[result.TestID]
+		if a == nil {
+			a = &acc{question: ConfigQuestion{TestID: result.TestID}}
+			byTest[result.TestID] = a
+		}
+		a.question.Results++
+		if result.Error != "" {
+			a.question.Errors++
+		} else {
+			a.sum += result.Scores.Combined
+		}
+		if a.question.LastSeen == "" || result.Timestamp >= a.question.LastSeen {
+			a.question.LastSeen, a.question.LastScore = result.Timestamp, result.Scores.Combined
+			if result.Question != "" {
+				a.question.Question = result.Question
+			}
+		}
+	}
+	for _, a := range byTest {
+		q := a.question
+		if scored := q.Results - q.Errors; scored > 0 {
+			q.AvgScore = a.sum / float64(scored)
+		}
+		report.Questions = append(report.Questions, q)
+	}
+	sort.Slice(report.Questions, func(i, j int) bool {
+		if report.Questions[i].AvgScore != report.Questions[j].AvgScore {
+			return report.Questions[i].AvgScore < report.Questions[j].AvgScore
+		}
+		return report.Questions[i].TestID < report.Questions[j].TestID
+	})
+
+	report.Runs = BuildRunStats(own)
+	report.Runs = report.Runs[:min(len(report.Runs), configTrendRuns)]
+	return report, true
+}
+
+// configRequest loads the report of the ?key= config, filtered like the dashboard, writing an
+// error and returning false when there is none
+func configRequest(w http.ResponseWriter, r *http.Request) (ConfigReport, bool) {
+	config := r.URL.Query().Get("key")
+	if config == "" {
+		http.Error(w, "key is required, e.g. /config?key=llama3|top_k=5", http.StatusBadRequest)
+		return ConfigReport{}, false
+	}
+	filter := dashboardFilter(r)
+	filter.ConfigKey = config
+	results, err := store.Query(r.Context(), filter)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return ConfigReport{}, false
+	}
+	results, ok := reweightRequest(w, r, results)
+	if !ok {
+		return ConfigReport{}, false
+	}
+	report, ok := BuildConfigReport(results, config, slasFor(r.Context()), labelsFor(r.Context()))
+	if !ok {
+		http.Error(w, fmt.Sprintf("No results for config %q", config), http.StatusNotFound)
+		return ConfigReport{}, false
+	}
+	return report, true
+}
+
+// configHandler renders the drill-down page of one config: /config?key=llama3|top_k=5
+func configHandler(w http.ResponseWriter, r *http.Request) {
+	report, ok := configRequest(w, r)
+	if !ok {
+		return
+	}
+
+	names := make(map[string]bool)
+	for name := range report.Summary.Scores {
+		names[name] = true
+	}
+	scores := sortedKeys(names, "combined")
+	histogram := func(name string, width, height int) BarChart {
+		d := report.Summary.Scores[name]
+		labels := make([]string, len(d.Histogram))
+		for i, n := range d.Histogram {
+			labels[i] = fmt.Sprintf("%.1f-%.1f: %d results", float64(i)/statsBins, float64(i+1)/statsBins, n)
+		}
+		return newBarChart(width, height, d.Histogram, labels)
+	}
+	histograms := make(map[string]BarChart, len(scores))
+	for _, name := range scores {
+		histograms[name] = histogram(name, 80, 20)
+	}
+
+	params := r.URL.Query()
+	params.Set("model", report.Config)
+	params.Del("key")
+	data := struct {
+		Title    string
+		Subtitle string
+		ConfigReport
+		Scores     []string
+		Histograms map[string]BarChart // Per score, for the table
+		Combined   BarChart            // Large histogram of combined
+		Trend      *RunTrend
+		TestsURL   string // The config's results on the tests page
+		TrendRuns  int
+	}{
+		Title:        report.Label,
+		Subtitle:     report.Config,
+		ConfigReport: report,
+		Scores:       scores,
+		Histograms:   histograms,
+		Combined:     histogram("combined", 400, 120),
+		Trend:        buildRunTrend(report.Runs),
+		TestsURL:     "/tests?" + params.Encode(),
+		TrendRuns:    configTrendRuns,
+	}
+	renderPage(w, r, "config", configTemplate, data)
+}
+
+// configAPIHandler returns the config page data as JSON
+func configAPIHandler(w http.ResponseWriter, r *http.Request) {
+	report, ok := configRequest(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("Error encoding JSON: %v", err)
+	}
+}
+
+const configTemplate = `
+{{ define "style" }}
+        .summary-grid {
+            display: grid;
+            grid-template-columns: repeat(auto-fit, minmax(150px, 1fr));
+            gap: 1rem;
+        }
+        .summary-grid .value {
+            font-size: 1.5rem;
+            font-weight: 700;
+        }
+        .summary-grid .label {
+            font-size: 0.75rem;
+            color: var(--text-tertiary);
+            text-transform: uppercase;
+            letter-spacing: 0.05em;
+        }
+        td.num {
+            font-family: monospace;
+            text-align: right;
+            white-space: nowrap;
+        }
+        td a.mono {
+            color: var(--accent);
+            text-decoration: none;
+        }
+        .question-text {
+            max-width: 32rem;
+            overflow: hidden;
+            text-overflow: ellipsis;
+            white-space: nowrap;
+        }
+{{ end }}
+
+{{ define "content" }}
+        {{ $s := .Summary }}
+        <div class="panel">
+            <div class="summary-grid">
+                <div><div class="label">Tests</div><div class="value">{{ human $s.Count }}</div></div>
+                {{ with index $s.Scores "combined" }}{{ if .Count }}
+                <div><div class="label">Avg Score</div><div class="value"><span class="{{ scoreClass .Mean }}">{{ printf "%.3f" .Mean }}</span></div>
+                    <div class="muted" title="95% bootstrap confidence interval">{{ printf "%.3f" $s.CILow }} – {{ printf "%.3f" $s.CIHigh }}</div></div>
+                {{ end }}{{ end }}
+                <div><div class="label">Errors</div><div class="value">{{ printf "%.1f" (percent $s.ErrorRate) }}%</div><div class="muted">{{ $s.Errors }} results</div></div>
+                {{ with $s.ResponseTimeMS }}
+                <div><div class="label">Latency p50 / p95</div><div class="value">{{ printf "%.0f" .P50 }}<span class="muted"> / {{ printf "%.0f" .P95 }}ms</span></div></div>
+                {{ end }}
+            </div>
+            {{ if $s.Fields }}
+            <p class="mono muted" style="margin-top: 1rem;">model={{ $s.Model }}{{ range $name, $value := $s.Fields }} · {{ $name }}={{ $value }}{{ end }}</p>
+            {{ end }}
+            <p style="margin-top: 1rem;"><a href="{{ .TestsURL }}">All {{ human $s.Count }} results →</a></p>
+        </div>
+
+        {{ if (index $s.Scores "combined").Count }}
+        <div class="panel chart">
+            <h2>Score Distribution</h2>
+            {{ template "bar-chart" .Combined }}
+            <p class="muted" style="font-size: 0.8125rem;">Combined score in bins of 0.1, from 0 to 1.</p>
+        </div>
+        {{ end }}
+
+        {{ if .Scores }}
+        <div class="panel">
+            <h2>Scores</h2>
+            <div style="overflow-x: auto;">
+            <table>
+                <thead>
+                    <tr>
+                        <th>Score</th>
+                        <th>Mean</th>
+                        <th>Std</th>
+                        <th>Min</th>
+                        <th>p25</th>
+                        <th>Median</th>
+                        <th>p75</th>
+                        <th>Max</th>
+                        <th>Distribution</th>
+                    </tr>
+                </thead>
+                <tbody>
+                    {{ range .Scores }}
+                    {{ $d := index $s.Scores . }}
+                    <tr>
+                        <td class="mono">{{ . }}</td>
+                        <td><span class="score-badge {{ scoreClass $d.Mean }}">{{ printf "%.3f" $d.Mean }}</span></td>
+                        <td class="num">{{ printf "%.3f" $d.Std }}</td>
+                        <td class="num">{{ printf "%.2f" $d.Min }}</td>
+                        <td class="num">{{ printf "%.2f" $d.P25 }}</td>
+                        <td class="num">{{ printf "%.2f" $d.P50 }}</td>
+                        <td class="num">{{ printf "%.2f" $d.P75 }}</td>
+                        <td class="num">{{ printf "%.2f" $d.Max }}</td>
+                        <td>{{ template "bar-chart" index $.Histograms . }}</td>
+                    </tr>
+                    {{ end }}
+                </tbody>
+            </table>
+            </div>
+        </div>
+        {{ end }}
+
+        {{ with .Trend }}
+        <div class="panel chart">
+            <h2>Recent Trend</h2>
+            <svg viewBox="0 0 {{ .Frame.Width }} {{ .Frame.Height }}" role="img" aria-label="average score by run">
+                {{ template "chart-axes" .Frame }}
+                {{ range .Series }}
+                <g class="{{ .Color }}">
+                    <polyline points="{{ .Path }}" fill="none" stroke="currentColor" stroke-width="2"/>
+                    {{ range .Points }}
+                    <circle cx="{{ .X }}" cy="{{ .Y }}" r="4" fill="currentColor"><title>{{ .Run }}: {{ printf "%.3f" .Score }}</title></circle>
+                    {{ end }}
+                </g>
+                {{ end }}
+            </svg>
+            <p class="muted" style="font-size: 0.8125rem;">Average combined score per run, over the last {{ $.TrendRuns }} runs at most.</p>
+        </div>
+        {{ end }}
+
+        <div class="panel">
+            <h2>Questions</h2>
+            {{ if .Questions }}
+            <div style="overflow-x: auto;">
+            <table>
+                <thead>
+                    <tr>
+                        <th>Test</th>
+                        <th>Question</th>
+                        <th>Results</th>
+                        <th>Avg Score</th>
+                        <th>Last Score</th>
+                        <th>Last Seen</th>
+                    </tr>
+                </thead>
+                <tbody>
+                    {{ range .Questions }}
+                    <tr>
+                        <td><a class="mono" href="/tests/{{ .TestID }}">{{ .TestID }}</a></td>
+                        <td class="question-text" title="{{ display .Question }}">{{ display .Question }}</td>
+                        <td class="num">{{ .Results }}{{ if .Errors }} <span class="muted" title="Errored results, left out of the average">({{ .Errors }} errors)</span>{{ end }}</td>
+                        <td>{{ if lt .Errors .Results }}<span class="score-badge {{ scoreClass .AvgScore }}">{{ printf "%.3f" .AvgScore }}</span>{{ else }}-{{ end }}</td>
+                        <td class="num">{{ printf "%.2f" .LastScore }}</td>
+                        <td class="mono muted">{{ .LastSeen }}</td>
+                    </tr>
+                    {{ end }}
+                </tbody>
+            </table>
+            </div>
+            <p class="muted" style="margin-top: 1rem; font-size: 0.8125rem;">Weakest questions first.</p>
+            {{ else }}
+            <p class="muted">No results of this config have a test_id.</p>
+            {{ end }}
+        </div>
+{{ end }}`
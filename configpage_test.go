@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBuildConfigReport(t *testing.T) {
+	results := []EvalResult{
+		{Timestamp: "2025-01-01T10:00:00Z", Model: "a", TestID: "q1", Question: "old", Scores: ScoreBreakdown{Combined: 0.9}, CustomFields: map[string]any{"k": 1.0}, Metadata: map[string]any{"run_id": "r1"}},
+		{Timestamp: "2025-01-02T10:00:00Z", Model: "a", TestID: "q1", Question: "new", Scores: ScoreBreakdown{Combined: 0.5}, CustomFields: map[string]any{"k": 1.0}, Metadata: map[string]any{"run_id": "r2"}},
+		{Timestamp: "2025-01-02T10:00:00Z", Model: "a", TestID: "q2", Error: "timeout", CustomFields: map[string]any{"k": 1.0}, Metadata: map[string]any{"run_id": "r2"}},
+		{Timestamp: "2025-01-02T10:00:00Z", Model: "a", TestID: "q3", Scores: ScoreBreakdown{Combined: 0.2}, CustomFields: map[string]any{"k": 2.0}},
+	}
+
+	report, ok := BuildConfigReport(results, "a|k=1", nil, nil)
+	if !ok {
+		t.Fatal("no report")
+	}
+	if report.Summary.Count != 3 || report.Summary.Errors != 1 || report.Summary.Scores["combined"].Mean != 0.7 {
+		t.Errorf("summary = %+v", report.Summary)
+	}
+	// The errored-only question has no average and sorts first
+	if len(report.Questions) != 2 || report.Questions[0].TestID != "q2" || report.Questions[0].Errors != 1 {
+		t.Fatalf("questions = %+v", report.Questions)
+	}
+	q := report.Questions[1]
+	if q.Results != 2 || q.AvgScore != 0.7 || q.LastScore != 0.5 || q.Question != "new" {
+		t.Errorf("q1 = %+v", q)
+	}
+	if len(report.Runs) != 2 || report.Runs[0].RunID != "r2" {
+		t.Errorf("runs = %+v", report.Runs)
+	}
+
+	if _, ok := BuildConfigReport(results, "b", nil, nil); ok {
+		t.Error("report for a config without results")
+	}
+}
+
+func TestConfigHandler(t *testing.T) {
+	withStore(t, `{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"q1","question":"What?","scores":{"combined":0.8,"accuracy":1},"top_k":5}
+{"timestamp":"2025-01-01T10:01:00Z","model":"a","test_id":"q1","scores":{"combined":0.6},"top_k":10}`)
+
+	rec := httptest.NewRecorder()
+	configHandler(rec, httptest.NewRequest(http.MethodGet, "/config?key=a%7Ctop_k%3D5", nil))
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, "a · tk5") || !strings.Contains(body, "What?") || !strings.Contains(body, `href="/tests?model=a%7Ctop_k%3D5"`) {
+		t.Errorf("page = %d %s", rec.Code, body)
+	}
+
+	rec = httptest.NewRecorder()
+	configAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/api/config?key=a%7Ctop_k%3D10", nil))
+	var report ConfigReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil || report.Summary.Count != 1 || report.Summary.Scores["combined"].Mean != 0.6 {
+		t.Errorf("api = %s (%v)", rec.Body, err)
+	}
+
+	for target, want := range map[string]int{"/config": http.StatusBadRequest, "/config?key=b": http.StatusNotFound} {
+		rec = httptest.NewRecorder()
+		configHandler(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != want {
+			t.Errorf("%s = %d, want %d", target, rec.Code, want)
+		}
+	}
+}
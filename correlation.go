@@ -0,0 +1,252 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// minCorrelationPairs is the number of paired observations needed before a coefficient is shown
+const minCorrelationPairs = 3
+
+// CorrelationCell is the coefficient between two variables
+type CorrelationCell struct {
+	R     float64 // NaN when there are too few pairs or no variance
+	Pairs int     // Results where both variables are present
+}
+
+// CorrelationMatrix holds pairwise correlations between scores and numeric custom fields
+type CorrelationMatrix struct {
+	Method    string   // "pearson" or "spearman"
+	Variables []string // Score names first ("combined" + custom scores), then numeric fields
+	NumScores int      // How many of Variables are scores
+	Cells     [][]CorrelationCell
+}
+
+// correlationVariables extracts every numeric variable from a result
+// Scores keep their name, numeric custom fields are prefixed to avoid collisions
+func correlationVariables(result EvalResult) map[string]float64 {
+	vars := map[string]float64{"combined": result.Scores.Combined}
+	for name, value := range result.Scores.Custom {
+		vars[name] = value
+	}
+	for name, value := range result.Fields() {
+		if v, ok := value.(float64); ok {
+			vars["field:"+name] = v
+		}
+	}
+	return vars
+}
+
+// BuildCorrelationMatrix computes pairwise correlations using complete pairs only
+func BuildCorrelationMatrix(results []EvalResult, method string) CorrelationMatrix {
+	if method != "spearman" {
+		method = "pearson"
+	}
+
+	results = scored(results)
+	rows := make([]map[string]float64, len(results))
+	scoreSet := make(map[string]bool)
+	fieldSet := make(map[string]bool)
+	for i, result := range results {
+		rows[i] = correlationVariables(result)
+		for name := range rows[i] {
+			if strings.HasPrefix(name, "field:") {
+				fieldSet[name] = true
+			} else {
+				scoreSet[name] = true
+			}
+		}
+	}
+
+	m := CorrelationMatrix{Method: method}
+	m.Variables = append(sortedKeys(scoreSet, "combined"), sortedKeys(fieldSet, "")...)
+	m.NumScores = len(scoreSet)
+
+	m.Cells = make([][]CorrelationCell, len(m.Variables))
+	for i := range m.Cells {
+		m.Cells[i] = make([]CorrelationCell, len(m.Variables))
+	}
+	for i, a := range m.Variables {
+		for j := i; j < len(m.Variables); j++ {
+			b := m.Variables[j]
+			var xs, ys []float64
+			for _, row := range rows {
+				x, okA := row[a]
+				y, okB := row[b]
+				if okA && okB {
+					xs = append(xs, x)
+					ys = append(ys, y)
+				}
+			}
+
+			cell := CorrelationCell{R: math.NaN(), Pairs: len(xs)}
+			if len(xs) >= minCorrelationPairs {
+				if method == "spearman" {
+					cell.R = pearson(ranks(xs), ranks(ys))
+				} else {
+					cell.R = pearson(xs, ys)
+				}
+			}
+			m.Cells[i][j] = cell
+			m.Cells[j][i] = cell
+		}
+	}
+
+	return m
+}
+
+// sortedKeys returns set keys in order, with first (if present) moved to the front
+func sortedKeys(set map[string]bool, first string) []string {
+	var keys []string
+	for key := range set {
+		if key != first {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if set[first] {
+		keys = append([]string{first}, keys...)
+	}
+	return keys
+}
+
+// pearson returns the Pearson correlation coefficient, NaN if either side is constant
+func pearson(xs, ys []float64) float64 {
+	mx, my := mean(xs), mean(ys)
+	var cov, vx, vy float64
+	for i := range xs {
+		dx, dy := xs[i]-mx, ys[i]-my
+		cov += dx * dy
+		vx += dx * dx
+		vy += dy * dy
+	}
+	if vx == 0 || vy == 0 {
+		return math.NaN()
+	}
+	return cov / math.Sqrt(vx*vy)
+}
+
+// ranks converts values to 1-based ranks, ties get their average rank
+func ranks(values []float64) []float64 {
+	idx := make([]int, len(values))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(a, b int) bool { return values[idx[a]] < values[idx[b]] })
+
+	out := make([]float64, len(values))
+	for i := 0; i < len(idx); {
+		j := i
+		for j+1 < len(idx) && values[idx[j+1]] == values[idx[i]] {
+			j++
+		}
+		avg := float64(i+j)/2 + 1
+		for k := i; k <= j; k++ {
+			out[idx[k]] = avg
+		}
+		i = j + 1
+	}
+	return out
+}
+
+// correlationsHandler renders the correlation heatmap
+func correlationsHandler(w http.ResponseWriter, r *http.Request) {
+	results, err := store.Query(r.Context(), Query{})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Title    string
+		Subtitle string
+		MinPairs int
+		CorrelationMatrix
+	}{
+		Title:             "Correlations",
+		Subtitle:          "How custom scores and numeric config fields move together",
+		MinPairs:          minCorrelationPairs,
+		CorrelationMatrix: BuildCorrelationMatrix(results, r.URL.Query().Get("method")),
+	}
+
+	renderPage(w, r, "correlations", correlationsTemplate, data)
+}
+
+// heatColor maps a coefficient to a blue (positive) / red (negative) background
+func heatColor(r float64) template.CSS {
+	if math.IsNaN(r) {
+		return "transparent"
+	}
+	if r >= 0 {
+		return template.CSS(fmt.Sprintf("rgba(59, 130, 246, %.2f)", r*0.85))
+	}
+	return template.CSS(fmt.Sprintf("rgba(239, 68, 68, %.2f)", -r*0.85))
+}
+
+const correlationsTemplate = `
+{{ define "style" }}
+        .heatmap th {
+            white-space: nowrap;
+        }
+        .heatmap td {
+            text-align: center;
+            font-family: monospace;
+            min-width: 4.5rem;
+        }
+        .heatmap td.var-name {
+            text-align: left;
+            font-family: inherit;
+            font-weight: 600;
+            white-space: nowrap;
+        }
+        .method-links a {
+            color: var(--accent);
+            text-decoration: none;
+            margin-right: 1rem;
+        }
+        .method-links a.active {
+            font-weight: 700;
+            text-decoration: underline;
+        }
+{{ end }}
+
+{{ define "content" }}
+        <div class="panel" style="overflow-x: auto;">
+            <div class="method-links" style="margin-bottom: 1rem;">
+                <a href="?method=pearson" class="{{ if eq .Method "pearson" }}active{{ end }}">Pearson</a>
+                <a href="?method=spearman" class="{{ if eq .Method "spearman" }}active{{ end }}">Spearman (rank)</a>
+            </div>
+            {{ if gt (len .Variables) 1 }}
+            <table class="heatmap">
+                <thead>
+                    <tr>
+                        <th></th>
+                        {{ range .Variables }}<th>{{ . }}</th>{{ end }}
+                    </tr>
+                </thead>
+                <tbody>
+                    {{ range $i, $name := .Variables }}
+                    <tr>
+                        <td class="var-name">{{ $name }}</td>
+                        {{ range $j, $cell := index $.Cells $i }}
+                        <td style="background: {{ heatColor $cell.R }};" title="{{ $name }} vs {{ index $.Variables $j }}: {{ $cell.Pairs }} pairs">
+                            {{ if isNaN $cell.R }}<span class="muted">–</span>{{ else }}{{ printf "%.2f" $cell.R }}{{ end }}
+                        </td>
+                        {{ end }}
+                    </tr>
+                    {{ end }}
+                </tbody>
+            </table>
+            <p class="muted" style="margin-top: 1rem; font-size: 0.8125rem;">
+                Blue = positive, red = negative correlation. Coefficients need at least {{ .MinPairs }} results with both values and some variance on each side.
+                Numeric custom fields are prefixed with <span class="mono">field:</span>.
+            </p>
+            {{ else }}
+            <p class="muted">Need at least two numeric scores or fields to correlate.</p>
+            {{ end }}
+        </div>
+{{ end }}`
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPearsonAndSpearman(t *testing.T) {
+	xs := []float64{1, 2, 3, 4, 5}
+	if r := pearson(xs, []float64{2, 4, 6, 8, 10}); math.Abs(r-1) > 1e-9 {
+		t.Errorf("perfect positive = %f", r)
+	}
+	if r := pearson(xs, []float64{5, 4, 3, 2, 1}); math.Abs(r+1) > 1e-9 {
+		t.Errorf("perfect negative = %f", r)
+	}
+	if r := pearson(xs, []float64{3, 3, 3, 3, 3}); !math.IsNaN(r) {
+		t.Errorf("constant side should be NaN, got %f", r)
+	}
+
+	// Monotonic but non-linear: Spearman is exactly 1
+	ys := []float64{1, 8, 27, 64, 125}
+	if r := pearson(ranks(xs), ranks(ys)); math.Abs(r-1) > 1e-9 {
+		t.Errorf("spearman = %f", r)
+	}
+
+	got := ranks([]float64{10, 20, 20, 30})
+	want := []float64{1, 2.5, 2.5, 4}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ranks = %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestBuildCorrelationMatrix(t *testing.T) {
+	var results []EvalResult
+	for i, chunk := range []float64{100, 200, 300, 400} {
+		results = append(results, EvalResult{
+			Scores:       ScoreBreakdown{Combined: 0.2 * float64(i+1), Custom: map[string]float64{"faithfulness": 1 - 0.2*float64(i)}},
+			CustomFields: map[string]any{"chunk_size": chunk, "embedding_model": "nomic"},
+		})
+	}
+
+	m := BuildCorrelationMatrix(results, "spearman")
+	want := []string{"combined", "faithfulness", "field:chunk_size"}
+	if len(m.Variables) != len(want) || m.NumScores != 2 {
+		t.Fatalf("variables = %v (scores %d)", m.Variables, m.NumScores)
+	}
+	for i := range want {
+		if m.Variables[i] != want[i] {
+			t.Fatalf("variables = %v, want %v", m.Variables, want)
+		}
+	}
+	if r := m.Cells[0][2].R; math.Abs(r-1) > 1e-9 {
+		t.Errorf("combined vs chunk_size = %f", r)
+	}
+	if r := m.Cells[1][0].R; math.Abs(r+1) > 1e-9 || m.Cells[1][0].Pairs != 4 {
+		t.Errorf("faithfulness vs combined = %+v", m.Cells[1][0])
+	}
+}
@@ -0,0 +1,401 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+// CoverageCell is what one row (model or config) has for one test
+type CoverageCell struct {
+	Results int `json:"results"`
+	Errors  int `json:"errors"` // Results == Errors > 0: only errored, so left out of the scores
+}
+
+// CoverageRow is one model (or config) across the current page of tests
+type CoverageRow struct {
+	Label    string         `json:"label"`
+	Covered  int            `json:"covered"`  // Tests with a scored result, over all tests
+	Errored  int            `json:"errored"`  // Tests with only errored results
+	Missing  int            `json:"missing"`  // Tests without any result
+	Coverage float64        `json:"coverage"` // Covered / tests
+	Cells    []CoverageCell `json:"cells"`
+}
+
+// CoverageMatrix is a models × test_ids matrix of which tests each row was scored on, so averages
+// over different subsets of the tests are spotted before they are compared
+type CoverageMatrix struct {
+	RowsBy     string        `json:"rows_by"`  // model or config
+	Gaps       bool          `json:"gaps"`     // Only tests some row lacks
+	Tests      int           `json:"tests"`    // Every test: test_ids of results and the --dataset cases
+	Complete   int           `json:"complete"` // Tests every row has a scored result for
+	TestIDs    []string      `json:"test_ids"` // Columns on this page
+	Rows       []CoverageRow `json:"rows"`     // Least covered first
+	Page       int           `json:"page"`
+	Pages      int           `json:"pages"`
+	PerPage    int           `json:"per_page"`
+	TotalTests int           `json:"total_tests"` // Columns over all pages, less than Tests with Gaps
+}
+
+// Coverage paging limits, cells are small so a page holds more tests than the heatmap's
+const (
+	defaultCoveragePerPage = 100
+	maxCoveragePerPage     = 1000
+)
+
+// BuildCoverage counts the results of every row on every test and returns one page of columns
+// Results without a test_id are skipped; with gaps, only tests some row has no scored result for
+// are columns
+func BuildCoverage(results []EvalResult, rowsBy string, gaps bool, page, perPage int) CoverageMatrix {
+	cm := CoverageMatrix{RowsBy: rowsBy, Gaps: gaps, PerPage: perPage}
+	cells := make(map[string]map[string]*CoverageCell) // row -> test -> cell
+	tests := make(map[string]bool)
+	if goldenSet != nil {
+		for _, c := range goldenSet.Cases {
+			tests[c.TestID] = true
+		}
+	}
+	for _, result := range results {
+		if result.TestID == "" {
+			continue
+		}
+		row := result.Model
+		if rowsBy == "config" {
+			row = buildConfigKey(result)
+		}
+		if cells[row] == nil {
+			cells[row] = make(map[string]*CoverageCell)
+		}
+		cell := cells[row][result.TestID]
+		if cell == nil {
+			cell = &CoverageCell{}
+			cells[row][result.TestID] = cell
+		}
+		cell.Results++
+		if result.Error != "" {
+			cell.Errors++
+		}
+		tests[result.TestID] = true
+	}
+	cm.Tests = len(tests)
+
+	scored := func(cell *CoverageCell) bool { return cell != nil && cell.Results > cell.Errors }
+	var testIDs []string
+	for _, id := range sortedKeys(tests, "") {
+		complete := len(cells) > 0
+		for _, row := range cells {
+			complete = complete && scored(row[id])
+		}
+		if complete {
+			cm.Complete++
+		}
+		if !gaps || !complete {
+			testIDs = append(testIDs, id)
+		}
+	}
+
+	cm.TotalTests = len(testIDs)
+	cm.Pages = max(1, (len(testIDs)+perPage-1)/perPage)
+	cm.Page = min(max(page, 1), cm.Pages)
+	start := (cm.Page - 1) * perPage
+	cm.TestIDs = testIDs[min(start, len(testIDs)):min(start+perPage, len(testIDs))]
+
+	for label, row := range cells {
+		r := CoverageRow{Label: label}
+		for id := range tests {
+			switch cell := row[id]; {
+			case cell == nil:
+				r.Missing++
+			case scored(cell):
+				r.Covered++
+			default:
+				r.Errored++
+			}
+		}
+		r.Coverage = float64(r.Covered) / float64(cm.Tests)
+		for _, id := range cm.TestIDs {
+			cell := CoverageCell{}
+			if c := row[id]; c != nil {
+				cell = *c
+			}
+			r.Cells = append(r.Cells, cell)
+		}
+		cm.Rows = append(cm.Rows, r)
+	}
+	sort.Slice(cm.Rows, func(i, j int) bool {
+		if cm.Rows[i].Covered != cm.Rows[j].Covered {
+			return cm.Rows[i].Covered < cm.Rows[j].Covered
+		}
+		return cm.Rows[i].Label < cm.Rows[j].Label
+	})
+	return cm
+}
+
+// maxCoverageGaps is how many incomplete configs the dashboard banner names
+const maxCoverageGaps = 5
+
+// CoverageGaps is the dashboard banner for configs scored on fewer tests than the others
+type CoverageGaps struct {
+	Rows  []CoverageRow // Least covered first, at most maxCoverageGaps
+	More  int           // Incomplete configs not listed
+	Tests int
+}
+
+// coverageGaps lists the configs without a scored result for every test, nothing when all
+// configs were scored on the same tests
+func coverageGaps(results []EvalResult) CoverageGaps {
+	cm := BuildCoverage(results, "config", true, 1, 1)
+	gaps := CoverageGaps{Tests: cm.Tests}
+	for _, row := range cm.Rows {
+		if row.Covered == cm.Tests {
+			continue
+		}
+		row.Cells = nil
+		if len(gaps.Rows) < maxCoverageGaps {
+			gaps.Rows = append(gaps.Rows, row)
+		} else {
+			gaps.More++
+		}
+	}
+	return gaps
+}
+
+// coverageParams reads and validates the coverage query parameters
+func coverageParams(params url.Values) (rowsBy string, gaps bool, page, perPage int, err error) {
+	rowsBy = params.Get("rows")
+	if rowsBy == "" {
+		rowsBy = "model"
+	}
+	if rowsBy != "model" && rowsBy != "config" {
+		return "", false, 0, 0, fmt.Errorf("rows must be model or config")
+	}
+	gaps = params.Get("gaps") == "1"
+	page, perPage = 1, defaultCoveragePerPage
+	if s := params.Get("page"); s != "" {
+		if page, err = strconv.Atoi(s); err != nil || page < 1 {
+			return "", false, 0, 0, fmt.Errorf("page must be a positive number")
+		}
+	}
+	if s := params.Get("per_page"); s != "" {
+		if perPage, err = strconv.Atoi(s); err != nil || perPage < 1 || perPage > maxCoveragePerPage {
+			return "", false, 0, 0, fmt.Errorf("per_page must be between 1 and %d", maxCoveragePerPage)
+		}
+	}
+	return rowsBy, gaps, page, perPage, nil
+}
+
+// coverageURL returns the coverage URL with one parameter changed
+func coverageURL(params url.Values, key, value string) string {
+	link := url.Values{}
+	for k, v := range params {
+		link[k] = v
+	}
+	link.Set(key, value)
+	return "/coverage?" + link.Encode()
+}
+
+// coverageHandler renders the model × test coverage matrix
+func coverageHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	rowsBy, gaps, page, perPage, err := coverageParams(params)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	results, err := store.Query(r.Context(), dashboardFilter(r))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	cm := BuildCoverage(results, rowsBy, gaps, page, perPage)
+	data := struct {
+		Title    string
+		Subtitle string
+		CoverageMatrix
+		Dataset bool
+		PrevURL string
+		NextURL string
+		Params  url.Values
+	}{
+		Title:          "Test Coverage",
+		Subtitle:       "Models × tests: were the averages you compare computed over the same tests?",
+		CoverageMatrix: cm,
+		Dataset:        goldenSet != nil,
+		Params:         params,
+	}
+	if cm.Page > 1 {
+		data.PrevURL = coverageURL(params, "page", strconv.Itoa(cm.Page-1))
+	}
+	if cm.Page < cm.Pages {
+		data.NextURL = coverageURL(params, "page", strconv.Itoa(cm.Page+1))
+	}
+
+	renderPage(w, r, "coverage", coverageTemplate, data)
+}
+
+// coverageAPIHandler returns one page of the coverage matrix as JSON
+func coverageAPIHandler(w http.ResponseWriter, r *http.Request) {
+	rowsBy, gaps, page, perPage, err := coverageParams(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	results, err := store.Query(r.Context(), dashboardFilter(r))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(BuildCoverage(results, rowsBy, gaps, page, perPage)); err != nil {
+		log.Printf("Error encoding JSON: %v", err)
+	}
+}
+
+const coverageTemplate = `
+{{ define "style" }}
+        .coverage-controls {
+            display: flex;
+            flex-wrap: wrap;
+            gap: 0.75rem;
+            align-items: center;
+        }
+        .coverage-controls a {
+            color: var(--accent);
+            text-decoration: none;
+        }
+        .coverage-matrix {
+            border-collapse: separate;
+            border-spacing: 1px;
+            width: auto;
+        }
+        .coverage-matrix td, .coverage-matrix th {
+            padding: 0;
+            border: none;
+        }
+        .coverage-matrix .cell {
+            width: 12px;
+            height: 12px;
+        }
+        .coverage-matrix .cell a {
+            display: block;
+            width: 100%;
+            height: 100%;
+        }
+        .coverage-matrix .ran {
+            background: var(--success);
+        }
+        .coverage-matrix .errored {
+            background: var(--warning);
+        }
+        .coverage-matrix .missing {
+            background: var(--bg-tertiary);
+            outline: 1px solid var(--error);
+            outline-offset: -1px;
+        }
+        .coverage-matrix .row-label {
+            padding-right: 0.75rem;
+            white-space: nowrap;
+            font-size: 0.8125rem;
+            text-align: right;
+        }
+        .coverage-matrix .col-label {
+            writing-mode: vertical-rl;
+            transform: rotate(180deg);
+            font-family: monospace;
+            font-size: 0.6875rem;
+            font-weight: 400;
+            text-transform: none;
+            letter-spacing: 0;
+            background: none;
+            padding: 0.25rem 0;
+            white-space: nowrap;
+        }
+        .coverage-matrix .share {
+            font-family: monospace;
+            font-size: 0.6875rem;
+            padding: 0 0.5rem;
+            white-space: nowrap;
+        }
+        .coverage-legend span {
+            display: inline-block;
+            width: 10px;
+            height: 10px;
+            margin: 0 0.25rem 0 0.75rem;
+            vertical-align: middle;
+        }
+{{ end }}
+
+{{ define "content" }}
+        <div class="panel">
+            <form method="get" action="/coverage" class="coverage-controls">
+                <label class="muted" for="rows">Rows</label>
+                <select id="rows" name="rows" onchange="this.form.submit()">
+                    <option value="model" {{ if eq .RowsBy "model" }}selected{{ end }}>Models</option>
+                    <option value="config" {{ if eq .RowsBy "config" }}selected{{ end }}>Configs</option>
+                </select>
+                <label class="muted"><input type="checkbox" name="gaps" value="1" {{ if .Gaps }}checked{{ end }} onchange="this.form.submit()"> Only tests with gaps</label>
+                {{ with .Params.Get "model" }}<input type="hidden" name="model" value="{{ . }}">{{ end }}
+                {{ with .Params.Get "run_id" }}<input type="hidden" name="run_id" value="{{ . }}">{{ end }}
+                {{ with .Params.Get "source" }}<input type="hidden" name="source" value="{{ . }}">{{ end }}
+                {{ with .Params.Get "dataset" }}<input type="hidden" name="dataset" value="{{ . }}">{{ end }}
+                {{ with .Params.Get "per_page" }}<input type="hidden" name="per_page" value="{{ . }}">{{ end }}
+                <span class="muted" style="margin-left: auto;">
+                    {{ .Complete }} of {{ .Tests }} tests covered by every {{ .RowsBy }}{{ if .Dataset }} (the dataset and the results){{ end }}
+                </span>
+                <span class="muted">
+                    {{ if .PrevURL }}<a href="{{ .PrevURL }}">← Prev</a>{{ end }}
+                    Page {{ .Page }} of {{ .Pages }}
+                    {{ if .NextURL }}<a href="{{ .NextURL }}">Next →</a>{{ end }}
+                </span>
+            </form>
+        </div>
+
+        <div class="panel" style="overflow-x: auto;">
+            {{ if and .Rows .TestIDs }}
+            <table class="coverage-matrix">
+                <thead>
+                    <tr>
+                        <th></th>
+                        <th class="col-label">covered</th>
+                        {{ range .TestIDs }}<th class="col-label" title="{{ . }}">{{ . }}</th>{{ end }}
+                    </tr>
+                </thead>
+                <tbody>
+                    {{ range .Rows }}
+                    {{ $row := . }}
+                    <tr>
+                        <td class="row-label mono">{{ configLabel .Label }}</td>
+                        <td class="share {{ if eq .Covered $.Tests }}score-good{{ else }}score-poor{{ end }}" title="{{ .Covered }} scored, {{ .Errored }} errored, {{ .Missing }} missing">{{ .Covered }}/{{ $.Tests }}</td>
+                        {{ range $i, $cell := .Cells }}
+                        {{ $test := index $.TestIDs $i }}
+                        {{ if not $cell.Results }}
+                        <td class="cell missing" title="{{ $row.Label }} · {{ $test }}: no result"></td>
+                        {{ else if eq $cell.Results $cell.Errors }}
+                        <td class="cell errored" title="{{ $row.Label }} · {{ $test }}: {{ $cell.Errors }} errored result{{ if gt $cell.Errors 1 }}s{{ end }}, no score"><a href="/compare?test_id={{ $test }}"></a></td>
+                        {{ else }}
+                        <td class="cell ran" title="{{ $row.Label }} · {{ $test }}: {{ $cell.Results }} result{{ if gt $cell.Results 1 }}s{{ end }}"><a href="/compare?test_id={{ $test }}"></a></td>
+                        {{ end }}
+                        {{ end }}
+                    </tr>
+                    {{ end }}
+                </tbody>
+            </table>
+            <p class="muted coverage-legend" style="margin-top: 1rem; font-size: 0.8125rem;">
+                <span style="background: var(--success);"></span>scored
+                <span style="background: var(--warning);"></span>only errors
+                <span style="background: var(--bg-tertiary); outline: 1px solid var(--error);"></span>missing.
+                A row with gaps averages fewer tests than the others, so its scores aren't directly comparable.
+            </p>
+            {{ else if .Rows }}
+            <p class="muted">Every {{ .RowsBy }} has a scored result for all {{ .Tests }} tests.</p>
+            {{ else }}
+            <p class="muted">No results with a test_id.</p>
+            {{ end }}
+        </div>
+{{ end }}`
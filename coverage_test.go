@@ -0,0 +1,92 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestBuildCoverage(t *testing.T) {
+	results := []EvalResult{
+		{Model: "a", TestID: "t1"},
+		{Model: "a", TestID: "t1"},
+		{Model: "a", TestID: "t2"},
+		{Model: "a", TestID: "t3"},
+		{Model: "b", TestID: "t1"},
+		{Model: "b", TestID: "t2", Error: "timeout"},
+		{Model: "b"}, // No test_id
+	}
+
+	cm := BuildCoverage(results, "model", false, 1, 100)
+	if strings.Join(cm.TestIDs, ",") != "t1,t2,t3" || cm.Tests != 3 || cm.Complete != 1 {
+		t.Fatalf("columns = %v (%d tests, %d complete)", cm.TestIDs, cm.Tests, cm.Complete)
+	}
+	if len(cm.Rows) != 2 || cm.Rows[0].Label != "b" {
+		t.Fatalf("rows = %+v", cm.Rows)
+	}
+	b := cm.Rows[0]
+	if b.Covered != 1 || b.Errored != 1 || b.Missing != 1 || b.Cells[1] != (CoverageCell{Results: 1, Errors: 1}) || b.Cells[2].Results != 0 {
+		t.Errorf("row b = %+v", b)
+	}
+	if a := cm.Rows[1]; a.Covered != 3 || a.Coverage != 1 || a.Cells[0].Results != 2 {
+		t.Errorf("row a = %+v", a)
+	}
+
+	// Only the tests b lacks, second page of one
+	cm = BuildCoverage(results, "model", true, 2, 1)
+	if cm.TotalTests != 2 || cm.Pages != 2 || strings.Join(cm.TestIDs, ",") != "t3" {
+		t.Errorf("gaps page 2 = %v of %d", cm.TestIDs, cm.Pages)
+	}
+
+	// Cases of the dataset nobody ran are gaps too
+	goldenSet = &GoldenSet{Cases: []DatasetCase{{TestID: "t1"}, {TestID: "t4"}}}
+	defer func() { goldenSet = nil }()
+	if cm = BuildCoverage(results, "model", false, 1, 100); cm.Tests != 4 || cm.Rows[1].Missing != 1 {
+		t.Errorf("with dataset = %+v", cm)
+	}
+}
+
+func TestCoverageGaps(t *testing.T) {
+	complete := []EvalResult{{Model: "a", TestID: "t1"}, {Model: "b", TestID: "t1"}}
+	if gaps := coverageGaps(complete); gaps.Rows != nil {
+		t.Errorf("complete = %+v", gaps)
+	}
+	gaps := coverageGaps(append(complete, EvalResult{Model: "a", TestID: "t2"}))
+	if len(gaps.Rows) != 1 || gaps.Rows[0].Label != "b" || gaps.Rows[0].Covered != 1 || gaps.Tests != 2 || gaps.Rows[0].Cells != nil {
+		t.Errorf("gaps = %+v", gaps)
+	}
+}
+
+func TestCoverageHandlers(t *testing.T) {
+	for _, query := range []string{"rows=judge", "page=0", "per_page=1001"} {
+		params, _ := url.ParseQuery(query)
+		if _, _, _, _, err := coverageParams(params); err == nil {
+			t.Errorf("%s: expected error", query)
+		}
+	}
+
+	withStore(t, `{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"t1","scores":{"combined":0.5}}
+{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"t2","scores":{"combined":0.5}}
+{"timestamp":"2025-01-01T10:00:00Z","model":"b","test_id":"t1","scores":{"combined":0.9}}`)
+
+	rec := httptest.NewRecorder()
+	coverageHandler(rec, httptest.NewRequest(http.MethodGet, "/coverage?gaps=1", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `class="coverage-matrix"`) || !strings.Contains(body, "b · t2: no result") || strings.Contains(body, "a · t1") {
+		t.Errorf("coverage page = %s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	coverageAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/api/coverage?model=a", nil))
+	if !strings.Contains(rec.Body.String(), `"complete":2`) {
+		t.Errorf("api with model filter = %s", rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	dashboardHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !strings.Contains(rec.Body.String(), "Not every config was scored on all 2 tests") {
+		t.Error("dashboard has no coverage banner")
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// sealedPrefix marks an encrypted JSONL line: "enc:v1:" + base64(nonce || AES-GCM ciphertext)
+// Plain JSON lines never start with it, so encrypted and plaintext lines can share a file
+var sealedPrefix = []byte("enc:v1:")
+
+// atRest encrypts everything goevals writes itself (JSONL ingest target, Redis checkpoints,
+// Postgres rows, archive objects); nil means data is stored in plaintext
+var atRest *sealer
+
+// sealer encrypts and decrypts with AES-256-GCM
+type sealer struct {
+	aead cipher.AEAD
+}
+
+func newSealer(key []byte) (*sealer, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes (AES-256), got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &sealer{aead: aead}, nil
+}
+
+// Seal returns nonce || ciphertext
+func (s *sealer) Seal(plaintext []byte) []byte {
+	nonce := make([]byte, s.aead.NonceSize())
+	rand.Read(nonce)
+	return s.aead.Seal(nonce, nonce, plaintext, nil)
+}
+
+// Open reverses Seal
+func (s *sealer) Open(sealed []byte) ([]byte, error) {
+	n := s.aead.NonceSize()
+	if len(sealed) < n {
+		return nil, errors.New("ciphertext too short")
+	}
+	plaintext, err := s.aead.Open(nil, sealed[:n], sealed[n:], nil)
+	if err != nil {
+		return nil, errors.New("decryption failed - wrong key or corrupted data")
+	}
+	return plaintext, nil
+}
+
+// encodeResult returns the stored form of one result (without newline), sealed when encryption is on
+func encodeResult(result EvalResult) ([]byte, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	if atRest == nil {
+		return data, nil
+	}
+	sealed := atRest.Seal(data)
+	line := make([]byte, len(sealedPrefix)+base64.StdEncoding.EncodedLen(len(sealed)))
+	copy(line, sealedPrefix)
+	base64.StdEncoding.Encode(line[len(sealedPrefix):], sealed)
+	return line, nil
+}
+
+// decodeResult parses a stored line, decrypting it if it is sealed
+// Plaintext lines are always accepted so existing files keep working after enabling encryption
+func decodeResult(line []byte) (EvalResult, error) {
+	var result EvalResult
+	line = bytes.TrimSpace(line)
+	if bytes.HasPrefix(line, sealedPrefix) {
+		if atRest == nil {
+			return result, errors.New("line is encrypted but no key is configured (set GOEVALS_ENCRYPTION_KEY)")
+		}
+		sealed, err := base64.StdEncoding.DecodeString(string(line[len(sealedPrefix):]))
+		if err != nil {
+			return result, fmt.Errorf("invalid encrypted line: %w", err)
+		}
+		if line, err = atRest.Open(sealed); err != nil {
+			return result, err
+		}
+	}
+	err := json.Unmarshal(line, &result)
+	return result, err
+}
+
+// loadEncryptionKey configures atRest from the environment
+//
+//	GOEVALS_ENCRYPTION_KEY      base64 encoded 32 byte key
+//	GOEVALS_ENCRYPTION_KEY_KMS  base64 AWS KMS ciphertext of a 32 byte data key (e.g. from
+//	                            `aws kms generate-data-key --key-spec AES_256`), decrypted at startup
+func loadEncryptionKey() error {
+	plain, wrapped := os.Getenv("GOEVALS_ENCRYPTION_KEY"), os.Getenv("GOEVALS_ENCRYPTION_KEY_KMS")
+	var key []byte
+	var err error
+	switch {
+	case plain != "" && wrapped != "":
+		return errors.New("set only one of GOEVALS_ENCRYPTION_KEY and GOEVALS_ENCRYPTION_KEY_KMS")
+	case plain != "":
+		key, err = base64.StdEncoding.DecodeString(plain)
+		if err != nil {
+			return fmt.Errorf("GOEVALS_ENCRYPTION_KEY is not valid base64: %w", err)
+		}
+	case wrapped != "":
+		key, err = kmsDecrypt(awsCredentialsFromEnv(), os.Getenv("AWS_KMS_ENDPOINT_URL"), wrapped)
+		if err != nil {
+			return fmt.Errorf("failed to unwrap GOEVALS_ENCRYPTION_KEY_KMS: %w", err)
+		}
+	default:
+		return nil
+	}
+
+	s, err := newSealer(key)
+	if err != nil {
+		return err
+	}
+	atRest = s
+	return nil
+}
+
+// kmsDecrypt calls the AWS KMS Decrypt API and returns the plaintext data key
+func kmsDecrypt(creds awsCredentials, endpoint, ciphertext string) ([]byte, error) {
+	if creds.accessKey == "" || creds.secretKey == "" {
+		return nil, errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set for KMS")
+	}
+	if creds.region == "" {
+		creds.region = "us-east-1"
+	}
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://kms.%s.amazonaws.com/", creds.region)
+	}
+
+	body, _ := json.Marshal(map[string]string{"CiphertextBlob": ciphertext})
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService.Decrypt")
+	creds.sign(req, body, "kms")
+
+	resp, err := (&http.Client{Timeout: 30 * time.Second}).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("KMS returned %s: %s", resp.Status, bytes.TrimSpace(data))
+	}
+
+	var out struct {
+		Plaintext []byte // base64 in JSON, decoded by encoding/json
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("invalid KMS response: %w", err)
+	}
+	return out.Plaintext, nil
+}
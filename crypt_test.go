@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncryptedFileSource(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	s, err := newSealer(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	atRest = s
+	defer func() { atRest = nil }()
+
+	path := filepath.Join(t.TempDir(), "evals.jsonl")
+	plain := `{"timestamp":"2025-01-01T10:00:00Z","model":"old","scores":{"combined":0.5}}` + "\n"
+	if err := os.WriteFile(path, []byte(plain), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	src := &fileSource{path: path}
+	secret := EvalResult{Timestamp: "2025-01-02T10:00:00Z", Model: "new", Question: "confidential clause 4.2"}
+	if _, err := src.Append([]EvalResult{secret}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "confidential") || !bytes.Contains(data, sealedPrefix) {
+		t.Fatalf("appended line is not encrypted:\n%s", data)
+	}
+
+	// Existing plaintext lines and new encrypted lines are both readable
+	results, err := src.Load()
+	if err != nil || len(results) != 2 || results[1].Question != secret.Question {
+		t.Fatalf("Load = %+v, %v", results, err)
+	}
+
+	// Removing works on encrypted lines too
+	if n, err := src.Remove(map[string]bool{resultKey(results[1]): true}); err != nil || n != 1 {
+		t.Errorf("Remove = %d, %v", n, err)
+	}
+
+	// Without the right key the encrypted line is rejected, not misread
+	other, _ := newSealer(bytes.Repeat([]byte{8}, 32))
+	atRest = other
+	line, _ := encodeResult(secret)
+	atRest = s
+	if _, err := decodeResult(line); err == nil {
+		t.Error("expected error decrypting with the wrong key")
+	}
+	atRest = nil
+	if _, err := decodeResult(line); err == nil {
+		t.Error("expected error for encrypted line without a key")
+	}
+}
+
+func TestLoadEncryptionKeyFromKMS(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("X-Amz-Target") != "TrentService.Decrypt" || !strings.Contains(r.Header.Get("Authorization"), "/kms/aws4_request") {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		var in struct{ CiphertextBlob string }
+		json.Unmarshal(body, &in)
+		if in.CiphertextBlob != "d3JhcHBlZA==" {
+			http.Error(w, `{"__type":"InvalidCiphertextException"}`, http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"Plaintext": base64.StdEncoding.EncodeToString(key)})
+	}))
+	defer srv.Close()
+	defer func() { atRest = nil }()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_KMS_ENDPOINT_URL", srv.URL)
+	t.Setenv("GOEVALS_ENCRYPTION_KEY_KMS", "d3JhcHBlZA==")
+	if err := loadEncryptionKey(); err != nil || atRest == nil {
+		t.Fatalf("loadEncryptionKey: %v", err)
+	}
+
+	t.Setenv("GOEVALS_ENCRYPTION_KEY", base64.StdEncoding.EncodeToString(key))
+	if err := loadEncryptionKey(); err == nil {
+		t.Error("expected error when both key variables are set")
+	}
+	t.Setenv("GOEVALS_ENCRYPTION_KEY_KMS", "")
+	t.Setenv("GOEVALS_ENCRYPTION_KEY", base64.StdEncoding.EncodeToString(key[:16]))
+	if err := loadEncryptionKey(); err == nil {
+		t.Error("expected error for a 16 byte key")
+	}
+}
@@ -0,0 +1,588 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DashboardConfig is one dashboard of a multi-dashboard config file (serve --config), so one
+// process can host evals for many teams, each with its own sources, thresholds and access
+type DashboardConfig struct {
+	Name       string         `json:"name"`
+	Path       string         `json:"path"`        // Base path, e.g. /search
+	Sources    []string       `json:"sources"`     // Same forms as command line arguments
+	LatencySLA string         `json:"latency_sla"` // As --latency-sla, default --latency-sla
+	Weights    string         `json:"weights"`     // As --weights, default --weights
+	Theme      *Branding      `json:"theme"`       // As the --theme file, default --theme
+	MediaDir   string         `json:"media_dir"`   // As --media-dir, default --media-dir
+	Auth       *DashboardAuth `json:"auth"`        // nil = open to everyone
+}
+
+// DashboardAuth restricts a dashboard to HTTP basic auth users or a bearer token (for scripts
+// posting to /api/ingest); either works when both are set
+// Values are expanded from the environment ($VAR), so secrets can stay out of the file
+type DashboardAuth struct {
+	Users map[string]string `json:"users"` // User name -> password
+	Token string            `json:"token"`
+}
+
+// MultiConfig is the serve --config file
+type MultiConfig struct {
+	Dashboards []DashboardConfig `json:"dashboards"`
+}
+
+// Dashboard is a configured dashboard ready to serve
+type Dashboard struct {
+	Name     string
+	Path     string
+	store    Store
+	slas     latencyBudgets
+	weights  map[string]float64
+	branding Branding
+	mediaDir string
+	auth     *DashboardAuth
+	sources  int
+	all      []*Dashboard // Every dashboard of the instance, for the project switcher
+
+	// What users save, in files of their own next to the --views-file etc. of single dashboards
+	views       *viewStore
+	labels      *labelStore
+	alerts      *alertStore
+	baselines   *baselineStore
+	annotations *annotationStore
+	activity    *activityLog
+}
+
+// StateFiles are the files goevals keeps what users save in: --views-file, --labels-file,
+// --alerts-file, --baselines-file and --annotations-file, and the audit trail, --activity-file
+type StateFiles struct {
+	Views, Labels, Alerts, Baselines, Annotations, Activity string
+}
+
+// stateFiles are the files of the instance, see StateFiles.For for those of a dashboard
+var stateFiles StateFiles
+
+// For returns the files of the dashboard at base path: goevals-views.json becomes
+// goevals-views.search.json for /search, so projects never see each other's views, labels,
+// alerts, baselines, annotations or activity
+func (f StateFiles) For(base string) StateFiles {
+	name := strings.ReplaceAll(strings.Trim(base, "/"), "/", ".") // Paths have no dots, no two dashboards share a name
+	file := func(path string) string {
+		if path == "" {
+			return ""
+		}
+		ext := filepath.Ext(path)
+		return strings.TrimSuffix(path, ext) + "." + name + ext
+	}
+	return StateFiles{file(f.Views), file(f.Labels), file(f.Alerts), file(f.Baselines), file(f.Annotations), file(f.Activity)}
+}
+
+// openState loads the dashboard's views, labels, alert states, baselines, annotations and activity
+func (d *Dashboard) openState(files StateFiles) error {
+	var err error
+	if d.views, err = newViewStore(files.Views); err != nil {
+		return err
+	}
+	if d.labels, err = newLabelStore(files.Labels); err != nil {
+		return err
+	}
+	if d.alerts, err = newAlertStore(files.Alerts); err != nil {
+		return err
+	}
+	if d.baselines, err = newBaselineStore(files.Baselines); err != nil {
+		return err
+	}
+	if d.annotations, err = newAnnotationStore(files.Annotations); err != nil {
+		return err
+	}
+	d.activity, err = newActivityLog(files.Activity)
+	return err
+}
+
+// dashboardKey is the context key of the Dashboard serving a request
+type dashboardKey struct{}
+
+// dashboardFrom returns the dashboard serving ctx, nil when goevals serves a single dashboard
+func dashboardFrom(ctx context.Context) *Dashboard {
+	d, _ := ctx.Value(dashboardKey{}).(*Dashboard)
+	return d
+}
+
+// slasFor returns the latency budgets of the dashboard serving ctx
+func slasFor(ctx context.Context) latencyBudgets {
+	if d := dashboardFrom(ctx); d != nil {
+		return d.slas
+	}
+	return latencySLAs
+}
+
+// weightsFor returns the default score weights of the dashboard serving ctx
+func weightsFor(ctx context.Context) map[string]float64 {
+	if d := dashboardFrom(ctx); d != nil {
+		return d.weights
+	}
+	return scoreWeights
+}
+
+// brandingFor returns the theme of the dashboard serving ctx
+func brandingFor(ctx context.Context) Branding {
+	if d := dashboardFrom(ctx); d != nil {
+		return d.branding
+	}
+	return branding
+}
+
+// viewsFor returns the saved views of the dashboard serving ctx
+func viewsFor(ctx context.Context) *viewStore {
+	if d := dashboardFrom(ctx); d != nil {
+		return d.views
+	}
+	return views
+}
+
+// labelsFor returns the config labels of the dashboard serving ctx
+func labelsFor(ctx context.Context) *labelStore {
+	if d := dashboardFrom(ctx); d != nil {
+		return d.labels
+	}
+	return configLabels
+}
+
+// alertStatesFor returns the alert decisions of the dashboard serving ctx
+func alertStatesFor(ctx context.Context) *alertStore {
+	if d := dashboardFrom(ctx); d != nil {
+		return d.alerts
+	}
+	return alertStates
+}
+
+// baselinesFor returns the baselines of the dashboard serving ctx
+func baselinesFor(ctx context.Context) *baselineStore {
+	if d := dashboardFrom(ctx); d != nil {
+		return d.baselines
+	}
+	return baselines
+}
+
+// annotationsFor returns the annotations of the dashboard serving ctx
+func annotationsFor(ctx context.Context) *annotationStore {
+	if d := dashboardFrom(ctx); d != nil {
+		return d.annotations
+	}
+	return annotations
+}
+
+// activityFor returns the audit trail of the dashboard serving ctx
+func activityFor(ctx context.Context) *activityLog {
+	if d := dashboardFrom(ctx); d != nil {
+		return d.activity
+	}
+	return activity
+}
+
+// dashboardPath matches valid base paths: /name, lowercase segments of letters, digits, - and _
+var dashboardPath = regexp.MustCompile(`^(/[a-z0-9_-]+)+$`)
+
+// loadDashboards reads a multi-dashboard config and opens every dashboard's sources
+// The config is YAML when the file ends in .yaml or .yml (see parseYAML) and JSON otherwise
+// Settings a dashboard leaves out fall back to the command line flags
+func loadDashboards(path string) ([]*Dashboard, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	if (ext == ".yaml" || ext == ".yml") && !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) { // JSON is YAML too
+		doc, err := parseYAML(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+		data, _ = json.Marshal(doc) // The parsed tree has the shape of the JSON config
+	}
+	var config MultiConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return newDashboards(config, "invalid config file "+path)
+}
+
+// newDashboards validates config and opens every dashboard's sources, errors start with where
+func newDashboards(config MultiConfig, where string) ([]*Dashboard, error) {
+	if len(config.Dashboards) == 0 {
+		return nil, fmt.Errorf("%s: no dashboards", where)
+	}
+
+	var dashboards []*Dashboard
+	var err error
+	paths := make(map[string]bool)
+	for i, dc := range config.Dashboards {
+		invalid := func(format string, args ...any) error {
+			return fmt.Errorf("%s: dashboard %d (%s): %s", where, i+1, dc.Name, fmt.Sprintf(format, args...))
+		}
+		switch {
+		case dc.Name == "":
+			return nil, invalid("missing name")
+		case !dashboardPath.MatchString(dc.Path):
+			return nil, invalid("path %q must look like /team-name", dc.Path)
+		case siteLinks.MatchString(`"` + dc.Path + `/"`):
+			return nil, invalid("path %s is taken by goevals' own routes", dc.Path)
+		case paths[dc.Path]:
+			return nil, invalid("path %s is used twice", dc.Path)
+		case len(dc.Sources) == 0:
+			return nil, invalid("no sources")
+		}
+		for other := range paths {
+			if strings.HasPrefix(dc.Path+"/", other+"/") || strings.HasPrefix(other+"/", dc.Path+"/") {
+				return nil, invalid("path %s overlaps %s", dc.Path, other)
+			}
+		}
+		paths[dc.Path] = true
+
+		d := &Dashboard{Name: dc.Name, Path: dc.Path, slas: latencySLAs, weights: scoreWeights, branding: branding, mediaDir: mediaDir}
+		if dc.LatencySLA != "" {
+			if d.slas, err = parseLatencySLA(dc.LatencySLA); err != nil {
+				return nil, invalid("%v", err)
+			}
+		}
+		if dc.Weights != "" {
+			if d.weights, err = parseWeights(dc.Weights); err != nil {
+				return nil, invalid("%v", err)
+			}
+		}
+		if dc.Theme != nil {
+			if dc.Theme.Accent != "" && !hexColor.MatchString(dc.Theme.Accent) {
+				return nil, invalid("accent %q must be a hex color like #e11d48", dc.Theme.Accent)
+			}
+			d.branding = *dc.Theme
+		}
+		if dc.MediaDir != "" {
+			d.mediaDir = dc.MediaDir
+		}
+		if d.branding.Title == "" {
+			d.branding.Title = dc.Name
+		}
+		if auth := dc.Auth; auth != nil {
+			d.auth = &DashboardAuth{Token: os.ExpandEnv(auth.Token), Users: make(map[string]string)}
+			for user, password := range auth.Users {
+				d.auth.Users[user] = os.ExpandEnv(password)
+			}
+			if d.auth.Token == "" && len(d.auth.Users) == 0 {
+				return nil, invalid("auth needs users or a token (is the environment variable set?)")
+			}
+		}
+
+		var sources []Source
+		for _, arg := range dc.Sources {
+			source, err := NewSource(arg)
+			if err != nil {
+				return nil, invalid("%v", err)
+			}
+			sources = append(sources, source)
+		}
+		d.store, d.sources = NewMemoryStore(sources), len(sources)
+		if err := d.openState(stateFiles.For(d.Path)); err != nil {
+			return nil, invalid("%v", err)
+		}
+		dashboards = append(dashboards, d)
+	}
+	for _, d := range dashboards {
+		d.all = dashboards
+	}
+	return dashboards, nil
+}
+
+// projectName matches --project names, which are also the project's base path
+var projectName = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// projectConfig turns --project name=source flags into one dashboard per project, served at
+// /name; naming a project again adds another source to it
+func projectConfig(specs []string) (MultiConfig, error) {
+	var config MultiConfig
+	index := make(map[string]int)
+	for _, spec := range specs {
+		name, source, ok := strings.Cut(spec, "=")
+		if !ok || source == "" || !projectName.MatchString(name) {
+			return config, fmt.Errorf("invalid --project %q, use name=source with a lowercase name of letters, digits, - and _", spec)
+		}
+		if i, ok := index[name]; ok {
+			config.Dashboards[i].Sources = append(config.Dashboards[i].Sources, source)
+			continue
+		}
+		index[name] = len(config.Dashboards)
+		config.Dashboards = append(config.Dashboards, DashboardConfig{Name: name, Path: "/" + name, Sources: []string{source}})
+	}
+	return config, nil
+}
+
+// ProjectLink is one entry of the project switcher in page headers
+type ProjectLink struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Auth    bool   `json:"auth_required"`
+	Current bool   `json:"-"`
+}
+
+// projectsFor lists the dashboards served next to the one serving ctx, nil when goevals serves
+// a single dashboard
+func projectsFor(ctx context.Context) []ProjectLink {
+	current := dashboardFrom(ctx)
+	if current == nil {
+		return nil
+	}
+	return projectLinks(current.all, current)
+}
+
+// projectLinks lists dashboards by name, marking current
+func projectLinks(dashboards []*Dashboard, current *Dashboard) []ProjectLink {
+	links := make([]ProjectLink, 0, len(dashboards))
+	for _, d := range dashboards {
+		links = append(links, ProjectLink{Name: d.Name, Path: d.Path, Auth: d.auth != nil, Current: d == current})
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i].Name < links[j].Name })
+	return links
+}
+
+// projectTemplates are parsed next to brandingTemplates
+//
+//	{{ template "project-switcher" }}  dropdown to the other dashboards, inside .header-right
+const projectTemplates = `
+{{ define "project-switcher" }}{{ with projects }}
+                <select class="theme-toggle project-switcher" title="Switch project" aria-label="Project" onchange="location.href = this.value">
+                    {{ range . }}<option value="{{ .Path }}/"{{ if .Current }} selected{{ end }}>{{ .Name }}</option>{{ end }}
+                </select>{{ end }}{{ end }}`
+
+// authorized reports whether r carries the dashboard's credentials
+func (d *Dashboard) authorized(r *http.Request) bool {
+	if d.auth == nil {
+		return true
+	}
+	equal := func(a, b string) bool { return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1 }
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && d.auth.Token != "" {
+		return equal(token, d.auth.Token)
+	}
+	if user, password, ok := r.BasicAuth(); ok {
+		want, known := d.auth.Users[user]
+		return known && equal(password, want)
+	}
+	return false
+}
+
+// Handler serves mux under the dashboard's base path: it checks credentials, tells handlers
+// which dashboard they serve and rewrites the site-absolute links of pages to the base path
+func (d *Dashboard) Handler(mux http.Handler) http.Handler {
+	inner := http.StripPrefix(d.Path, mux)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !d.authorized(r) {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", d.Name))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == d.Path {
+			http.Redirect(w, r, d.Path+"/", http.StatusMovedPermanently)
+			return
+		}
+		r = r.WithContext(context.WithValue(r.Context(), dashboardKey{}, d))
+		pw := &prefixWriter{ResponseWriter: w, prefix: d.Path}
+		inner.ServeHTTP(pw, r)
+		pw.finish()
+	})
+}
+
+// routedStore is the global store while serving several dashboards: every call goes to the
+// store of the dashboard serving the request
+type routedStore struct{}
+
+// errNoDashboard is returned for calls made outside a dashboard request
+var errNoDashboard = errors.New("no dashboard for this request")
+
+func (routedStore) Append(ctx context.Context, results []EvalResult) (int, error) {
+	if d := dashboardFrom(ctx); d != nil {
+		return d.store.Append(ctx, results)
+	}
+	return 0, errNoDashboard
+}
+
+func (routedStore) Query(ctx context.Context, q Query) ([]EvalResult, error) {
+	if d := dashboardFrom(ctx); d != nil {
+		return d.store.Query(ctx, q)
+	}
+	return nil, errNoDashboard
+}
+
+func (routedStore) Stats(ctx context.Context) (DashboardData, error) {
+	if d := dashboardFrom(ctx); d != nil {
+		return d.store.Stats(ctx)
+	}
+	return DashboardData{}, errNoDashboard
+}
+
+func (routedStore) Watch(ctx context.Context, interval time.Duration) <-chan []EvalResult {
+	if d := dashboardFrom(ctx); d != nil {
+		return d.store.Watch(ctx, interval)
+	}
+	ch := make(chan []EvalResult)
+	close(ch)
+	return ch
+}
+
+func (routedStore) Sources(ctx context.Context) []SourceStatus {
+	if d := dashboardFrom(ctx); d != nil {
+		return d.store.Sources(ctx)
+	}
+	return nil
+}
+
+// siteLinks matches site-absolute links to goevals routes in pages and scripts: page routes,
+// anything under /api/, /static/, /media/, /tests/ and /v/, and the root when it is a link target or has a query
+var siteLinks = regexp.MustCompile("(?:href|action|src)=[\"']/[\"'?#]|[\"'`(]/(?:(?:api|static|media|v|tests)/|(?:tests|compare|leaderboard|correlations|sweeps|latency|tools|safety|judges|stability|questions|heatmap|runs|matrix|sources|health|config|pivot|scorecard|coverage|activity)[\"'`?#)]|\\?)")
+
+// prefixLinks rewrites the site-absolute links of a page to live under prefix
+func prefixLinks(page []byte, prefix string) []byte {
+	return siteLinks.ReplaceAllFunc(page, func(link []byte) []byte {
+		i := bytes.IndexByte(link, '/')
+		return slices.Concat(link[:i], []byte(prefix), link[i:])
+	})
+}
+
+// prefixWriter moves redirects and the links of HTML responses under a base path
+// HTML is held until the handler returns, links can span several writes of a template;
+// JSON and other responses pass through untouched
+type prefixWriter struct {
+	http.ResponseWriter
+	prefix string
+	status int // 0 until the handler sets it
+	html   bool
+	page   bytes.Buffer
+}
+
+func (w *prefixWriter) WriteHeader(code int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = code
+	if location := w.Header().Get("Location"); strings.HasPrefix(location, "/") && !strings.HasPrefix(location, "//") {
+		w.Header().Set("Location", w.prefix+location)
+	}
+	w.html = strings.HasPrefix(w.Header().Get("Content-Type"), "text/html")
+	if !w.html {
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(p))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.html {
+		return w.page.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the connection, e.g. for /api/ws
+func (w *prefixWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// finish writes the held page, call it once the handler has returned
+func (w *prefixWriter) finish() {
+	if !w.html {
+		return
+	}
+	page := prefixLinks(w.page.Bytes(), w.prefix)
+	w.Header().Set("Content-Length", strconv.Itoa(len(page)))
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(page)
+}
+
+// dashboardsIndex lists the dashboards at the root of a multi-dashboard server
+func dashboardsIndex(dashboards []*Dashboard) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		list := append([]*Dashboard(nil), dashboards...)
+		sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+		data := struct {
+			Title      string
+			Subtitle   string
+			Dashboards []*Dashboard
+		}{"Dashboards", fmt.Sprintf("%d dashboards served by this instance", len(list)), list}
+		renderPage(w, r, "dashboards", dashboardsTemplate, data)
+	}
+}
+
+// serveDashboards serves every dashboard of a multi-dashboard config under its base path
+func serveDashboards(dashboards []*Dashboard, mux *http.ServeMux) *http.ServeMux {
+	root := http.NewServeMux()
+	for _, d := range dashboards {
+		root.Handle(d.Path, d.Handler(mux))
+		root.Handle(d.Path+"/", d.Handler(mux))
+		access := "open"
+		if d.auth != nil {
+			access = "auth required"
+		}
+		log.Printf("  ✓ %s at %s/ (%d source(s), %s)", d.Name, d.Path, d.sources, access)
+	}
+	root.HandleFunc("/api/projects", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(projectLinks(dashboards, nil)); err != nil {
+			log.Printf("Error encoding JSON: %v", err)
+		}
+	})
+	root.HandleFunc("/healthz", healthzHandler)
+	root.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		// Every dashboard's sources, named after their dashboard; those behind auth are only counted
+		targets := make([]readinessTarget, len(dashboards))
+		for i, d := range dashboards {
+			targets[i] = readinessTarget{ctx: context.WithValue(r.Context(), dashboardKey{}, d), name: d.Name, private: d.auth != nil}
+		}
+		rd, code := checkReadiness(targets)
+		writeReadiness(w, rd, code)
+	})
+	root.HandleFunc("/", dashboardsIndex(dashboards))
+	return root
+}
+
+const dashboardsTemplate = `
+{{ define "content" }}
+        <div class="panel">
+            <table>
+                <thead>
+                    <tr><th>Dashboard</th><th>Path</th><th>Access</th></tr>
+                </thead>
+                <tbody>
+                    {{ range .Dashboards }}
+                    <tr>
+                        <td><a href="{{ .Path }}/">{{ .Name }}</a></td>
+                        <td class="mono">{{ .Path }}</td>
+                        <td class="muted">{{ if .RequiresAuth }}Sign-in required{{ else }}Open{{ end }}</td>
+                    </tr>
+                    {{ end }}
+                </tbody>
+            </table>
+        </div>
+{{ end }}`
+
+// RequiresAuth reports whether the dashboard asks for credentials, shown on the index
+func (d *Dashboard) RequiresAuth() bool {
+	return d.auth != nil
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrefixLinks(t *testing.T) {
+	page := `<a href="/">Back</a> <a href="/?model=a">A</a> <a href="/tests?model=a">tests</a> <a href="/tests/t1?run=r1">t1</a> <img src="/static/logo.svg"> <img src="/media/q1.png">
+<script>fetch('/api/evals/since?ts=' + ts); location.href = '/?' + params; if (e.key === '/') {} fetch(` + "`/api/views`" + `)</script>
+<a href="/testsuite">not a route</a> <a href="https://example.com/tests?x">external</a>`
+	want := `<a href="/a/">Back</a> <a href="/a/?model=a">A</a> <a href="/a/tests?model=a">tests</a> <a href="/a/tests/t1?run=r1">t1</a> <img src="/a/static/logo.svg"> <img src="/a/media/q1.png">
+<script>fetch('/a/api/evals/since?ts=' + ts); location.href = '/a/?' + params; if (e.key === '/') {} fetch(` + "`/a/api/views`" + `)</script>
+<a href="/testsuite">not a route</a> <a href="https://example.com/tests?x">external</a>`
+	if got := string(prefixLinks([]byte(page), "/a")); got != want {
+		t.Errorf("prefixLinks =\n%s\nwant\n%s", got, want)
+	}
+
+	// Links to every page route, as the templates write them
+	for _, link := range []string{
+		`data-href="/config?key=x"`,
+		`<a href="/pivot" class="help-btn">`,
+		`<a href="/scorecard" class="help-btn">`,
+		`<a href="/coverage?rows=config&gaps=1">`,
+		`<a href="/activity?source=evals.jsonl">`,
+	} {
+		want := strings.Replace(link, `"/`, `"/a/`, 1)
+		if got := string(prefixLinks([]byte(link), "/a")); got != want {
+			t.Errorf("prefixLinks(%s) = %s, want %s", link, got, want)
+		}
+	}
+}
+
+func TestLoadDashboards(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "dashboards.json")
+	t.Setenv("SEARCH_PASSWORD", "hunter2")
+	os.WriteFile(path, []byte(`{"dashboards": [
+		{"name": "Search", "path": "/search", "sources": ["search.jsonl"], "latency_sla": "*:500", "auth": {"users": {"alice": "$SEARCH_PASSWORD"}}},
+		{"name": "Chat", "path": "/chat", "sources": ["chat.jsonl"], "weights": "helpful:2,safe:1", "theme": {"accent": "#e11d48"}, "media_dir": "chat-media"}
+	]}`), 0o644)
+	dashboards, err := loadDashboards(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	search, chat := dashboards[0], dashboards[1]
+	if search.slas["*"] != 500 || search.auth.Users["alice"] != "hunter2" || search.branding.Title != "Search" {
+		t.Errorf("search = %+v", search)
+	}
+	if chat.weights["helpful"] != 2 || chat.branding.Accent != "#e11d48" || chat.branding.Title != "Chat" || chat.auth != nil || chat.mediaDir != "chat-media" || search.mediaDir != mediaDir {
+		t.Errorf("chat = %+v", chat)
+	}
+
+	// The same config as YAML
+	yamlPath := filepath.Join(dir, "dashboards.yaml")
+	os.WriteFile(yamlPath, []byte(`dashboards:
+  - name: Search
+    path: /search
+    sources: [search.jsonl]
+    latency_sla: "*:500"
+    auth:
+      users:
+        alice: $SEARCH_PASSWORD
+  - name: Chat
+    path: /chat
+    sources:
+      - chat.jsonl
+    weights: helpful:2,safe:1
+    theme:
+      accent: "#e11d48"
+    media_dir: chat-media
+`), 0o644)
+	fromYAML, err := loadDashboards(yamlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if search, chat := fromYAML[0], fromYAML[1]; search.slas["*"] != 500 || search.auth.Users["alice"] != "hunter2" || chat.weights["helpful"] != 2 || chat.branding.Accent != "#e11d48" || chat.mediaDir != "chat-media" {
+		t.Errorf("YAML config = %+v, %+v", search, chat)
+	}
+
+	for _, bad := range []string{
+		`{"dashboards": []}`,
+		`{"dashboards": [{"path": "/a", "sources": ["a.jsonl"]}]}`,
+		`{"dashboards": [{"name": "A", "path": "a", "sources": ["a.jsonl"]}]}`,
+		`{"dashboards": [{"name": "A", "path": "/a"}]}`,
+		`{"dashboards": [{"name": "A", "path": "/a", "sources": ["a.jsonl"]}, {"name": "B", "path": "/a", "sources": ["b.jsonl"]}]}`,
+		`{"dashboards": [{"name": "A", "path": "/a", "sources": ["a.jsonl"]}, {"name": "B", "path": "/a/b", "sources": ["b.jsonl"]}]}`,
+		`{"dashboards": [{"name": "A", "path": "/a", "sources": ["a.jsonl"], "latency_sla": "fast"}]}`,
+		`{"dashboards": [{"name": "A", "path": "/a", "sources": ["a.jsonl"], "auth": {"token": "$UNSET_GOEVALS_TOKEN"}}]}`,
+		`{"dashboards": [{"name": "A", "path": "/a", "sources": ["a.jsonl"], "theme": {"accent": "red"}}]}`,
+		`{"dashboards": [{"name": "API", "path": "/api", "sources": ["a.jsonl"]}]}`,
+	} {
+		os.WriteFile(path, []byte(bad), 0o644)
+		if _, err := loadDashboards(path); err == nil {
+			t.Errorf("loadDashboards accepted %s", bad)
+		}
+	}
+}
+
+func TestServeDashboards(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "search.jsonl"), []byte(`{"timestamp":"2025-01-01T10:00:00Z","model":"ranker","response_time_ms":900,"scores":{"combined":0.9}}
+`), 0o644)
+	os.WriteFile(filepath.Join(dir, "chat.jsonl"), []byte(`{"timestamp":"2025-01-01T10:00:00Z","model":"chatbot","scores":{"combined":0.7}}
+{"timestamp":"2025-01-01T10:01:00Z","model":"chatbot","scores":{"combined":0.5}}
+`), 0o644)
+	path := filepath.Join(dir, "dashboards.json")
+	os.WriteFile(path, []byte(`{"dashboards": [
+		{"name": "Search", "path": "/search", "sources": ["`+filepath.Join(dir, "search.jsonl")+`"], "latency_sla": "*:500", "auth": {"users": {"alice": "secret"}, "token": "s3cr3t"}},
+		{"name": "Chat", "path": "/chat", "sources": ["`+filepath.Join(dir, "chat.jsonl")+`"]}
+	]}`), 0o644)
+	dashboards, err := loadDashboards(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	useStore(t, routedStore{})
+	mux := http.NewServeMux()
+	registerRoutes(mux, "")
+	server := serveDashboards(dashboards, mux)
+
+	get := func(target string, auth func(*http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if auth != nil {
+			auth(req)
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/chat/", nil)
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, "chatbot") || strings.Contains(body, "ranker") {
+		t.Fatalf("chat dashboard = %d", rec.Code)
+	}
+	if !strings.Contains(body, `href="/chat/tests?`) || strings.Contains(body, `href="/tests?`) || strings.Contains(body, "Within SLA") {
+		t.Error("chat dashboard links aren't under /chat or it shows another dashboard's SLA")
+	}
+
+	var evals struct{ TotalTests int }
+	json.Unmarshal(get("/chat/api/evals", nil).Body.Bytes(), &evals)
+	if evals.TotalTests != 2 {
+		t.Errorf("chat /api/evals = %d tests", evals.TotalTests)
+	}
+
+	if rec := get("/search/", nil); rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("search without credentials = %d", rec.Code)
+	}
+	if rec := get("/search/", func(r *http.Request) { r.SetBasicAuth("alice", "wrong") }); rec.Code != http.StatusUnauthorized {
+		t.Errorf("search with a wrong password = %d", rec.Code)
+	}
+	rec = get("/search/", func(r *http.Request) { r.SetBasicAuth("alice", "secret") })
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Within SLA") || !strings.Contains(rec.Body.String(), "<title>Search") {
+		t.Errorf("search with basic auth = %d", rec.Code)
+	}
+	if rec := get("/search/api/evals", func(r *http.Request) { r.Header.Set("Authorization", "Bearer s3cr3t") }); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "ranker") {
+		t.Errorf("search with token = %d", rec.Code)
+	}
+
+	if rec := get("/chat", nil); rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "/chat/" {
+		t.Errorf("/chat = %d %s", rec.Code, rec.Header().Get("Location"))
+	}
+	if rec := get("/", nil); !strings.Contains(rec.Body.String(), `href="/search/"`) || !strings.Contains(rec.Body.String(), "Sign-in required") {
+		t.Error("index doesn't list the dashboards")
+	}
+	if rec := get("/tests", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("/tests outside a dashboard = %d", rec.Code)
+	}
+}
+
+func TestProjects(t *testing.T) {
+	for _, bad := range []string{"search", "=a.jsonl", "Search=a.jsonl", "a/b=a.jsonl", "search="} {
+		if _, err := projectConfig([]string{bad}); err == nil {
+			t.Errorf("projectConfig accepted %q", bad)
+		}
+	}
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "search-1.jsonl"), []byte(`{"model":"ranker","scores":{"combined":0.9}}
+`), 0o644)
+	os.WriteFile(filepath.Join(dir, "search-2.jsonl"), []byte(`{"model":"reranker","scores":{"combined":0.8}}
+`), 0o644)
+	os.WriteFile(filepath.Join(dir, "chat.jsonl"), []byte(`{"model":"chatbot","scores":{"combined":0.7}}
+`), 0o644)
+	config, err := projectConfig([]string{
+		"search=" + filepath.Join(dir, "search-*.jsonl"),
+		"chat=" + filepath.Join(dir, "chat.jsonl"),
+		"search=" + filepath.Join(dir, "missing.jsonl"),
+	})
+	if err != nil || len(config.Dashboards) != 2 || config.Dashboards[0].Path != "/search" || len(config.Dashboards[0].Sources) != 2 {
+		t.Fatalf("projectConfig = %+v, %v", config, err)
+	}
+	dashboards, err := newDashboards(config, "invalid --project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	useStore(t, routedStore{})
+	mux := http.NewServeMux()
+	registerRoutes(mux, "")
+	server := serveDashboards(dashboards, mux)
+	get := func(target string) string {
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec.Body.String()
+	}
+
+	var stats StatsReport
+	json.Unmarshal([]byte(get("/search/api/stats")), &stats)
+	if stats.TotalTests != 2 {
+		t.Errorf("search /api/stats = %d tests", stats.TotalTests)
+	}
+	// The switcher links to the projects' own paths, not rewritten under the current one
+	body := get("/chat/leaderboard")
+	if !strings.Contains(body, `<option value="/search/">search</option>`) || !strings.Contains(body, `<option value="/chat/" selected>chat</option>`) {
+		t.Errorf("leaderboard has no project switcher:\n%s", body)
+	}
+	if body := get("/chat/"); !strings.Contains(body, `class="theme-toggle project-switcher"`) || strings.Contains(body, "ranker") {
+		t.Error("chat dashboard has no project switcher or shows another project's results")
+	}
+	var projects []ProjectLink
+	if err := json.Unmarshal([]byte(get("/api/projects")), &projects); err != nil || len(projects) != 2 || projects[0].Name != "chat" {
+		t.Errorf("/api/projects = %+v, %v", projects, err)
+	}
+}
+
+func TestDashboardState(t *testing.T) {
+	dir := t.TempDir()
+	defer func(files StateFiles) { stateFiles = files }(stateFiles)
+	stateFiles = StateFiles{Views: filepath.Join(dir, "views.json"), Labels: filepath.Join(dir, "labels.json"),
+		Alerts: filepath.Join(dir, "alerts.json"), Baselines: filepath.Join(dir, "baselines.json"), Annotations: filepath.Join(dir, "annotations.jsonl"),
+		Activity: filepath.Join(dir, "activity.jsonl")}
+	if got := stateFiles.For("/team/search").Views; got != filepath.Join(dir, "views.team.search.json") {
+		t.Errorf("views file = %s", got)
+	}
+
+	os.WriteFile(filepath.Join(dir, "a.jsonl"), []byte(`{"model":"ranker","scores":{"combined":0.9}}
+`), 0o644)
+	config, _ := projectConfig([]string{"search=" + filepath.Join(dir, "a.jsonl"), "chat=" + filepath.Join(dir, "a.jsonl")})
+	dashboards, err := newDashboards(config, "invalid --project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	useStore(t, routedStore{})
+	mux := http.NewServeMux()
+	registerRoutes(mux, "")
+	server := serveDashboards(dashboards, mux)
+	serve := func(method, target, body string) string {
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec.Body.String()
+	}
+
+	serve(http.MethodPost, "/search/api/views", `{"name":"mine","query":"model=ranker"}`)
+	serve(http.MethodPost, "/search/api/labels", `{"config":"ranker","label":"Search ranker"}`)
+	serve(http.MethodPost, "/search/api/annotations", `{"result_id":"r1","decision":"accept"}`)
+	for _, api := range []string{"views", "labels", "annotations"} {
+		if search, chat := serve(http.MethodGet, "/search/api/"+api, ""), serve(http.MethodGet, "/chat/api/"+api, ""); search == "[]\n" || chat != "[]\n" {
+			t.Errorf("%s: search %s, chat %s", api, search, chat)
+		}
+	}
+	if body := serve(http.MethodGet, "/chat/", ""); strings.Contains(body, "Search ranker") {
+		t.Error("chat dashboard shows the search project's label")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "views.search.json")); err != nil {
+		t.Errorf("search views not in their own file: %v", err)
+	}
+	if _, err := os.Stat(stateFiles.Views); err == nil {
+		t.Error("a project wrote the instance's views file")
+	}
+	// Each project records the activity of its own store, as the tracker serve starts for it does
+	os.WriteFile(filepath.Join(dir, "b.jsonl"), []byte(`{"model":"chatbot","scores":{"combined":0.8}}
+`), 0o644)
+	config, _ = projectConfig([]string{"search=" + filepath.Join(dir, "a.jsonl"), "chat=" + filepath.Join(dir, "b.jsonl")})
+	if dashboards, err = newDashboards(config, "invalid --project"); err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range dashboards {
+		results, _ := d.store.Query(context.Background(), Query{})
+		if err := d.activity.Record(newActivityTracker(d.activity).Observe(results, time.Now(), true)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	server = serveDashboards(dashboards, mux)
+	if body := serve(http.MethodGet, "/chat/activity", ""); !strings.Contains(body, `href="/chat/activity?source=`) || !strings.Contains(body, "b.jsonl") || strings.Contains(body, "a.jsonl") {
+		t.Errorf("chat activity = %s", body)
+	}
+	if body := serve(http.MethodGet, "/search/api/activity", ""); !strings.Contains(body, "a.jsonl") || strings.Contains(body, "b.jsonl") {
+		t.Errorf("search activity = %s", body)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "activity.chat.jsonl")); err != nil {
+		t.Errorf("chat activity not in its own file: %v", err)
+	}
+}
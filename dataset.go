@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Dataset is the dataset version a result was evaluated on: dataset_version, else dataset_hash
+func (er EvalResult) Dataset() string {
+	if er.DatasetVersion != "" {
+		return er.DatasetVersion
+	}
+	return er.DatasetHash
+}
+
+// DatasetLink is one dataset version in the mixed versions warning, linking to the dashboard
+// filtered to it
+type DatasetLink struct {
+	Version string // Empty for results without a version
+	Results int
+	URL     string
+}
+
+// datasetLinks lists the dataset versions of datasets (version -> results) when there is more
+// than one, most results first, each linking to the current page filtered to it
+// Results without a version have no link, they can't be filtered to
+func datasetLinks(params url.Values, datasets map[string]int) []DatasetLink {
+	if len(datasets) < 2 {
+		return nil
+	}
+	versions := slices.SortedFunc(maps.Keys(datasets), func(a, b string) int {
+		if datasets[a] != datasets[b] {
+			return datasets[b] - datasets[a]
+		}
+		return strings.Compare(a, b)
+	})
+	links := make([]DatasetLink, len(versions))
+	for i, version := range versions {
+		links[i] = DatasetLink{Version: version, Results: datasets[version]}
+		if version != "" {
+			link := url.Values{}
+			for key, values := range params {
+				link[key] = values
+			}
+			link.Set("dataset", version)
+			links[i].URL = "/?" + link.Encode()
+		}
+	}
+	return links
+}
+
+// datasetVersions counts the results of each dataset version, "" for results without one;
+// nil when no result has a version
+func datasetVersions(results []EvalResult) map[string]int {
+	counts := make(map[string]int)
+	versioned := false
+	for _, result := range results {
+		version := result.Dataset()
+		counts[version]++
+		versioned = versioned || version != ""
+	}
+	if !versioned {
+		return nil
+	}
+	return counts
+}
+
+// warnDatasetMismatch prints a warning when baseline and candidate were evaluated on different
+// dataset versions, or either mixes several; their scores are then not directly comparable
+func warnDatasetMismatch(w io.Writer, baseline, candidate []EvalResult) {
+	before, after := datasetVersions(baseline), datasetVersions(candidate)
+	if before == nil && after == nil {
+		return
+	}
+	list := func(counts map[string]int) string {
+		versions := slices.Sorted(maps.Keys(counts))
+		for i, version := range versions {
+			if version == "" {
+				versions[i] = "(none)"
+			}
+		}
+		return strings.Join(versions, ", ")
+	}
+	if b, c := list(before), list(after); b != c || len(before) > 1 || len(after) > 1 {
+		fmt.Fprintf(w, "Warning: mixed dataset versions (baseline: %s; candidate: %s), scores may not be comparable\n", b, c)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDatasetVersion(t *testing.T) {
+	if got := (EvalResult{DatasetVersion: "v2", DatasetHash: "ab12"}).Dataset(); got != "v2" {
+		t.Errorf("Dataset() = %q, want the version", got)
+	}
+	if got := (EvalResult{DatasetHash: "ab12"}).Dataset(); got != "ab12" {
+		t.Errorf("Dataset() = %q, want the hash", got)
+	}
+
+	var result EvalResult
+	if err := result.UnmarshalJSON([]byte(`{"model":"a","dataset_version":"v2","dataset_hash":"ab12"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.CustomFields) != 0 {
+		t.Errorf("dataset fields became custom fields: %v", result.CustomFields)
+	}
+	data, _ := result.MarshalJSON()
+	if !strings.Contains(string(data), `"dataset_version":"v2"`) || !strings.Contains(string(data), `"dataset_hash":"ab12"`) {
+		t.Errorf("MarshalJSON() = %s", data)
+	}
+
+	if (Query{Dataset: "v1"}).Match(result) || !(Query{Dataset: "v2"}).Match(result) {
+		t.Error("Query.Dataset does not match on the dataset version")
+	}
+}
+
+func TestStatsDatasets(t *testing.T) {
+	data := CalculateStats([]EvalResult{{Model: "a"}, {Model: "b"}}, nil)
+	if data.Datasets != nil {
+		t.Errorf("Datasets = %v without versions, want nil", data.Datasets)
+	}
+	data = CalculateStats([]EvalResult{{Model: "a", DatasetVersion: "v1"}, {Model: "b", DatasetVersion: "v1"}}, nil)
+	if len(data.Datasets) != 1 || data.Datasets["v1"] != 2 {
+		t.Errorf("Datasets = %v, want v1: 2", data.Datasets)
+	}
+	data = CalculateStats([]EvalResult{{Model: "a", DatasetVersion: "v1"}, {Model: "b", DatasetHash: "ab12"}, {Model: "b"}}, nil)
+	if len(data.Datasets) != 3 || data.Datasets[""] != 1 {
+		t.Errorf("Datasets = %v, want v1, ab12 and unversioned", data.Datasets)
+	}
+}
+
+func TestDatasetLinks(t *testing.T) {
+	if links := datasetLinks(url.Values{}, map[string]int{"v1": 3}); links != nil {
+		t.Errorf("datasetLinks() = %v for a single version, want none", links)
+	}
+	links := datasetLinks(url.Values{"run_id": {"nightly"}}, map[string]int{"v1": 1, "v2": 3, "": 2})
+	if len(links) != 3 {
+		t.Fatalf("datasetLinks() = %v", links)
+	}
+	if links[0].Version != "v2" || links[1].Version != "" || links[2].Version != "v1" {
+		t.Errorf("links not ordered by results: %v", links)
+	}
+	if links[0].URL != "/?dataset=v2&run_id=nightly" {
+		t.Errorf("URL = %q, want the other filters kept", links[0].URL)
+	}
+	if links[1].URL != "" {
+		t.Errorf("unversioned results have a link: %q", links[1].URL)
+	}
+}
+
+func TestWarnDatasetMismatch(t *testing.T) {
+	tests := []struct {
+		name                string
+		baseline, candidate []EvalResult
+		warn                bool
+	}{
+		{"unversioned", []EvalResult{{}}, []EvalResult{{}}, false},
+		{"same version", []EvalResult{{DatasetVersion: "v1"}}, []EvalResult{{DatasetVersion: "v1"}}, false},
+		{"different versions", []EvalResult{{DatasetVersion: "v1"}}, []EvalResult{{DatasetVersion: "v2"}}, true},
+		{"newly versioned", []EvalResult{{}}, []EvalResult{{DatasetVersion: "v1"}}, true},
+		{"mixed candidate", []EvalResult{{DatasetVersion: "v1"}}, []EvalResult{{DatasetVersion: "v1"}, {DatasetVersion: "v2"}}, true},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		warnDatasetMismatch(&buf, tt.baseline, tt.candidate)
+		if warned := buf.Len() > 0; warned != tt.warn {
+			t.Errorf("%s: warned = %v, want %v (%q)", tt.name, warned, tt.warn, buf.String())
+		}
+	}
+}
+
+func TestDashboardDatasetWarning(t *testing.T) {
+	withStore(t, `{"timestamp":"2025-01-01T10:00:00Z","model":"a","dataset_version":"v1","scores":{"combined":0.9}}
+{"timestamp":"2025-01-01T10:01:00Z","model":"b","dataset_version":"v2","scores":{"combined":0.5}}`)
+
+	rec := httptest.NewRecorder()
+	dashboardHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, "mixes 2 dataset versions") || !strings.Contains(body, `href="/?dataset=v1"`) {
+		t.Error("dashboard is missing the mixed dataset versions warning")
+	}
+	if !strings.Contains(body, `data-param="dataset"`) {
+		t.Error("dashboard is missing the dataset filter")
+	}
+
+	rec = httptest.NewRecorder()
+	dashboardHandler(rec, httptest.NewRequest(http.MethodGet, "/?dataset=v1", nil))
+	body = rec.Body.String()
+	if strings.Contains(body, "mixes 2 dataset versions") {
+		t.Error("warning shown when filtered to one dataset version")
+	}
+	if !strings.Contains(body, "/config?key=a&dataset=v1") {
+		t.Error("config links don't keep the dataset filter")
+	}
+}
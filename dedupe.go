@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DedupePolicy decides what happens when the same test was recorded more than once
+// for the same run and config (e.g. a file loaded twice, or a re-run appended to the same file)
+type DedupePolicy string
+
+const (
+	DedupeKeepAll DedupePolicy = "keep-all" // Count every result (default, repeated samples are intentional)
+	DedupeLatest  DedupePolicy = "latest"   // Keep only the newest result by timestamp
+	DedupeError   DedupePolicy = "error"    // Refuse to serve data containing duplicates
+)
+
+// dedupePolicy is the policy from --dedupe
+var dedupePolicy = DedupeKeepAll
+
+// parseDedupePolicy validates a --dedupe value
+func parseDedupePolicy(value string) (DedupePolicy, error) {
+	switch policy := DedupePolicy(value); policy {
+	case DedupeKeepAll, DedupeLatest, DedupeError:
+		return policy, nil
+	}
+	return "", fmt.Errorf("invalid dedupe policy %q: use keep-all, latest or error", value)
+}
+
+// dedupeKey identifies a test within a run: test_id + metadata.run_id + config key
+// Results without a test_id can't be matched and are never considered duplicates
+func dedupeKey(result EvalResult) string {
+	if result.TestID == "" {
+		return ""
+	}
+	runID, _ := result.Metadata["run_id"].(string)
+	return result.TestID + "\x00" + runID + "\x00" + buildConfigKey(result)
+}
+
+// DuplicateError is returned under DedupeError when results share a dedupe key
+type DuplicateError struct {
+	Count    int      // Results beyond the first for each key
+	Examples []string // A few "test_id (run run_id, config)" descriptions
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("%d duplicate result(s) for the same test_id, run_id and config (--dedupe error): %s",
+		e.Count, strings.Join(e.Examples, "; "))
+}
+
+// maxDuplicateExamples limits how many duplicates a DuplicateError lists
+const maxDuplicateExamples = 5
+
+// applyDedupe applies policy to results, keeping source order
+// Returns the kept results and how many duplicates were found
+func applyDedupe(results []EvalResult, policy DedupePolicy) ([]EvalResult, int, error) {
+	if policy == DedupeKeepAll {
+		return results, 0, nil
+	}
+
+	// Index of the result to keep for each key; later results win timestamp ties (re-runs append)
+	keep := make(map[string]int)
+	duplicates := 0
+	dupErr := &DuplicateError{}
+	for i, result := range results {
+		key := dedupeKey(result)
+		if key == "" {
+			continue
+		}
+		prev, seen := keep[key]
+		if !seen {
+			keep[key] = i
+			continue
+		}
+		duplicates++
+		if len(dupErr.Examples) < maxDuplicateExamples {
+			runID, _ := result.Metadata["run_id"].(string)
+			dupErr.Examples = append(dupErr.Examples, fmt.Sprintf("%s (run %q, %s)", result.TestID, runID, buildConfigKey(result)))
+		}
+		if result.Timestamp >= results[prev].Timestamp {
+			keep[key] = i
+		}
+	}
+	if duplicates == 0 {
+		return results, 0, nil
+	}
+	if policy == DedupeError {
+		dupErr.Count = duplicates
+		return nil, duplicates, dupErr
+	}
+
+	kept := make([]EvalResult, 0, len(results)-duplicates)
+	for i, result := range results {
+		if key := dedupeKey(result); key == "" || keep[key] == i {
+			kept = append(kept, result)
+		}
+	}
+	return kept, duplicates, nil
+}
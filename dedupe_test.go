@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestApplyDedupe(t *testing.T) {
+	run := func(id string) map[string]any { return map[string]any{"run_id": id} }
+	results := []EvalResult{
+		{Timestamp: "2025-01-01T10:00:00Z", Model: "a", TestID: "t1", Metadata: run("r1"), Scores: ScoreBreakdown{Combined: 0.2}},
+		{Timestamp: "2025-01-01T10:00:00Z", Model: "a", TestID: "t2", Metadata: run("r1")},
+		{Timestamp: "2025-01-02T10:00:00Z", Model: "a", TestID: "t1", Metadata: run("r1"), Scores: ScoreBreakdown{Combined: 0.9}}, // Re-run
+		{Timestamp: "2025-01-01T09:00:00Z", Model: "a", TestID: "t1", Metadata: run("r1"), Scores: ScoreBreakdown{Combined: 0.5}}, // Older copy from another file
+		{Timestamp: "2025-01-01T10:00:00Z", Model: "a", TestID: "t1", Metadata: run("r2")},                                        // Different run
+		{Timestamp: "2025-01-01T10:00:00Z", Model: "b", TestID: "t1", Metadata: run("r1")},                                        // Different model
+		{Timestamp: "2025-01-01T10:00:00Z", Model: "a"},                                                                           // No test_id
+		{Timestamp: "2025-01-01T10:00:00Z", Model: "a"},
+	}
+
+	kept, n, err := applyDedupe(results, DedupeKeepAll)
+	if err != nil || n != 0 || len(kept) != len(results) {
+		t.Errorf("keep-all = %d kept, %d duplicates, %v", len(kept), n, err)
+	}
+
+	kept, n, err = applyDedupe(results, DedupeLatest)
+	if err != nil || n != 2 || len(kept) != 6 {
+		t.Fatalf("latest = %d kept, %d duplicates, %v", len(kept), n, err)
+	}
+	for _, r := range kept {
+		if r.TestID == "t1" && r.Model == "a" && r.Metadata["run_id"] == "r1" && r.Scores.Combined != 0.9 {
+			t.Errorf("kept %+v, want the newest run", r)
+		}
+	}
+	if kept[0].TestID != "t2" {
+		t.Errorf("source order not kept: %+v", kept[0])
+	}
+
+	_, n, err = applyDedupe(results, DedupeError)
+	var dupErr *DuplicateError
+	if !errors.As(err, &dupErr) || n != 2 || dupErr.Count != 2 || len(dupErr.Examples) != 2 {
+		t.Errorf("error policy = %d, %v", n, err)
+	}
+
+	if _, err := parseDedupePolicy("first"); err == nil {
+		t.Error("expected error for unknown policy")
+	}
+}
+
+func TestStoreDedupe(t *testing.T) {
+	dir := t.TempDir()
+	line := `{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"t1","metadata":{"run_id":"r1"},"scores":{"combined":0.5}}` + "\n"
+	var sources []Source
+	for _, name := range []string{"a.jsonl", "copy-of-a.jsonl"} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(line), 0644)
+		sources = append(sources, &fileSource{path: path})
+	}
+	s := NewMemoryStore(sources)
+	defer func() { dedupePolicy = DedupeKeepAll }()
+
+	ctx := context.Background()
+	for policy, want := range map[DedupePolicy]int{DedupeKeepAll: 2, DedupeLatest: 1} {
+		dedupePolicy = policy
+		if stats, err := s.Stats(ctx); err != nil || stats.TotalTests != want {
+			t.Errorf("%s: %d tests, %v", policy, stats.TotalTests, err)
+		}
+	}
+
+	dedupePolicy = DedupeError
+	if _, err := s.Query(ctx, Query{}); err == nil {
+		t.Error("expected duplicate error")
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// federationField is the custom field that labels results with the instance they came from
+// Being a custom field, it shows up as a dashboard column and splits configs per instance
+const federationField = "source"
+
+// remoteSource reads results from another goevals instance's /api/evals endpoint
+type remoteSource struct {
+	label   string
+	baseURL string
+	client  *http.Client
+
+	mu     sync.Mutex
+	cached []EvalResult // Last successful response, served while the instance is unreachable
+	etag   string       // ETag of cached, so unchanged result sets cost a 304
+}
+
+// newRemoteSource parses a "label=url" federation spec
+// The label is optional and defaults to the URL host
+func newRemoteSource(spec string) (*remoteSource, error) {
+	label, rawURL, found := strings.Cut(spec, "=")
+	if !found || strings.Contains(label, "://") {
+		label, rawURL = "", spec
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid federation URL %q", rawURL)
+	}
+	if label == "" {
+		label = u.Host
+	}
+
+	return &remoteSource{
+		label:   label,
+		baseURL: strings.TrimSuffix(u.String(), "/"),
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (s *remoteSource) Name() string {
+	return fmt.Sprintf("%s (%s)", s.label, s.baseURL)
+}
+
+// Load fetches the full result set and labels every result with this instance
+func (s *remoteSource) Load() ([]EvalResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	req, err := http.NewRequest(http.MethodGet, s.baseURL+"/api/evals", nil)
+	if err != nil {
+		return s.cached, err
+	}
+	if s.etag != "" {
+		req.Header.Set("If-None-Match", s.etag)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return s.cached, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && s.etag != "" {
+		return s.cached, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return s.cached, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var payload struct {
+		Results []EvalResult `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return s.cached, fmt.Errorf("invalid response: %w", err)
+	}
+
+	for i, orig := range payload.Results {
+		result := orig
+		result.CustomFields = maps.Clone(orig.CustomFields)
+		if result.CustomFields == nil {
+			result.CustomFields = make(map[string]any)
+		}
+		result.CustomFields[federationField] = s.label
+		if orig.Config != nil {
+			result.Config = maps.Clone(orig.Config)
+			result.Config[federationField] = s.label // Keeps sources apart in the config key, see EvalResult.Params
+		}
+		payload.Results[i] = restampIfIntact(orig, result) // Labeling is not an edit
+	}
+
+	s.cached, s.etag = payload.Results, resp.Header.Get("ETag")
+	return s.cached, nil
+}
+
+// stringList is a repeatable command line flag
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRemoteSourceLabelsResults(t *testing.T) {
+	up, unchanged := true, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up || r.URL.Path != "/api/evals" {
+			http.Error(w, "down", http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			unchanged++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fmt.Fprint(w, `{"results":[{"timestamp":"2025-01-01T10:00:00Z","model":"a","scores":{"combined":0.8},"chunk_size":500}]}`)
+	}))
+	defer srv.Close()
+
+	s, err := newRemoteSource("team-a=" + srv.URL + "/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	results, err := s.Load()
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Load = %d results, %v", len(results), err)
+	}
+	if results[0].CustomFields[federationField] != "team-a" || results[0].CustomFields["chunk_size"] != 500.0 {
+		t.Errorf("custom fields = %v", results[0].CustomFields)
+	}
+
+	// An unchanged result set is revalidated, not downloaded again
+	if results, err := s.Load(); err != nil || len(results) != 1 || unchanged != 1 {
+		t.Errorf("revalidated Load = %d results, %v, %d 304s", len(results), err, unchanged)
+	}
+
+	// Unreachable instance keeps serving the last good result set
+	up = false
+	if results, err := s.Load(); err == nil || len(results) != 1 {
+		t.Errorf("expected cached results with error, got %d, %v", len(results), err)
+	}
+
+	if s, err := newRemoteSource(srv.URL); err != nil || s.label == "" {
+		t.Errorf("label should default to host: %+v, %v", s, err)
+	}
+	if _, err := newRemoteSource("team=ftp://example"); err == nil {
+		t.Error("expected error for non-http URL")
+	}
+}
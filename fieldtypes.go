@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kinds of custom field values, a field collects the kinds of all its values in a bit set
+const (
+	kindNumber        uint8 = 1 << iota // JSON number
+	kindNumericString                   // String holding a number, e.g. "500"
+	kindBool                            // JSON true or false
+	kindBoolString                      // "true" or "false"
+	kindTime                            // String holding a timestamp or a date
+	kindString                          // Any other string
+	kindOther                           // Object or array
+)
+
+// Field types of CustomFieldTypes
+const (
+	fieldNumber = "number"
+	fieldBool   = "bool"
+	fieldTime   = "time"
+	fieldString = "string"
+)
+
+// valueKind classifies one custom field value, 0 for null
+func valueKind(v any) uint8 {
+	switch v := v.(type) {
+	case nil:
+		return 0
+	case float64:
+		return kindNumber
+	case bool:
+		return kindBool
+	case string:
+		s := strings.TrimSpace(v)
+		if _, err := strconv.ParseFloat(s, 64); err == nil {
+			return kindNumericString
+		}
+		if s == "true" || s == "false" {
+			return kindBoolString
+		}
+		if _, ok := parseFieldTime(s); ok {
+			return kindTime
+		}
+		return kindString
+	}
+	return kindOther
+}
+
+// parseFieldTime reads a timestamp (see parseTimestamp) or a plain date
+func parseFieldTime(s string) (time.Time, bool) {
+	if t, ok := parseTimestamp(s); ok {
+		return t, true
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	return t, err == nil
+}
+
+// fieldType promotes the kinds a field's values had to one type: numbers and numeric strings
+// are a number, booleans and "true"/"false" a bool, timestamps and dates a time; any other mix,
+// e.g. numbers and words or numbers and booleans, is a string
+func fieldType(kinds uint8) string {
+	switch {
+	case kinds == 0:
+		return fieldString
+	case kinds&^(kindNumber|kindNumericString) == 0:
+		return fieldNumber
+	case kinds&^(kindBool|kindBoolString) == 0:
+		return fieldBool
+	case kinds == kindTime:
+		return fieldTime
+	}
+	return fieldString
+}
+
+// mixedKinds reports whether a field had values of several JSON types, e.g. 500 and "500"
+func mixedKinds(kinds uint8) bool {
+	return kinds&(kinds-1) != 0
+}
+
+// formatField renders a config's value of a field of type typ (see fieldType) for the table:
+// numbers without trailing zeros (integers without decimals, at most 3 otherwise), the rest as is
+func formatField(typ, value string) string {
+	if typ != fieldNumber {
+		return value
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return value
+	}
+	if n == float64(int64(n)) {
+		return fmt.Sprintf("%.0f", n)
+	}
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.3f", n), "0"), ".")
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFieldType(t *testing.T) {
+	tests := []struct {
+		values []any
+		want   string
+		mixed  bool
+	}{
+		{[]any{500.0, 1000.0}, fieldNumber, false},
+		{[]any{500.0, "1000"}, fieldNumber, true},
+		{[]any{500.0, "auto"}, fieldString, true},
+		{[]any{true, "false"}, fieldBool, true},
+		{[]any{true, 1.0}, fieldString, true},
+		{[]any{"2025-01-02", "2025-01-03T10:00:00Z"}, fieldTime, false},
+		{[]any{"2025-01-02", "yesterday"}, fieldString, true},
+		{[]any{nil, 3.0}, fieldNumber, false},
+		{[]any{map[string]any{"a": 1.0}}, fieldString, false},
+	}
+	for _, tt := range tests {
+		var kinds uint8
+		for _, v := range tt.values {
+			kinds |= valueKind(v)
+		}
+		if got := fieldType(kinds); got != tt.want || mixedKinds(kinds) != tt.mixed {
+			t.Errorf("%v: type %s, mixed %v; want %s, %v", tt.values, got, mixedKinds(kinds), tt.want, tt.mixed)
+		}
+	}
+}
+
+func TestFormatField(t *testing.T) {
+	tests := []struct{ typ, value, want string }{
+		{fieldNumber, "500", "500"},
+		{fieldNumber, "0.125", "0.125"},
+		{fieldNumber, "0.70000", "0.7"},
+		{fieldNumber, "auto", "auto"},
+		{fieldString, "0.70000", "0.70000"},
+		{fieldBool, "true", "true"},
+	}
+	for _, tt := range tests {
+		if got := formatField(tt.typ, tt.value); got != tt.want {
+			t.Errorf("%s %q = %q, want %q", tt.typ, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestStatsFieldPromotion(t *testing.T) {
+	results := []EvalResult{
+		{Model: "a", CustomFields: map[string]any{"chunk_size": 500.0, "mode": 1.0, "rerank": true}},
+		{Model: "a", CustomFields: map[string]any{"chunk_size": "1000", "mode": "auto", "rerank": "false"}},
+	}
+	data := CalculateStats(results, nil)
+	want := map[string]string{"chunk_size": fieldNumber, "mode": fieldString, "rerank": fieldBool}
+	if !reflect.DeepEqual(data.CustomFieldTypes, want) || !reflect.DeepEqual(data.MixedFields, []string{"chunk_size", "mode", "rerank"}) {
+		t.Errorf("types = %v, mixed = %v", data.CustomFieldTypes, data.MixedFields)
+	}
+}
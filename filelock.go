@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"time"
+)
+
+// Lock file timings. Appends and rewrites hold the lock for milliseconds, so a lock older
+// than lockStaleAfter was left behind by a crashed process and is broken
+const (
+	lockTimeout      = 10 * time.Second
+	lockStaleAfter   = 2 * time.Minute
+	lockPollInterval = 20 * time.Millisecond
+)
+
+// lockFile takes an exclusive lock on path by creating path+".lock"
+// O_EXCL creation behaves the same on Windows, Linux and macOS and on network shares,
+// unlike flock/LockFileEx, so several goevals processes can safely write one JSONL file
+// The lock file holds a token unique to this lock, so unlocking never removes a lock another
+// process took after breaking this one as stale
+func lockFile(path string) (unlock func(), err error) {
+	lockPath := path + ".lock"
+	owner := lockToken()
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			_, err = f.WriteString(owner)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(lockPath)
+				return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+			}
+			return func() {
+				if data, err := os.ReadFile(lockPath); err == nil && string(data) == owner {
+					os.Remove(lockPath)
+				}
+			}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+
+		if breakStaleLock(lockPath) {
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for %s (remove it if no goevals process is writing)", lockPath)
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// lockToken identifies one lock: the process ID, for whoever finds it, and a random part
+func lockToken() string {
+	return fmt.Sprintf("%d %016x\n", os.Getpid(), rand.Uint64())
+}
+
+// breakStaleLock removes lockPath when it is older than lockStaleAfter, reporting whether it did
+// Processes finding the same stale lock race to break it, and the winner may already have taken
+// a new lock by the time another one acts. So the lock is renamed aside first, which only one of
+// them can do, and put back when what was renamed turns out to be fresh
+func breakStaleLock(lockPath string) bool {
+	if info, err := os.Stat(lockPath); err != nil || time.Since(info.ModTime()) <= lockStaleAfter {
+		return false
+	}
+	aside := lockPath + "." + strings.ReplaceAll(strings.TrimSpace(lockToken()), " ", "-")
+	if os.Rename(lockPath, aside) != nil {
+		return false // Broken by another process
+	}
+	defer os.Remove(aside)
+	if info, err := os.Stat(aside); err == nil && time.Since(info.ModTime()) > lockStaleAfter {
+		return true
+	}
+	// Restore the fresh lock, unless yet another process has locked meanwhile
+	os.Link(aside, lockPath)
+	return false
+}
+
+// replaceAttempts bounds how long replaceFile waits for readers to let go of the target
+const replaceAttempts = 10
+
+// replaceFile renames tmp over dst
+// On Windows the rename fails while another handle (a concurrent reader) has dst open,
+// so it is retried briefly before giving up
+func replaceFile(tmp, dst string) error {
+	var err error
+	for attempt := 1; attempt <= replaceAttempts; attempt++ {
+		if err = os.Rename(tmp, dst); err == nil {
+			return nil
+		}
+		time.Sleep(time.Duration(attempt) * 10 * time.Millisecond)
+	}
+	os.Remove(tmp)
+	return err
+}
@@ -0,0 +1,355 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formula is a derived score, e.g. quality = 0.6*faithfulness + 0.4*accuracy - 0.001*response_time_ms
+// Its expression can use numbers, + - * / and parentheses, min(), max() and abs(), and these
+// names: combined, response_time_ms, any custom score (including earlier formulas) and any
+// numeric custom field
+type Formula struct {
+	Name string
+	Expr string
+
+	eval formulaExpr
+}
+
+// formulaExpr evaluates a parsed expression, ok is false when a name it uses is missing
+type formulaExpr func(lookup func(string) (float64, bool)) (v float64, ok bool)
+
+// formulas holds the derived scores from --formula, in order
+var formulas []Formula
+
+// formulaName is what a derived score can be called, the same as a JSON key without quoting
+var formulaName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// parseFormula reads "name = expression"
+func parseFormula(spec string) (Formula, error) {
+	name, expr, ok := strings.Cut(spec, "=")
+	name, expr = strings.TrimSpace(name), strings.TrimSpace(expr)
+	switch {
+	case !ok || expr == "":
+		return Formula{}, fmt.Errorf("invalid formula %q: expected name = expression", spec)
+	case !formulaName.MatchString(name):
+		return Formula{}, fmt.Errorf("invalid formula %q: the name must be letters, digits and _", spec)
+	case name == "combined" || name == "response_time_ms":
+		return Formula{}, fmt.Errorf("invalid formula %q: %s is not a score that can be derived", spec, name)
+	}
+	p := &formulaParser{src: expr}
+	p.next()
+	eval, err := p.expr()
+	if err == nil && p.tok != "" {
+		err = fmt.Errorf("unexpected %q", p.tok)
+	}
+	if err != nil {
+		return Formula{}, fmt.Errorf("invalid formula %s: %w", name, err)
+	}
+	return Formula{Name: name, Expr: expr, eval: eval}, nil
+}
+
+// parseFormulas parses every --formula, a name can only be defined once
+func parseFormulas(specs []string) ([]Formula, error) {
+	var list []Formula
+	seen := make(map[string]bool)
+	for _, spec := range specs {
+		f, err := parseFormula(spec)
+		if err != nil {
+			return nil, err
+		}
+		if seen[f.Name] {
+			return nil, fmt.Errorf("formula %s is defined twice", f.Name)
+		}
+		seen[f.Name] = true
+		list = append(list, f)
+	}
+	return list, nil
+}
+
+// formulaParser is a recursive descent parser over a one token lookahead
+type formulaParser struct {
+	src string
+	pos int
+	tok string // Current token, "" at the end
+}
+
+// next moves to the next token: a number, a name or a single character operator
+func (p *formulaParser) next() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+	start := p.pos
+	if p.pos == len(p.src) {
+		p.tok = ""
+		return
+	}
+	switch c := p.src[p.pos]; {
+	case c >= '0' && c <= '9' || c == '.':
+		for p.pos < len(p.src) && (isDigit(p.src[p.pos]) || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		// Exponent, e.g. 1e-3
+		if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+			p.pos++
+			if p.pos < len(p.src) && (p.src[p.pos] == '-' || p.src[p.pos] == '+') {
+				p.pos++
+			}
+			for p.pos < len(p.src) && isDigit(p.src[p.pos]) {
+				p.pos++
+			}
+		}
+	case isNameStart(c):
+		for p.pos < len(p.src) && (isNameStart(p.src[p.pos]) || isDigit(p.src[p.pos])) {
+			p.pos++
+		}
+	default:
+		p.pos++
+	}
+	p.tok = p.src[start:p.pos]
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isNameStart(c byte) bool { return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' }
+
+// expr = term { ("+" | "-") term }
+func (p *formulaParser) expr() (formulaExpr, error) {
+	left, err := p.term()
+	for err == nil && (p.tok == "+" || p.tok == "-") {
+		op := p.tok
+		p.next()
+		var right formulaExpr
+		if right, err = p.term(); err == nil {
+			left = formulaOp(op, left, right)
+		}
+	}
+	return left, err
+}
+
+// term = unary { ("*" | "/") unary }
+func (p *formulaParser) term() (formulaExpr, error) {
+	left, err := p.unary()
+	for err == nil && (p.tok == "*" || p.tok == "/") {
+		op := p.tok
+		p.next()
+		var right formulaExpr
+		if right, err = p.unary(); err == nil {
+			left = formulaOp(op, left, right)
+		}
+	}
+	return left, err
+}
+
+// unary = "-" unary | primary
+func (p *formulaParser) unary() (formulaExpr, error) {
+	if p.tok != "-" {
+		return p.primary()
+	}
+	p.next()
+	inner, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	return func(lookup func(string) (float64, bool)) (float64, bool) {
+		v, ok := inner(lookup)
+		return -v, ok
+	}, nil
+}
+
+// primary = number | name | function "(" expr { "," expr } ")" | "(" expr ")"
+func (p *formulaParser) primary() (formulaExpr, error) {
+	tok := p.tok
+	switch {
+	case tok == "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case tok == "(":
+		p.next()
+		inner, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.next()
+		return inner, nil
+	case isDigit(tok[0]) || tok[0] == '.':
+		v, err := strconv.ParseFloat(tok, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", tok)
+		}
+		p.next()
+		return func(func(string) (float64, bool)) (float64, bool) { return v, true }, nil
+	case formulaName.MatchString(tok):
+		p.next()
+		if p.tok == "(" {
+			return p.call(tok)
+		}
+		return func(lookup func(string) (float64, bool)) (float64, bool) { return lookup(tok) }, nil
+	}
+	return nil, fmt.Errorf("unexpected %q", tok)
+}
+
+// call parses the arguments of min, max or abs
+func (p *formulaParser) call(name string) (formulaExpr, error) {
+	if name != "min" && name != "max" && name != "abs" {
+		return nil, fmt.Errorf("unknown function %s, use min, max or abs", name)
+	}
+	var args []formulaExpr
+	for sep := "("; p.tok == sep; sep = "," {
+		p.next()
+		arg, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	if p.tok != ")" {
+		return nil, fmt.Errorf("missing ) after the arguments of %s", name)
+	}
+	p.next()
+	if name == "abs" && len(args) != 1 {
+		return nil, fmt.Errorf("abs takes one argument")
+	}
+	return func(lookup func(string) (float64, bool)) (float64, bool) {
+		values := make([]float64, len(args))
+		for i, arg := range args {
+			v, ok := arg(lookup)
+			if !ok {
+				return 0, false
+			}
+			values[i] = v
+		}
+		switch name {
+		case "abs":
+			return math.Abs(values[0]), true
+		case "min":
+			return slices.Min(values), true
+		}
+		return slices.Max(values), true
+	}, nil
+}
+
+// formulaOp applies an arithmetic operator, missing values propagate
+func formulaOp(op string, left, right formulaExpr) formulaExpr {
+	return func(lookup func(string) (float64, bool)) (float64, bool) {
+		a, ok := left(lookup)
+		if !ok {
+			return 0, false
+		}
+		b, ok := right(lookup)
+		if !ok {
+			return 0, false
+		}
+		switch op {
+		case "+":
+			return a + b, true
+		case "-":
+			return a - b, true
+		case "*":
+			return a * b, true
+		}
+		return a / b, true
+	}
+}
+
+// formulaOf returns the expression of a derived score, "" for a score as logged
+func formulaOf(name string) string {
+	for _, f := range formulas {
+		if f.Name == name {
+			return f.Expr
+		}
+	}
+	return ""
+}
+
+// formulaValue looks up a name used in a formula for one result
+func formulaValue(result EvalResult, name string) (float64, bool) {
+	switch name {
+	case "combined":
+		return result.Scores.Combined, true
+	case "response_time_ms":
+		return float64(result.ResponseTimeMS), true
+	}
+	if v, ok := result.Scores.Custom[name]; ok {
+		return v, true
+	}
+	v, ok := result.Fields()[name].(float64)
+	return v, ok
+}
+
+// deriveScores returns copies of results with the derived scores added to their custom scores
+// A result gets no value for a formula using a name it doesn't have, or one that divides by zero
+func deriveScores(results []EvalResult, list []Formula) []EvalResult {
+	if len(list) == 0 || len(results) == 0 {
+		return results
+	}
+	out := make([]EvalResult, len(results))
+	for i, result := range results {
+		out[i] = result
+		derived := false
+		for _, f := range list {
+			v, ok := f.eval(func(name string) (float64, bool) { return formulaValue(out[i], name) })
+			if !ok || math.IsNaN(v) || math.IsInf(v, 0) {
+				continue
+			}
+			if !derived {
+				out[i].Scores.Custom = maps.Clone(out[i].Scores.Custom)
+				if out[i].Scores.Custom == nil {
+					out[i].Scores.Custom = make(map[string]float64)
+				}
+				derived = true
+			}
+			out[i].Scores.Custom[f.Name] = v
+		}
+		if derived {
+			// Like reweighting, a derived score is a view of the data, not an edit
+			out[i] = restampIfIntact(result, out[i])
+		}
+	}
+	return out
+}
+
+// derivingStore adds the derived scores to everything read from the wrapped store
+type derivingStore struct {
+	Store
+	formulas []Formula
+}
+
+func (s derivingStore) Query(ctx context.Context, q Query) ([]EvalResult, error) {
+	results, err := s.Store.Query(ctx, q)
+	return deriveScores(results, s.formulas), err
+}
+
+func (s derivingStore) Stats(ctx context.Context) (DashboardData, error) {
+	data, err := s.Store.Stats(ctx)
+	if err != nil {
+		return data, err
+	}
+	// The custom score columns and averages depend on the derived scores, so recompute
+	return CalculateStats(deriveScores(data.Results, s.formulas), slasFor(ctx)), nil
+}
+
+func (s derivingStore) Watch(ctx context.Context, interval time.Duration) <-chan []EvalResult {
+	in := s.Store.Watch(ctx, interval)
+	out := make(chan []EvalResult)
+	go func() {
+		defer close(out)
+		for batch := range in {
+			select {
+			case out <- deriveScores(batch, s.formulas):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseFormula(t *testing.T) {
+	result := EvalResult{
+		ResponseTimeMS: 200,
+		Scores:         ScoreBreakdown{Combined: 0.5, Custom: map[string]float64{"faithfulness": 0.8, "accuracy": 0.6}},
+		CustomFields:   map[string]any{"cost_usd": 0.02, "model_family": "gpt"},
+	}
+	lookup := func(name string) (float64, bool) { return formulaValue(result, name) }
+	for expr, want := range map[string]float64{
+		"0.6*faithfulness + 0.4*accuracy - 0.001*response_time_ms": 0.52,
+		"(faithfulness + accuracy) / 2":                            0.7,
+		"-accuracy + 1":                                            0.4,
+		"min(faithfulness, accuracy, combined)":                    0.5,
+		"max(faithfulness, 2 * accuracy)":                          1.2,
+		"abs(combined - faithfulness)":                             0.3,
+		"combined - cost_usd * 1e1":                                0.3,
+		"2 - 3 - 4":                                                -5,
+		"12 / 3 / 2":                                               2,
+	} {
+		f, err := parseFormula("x = " + expr)
+		if err != nil {
+			t.Errorf("%s: %v", expr, err)
+			continue
+		}
+		if got, ok := f.eval(lookup); !ok || math.Abs(got-want) > 1e-9 {
+			t.Errorf("%s = %v, %v; want %v", expr, got, ok, want)
+		}
+	}
+
+	f, _ := parseFormula("x = faithfulness + toxicity")
+	if _, ok := f.eval(lookup); ok {
+		t.Error("a missing score should leave the formula without a value")
+	}
+	f, _ = parseFormula("x = combined + model_family")
+	if _, ok := f.eval(lookup); ok {
+		t.Error("a text field should leave the formula without a value")
+	}
+
+	for _, bad := range []string{"quality", "= a + b", "combined = a", "2x = a", "x = a +", "x = (a", "x = a b", "x = sqrt(a)", "x = abs(a, b)", "x = min()", "x = a $ b"} {
+		if _, err := parseFormula(bad); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+	if _, err := parseFormulas([]string{"x = a", "x = b"}); err == nil {
+		t.Error("a formula defined twice should be an error")
+	}
+}
+
+func TestDerivedScores(t *testing.T) {
+	withStore(t, `{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"t1","scores":{"combined":0.5,"faithfulness":1,"accuracy":0.5}}
+{"timestamp":"2025-01-01T10:01:00Z","model":"a","test_id":"t2","scores":{"combined":0.5,"faithfulness":0.5,"accuracy":0}}
+{"timestamp":"2025-01-01T10:02:00Z","model":"b","test_id":"t1","scores":{"combined":0.5,"accuracy":1}}`)
+	var err error
+	formulas, err = parseFormulas([]string{"quality = 0.6*faithfulness + 0.4*accuracy", "boosted = quality * 2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store = derivingStore{Store: store, formulas: formulas}
+	defer func() { formulas = nil }()
+
+	results, _ := store.Query(t.Context(), Query{})
+	if q := results[0].Scores.Custom["quality"]; math.Abs(q-0.8) > 1e-9 || math.Abs(results[0].Scores.Custom["boosted"]-1.6) > 1e-9 {
+		t.Errorf("derived = %v", results[0].Scores.Custom)
+	}
+	if _, ok := results[2].Scores.Custom["quality"]; ok {
+		t.Error("a result without faithfulness shouldn't get a quality score")
+	}
+
+	data, _ := store.Stats(t.Context())
+	if !strings.Contains(strings.Join(data.CustomScores, ","), "quality") || math.Abs(data.ModelStats["a"].CustomScores["quality"]-0.55) > 1e-9 {
+		t.Errorf("stats = %v, %v", data.CustomScores, data.ModelStats["a"].CustomScores)
+	}
+
+	rec := httptest.NewRecorder()
+	dashboardHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !strings.Contains(rec.Body.String(), `title="Derived: quality * 2"`) {
+		t.Error("the comparison table has no derived quality column")
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Long texts are cut on the tests page so a few huge responses don't blow up its size; the
+// modal fetches the rest from /api/tests/{id}/full when asked
+const (
+	modalTextLimit = 4000  // Characters of each text in a test's modal
+	rowTextLimit   = 300   // Characters of the question in the results table
+	pageTextLimit  = 20000 // Characters of a text on other pages (compare, question bank, config)
+)
+
+// ClippedText is a text cut to a limit for the tests page
+type ClippedText struct {
+	Text      string
+	Truncated bool
+	Chars     int // Length of the full text in characters
+}
+
+// clipText cuts s to limit characters, after sanitizeText
+func clipText(s string, limit int) ClippedText {
+	s = sanitizeText(s)
+	c := ClippedText{Text: s, Chars: utf8.RuneCountInString(s)}
+	if c.Chars <= limit {
+		return c
+	}
+	n := 0
+	for i := range s {
+		if n == limit {
+			c.Text, c.Truncated = s[:i], true
+			break
+		}
+		n++
+	}
+	return c
+}
+
+// displayText is s sanitized and cut to limit characters, ending in "…" when cut, for texts
+// shown without a way to expand them
+func displayText(s string, limit int) string {
+	c := clipText(s, limit)
+	if c.Truncated {
+		return c.Text + "…"
+	}
+	return c.Text
+}
+
+// sanitizeText prepares model output and other recorded text for a page, on top of the escaping
+// html/template does: invalid UTF-8 becomes U+FFFD, and control characters other than tabs and
+// line breaks are dropped, as are bidirectional overrides and isolates, which can make a response
+// read differently from what it contains
+func sanitizeText(s string) string {
+	if utf8.ValidString(s) && !strings.ContainsFunc(s, unsafeRune) {
+		return s
+	}
+	return strings.Map(func(r rune) rune {
+		if unsafeRune(r) {
+			return -1
+		}
+		return r
+	}, strings.ToValidUTF8(s, "\uFFFD"))
+}
+
+func unsafeRune(r rune) bool {
+	switch {
+	case r == '\n' || r == '\t' || r == '\r':
+		return false
+	case r >= '\u202a' && r <= '\u202e', r >= '\u2066' && r <= '\u2069':
+		return true
+	}
+	return unicode.IsControl(r)
+}
+
+// fullTextHandler returns one result with every text in full, e.g. /api/tests/{id}/full where
+// id is the result ID used by annotations
+func fullTextHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	results, err := store.Query(r.Context(), Query{})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	for _, result := range results {
+		if resultID(result) != id {
+			continue
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			log.Printf("Error encoding JSON: %v", err)
+		}
+		return
+	}
+	http.Error(w, "No result with ID "+id, http.StatusNotFound)
+}
+
+// permalinkURL links to the page of result's test, narrowed to its run when it has a run_id
+func permalinkURL(result EvalResult) string {
+	link := "/tests/" + url.PathEscape(result.TestID)
+	if runID, _ := runOf(result); runID != "" {
+		link += "?run=" + url.QueryEscape(runID)
+	}
+	return link
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClipText(t *testing.T) {
+	if c := clipText("short", 10); c.Truncated || c.Text != "short" || c.Chars != 5 {
+		t.Errorf("short text = %+v", c)
+	}
+	// Cut by characters, not bytes
+	if c := clipText("żółw żółw", 4); !c.Truncated || c.Text != "żółw" || c.Chars != 9 {
+		t.Errorf("clipped = %+v", c)
+	}
+}
+
+func TestSanitizeText(t *testing.T) {
+	tests := map[string]string{
+		"plain\ttext\nwith lines": "plain\ttext\nwith lines",
+		"a\x00b\x1bc":             "abc",
+		"\u202eevil\u202c":        "evil", // Right-to-left override
+		"bad \xff byte":           "bad \ufffd byte",
+	}
+	for in, want := range tests {
+		if got := sanitizeText(in); got != want {
+			t.Errorf("sanitizeText(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := displayText("abc\x00def", 3); got != "abc…" {
+		t.Errorf("displayText = %q", got)
+	}
+}
+
+func TestTemplateSafety(t *testing.T) {
+	line, _ := json.Marshal(map[string]any{"timestamp": "2025-01-01T10:00:00Z", "model": `a');alert(1);//`, "test_id": "t1",
+		"question": `<img src=x onerror=alert(1)>`, "response": "<script>alert(1)</script>\u202e", "scores": map[string]float64{"combined": 0.5},
+		"judge_reasoning": `"><script>alert(2)</script>`})
+	withStore(t, string(line))
+	mux := http.NewServeMux()
+	registerRoutes(mux, "")
+
+	for _, page := range []string{"/", "/tests", "/compare?test_id=t1", "/questions"} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, page, nil))
+		body := rec.Body.String()
+		if strings.Contains(body, "<script>alert") || strings.Contains(body, "<img src=x") || strings.Contains(body, "\u202e") {
+			t.Errorf("%s renders recorded content unescaped", page)
+		}
+		if strings.Contains(body, "alert(1);//'") || strings.Contains(body, `onclick="window.location`) {
+			t.Errorf("%s puts a config key in an inline handler", page)
+		}
+	}
+}
+
+func TestFullText(t *testing.T) {
+	long := strings.Repeat("lorem ipsum ", 1000) + "THE END"
+	line, _ := json.Marshal(map[string]any{"timestamp": "2025-01-01T10:00:00Z", "model": "a", "test_id": "t1", "question": "q", "response": long, "scores": map[string]float64{"combined": 0.5}})
+	withStore(t, string(line))
+	mux := http.NewServeMux()
+	registerRoutes(mux, "")
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tests", nil))
+	body := rec.Body.String()
+	if strings.Contains(body, "THE END") || !strings.Contains(body, `data-field="response" data-truncated`) || !strings.Contains(body, "Show all "+string(humanNumber(12007))+" chars") {
+		t.Error("the tests page has the full response or no expand button")
+	}
+
+	results, _ := store.Query(t.Context(), Query{})
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/tests/"+resultID(results[0])+"/full", nil))
+	var full EvalResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &full); err != nil || full.Response != long {
+		t.Errorf("full = %d, %v", rec.Code, err)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/tests/0000/full", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown ID = %d", rec.Code)
+	}
+}
+
+func TestPermalink(t *testing.T) {
+	withStore(t, `{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"t1","question":"first run question","metadata":{"run_id":"r1"},"scores":{"combined":0.2}}
+{"timestamp":"2025-01-02T10:00:00Z","model":"a","test_id":"t1","question":"second run question","metadata":{"run_id":"r2"},"scores":{"combined":0.9}}
+{"timestamp":"2025-01-02T10:00:00Z","model":"a","test_id":"t2","question":"other test","metadata":{"run_id":"r2"},"scores":{"combined":0.9}}`)
+	mux := http.NewServeMux()
+	registerRoutes(mux, "")
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	body := get("/tests/t1").Body.String()
+	if !strings.Contains(body, "first run question") || !strings.Contains(body, "second run question") || strings.Contains(body, "other test") {
+		t.Error("the permalink doesn't show every result of t1 and only them")
+	}
+	if strings.Contains(body, `class="modal"`) || !strings.Contains(body, `class="modal-content result-card"`) {
+		t.Error("the permalink shows modals instead of the results in full")
+	}
+
+	body = get("/tests/t1?run=r1").Body.String()
+	if !strings.Contains(body, "first run question") || strings.Contains(body, "second run question") {
+		t.Error("?run= doesn't narrow the permalink to the run")
+	}
+
+	if rec := get("/tests/nope"); rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), "No results for test nope") {
+		t.Errorf("unknown test = %d", rec.Code)
+	}
+
+	// The modals of the tests page link to the permalink of their result
+	if body := get("/tests").Body.String(); !strings.Contains(body, `href="/tests/t1?run=r1"`) {
+		t.Error("no permalink in the modals")
+	}
+}
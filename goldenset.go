@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"time"
+)
+
+// DatasetCase is one test of the golden set, see --dataset
+type DatasetCase struct {
+	TestID   string   `json:"test_id"`
+	Question string   `json:"question,omitempty"`
+	Expected string   `json:"expected,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Weight   *float64 `json:"weight,omitempty"` // Default weight of the test's results, see TestWeight
+}
+
+// GoldenSet is the --dataset file: the tests an eval suite is made of, kept apart from the
+// results so records only need a test_id
+type GoldenSet struct {
+	Cases []DatasetCase // In file order
+	byID  map[string]int
+}
+
+// goldenSet holds the cases from --dataset, nil = results carry their own questions
+var goldenSet *GoldenSet
+
+// loadGoldenSet reads a JSONL file of cases, an empty path means no golden set
+func loadGoldenSet(path string) (*GoldenSet, error) {
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dataset: %w", err)
+	}
+	defer f.Close()
+
+	set := &GoldenSet{byID: make(map[string]int)}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var c DatasetCase
+		if err := json.Unmarshal(text, &c); err != nil {
+			return nil, fmt.Errorf("invalid dataset %s line %d: %w", path, line, err)
+		}
+		if c.TestID == "" {
+			return nil, fmt.Errorf("invalid dataset %s line %d: no test_id", path, line)
+		}
+		if c.Weight != nil && !validWeight(*c.Weight) {
+			return nil, fmt.Errorf("invalid dataset %s line %d: weight must be a non-negative number", path, line)
+		}
+		if _, ok := set.byID[c.TestID]; ok {
+			return nil, fmt.Errorf("invalid dataset %s line %d: test_id %q appears twice", path, line, c.TestID)
+		}
+		set.byID[c.TestID] = len(set.Cases)
+		set.Cases = append(set.Cases, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dataset: %w", err)
+	}
+	return set, nil
+}
+
+// Lookup returns the case of testID, ok is false when the golden set has none
+func (set *GoldenSet) Lookup(testID string) (DatasetCase, bool) {
+	if set == nil {
+		return DatasetCase{}, false
+	}
+	i, ok := set.byID[testID]
+	if !ok {
+		return DatasetCase{}, false
+	}
+	return set.Cases[i], true
+}
+
+// TestWeight is how much the result counts in averages: its own weight, else the weight of its
+// case in --dataset, else 1. A weight of 0 leaves the result out of the averages
+func (er EvalResult) TestWeight() float64 {
+	if er.Weight != nil && validWeight(*er.Weight) {
+		return *er.Weight
+	}
+	if c, ok := goldenSet.Lookup(er.TestID); ok && c.Weight != nil {
+		return *c.Weight
+	}
+	return 1
+}
+
+// validWeight reports whether w can weigh a test: finite and not negative
+func validWeight(w float64) bool {
+	return w >= 0 && !math.IsInf(w, 0)
+}
+
+// Join returns copies of results with the question and expected answer of their case filled in
+// where the record leaves them out; what a record logged itself wins
+func (set *GoldenSet) Join(results []EvalResult) []EvalResult {
+	if set == nil || len(results) == 0 {
+		return results
+	}
+	out := make([]EvalResult, len(results))
+	for i, result := range results {
+		out[i] = result
+		c, ok := set.Lookup(result.TestID)
+		question := result.Question == "" && c.Question != ""
+		expected := result.Expected == "" && c.Expected != ""
+		if !ok || !question && !expected {
+			continue
+		}
+		if question {
+			out[i].Question = c.Question
+		}
+		if expected {
+			out[i].Expected = c.Expected
+		}
+		// Like derived scores, the joined text is a view of the data, not an edit
+		out[i] = restampIfIntact(result, out[i])
+	}
+	return out
+}
+
+// ModelCoverage is how much of the golden set one model has results for
+type ModelCoverage struct {
+	Model   string   `json:"model"`
+	Covered int      `json:"covered"`
+	Missing []string `json:"missing"` // test_ids without a result, in dataset order
+}
+
+// DatasetCoverage compares the golden set with the test_ids results ran
+type DatasetCoverage struct {
+	Tests   int             `json:"tests"`
+	Models  []ModelCoverage `json:"models"`  // Least covered first
+	Unknown []string        `json:"unknown"` // test_ids of results that aren't in the golden set, sorted
+}
+
+// Coverage lists, per model, which cases of the golden set have no result
+// Errored results count as run: the test was attempted
+func (set *GoldenSet) Coverage(results []EvalResult) DatasetCoverage {
+	ran := make(map[string]map[string]bool)
+	unknown := make(map[string]bool)
+	for _, result := range results {
+		if result.TestID == "" {
+			continue
+		}
+		// A model whose results are all outside the golden set still covers none of it
+		if ran[result.Model] == nil {
+			ran[result.Model] = make(map[string]bool)
+		}
+		if _, ok := set.Lookup(result.TestID); ok {
+			ran[result.Model][result.TestID] = true
+		} else {
+			unknown[result.TestID] = true
+		}
+	}
+
+	coverage := DatasetCoverage{Tests: len(set.Cases), Models: []ModelCoverage{}, Unknown: sortedKeys(unknown, "")}
+	if coverage.Unknown == nil {
+		coverage.Unknown = []string{}
+	}
+	for model, tests := range ran {
+		mc := ModelCoverage{Model: model, Covered: len(tests), Missing: []string{}}
+		for _, c := range set.Cases {
+			if !tests[c.TestID] {
+				mc.Missing = append(mc.Missing, c.TestID)
+			}
+		}
+		coverage.Models = append(coverage.Models, mc)
+	}
+	sort.Slice(coverage.Models, func(i, j int) bool {
+		a, b := coverage.Models[i], coverage.Models[j]
+		if a.Covered != b.Covered {
+			return a.Covered < b.Covered
+		}
+		return a.Model < b.Model
+	})
+	return coverage
+}
+
+// withDatasetCases adds the golden set's cases that have no results yet to the question bank
+func withDatasetCases(bank []QuestionEntry) []QuestionEntry {
+	if goldenSet == nil {
+		return bank
+	}
+	listed := make(map[string]bool, len(bank))
+	for _, entry := range bank {
+		listed[entry.TestID] = true
+	}
+	n := len(bank)
+	for _, c := range goldenSet.Cases {
+		if !listed[c.TestID] {
+			bank = append(bank, QuestionEntry{TestID: c.TestID, Question: c.Question, Expected: c.Expected, Tags: slices.Clone(c.Tags)})
+		}
+	}
+	if len(bank) > n {
+		sort.Slice(bank, func(i, j int) bool { return bank[i].TestID < bank[j].TestID })
+	}
+	return bank
+}
+
+// datasetAPIHandler returns the golden set's coverage by the (filtered) results as JSON
+func datasetAPIHandler(w http.ResponseWriter, r *http.Request) {
+	if goldenSet == nil {
+		http.Error(w, "No dataset loaded, start the server with --dataset", http.StatusNotFound)
+		return
+	}
+	results, err := store.Query(r.Context(), dashboardFilter(r))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(goldenSet.Coverage(results)); err != nil {
+		log.Printf("Error encoding JSON: %v", err)
+	}
+}
+
+// joiningStore fills in questions and expected answers from the golden set for everything read
+// from the wrapped store
+type joiningStore struct {
+	Store
+	set *GoldenSet
+}
+
+func (s joiningStore) Query(ctx context.Context, q Query) ([]EvalResult, error) {
+	results, err := s.Store.Query(ctx, q)
+	return s.set.Join(results), err
+}
+
+func (s joiningStore) Stats(ctx context.Context) (DashboardData, error) {
+	data, err := s.Store.Stats(ctx)
+	// The joined fields are text, none of the aggregates depend on them
+	data.Results = s.set.Join(data.Results)
+	return data, err
+}
+
+func (s joiningStore) Watch(ctx context.Context, interval time.Duration) <-chan []EvalResult {
+	in := s.Store.Watch(ctx, interval)
+	out := make(chan []EvalResult)
+	go func() {
+		defer close(out)
+		for batch := range in {
+			select {
+			case out <- s.set.Join(batch):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
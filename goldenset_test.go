@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func writeGoldenSet(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "golden.jsonl")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const testGoldenSet = `{"test_id":"q1","question":"What is 2+2?","expected":"4","tags":["math"]}
+
+{"test_id":"q2","question":"Capital of France?","expected":"Paris","tags":["geo"]}
+{"test_id":"q3","question":"Largest ocean?"}
+`
+
+func TestLoadGoldenSet(t *testing.T) {
+	set, err := loadGoldenSet(writeGoldenSet(t, testGoldenSet))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set.Cases) != 3 {
+		t.Fatalf("cases = %+v", set.Cases)
+	}
+	if c, ok := set.Lookup("q2"); !ok || c.Expected != "Paris" || !slices.Equal(c.Tags, []string{"geo"}) {
+		t.Errorf("q2 = %+v, %v", c, ok)
+	}
+	if _, ok := set.Lookup("q9"); ok {
+		t.Error("found q9")
+	}
+	if set, err := loadGoldenSet(""); set != nil || err != nil {
+		t.Errorf("no path = %v, %v", set, err)
+	}
+
+	for content, want := range map[string]string{
+		`{"question":"no id"}`:                     "line 1: no test_id",
+		"{\"test_id\":\"a\"}\n{\"test_id\":\"a\"}": `line 2: test_id "a" appears twice`,
+		"{\"test_id\":\"a\"}\nnope":                "line 2",
+	} {
+		if _, err := loadGoldenSet(writeGoldenSet(t, content)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: %v, want %q", content, err, want)
+		}
+	}
+}
+
+func TestGoldenSetJoin(t *testing.T) {
+	set, err := loadGoldenSet(writeGoldenSet(t, testGoldenSet))
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := []EvalResult{
+		{Model: "a", TestID: "q1"},
+		{Model: "a", TestID: "q2", Question: "Capital of France (logged)?"},
+		{Model: "a", TestID: "q9"},
+	}
+	results[0].Integrity = contentHash(results[0])
+	joined := set.Join(results)
+	if joined[0].Question != "What is 2+2?" || joined[0].Expected != "4" || isTampered(joined[0]) {
+		t.Errorf("q1 = %+v", joined[0])
+	}
+	if joined[1].Question != "Capital of France (logged)?" || joined[1].Expected != "Paris" {
+		t.Errorf("q2 = %+v", joined[1])
+	}
+	if joined[2].Question != "" {
+		t.Errorf("q9 = %+v", joined[2])
+	}
+	if results[0].Question != "" {
+		t.Error("Join modified its input")
+	}
+
+	goldenSet = set
+	defer func() { goldenSet = nil }()
+	if tags := resultTags(results[0]); !slices.Equal(tags, []string{"math"}) {
+		t.Errorf("tags = %v", tags)
+	}
+	tagged := EvalResult{TestID: "q1", CustomFields: map[string]any{"tags": "logged"}}
+	if tags := resultTags(tagged); !slices.Equal(tags, []string{"logged"}) {
+		t.Errorf("logged tags = %v", tags)
+	}
+}
+
+func TestGoldenSetCoverage(t *testing.T) {
+	set, err := loadGoldenSet(writeGoldenSet(t, testGoldenSet))
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := []EvalResult{
+		{Model: "a", TestID: "q1"},
+		{Model: "a", TestID: "q2"},
+		{Model: "a", TestID: "q3", Error: "timeout"},
+		{Model: "b", TestID: "q2"},
+		{Model: "b", TestID: "q2"},
+		{Model: "b", TestID: "extra"},
+		{Model: "c", TestID: "other"},
+	}
+	got := set.Coverage(results)
+	want := DatasetCoverage{
+		Tests: 3,
+		Models: []ModelCoverage{
+			{Model: "c", Covered: 0, Missing: []string{"q1", "q2", "q3"}},
+			{Model: "b", Covered: 1, Missing: []string{"q1", "q3"}},
+			{Model: "a", Covered: 3, Missing: []string{}},
+		},
+		Unknown: []string{"extra", "other"},
+	}
+	gotJSON, _ := json.Marshal(got)
+	wantJSON, _ := json.Marshal(want)
+	if string(gotJSON) != string(wantJSON) {
+		t.Errorf("coverage = %s\nwant %s", gotJSON, wantJSON)
+	}
+}
+
+func TestDatasetEndpoints(t *testing.T) {
+	set, err := loadGoldenSet(writeGoldenSet(t, testGoldenSet))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	datasetAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/api/dataset", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("without --dataset = %d", rec.Code)
+	}
+
+	goldenSet = set
+	withStore(t, `{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"q1","scores":{"combined":0.8}}`)
+	store = joiningStore{Store: store, set: set}
+	defer func() { goldenSet = nil }()
+
+	rec = httptest.NewRecorder()
+	datasetAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/api/dataset", nil))
+	var coverage DatasetCoverage
+	if err := json.Unmarshal(rec.Body.Bytes(), &coverage); err != nil || len(coverage.Models) != 1 || coverage.Models[0].Covered != 1 {
+		t.Errorf("coverage = %s (%v)", rec.Body, err)
+	}
+
+	// The question bank has the joined question and the cases no model ran
+	rec = httptest.NewRecorder()
+	questionsAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/api/questions", nil))
+	var bank []QuestionEntry
+	if err := json.Unmarshal(rec.Body.Bytes(), &bank); err != nil || len(bank) != 3 {
+		t.Fatalf("bank = %s (%v)", rec.Body, err)
+	}
+	if bank[0].Question != "What is 2+2?" || bank[0].Evaluations != 1 || !slices.Equal(bank[0].Tags, []string{"math"}) {
+		t.Errorf("q1 = %+v", bank[0])
+	}
+	if bank[2].TestID != "q3" || bank[2].Evaluations != 0 || bank[2].Question != "Largest ocean?" {
+		t.Errorf("q3 = %+v", bank[2])
+	}
+
+	rec = httptest.NewRecorder()
+	questionsHandler(rec, httptest.NewRequest(http.MethodGet, "/questions", nil))
+	if !strings.Contains(rec.Body.String(), "not run") {
+		t.Error("questions page doesn't mark q3 as not run")
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+// ModelGroup is a model with several configs in the comparison table, shown as a collapsible row
+// holding the aggregate of its configs
+type ModelGroup struct {
+	Model   string    // Model name, see modelName
+	Configs []string  // Config keys, in the order of DashboardData.Models
+	Stat    ModelStat // Aggregate over Configs, Model is the model name
+}
+
+// Collapsible reports whether the group gets its own row, a model with one config is shown as is
+func (g ModelGroup) Collapsible() bool {
+	return len(g.Configs) > 1
+}
+
+// groupByModel groups the configs of data by model, in order of their first config
+// With grouped false every config is a group of its own, so the table renders flat
+func groupByModel(data DashboardData, grouped bool) []ModelGroup {
+	var groups []ModelGroup
+	index := make(map[string]int)
+	for _, config := range data.Models {
+		model := modelName(config)
+		if !grouped {
+			model = config
+		}
+		i, ok := index[model]
+		if !ok {
+			i = len(groups)
+			index[model] = i
+			groups = append(groups, ModelGroup{Model: modelName(config)})
+		}
+		groups[i].Configs = append(groups[i].Configs, config)
+	}
+	for i := range groups {
+		if groups[i].Collapsible() {
+			groups[i].Stat = mergeModelStats(groups[i].Model, groups[i].Configs, data.ModelStats)
+		}
+	}
+	return groups
+}
+
+// mergeModelStats aggregates the stats of configs as if their results were one config
+// Averages are weighted by the results they were taken over; custom scores by the scored results,
+// since ModelStat doesn't keep how many results had each score
+func mergeModelStats(model string, configs []string, stats map[string]ModelStat) ModelStat {
+	merged := ModelStat{Model: model, ActualModelName: model, DisplayName: modelRegistry.DisplayName(model), CustomScores: make(map[string]float64)}
+	customWeights := make(map[string]float64)
+	var scored, slaWithin float64
+	first := true
+	for _, config := range configs {
+		stat := stats[config]
+		n := stat.ScoredWeight // Test weights, so the group average weighs tests like its configs do
+		merged.TestCount += stat.TestCount
+		merged.Errors += stat.Errors
+		if n > 0 {
+			merged.AvgScore += stat.AvgScore * n
+			scored += n
+			if first || stat.MinScore < merged.MinScore {
+				merged.MinScore = stat.MinScore
+			}
+			if first || stat.MaxScore > merged.MaxScore {
+				merged.MaxScore = stat.MaxScore
+			}
+			first = false
+		}
+		for name, score := range stat.CustomScores {
+			merged.CustomScores[name] += score * n
+			customWeights[name] += n
+		}
+		merged.AvgTimeMS += stat.AvgTimeMS * float64(stat.TestCount) // Over all results, see StatsAggregator.Data
+		merged.SLATimed += stat.SLATimed
+		slaWithin += stat.WithinSLA * float64(stat.SLATimed)
+		merged.SLAMS = max(merged.SLAMS, stat.SLAMS)
+		merged.AvgContexts += stat.AvgContexts * float64(stat.ContextResults)
+		merged.AvgContextChars += stat.AvgContextChars * float64(stat.ContextResults)
+		merged.ContextResults += stat.ContextResults
+		merged.EstCost += stat.EstCost
+		merged.CostResults += stat.CostResults
+	}
+	if scored > 0 {
+		merged.AvgScore /= scored
+	}
+	for name, w := range customWeights {
+		if w > 0 {
+			merged.CustomScores[name] /= w
+		} else {
+			delete(merged.CustomScores, name)
+		}
+	}
+	if merged.SLATimed > 0 {
+		merged.WithinSLA = slaWithin / float64(merged.SLATimed)
+	}
+	if merged.ContextResults > 0 {
+		merged.AvgContexts /= float64(merged.ContextResults)
+		merged.AvgContextChars /= float64(merged.ContextResults)
+	}
+	if merged.TestCount > 0 {
+		merged.AvgTimeMS /= float64(merged.TestCount)
+		merged.ErrorRate = float64(merged.Errors) / float64(merged.TestCount)
+	}
+	return merged
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGroupByModel(t *testing.T) {
+	data := DashboardData{
+		Models: []string{"gemma", "llama3|k=1", "llama3:8b", "llama3|k=2"},
+		ModelStats: map[string]ModelStat{
+			"gemma":      {TestCount: 2, ScoredWeight: 2, AvgScore: 0.5, MinScore: 0.4, MaxScore: 0.6, AvgTimeMS: 100},
+			"llama3|k=1": {TestCount: 4, Errors: 1, ScoredWeight: 3, AvgScore: 0.9, MinScore: 0.8, MaxScore: 1, AvgTimeMS: 100, CustomScores: map[string]float64{"acc": 1}, EstCost: 0.5, CostResults: 4},
+			"llama3:8b":  {TestCount: 1, ScoredWeight: 1, AvgScore: 0.3, MinScore: 0.3, MaxScore: 0.3},
+			"llama3|k=2": {TestCount: 1, ScoredWeight: 1, AvgScore: 0.5, MinScore: 0.5, MaxScore: 0.5, AvgTimeMS: 400, CustomScores: map[string]float64{"acc": 0}, EstCost: 0.25, CostResults: 1},
+		},
+	}
+
+	groups := groupByModel(data, true)
+	if len(groups) != 3 || groups[0].Model != "gemma" || groups[1].Model != "llama3" || groups[2].Model != "llama3:8b" {
+		t.Fatalf("groups = %+v", groups)
+	}
+	if groups[0].Collapsible() || !groups[1].Collapsible() {
+		t.Errorf("collapsible = %v, %v", groups[0].Collapsible(), groups[1].Collapsible())
+	}
+	if got := groups[1].Configs; len(got) != 2 || got[0] != "llama3|k=1" || got[1] != "llama3|k=2" {
+		t.Errorf("configs = %v", got)
+	}
+
+	// 3 scored results at 0.9 and 1 at 0.5
+	stat := groups[1].Stat
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	if stat.TestCount != 5 || stat.Errors != 1 || !near(stat.AvgScore, 0.8) || stat.MinScore != 0.5 || stat.MaxScore != 1 {
+		t.Errorf("merged = %+v", stat)
+	}
+	if !near(stat.CustomScores["acc"], 0.75) || !near(stat.AvgTimeMS, 160) || !near(stat.ErrorRate, 0.2) {
+		t.Errorf("acc = %v, time = %v, error rate = %v", stat.CustomScores["acc"], stat.AvgTimeMS, stat.ErrorRate)
+	}
+	if stat.EstCost != 0.75 || stat.CostResults != 5 {
+		t.Errorf("cost = %v over %d", stat.EstCost, stat.CostResults)
+	}
+
+	if flat := groupByModel(data, false); len(flat) != 4 || flat[1].Collapsible() {
+		t.Errorf("flat = %+v", flat)
+	}
+}
@@ -0,0 +1,450 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gRPC ingest is a minimal gRPC server for the Ingest service of goevals.proto: unary and client
+// streaming calls over cleartext HTTP/2, without compression, decoded by hand like pgwire.go
+// speaks the Postgres protocol, so the binary stays free of dependencies
+const (
+	grpcStreamMethod = "/goevals.v1.Ingest/Stream"
+	grpcSendMethod   = "/goevals.v1.Ingest/Send"
+	maxGRPCMessage   = 4 << 20 // Same default limit as gRPC clients
+	grpcBatchSize    = 500     // Streamed results are appended every this many, so they show up live
+)
+
+// gRPC status codes, see https://grpc.github.io/grpc/core/md_doc_statuscodes.html
+const (
+	grpcOK                = 0
+	grpcInvalidArgument   = 3
+	grpcResourceExhausted = 8
+	grpcUnimplemented     = 12
+	grpcInternal          = 13
+	grpcUnavailable       = 14
+)
+
+// grpcError is a failed call, sent as the grpc-status and grpc-message trailers
+type grpcError struct {
+	code    int
+	message string
+}
+
+func (e *grpcError) Error() string { return e.message }
+
+// newGRPCServer serves gRPC ingest on addr over h2c, the way gRPC clients connect with
+// insecure credentials
+func newGRPCServer(addr string) *http.Server {
+	srv := &http.Server{Addr: addr, Handler: traceRequests(http.HandlerFunc(grpcIngestHandler))}
+	srv.Protocols = new(http.Protocols)
+	srv.Protocols.SetUnencryptedHTTP2(true)
+	return srv
+}
+
+// grpcIngestHandler implements Ingest.Stream and Ingest.Send: every message is an EvalResult,
+// stored in batches like POST /api/ingest, and the reply counts what was stored
+func grpcIngestHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/grpc")
+	w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+	var accepted, dropped, total int
+	err := func() error {
+		if r.ProtoMajor != 2 || r.Method != http.MethodPost || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			return &grpcError{grpcUnimplemented, "gRPC ingest expects gRPC over HTTP/2"}
+		}
+		if r.URL.Path != grpcStreamMethod && r.URL.Path != grpcSendMethod {
+			return &grpcError{grpcUnimplemented, "unknown method " + r.URL.Path}
+		}
+		if ok, wait := ingestLimiter.allow(clientIP(r)); !ok {
+			return &grpcError{grpcResourceExhausted, fmt.Sprintf("too many ingest requests from this client, retry in %s", wait.Round(time.Millisecond))}
+		}
+		var batch []EvalResult
+		flush := func() error {
+			n, d, err := ingestResults(r.Context(), batch)
+			accepted += n
+			dropped += d
+			batch = batch[:0]
+			switch {
+			case errors.Is(err, errInvalidResults):
+				return &grpcError{grpcInvalidArgument, err.Error()}
+			case errors.Is(err, ErrReadOnly):
+				return &grpcError{grpcUnavailable, "ingest is disabled - " + err.Error()}
+			case err != nil:
+				return &grpcError{grpcInternal, "failed to store results"}
+			}
+			return nil
+		}
+		for {
+			msg, err := readGRPCMessage(r.Body)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			result, err := decodeEvalResult(msg)
+			if err != nil {
+				return &grpcError{grpcInvalidArgument, fmt.Sprintf("invalid EvalResult #%d: %v", total+1, err)}
+			}
+			batch = append(batch, result)
+			total++
+			if len(batch) == grpcBatchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+		if r.URL.Path == grpcSendMethod && total != 1 {
+			return &grpcError{grpcInvalidArgument, fmt.Sprintf("Send takes one EvalResult, got %d", total)}
+		}
+		if len(batch) > 0 {
+			return flush()
+		}
+		return nil
+	}()
+
+	status, message := grpcOK, ""
+	if err != nil {
+		var gerr *grpcError
+		if !errors.As(err, &gerr) {
+			gerr = &grpcError{grpcInternal, err.Error()}
+		}
+		status, message = gerr.code, gerr.message
+	} else {
+		var reply []byte
+		reply = appendProtoVarint(reply, 1, uint64(accepted))
+		reply = appendProtoVarint(reply, 2, uint64(total-dropped-accepted))
+		if dropped > 0 {
+			reply = appendProtoVarint(reply, 3, uint64(dropped))
+		}
+		if err := writeGRPCMessage(w, reply); err != nil {
+			log.Printf("Error writing gRPC reply: %v", err)
+		}
+	}
+	w.Header().Set("Grpc-Status", strconv.Itoa(status))
+	w.Header().Set("Grpc-Message", grpcPercentEncode(message))
+}
+
+// grpcPercentEncode escapes grpc-message the way the gRPC spec asks: bytes outside printable
+// ASCII and % itself as %XX
+func grpcPercentEncode(message string) string {
+	var b strings.Builder
+	for i := 0; i < len(message); i++ {
+		if c := message[i]; c < 0x20 || c > 0x7e || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// readGRPCMessage reads one length-prefixed message: a compression flag byte, a big endian
+// uint32 length and the message, io.EOF at the end of the stream
+func readGRPCMessage(r io.Reader) ([]byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, &grpcError{grpcInvalidArgument, "truncated message header"}
+	}
+	if header[0] != 0 {
+		return nil, &grpcError{grpcUnimplemented, "compressed messages are not supported"}
+	}
+	size := binary.BigEndian.Uint32(header[1:])
+	if size > maxGRPCMessage {
+		return nil, &grpcError{grpcResourceExhausted, fmt.Sprintf("message of %d bytes is over the %d byte limit", size, maxGRPCMessage)}
+	}
+	msg := make([]byte, size)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, &grpcError{grpcInvalidArgument, "truncated message"}
+	}
+	return msg, nil
+}
+
+// writeGRPCMessage writes one uncompressed length-prefixed message
+func writeGRPCMessage(w io.Writer, msg []byte) error {
+	frame := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(msg)))
+	_, err := w.Write(append(frame, msg...))
+	return err
+}
+
+// Protobuf wire types
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+	protoFixed32 = 5
+)
+
+// protoReader walks the fields of an encoded protobuf message
+type protoReader struct {
+	buf []byte
+}
+
+// next returns the number and wire type of the next field, ok is false at the end
+func (p *protoReader) next() (field, wire int, ok bool, err error) {
+	if len(p.buf) == 0 {
+		return 0, 0, false, nil
+	}
+	key, err := p.varint()
+	if err != nil {
+		return 0, 0, false, err
+	}
+	return int(key >> 3), int(key & 7), true, nil
+}
+
+func (p *protoReader) varint() (uint64, error) {
+	v, n := binary.Uvarint(p.buf)
+	if n <= 0 {
+		return 0, errors.New("invalid varint")
+	}
+	p.buf = p.buf[n:]
+	return v, nil
+}
+
+func (p *protoReader) bytes() ([]byte, error) {
+	size, err := p.varint()
+	if err != nil {
+		return nil, err
+	}
+	if size > uint64(len(p.buf)) {
+		return nil, errors.New("truncated field")
+	}
+	b := p.buf[:size]
+	p.buf = p.buf[size:]
+	return b, nil
+}
+
+func (p *protoReader) double() (float64, error) {
+	if len(p.buf) < 8 {
+		return 0, errors.New("truncated double")
+	}
+	v := math.Float64frombits(binary.LittleEndian.Uint64(p.buf))
+	p.buf = p.buf[8:]
+	return v, nil
+}
+
+// skip moves past a field this server doesn't know, for forward compatibility
+func (p *protoReader) skip(wire int) error {
+	switch wire {
+	case protoVarint:
+		_, err := p.varint()
+		return err
+	case protoFixed64:
+		_, err := p.double()
+		return err
+	case protoBytes:
+		_, err := p.bytes()
+		return err
+	case protoFixed32:
+		if len(p.buf) < 4 {
+			return errors.New("truncated fixed32")
+		}
+		p.buf = p.buf[4:]
+		return nil
+	}
+	return fmt.Errorf("unsupported wire type %d", wire)
+}
+
+// expectWire checks the wire type of a known field
+func expectWire(field, wire, want int) error {
+	if wire != want {
+		return fmt.Errorf("field %d has wire type %d, want %d", field, wire, want)
+	}
+	return nil
+}
+
+// decodeEvalResult reads an EvalResult message of goevals.proto
+func decodeEvalResult(msg []byte) (EvalResult, error) {
+	var result EvalResult
+	scores := make(map[string]float64)
+	metadata := make(map[string]any)
+	fields := make(map[string]any)
+	var extra []byte
+	p := &protoReader{buf: msg}
+	for {
+		field, wire, ok, err := p.next()
+		if err != nil {
+			return result, err
+		}
+		if !ok {
+			break
+		}
+		switch field {
+		case 1, 2, 3, 4, 5, 6, 10, 11, 15: // Strings, see stringField
+			if err := expectWire(field, wire, protoBytes); err != nil {
+				return result, err
+			}
+			b, err := p.bytes()
+			if err != nil {
+				return result, err
+			}
+			if field == 15 {
+				extra = b
+			} else {
+				*stringField(&result, field) = string(b)
+			}
+		case 8:
+			if err := expectWire(field, wire, protoVarint); err != nil {
+				return result, err
+			}
+			v, err := p.varint()
+			if err != nil {
+				return result, err
+			}
+			result.ResponseTimeMS = int64(v)
+		case 7, 9, 12, 13:
+			if err := expectWire(field, wire, protoBytes); err != nil {
+				return result, err
+			}
+			entry, err := p.bytes()
+			if err != nil {
+				return result, err
+			}
+			key, value, err := decodeMapEntry(entry, field == 7 || field == 12)
+			if err != nil {
+				return result, fmt.Errorf("field %d: %w", field, err)
+			}
+			switch field {
+			case 7:
+				scores[key] = value.(float64)
+			case 9:
+				metadata[key] = value
+			default:
+				fields[key] = value
+			}
+		default:
+			if err := p.skip(wire); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	// The JSON object goes first, the typed fields win over it
+	if len(extra) > 0 {
+		typed := result
+		result = EvalResult{}
+		if err := json.Unmarshal(extra, &result); err != nil {
+			return result, fmt.Errorf("json: %w", err)
+		}
+		for _, field := range []int{1, 2, 3, 4, 5, 6, 10, 11} {
+			if v := *stringField(&typed, field); v != "" {
+				*stringField(&result, field) = v
+			}
+		}
+		if typed.ResponseTimeMS != 0 {
+			result.ResponseTimeMS = typed.ResponseTimeMS
+		}
+	}
+	for key, v := range scores {
+		if key == "combined" {
+			result.Scores.Combined = v
+			continue
+		}
+		if result.Scores.Custom == nil {
+			result.Scores.Custom = make(map[string]float64)
+		}
+		result.Scores.Custom[key] = v
+	}
+	for key, v := range metadata {
+		if result.Metadata == nil {
+			result.Metadata = make(map[string]any)
+		}
+		result.Metadata[key] = v
+	}
+	for key, v := range fields {
+		if knownFields[key] {
+			return result, fmt.Errorf("%s is not a custom field", key)
+		}
+		if result.CustomFields == nil {
+			result.CustomFields = make(map[string]any)
+		}
+		result.CustomFields[key] = v
+	}
+	return result, nil
+}
+
+// stringField returns the string field of result with the given field number of goevals.proto
+func stringField(result *EvalResult, field int) *string {
+	switch field {
+	case 1:
+		return &result.Timestamp
+	case 2:
+		return &result.Model
+	case 3:
+		return &result.TestID
+	case 4:
+		return &result.Question
+	case 5:
+		return &result.Response
+	case 6:
+		return &result.Expected
+	case 10:
+		return &result.Error
+	}
+	return &result.JudgeModel
+}
+
+// decodeMapEntry reads a map<string, double> or map<string, string> entry: key 1, value 2
+func decodeMapEntry(entry []byte, double bool) (string, any, error) {
+	var key string
+	var value any = ""
+	if double {
+		value = 0.0
+	}
+	p := &protoReader{buf: entry}
+	for {
+		field, wire, ok, err := p.next()
+		if err != nil {
+			return "", nil, err
+		}
+		if !ok {
+			return key, value, nil
+		}
+		switch {
+		case field == 1 && wire == protoBytes:
+			b, err := p.bytes()
+			if err != nil {
+				return "", nil, err
+			}
+			key = string(b)
+		case field == 2 && double && wire == protoFixed64:
+			v, err := p.double()
+			if err != nil {
+				return "", nil, err
+			}
+			value = v
+		case field == 2 && !double && wire == protoBytes:
+			b, err := p.bytes()
+			if err != nil {
+				return "", nil, err
+			}
+			value = string(b)
+		default:
+			if err := p.skip(wire); err != nil {
+				return "", nil, err
+			}
+		}
+	}
+}
+
+// appendProtoVarint encodes a varint field, protobuf leaves out zero values
+func appendProtoVarint(buf []byte, field int, v uint64) []byte {
+	if v == 0 {
+		return buf
+	}
+	buf = binary.AppendUvarint(buf, uint64(field)<<3|protoVarint)
+	return binary.AppendUvarint(buf, v)
+}
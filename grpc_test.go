@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Test encoders for the messages of goevals.proto
+
+func appendProtoString(buf []byte, field int, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(field)<<3|protoBytes)
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+func appendProtoScore(buf []byte, field int, key string, v float64) []byte {
+	entry := appendProtoString(nil, 1, key)
+	entry = binary.AppendUvarint(entry, 2<<3|protoFixed64)
+	entry = binary.LittleEndian.AppendUint64(entry, math.Float64bits(v))
+	return appendProtoString(buf, field, string(entry))
+}
+
+func appendProtoLabel(buf []byte, field int, key, value string) []byte {
+	return appendProtoString(buf, field, string(appendProtoString(appendProtoString(nil, 1, key), 2, value)))
+}
+
+func TestDecodeEvalResult(t *testing.T) {
+	var msg []byte
+	msg = appendProtoString(msg, 1, "2025-01-01T10:00:00Z")
+	msg = appendProtoString(msg, 2, "gpt-4o")
+	msg = appendProtoString(msg, 3, "q1")
+	msg = appendProtoScore(msg, 7, "combined", 0.75)
+	msg = appendProtoScore(msg, 7, "faithfulness", 0.9)
+	msg = appendProtoVarint(msg, 8, 1200)
+	msg = appendProtoLabel(msg, 9, "run_id", "r1")
+	msg = appendProtoScore(msg, 12, "chunk_size", 512)
+	msg = appendProtoLabel(msg, 13, "retriever", "bm25")
+	msg = appendProtoString(msg, 15, `{"model":"ignored","judge_scores":{"a":{"x":1}},"temperature":0.2}`)
+	msg = appendProtoVarint(msg, 99, 7) // Unknown fields are skipped
+
+	result, err := decodeEvalResult(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Model != "gpt-4o" || result.TestID != "q1" || result.Timestamp != "2025-01-01T10:00:00Z" || result.ResponseTimeMS != 1200 {
+		t.Errorf("result = %+v", result)
+	}
+	if result.Scores.Combined != 0.75 || result.Scores.Custom["faithfulness"] != 0.9 || result.Metadata["run_id"] != "r1" {
+		t.Errorf("scores %+v, metadata %v", result.Scores, result.Metadata)
+	}
+	if result.CustomFields["chunk_size"] != 512.0 || result.CustomFields["retriever"] != "bm25" || result.CustomFields["temperature"] != 0.2 {
+		t.Errorf("custom fields = %v", result.CustomFields)
+	}
+	if result.JudgeScores["a"]["x"] != 1 {
+		t.Errorf("judge_scores from json = %v", result.JudgeScores)
+	}
+
+	for name, bad := range map[string][]byte{
+		"truncated":    appendProtoString(nil, 2, "gpt-4o")[:4],
+		"wire type":    appendProtoVarint(nil, 2, 1),
+		"known field":  appendProtoLabel(nil, 13, "model", "x"),
+		"invalid json": appendProtoString(nil, 15, "{"),
+	} {
+		if _, err := decodeEvalResult(bad); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestGRPCIngest(t *testing.T) {
+	path := withStore(t)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(grpcIngestHandler))
+	srv.Config.Protocols = new(http.Protocols)
+	srv.Config.Protocols.SetUnencryptedHTTP2(true)
+	srv.Start()
+	defer srv.Close()
+	transport := &http.Transport{Protocols: new(http.Protocols)}
+	transport.Protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: transport}
+
+	call := func(method string, messages ...[]byte) (status, message string, reply []byte) {
+		var body bytes.Buffer
+		for _, msg := range messages {
+			writeGRPCMessage(&body, msg)
+		}
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+method, &body)
+		req.Header.Set("Content-Type", "application/grpc")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.ProtoMajor != 2 {
+			t.Fatalf("served over HTTP/%d", resp.ProtoMajor)
+		}
+		reply, err = readGRPCMessage(resp.Body)
+		if err == io.EOF {
+			reply = nil
+		}
+		io.Copy(io.Discard, resp.Body)
+		return resp.Trailer.Get("Grpc-Status"), resp.Trailer.Get("Grpc-Message"), reply
+	}
+	result := func(model string) []byte {
+		msg := appendProtoString(nil, 1, "2025-01-01T10:00:00Z")
+		msg = appendProtoString(msg, 2, model)
+		msg = appendProtoString(msg, 3, "q1")
+		return appendProtoScore(msg, 7, "combined", 0.5)
+	}
+
+	status, message, reply := call(grpcStreamMethod, result("a"), result("b"), result("a"))
+	want := appendProtoVarint(appendProtoVarint(nil, 1, 2), 2, 1) // 2 accepted, the repeat is a duplicate
+	if status != "0" || !bytes.Equal(reply, want) {
+		t.Errorf("Stream: status %s %q, reply %v", status, message, reply)
+	}
+	results, err := ParseJSONL(path)
+	if err != nil || len(results) != 2 || results[0].Integrity == "" {
+		t.Fatalf("stored %+v, err=%v", results, err)
+	}
+
+	if status, _, _ := call(grpcSendMethod, result("c")); status != "0" {
+		t.Errorf("Send: status %s", status)
+	}
+	if status, message, _ := call(grpcSendMethod, result("d"), result("e")); status != "3" || message == "" {
+		t.Errorf("Send with two results: status %s %q", status, message)
+	}
+	if status, _, _ := call("/goevals.v1.Ingest/Delete"); status != "12" {
+		t.Errorf("unknown method: status %s", status)
+	}
+	if status, _, _ := call(grpcStreamMethod, []byte{0xff}); status != "3" {
+		t.Errorf("invalid message: status %s", status)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// Startup state for /readyz: requests get 503 until the initial load of the sources is done
+var (
+	startedAt = time.Now()
+	readyAt   atomic.Pointer[time.Time] // nil while loading
+)
+
+// markReady records that the initial load is done
+func markReady() {
+	now := time.Now()
+	readyAt.Store(&now)
+}
+
+// Readiness is the /readyz response
+type Readiness struct {
+	Status           string         `json:"status"` // loading, ready, degraded (some sources failing) or error
+	Error            string         `json:"error,omitempty"`
+	StartedAt        time.Time      `json:"started_at"`
+	ReadyAt          time.Time      `json:"ready_at,omitzero"`
+	LastReload       time.Time      `json:"last_reload,omitzero"` // Most recent successful load of any source
+	StalenessSeconds float64        `json:"staleness_seconds"`    // Since the least recently loaded source last loaded
+	Results          int            `json:"results"`
+	InvalidLines     int            `json:"invalid_lines"`
+	FailingSources   int            `json:"failing_sources"`
+	Sources          []SourceStatus `json:"sources"`
+	Memory           MemoryUsage    `json:"memory"`
+}
+
+// MemoryUsage is the Go runtime's view of the process memory
+type MemoryUsage struct {
+	HeapAllocBytes uint64 `json:"heap_alloc_bytes"` // Live heap objects
+	SysBytes       uint64 `json:"sys_bytes"`        // Obtained from the OS
+	NumGC          uint32 `json:"num_gc"`
+	Goroutines     int    `json:"goroutines"`
+}
+
+func memoryUsage() MemoryUsage {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return MemoryUsage{HeapAllocBytes: m.HeapAlloc, SysBytes: m.Sys, NumGC: m.NumGC, Goroutines: runtime.NumGoroutine()}
+}
+
+// readinessTarget is one store checked by /readyz, a dashboard when serving several
+type readinessTarget struct {
+	ctx     context.Context
+	name    string // Prefixed to its source names, empty for the only store
+	private bool   // Behind auth: counted, but its sources aren't listed
+}
+
+// checkReadiness reloads the sources behind each target and reports their state
+// The returned status code is 503 while loading or when the store fails, 200 otherwise
+func checkReadiness(targets []readinessTarget) (Readiness, int) {
+	rd := Readiness{Status: "ready", StartedAt: startedAt, Sources: []SourceStatus{}, Memory: memoryUsage()}
+	ready := readyAt.Load()
+	if ready == nil {
+		rd.Status = "loading"
+		return rd, http.StatusServiceUnavailable
+	}
+	rd.ReadyAt = *ready
+
+	now := time.Now()
+	for _, target := range targets {
+		data, err := store.Stats(target.ctx) // Loads every source, recording its status
+		if err != nil {
+			rd.Status, rd.Error = "error", err.Error()
+			return rd, http.StatusServiceUnavailable
+		}
+		rd.Results += data.TotalTests
+		for _, st := range store.Sources(target.ctx) {
+			if target.name != "" {
+				st.Name = target.name + ": " + st.Name
+			}
+			rd.InvalidLines += st.InvalidLines
+			if !st.Healthy && st.Failures > 0 {
+				rd.FailingSources++
+			}
+			if st.LastSuccess.After(rd.LastReload) {
+				rd.LastReload = st.LastSuccess
+			}
+			if !st.LastSuccess.IsZero() {
+				rd.StalenessSeconds = max(rd.StalenessSeconds, now.Sub(st.LastSuccess).Seconds())
+			}
+			if !target.private {
+				rd.Sources = append(rd.Sources, st)
+			}
+		}
+	}
+	if rd.FailingSources > 0 {
+		rd.Status = "degraded" // Still serving, failing sources keep their last good results
+	}
+	return rd, http.StatusOK
+}
+
+// healthzHandler is the liveness check: the process is up and serving requests
+func healthzHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"status":"ok","uptime_seconds":%.0f}`, time.Since(startedAt).Seconds())
+}
+
+// readyzHandler is the readiness check, 503 until the initial load completes
+func readyzHandler(w http.ResponseWriter, r *http.Request) {
+	rd, code := checkReadiness([]readinessTarget{{ctx: r.Context()}})
+	writeReadiness(w, rd, code)
+}
+
+func writeReadiness(w http.ResponseWriter, rd Readiness, code int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(rd); err != nil {
+		log.Printf("Error encoding JSON: %v", err)
+	}
+}
+
+// startupGate lets goevals listen before the initial load is done: /healthz and /readyz are
+// answered right away, everything else gets 503 until open hands requests to the real handler
+type startupGate struct {
+	next atomic.Pointer[http.Handler]
+}
+
+// open serves every request with h from now on and marks goevals ready
+func (g *startupGate) open(h http.Handler) {
+	g.next.Store(&h)
+	markReady()
+}
+
+func (g *startupGate) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h := g.next.Load(); h != nil {
+		(*h).ServeHTTP(w, r)
+		return
+	}
+	switch r.URL.Path {
+	case "/healthz":
+		healthzHandler(w, r)
+	case "/readyz":
+		readyzHandler(w, r)
+	default:
+		w.Header().Set("Retry-After", "5")
+		http.Error(w, "GoEvals is still loading results, try again shortly", http.StatusServiceUnavailable)
+	}
+}
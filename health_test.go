@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadiness(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "evals.jsonl")
+	os.WriteFile(path, []byte(`{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"t1","scores":{"combined":0.5}}
+
+not json
+{"timestamp":"2025-01-01T11:00:00Z","model":"a","test_id":"t2","scores":{"combined":0.7}}
+`), 0o644)
+	useStore(t, NewMemoryStore([]Source{&fileSource{path: path}, &fileSource{path: filepath.Join(dir, "missing.jsonl")}}))
+	defer readyAt.Store(nil)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", dashboardHandler)
+	mux.HandleFunc("/healthz", healthzHandler)
+	mux.HandleFunc("/readyz", readyzHandler)
+	gate := &startupGate{}
+	get := func(path string) (*httptest.ResponseRecorder, Readiness) {
+		w := httptest.NewRecorder()
+		gate.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var rd Readiness
+		if path == "/readyz" {
+			if err := json.Unmarshal(w.Body.Bytes(), &rd); err != nil {
+				t.Fatalf("invalid /readyz response %q: %v", w.Body.String(), err)
+			}
+		}
+		return w, rd
+	}
+
+	// While loading only health checks are answered
+	readyAt.Store(nil)
+	if w, _ := get("/healthz"); w.Code != http.StatusOK {
+		t.Errorf("/healthz while loading = %d", w.Code)
+	}
+	if w, rd := get("/readyz"); w.Code != http.StatusServiceUnavailable || rd.Status != "loading" || rd.Memory.HeapAllocBytes == 0 {
+		t.Errorf("/readyz while loading = %d %+v", w.Code, rd)
+	}
+	if w, _ := get("/"); w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+		t.Errorf("/ while loading = %d", w.Code)
+	}
+
+	gate.open(mux)
+	w, rd := get("/readyz")
+	if w.Code != http.StatusOK || rd.Status != "degraded" || rd.Results != 2 || rd.InvalidLines != 1 || rd.FailingSources != 1 {
+		t.Errorf("/readyz = %d %+v", w.Code, rd)
+	}
+	if len(rd.Sources) != 2 || rd.Sources[0].InvalidLines != 1 || rd.Sources[1].Healthy || rd.LastReload.IsZero() || rd.ReadyAt.IsZero() {
+		t.Errorf("/readyz sources = %+v", rd.Sources)
+	}
+	if w, _ := get("/"); w.Code != http.StatusOK {
+		t.Errorf("/ after loading = %d", w.Code)
+	}
+
+	os.WriteFile(filepath.Join(dir, "missing.jsonl"), nil, 0o644)
+	store.(*memoryStore).sources[1].(*fileSource).resetBackoff()
+	if w, rd := get("/readyz"); w.Code != http.StatusOK || rd.Status != "ready" || rd.FailingSources != 0 {
+		t.Errorf("/readyz after recovery = %d %+v", w.Code, rd)
+	}
+}
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+// HeatmapCell is the average score of one row (model or config) on one test
+type HeatmapCell struct {
+	Score float64 `json:"score"`
+	Count int     `json:"count"` // 0 = the row has no result for this test
+}
+
+// HeatmapRow is one model (or config) across the current page of tests
+type HeatmapRow struct {
+	Label string        `json:"label"`
+	Avg   float64       `json:"avg"` // Over all tests, not just this page
+	Cells []HeatmapCell `json:"cells"`
+}
+
+// Heatmap is a models × test_ids score matrix, paginated over tests
+type Heatmap struct {
+	Score      string       `json:"score"`
+	RowsBy     string       `json:"rows_by"`  // model or config
+	Order      string       `json:"order"`    // test_id or difficulty (hardest tests first)
+	TestIDs    []string     `json:"test_ids"` // Columns on this page
+	ColumnAvgs []float64    `json:"column_avgs"`
+	Rows       []HeatmapRow `json:"rows"` // Best average first
+	Page       int          `json:"page"`
+	Pages      int          `json:"pages"`
+	PerPage    int          `json:"per_page"`
+	TotalTests int          `json:"total_tests"`
+}
+
+// Heatmap paging and zoom limits
+const (
+	defaultHeatmapPerPage = 50
+	maxHeatmapPerPage     = 500
+)
+
+// heatmapCellSizes are the cell widths in pixels for zoom levels 1-4, values are printed from level 3
+var heatmapCellSizes = []int{10, 16, 28, 44}
+
+// BuildHeatmap averages score per row and test_id and returns one page of columns
+// score is "combined" or a custom score name; results without it or without a test_id are skipped
+func BuildHeatmap(results []EvalResult, score, rowsBy, order string, page, perPage int) Heatmap {
+	hm := Heatmap{Score: score, RowsBy: rowsBy, Order: order, PerPage: perPage}
+	type acc struct {
+		sum   float64
+		count int
+	}
+	cells := make(map[string]map[string]*acc) // row -> test -> acc
+	columns := make(map[string]*acc)
+	add := func(m map[string]*acc, key string, value float64) {
+		a := m[key]
+		if a == nil {
+			a = &acc{}
+			m[key] = a
+		}
+		a.sum += value
+		a.count++
+	}
+	for _, result := range scored(results) {
+		value, ok := result.Scores.Combined, true
+		if score != "combined" {
+			value, ok = result.Scores.Custom[score]
+		}
+		if !ok || result.TestID == "" {
+			continue
+		}
+		row := result.Model
+		if rowsBy == "config" {
+			row = buildConfigKey(result)
+		}
+		if cells[row] == nil {
+			cells[row] = make(map[string]*acc)
+		}
+		add(cells[row], result.TestID, value)
+		add(columns, result.TestID, value)
+	}
+
+	testIDs := make([]string, 0, len(columns))
+	columnAvg := make(map[string]float64, len(columns))
+	for id, a := range columns {
+		testIDs = append(testIDs, id)
+		columnAvg[id] = a.sum / float64(a.count)
+	}
+	sort.Slice(testIDs, func(i, j int) bool {
+		if order == "difficulty" && columnAvg[testIDs[i]] != columnAvg[testIDs[j]] {
+			return columnAvg[testIDs[i]] < columnAvg[testIDs[j]]
+		}
+		return testIDs[i] < testIDs[j]
+	})
+
+	hm.TotalTests = len(testIDs)
+	hm.Pages = max(1, (len(testIDs)+perPage-1)/perPage)
+	hm.Page = min(max(page, 1), hm.Pages)
+	start := (hm.Page - 1) * perPage
+	hm.TestIDs = testIDs[min(start, len(testIDs)):min(start+perPage, len(testIDs))]
+	for _, id := range hm.TestIDs {
+		hm.ColumnAvgs = append(hm.ColumnAvgs, columnAvg[id])
+	}
+
+	for row, tests := range cells {
+		// Rows are averaged per test so a model with many repeats of one test isn't skewed by it
+		r := HeatmapRow{Label: row}
+		sum := 0.0
+		for _, a := range tests {
+			sum += a.sum / float64(a.count)
+		}
+		r.Avg = sum / float64(len(tests))
+		for _, id := range hm.TestIDs {
+			cell := HeatmapCell{}
+			if a := tests[id]; a != nil {
+				cell = HeatmapCell{Score: a.sum / float64(a.count), Count: a.count}
+			}
+			r.Cells = append(r.Cells, cell)
+		}
+		hm.Rows = append(hm.Rows, r)
+	}
+	sort.Slice(hm.Rows, func(i, j int) bool {
+		if hm.Rows[i].Avg != hm.Rows[j].Avg {
+			return hm.Rows[i].Avg > hm.Rows[j].Avg
+		}
+		return hm.Rows[i].Label < hm.Rows[j].Label
+	})
+	return hm
+}
+
+// scoreColor maps a 0-1 score to red (0) through amber (0.5) to green (1)
+func scoreColor(score float64) template.CSS {
+	hue := math.Max(0, math.Min(1, score)) * 120
+	return template.CSS(fmt.Sprintf("hsl(%.0f, 70%%, 48%%)", hue))
+}
+
+// heatmapParams reads and validates the heatmap query parameters
+func heatmapParams(params url.Values) (score, rowsBy, order string, page, perPage int, err error) {
+	score = params.Get("score")
+	if score == "" {
+		score = "combined"
+	}
+	rowsBy = params.Get("rows")
+	if rowsBy == "" {
+		rowsBy = "model"
+	}
+	if rowsBy != "model" && rowsBy != "config" {
+		return "", "", "", 0, 0, fmt.Errorf("rows must be model or config")
+	}
+	order = params.Get("order")
+	if order == "" {
+		order = "test_id"
+	}
+	if order != "test_id" && order != "difficulty" {
+		return "", "", "", 0, 0, fmt.Errorf("order must be test_id or difficulty")
+	}
+	page, perPage = 1, defaultHeatmapPerPage
+	if s := params.Get("page"); s != "" {
+		if page, err = strconv.Atoi(s); err != nil || page < 1 {
+			return "", "", "", 0, 0, fmt.Errorf("page must be a positive number")
+		}
+	}
+	if s := params.Get("per_page"); s != "" {
+		if perPage, err = strconv.Atoi(s); err != nil || perPage < 1 || perPage > maxHeatmapPerPage {
+			return "", "", "", 0, 0, fmt.Errorf("per_page must be between 1 and %d", maxHeatmapPerPage)
+		}
+	}
+	return score, rowsBy, order, page, perPage, nil
+}
+
+// heatmapURL returns the heatmap URL with one parameter changed
+func heatmapURL(params url.Values, key, value string) string {
+	link := url.Values{}
+	for k, v := range params {
+		link[k] = v
+	}
+	link.Set(key, value)
+	return "/heatmap?" + link.Encode()
+}
+
+// heatmapHandler renders the model × test score matrix
+func heatmapHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	score, rowsBy, order, page, perPage, err := heatmapParams(params)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	zoom := 2
+	if z, err := strconv.Atoi(params.Get("zoom")); err == nil && z >= 1 && z <= len(heatmapCellSizes) {
+		zoom = z
+	}
+
+	results, err := store.Query(r.Context(), Query{})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	results, ok := reweightRequest(w, r, results)
+	if !ok {
+		return
+	}
+
+	hm := BuildHeatmap(results, score, rowsBy, order, page, perPage)
+	data := struct {
+		Title    string
+		Subtitle string
+		Heatmap
+		Scores     []string
+		CellSize   int
+		ShowValues bool
+		PrevURL    string
+		NextURL    string
+		ZoomInURL  string
+		ZoomOutURL string
+		Params     url.Values
+	}{
+		Title:      "Score Heatmap",
+		Subtitle:   "Models × tests: is a failure model-specific or question-specific?",
+		Heatmap:    hm,
+		Scores:     scoreNames(results),
+		CellSize:   heatmapCellSizes[zoom-1],
+		ShowValues: zoom >= 3,
+		Params:     params,
+	}
+	if hm.Page > 1 {
+		data.PrevURL = heatmapURL(params, "page", strconv.Itoa(hm.Page-1))
+	}
+	if hm.Page < hm.Pages {
+		data.NextURL = heatmapURL(params, "page", strconv.Itoa(hm.Page+1))
+	}
+	if zoom < len(heatmapCellSizes) {
+		data.ZoomInURL = heatmapURL(params, "zoom", strconv.Itoa(zoom+1))
+	}
+	if zoom > 1 {
+		data.ZoomOutURL = heatmapURL(params, "zoom", strconv.Itoa(zoom-1))
+	}
+
+	renderPage(w, r, "heatmap", heatmapTemplate, data)
+}
+
+// heatmapAPIHandler returns one page of the heatmap as JSON
+func heatmapAPIHandler(w http.ResponseWriter, r *http.Request) {
+	score, rowsBy, order, page, perPage, err := heatmapParams(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	results, err := store.Query(r.Context(), Query{})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	results, ok := reweightRequest(w, r, results)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(BuildHeatmap(results, score, rowsBy, order, page, perPage)); err != nil {
+		log.Printf("Error encoding JSON: %v", err)
+	}
+}
+
+const heatmapTemplate = `
+{{ define "style" }}
+        .heatmap-controls {
+            display: flex;
+            flex-wrap: wrap;
+            gap: 0.75rem;
+            align-items: center;
+        }
+        .heatmap-controls a {
+            color: var(--accent);
+            text-decoration: none;
+        }
+        .score-matrix {
+            border-collapse: separate;
+            border-spacing: 1px;
+            width: auto;
+        }
+        .score-matrix td, .score-matrix th {
+            padding: 0;
+            border: none;
+        }
+        .score-matrix .cell {
+            text-align: center;
+            font-family: monospace;
+            font-size: 0.625rem;
+            color: #fff;
+        }
+        .score-matrix .cell a {
+            display: block;
+            color: inherit;
+            text-decoration: none;
+        }
+        .score-matrix .empty {
+            background: var(--bg-tertiary);
+        }
+        .score-matrix .row-label {
+            padding-right: 0.75rem;
+            white-space: nowrap;
+            font-size: 0.8125rem;
+            text-align: right;
+        }
+        .score-matrix .col-label {
+            writing-mode: vertical-rl;
+            transform: rotate(180deg);
+            font-family: monospace;
+            font-size: 0.6875rem;
+            font-weight: 400;
+            text-transform: none;
+            letter-spacing: 0;
+            background: none;
+            padding: 0.25rem 0;
+            white-space: nowrap;
+        }
+        .score-matrix .avg {
+            font-family: monospace;
+            font-size: 0.6875rem;
+            color: var(--text-tertiary);
+            padding: 0 0.5rem;
+        }
+{{ end }}
+
+{{ define "content" }}
+        <div class="panel">
+            <form method="get" action="/heatmap" class="heatmap-controls">
+                <label class="muted" for="score">Score</label>
+                <select id="score" name="score" onchange="this.form.submit()">
+                    {{ range .Scores }}<option value="{{ . }}" {{ if eq . $.Score }}selected{{ end }}>{{ . }}</option>{{ end }}
+                </select>
+                <label class="muted" for="rows">Rows</label>
+                <select id="rows" name="rows" onchange="this.form.submit()">
+                    <option value="model" {{ if eq .RowsBy "model" }}selected{{ end }}>Models</option>
+                    <option value="config" {{ if eq .RowsBy "config" }}selected{{ end }}>Configs</option>
+                </select>
+                <label class="muted" for="order">Tests</label>
+                <select id="order" name="order" onchange="this.form.submit()">
+                    <option value="test_id" {{ if eq .Order "test_id" }}selected{{ end }}>By test ID</option>
+                    <option value="difficulty" {{ if eq .Order "difficulty" }}selected{{ end }}>Hardest first</option>
+                </select>
+                {{ with .Params.Get "zoom" }}<input type="hidden" name="zoom" value="{{ . }}">{{ end }}
+                {{ with .Params.Get "per_page" }}<input type="hidden" name="per_page" value="{{ . }}">{{ end }}
+                {{ with .Params.Get "weights" }}<input type="hidden" name="weights" value="{{ . }}">{{ end }}
+                <span class="muted" style="margin-left: auto;">
+                    Zoom {{ if .ZoomOutURL }}<a href="{{ .ZoomOutURL }}">−</a>{{ else }}−{{ end }} / {{ if .ZoomInURL }}<a href="{{ .ZoomInURL }}">+</a>{{ else }}+{{ end }}
+                </span>
+                <span class="muted">
+                    {{ if .PrevURL }}<a href="{{ .PrevURL }}">← Prev</a>{{ end }}
+                    Tests page {{ .Page }} of {{ .Pages }} ({{ .TotalTests }} tests)
+                    {{ if .NextURL }}<a href="{{ .NextURL }}">Next →</a>{{ end }}
+                </span>
+            </form>
+        </div>
+
+        <div class="panel" style="overflow-x: auto;">
+            {{ if and .Rows .TestIDs }}
+            <table class="score-matrix">
+                <thead>
+                    <tr>
+                        <th></th>
+                        <th class="col-label">avg</th>
+                        {{ range .TestIDs }}<th class="col-label" title="{{ . }}">{{ . }}</th>{{ end }}
+                    </tr>
+                </thead>
+                <tbody>
+                    {{ range .Rows }}
+                    {{ $row := . }}
+                    <tr>
+                        <td class="row-label mono">{{ .Label }}</td>
+                        <td class="avg">{{ printf "%.2f" .Avg }}</td>
+                        {{ range $i, $cell := .Cells }}
+                        {{ $test := index $.TestIDs $i }}
+                        {{ if $cell.Count }}
+                        <td class="cell" style="background: {{ scoreColor $cell.Score }}; width: {{ $.CellSize }}px; height: {{ $.CellSize }}px;" title="{{ $row.Label }} · {{ $test }}: {{ printf "%.3f" $cell.Score }} ({{ $cell.Count }} result{{ if gt $cell.Count 1 }}s{{ end }})">
+                            <a href="/compare?test_id={{ $test }}">{{ if $.ShowValues }}{{ printf "%.2f" $cell.Score }}{{ else }}&nbsp;{{ end }}</a>
+                        </td>
+                        {{ else }}
+                        <td class="empty" style="width: {{ $.CellSize }}px; height: {{ $.CellSize }}px;" title="{{ $row.Label }} · {{ $test }}: not evaluated"></td>
+                        {{ end }}
+                        {{ end }}
+                    </tr>
+                    {{ end }}
+                    <tr>
+                        <td class="row-label muted">avg</td>
+                        <td></td>
+                        {{ range .ColumnAvgs }}<td class="cell" style="background: {{ scoreColor . }}; height: {{ $.CellSize }}px;" title="{{ printf "%.3f" . }}">{{ if $.ShowValues }}{{ printf "%.2f" . }}{{ end }}</td>{{ end }}
+                    </tr>
+                </tbody>
+            </table>
+            <p class="muted" style="margin-top: 1rem; font-size: 0.8125rem;">
+                Red = low, green = high. A red column means a hard (or broken) test for every model; a red row means a weak model.
+                Click a cell to compare every model's answer.
+            </p>
+            {{ else }}
+            <p class="muted">No results with a test_id{{ if ne .Score "combined" }} and a {{ .Score }} score{{ end }}.</p>
+            {{ end }}
+        </div>
+{{ end }}`
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestBuildHeatmap(t *testing.T) {
+	results := []EvalResult{
+		{Model: "a", TestID: "t1", Scores: ScoreBreakdown{Combined: 0.9}},
+		{Model: "a", TestID: "t1", Scores: ScoreBreakdown{Combined: 0.7}},
+		{Model: "a", TestID: "t2", Scores: ScoreBreakdown{Combined: 0.2}},
+		{Model: "b", TestID: "t2", Scores: ScoreBreakdown{Combined: 0.1}},
+		{Model: "b", TestID: "t3", Scores: ScoreBreakdown{Combined: 0.6, Custom: map[string]float64{"accuracy": 1}}},
+		{Model: "b", Scores: ScoreBreakdown{Combined: 1}}, // No test_id
+	}
+
+	hm := BuildHeatmap(results, "combined", "model", "test_id", 1, 50)
+	if strings.Join(hm.TestIDs, ",") != "t1,t2,t3" || hm.TotalTests != 3 || hm.Pages != 1 {
+		t.Fatalf("columns = %v (%d tests, %d pages)", hm.TestIDs, hm.TotalTests, hm.Pages)
+	}
+	if len(hm.Rows) != 2 || hm.Rows[0].Label != "a" {
+		t.Fatalf("rows = %+v", hm.Rows)
+	}
+	a := hm.Rows[0]
+	if a.Cells[0].Count != 2 || a.Cells[0].Score != 0.8 || a.Cells[2].Count != 0 || a.Avg != 0.5 {
+		t.Errorf("row a = %+v", a)
+	}
+
+	// Hardest tests first, second page of one
+	hm = BuildHeatmap(results, "combined", "model", "difficulty", 2, 1)
+	if hm.Pages != 3 || hm.Page != 2 || len(hm.TestIDs) != 1 || hm.TestIDs[0] != "t3" {
+		t.Errorf("difficulty page 2 = %v of %d", hm.TestIDs, hm.Pages)
+	}
+	if hm = BuildHeatmap(results, "combined", "model", "test_id", 99, 2); hm.Page != 2 {
+		t.Errorf("page past the end = %d, want last page", hm.Page)
+	}
+
+	if hm = BuildHeatmap(results, "accuracy", "model", "test_id", 1, 50); len(hm.Rows) != 1 || len(hm.TestIDs) != 1 {
+		t.Errorf("custom score heatmap = %+v", hm)
+	}
+}
+
+func TestHeatmapParams(t *testing.T) {
+	for _, query := range []string{"rows=judge", "order=random", "page=0", "per_page=501"} {
+		params, _ := url.ParseQuery(query)
+		if _, _, _, _, _, err := heatmapParams(params); err == nil {
+			t.Errorf("%s: expected error", query)
+		}
+	}
+
+	useStore(t, NewMemoryStore([]Source{&fileSource{path: "evals.jsonl"}}))
+	rec := httptest.NewRecorder()
+	heatmapHandler(rec, httptest.NewRequest(http.MethodGet, "/heatmap?per_page=1&zoom=3", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `class="score-matrix"`) || !strings.Contains(body, `href="/heatmap?page=2&amp;per_page=1&amp;zoom=3"`) {
+		t.Error("heatmap page missing the matrix or paging links")
+	}
+}
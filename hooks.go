@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// IngestHook transforms results on their way into the store: it can rewrite or enrich them,
+// drop some, or reject the whole batch with an error wrapping errHookRejected
+type IngestHook interface {
+	Name() string
+	Transform(ctx context.Context, results []EvalResult) ([]EvalResult, error)
+}
+
+// errHookRejected is returned by hooks refusing a batch, the client gets it as invalid results
+var errHookRejected = errors.New("rejected by ingest hook")
+
+// ingestHooks run in order on every ingested batch, see --ingest-hook
+var ingestHooks []IngestHook
+
+// hookTimeout bounds one hook run, a hung hook must not hold ingest forever
+const hookTimeout = 30 * time.Second
+
+// maxHookStderr bounds the hook output quoted in errors
+const maxHookStderr = 1000
+
+// execHook runs a command per batch: the results go to its stdin as JSONL and whatever JSONL it
+// writes to stdout replaces them, so it can be written in any language
+// A non-zero exit status rejects the batch, with stderr as the reason
+type execHook struct {
+	args []string
+}
+
+// newExecHook parses a hook command line, split on whitespace (wrap anything fancier in a script)
+func newExecHook(command string) (*execHook, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, errors.New("empty ingest hook command")
+	}
+	return &execHook{args: args}, nil
+}
+
+func (h *execHook) Name() string {
+	return strings.Join(h.args, " ")
+}
+
+func (h *execHook) Transform(ctx context.Context, results []EvalResult) ([]EvalResult, error) {
+	var stdin bytes.Buffer
+	for _, result := range results {
+		line, err := json.Marshal(result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode result for %s: %w", h.Name(), err)
+		}
+		stdin.Write(line)
+		stdin.WriteByte('\n')
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, hookTimeout)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, h.args[0], h.args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = &stdin, &stdout, &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		return nil, fmt.Errorf("ingest hook %s timed out after %s", h.Name(), hookTimeout)
+	case errors.As(err, &exitErr):
+		reason := strings.TrimSpace(stderr.String())
+		if len(reason) > maxHookStderr {
+			reason = reason[:maxHookStderr] + "..."
+		}
+		if reason == "" {
+			reason = exitErr.Error()
+		}
+		return nil, fmt.Errorf("%w %s: %s", errHookRejected, h.Name(), reason)
+	case err != nil:
+		return nil, fmt.Errorf("failed to run ingest hook %s: %w", h.Name(), err)
+	}
+
+	var out []EvalResult
+	scanner := bufio.NewScanner(&stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var result EvalResult
+		if err := json.Unmarshal(line, &result); err != nil {
+			return nil, fmt.Errorf("ingest hook %s: invalid output line %d: %w", h.Name(), lineNum, err)
+		}
+		out = append(out, result)
+	}
+	return out, scanner.Err()
+}
+
+// applyIngestHooks passes results through every hook in turn
+// Hooks see results whose integrity hashes were already checked; a returned result still carrying
+// the hash of one of them is hashed again, since that is what gets stored. Results a hook made up
+// keep the hash it gave them: none, or a stale one that flags them as tampered
+func applyIngestHooks(ctx context.Context, results []EvalResult) ([]EvalResult, error) {
+	if len(ingestHooks) == 0 {
+		return results, nil
+	}
+	checked := make(map[string]EvalResult, len(results)) // By hash
+	for _, result := range results {
+		checked[result.Integrity] = result
+	}
+	for _, hook := range ingestHooks {
+		_, span := startSpan(ctx, "ingest.hook")
+		span.SetAttr("goevals.hook", hook.Name())
+		span.SetAttr("goevals.results", len(results))
+		var err error
+		results, err = hook.Transform(ctx, results)
+		span.RecordError(err)
+		span.End()
+		if err != nil {
+			return nil, err
+		}
+	}
+	for i, result := range results {
+		orig, ok := checked[result.Integrity]
+		if !ok {
+			orig = result
+		}
+		results[i] = restampIfIntact(orig, result)
+	}
+	return results, nil
+}
+
+// parseIngestHooks builds the hooks of the --ingest-hook flags
+func parseIngestHooks(commands []string) ([]IngestHook, error) {
+	var hooks []IngestHook
+	for _, command := range commands {
+		hook, err := newExecHook(command)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks, nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeHook writes a shell script hook, skipping the test where there is no sh
+func writeHook(t *testing.T, script string) string {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh to run hook scripts")
+	}
+	path := filepath.Join(t.TempDir(), "hook.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return "sh " + path
+}
+
+func TestExecHookTransforms(t *testing.T) {
+	// Normalize a model name and drop one test
+	hook, err := newExecHook(writeHook(t, `sed 's/"model":"llama3:latest"/"model":"llama3"/' | grep -v '"test_id":"q2"'`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := []EvalResult{
+		{Timestamp: "2025-01-01T10:00:00Z", Model: "llama3:latest", TestID: "q1", Scores: ScoreBreakdown{Combined: 0.5}, CustomFields: map[string]any{"top_k": 5.0}},
+		{Timestamp: "2025-01-01T10:00:00Z", Model: "llama3:latest", TestID: "q2"},
+	}
+	out, err := hook.Transform(t.Context(), results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 1 || out[0].Model != "llama3" || out[0].TestID != "q1" || out[0].CustomFields["top_k"] != 5.0 {
+		t.Errorf("out = %+v", out)
+	}
+
+	reject, _ := newExecHook(writeHook(t, "echo 'missing dataset_version' >&2\nexit 1"))
+	if _, err := reject.Transform(t.Context(), results); !errors.Is(err, errHookRejected) || !strings.Contains(err.Error(), "missing dataset_version") {
+		t.Errorf("reject = %v", err)
+	}
+	garbage, _ := newExecHook(writeHook(t, "echo nope"))
+	if _, err := garbage.Transform(t.Context(), results); err == nil || errors.Is(err, errHookRejected) {
+		t.Errorf("invalid output = %v", err)
+	}
+	if _, err := newExecHook("  "); err == nil {
+		t.Error("empty command accepted")
+	}
+}
+
+func TestIngestHandlerRunsHooks(t *testing.T) {
+	path := withStore(t)
+	hooks, err := parseIngestHooks([]string{writeHook(t, `grep -v '"test_id":"q2"' | sed 's/"model":"a"/"model":"a","team":"search"/'`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ingestHooks = hooks
+	defer func() { ingestHooks = nil }()
+
+	body := `{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"q1","scores":{"combined":0.5}}
+{"timestamp":"2025-01-01T10:01:00Z","model":"a","test_id":"q2","scores":{"combined":0.7}}
+`
+	rec := httptest.NewRecorder()
+	ingestHandler(rec, ingestRequest(t, body))
+	if want := `{"accepted":1,"duplicates":0,"dropped":1}`; rec.Code != http.StatusOK || rec.Body.String() != want {
+		t.Fatalf("ingest = %d %s, want %s", rec.Code, rec.Body, want)
+	}
+
+	// The derived field is stored, with a hash over what the hook returned
+	results, err := ParseJSONL(path)
+	if err != nil || len(results) != 1 || results[0].CustomFields["team"] != "search" || isTampered(results[0]) || results[0].Integrity == "" {
+		t.Fatalf("stored %+v, err=%v", results, err)
+	}
+
+	ingestHooks, _ = parseIngestHooks([]string{writeHook(t, "echo 'no scores' >&2\nexit 3")})
+	rec = httptest.NewRecorder()
+	ingestHandler(rec, ingestRequest(t, body))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "no scores") {
+		t.Errorf("rejected batch = %d %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestAPIConditionalRequests(t *testing.T) {
+	path := withStore(t, `{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"t1","scores":{"combined":0.5}}`)
+
+	for _, tc := range []struct {
+		path    string
+		handler http.HandlerFunc
+	}{{"/api/evals", evalsAPIHandler}, {"/api/stats", statsAPIHandler}} {
+		get := func(header, value string) *httptest.ResponseRecorder {
+			r := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if header != "" {
+				r.Header.Set(header, value)
+			}
+			w := httptest.NewRecorder()
+			tc.handler(w, r)
+			return w
+		}
+
+		first := get("", "")
+		etag := first.Header().Get("ETag")
+		if first.Code != http.StatusOK || etag == "" || first.Body.Len() == 0 {
+			t.Fatalf("%s = %d, ETag %q", tc.path, first.Code, etag)
+		}
+		if w := get("If-None-Match", etag); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+			t.Errorf("%s with matching If-None-Match = %d, %d bytes", tc.path, w.Code, w.Body.Len())
+		}
+		if w := get("If-Modified-Since", time.Now().UTC().Format(http.TimeFormat)); w.Code != http.StatusOK {
+			t.Errorf("%s with If-Modified-Since = %d, only the ETag can tell", tc.path, w.Code)
+		}
+		if w := get("If-None-Match", `"stale"`); w.Code != http.StatusOK {
+			t.Errorf("%s with stale If-None-Match = %d", tc.path, w.Code)
+		}
+	}
+
+	// New results change the tag
+	r := httptest.NewRequest(http.MethodGet, "/api/evals", nil)
+	w := httptest.NewRecorder()
+	evalsAPIHandler(w, r)
+	etag := w.Header().Get("ETag")
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	f.WriteString(`{"timestamp":"2025-01-01T11:00:00Z","model":"a","test_id":"t2","scores":{"combined":0.7}}` + "\n")
+	f.Close()
+	r.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	evalsAPIHandler(w, r)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Errorf("after append = %d, ETag %q unchanged", w.Code, etag)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// httpPollInterval is the shortest time between two requests of an HTTP source
+// Loads in between serve the cached results, so a busy dashboard doesn't poll remote runners per request
+const httpPollInterval = 5 * time.Second
+
+// HTTPSource reads a JSONL file served over HTTP(S), e.g. a runner's log behind a static file server
+// The file is polled with If-None-Match / If-Modified-Since, so unchanged files cost a 304 and
+// are not parsed again; while the server is unreachable the last good snapshot is served
+type HTTPSource struct {
+	url    string
+	name   string // url without password
+	client *http.Client
+
+	mu           sync.Mutex
+	snapshot     []EvalResult
+	etag         string // Validators of the snapshot, sent back on the next poll
+	lastModified string
+	invalid      int       // Lines of the snapshot skipped as invalid
+	polled       time.Time // Last request
+	lastErr      error
+	now          func() time.Time
+}
+
+// NewHTTPSource creates a source from an http:// or https:// URL of a JSONL file
+// User info in the URL is sent as basic auth
+func NewHTTPSource(rawURL string) (*HTTPSource, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid source URL %q", rawURL)
+	}
+	return &HTTPSource{
+		url:    rawURL,
+		name:   u.Redacted(),
+		client: &http.Client{Timeout: 30 * time.Second},
+		now:    time.Now,
+	}, nil
+}
+
+func (s *HTTPSource) Name() string { return s.name }
+
+// Load returns the results of the last successful poll, polling again at most every httpPollInterval
+func (s *HTTPSource) Load() ([]EvalResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if !s.polled.IsZero() && now.Sub(s.polled) < httpPollInterval {
+		return s.snapshot, s.lastErr
+	}
+	s.polled = now
+
+	s.lastErr = s.poll()
+	if s.lastErr != nil && s.snapshot != nil {
+		s.lastErr = fmt.Errorf("%w (serving last good snapshot, retrying in %s)", s.lastErr, httpPollInterval)
+	}
+	return s.snapshot, s.lastErr
+}
+
+// InvalidLines returns how many lines of the served snapshot were skipped as invalid
+func (s *HTTPSource) InvalidLines() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.invalid
+}
+
+// poll requests the file, replacing the snapshot when it changed
+func (s *HTTPSource) poll() error {
+	req, err := http.NewRequest(http.MethodGet, s.url, nil)
+	if err != nil {
+		return err
+	}
+	if s.etag != "" {
+		req.Header.Set("If-None-Match", s.etag)
+	}
+	if s.lastModified != "" {
+		req.Header.Set("If-Modified-Since", s.lastModified)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil
+	case http.StatusOK:
+	default:
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	results, invalid, err := parseJSONL(resp.Body)
+	if err != nil {
+		return err
+	}
+	if results == nil {
+		results = []EvalResult{} // An empty file is still a good snapshot
+	}
+	s.snapshot, s.invalid = results, invalid
+	s.etag, s.lastModified = resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	return nil
+}
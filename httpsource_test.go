@@ -0,0 +1,92 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHTTPSource(t *testing.T) {
+	var mu sync.Mutex
+	body := `{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"t1","scores":{"combined":0.5}}` + "\n"
+	etag, requests, notModified := `"v1"`, 0, 0
+	down := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		if down {
+			http.Error(w, "maintenance", http.StatusServiceUnavailable)
+			return
+		}
+		if user, pass, _ := r.BasicAuth(); user != "runner" || pass != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	rawURL := strings.Replace(srv.URL, "http://", "http://runner:secret@", 1) + "/evals.jsonl"
+	src, err := NewSource(rawURL)
+	if err != nil {
+		t.Fatalf("NewSource: %v", err)
+	}
+	s := src.(*HTTPSource)
+	if strings.Contains(s.Name(), "secret") {
+		t.Errorf("Name() shows the password: %s", s.Name())
+	}
+	now := time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	if results, err := s.Load(); err != nil || len(results) != 1 {
+		t.Fatalf("Load = %d results, %v", len(results), err)
+	}
+
+	// Within the interval nothing is requested, after it an unchanged file costs a 304
+	s.Load()
+	now = now.Add(httpPollInterval)
+	if results, err := s.Load(); err != nil || len(results) != 1 {
+		t.Fatalf("Load = %d results, %v", len(results), err)
+	}
+	mu.Lock()
+	if requests != 2 || notModified != 1 {
+		t.Errorf("requests = %d, 304s = %d, want 2 and 1", requests, notModified)
+	}
+
+	// New records replace the snapshot
+	body += `{"timestamp":"2025-01-02T10:00:00Z","model":"b","test_id":"t1","scores":{"combined":0.7}}` + "\n"
+	etag = `"v2"`
+	mu.Unlock()
+	now = now.Add(httpPollInterval)
+	if results, err := s.Load(); err != nil || len(results) != 2 {
+		t.Fatalf("after update = %d results, %v", len(results), err)
+	}
+
+	// An unreachable server keeps the last snapshot
+	mu.Lock()
+	down = true
+	mu.Unlock()
+	now = now.Add(httpPollInterval)
+	results, err := s.Load()
+	if err == nil || !strings.Contains(err.Error(), "last good snapshot") || len(results) != 2 {
+		t.Errorf("while down = %d results, %v", len(results), err)
+	}
+}
+
+func TestHTTPSourceInvalidURL(t *testing.T) {
+	for _, arg := range []string{"https://", "http:///evals.jsonl"} {
+		if _, err := NewHTTPSource(arg); err == nil {
+			t.Errorf("NewHTTPSource(%q) should fail", arg)
+		}
+	}
+}
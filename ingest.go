@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// maxIngestBytes is the default limit on the size of a single ingest request body, see --max-ingest-mb
+const maxIngestBytes = 32 << 20
+
+// acceptIngest and ingestToken open /api/ingest, see --accept-ingest and --ingest-token. It is
+// closed by default: any page a user visits could otherwise post results to a local dashboard
+var (
+	acceptIngest bool
+	ingestToken  string
+)
+
+// ingestAllowed reports whether r may ingest, writing the error response when it may not: the
+// request needs the --ingest-token (or the serving dashboard's token) as a bearer token, or
+// --accept-ingest, and a JSON body, which a cross-site form can't send without a CORS preflight
+func ingestAllowed(w http.ResponseWriter, r *http.Request) bool {
+	equal := func(a, b string) bool { return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1 }
+	token, bearer := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	authorized := acceptIngest || bearer && ingestToken != "" && equal(token, ingestToken)
+	if d := dashboardFrom(r.Context()); !authorized && bearer && d != nil && d.auth != nil && d.auth.Token != "" {
+		authorized = equal(token, d.auth.Token)
+	}
+	if !authorized {
+		if ingestToken != "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="goevals ingest"`)
+			http.Error(w, "Unauthorized - send the --ingest-token as a bearer token", http.StatusUnauthorized)
+			return false
+		}
+		http.Error(w, "Ingest is disabled - start goevals with --accept-ingest or --ingest-token", http.StatusForbidden)
+		return false
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" && mediaType != "application/x-ndjson" {
+		http.Error(w, "Unsupported Content-Type, send application/x-ndjson or application/json", http.StatusUnsupportedMediaType)
+		return false
+	}
+	return true
+}
+
+// ingestHandler accepts JSONL eval results (one per line) and appends them to the store
+// Results already present are skipped, so replicas can safely resend after a retry
+func ingestHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !ingestAllowed(w, r) {
+		return
+	}
+
+	_, parseSpan := startSpan(r.Context(), "ingest.parse")
+	var incoming []EvalResult
+	scanner := bufio.NewScanner(http.MaxBytesReader(w, r.Body, ingestBodyLimit))
+	scanner.Buffer(make([]byte, 64*1024), int(ingestBodyLimit))
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var result EvalResult
+		if err := json.Unmarshal(line, &result); err != nil {
+			if scanner.Err() != nil {
+				break // A body cut off at the size limit, reported below
+			}
+			parseSpan.RecordError(err)
+			parseSpan.End()
+			http.Error(w, fmt.Sprintf("Invalid JSON at line %d: %v", lineNum, err), http.StatusBadRequest)
+			return
+		}
+		incoming = append(incoming, result)
+	}
+	parseSpan.SetAttr("goevals.results", len(incoming))
+	parseSpan.RecordError(scanner.Err())
+	parseSpan.End()
+	if err := scanner.Err(); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) || errors.Is(err, bufio.ErrTooLong) {
+			http.Error(w, fmt.Sprintf("Request body is larger than %d MB, send results in smaller batches", ingestBodyLimit>>20), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error reading body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	accepted, dropped, err := ingestResults(r.Context(), incoming)
+	switch {
+	case errors.Is(err, errInvalidResults):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, ErrReadOnly):
+		http.Error(w, "Ingest is disabled - "+err.Error(), http.StatusServiceUnavailable)
+		return
+	case err != nil:
+		http.Error(w, "Failed to store results", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if dropped > 0 {
+		fmt.Fprintf(w, `{"accepted":%d,"duplicates":%d,"dropped":%d}`, accepted, len(incoming)-dropped-accepted, dropped)
+		return
+	}
+	fmt.Fprintf(w, `{"accepted":%d,"duplicates":%d}`, accepted, len(incoming)-accepted)
+}
+
+// errInvalidResults is returned by ingestResults for results that can't be stored as sent
+var errInvalidResults = errors.New("invalid results")
+
+// ingestResults stores results sent by a client, shared by /api/ingest and gRPC ingest: integrity
+// hashes are checked or recorded, the --ingest-hook commands run, redaction at ingest applied,
+// then the results are appended, skipping those the store already has
+// dropped counts the results hooks removed
+func ingestResults(ctx context.Context, incoming []EvalResult) (accepted, dropped int, err error) {
+	if err := stampIntegrity(incoming); err != nil {
+		return 0, 0, fmt.Errorf("%w: %v", errInvalidResults, err)
+	}
+	received := len(incoming)
+	if incoming, err = applyIngestHooks(ctx, incoming); err != nil {
+		if errors.Is(err, errHookRejected) {
+			return 0, 0, fmt.Errorf("%w: %v", errInvalidResults, err)
+		}
+		log.Printf("Error running ingest hooks: %v", err)
+		return 0, 0, err
+	}
+	dropped = max(0, received-len(incoming))
+	if redaction != nil && redaction.At == "ingest" {
+		incoming = redaction.RedactAll(incoming) // Before anything reaches the sources
+	}
+
+	accepted, err = store.Append(ctx, incoming)
+	if err != nil {
+		if !errors.Is(err, ErrReadOnly) {
+			log.Printf("Error ingesting results: %v", err)
+		}
+		return accepted, dropped, err
+	}
+	duplicates := len(incoming) - accepted
+	span := spanFromContext(ctx)
+	span.SetAttr("goevals.ingest.accepted", accepted)
+	span.SetAttr("goevals.ingest.duplicates", duplicates)
+	if dropped > 0 {
+		span.SetAttr("goevals.ingest.dropped", dropped)
+	}
+	if accepted > 0 {
+		log.Printf("Ingested %d results (%d duplicates skipped)", accepted, duplicates)
+	}
+	return accepted, dropped, nil
+}
+
+// runImport implements `goevals import`, the offline version of /api/ingest: results from the
+// sources are appended to a target, skipping those it already has, so imports can be rerun
+func runImport(args []string) int {
+	fs := newFlagSet("import", "--to <target> [flags] <source1> [source2] [...]",
+		"Copies results into a JSONL file or postgres:// store, e.g. to move logs into Postgres",
+		"or merge Redis streams into one file. Results the target already has (same timestamp,",
+		"test and config) are skipped")
+	to := fs.String("to", "", "JSONL `file` or postgres:// URL to import into")
+	dryRun := fs.Bool("dry-run", false, "count what would be imported without writing it")
+	var hookCommands stringList
+	fs.Var(&hookCommands, "ingest-hook", "pipe the results through this `command` before importing, like serve --ingest-hook (repeatable)")
+	sources := parseFlags(fs, args)
+	if len(sources) == 0 || *to == "" {
+		fs.Usage()
+		return 2
+	}
+	target, err := NewSource(*to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if _, ok := target.(Appender); !ok {
+		fmt.Fprintf(os.Stderr, "Error: %s can't store results, import into a JSONL file or a postgres:// URL\n", target.Name())
+		return 2
+	}
+
+	results, err := commandResults(sources, "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if err := stampIntegrity(results); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if ingestHooks, err = parseIngestHooks(hookCommands); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --ingest-hook: %v\n", err)
+		return 2
+	}
+	received := len(results)
+	if results, err = applyIngestHooks(context.Background(), results); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if dropped := received - len(results); dropped > 0 {
+		fmt.Printf("Ingest hooks dropped %d of %d results\n", dropped, received)
+	}
+
+	if *dryRun {
+		existing, err := target.Load()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to load %s: %v\n", target.Name(), err)
+			return 2
+		}
+		seen := make(map[string]bool, len(existing))
+		for _, result := range existing {
+			seen[resultKey(result)] = true
+		}
+		fresh := 0
+		for _, result := range results {
+			if key := resultKey(result); !seen[key] {
+				seen[key] = true
+				fresh++
+			}
+		}
+		fmt.Printf("Would import %d of %d results into %s (%d duplicates skipped)\n", fresh, len(results), target.Name(), len(results)-fresh)
+		return 0
+	}
+	n, err := NewMemoryStore([]Source{target}).Append(context.Background(), results)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Imported %d of %d results into %s (%d duplicates skipped)\n", n, len(results), target.Name(), len(results)-n)
+	return 0
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ingestRequest is a POST of body to /api/ingest, with ingest open for the test
+func ingestRequest(t *testing.T, body string) *http.Request {
+	t.Helper()
+	acceptIngest = true
+	t.Cleanup(func() { acceptIngest = false })
+	r := httptest.NewRequest(http.MethodPost, "/api/ingest", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/x-ndjson")
+	return r
+}
+
+func TestIngestHandlerSkipsDuplicates(t *testing.T) {
+	path := withStore(t)
+
+	body := `{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"q1","scores":{"combined":0.5}}
+{"timestamp":"2025-01-01T10:01:00Z","model":"b","test_id":"q1","scores":{"combined":0.7}}
+`
+	for i, want := range []string{`{"accepted":2,"duplicates":0}`, `{"accepted":0,"duplicates":2}`} {
+		rec := httptest.NewRecorder()
+		ingestHandler(rec, ingestRequest(t, body))
+		if rec.Code != http.StatusOK || rec.Body.String() != want {
+			t.Errorf("request %d: %d %s, want %s", i, rec.Code, rec.Body.String(), want)
+		}
+	}
+
+	results, err := ParseJSONL(path)
+	if err != nil || len(results) != 2 {
+		t.Fatalf("stored %d results, err=%v", len(results), err)
+	}
+
+	rec := httptest.NewRecorder()
+	ingestHandler(rec, ingestRequest(t, "{not json"))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid JSON: got %d", rec.Code)
+	}
+}
+
+func TestIngestAccess(t *testing.T) {
+	withStore(t)
+	defer func() { ingestToken = "" }()
+
+	post := func(contentType, authorization string) int {
+		r := httptest.NewRequest(http.MethodPost, "/api/ingest", strings.NewReader(`{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"q1","scores":{"combined":0.5}}`))
+		r.Header.Set("Content-Type", contentType)
+		if authorization != "" {
+			r.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		ingestHandler(rec, r)
+		return rec.Code
+	}
+	if code := post("application/x-ndjson", ""); code != http.StatusForbidden {
+		t.Errorf("closed ingest = %d", code)
+	}
+
+	ingestToken = "secret"
+	tests := []struct {
+		contentType, authorization string
+		want                       int
+	}{
+		{"application/x-ndjson", "", http.StatusUnauthorized},
+		{"application/x-ndjson", "Bearer wrong", http.StatusUnauthorized},
+		{"text/plain", "Bearer secret", http.StatusUnsupportedMediaType}, // What a cross-site form sends
+		{"application/x-www-form-urlencoded", "Bearer secret", http.StatusUnsupportedMediaType},
+		{"application/json; charset=utf-8", "Bearer secret", http.StatusOK},
+		{"application/x-ndjson", "Bearer secret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		if code := post(tt.contentType, tt.authorization); code != tt.want {
+			t.Errorf("%s with %q = %d, want %d", tt.contentType, tt.authorization, code, tt.want)
+		}
+	}
+}
+
+func TestRunImportSkipsResultsTheTargetHas(t *testing.T) {
+	dir := t.TempDir()
+	from, to := filepath.Join(dir, "run.jsonl"), filepath.Join(dir, "all.jsonl")
+	os.WriteFile(from, []byte(`{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"q1","scores":{"combined":0.5}}
+{"timestamp":"2025-01-01T10:01:00Z","model":"b","test_id":"q1","scores":{"combined":0.7}}
+`), 0o644)
+	os.WriteFile(to, []byte(`{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"q1","scores":{"combined":0.5}}
+`), 0o644)
+
+	if code := runImport([]string{from, "--to", to}); code != 0 {
+		t.Fatalf("exit code %d", code)
+	}
+	results, err := ParseJSONL(to)
+	if err != nil || len(results) != 2 || results[1].Model != "b" || results[1].Integrity == "" {
+		t.Fatalf("target holds %+v, err=%v", results, err)
+	}
+	if code := runImport([]string{"--to", "redis://localhost:6379/0?stream=evals", from}); code != 2 {
+		t.Errorf("read-only target exit code = %d", code)
+	}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Ingest log defaults
+const (
+	ingestLogActive      = "ingest.jsonl" // Segment being written, rotated segments are ingest-<time>.jsonl
+	defaultIngestLogSize = 64             // MB
+	defaultIngestLogAge  = 24 * time.Hour
+)
+
+// IngestLog is an append-only directory of JSONL segments that results pushed to /api/ingest or
+// gRPC are written to, so goevals can be the durable sink of an eval pipeline
+//
+// Every batch is written with a single write and acknowledged only after an fsync. Batches that
+// arrive while a sync is running share the next one (group commit), so many small concurrent
+// batches don't each pay for a disk flush. The active segment is rotated when it grows past
+// maxSize or gets older than maxAge; a torn last line left by a crash is cut off on startup
+type IngestLog struct {
+	dir     string
+	maxSize int64         // Bytes, 0 = no size limit
+	maxAge  time.Duration // 0 = no age limit
+	now     func() time.Time
+
+	segments *globSource // Reads every segment, rotated ones included
+
+	mu          sync.Mutex // Guards the fields below and serializes writes
+	file        *os.File   // Active segment
+	size        int64
+	opened      time.Time
+	written     uint64 // Batches written
+	rotatedUpTo uint64 // Batches synced by closing a segment
+
+	syncMu sync.Mutex // Held by the goroutine syncing for everyone
+	synced uint64     // Batches known to be on disk
+}
+
+// NewIngestLog opens (creating if needed) the ingest log in dir
+func NewIngestLog(dir string, maxSize int64, maxAge time.Duration) (*IngestLog, error) {
+	if maxSize < 0 || maxAge < 0 {
+		return nil, fmt.Errorf("ingest log rotation limits can't be negative, got %d bytes and %s", maxSize, maxAge)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create ingest log directory: %w", err)
+	}
+	l := &IngestLog{
+		dir:      dir,
+		maxSize:  maxSize,
+		maxAge:   maxAge,
+		now:      time.Now,
+		segments: &globSource{pattern: filepath.Join(dir, "*.jsonl"), files: make(map[string]*fileSource)},
+	}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *IngestLog) Name() string { return l.dir }
+
+// Load returns the results of every segment, each labeled with its file
+func (l *IngestLog) Load() ([]EvalResult, error) {
+	return l.segments.Load()
+}
+
+// InvalidLines returns how many lines of the segments were skipped as invalid
+func (l *IngestLog) InvalidLines() int {
+	return l.segments.InvalidLines()
+}
+
+// open opens the active segment for appending, cutting off a torn last line
+// A crash in the middle of a write can leave a partial line; appending after it would glue the
+// next result onto it and lose both
+func (l *IngestLog) open() error {
+	path := filepath.Join(l.dir, ingestLogActive)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	size, err := repairTail(f)
+	if err == nil {
+		_, err = f.Seek(size, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	l.file, l.size, l.opened = f, size, l.now()
+	return nil
+}
+
+// repairTail truncates f after its last newline and returns the new size
+func repairTail(f *os.File) (int64, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := info.Size()
+	buf := make([]byte, 64*1024)
+	for end := size; end > 0; {
+		start := max(end-int64(len(buf)), 0)
+		chunk := buf[:end-start]
+		if _, err := f.ReadAt(chunk, start); err != nil {
+			return 0, err
+		}
+		if i := bytes.LastIndexByte(chunk, '\n'); i >= 0 {
+			end = start + int64(i) + 1
+			if end == size {
+				return size, nil
+			}
+			log.Printf("Warning: Cutting off %d bytes of a partial line at the end of %s", size-end, f.Name())
+			return end, f.Truncate(end)
+		}
+		end = start
+	}
+	if size > 0 {
+		log.Printf("Warning: Cutting off %d bytes of a partial line at the end of %s", size, f.Name())
+		return 0, f.Truncate(0)
+	}
+	return 0, nil
+}
+
+// Append writes results as one batch and returns once they are on disk
+func (l *IngestLog) Append(results []EvalResult) (int, error) {
+	if len(results) == 0 {
+		return 0, nil
+	}
+	var batch bytes.Buffer
+	for _, result := range results {
+		line, err := encodeResult(result)
+		if err != nil {
+			return 0, err
+		}
+		batch.Write(line)
+		batch.WriteByte('\n')
+	}
+
+	l.mu.Lock()
+	if err := l.rotateIfNeeded(int64(batch.Len())); err != nil {
+		l.mu.Unlock()
+		return 0, err
+	}
+	if _, err := l.file.Write(batch.Bytes()); err != nil {
+		// Don't leave half a batch for the next one to be glued onto
+		l.file.Truncate(l.size)
+		l.file.Seek(l.size, io.SeekStart)
+		l.mu.Unlock()
+		return 0, fmt.Errorf("failed to write %s: %w", l.file.Name(), err)
+	}
+	l.size += int64(batch.Len())
+	l.written++
+	seq := l.written
+	l.mu.Unlock()
+
+	if err := l.syncUpTo(seq); err != nil {
+		return 0, err
+	}
+	return len(results), nil
+}
+
+// syncUpTo returns once batch seq is on disk
+// Whoever gets syncMu first syncs every batch written so far; the others waiting behind it
+// find their batch already synced and return without touching the disk
+func (l *IngestLog) syncUpTo(seq uint64) error {
+	l.syncMu.Lock()
+	defer l.syncMu.Unlock()
+	if l.synced >= seq {
+		return nil
+	}
+
+	l.mu.Lock()
+	f, written := l.file, l.written
+	l.mu.Unlock()
+
+	if err := f.Sync(); err != nil {
+		if errors.Is(err, os.ErrClosed) && l.syncedByRotation(seq) {
+			return nil
+		}
+		return fmt.Errorf("failed to sync %s: %w", f.Name(), err)
+	}
+	l.synced = max(l.synced, written)
+	return nil
+}
+
+// syncedByRotation reports whether a rotation synced and closed the segment holding batch seq
+func (l *IngestLog) syncedByRotation(seq uint64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rotatedUpTo >= seq
+}
+
+// rotateIfNeeded starts a new segment when writing n more bytes would exceed the limits
+// Called with mu held
+func (l *IngestLog) rotateIfNeeded(n int64) error {
+	full := l.maxSize > 0 && l.size > 0 && l.size+n > l.maxSize
+	old := l.maxAge > 0 && l.size > 0 && l.now().Sub(l.opened) >= l.maxAge
+	if !full && !old {
+		return nil
+	}
+	if err := l.closeActive(); err != nil {
+		return err
+	}
+
+	rotated := filepath.Join(l.dir, "ingest-"+l.now().UTC().Format("20060102-150405")+".jsonl")
+	for i := 1; ; i++ {
+		if _, err := os.Stat(rotated); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		rotated = filepath.Join(l.dir, fmt.Sprintf("ingest-%s-%d.jsonl", l.now().UTC().Format("20060102-150405"), i))
+	}
+	if err := os.Rename(filepath.Join(l.dir, ingestLogActive), rotated); err != nil {
+		// Keep writing the old segment rather than refusing results, rotation is retried at the next limit
+		log.Printf("Warning: Failed to rotate %s: %v", ingestLogActive, err)
+	}
+	return l.open()
+}
+
+// closeActive syncs and closes the active segment, called with mu held
+func (l *IngestLog) closeActive() error {
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync %s: %w", l.file.Name(), err)
+	}
+	l.rotatedUpTo = l.written
+	return l.file.Close()
+}
+
+// Remove deletes results from every segment (used by archiving)
+// The active segment is closed meanwhile, segments are rewritten and renamed over the old ones
+func (l *IngestLog) Remove(keys map[string]bool) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.closeActive(); err != nil {
+		return 0, err
+	}
+	opened := l.opened
+	defer func() {
+		if err := l.open(); err != nil {
+			log.Printf("Error reopening ingest log: %v", err)
+		}
+		l.opened = opened // Archiving doesn't postpone rotation
+	}()
+
+	paths, err := filepath.Glob(l.segments.pattern)
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, path := range paths {
+		n, err := (&fileSource{path: path}).Remove(keys)
+		removed += n
+		if err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+// Close syncs and closes the active segment
+func (l *IngestLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.closeActive()
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func ingestLogResult(i int) EvalResult {
+	return EvalResult{
+		Timestamp: fmt.Sprintf("2025-01-01T10:00:%02dZ", i%60),
+		Model:     "a",
+		TestID:    fmt.Sprintf("t%d", i),
+		Scores:    ScoreBreakdown{Combined: 0.5},
+	}
+}
+
+func TestIngestLogRotation(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewIngestLog(dir, 400, time.Hour)
+	if err != nil {
+		t.Fatalf("NewIngestLog: %v", err)
+	}
+	defer l.Close()
+	now := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+	l.opened = now
+
+	// Each result is ~100 bytes, so the 400 byte limit rotates after a few
+	for i := 0; i < 10; i++ {
+		if n, err := l.Append([]EvalResult{ingestLogResult(i)}); err != nil || n != 1 {
+			t.Fatalf("Append = %d, %v", n, err)
+		}
+	}
+	segments, _ := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if len(segments) < 3 {
+		t.Errorf("expected size rotation, got segments %v", segments)
+	}
+	results, err := l.Load()
+	if err != nil || len(results) != 10 {
+		t.Fatalf("Load = %d results, %v", len(results), err)
+	}
+
+	// Age rotation, even below the size limit
+	l.maxSize = 0
+	before := len(segments)
+	now = now.Add(time.Hour)
+	l.Append([]EvalResult{ingestLogResult(10)})
+	if segments, _ = filepath.Glob(filepath.Join(dir, "*.jsonl")); len(segments) != before+1 {
+		t.Errorf("expected age rotation, got %d segments, had %d", len(segments), before)
+	}
+	if results, _ := l.Load(); len(results) != 11 {
+		t.Errorf("after age rotation = %d results", len(results))
+	}
+}
+
+func TestIngestLogRepairsTornLine(t *testing.T) {
+	dir := t.TempDir()
+	good := `{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"t1","scores":{"combined":0.5}}` + "\n"
+	torn := `{"timestamp":"2025-01-01T10:00:01Z","model":"a","test_`
+	if err := os.WriteFile(filepath.Join(dir, ingestLogActive), []byte(good+torn), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := NewIngestLog(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("NewIngestLog: %v", err)
+	}
+	defer l.Close()
+	if _, err := l.Append([]EvalResult{ingestLogResult(2)}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, ingestLogActive))
+	if strings.Contains(string(data), torn) || !strings.HasPrefix(string(data), good) {
+		t.Errorf("torn line not cut off:\n%s", data)
+	}
+	if results, err := l.Load(); err != nil || len(results) != 2 {
+		t.Errorf("Load = %d results, %v", len(results), err)
+	}
+}
+
+func TestIngestLogConcurrentAppends(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewIngestLog(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("NewIngestLog: %v", err)
+	}
+	defer l.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := l.Append([]EvalResult{ingestLogResult(2 * i), ingestLogResult(2*i + 1)}); err != nil {
+				t.Errorf("Append: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if l.synced != 50 {
+		t.Errorf("synced %d batches, want 50", l.synced)
+	}
+	if results, err := l.Load(); err != nil || len(results) != 100 {
+		t.Errorf("Load = %d results, %v", len(results), err)
+	}
+}
+
+func TestIngestLogRemove(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewIngestLog(dir, 200, 0)
+	if err != nil {
+		t.Fatalf("NewIngestLog: %v", err)
+	}
+	defer l.Close()
+	var all []EvalResult
+	for i := 0; i < 4; i++ {
+		all = append(all, ingestLogResult(i))
+		l.Append([]EvalResult{ingestLogResult(i)})
+	}
+
+	keys := map[string]bool{resultKey(all[0]): true, resultKey(all[3]): true}
+	if n, err := l.Remove(keys); err != nil || n != 2 {
+		t.Fatalf("Remove = %d, %v", n, err)
+	}
+	// The active segment is writable again afterwards
+	if _, err := l.Append([]EvalResult{ingestLogResult(9)}); err != nil {
+		t.Fatalf("Append after Remove: %v", err)
+	}
+	results, _ := l.Load()
+	var ids []string
+	for _, r := range results {
+		ids = append(ids, r.TestID)
+	}
+	if len(ids) != 3 || strings.Contains(strings.Join(ids, ","), "t0") || strings.Contains(strings.Join(ids, ","), "t3") {
+		t.Errorf("after Remove = %v", ids)
+	}
+}
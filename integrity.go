@@ -0,0 +1,156 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// contentHash is the SHA-256 of a result's canonical JSON (without its own sha256 field)
+// MarshalJSON sorts keys, so the hash survives any number of store/load round trips
+func contentHash(result EvalResult) string {
+	result.Integrity = ""
+	data, err := json.Marshal(result)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// isTampered reports whether a result carries a hash that no longer matches its content
+// Results without a hash (written directly by eval scripts) are not considered tampered
+func isTampered(result EvalResult) bool {
+	return result.Integrity != "" && result.Integrity != contentHash(result)
+}
+
+// restampIfIntact returns out, a view or transform of orig, with its hash recomputed when orig's
+// hash was intact; a tampered orig leaves out with the stale hash so it stays flagged, an unhashed
+// one leaves it unhashed
+func restampIfIntact(orig, out EvalResult) EvalResult {
+	if orig.Integrity != "" && !isTampered(orig) {
+		out.Integrity = contentHash(out)
+	}
+	return out
+}
+
+// stampIntegrity records the content hash of every result that doesn't carry one yet
+// Hashes supplied by the producer are kept and must match, so corruption in transit is caught too
+func stampIntegrity(results []EvalResult) error {
+	for i := range results {
+		if results[i].Integrity == "" {
+			results[i].Integrity = contentHash(results[i])
+			continue
+		}
+		if isTampered(results[i]) {
+			return fmt.Errorf("sha256 of result %d (test_id %q) does not match its content", i+1, results[i].TestID)
+		}
+	}
+	return nil
+}
+
+// countTampered returns how many results fail their integrity check
+func countTampered(results []EvalResult) int {
+	n := 0
+	for _, result := range results {
+		if isTampered(result) {
+			n++
+		}
+	}
+	return n
+}
+
+// runVerify implements `goevals verify`: checks result hashes in every source and,
+// with --archive-to, the hash of every archive object. Returns the process exit code
+func runVerify(args []string) int {
+	fs := newFlagSet("verify", "[--archive-to url] <source1> [source2] [...]",
+		"Checks the SHA-256 recorded for every ingested result (and archived object)",
+		"Exits with status 1 if anything was modified after it was stored")
+	archiveTo := fs.String("archive-to", "", "also verify archive objects at `url` (s3://bucket/prefix, gs://bucket/prefix or a directory)")
+	sources := parseFlags(fs, args)
+	if len(sources) == 0 && *archiveTo == "" {
+		fs.Usage()
+		return 2
+	}
+	if err := loadEncryptionKey(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	failed := false
+	for _, arg := range sources {
+		source, err := NewSource(arg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		results, err := source.Load()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to load %s: %v\n", source.Name(), err)
+			return 2
+		}
+
+		ok, unsigned := 0, 0
+		var tampered []string
+		for _, result := range results {
+			switch {
+			case result.Integrity == "":
+				unsigned++
+			case isTampered(result):
+				tampered = append(tampered, fmt.Sprintf("%s %s %s", result.Timestamp, result.Model, result.TestID))
+			default:
+				ok++
+			}
+		}
+		fmt.Printf("%s: %d verified, %d without hash, %d TAMPERED\n", source.Name(), ok, unsigned, len(tampered))
+		for _, line := range tampered {
+			fmt.Printf("  ✗ %s\n", line)
+		}
+		if len(tampered) > 0 {
+			failed = true
+		}
+	}
+
+	if *archiveTo != "" {
+		a, err := NewArchive(*archiveTo, Retention{}) // Retention is unused when verifying
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		objects, err := a.Objects("", "")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to list archive: %v\n", err)
+			return 2
+		}
+		ok, unsigned := 0, 0
+		var bad []string
+		for _, obj := range objects {
+			err := a.verifyObject(obj.Key)
+			switch {
+			case err == errNoChecksum:
+				unsigned++
+			case err != nil:
+				bad = append(bad, fmt.Sprintf("%s: %v", obj.Key, err))
+			default:
+				ok++
+			}
+		}
+		fmt.Printf("archive %s: %d verified, %d without checksum, %d TAMPERED\n", *archiveTo, ok, unsigned, len(bad))
+		for _, line := range bad {
+			fmt.Printf("  ✗ %s\n", line)
+		}
+		if len(bad) > 0 {
+			failed = true
+		}
+	}
+
+	if failed {
+		fmt.Println(strings.Repeat("-", 40))
+		fmt.Println("Integrity check FAILED")
+		return 1
+	}
+	return 0
+}
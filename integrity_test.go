@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIntegrityRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "evals.jsonl")
+	results := []EvalResult{{
+		Timestamp:    "2025-01-01T10:00:00Z",
+		Model:        "a",
+		TestID:       "t1",
+		Question:     "Is <b>this</b> & that equal?",
+		Scores:       ScoreBreakdown{Combined: 0.75, Custom: map[string]float64{"accuracy": 0.5}},
+		Metadata:     map[string]any{"run_id": "r1"},
+		CustomFields: map[string]any{"chunk_size": 512.0},
+	}}
+	if err := stampIntegrity(results); err != nil || len(results[0].Integrity) != 64 {
+		t.Fatalf("stampIntegrity: %q, %v", results[0].Integrity, err)
+	}
+
+	src := &fileSource{path: path}
+	if _, err := src.Append(results); err != nil {
+		t.Fatal(err)
+	}
+	loaded, _ := src.Load()
+	if len(loaded) != 1 || loaded[0].Integrity != results[0].Integrity || isTampered(loaded[0]) {
+		t.Fatalf("hash did not survive a store/load round trip: %+v", loaded)
+	}
+	if _, ok := loaded[0].CustomFields["sha256"]; ok {
+		t.Error("sha256 must not become a custom field (it would change the config key)")
+	}
+	if code := runVerify([]string{path}); code != 0 {
+		t.Errorf("verify on intact file = %d", code)
+	}
+
+	// Editing a score by hand is detected
+	data, _ := os.ReadFile(path)
+	os.WriteFile(path, []byte(strings.Replace(string(data), `"combined":0.75`, `"combined":0.95`, 1)), 0644)
+	loaded, _ = src.Load()
+	if !isTampered(loaded[0]) || countTampered(loaded) != 1 {
+		t.Error("modified result not detected")
+	}
+	if code := runVerify([]string{path}); code != 1 {
+		t.Errorf("verify on tampered file = %d", code)
+	}
+
+	// A producer-supplied hash must match
+	loaded[0].Question = "changed"
+	if err := stampIntegrity(loaded); err == nil {
+		t.Error("expected error for mismatched supplied hash")
+	}
+}
+
+func TestArchiveChecksums(t *testing.T) {
+	bucket := &fakeS3{objects: make(map[string][]byte)}
+	srv := httptest.NewServer(bucket)
+	defer srv.Close()
+
+	t.Setenv("AWS_ENDPOINT_URL", srv.URL)
+	t.Setenv("AWS_ACCESS_KEY_ID", "key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	a, err := NewArchive("s3://evals/cold", Retention{MaxAge: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "evals.jsonl")
+	os.WriteFile(path, []byte(`{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"t1","scores":{"combined":0.5}}`+"\n"), 0644)
+	if n, err := a.ArchiveOnce([]Source{&fileSource{path: path}}, time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)); err != nil || n != 1 {
+		t.Fatalf("ArchiveOnce = %d, %v", n, err)
+	}
+
+	objects, _ := a.Objects("", "")
+	if len(objects) != 1 {
+		t.Fatalf("expected one object (sidecar excluded), got %+v", objects)
+	}
+	key := objects[0].Key
+	if err := a.verifyObject(key); err != nil {
+		t.Errorf("verifyObject on intact object: %v", err)
+	}
+
+	bucket.objects["evals/"+key] = append(bucket.objects["evals/"+key], 0)
+	if err := a.verifyObject(key); err == nil || err == errNoChecksum {
+		t.Errorf("expected checksum mismatch, got %v", err)
+	}
+	if _, _, err := a.Rehydrate("", ""); err == nil {
+		t.Error("rehydrating a modified object should fail")
+	}
+
+	delete(bucket.objects, "evals/"+key+".sha256")
+	if err := a.verifyObject(key); err != errNoChecksum {
+		t.Errorf("expected errNoChecksum, got %v", err)
+	}
+}
+
+// hookFunc is an in-process IngestHook
+type hookFunc func([]EvalResult) []EvalResult
+
+func (h hookFunc) Name() string { return "func" }
+func (h hookFunc) Transform(ctx context.Context, results []EvalResult) ([]EvalResult, error) {
+	return h(results), nil
+}
+
+func TestRestampIfIntact(t *testing.T) {
+	intact := []EvalResult{{Model: "a", TestID: "t1"}}
+	stampIntegrity(intact)
+	tampered := intact[0]
+	tampered.Model = "b"
+	edit := func(r EvalResult) EvalResult { r.Question = "joined"; return r }
+
+	if out := restampIfIntact(intact[0], edit(intact[0])); out.Integrity == intact[0].Integrity || isTampered(out) {
+		t.Errorf("intact: %q", out.Integrity)
+	}
+	if out := restampIfIntact(tampered, edit(tampered)); !isTampered(out) {
+		t.Error("tampered result got a fresh hash")
+	}
+	if out := restampIfIntact(EvalResult{}, edit(EvalResult{})); out.Integrity != "" {
+		t.Errorf("unhashed result got hash %q", out.Integrity)
+	}
+
+	// Hooks: a transformed result is re-hashed, made up ones keep the hash they came with
+	defer func(hooks []IngestHook) { ingestHooks = hooks }(ingestHooks)
+	ingestHooks = []IngestHook{hookFunc(func(results []EvalResult) []EvalResult {
+		forged := EvalResult{Model: "c", Integrity: strings.Repeat("0", 64)}
+		return []EvalResult{edit(results[0]), {Model: "d"}, forged}
+	})}
+	out, err := applyIngestHooks(t.Context(), intact)
+	if err != nil || len(out) != 3 {
+		t.Fatal(out, err)
+	}
+	if isTampered(out[0]) || out[0].Integrity == "" || out[1].Integrity != "" || !isTampered(out[2]) {
+		t.Errorf("hooked = %+v", out)
+	}
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Judge bias thresholds: a judge is flagged for length bias when its scores correlate with
+// response length at least this much, and for self-preference when it scores its own family
+// this much higher than other families
+const (
+	lengthBiasR          = 0.3
+	selfPreferenceGap    = 0.05
+	minJudgeBiasResults  = 10 // Results a judge needs before its length bias is flagged
+	minSelfPreferenceOwn = 5  // Results of the judge's own family needed before self-preference is flagged
+	judgeLengthBins      = 5  // Response length bins of the length bias chart, by quantile
+)
+
+// Judge bias chart sizes in SVG user units
+const (
+	judgeBiasWidth  = 560
+	judgeBiasHeight = 300
+)
+
+// modelFamilies maps model name prefixes to the family (vendor) that trains them
+var modelFamilies = []struct{ prefix, family string }{
+	{"gpt", "openai"}, {"o1", "openai"}, {"o3", "openai"}, {"o4", "openai"}, {"chatgpt", "openai"},
+	{"claude", "anthropic"},
+	{"gemini", "google"}, {"gemma", "google"}, {"palm", "google"},
+	{"llama", "meta"}, {"meta-llama", "meta"},
+	{"mistral", "mistral"}, {"mixtral", "mistral"}, {"codestral", "mistral"},
+	{"qwen", "qwen"}, {"deepseek", "deepseek"}, {"command", "cohere"}, {"grok", "xai"}, {"phi", "microsoft"},
+}
+
+// modelFamily guesses the family of a model from its name: "openai/gpt-4o" and "gpt-4o-mini" are
+// both openai; unknown names are their first word, e.g. "acme" for "acme-large:v2"
+func modelFamily(model string) string {
+	name := strings.ToLower(strings.TrimSpace(model))
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	for _, f := range modelFamilies {
+		if strings.HasPrefix(name, f.prefix) {
+			return f.family
+		}
+	}
+	if i := strings.IndexAny(name, "-_:. "); i > 0 {
+		return name[:i]
+	}
+	return name
+}
+
+// judgeScoresOf returns each judge's scores of a result: judge_scores when several judges
+// scored it, otherwise judge_model with the logged scores
+// A judge in judge_scores without a combined score gets the mean of its scores as combined
+func judgeScoresOf(result EvalResult) map[string]map[string]float64 {
+	if len(result.JudgeScores) > 0 {
+		out := make(map[string]map[string]float64, len(result.JudgeScores))
+		for judge, scores := range result.JudgeScores {
+			if _, ok := scores["combined"]; !ok && len(scores) > 0 {
+				scores = maps.Clone(scores)
+				scores["combined"] = mean(slices.Collect(maps.Values(scores)))
+			}
+			out[judge] = scores
+		}
+		return out
+	}
+	if result.JudgeModel == "" {
+		return nil
+	}
+	scores := maps.Clone(result.Scores.Custom)
+	if scores == nil {
+		scores = make(map[string]float64)
+	}
+	scores["combined"] = result.Scores.Combined
+	return map[string]map[string]float64{result.JudgeModel: scores}
+}
+
+// LengthBin is one judge's mean score over responses of similar length
+type LengthBin struct {
+	MinChars  int     `json:"min_chars"`
+	MaxChars  int     `json:"max_chars"`
+	MeanChars float64 `json:"mean_chars"`
+	MeanScore float64 `json:"mean_score"`
+	Count     int     `json:"count"`
+
+	X, Y float64 `json:"-"` // Position in the chart
+}
+
+// LengthBias is how one judge's scores follow response length, the same judges in the same order
+// as SelfPreference
+type LengthBias struct {
+	Judge   string      `json:"judge"`
+	Results int         `json:"results"`
+	R       float64     `json:"-"` // Spearman correlation of score and length, NaN without variance
+	Bins    []LengthBin `json:"bins"`
+	Color   string      `json:"-"`
+	Path    string      `json:"-"`
+}
+
+// Flagged reports a judge whose scores rise (or fall) with response length
+func (b LengthBias) Flagged() bool {
+	return b.Results >= minJudgeBiasResults && math.Abs(b.R) >= lengthBiasR
+}
+
+// MarshalJSON writes an undefined correlation as null
+func (b LengthBias) MarshalJSON() ([]byte, error) {
+	type plain LengthBias
+	return json.Marshal(struct {
+		plain
+		R       any  `json:"spearman_r"`
+		Flagged bool `json:"flagged"`
+	}{plain(b), jsonNumber(b.R), b.Flagged()})
+}
+
+// SelfPreference compares the scores a judge gives its own model family with the others
+type SelfPreference struct {
+	Judge  string  `json:"judge"`
+	Family string  `json:"family"`
+	Own    int     `json:"own_results"`   // Results of models of the judge's family
+	Other  int     `json:"other_results"` // Results of other models
+	OwnAvg float64 `json:"-"`             // NaN without own results
+	// OtherAvg is NaN without results of other models
+	OtherAvg float64 `json:"-"`
+	// RelativeGap controls for the judged models being better or worse: how much more the judge
+	// scores its own family than the other judges do, minus the same for other families
+	// NaN without results scored by other judges too
+	RelativeGap float64 `json:"-"`
+
+	Color            string   `json:"-"`
+	OwnBar, OtherBar ChartBar `json:"-"`
+}
+
+// Gap is how much higher the judge scores its own family than the others, NaN when either is missing
+func (p SelfPreference) Gap() float64 {
+	return p.OwnAvg - p.OtherAvg
+}
+
+// Flagged reports a judge favoring its own family: a gap that the other judges, when there are
+// any, don't show on the same results
+func (p SelfPreference) Flagged() bool {
+	if p.Own < minSelfPreferenceOwn || math.IsNaN(p.Gap()) || p.Gap() < selfPreferenceGap {
+		return false
+	}
+	return math.IsNaN(p.RelativeGap) || p.RelativeGap >= selfPreferenceGap
+}
+
+// MarshalJSON writes missing averages and gaps as null
+func (p SelfPreference) MarshalJSON() ([]byte, error) {
+	type plain SelfPreference
+	return json.Marshal(struct {
+		plain
+		OwnAvg      any  `json:"own_avg"`
+		OtherAvg    any  `json:"other_avg"`
+		Gap         any  `json:"gap"`
+		RelativeGap any  `json:"relative_gap"`
+		Flagged     bool `json:"flagged"`
+	}{plain(p), jsonNumber(p.OwnAvg), jsonNumber(p.OtherAvg), jsonNumber(p.Gap()), jsonNumber(p.RelativeGap), p.Flagged()})
+}
+
+// JudgeBias is the bias section of the judges page
+type JudgeBias struct {
+	Score          string           `json:"score"`
+	Scores         []string         `json:"-"` // Scores the judges gave, for the score picker
+	LengthBias     []LengthBias     `json:"length_bias"`
+	SelfPreference []SelfPreference `json:"self_preference"`
+
+	LengthFrame *ChartFrame `json:"-"`
+	FamilyFrame *ChartFrame `json:"-"`
+}
+
+// BuildJudgeBias looks for systematic biases of the judges in one score over results without an
+// error: scores following response length, and judges favoring models of their own family
+func BuildJudgeBias(results []EvalResult, score string) JudgeBias {
+	bias := JudgeBias{Score: score, LengthBias: []LengthBias{}, SelfPreference: []SelfPreference{}}
+	type judged struct {
+		length int
+		score  float64
+		own    bool
+		others float64 // Mean score of the other judges, NaN when there are none
+	}
+	byJudge := make(map[string][]judged)
+	scoreNames := make(map[string]bool)
+	var lengths []float64
+	for _, result := range scored(results) {
+		all := judgeScoresOf(result)
+		length := utf8.RuneCountInString(result.Response)
+		family := modelFamily(result.Model)
+		for judge, scores := range all {
+			for name := range scores {
+				scoreNames[name] = true
+			}
+			v, ok := scores[score]
+			if !ok {
+				continue
+			}
+			var others []float64
+			for other, otherScores := range all {
+				if o, ok := otherScores[score]; ok && other != judge {
+					others = append(others, o)
+				}
+			}
+			j := judged{length: length, score: v, own: modelFamily(judge) == family, others: math.NaN()}
+			if len(others) > 0 {
+				j.others = mean(others)
+			}
+			byJudge[judge] = append(byJudge[judge], j)
+			if result.Response != "" {
+				lengths = append(lengths, float64(length))
+			}
+		}
+	}
+	judges := slices.Sorted(maps.Keys(byJudge))
+	bias.Scores = sortedKeys(scoreNames, "combined")
+
+	// Length bins by quantile of every judged response, the same for all judges
+	sort.Float64s(lengths)
+	var edges []float64
+	for i := 1; i < judgeLengthBins && len(lengths) > 0; i++ {
+		edges = append(edges, percentile(lengths, float64(i)*100/judgeLengthBins))
+	}
+	edges = slices.Compact(edges)
+
+	for _, judge := range judges {
+		lb := LengthBias{Judge: judge, R: math.NaN()}
+		var xs, ys []float64
+		bins := make([]LengthBin, len(edges)+1)
+		for _, j := range byJudge[judge] {
+			if j.length == 0 {
+				continue
+			}
+			xs, ys = append(xs, float64(j.length)), append(ys, j.score)
+			b := &bins[sort.SearchFloat64s(edges, float64(j.length))]
+			if b.Count == 0 || j.length < b.MinChars {
+				b.MinChars = j.length
+			}
+			b.MaxChars = max(b.MaxChars, j.length)
+			b.MeanChars += float64(j.length)
+			b.MeanScore += j.score
+			b.Count++
+		}
+		lb.Results = len(xs)
+		if len(xs) >= 3 {
+			lb.R = pearson(ranks(xs), ranks(ys))
+		}
+		for _, b := range bins {
+			if b.Count > 0 {
+				b.MeanChars /= float64(b.Count)
+				b.MeanScore /= float64(b.Count)
+				lb.Bins = append(lb.Bins, b)
+			}
+		}
+		bias.LengthBias = append(bias.LengthBias, lb)
+
+		sp := SelfPreference{Judge: judge, Family: modelFamily(judge), OwnAvg: math.NaN(), OtherAvg: math.NaN(), RelativeGap: math.NaN()}
+		var own, other, ownRel, otherRel []float64
+		for _, j := range byJudge[judge] {
+			if j.own {
+				own = append(own, j.score)
+				if !math.IsNaN(j.others) {
+					ownRel = append(ownRel, j.score-j.others)
+				}
+			} else {
+				other = append(other, j.score)
+				if !math.IsNaN(j.others) {
+					otherRel = append(otherRel, j.score-j.others)
+				}
+			}
+		}
+		sp.Own, sp.Other = len(own), len(other)
+		if len(own) > 0 {
+			sp.OwnAvg = mean(own)
+		}
+		if len(other) > 0 {
+			sp.OtherAvg = mean(other)
+		}
+		if len(ownRel) > 0 && len(otherRel) > 0 {
+			sp.RelativeGap = mean(ownRel) - mean(otherRel)
+		}
+		bias.SelfPreference = append(bias.SelfPreference, sp)
+	}
+	layoutJudgeBias(&bias)
+	return bias
+}
+
+// layoutJudgeBias places the length bias lines and the own/other family bars
+func layoutJudgeBias(bias *JudgeBias) {
+	minX, maxX, maxY := math.Inf(1), math.Inf(-1), 1.0
+	for _, lb := range bias.LengthBias {
+		for _, b := range lb.Bins {
+			minX, maxX = math.Min(minX, b.MeanChars), math.Max(maxX, b.MeanChars)
+			maxY = math.Max(maxY, b.MeanScore)
+		}
+	}
+	for i := range bias.SelfPreference {
+		bias.SelfPreference[i].Color = seriesColor(i)
+	}
+	if !math.IsInf(minX, 1) {
+		frame := newChartFrame(judgeBiasWidth, judgeBiasHeight, minX, maxX, maxY)
+		frame.tickX(nil, func(v float64) string { return formatSweepValue(math.Round(v)) })
+		for i := range bias.LengthBias {
+			lb := &bias.LengthBias[i]
+			lb.Color = seriesColor(i)
+			xs, ys := make([]float64, len(lb.Bins)), make([]float64, len(lb.Bins))
+			for j := range lb.Bins {
+				b := &lb.Bins[j]
+				b.X, b.Y = frame.X(b.MeanChars), frame.Y(b.MeanScore)
+				xs[j], ys[j] = b.X, b.Y
+			}
+			lb.Path = polyline(xs, ys)
+		}
+		bias.LengthFrame = frame
+	}
+
+	var charted []*SelfPreference
+	for i := range bias.SelfPreference {
+		sp := &bias.SelfPreference[i]
+		if sp.Own > 0 && sp.Other > 0 {
+			charted = append(charted, sp)
+			maxY = math.Max(maxY, math.Max(sp.OwnAvg, sp.OtherAvg))
+		}
+	}
+	if len(charted) == 0 {
+		return
+	}
+	frame := newChartFrame(judgeBiasWidth, judgeBiasHeight, 0, float64(len(charted)), maxY)
+	group := (frame.X(1) - frame.X(0))
+	barW := group * 0.3
+	for i, sp := range charted {
+		center := frame.X(float64(i) + 0.5)
+		frame.XTicks = append(frame.XTicks, ChartTick{Pos: center, Label: sp.Judge})
+		sp.OwnBar = ChartBar{X: center - barW, Y: frame.Y(sp.OwnAvg), W: barW - 1, H: frame.Y(0) - frame.Y(sp.OwnAvg),
+			Label: fmt.Sprintf("%s on %s models: %.3f (n=%d)", sp.Judge, sp.Family, sp.OwnAvg, sp.Own)}
+		sp.OtherBar = ChartBar{X: center + 1, Y: frame.Y(sp.OtherAvg), W: barW - 1, H: frame.Y(0) - frame.Y(sp.OtherAvg),
+			Label: fmt.Sprintf("%s on other models: %.3f (n=%d)", sp.Judge, sp.OtherAvg, sp.Other)}
+	}
+	bias.FamilyFrame = frame
+}
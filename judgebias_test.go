@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestModelFamily(t *testing.T) {
+	for model, want := range map[string]string{
+		"gpt-4o-mini":           "openai",
+		"openai/gpt-4o":         "openai",
+		"o3-mini":               "openai",
+		"Claude-3-5-Sonnet":     "anthropic",
+		"gemini-1.5-pro":        "google",
+		"meta-llama/Llama-3-8B": "meta",
+		"mixtral-8x7b":          "mistral",
+		"acme-large:v2":         "acme",
+		"local":                 "local",
+	} {
+		if got := modelFamily(model); got != want {
+			t.Errorf("modelFamily(%q) = %q, want %q", model, got, want)
+		}
+	}
+}
+
+func TestJudgeLengthBias(t *testing.T) {
+	var results []EvalResult
+	for i := range 20 {
+		results = append(results, EvalResult{
+			Model:      "acme",
+			JudgeModel: "gpt-4o",
+			Response:   strings.Repeat("x", 10*(i+1)),
+			Scores:     ScoreBreakdown{Combined: float64(i) / 20},
+		})
+	}
+	results = append(results, EvalResult{Model: "acme", JudgeModel: "gpt-4o", Error: "timeout", Scores: ScoreBreakdown{Combined: 1}})
+
+	bias := BuildJudgeBias(results, "combined")
+	if len(bias.LengthBias) != 1 {
+		t.Fatalf("length bias = %+v", bias.LengthBias)
+	}
+	lb := bias.LengthBias[0]
+	if lb.Results != 20 || math.Abs(lb.R-1) > 1e-9 || !lb.Flagged() {
+		t.Errorf("length bias = %+v", lb)
+	}
+	if len(lb.Bins) != judgeLengthBins || lb.Bins[0].MeanScore >= lb.Bins[len(lb.Bins)-1].MeanScore {
+		t.Errorf("bins = %+v", lb.Bins)
+	}
+	if bias.LengthFrame == nil || lb.Path == "" {
+		t.Error("length bias chart is not laid out")
+	}
+}
+
+func TestJudgeSelfPreference(t *testing.T) {
+	var results []EvalResult
+	for i := range 10 {
+		// Both judges agree on everything but gpt-4o's own family, which it scores 0.2 higher
+		model := "claude-3-haiku"
+		if i%2 == 0 {
+			model = "gpt-4o-mini"
+		}
+		gpt := 0.5
+		if i%2 == 0 {
+			gpt = 0.7
+		}
+		results = append(results, EvalResult{
+			Model:       model,
+			Response:    "answer",
+			JudgeScores: map[string]map[string]float64{"gpt-4o": {"accuracy": gpt}, "claude-3-opus": {"accuracy": 0.5}},
+		})
+	}
+
+	bias := BuildJudgeBias(results, "accuracy")
+	if len(bias.SelfPreference) != 2 {
+		t.Fatalf("self preference = %+v", bias.SelfPreference)
+	}
+	claude, gpt := bias.SelfPreference[0], bias.SelfPreference[1]
+	if gpt.Judge != "gpt-4o" || gpt.Own != 5 || gpt.Other != 5 || math.Abs(gpt.Gap()-0.2) > 1e-9 || !gpt.Flagged() {
+		t.Errorf("gpt-4o = %+v", gpt)
+	}
+	// claude-3-opus scores both families the same, its relative gap only mirrors gpt-4o's
+	if claude.Family != "anthropic" || math.Abs(claude.Gap()) > 1e-9 || claude.Flagged() || math.Abs(claude.RelativeGap-0.2) > 1e-9 {
+		t.Errorf("claude-3-opus = %+v", claude)
+	}
+	if bias.FamilyFrame == nil || gpt.OwnBar.H <= gpt.OtherBar.H {
+		t.Error("self-preference chart is not laid out")
+	}
+
+	// Same response lengths: no correlation, written as null
+	data, err := json.Marshal(bias.LengthBias[0])
+	if err != nil || !strings.Contains(string(data), `"spearman_r":null`) {
+		t.Errorf("JSON = %s, %v", data, err)
+	}
+}
+
+func TestJudgesPageBias(t *testing.T) {
+	var lines []string
+	for i := range 6 {
+		lines = append(lines, fmt.Sprintf(`{"timestamp":"2025-01-01T10:00:0%dZ","model":"gpt-4o-mini","test_id":"t%d","response":"%s","judge_model":"gpt-4o","scores":{"combined":0.%d}}`, i, i, strings.Repeat("a", i+1), i))
+	}
+	withStore(t, lines...)
+
+	rec := httptest.NewRecorder()
+	judgesHandler(rec, httptest.NewRequest(http.MethodGet, "/judges", nil))
+	if body := rec.Body.String(); !strings.Contains(body, "Length Bias") || !strings.Contains(body, "No results scored by several judges") {
+		t.Error("judges page has no length bias for a single judge")
+	}
+
+	rec = httptest.NewRecorder()
+	judgesAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/api/judges", nil))
+	var report struct {
+		Bias struct {
+			Score      string `json:"score"`
+			LengthBias []struct {
+				Judge string   `json:"judge"`
+				R     *float64 `json:"spearman_r"`
+			} `json:"length_bias"`
+		} `json:"bias"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil || report.Bias.Score != "combined" ||
+		len(report.Bias.LengthBias) != 1 || report.Bias.LengthBias[0].R == nil || *report.Bias.LengthBias[0].R != 1 {
+		t.Errorf("API = %+v, %v", report, err)
+	}
+}
@@ -0,0 +1,652 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// judgePassThreshold splits judge scores into pass and fail verdicts for Cohen's kappa
+const judgePassThreshold = 0.5
+
+// minJudgeItems is the number of results two judges must both score before their agreement counts
+const minJudgeItems = 5
+
+// lowJudgeAgreement is the mean kappa below which a judge is flagged: at most "fair" agreement
+// with the other judges on the Landis & Koch scale
+const lowJudgeAgreement = 0.4
+
+// JudgePair is the agreement of two judges on one score
+type JudgePair struct {
+	A, B        string
+	Items       int     // Results both judges scored
+	Agreement   float64 // Share of results with the same pass/fail verdict
+	Kappa       float64 // Cohen's kappa of the pass/fail verdicts, NaN when undefined
+	MeanAbsDiff float64 // Mean absolute difference of the scores
+}
+
+// JudgeAgreement is the agreement of every judge on one score
+type JudgeAgreement struct {
+	Score  string
+	Items  int     // Results scored by at least two judges
+	Judges int     // Judges that gave this score
+	Alpha  float64 // Krippendorff's alpha (interval metric) over all judges, NaN when undefined
+	Pairs  []JudgePair
+}
+
+// JudgeReliability summarizes how one judge agrees with the others
+type JudgeReliability struct {
+	Judge     string
+	Items     int     // Results the judge scored along with another judge
+	MeanKappa float64 // Mean kappa with the other judges over pairs with enough results, NaN without any
+	// AlphaWithout is, per score, the alpha of the other judges without this one; with three
+	// judges or more, an alpha that rises when a judge is left out points at that judge
+	AlphaWithout map[string]float64
+}
+
+// Low reports whether the judge agrees with the others no better than fairly
+func (j JudgeReliability) Low() bool {
+	return !math.IsNaN(j.MeanKappa) && j.MeanKappa < lowJudgeAgreement
+}
+
+// JudgeReport is the judges page: agreement per score and judge pair, and per judge
+type JudgeReport struct {
+	Threshold   float64            `json:"threshold"`
+	Results     int                `json:"results"` // Results with scores from at least two judges
+	Judges      []string           `json:"judges"`
+	Scores      []JudgeAgreement   `json:"scores"` // "combined" first, then by name
+	Reliability []JudgeReliability `json:"reliability"`
+}
+
+// agreementStrength names a kappa or alpha on the Landis & Koch scale
+func agreementStrength(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "undefined"
+	case v < 0:
+		return "poor"
+	case v < 0.2:
+		return "slight"
+	case v < 0.4:
+		return "fair"
+	case v < 0.6:
+		return "moderate"
+	case v < 0.8:
+		return "substantial"
+	}
+	return "almost perfect"
+}
+
+func (p JudgePair) Strength() string      { return agreementStrength(p.Kappa) }
+func (a JudgeAgreement) Strength() string { return agreementStrength(a.Alpha) }
+
+// jsonNumber is v for JSON, null when it is NaN
+func jsonNumber(v float64) any {
+	if math.IsNaN(v) {
+		return nil
+	}
+	return v
+}
+
+// MarshalJSON writes undefined coefficients as null
+func (p JudgePair) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"a": p.A, "b": p.B, "items": p.Items, "agreement": p.Agreement,
+		"kappa": jsonNumber(p.Kappa), "mean_abs_diff": p.MeanAbsDiff,
+	})
+}
+
+// MarshalJSON writes undefined coefficients as null
+func (a JudgeAgreement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"score": a.Score, "items": a.Items, "judges": a.Judges, "alpha": jsonNumber(a.Alpha), "pairs": a.Pairs,
+	})
+}
+
+// MarshalJSON writes undefined coefficients as null
+func (j JudgeReliability) MarshalJSON() ([]byte, error) {
+	without := make(map[string]any, len(j.AlphaWithout))
+	for score, alpha := range j.AlphaWithout {
+		without[score] = jsonNumber(alpha)
+	}
+	return json.Marshal(map[string]any{
+		"judge": j.Judge, "items": j.Items, "mean_kappa": jsonNumber(j.MeanKappa), "alpha_without": without, "low": j.Low(),
+	})
+}
+
+// krippendorffAlpha computes Krippendorff's alpha with the interval metric; each unit holds the
+// values the judges gave one result, units with a single value don't count
+func krippendorffAlpha(units [][]float64) float64 {
+	var n, sum, sumSq, observed float64
+	for _, values := range units {
+		if len(values) < 2 {
+			continue
+		}
+		d := 0.0
+		for _, a := range values {
+			for _, b := range values {
+				d += (a - b) * (a - b)
+			}
+		}
+		observed += d / float64(len(values)-1)
+		n += float64(len(values))
+		for _, v := range values {
+			sum += v
+			sumSq += v * v
+		}
+	}
+	if n < 2 {
+		return math.NaN()
+	}
+	// Mean squared difference of all pairs of values, whichever result they belong to
+	expected := 2 * (n*sumSq - sum*sum) / (n * (n - 1))
+	if expected <= 0 {
+		return math.NaN()
+	}
+	return 1 - (observed/n)/expected
+}
+
+// cohensKappa computes Cohen's kappa of two raters' pass/fail verdicts on the same items
+func cohensKappa(a, b []bool) float64 {
+	if len(a) == 0 {
+		return math.NaN()
+	}
+	var agree, aPass, bPass float64
+	for i := range a {
+		if a[i] == b[i] {
+			agree++
+		}
+		if a[i] {
+			aPass++
+		}
+		if b[i] {
+			bPass++
+		}
+	}
+	n := float64(len(a))
+	chance := (aPass/n)*(bPass/n) + (1-aPass/n)*(1-bPass/n)
+	if chance == 1 {
+		return math.NaN() // Both judges always give the same verdict
+	}
+	return (agree/n - chance) / (1 - chance)
+}
+
+// BuildJudgeReport measures inter-rater agreement of the judges in judge_scores, over results
+// without an error that at least two judges scored; scores >= threshold are pass verdicts
+func BuildJudgeReport(results []EvalResult, threshold float64) JudgeReport {
+	report := JudgeReport{Threshold: threshold, Scores: []JudgeAgreement{}, Reliability: []JudgeReliability{}}
+	judgeSet, scoreSet := make(map[string]bool), make(map[string]bool)
+	var scored []EvalResult
+	for _, result := range results {
+		if result.Error != "" || len(result.JudgeScores) < 2 {
+			continue
+		}
+		scored = append(scored, result)
+		for judge, scores := range result.JudgeScores {
+			judgeSet[judge] = true
+			for score := range scores {
+				scoreSet[score] = true
+			}
+		}
+	}
+	report.Results = len(scored)
+	report.Judges = append([]string{}, sortedKeys(judgeSet, "")...)
+	scores := sortedKeys(scoreSet, "combined")
+
+	// units collects the values of one score per result, optionally leaving a judge out
+	units := func(score, skip string) [][]float64 {
+		var list [][]float64
+		for _, result := range scored {
+			var values []float64
+			for _, judge := range report.Judges {
+				if v, ok := result.JudgeScores[judge][score]; ok && judge != skip {
+					values = append(values, v)
+				}
+			}
+			list = append(list, values)
+		}
+		return list
+	}
+
+	kappas := make(map[string][]float64)
+	for _, score := range scores {
+		agreement := JudgeAgreement{Score: score}
+		judges := make(map[string]bool)
+		for _, values := range units(score, "") {
+			if len(values) >= 2 {
+				agreement.Items++
+			}
+		}
+		agreement.Alpha = krippendorffAlpha(units(score, ""))
+
+		for i, a := range report.Judges {
+			for _, b := range report.Judges[i+1:] {
+				pair := JudgePair{A: a, B: b, Kappa: math.NaN()}
+				var va, vb []bool
+				diff := 0.0
+				for _, result := range scored {
+					sa, okA := result.JudgeScores[a][score]
+					sb, okB := result.JudgeScores[b][score]
+					if !okA || !okB {
+						continue
+					}
+					judges[a], judges[b] = true, true
+					va, vb = append(va, sa >= threshold), append(vb, sb >= threshold)
+					if va[len(va)-1] == vb[len(vb)-1] {
+						pair.Agreement++
+					}
+					diff += math.Abs(sa - sb)
+				}
+				if pair.Items = len(va); pair.Items == 0 {
+					continue
+				}
+				pair.Agreement /= float64(pair.Items)
+				pair.MeanAbsDiff = diff / float64(pair.Items)
+				if pair.Items >= minJudgeItems {
+					pair.Kappa = cohensKappa(va, vb)
+					if !math.IsNaN(pair.Kappa) {
+						kappas[a] = append(kappas[a], pair.Kappa)
+						kappas[b] = append(kappas[b], pair.Kappa)
+					}
+				}
+				agreement.Pairs = append(agreement.Pairs, pair)
+			}
+		}
+		agreement.Judges = len(judges)
+		report.Scores = append(report.Scores, agreement)
+	}
+
+	for _, judge := range report.Judges {
+		reliability := JudgeReliability{Judge: judge, MeanKappa: math.NaN(), AlphaWithout: make(map[string]float64)}
+		for _, result := range scored {
+			if len(result.JudgeScores[judge]) > 0 {
+				reliability.Items++
+			}
+		}
+		if len(kappas[judge]) > 0 {
+			reliability.MeanKappa = mean(kappas[judge])
+		}
+		for _, agreement := range report.Scores {
+			if agreement.Judges >= 3 {
+				reliability.AlphaWithout[agreement.Score] = krippendorffAlpha(units(agreement.Score, judge))
+			}
+		}
+		report.Reliability = append(report.Reliability, reliability)
+	}
+	sort.SliceStable(report.Reliability, func(i, j int) bool {
+		// Least agreeing judges first, judges without a kappa last
+		ki, kj := report.Reliability[i].MeanKappa, report.Reliability[j].MeanKappa
+		return !math.IsNaN(ki) && (math.IsNaN(kj) || ki < kj)
+	})
+	return report
+}
+
+// judgeThreshold reads ?threshold=, the pass/fail split of judge scores
+func judgeThreshold(r *http.Request) (float64, error) {
+	value := r.URL.Query().Get("threshold")
+	if value == "" {
+		return judgePassThreshold, nil
+	}
+	threshold, err := strconv.ParseFloat(value, 64)
+	if err != nil || threshold < 0 || threshold > 1 {
+		return 0, fmt.Errorf("threshold must be a number between 0 and 1")
+	}
+	return threshold, nil
+}
+
+// judgeBiasScore reads ?score=, the score checked for judge bias and calibration, combined by default
+func judgeBiasScore(r *http.Request) string {
+	if score := r.URL.Query().Get("score"); score != "" {
+		return score
+	}
+	return "combined"
+}
+
+// judgesHandler renders the judge reliability page
+func judgesHandler(w http.ResponseWriter, r *http.Request) {
+	threshold, err := judgeThreshold(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	results, err := store.Query(r.Context(), dashboardFilter(r))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Title       string
+		Subtitle    string
+		MinItems    int
+		Bias        JudgeBias
+		Calibration Calibration
+		JudgeReport
+	}{
+		Title:       "Judges",
+		Subtitle:    "How much the judge models agree with each other, and whether they favor long answers or their own model family",
+		MinItems:    minJudgeItems,
+		Bias:        BuildJudgeBias(results, judgeBiasScore(r)),
+		Calibration: BuildCalibration(results, annotationsFor(r.Context()).List(), judgeBiasScore(r), threshold),
+		JudgeReport: BuildJudgeReport(results, threshold),
+	}
+
+	renderPage(w, r, "judges", judgesTemplate, data)
+}
+
+// judgesAPIHandler returns the judge agreement report as JSON
+func judgesAPIHandler(w http.ResponseWriter, r *http.Request) {
+	threshold, err := judgeThreshold(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	results, err := store.Query(r.Context(), dashboardFilter(r))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	report := struct {
+		JudgeReport
+		Bias        JudgeBias   `json:"bias"`
+		Calibration Calibration `json:"calibration"`
+	}{
+		BuildJudgeReport(results, threshold),
+		BuildJudgeBias(results, judgeBiasScore(r)),
+		BuildCalibration(results, annotationsFor(r.Context()).List(), judgeBiasScore(r), threshold),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("Error encoding JSON: %v", err)
+	}
+}
+
+const judgesTemplate = `
+{{ define "style" }}
+        td.num {
+            font-family: monospace;
+            text-align: right;
+            white-space: nowrap;
+        }
+        td.empty {
+            color: var(--text-tertiary);
+            text-align: center;
+        }
+        .low-agreement {
+            color: var(--error);
+            font-size: 0.8125rem;
+            font-weight: 600;
+        }
+        .bias-grid {
+            display: grid;
+            grid-template-columns: repeat(auto-fit, minmax(420px, 1fr));
+            gap: 1.5rem;
+            margin-bottom: 1.5rem;
+        }
+        .bias-grid .panel {
+            margin-bottom: 0;
+        }
+        .bar-other {
+            opacity: 0.45;
+        }
+        .diagonal {
+            stroke: var(--text-tertiary);
+            stroke-dasharray: 4,4;
+        }
+{{ end }}
+
+{{ define "coefficient" }}{{ if isNaN . }}<td class="empty">-</td>{{ else }}<td class="num" style="background: {{ heatColor . }};">{{ printf "%.2f" . }}</td>{{ end }}{{ end }}
+
+{{ define "rate" }}{{ if isNaN . }}<td class="empty">-</td>{{ else }}<td class="num">{{ printf "%.1f" (percent .) }}%</td>{{ end }}{{ end }}
+
+{{ define "content" }}
+        {{ if .Judges }}
+        <div class="panel">
+            <h2>Judge Reliability</h2>
+            <p class="muted" style="margin-bottom: 1rem; font-size: 0.875rem;">{{ human .Results }} results scored by at least two judges. Judges agreeing with the others no better than fairly (mean kappa below 0.4) come first: consider checking their prompts or dropping them.</p>
+            <div style="overflow-x: auto;">
+            <table>
+                <thead>
+                    <tr>
+                        <th>Judge</th>
+                        <th>Results</th>
+                        <th title="Mean Cohen's kappa with the other judges, over every score and pair with at least {{ .MinItems }} shared results">Mean kappa</th>
+                        {{ range .Scores }}{{ if ge .Judges 3 }}<th title="Krippendorff's alpha of the other judges on {{ .Score }} (all judges: {{ printf "%.2f" .Alpha }}) - higher than with everyone means this judge lowers agreement">α without, {{ .Score }}</th>{{ end }}{{ end }}
+                    </tr>
+                </thead>
+                <tbody>
+                    {{ range $judge := .Reliability }}
+                    <tr>
+                        <td><strong>{{ .Judge }}</strong>{{ if .Low }} <span class="low-agreement">low agreement</span>{{ end }}</td>
+                        <td class="num">{{ human .Items }}</td>
+                        {{ template "coefficient" .MeanKappa }}
+                        {{ range $.Scores }}{{ if ge .Judges 3 }}{{ template "coefficient" (index $judge.AlphaWithout .Score) }}{{ end }}{{ end }}
+                    </tr>
+                    {{ end }}
+                </tbody>
+            </table>
+            </div>
+        </div>
+
+        <div class="panel">
+            <h2>Agreement per Score</h2>
+            <div style="overflow-x: auto;">
+            <table>
+                <thead>
+                    <tr>
+                        <th>Score</th>
+                        <th>Results</th>
+                        <th>Judges</th>
+                        <th title="Krippendorff's alpha (interval): 1 = perfect agreement, 0 = no better than chance">Alpha</th>
+                        <th>Strength</th>
+                    </tr>
+                </thead>
+                <tbody>
+                    {{ range .Scores }}
+                    <tr>
+                        <td class="mono">{{ .Score }}</td>
+                        <td class="num">{{ human .Items }}</td>
+                        <td class="num">{{ .Judges }}</td>
+                        {{ template "coefficient" .Alpha }}
+                        <td>{{ .Strength }}</td>
+                    </tr>
+                    {{ end }}
+                </tbody>
+            </table>
+            </div>
+        </div>
+
+        <div class="panel">
+            <h2>Judge Pairs</h2>
+            <p class="muted" style="margin-bottom: 1rem; font-size: 0.875rem;">Verdicts are pass for scores of at least {{ .Threshold }} (change with <span class="mono">?threshold=</span>). Kappa needs {{ .MinItems }} shared results.</p>
+            <div style="overflow-x: auto;">
+            <table>
+                <thead>
+                    <tr>
+                        <th>Score</th>
+                        <th>Judges</th>
+                        <th>Results</th>
+                        <th title="Share of results where both judges give the same pass/fail verdict">Same verdict</th>
+                        <th title="Cohen's kappa of the pass/fail verdicts: agreement corrected for chance">Kappa</th>
+                        <th>Strength</th>
+                        <th title="Mean absolute difference of the two judges' scores">Mean |diff|</th>
+                    </tr>
+                </thead>
+                <tbody>
+                    {{ range .Scores }}{{ $score := .Score }}{{ range .Pairs }}
+                    <tr>
+                        <td class="mono">{{ $score }}</td>
+                        <td>{{ .A }} · {{ .B }}</td>
+                        <td class="num">{{ human .Items }}</td>
+                        <td class="num">{{ printf "%.1f" (percent .Agreement) }}%</td>
+                        {{ template "coefficient" .Kappa }}
+                        <td>{{ .Strength }}</td>
+                        <td class="num">{{ printf "%.3f" .MeanAbsDiff }}</td>
+                    </tr>
+                    {{ end }}{{ end }}
+                </tbody>
+            </table>
+            </div>
+        </div>
+        {{ else }}
+        <div class="panel">
+            <p class="muted">No results scored by several judges. Log each judge's scores in <span class="mono">judge_scores</span>, e.g. <span class="mono">"judge_scores": {"gpt-4o": {"faithfulness": 0.9}, "claude-3-5-sonnet": {"faithfulness": 0.7}}</span>, to measure how much the judges agree.</p>
+        </div>
+        {{ end }}
+
+        {{ with .Bias }}
+        {{ if .LengthBias }}
+        <div class="panel">
+            <form method="get">
+                <input type="hidden" name="threshold" value="{{ $.Threshold }}">
+                <label class="muted" for="score">Bias in score</label>
+                <select id="score" name="score" onchange="this.form.submit()">
+                    {{ range .Scores }}<option value="{{ . }}" {{ if eq . $.Bias.Score }}selected{{ end }}>{{ . }}</option>{{ end }}
+                </select>
+            </form>
+        </div>
+
+        <div class="bias-grid">
+            {{ if .LengthFrame }}
+            <div class="panel chart">
+                <h2>Length Bias{{ if .LengthFrame.LogX }} <span class="muted" style="font-size: 0.75rem;">(log scale)</span>{{ end }}</h2>
+                <p class="muted" style="margin-bottom: 1rem; font-size: 0.875rem;">Mean <span class="mono">{{ .Score }}</span> per response length quintile (characters). A line rising to the right means longer answers score higher.</p>
+                <svg viewBox="0 0 {{ .LengthFrame.Width }} {{ .LengthFrame.Height }}" role="img" aria-label="{{ .Score }} vs response length">
+                    {{ template "chart-axes" .LengthFrame }}
+                    {{ range .LengthBias }}
+                    <g class="{{ .Color }}">
+                        <polyline points="{{ .Path }}" fill="none" stroke="currentColor" stroke-width="2"/>
+                        {{ $judge := .Judge }}
+                        {{ range .Bins }}
+                        <circle cx="{{ .X }}" cy="{{ .Y }}" r="4" fill="currentColor">
+                            <title>{{ $judge }} · {{ .MinChars }}-{{ .MaxChars }} chars: {{ printf "%.3f" .MeanScore }} (n={{ .Count }})</title>
+                        </circle>
+                        {{ end }}
+                    </g>
+                    {{ end }}
+                </svg>
+                <div class="legend">
+                    {{ range .LengthBias }}<span><span class="legend-swatch {{ .Color }}"></span>{{ .Judge }}</span>{{ end }}
+                </div>
+            </div>
+            {{ end }}
+
+            {{ if .FamilyFrame }}
+            <div class="panel chart">
+                <h2>Self-Preference</h2>
+                <p class="muted" style="margin-bottom: 1rem; font-size: 0.875rem;">Mean <span class="mono">{{ .Score }}</span> each judge gives models of its own family (solid) and of other families (faded).</p>
+                <svg viewBox="0 0 {{ .FamilyFrame.Width }} {{ .FamilyFrame.Height }}" role="img" aria-label="{{ .Score }} on own vs other model families">
+                    {{ template "chart-axes" .FamilyFrame }}
+                    {{ range $p := .SelfPreference }}{{ if and $p.Own $p.Other }}
+                    <g class="{{ $p.Color }}">
+                        <rect x="{{ $p.OwnBar.X }}" y="{{ $p.OwnBar.Y }}" width="{{ $p.OwnBar.W }}" height="{{ $p.OwnBar.H }}" fill="currentColor"><title>{{ $p.OwnBar.Label }}</title></rect>
+                        <rect class="bar-other" x="{{ $p.OtherBar.X }}" y="{{ $p.OtherBar.Y }}" width="{{ $p.OtherBar.W }}" height="{{ $p.OtherBar.H }}" fill="currentColor"><title>{{ $p.OtherBar.Label }}</title></rect>
+                    </g>
+                    {{ end }}{{ end }}
+                </svg>
+            </div>
+            {{ end }}
+        </div>
+
+        <div class="panel">
+            <h2>Judge Bias</h2>
+            <p class="muted" style="margin-bottom: 1rem; font-size: 0.875rem;">Families are guessed from model names (gpt → openai, claude → anthropic, ...). The relative gap compares the judge with the other judges of the same results, so it isn't fooled by its own family simply answering better.</p>
+            <div style="overflow-x: auto;">
+            <table>
+                <thead>
+                    <tr>
+                        <th>Judge</th>
+                        <th>Family</th>
+                        <th title="Spearman correlation of {{ .Score }} and response length">Length r</th>
+                        <th>Own family</th>
+                        <th>Other families</th>
+                        <th title="Mean score on its own family minus on the others">Gap</th>
+                        <th title="Gap of this judge minus the gap of the other judges on the same results">Relative gap</th>
+                    </tr>
+                </thead>
+                <tbody>
+                    {{ range $i, $p := .SelfPreference }}
+                    {{ $length := index $.Bias.LengthBias $i }}
+                    <tr>
+                        <td><strong>{{ $p.Judge }}</strong>{{ if $length.Flagged }} <span class="low-agreement">length bias</span>{{ end }}{{ if $p.Flagged }} <span class="low-agreement">self-preference</span>{{ end }}</td>
+                        <td>{{ $p.Family }}</td>
+                        {{ template "coefficient" $length.R }}
+                        {{ if $p.Own }}<td class="num">{{ printf "%.3f" $p.OwnAvg }} <span class="muted">({{ human $p.Own }})</span></td>{{ else }}<td class="empty">-</td>{{ end }}
+                        {{ if $p.Other }}<td class="num">{{ printf "%.3f" $p.OtherAvg }} <span class="muted">({{ human $p.Other }})</span></td>{{ else }}<td class="empty">-</td>{{ end }}
+                        {{ if isNaN $p.Gap }}<td class="empty">-</td>{{ else }}<td class="num">{{ printf "%+.3f" $p.Gap }}</td>{{ end }}
+                        {{ if isNaN $p.RelativeGap }}<td class="empty">-</td>{{ else }}<td class="num">{{ printf "%+.3f" $p.RelativeGap }}</td>{{ end }}
+                    </tr>
+                    {{ end }}
+                </tbody>
+            </table>
+            </div>
+        </div>
+        {{ end }}
+        {{ end }}
+
+        {{ with .Calibration }}{{ if .Judges }}
+        <div class="bias-grid">
+            <div class="panel chart">
+                <h2>Calibration vs Humans</h2>
+                <p class="muted" style="margin-bottom: 1rem; font-size: 0.875rem;">Share of results reviewers accepted per <span class="mono">{{ .Score }}</span> bin, over {{ human .Reviewed }} reviewed results. A well calibrated judge follows the dashed diagonal; a curve below it is too lenient, above it too strict.</p>
+                <svg viewBox="0 0 {{ .Frame.Width }} {{ .Frame.Height }}" role="img" aria-label="human accept rate vs {{ .Score }}">
+                    {{ template "chart-axes" .Frame }}
+                    <polyline class="diagonal" points="{{ .Diagonal }}" fill="none"/>
+                    {{ range .Judges }}
+                    <g class="{{ .Color }}">
+                        <polyline points="{{ .Path }}" fill="none" stroke="currentColor" stroke-width="2"/>
+                        {{ $judge := .Judge }}
+                        {{ range .Bins }}
+                        <circle cx="{{ .X }}" cy="{{ .Y }}" r="4" fill="currentColor">
+                            <title>{{ $judge }} · {{ printf "%.1f" .Low }}-{{ printf "%.1f" .High }}: {{ printf "%.0f" (percent .AcceptRate) }}% accepted (n={{ .Count }})</title>
+                        </circle>
+                        {{ end }}
+                    </g>
+                    {{ end }}
+                </svg>
+                <div class="legend">
+                    {{ range .Judges }}<span><span class="legend-swatch {{ .Color }}"></span>{{ .Judge }}</span>{{ end }}
+                </div>
+            </div>
+
+            <div class="panel">
+                <h2>Judges vs Humans</h2>
+                <p class="muted" style="margin-bottom: 1rem; font-size: 0.875rem;">The judge passes a result when <span class="mono">{{ .Score }}</span> ≥ {{ .Threshold }}; reviewers accept or reject it (each reviewer's latest decision, majority wins). Precision: passed results humans accepted. Recall: accepted results the judge passed.</p>
+                <div style="overflow-x: auto;">
+                <table>
+                    <thead>
+                        <tr>
+                            <th>Judge</th>
+                            <th>Results</th>
+                            <th>Accuracy</th>
+                            <th>Precision</th>
+                            <th>Recall</th>
+                            <th title="Cohen's kappa of judge and human verdicts">Kappa</th>
+                            <th title="Judge passed, humans rejected / judge failed, humans accepted">FP / FN</th>
+                        </tr>
+                    </thead>
+                    <tbody>
+                        {{ range .Judges }}
+                        <tr>
+                            <td><strong>{{ .Judge }}</strong></td>
+                            <td class="num">{{ human .Results }}</td>
+                            {{ template "rate" .Accuracy }}
+                            {{ template "rate" .Precision }}
+                            {{ template "rate" .Recall }}
+                            {{ template "coefficient" .Kappa }}
+                            <td class="num">{{ .FalsePositives }} / {{ .FalseNegatives }}</td>
+                        </tr>
+                        {{ end }}
+                    </tbody>
+                </table>
+                </div>
+            </div>
+        </div>
+        {{ end }}{{ end }}
+{{ end }}`
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestKrippendorffAlpha(t *testing.T) {
+	if a := krippendorffAlpha([][]float64{{1, 1}, {0, 0}, {0.5, 0.5}}); a != 1 {
+		t.Errorf("perfect agreement: alpha = %v", a)
+	}
+	// Worked out by hand: D_o = 1, D_e = 2/3
+	if a := krippendorffAlpha([][]float64{{0, 1}, {1, 0}, {1}}); math.Abs(a+0.5) > 1e-9 {
+		t.Errorf("disagreement: alpha = %v", a)
+	}
+	if a := krippendorffAlpha([][]float64{{1, 1}, {1, 1}}); !math.IsNaN(a) {
+		t.Errorf("no variance: alpha = %v", a)
+	}
+}
+
+func TestCohensKappa(t *testing.T) {
+	a := []bool{true, true, true, false, false, false}
+	if k := cohensKappa(a, a); k != 1 {
+		t.Errorf("same verdicts: kappa = %v", k)
+	}
+	// 4 of 6 agree, both pass half: chance agreement 0.5
+	b := []bool{true, true, false, true, false, false}
+	if k := cohensKappa(a, b); math.Abs(k-1.0/3) > 1e-9 {
+		t.Errorf("kappa = %v", k)
+	}
+	if k := cohensKappa([]bool{true, true}, []bool{true, true}); !math.IsNaN(k) {
+		t.Errorf("constant verdicts: kappa = %v", k)
+	}
+}
+
+func TestBuildJudgeReport(t *testing.T) {
+	// a and b agree, c passes everything
+	var results []EvalResult
+	for i := range 10 {
+		score := float64(i%2) * 0.9
+		results = append(results, EvalResult{TestID: fmt.Sprint(i), JudgeScores: map[string]map[string]float64{
+			"a": {"faithfulness": score},
+			"b": {"faithfulness": score + 0.05},
+			"c": {"faithfulness": 0.8 + float64(i%3)*0.05},
+		}})
+	}
+	results = append(results,
+		EvalResult{JudgeScores: map[string]map[string]float64{"a": {"faithfulness": 1}}},                                             // Single judge
+		EvalResult{Error: "timeout", JudgeScores: map[string]map[string]float64{"a": {"faithfulness": 1}, "b": {"faithfulness": 0}}}, // Errored
+	)
+	report := BuildJudgeReport(results, judgePassThreshold)
+	if report.Results != 10 || strings.Join(report.Judges, ",") != "a,b,c" || len(report.Scores) != 1 {
+		t.Fatalf("report = %+v", report)
+	}
+	faithfulness := report.Scores[0]
+	if faithfulness.Items != 10 || faithfulness.Judges != 3 || len(faithfulness.Pairs) != 3 {
+		t.Errorf("faithfulness = %+v", faithfulness)
+	}
+	ab := faithfulness.Pairs[0]
+	if ab.A != "a" || ab.B != "b" || ab.Kappa != 1 || ab.Agreement != 1 || math.Abs(ab.MeanAbsDiff-0.05) > 1e-9 {
+		t.Errorf("a-b = %+v", ab)
+	}
+	if ac := faithfulness.Pairs[1]; ac.Kappa != 0 || ac.Agreement != 0.5 {
+		t.Errorf("a-c = %+v", ac) // c passes everything: no better than chance
+	}
+
+	// c agrees least and comes first; leaving it out raises alpha
+	if c := report.Reliability[0]; c.Judge != "c" || !c.Low() || !(c.AlphaWithout["faithfulness"] > faithfulness.Alpha) {
+		t.Errorf("c = %+v, alpha %v", c, faithfulness.Alpha)
+	}
+	if a := report.Reliability[1]; a.Judge != "a" || a.MeanKappa != 0.5 || a.Low() {
+		t.Errorf("a = %+v", a)
+	}
+
+	if data, err := json.Marshal(JudgePair{A: "a", B: "b", Kappa: math.NaN()}); err != nil || !strings.Contains(string(data), `"kappa":null`) {
+		t.Errorf("JSON = %s, %v", data, err)
+	}
+}
+
+func TestJudgesPage(t *testing.T) {
+	var lines []string
+	for i := range 6 {
+		lines = append(lines, fmt.Sprintf(`{"timestamp":"2025-01-01T10:00:0%dZ","model":"m","test_id":"t%d","scores":{"combined":0.5},"judge_scores":{"gpt-4o":{"faithfulness":%d},"claude":{"faithfulness":%d}}}`, i, i, i%2, (i/2)%2))
+	}
+	withStore(t, lines...)
+
+	rec := httptest.NewRecorder()
+	judgesHandler(rec, httptest.NewRequest(http.MethodGet, "/judges", nil))
+	if body := rec.Body.String(); !strings.Contains(body, "Judge Reliability") || !strings.Contains(body, "claude · gpt-4o") {
+		t.Error("judges page has no reliability or pairs")
+	}
+
+	rec = httptest.NewRecorder()
+	judgesAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/api/judges?threshold=0.7", nil))
+	var report struct {
+		Threshold float64 `json:"threshold"`
+		Scores    []struct {
+			Pairs []struct {
+				Items int `json:"items"`
+			} `json:"pairs"`
+		} `json:"scores"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil || report.Threshold != 0.7 || report.Scores[0].Pairs[0].Items != 6 {
+		t.Errorf("API = %+v, %v", report, err)
+	}
+
+	rec = httptest.NewRecorder()
+	judgesAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/api/judges?threshold=2", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid threshold = %d", rec.Code)
+	}
+}
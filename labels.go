@@ -0,0 +1,229 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ConfigLabel is a friendly name a user gave a config key
+type ConfigLabel struct {
+	Config  string    `json:"config"` // Full config key, see buildConfigKey
+	Label   string    `json:"label"`
+	Updated time.Time `json:"updated"`
+}
+
+// maxLabelLength keeps labels short enough for table cells
+const maxLabelLength = 60
+
+// labelStore keeps config labels in a JSON file, like viewStore
+type labelStore struct {
+	path string
+
+	mu     sync.Mutex
+	labels map[string]ConfigLabel
+}
+
+// configLabels is the label registry, see --labels-file; nil = automatic labels only
+var configLabels *labelStore
+
+// newLabelStore loads labels from path, a missing file means no labels yet
+func newLabelStore(path string) (*labelStore, error) {
+	ls := &labelStore{path: path, labels: make(map[string]ConfigLabel)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ls, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read labels: %w", err)
+	}
+
+	var list []ConfigLabel
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("invalid labels file %s: %w", path, err)
+	}
+	for _, label := range list {
+		ls.labels[label.Config] = label
+	}
+	return ls, nil
+}
+
+// List returns all labels sorted by config key
+func (ls *labelStore) List() []ConfigLabel {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	return ls.sorted()
+}
+
+// Custom returns the user's label for a config key, ok is false when it has none
+func (ls *labelStore) Custom(config string) (string, bool) {
+	if ls == nil {
+		return "", false
+	}
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	label, ok := ls.labels[config]
+	return label.Label, ok
+}
+
+// Label is the name a config key is shown by: the user's label, or an automatic short one
+func (ls *labelStore) Label(config string) string {
+	if label, ok := ls.Custom(config); ok {
+		return label
+	}
+	return autoConfigLabel(config)
+}
+
+// Set adds or replaces a label and writes the file
+func (ls *labelStore) Set(label ConfigLabel) error {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	ls.labels[label.Config] = label
+	return ls.write()
+}
+
+// Delete removes a label, reports whether it existed
+func (ls *labelStore) Delete(config string) (bool, error) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	if _, ok := ls.labels[config]; !ok {
+		return false, nil
+	}
+	delete(ls.labels, config)
+	return true, ls.write()
+}
+
+// sorted returns the labels by config key, must be called with ls.mu held
+func (ls *labelStore) sorted() []ConfigLabel {
+	list := make([]ConfigLabel, 0, len(ls.labels))
+	for _, label := range ls.labels {
+		list = append(list, label)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Config < list[j].Config })
+	return list
+}
+
+// write replaces the file atomically, must be called with ls.mu held
+func (ls *labelStore) write() error {
+	data, err := json.MarshalIndent(ls.sorted(), "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := ls.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write labels: %w", err)
+	}
+	return replaceFile(tmp, ls.path)
+}
+
+// maxLabelValue is the longest parameter value kept whole in automatic labels
+const maxLabelValue = 12
+
+// autoConfigLabel shortens a config key to the model's display name and each parameter as the
+// initials of its name followed by its value:
+// llama3|chunk_size=500|top_k=5 -> llama3 · cs500 · tk5
+func autoConfigLabel(config string) string {
+	parts := strings.Split(config, "|")
+	label := []string{modelRegistry.DisplayName(parts[0])}
+	for _, param := range parts[1:] {
+		name, value, _ := strings.Cut(param, "=")
+		var initials strings.Builder
+		for _, word := range strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == '-' || r == '.' }) {
+			first, _ := utf8.DecodeRuneInString(word)
+			initials.WriteRune(unicode.ToLower(first))
+		}
+		if runes := []rune(value); len(runes) > maxLabelValue {
+			value = string(runes[:maxLabelValue-1]) + "…"
+		}
+		label = append(label, initials.String()+value)
+	}
+	return strings.Join(label, " · ")
+}
+
+// LabelHTML renders a config key by its label, with the raw key on hover
+func (ls *labelStore) LabelHTML(config string) template.HTML {
+	label := ls.Label(config)
+	if label == config {
+		return template.HTML(template.HTMLEscapeString(config))
+	}
+	return template.HTML(fmt.Sprintf(`<span class="config-label" title="%s">%s</span>`,
+		template.HTMLEscapeString(config), template.HTMLEscapeString(label)))
+}
+
+// StatLabel renders a dashboard row: its config label when it has parameters or a user label,
+// otherwise the model as the registry names it
+func (ls *labelStore) StatLabel(stat ModelStat) template.HTML {
+	if _, ok := ls.Custom(stat.Model); ok || stat.Model != stat.ActualModelName {
+		return ls.LabelHTML(stat.Model)
+	}
+	return modelLabel(stat.ActualModelName)
+}
+
+// labelsAPIHandler lists (GET), sets (POST {"config","label"}, an empty label removes it) and
+// deletes (DELETE ?config=) config labels
+func labelsAPIHandler(w http.ResponseWriter, r *http.Request) {
+	labels := labelsFor(r.Context())
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(labels.List())
+
+	case http.MethodPost:
+		var label ConfigLabel
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&label); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+			return
+		}
+		label.Label = strings.TrimSpace(label.Label)
+		if label.Config == "" || len([]rune(label.Label)) > maxLabelLength {
+			http.Error(w, fmt.Sprintf("config is required and label can have up to %d characters", maxLabelLength), http.StatusBadRequest)
+			return
+		}
+		if label.Label == "" {
+			if _, err := labels.Delete(label.Config); err != nil {
+				log.Printf("Error deleting label: %v", err)
+				http.Error(w, "Failed to delete label", http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		label.Updated = time.Now().UTC()
+		if err := labels.Set(label); err != nil {
+			log.Printf("Error saving label: %v", err)
+			http.Error(w, "Failed to save label", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(label)
+
+	case http.MethodDelete:
+		found, err := labels.Delete(r.URL.Query().Get("config"))
+		if err != nil {
+			log.Printf("Error deleting label: %v", err)
+			http.Error(w, "Failed to delete label", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAutoConfigLabel(t *testing.T) {
+	tests := []struct {
+		config, want string
+	}{
+		{"llama3", "llama3"},
+		{"llama3|chunk_size=500|top_k=5", "llama3 · cs500 · tk5"},
+		{"gpt-4o|retriever=hybrid-bm25-dense-v2", "gpt-4o · rhybrid-bm25…"},
+		{"qwen:4b|Überblick=ja", "qwen:4b · üja"},
+	}
+	for _, tt := range tests {
+		if got := autoConfigLabel(tt.config); got != tt.want {
+			t.Errorf("autoConfigLabel(%q) = %q, want %q", tt.config, got, tt.want)
+		}
+	}
+
+	modelRegistry = &ModelRegistry{exact: map[string]ModelInfo{"llama3": {DisplayName: "Llama 3"}}}
+	defer func() { modelRegistry = nil }()
+	if got := autoConfigLabel("llama3|top_k=5"); got != "Llama 3 · tk5" {
+		t.Errorf("with registry = %q", got)
+	}
+}
+
+func TestConfigLabels(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "labels.json")
+	ls, err := newLabelStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configLabels = ls
+	defer func() { configLabels = nil }()
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		labelsAPIHandler(rec, httptest.NewRequest(http.MethodPost, "/api/labels", strings.NewReader(body)))
+		return rec
+	}
+	if rec := post(`{"config":"llama3|top_k=5","label":" baseline "}`); rec.Code != http.StatusOK {
+		t.Fatalf("save: %d %s", rec.Code, rec.Body)
+	}
+	if rec := post(`{"config":"","label":"x"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("empty config: %d", rec.Code)
+	}
+	if rec := post(`{"config":"a","label":"` + strings.Repeat("x", maxLabelLength+1) + `"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("long label: %d", rec.Code)
+	}
+
+	// Labels survive a restart
+	reloaded, err := newLabelStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configLabels = reloaded
+
+	rec := httptest.NewRecorder()
+	labelsAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/api/labels", nil))
+	var list []ConfigLabel
+	json.Unmarshal(rec.Body.Bytes(), &list)
+	if len(list) != 1 || list[0].Config != "llama3|top_k=5" || list[0].Label != "baseline" {
+		t.Fatalf("list = %+v", list)
+	}
+
+	if got := configLabels.LabelHTML("llama3|top_k=5"); got != `<span class="config-label" title="llama3|top_k=5">baseline</span>` {
+		t.Errorf("custom label = %s", got)
+	}
+	if got := configLabels.LabelHTML("llama3|top_k=3"); got != `<span class="config-label" title="llama3|top_k=3">llama3 · tk3</span>` {
+		t.Errorf("automatic label = %s", got)
+	}
+	if got := configLabels.LabelHTML("<b>"); got != "&lt;b&gt;" {
+		t.Errorf("plain model = %s", got)
+	}
+	if got := configLabels.StatLabel(ModelStat{Model: "llama3", ActualModelName: "llama3"}); got != "llama3" {
+		t.Errorf("statLabel = %s", got)
+	}
+
+	// An empty label goes back to the automatic one
+	if rec := post(`{"config":"llama3|top_k=5","label":""}`); rec.Code != http.StatusNoContent {
+		t.Errorf("clear: %d", rec.Code)
+	}
+	if got := configLabels.Label("llama3|top_k=5"); got != "llama3 · tk5" {
+		t.Errorf("after clear = %q", got)
+	}
+
+	rec = httptest.NewRecorder()
+	labelsAPIHandler(rec, httptest.NewRequest(http.MethodDelete, "/api/labels?config=missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("delete missing = %d", rec.Code)
+	}
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+)
+
+// LatencyStat summarizes response times of one config (model + parameters)
+type LatencyStat struct {
+	Config    string  `json:"config"`
+	Model     string  `json:"model"`
+	Count     int     `json:"count"`
+	MeanMS    float64 `json:"mean_ms"`
+	P50MS     float64 `json:"p50_ms"`
+	P90MS     float64 `json:"p90_ms"`
+	P95MS     float64 `json:"p95_ms"`
+	P99MS     float64 `json:"p99_ms"`
+	MaxMS     float64 `json:"max_ms"`
+	AvgScore  float64 `json:"avg_score"`
+	Frontier  bool    `json:"frontier"`  // No other config is both faster (p50) and better
+	Histogram []int   `json:"histogram"` // Counts per LatencyReport.BinEdges bin
+
+	Color string         `json:"-"` // Series class, see seriesColor
+	X, Y  float64        `json:"-"` // Position of the config's p50/avg point in the scatter
+	Chart BarChart       `json:"-"` // Histogram drawn in the row
+	Dots  []LatencyPoint `json:"-"`
+}
+
+// LatencyPoint is one result in the scatter plot
+type LatencyPoint struct {
+	X, Y   float64
+	TestID string
+	MS     int64
+	Score  float64
+}
+
+// LatencyReport is the latency page: per-config stats, shared histogram bins and the scatter plot
+type LatencyReport struct {
+	Stats    []LatencyStat `json:"configs"` // Sorted by p50
+	BinEdges []float64     `json:"bin_edges_ms"`
+	Skipped  int           `json:"skipped"` // Results without response_time_ms
+	LogX     bool          `json:"log_x"`
+
+	Frame    *ChartFrame `json:"-"`
+	Frontier string      `json:"-"` // SVG polyline through the frontier configs
+}
+
+// Scatter and histogram geometry in SVG user units
+const (
+	latencyWidth     = 760
+	latencyHeight    = 380
+	latencyBins      = 12
+	latencyBarWidth  = 120
+	latencyBarHeight = 28
+	latencyMaxDots   = 2000 // Per config, more are thinned out evenly
+)
+
+// percentile returns the p-th percentile (0-100) of sorted values, interpolating between ranks
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(rank-float64(lo))
+}
+
+// BuildLatencyReport groups results by config and lays out the histogram and scatter plot
+// Results without a positive response_time_ms are counted in Skipped
+func BuildLatencyReport(results []EvalResult) LatencyReport {
+	var report LatencyReport
+	byConfig := make(map[string][]EvalResult)
+	var all []float64
+	for _, result := range results {
+		if result.ResponseTimeMS <= 0 {
+			report.Skipped++
+			continue
+		}
+		key := buildConfigKey(result)
+		byConfig[key] = append(byConfig[key], result)
+		all = append(all, float64(result.ResponseTimeMS))
+	}
+	if len(all) == 0 {
+		return report
+	}
+	sort.Float64s(all)
+
+	for key, group := range byConfig {
+		times := make([]float64, len(group))
+		scores := make([]float64, len(group))
+		for i, result := range group {
+			times[i] = float64(result.ResponseTimeMS)
+			scores[i] = result.Scores.Combined
+		}
+		sort.Float64s(times)
+		report.Stats = append(report.Stats, LatencyStat{
+			Config:   key,
+			Model:    group[0].Model,
+			Count:    len(group),
+			MeanMS:   mean(times),
+			P50MS:    percentile(times, 50),
+			P90MS:    percentile(times, 90),
+			P95MS:    percentile(times, 95),
+			P99MS:    percentile(times, 99),
+			MaxMS:    times[len(times)-1],
+			AvgScore: mean(scores),
+		})
+	}
+	sort.Slice(report.Stats, func(i, j int) bool {
+		if report.Stats[i].P50MS != report.Stats[j].P50MS {
+			return report.Stats[i].P50MS < report.Stats[j].P50MS
+		}
+		return report.Stats[i].Config < report.Stats[j].Config
+	})
+
+	// Frontier: walking from fastest to slowest, a config is on it if it beats every faster one
+	best := math.Inf(-1)
+	for i := range report.Stats {
+		if report.Stats[i].AvgScore > best {
+			report.Stats[i].Frontier = true
+			best = report.Stats[i].AvgScore
+		}
+	}
+
+	// Shared bins up to the overall p99 so one timeout doesn't squash every histogram
+	top := percentile(all, 99)
+	if top <= 0 {
+		top = all[len(all)-1]
+	}
+	for i := 0; i <= latencyBins; i++ {
+		report.BinEdges = append(report.BinEdges, math.Round(top*float64(i)/latencyBins))
+	}
+	for i := range report.Stats {
+		stat := &report.Stats[i]
+		stat.Histogram = make([]int, latencyBins)
+		for _, result := range byConfig[stat.Config] {
+			bin := int(float64(result.ResponseTimeMS) / top * latencyBins)
+			stat.Histogram[min(bin, latencyBins-1)]++ // The last bin also holds the slow tail
+		}
+	}
+
+	layoutLatency(&report, byConfig, all[0], all[len(all)-1])
+	return report
+}
+
+// layoutLatency positions histogram bars, scatter points, the frontier line and axis ticks
+func layoutLatency(report *LatencyReport, byConfig map[string][]EvalResult, minX, maxX float64) {
+	maxY := 1.0
+	for _, stat := range report.Stats {
+		for _, result := range byConfig[stat.Config] {
+			maxY = math.Max(maxY, result.Scores.Combined)
+		}
+	}
+	frame := newChartFrame(latencyWidth, latencyHeight, minX, maxX, maxY)
+
+	var frontierX, frontierY []float64
+	for i := range report.Stats {
+		stat := &report.Stats[i]
+		stat.Color = seriesColor(i)
+		stat.X, stat.Y = frame.X(stat.P50MS), frame.Y(stat.AvgScore)
+		if stat.Frontier {
+			frontierX, frontierY = append(frontierX, stat.X), append(frontierY, stat.Y)
+		}
+
+		group := byConfig[stat.Config]
+		step := max(1, (len(group)+latencyMaxDots-1)/latencyMaxDots)
+		for j := 0; j < len(group); j += step {
+			result := group[j]
+			stat.Dots = append(stat.Dots, LatencyPoint{
+				X:      frame.X(float64(result.ResponseTimeMS)),
+				Y:      frame.Y(result.Scores.Combined),
+				TestID: result.TestID,
+				MS:     result.ResponseTimeMS,
+				Score:  result.Scores.Combined,
+			})
+		}
+
+		labels := make([]string, latencyBins)
+		for bin, n := range stat.Histogram {
+			labels[bin] = fmt.Sprintf("%s-%s ms: %d", formatSweepValue(report.BinEdges[bin]), formatSweepValue(report.BinEdges[bin+1]), n)
+			if bin == latencyBins-1 {
+				labels[bin] = fmt.Sprintf("≥%s ms: %d", formatSweepValue(report.BinEdges[bin]), n)
+			}
+		}
+		stat.Chart = newBarChart(latencyBarWidth, latencyBarHeight, stat.Histogram, labels)
+	}
+	report.Frontier = polyline(frontierX, frontierY)
+
+	frame.tickX(nil, func(v float64) string { return formatSweepValue(math.Round(v)) + "ms" })
+	report.LogX = frame.LogX
+	report.Frame = frame
+}
+
+// latencyHandler renders latency percentiles, histograms and the latency vs score scatter
+func latencyHandler(w http.ResponseWriter, r *http.Request) {
+	results, err := store.Query(r.Context(), Query{})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	results, ok := reweightRequest(w, r, results)
+	if !ok {
+		return
+	}
+
+	data := struct {
+		Title    string
+		Subtitle string
+		LatencyReport
+	}{
+		Title:         "Latency",
+		Subtitle:      "Response time percentiles per config and the quality/latency frontier",
+		LatencyReport: BuildLatencyReport(results),
+	}
+
+	renderPage(w, r, "latency", latencyTemplate, data)
+}
+
+// latencyAPIHandler returns the latency report as JSON
+func latencyAPIHandler(w http.ResponseWriter, r *http.Request) {
+	results, err := store.Query(r.Context(), Query{})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	results, ok := reweightRequest(w, r, results)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(BuildLatencyReport(results)); err != nil {
+		log.Printf("Error encoding JSON: %v", err)
+	}
+}
+
+const latencyTemplate = `
+{{ define "style" }}
+        .frontier-line {
+            fill: none;
+            stroke: var(--text-secondary);
+            stroke-width: 1.5;
+            stroke-dasharray: 6,4;
+        }
+        .config-point {
+            stroke: var(--bg-primary);
+            stroke-width: 2;
+        }
+        .frontier-badge {
+            color: var(--success);
+            font-size: 0.75rem;
+            font-weight: 600;
+        }
+        td.num {
+            font-family: monospace;
+            text-align: right;
+            white-space: nowrap;
+        }
+        .legend-swatch {
+            border-radius: 50%;
+            margin-right: 0.35rem;
+        }
+{{ end }}
+
+{{ define "content" }}
+        {{ if .Stats }}
+        <div class="panel chart">
+            <h2>Latency vs Score{{ if .LogX }} <span class="muted" style="font-size: 0.75rem;">(log scale)</span>{{ end }}</h2>
+            <p class="muted" style="margin-bottom: 1rem; font-size: 0.875rem;">Each dot is one result; large dots are each config's median latency and average score. The dashed line connects the frontier: configs no faster config beats on quality.</p>
+            <svg viewBox="0 0 {{ .Frame.Width }} {{ .Frame.Height }}" role="img" aria-label="response time vs combined score">
+                {{ template "chart-axes" .Frame }}
+                {{ range .Stats }}
+                <g class="{{ .Color }}">
+                    {{ range .Dots }}
+                    <circle cx="{{ .X }}" cy="{{ .Y }}" r="2.5" fill="currentColor" opacity="0.45"><title>{{ .TestID }} · {{ .MS }}ms · {{ printf "%.3f" .Score }}</title></circle>
+                    {{ end }}
+                </g>
+                {{ end }}
+                {{ if .Frontier }}<polyline class="frontier-line" points="{{ .Frontier }}"/>{{ end }}
+                {{ range .Stats }}
+                <circle class="config-point {{ .Color }}" cx="{{ .X }}" cy="{{ .Y }}" r="7" fill="currentColor"><title>{{ configName .Config }} · p50 {{ printf "%.0f" .P50MS }}ms · avg {{ printf "%.3f" .AvgScore }}</title></circle>
+                {{ end }}
+            </svg>
+        </div>
+
+        <div class="panel">
+            <h2>Percentiles</h2>
+            <div style="overflow-x: auto;">
+            <table>
+                <thead>
+                    <tr>
+                        <th>Config</th>
+                        <th>Tests</th>
+                        <th>p50</th>
+                        <th>p90</th>
+                        <th>p95</th>
+                        <th>p99</th>
+                        <th>Max</th>
+                        <th>Mean</th>
+                        <th>Avg Score</th>
+                        <th>Distribution</th>
+                    </tr>
+                </thead>
+                <tbody>
+                    {{ range .Stats }}
+                    <tr>
+                        <td class="mono"><span class="legend-swatch {{ .Color }}"></span>{{ configLabel .Config }}{{ if .Frontier }} <span class="frontier-badge" title="No faster config scores higher">frontier</span>{{ end }}</td>
+                        <td class="num">{{ human .Count }}</td>
+                        <td class="num">{{ printf "%.0f" .P50MS }}ms</td>
+                        <td class="num">{{ printf "%.0f" .P90MS }}ms</td>
+                        <td class="num">{{ printf "%.0f" .P95MS }}ms</td>
+                        <td class="num">{{ printf "%.0f" .P99MS }}ms</td>
+                        <td class="num">{{ printf "%.0f" .MaxMS }}ms</td>
+                        <td class="num">{{ printf "%.0f" .MeanMS }}ms</td>
+                        <td><span class="score-badge {{ scoreClass .AvgScore }}">{{ printf "%.3f" .AvgScore }}</span></td>
+                        <td>{{ template "bar-chart" .Chart }}</td>
+                    </tr>
+                    {{ end }}
+                </tbody>
+            </table>
+            </div>
+            <p class="muted" style="margin-top: 1rem; font-size: 0.8125rem;">Histograms share bins from 0 to the overall p99; the last bin holds everything slower.{{ if .Skipped }} {{ .Skipped }} results without response_time_ms are not included.{{ end }}</p>
+        </div>
+        {{ else }}
+        <div class="panel">
+            <p class="muted">No response times recorded. Log <span class="mono">response_time_ms</span> with each result to see latency percentiles.</p>
+        </div>
+        {{ end }}
+{{ end }}`
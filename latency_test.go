@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPercentile(t *testing.T) {
+	values := []float64{100, 200, 300, 400, 500}
+	for p, want := range map[float64]float64{0: 100, 50: 300, 90: 460, 100: 500} {
+		if got := percentile(values, p); got != want {
+			t.Errorf("p%v = %v, want %v", p, got, want)
+		}
+	}
+	if percentile(nil, 50) != 0 {
+		t.Error("empty input should give 0")
+	}
+}
+
+func TestBuildLatencyReport(t *testing.T) {
+	results := []EvalResult{
+		{Model: "fast", TestID: "t1", ResponseTimeMS: 100, Scores: ScoreBreakdown{Combined: 0.6}},
+		{Model: "fast", TestID: "t2", ResponseTimeMS: 300, Scores: ScoreBreakdown{Combined: 0.6}},
+		{Model: "slow-good", TestID: "t1", ResponseTimeMS: 900, Scores: ScoreBreakdown{Combined: 0.9}},
+		{Model: "slow-bad", TestID: "t1", ResponseTimeMS: 800, Scores: ScoreBreakdown{Combined: 0.5}},
+		{Model: "fast", TestID: "t3", Scores: ScoreBreakdown{Combined: 1}}, // No latency recorded
+	}
+
+	report := BuildLatencyReport(results)
+	if report.Skipped != 1 || len(report.Stats) != 3 {
+		t.Fatalf("report = %+v", report)
+	}
+	fast := report.Stats[0]
+	if fast.Model != "fast" || fast.Count != 2 || fast.P50MS != 200 || fast.MaxMS != 300 || fast.AvgScore != 0.6 {
+		t.Errorf("fast = %+v", fast)
+	}
+
+	frontier := make(map[string]bool)
+	for _, stat := range report.Stats {
+		frontier[stat.Model] = stat.Frontier
+		total := 0
+		for _, n := range stat.Histogram {
+			total += n
+		}
+		if total != stat.Count || len(stat.Chart.Bars) != latencyBins {
+			t.Errorf("%s histogram %v doesn't cover %d results", stat.Model, stat.Histogram, stat.Count)
+		}
+	}
+	if !frontier["fast"] || !frontier["slow-good"] || frontier["slow-bad"] {
+		t.Errorf("frontier = %v, slow-bad is beaten by the faster fast config", frontier)
+	}
+	if report.Frontier == "" || len(report.Frame.XTicks) != 5 {
+		t.Error("scatter not laid out")
+	}
+
+	if empty := BuildLatencyReport([]EvalResult{{Model: "a"}}); len(empty.Stats) != 0 || empty.Skipped != 1 {
+		t.Errorf("no latencies = %+v", empty)
+	}
+}
+
+func TestLatencyPage(t *testing.T) {
+	useStore(t, NewMemoryStore([]Source{&fileSource{path: "evals.jsonl"}}))
+
+	rec := httptest.NewRecorder()
+	latencyHandler(rec, httptest.NewRequest(http.MethodGet, "/latency", nil))
+	if body := rec.Body.String(); !strings.Contains(body, "Latency vs Score") || !strings.Contains(body, "frontier-line") {
+		t.Error("latency page missing the scatter plot")
+	}
+}
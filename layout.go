@@ -0,0 +1,348 @@
+package main
+
+import (
+	"html/template"
+	"log"
+	"math"
+	"net/http"
+)
+
+// pageLayout holds the building blocks shared by the secondary pages
+// (theme variables, header, tables, score colors and the dark mode toggle)
+// Pages define a "content" template and call {{ template "page" . }}
+const pageLayout = `
+{{ define "page" }}<!DOCTYPE html>
+<html lang="en" data-theme="light">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>{{ .Title }} - {{ brand.Name }}</title>
+    <style>
+        :root {
+            --bg-primary: #ffffff;
+            --bg-secondary: #f8fafc;
+            --bg-tertiary: #f1f5f9;
+            --text-primary: #0f172a;
+            --text-secondary: #475569;
+            --text-tertiary: #94a3b8;
+            --border-color: #e2e8f0;
+            --accent: #3b82f6;
+            --accent-hover: #2563eb;
+            --success: #10b981;
+            --warning: #f59e0b;
+            --error: #ef4444;
+            --shadow-sm: 0 1px 2px 0 rgba(0, 0, 0, 0.05);
+            --shadow-md: 0 4px 6px -1px rgba(0, 0, 0, 0.1), 0 2px 4px -1px rgba(0, 0, 0, 0.06);
+            --series-1: #3b82f6;
+            --series-2: #10b981;
+            --series-3: #f59e0b;
+            --series-4: #ef4444;
+            --series-5: #8b5cf6;
+            --series-6: #ec4899;
+            --series-7: #14b8a6;
+            --series-8: #64748b;
+        }
+        [data-theme="dark"] {
+            --bg-primary: #1e293b;
+            --bg-secondary: #0f172a;
+            --bg-tertiary: #334155;
+            --text-primary: #f1f5f9;
+            --text-secondary: #cbd5e1;
+            --text-tertiary: #64748b;
+            --border-color: #334155;
+            --shadow-sm: 0 1px 2px 0 rgba(0, 0, 0, 0.3);
+            --shadow-md: 0 4px 6px -1px rgba(0, 0, 0, 0.3), 0 2px 4px -1px rgba(0, 0, 0, 0.2);
+            --series-1: #60a5fa;
+            --series-2: #34d399;
+            --series-3: #fbbf24;
+            --series-4: #f87171;
+            --series-5: #a78bfa;
+            --series-6: #f472b6;
+            --series-7: #2dd4bf;
+            --series-8: #94a3b8;
+        }
+        * { margin: 0; padding: 0; box-sizing: border-box; }
+        body {
+            font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, "Helvetica Neue", Arial, sans-serif;
+            background: var(--bg-secondary);
+            color: var(--text-primary);
+            padding: 2rem;
+            transition: background-color 0.3s ease, color 0.3s ease;
+        }
+        .container {
+            max-width: 95%;
+            margin: 0 auto;
+        }
+        header {
+            background: var(--bg-primary);
+            padding: 2rem;
+            border-radius: 12px;
+            box-shadow: var(--shadow-md);
+            margin-bottom: 2rem;
+            display: flex;
+            justify-content: space-between;
+            align-items: center;
+        }
+        .header-left h1 {
+            color: var(--text-primary);
+            margin-bottom: 0.5rem;
+            font-size: 1.875rem;
+            font-weight: 700;
+            letter-spacing: -0.025em;
+        }
+        .subtitle {
+            color: var(--text-secondary);
+            font-size: 0.875rem;
+        }
+        .back-link {
+            display: inline-block;
+            margin-bottom: 1rem;
+            color: var(--accent);
+            text-decoration: none;
+        }
+        .back-link:hover {
+            text-decoration: underline;
+        }
+        .theme-toggle {
+            background: var(--bg-tertiary);
+            border: 1px solid var(--border-color);
+            color: var(--text-secondary);
+            padding: 0.5rem 0.75rem;
+            border-radius: 6px;
+            cursor: pointer;
+            font-size: 0.875rem;
+            font-weight: 500;
+        }
+        .theme-toggle:hover {
+            background: var(--bg-primary);
+            border-color: var(--accent);
+            color: var(--accent);
+        }
+        .panel {
+            background: var(--bg-primary);
+            padding: 2rem;
+            border-radius: 12px;
+            box-shadow: var(--shadow-md);
+            border: 1px solid var(--border-color);
+            margin-bottom: 2rem;
+        }
+        h2 {
+            color: var(--text-primary);
+            font-size: 1.5rem;
+            font-weight: 700;
+            letter-spacing: -0.025em;
+            margin-bottom: 1rem;
+        }
+        table {
+            width: 100%;
+            border-collapse: collapse;
+        }
+        th {
+            background: var(--bg-tertiary);
+            padding: 0.75rem 1rem;
+            text-align: left;
+            font-weight: 600;
+            font-size: 0.75rem;
+            text-transform: uppercase;
+            letter-spacing: 0.05em;
+            color: var(--text-tertiary);
+            border-bottom: 1px solid var(--border-color);
+        }
+        td {
+            padding: 0.75rem 1rem;
+            color: var(--text-primary);
+            font-size: 0.875rem;
+            border-bottom: 1px solid var(--border-color);
+            vertical-align: top;
+        }
+        .mono {
+            font-family: monospace;
+            font-size: 0.8125rem;
+        }
+        .muted {
+            color: var(--text-tertiary);
+        }
+        .num-compact {
+            cursor: help;
+        }
+        .score-badge {
+            display: inline-flex;
+            align-items: center;
+            padding: 0.25rem 0.5rem;
+            border-radius: 4px;
+            font-size: 0.8125rem;
+            font-weight: 500;
+            font-family: monospace;
+        }
+        .score-good {
+            background: rgba(16, 185, 129, 0.1);
+            color: var(--success);
+        }
+        .score-fair {
+            background: rgba(245, 158, 11, 0.1);
+            color: var(--warning);
+        }
+        .score-poor {
+            background: rgba(239, 68, 68, 0.1);
+            color: var(--error);
+        }
+        select, input[type="text"], input[type="number"] {
+            background: var(--bg-primary);
+            color: var(--text-primary);
+            border: 1px solid var(--border-color);
+            border-radius: 6px;
+            padding: 0.5rem 0.75rem;
+            font-size: 0.875rem;
+        }
+        .chart svg {
+            width: 100%;
+            height: auto;
+        }
+        .chart .axis {
+            stroke: var(--border-color);
+        }
+        .chart text {
+            fill: var(--text-tertiary);
+            font-size: 11px;
+        }
+        .bar-chart rect {
+            fill: var(--accent);
+            opacity: 0.7;
+        }
+        .legend {
+            display: flex;
+            flex-wrap: wrap;
+            gap: 0.75rem;
+            font-size: 0.8125rem;
+            color: var(--text-secondary);
+        }
+        .legend-swatch {
+            display: inline-block;
+            width: 0.75rem;
+            height: 0.75rem;
+            border-radius: 2px;
+            margin-right: 0.25rem;
+            vertical-align: middle;
+            background: currentColor;
+        }
+        .series-1 { color: var(--series-1); }
+        .series-2 { color: var(--series-2); }
+        .series-3 { color: var(--series-3); }
+        .series-4 { color: var(--series-4); }
+        .series-5 { color: var(--series-5); }
+        .series-6 { color: var(--series-6); }
+        .series-7 { color: var(--series-7); }
+        .series-8 { color: var(--series-8); }
+        {{ template "brand-style" }}
+        {{ block "style" . }}{{ end }}
+    </style>
+</head>
+<body>
+    <div class="container">
+        <a href="/" class="back-link">← Back to Dashboard</a>
+
+        <header>
+            <div class="header-left">
+                <h1>{{ template "brand-logo" }}{{ .Title }}</h1>
+                <p class="subtitle">{{ .Subtitle }}</p>
+            </div>
+            <div class="header-right">
+                {{- template "project-switcher" }}
+                <button id="theme-toggle" class="theme-toggle">
+                    <span id="theme-icon">Dark</span>
+                </button>
+            </div>
+        </header>
+
+        {{ template "content" . }}
+        {{ template "brand-footer" }}
+    </div>
+    <script>
+        // Dark mode toggle (shares the localStorage key with the dashboard)
+        const themeToggle = document.getElementById('theme-toggle');
+        const themeIcon = document.getElementById('theme-icon');
+        const html = document.documentElement;
+        const savedTheme = localStorage.getItem('theme') || 'light';
+        html.setAttribute('data-theme', savedTheme);
+        themeIcon.textContent = savedTheme === 'light' ? 'Dark' : 'Light';
+
+        themeToggle.addEventListener('click', () => {
+            const newTheme = html.getAttribute('data-theme') === 'light' ? 'dark' : 'light';
+            html.setAttribute('data-theme', newTheme);
+            localStorage.setItem('theme', newTheme);
+            themeIcon.textContent = newTheme === 'light' ? 'Dark' : 'Light';
+        });
+
+        document.addEventListener('keydown', (e) => {
+            if (e.target.tagName === 'INPUT' || e.target.tagName === 'SELECT') {
+                return;
+            }
+            if (e.key === 'd' || e.key === 'D') {
+                e.preventDefault();
+                themeToggle.click();
+            }
+        });
+    </script>
+    {{ block "script" . }}{{ end }}
+</body>
+</html>{{ end }}`
+
+// pageFuncs are template helpers available to every page built on pageLayout
+var pageFuncs = template.FuncMap{
+	"scoreClass": func(score float64) string {
+		switch {
+		case score >= 0.7:
+			return "score-good"
+		case score >= 0.4:
+			return "score-fair"
+		default:
+			return "score-poor"
+		}
+	},
+	"hasScore": func(scores map[string]float64, name string) bool {
+		_, ok := scores[name]
+		return ok
+	},
+	"minTestsForCI": func() int { return minTestsForCI },
+	"configLabel":   func(config string) template.HTML { return "" }, // Replaced per request by renderPage
+	"configName":    func(config string) string { return "" },        // Plain text, for SVG titles, replaced per request
+	"percent":       func(v float64) float64 { return v * 100 },
+	"sub":           func(a, b float64) float64 { return a - b },
+	"clamp01":       func(v float64) float64 { return math.Max(0, math.Min(1, v)) },
+	"isNaN":         math.IsNaN,
+	"heatColor":     heatColor,
+	"scoreColor":    scoreColor,
+	"duration":      formatDuration,
+	"durationMS":    func(ms int64) string { return formatDuration(float64(ms) / 1000) },
+	"human":         humanNumber,
+	"display":       func(s string) string { return displayText(s, pageTextLimit) }, // Recorded text, sanitized and cut
+	"brand":         func() Branding { return branding },                            // Replaced per request by renderPage
+	"projects":      func() []ProjectLink { return nil },                            // Replaced per request by renderPage
+}
+
+// renderPage executes a page template on top of the shared layout
+// The page template must define "content" and may define "style" and "script"
+func renderPage(w http.ResponseWriter, r *http.Request, name, tmpl string, data any) {
+	_, renderSpan := startSpan(r.Context(), "template.render")
+	defer renderSpan.End()
+	renderSpan.SetAttr("goevals.page", name)
+
+	theme := brandingFor(r.Context())
+	projects := projectsFor(r.Context())
+	labels := labelsFor(r.Context())
+	t := template.New(name).Funcs(pageFuncs).Funcs(template.FuncMap{
+		"brand":       func() Branding { return theme },
+		"projects":    func() []ProjectLink { return projects },
+		"configLabel": labels.LabelHTML,
+		"configName":  labels.Label,
+	})
+	t = template.Must(t.Parse(pageLayout))
+	t = template.Must(t.Parse(chartTemplates))
+	t = template.Must(t.Parse(brandingTemplates))
+	t = template.Must(t.Parse(projectTemplates))
+	t = template.Must(t.Parse(tmpl))
+	if err := t.ExecuteTemplate(w, "page", data); err != nil {
+		// Don't call http.Error here - headers already sent by Execute
+		log.Printf("Template error: %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestPagesRender executes every page template against the sample data
+// Template errors only surface at execution time, so this catches typos in funcs and fields
+func TestPagesRender(t *testing.T) {
+	useStore(t, NewMemoryStore([]Source{&fileSource{path: "evals.jsonl"}}))
+
+	pages := []struct {
+		path    string
+		handler http.HandlerFunc
+		want    string
+	}{
+		{"/", dashboardHandler, `data-col="score:accuracy"`},
+		{"/compare?test_id=eval_001", compareHandler, "compare-col"},
+		{"/leaderboard", leaderboardHandler, `class="rank"`},
+		{"/correlations?method=spearman", correlationsHandler, "heatmap"},
+		{"/sweeps", sweepsHandler, "<polyline"},
+		{"/tests?q=shakespeare", testsHandler, "<mark>Shakespeare</mark>"},
+		{"/sources", sourcesHandler, "status-ok"},
+	}
+
+	for _, page := range pages {
+		rec := httptest.NewRecorder()
+		page.handler(rec, httptest.NewRequest(http.MethodGet, page.path, nil))
+		body := rec.Body.String()
+		if rec.Code != http.StatusOK || !strings.Contains(body, page.want) || !strings.HasSuffix(strings.TrimSpace(body), "</html>") {
+			t.Errorf("%s: status %d, incomplete page or missing %q", page.path, rec.Code, page.want)
+		}
+	}
+}
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// lazyMaxResults caps how many results one query reads into memory with --lazy
+// Broader queries fail with a hint to narrow them instead of growing the process
+const lazyMaxResults = 10000
+
+// errTooManyResults is returned by lazyStore.Query when a query matches more than lazyMaxResults
+var errTooManyResults = fmt.Errorf("query matches more than %d results - narrow it by model, run or source (--lazy keeps only aggregates in memory)", lazyMaxResults)
+
+// lazyStore is the memory-bounded Store behind --lazy: it keeps aggregate statistics and a read
+// offset per file, and reads results back from the JSONL files when a request needs them
+// Memory grows with the number of configs and the largest query, not with the size of the logs
+//
+// Only local JSONL files (and directories or wildcards of them) are supported. The embedded
+// memoryStore provides source status and the append target; every other Store method is overridden
+type lazyStore struct {
+	*memoryStore
+
+	scanMu sync.Mutex
+	stats  *StatsAggregator    // Aggregates of everything read so far, nil until the first Stats
+	marks  map[string]lazyMark // By file path: how far stats has read it
+}
+
+// lazyMark is how far a file has been read; a file that is still the same file (not rotated
+// away and recreated) with its last counted line in place is assumed unchanged up to there and
+// only its tail is read
+type lazyMark struct {
+	offset  int64
+	count   int         // Results counted
+	last    string      // resultKey of the last counted result
+	lastAt  int64       // Offset of its line
+	file    os.FileInfo // The file read, nil until it existed
+	invalid int         // Lines skipped as invalid
+}
+
+// statFile returns the file info of path for a lazyMark
+// Windows reads the file ID behind os.SameFile lazily by path, so it is loaded right away,
+// before logrotate can put another file at that path
+func statFile(path string) os.FileInfo {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	os.SameFile(info, info)
+	return info
+}
+
+// track records a counted result whose line starts at offset at
+func (m *lazyMark) track(result EvalResult, at int64) {
+	m.count++
+	m.last, m.lastAt = resultKey(result), at
+}
+
+// NewLazyStore creates the memory-bounded store over file sources
+func NewLazyStore(sources []Source) (Store, error) {
+	for _, source := range sources {
+		switch source.(type) {
+		case *fileSource, *globSource:
+		default:
+			return nil, fmt.Errorf("--lazy only works with JSONL files and directories, not %s", source.Name())
+		}
+	}
+	return &lazyStore{memoryStore: NewMemoryStore(sources).(*memoryStore)}, nil
+}
+
+// files lists the JSONL files of each source, in source order
+// Wildcards are expanded again on every call so new run files show up
+func (s *lazyStore) files() [][]string {
+	files := make([][]string, len(s.sources))
+	for i, source := range s.sources {
+		switch source := source.(type) {
+		case *fileSource:
+			files[i] = []string{source.path}
+		case *globSource:
+			matches, _ := filepath.Glob(source.pattern) // Validated by NewSource
+			for _, path := range matches {
+				if info, err := os.Stat(path); err == nil && !info.IsDir() {
+					files[i] = append(files[i], path)
+				}
+			}
+		}
+	}
+	return files
+}
+
+// scanFile decodes the results of a JSONL file from offset on, calling fn with each result and
+// the offset of its line, and returns the offset after the last line consumed
+// A final line without a newline is only consumed once it parses, it may still be being written.
+// An error from fn stops the scan and is returned
+func scanFile(path string, offset int64, fn func(result EvalResult, at int64) error) (end int64, invalid int, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return offset, 0, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return offset, 0, fmt.Errorf("error reading file: %w", err)
+	}
+
+	reader := bufio.NewReader(f)
+	end = offset
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return end, invalid, fmt.Errorf("error reading file: %w", readErr)
+		}
+		if len(line) == 0 {
+			return end, invalid, nil
+		}
+		text := line
+		if end == 0 {
+			text = bytes.TrimPrefix(text, utf8BOM) // Written by Notepad and PowerShell on Windows
+		}
+		result, decodeErr := decodeResult(text)
+		switch {
+		case decodeErr == nil:
+			result.Origin = path
+			if err := fn(result, end); err != nil {
+				return end, invalid, err
+			}
+		case readErr == io.EOF:
+			return end, invalid, nil // Partial line, read it again next time
+		case len(bytes.TrimSpace(text)) > 0:
+			invalid++
+		}
+		end += int64(len(line))
+		if readErr == io.EOF {
+			return end, invalid, nil
+		}
+	}
+}
+
+// unchanged reports whether path is still the file m read and holds the line m last counted at
+// the same place. A rotated file (moved away, new one created), a truncated one and one
+// overwritten by a new run all fail one of these checks
+func (m lazyMark) unchanged(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.Size() < m.offset || (m.file != nil && !os.SameFile(m.file, info)) {
+		return false
+	}
+	if m.count == 0 {
+		return true
+	}
+	line := make([]byte, m.offset-m.lastAt)
+	if _, err := f.ReadAt(line, m.lastAt); err != nil {
+		return false
+	}
+	if m.lastAt == 0 {
+		line = bytes.TrimPrefix(line, utf8BOM)
+	}
+	result, err := decodeResult(line)
+	return err == nil && resultKey(result) == m.last
+}
+
+func (s *lazyStore) Stats(ctx context.Context) (DashboardData, error) {
+	_, span := startSpan(ctx, "store.stats")
+	defer span.End()
+
+	files := s.files()
+	s.scanMu.Lock()
+	defer s.scanMu.Unlock()
+
+	// Appended lines are added to the aggregates; a rewritten, truncated or vanished file
+	// rebuilds them from scratch
+	incremental := s.stats != nil
+	current := make(map[string]bool)
+	for _, paths := range files {
+		for _, path := range paths {
+			current[path] = true
+		}
+	}
+	for path, mark := range s.marks {
+		if incremental && (!current[path] || !mark.unchanged(path)) {
+			if current[path] {
+				log.Printf("%s was rotated, truncated or rewritten, reading all files again", path)
+			}
+			incremental = false
+		}
+	}
+	if !incremental {
+		s.stats = NewStatsAggregator(slasFor(ctx))
+		s.stats.aggregateOnly = true
+		s.marks = make(map[string]lazyMark)
+	}
+
+	added := 0
+	for i, paths := range files {
+		count, invalidLines := 0, 0
+		var errs []error
+		for _, path := range paths {
+			mark := s.marks[path]
+			if mark.file == nil {
+				mark.file = statFile(path)
+			}
+			end, invalid, err := scanFile(path, mark.offset, func(result EvalResult, at int64) error {
+				s.stats.Add(result)
+				mark.track(result, at)
+				added++
+				return nil
+			})
+			mark.offset = end
+			mark.invalid += invalid
+			if _, ok := s.marks[path]; ok || end > 0 { // A missing file has nothing to track yet
+				s.marks[path] = mark
+			}
+			count += mark.count
+			invalidLines += mark.invalid
+			if invalid > 0 {
+				log.Printf("Warning: Skipping %d invalid line(s) in %s", invalid, path)
+			}
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+		s.recordLoad(i, count, invalidLines, errors.Join(errs...))
+	}
+	span.SetAttr("goevals.results", added)
+	span.SetAttr("goevals.incremental", incremental)
+	s.logLoaded(s.stats.Len())
+	return s.stats.Data(), nil
+}
+
+// Query reads the files and keeps only matching results, failing with errTooManyResults
+// rather than holding more than lazyMaxResults
+// Unreadable files are skipped, Stats reports them on /sources
+func (s *lazyStore) Query(ctx context.Context, q Query) ([]EvalResult, error) {
+	_, span := startSpan(ctx, "store.query")
+	defer span.End()
+
+	var matched []EvalResult
+	for _, paths := range s.files() {
+		for _, path := range paths {
+			_, _, err := scanFile(path, 0, func(result EvalResult, _ int64) error {
+				if !q.Match(result) {
+					return nil
+				}
+				if len(matched) == lazyMaxResults {
+					return errTooManyResults
+				}
+				matched = append(matched, result)
+				return nil
+			})
+			if errors.Is(err, errTooManyResults) {
+				span.RecordError(err)
+				return nil, err
+			}
+		}
+	}
+	span.SetAttr("goevals.results", len(matched))
+	return matched, nil
+}
+
+// Append stores results not already in the files, checked by streaming the files against the
+// keys of the batch so memory stays proportional to the batch
+func (s *lazyStore) Append(ctx context.Context, results []EvalResult) (int, error) {
+	if s.target == nil {
+		return 0, ErrReadOnly
+	}
+	_, span := startSpan(ctx, "store.append")
+	defer span.End()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	incoming := make(map[string]bool, len(results))
+	for _, result := range results {
+		incoming[resultKey(result)] = true
+	}
+	existing := make(map[string]bool)
+	for _, paths := range s.files() {
+		for _, path := range paths {
+			scanFile(path, 0, func(result EvalResult, _ int64) error {
+				if key := resultKey(result); incoming[key] {
+					existing[key] = true
+				}
+				return nil
+			})
+		}
+	}
+
+	var fresh []EvalResult
+	for _, result := range results {
+		key := resultKey(result)
+		if existing[key] {
+			continue
+		}
+		existing[key] = true
+		fresh = append(fresh, result)
+	}
+	n, err := s.target.Append(fresh)
+	span.SetAttr("goevals.results", n)
+	span.RecordError(err)
+	return n, err
+}
+
+// Watch tails the files and emits lines appended since the last poll, in batches of at most
+// lazyMaxResults; the first poll emits everything already present
+// A file that was rotated, truncated or rewritten (see lazyMark.unchanged) is read again from
+// the start, receivers skip results they already have
+func (s *lazyStore) Watch(ctx context.Context, interval time.Duration) <-chan []EvalResult {
+	ch := make(chan []EvalResult)
+	go func() {
+		defer close(ch)
+		marks := make(map[string]lazyMark)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		send := func(batch []EvalResult) error {
+			select {
+			case ch <- batch:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		for {
+			var batch []EvalResult
+			for _, paths := range s.files() {
+				for _, path := range paths {
+					mark, ok := marks[path]
+					if ok && !mark.unchanged(path) {
+						mark = lazyMark{}
+					}
+					if mark.file == nil {
+						mark.file = statFile(path)
+					}
+					end, _, err := scanFile(path, mark.offset, func(result EvalResult, at int64) error {
+						mark.track(result, at)
+						batch = append(batch, result)
+						if len(batch) < lazyMaxResults {
+							return nil
+						}
+						full := batch
+						batch = nil
+						return send(full)
+					})
+					if err != nil && ctx.Err() != nil {
+						return
+					}
+					mark.offset = end
+					marks[path] = mark
+				}
+			}
+			if len(batch) > 0 && send(batch) != nil {
+				return
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLazyStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "evals.jsonl")
+	os.WriteFile(path, []byte(`{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"q1","scores":{"combined":0.8},"metadata":{"run_id":"r1"}}
+not json
+{"timestamp":"2025-01-01T11:00:00Z","model":"b","test_id":"q1","scores":{"combined":0.4}}
+{"timestamp":"2025-01-01T12:00:00Z","mod`), 0o644) // Last line still being written
+	s, err := NewLazyStore([]Source{&fileSource{path: path}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	stats, err := s.Stats(ctx)
+	if err != nil || stats.TotalTests != 2 || len(stats.Results) != 0 {
+		t.Fatalf("Stats = %d tests, %d results, %v", stats.TotalTests, len(stats.Results), err)
+	}
+	if len(stats.RunIDs) != 1 || len(stats.Origins) != 1 {
+		t.Errorf("filter options = %v, %v", stats.RunIDs, stats.Origins)
+	}
+
+	// Finishing the partial line and appending another only reads the tail
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	f.WriteString(`el":"a","test_id":"q2","scores":{"combined":0.6}}
+{"timestamp":"2025-01-01T13:00:00Z","model":"a","test_id":"q3","scores":{"combined":1}}
+`)
+	f.Close()
+	stats, _ = s.Stats(ctx)
+	if stats.TotalTests != 4 || stats.ModelStats["a"].TestCount != 3 || math.Abs(stats.ModelStats["a"].AvgScore-0.8) > 1e-9 {
+		t.Errorf("appended Stats = %d tests, a = %+v", stats.TotalTests, stats.ModelStats["a"])
+	}
+	if marks := s.(*lazyStore).marks[path]; marks.count != 4 {
+		t.Errorf("mark = %+v", marks)
+	}
+
+	for _, tc := range []struct {
+		q    Query
+		want int
+	}{
+		{Query{}, 4},
+		{Query{Model: "a"}, 3},
+		{Query{TestID: "q1"}, 2},
+		{Query{Since: "2025-01-01T11:00:00Z"}, 2},
+	} {
+		got, err := s.Query(ctx, tc.q)
+		if err != nil || len(got) != tc.want {
+			t.Errorf("Query(%+v) = %d results, %v; want %d", tc.q, len(got), err, tc.want)
+		}
+	}
+
+	// A rewritten file rebuilds the aggregates
+	os.WriteFile(path, []byte(`{"timestamp":"2025-02-01T10:00:00Z","model":"c","scores":{"combined":0.5}}
+`), 0o644)
+	stats, _ = s.Stats(ctx)
+	if stats.TotalTests != 1 || len(stats.Models) != 1 || stats.Models[0] != "c" {
+		t.Errorf("rewritten Stats = %d tests, %v", stats.TotalTests, stats.Models)
+	}
+
+	results := []EvalResult{
+		{Timestamp: "2025-02-01T10:00:00Z", Model: "c"}, // Already stored
+		{Timestamp: "2025-02-01T11:00:00Z", Model: "d"},
+	}
+	if n, err := s.Append(ctx, results); err != nil || n != 1 {
+		t.Errorf("Append = %d, %v", n, err)
+	}
+	if stats, _ = s.Stats(ctx); stats.TotalTests != 2 {
+		t.Errorf("Stats after Append = %d tests", stats.TotalTests)
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	updates := s.Watch(watchCtx, 10*time.Millisecond)
+	if batch := <-updates; len(batch) != 2 {
+		t.Errorf("first Watch batch = %d results", len(batch))
+	}
+	s.Append(ctx, []EvalResult{{Timestamp: "2025-02-01T12:00:00Z", Model: "e"}})
+	if batch := <-updates; len(batch) != 1 || batch[0].Model != "e" {
+		t.Errorf("second Watch batch = %+v", batch)
+	}
+}
+
+func TestLazyStoreRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "evals.jsonl")
+	line := func(model string, i int) string {
+		return fmt.Sprintf(`{"timestamp":"2025-01-01T10:00:%02dZ","model":%q,"test_id":"q%d","scores":{"combined":0.5}}`+"\n", i, model, i)
+	}
+	os.WriteFile(path, []byte(line("a", 1)+line("a", 2)), 0o644)
+	s, err := NewLazyStore([]Source{&fileSource{path: path}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if stats, _ := s.Stats(ctx); stats.TotalTests != 2 {
+		t.Fatalf("Stats = %d tests", stats.TotalTests)
+	}
+	updates := s.Watch(ctx, 10*time.Millisecond)
+	if batch := <-updates; len(batch) != 2 {
+		t.Fatalf("first Watch batch = %d results", len(batch))
+	}
+
+	// logrotate moves the file away and a new run writes a longer one in its place
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(path, []byte(line("b", 3)+line("b", 4)+line("b", 5)), 0o644)
+
+	batch := <-updates
+	if len(batch) != 3 || batch[0].Model != "b" || batch[0].TestID != "q3" {
+		t.Errorf("Watch after rotation = %+v", batch)
+	}
+	stats, _ := s.Stats(ctx)
+	if stats.TotalTests != 3 || len(stats.Models) != 1 || stats.Models[0] != "b" {
+		t.Errorf("Stats after rotation = %d tests, %v", stats.TotalTests, stats.Models)
+	}
+}
+
+func TestLazyStoreLimitsQueries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "evals.jsonl")
+	var b strings.Builder
+	for i := 0; i <= lazyMaxResults; i++ {
+		model := "big"
+		if i == 0 {
+			model = "small"
+		}
+		fmt.Fprintf(&b, "{\"timestamp\":\"2025-01-01T10:00:%05dZ\",\"model\":%q}\n", i, model)
+	}
+	os.WriteFile(path, []byte(b.String()), 0o644)
+	s, _ := NewLazyStore([]Source{&globSource{pattern: filepath.Join(filepath.Dir(path), "*.jsonl"), files: make(map[string]*fileSource)}})
+
+	if _, err := s.Query(context.Background(), Query{}); !errors.Is(err, errTooManyResults) {
+		t.Errorf("unbounded Query err = %v", err)
+	}
+	if got, err := s.Query(context.Background(), Query{Model: "small"}); err != nil || len(got) != 1 {
+		t.Errorf("narrow Query = %d results, %v", len(got), err)
+	}
+	if stats, _ := s.Stats(context.Background()); stats.TotalTests != lazyMaxResults+1 {
+		t.Errorf("Stats = %d tests", stats.TotalTests)
+	}
+}
+
+func TestNewLazyStoreRejectsRemoteSources(t *testing.T) {
+	source, err := NewRedisStreamSource("redis://localhost:6379/0?stream=evals")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewLazyStore([]Source{source}); err == nil {
+		t.Error("NewLazyStore accepted a redis source")
+	}
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+)
+
+// LeaderboardEntry is one ranked model config
+type LeaderboardEntry struct {
+	Rank      int     `json:"rank"`
+	Config    string  `json:"config"`
+	Model     string  `json:"model"`
+	TestCount int     `json:"test_count"`
+	AvgScore  float64 `json:"avg_score"`
+	CILow     float64 `json:"ci_low"`   // 95% bootstrap confidence interval of the mean
+	CIHigh    float64 `json:"ci_high"`  // Equal to AvgScore when there are fewer than 2 tests
+	WinRate   float64 `json:"win_rate"` // Overall fraction of head-to-head comparisons won
+}
+
+// HeadToHead compares two configs on the test_ids both of them ran
+type HeadToHead struct {
+	Wins    int     `json:"wins"`
+	Losses  int     `json:"losses"`
+	Ties    int     `json:"ties"`
+	Shared  int     `json:"shared"`
+	WinRate float64 `json:"win_rate"` // Wins / Shared, 0 when nothing is shared
+}
+
+// Leaderboard ranks configs and holds the pairwise win-rate matrix
+type Leaderboard struct {
+	Entries []LeaderboardEntry               `json:"entries"`
+	Matrix  map[string]map[string]HeadToHead `json:"head_to_head"` // Matrix[a][b] = a against b
+}
+
+// BuildLeaderboard ranks configs by average combined score, weighing tests by their weight like
+// the dashboard does (see EvalResult.TestWeight)
+// Head-to-head uses each config's mean score per test_id, so repeated runs don't dominate; it
+// counts tests, unweighted
+func BuildLeaderboard(results []EvalResult) Leaderboard {
+	scores := make(map[string][]float64)
+	weighted := make(map[string]*weightedMean)
+	models := make(map[string]string)
+	perTest := make(map[string]map[string][]float64) // config -> test_id -> scores
+
+	for _, result := range scored(results) {
+		configKey := buildConfigKey(result)
+		models[configKey] = result.Model
+		scores[configKey] = append(scores[configKey], result.Scores.Combined)
+		if weighted[configKey] == nil {
+			weighted[configKey] = &weightedMean{}
+		}
+		weighted[configKey].add(result.Scores.Combined, result.TestWeight())
+
+		if result.TestID == "" {
+			continue
+		}
+		if perTest[configKey] == nil {
+			perTest[configKey] = make(map[string][]float64)
+		}
+		perTest[configKey][result.TestID] = append(perTest[configKey][result.TestID], result.Scores.Combined)
+	}
+
+	var configs []string
+	for configKey := range scores {
+		configs = append(configs, configKey)
+	}
+	sort.Strings(configs)
+
+	// Collapse repeated runs into one score per test
+	testMeans := make(map[string]map[string]float64)
+	for configKey, tests := range perTest {
+		testMeans[configKey] = make(map[string]float64)
+		for testID, values := range tests {
+			testMeans[configKey][testID] = mean(values)
+		}
+	}
+
+	board := Leaderboard{Matrix: make(map[string]map[string]HeadToHead)}
+	for _, a := range configs {
+		board.Matrix[a] = make(map[string]HeadToHead)
+		for _, b := range configs {
+			if a == b {
+				continue
+			}
+			var h HeadToHead
+			for testID, scoreA := range testMeans[a] {
+				scoreB, ok := testMeans[b][testID]
+				if !ok {
+					continue
+				}
+				h.Shared++
+				switch {
+				case scoreA > scoreB:
+					h.Wins++
+				case scoreA < scoreB:
+					h.Losses++
+				default:
+					h.Ties++
+				}
+			}
+			if h.Shared > 0 {
+				h.WinRate = float64(h.Wins) / float64(h.Shared)
+			}
+			board.Matrix[a][b] = h
+		}
+	}
+
+	for _, configKey := range configs {
+		values := scores[configKey]
+		avg := mean(values)
+		ciLow, ciHigh := bootstrapCI(values)
+		if m := weighted[configKey]; m.weighted {
+			// Recentered on the weighted mean like configStats.ci
+			unweighted := avg
+			avg = m.value()
+			ciLow, ciHigh = ciLow+avg-unweighted, ciHigh+avg-unweighted
+		}
+
+		wins, shared := 0, 0
+		for _, h := range board.Matrix[configKey] {
+			wins += h.Wins
+			shared += h.Shared
+		}
+		winRate := 0.0
+		if shared > 0 {
+			winRate = float64(wins) / float64(shared)
+		}
+
+		board.Entries = append(board.Entries, LeaderboardEntry{
+			Config:    configKey,
+			Model:     models[configKey],
+			TestCount: len(values),
+			AvgScore:  avg,
+			CILow:     ciLow,
+			CIHigh:    ciHigh,
+			WinRate:   winRate,
+		})
+	}
+
+	sort.SliceStable(board.Entries, func(i, j int) bool {
+		return board.Entries[i].AvgScore > board.Entries[j].AvgScore
+	})
+	for i := range board.Entries {
+		board.Entries[i].Rank = i + 1
+	}
+
+	return board
+}
+
+// mean returns the arithmetic mean, 0 for an empty slice
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// weightedMean is a running mean that weighs tests by EvalResult.TestWeight
+type weightedMean struct {
+	sum, weight float64
+	weighted    bool // Some weight isn't 1
+}
+
+func (m *weightedMean) add(v, w float64) {
+	m.sum += v * w
+	m.weight += w
+	m.weighted = m.weighted || w != 1
+}
+
+// value is the weighted mean, 0 when every weight is 0 like configStats.avg
+func (m *weightedMean) value() float64 {
+	if m.weight == 0 {
+		return 0
+	}
+	return m.sum / m.weight
+}
+
+// leaderboardHandler renders the ranked leaderboard with the head-to-head matrix
+func leaderboardHandler(w http.ResponseWriter, r *http.Request) {
+	results, err := store.Query(r.Context(), Query{})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	results, ok := reweightRequest(w, r, results)
+	if !ok {
+		return
+	}
+
+	data := struct {
+		Title    string
+		Subtitle string
+		Leaderboard
+	}{
+		Title:       "Leaderboard",
+		Subtitle:    "Models ranked by combined score with 95% confidence intervals and head-to-head win rates",
+		Leaderboard: BuildLeaderboard(results),
+	}
+
+	renderPage(w, r, "leaderboard", leaderboardTemplate, data)
+}
+
+// leaderboardAPIHandler returns the leaderboard as JSON
+func leaderboardAPIHandler(w http.ResponseWriter, r *http.Request) {
+	results, err := store.Query(r.Context(), Query{})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	results, ok := reweightRequest(w, r, results)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(BuildLeaderboard(results)); err != nil {
+		log.Printf("Error encoding JSON: %v", err)
+	}
+}
+
+const leaderboardTemplate = `
+{{ define "style" }}
+        .rank {
+            font-weight: 700;
+            font-size: 1.125rem;
+            color: var(--text-tertiary);
+        }
+        .ci-bar {
+            position: relative;
+            height: 6px;
+            width: 160px;
+            background: var(--bg-tertiary);
+            border-radius: 3px;
+            margin-top: 0.35rem;
+        }
+        .ci-bar span {
+            position: absolute;
+            top: 0;
+            height: 6px;
+            background: var(--accent);
+            border-radius: 3px;
+            opacity: 0.6;
+        }
+        .matrix td, .matrix th {
+            text-align: center;
+        }
+        .matrix td:first-child, .matrix th:first-child {
+            text-align: left;
+        }
+{{ end }}
+
+{{ define "content" }}
+        <div class="panel">
+            <h2>Ranking</h2>
+            {{ if .Entries }}
+            <table>
+                <thead>
+                    <tr>
+                        <th>#</th>
+                        <th>Model</th>
+                        <th>Avg Score</th>
+                        <th>95% CI</th>
+                        <th>Win Rate</th>
+                        <th>Tests</th>
+                    </tr>
+                </thead>
+                <tbody>
+                    {{ range .Entries }}
+                    <tr>
+                        <td class="rank">{{ .Rank }}</td>
+                        <td><strong>{{ .Model }}</strong><div class="mono muted">{{ configLabel .Config }}</div></td>
+                        <td><span class="score-badge {{ scoreClass .AvgScore }}">{{ printf "%.3f" .AvgScore }}</span></td>
+                        <td class="mono">
+                            {{ printf "%.3f" .CILow }} – {{ printf "%.3f" .CIHigh }}
+                            <div class="ci-bar"><span style="left: {{ percent (clamp01 .CILow) }}%; width: {{ percent (sub (clamp01 .CIHigh) (clamp01 .CILow)) }}%;"></span></div>
+                        </td>
+                        <td class="mono">{{ printf "%.0f" (percent .WinRate) }}%</td>
+                        <td>{{ human .TestCount }}{{ if lt .TestCount minTestsForCI }} <span class="muted" title="Few tests - interval is wide">⚠</span>{{ end }}</td>
+                    </tr>
+                    {{ end }}
+                </tbody>
+            </table>
+            {{ else }}
+            <p class="muted">No results yet.</p>
+            {{ end }}
+        </div>
+
+        {{ if gt (len .Entries) 1 }}
+        <div class="panel" style="overflow-x: auto;">
+            <h2>Head-to-Head</h2>
+            <p class="muted" style="margin-bottom: 1rem; font-size: 0.875rem;">Row model's win rate against column model on shared test IDs</p>
+            <table class="matrix">
+                <thead>
+                    <tr>
+                        <th></th>
+                        {{ range .Entries }}<th title="{{ .Config }}">{{ .Model }}</th>{{ end }}
+                    </tr>
+                </thead>
+                <tbody>
+                    {{ range $row := .Entries }}
+                    <tr>
+                        <td title="{{ $row.Config }}"><strong>{{ $row.Model }}</strong></td>
+                        {{ range $col := $.Entries }}
+                        {{ if eq $row.Config $col.Config }}
+                        <td class="muted">–</td>
+                        {{ else }}
+                        {{ $h := index (index $.Matrix $row.Config) $col.Config }}
+                        {{ if $h.Shared }}
+                        <td title="{{ $h.Wins }}W / {{ $h.Losses }}L / {{ $h.Ties }}T on {{ $h.Shared }} shared tests">
+                            <span class="score-badge {{ scoreClass $h.WinRate }}">{{ printf "%.0f" (percent $h.WinRate) }}%</span>
+                        </td>
+                        {{ else }}
+                        <td class="muted" title="No shared tests">n/a</td>
+                        {{ end }}
+                        {{ end }}
+                        {{ end }}
+                    </tr>
+                    {{ end }}
+                </tbody>
+            </table>
+        </div>
+        {{ end }}
+{{ end }}`
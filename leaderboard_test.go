@@ -0,0 +1,37 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBuildLeaderboard(t *testing.T) {
+	results := []EvalResult{
+		{Model: "a", TestID: "q1", Scores: ScoreBreakdown{Combined: 0.9}},
+		{Model: "a", TestID: "q2", Scores: ScoreBreakdown{Combined: 0.5}},
+		{Model: "a", TestID: "q3", Scores: ScoreBreakdown{Combined: 0.7}},
+		{Model: "b", TestID: "q1", Scores: ScoreBreakdown{Combined: 0.4}},
+		{Model: "b", TestID: "q2", Scores: ScoreBreakdown{Combined: 0.5}},
+		{Model: "b", TestID: "q4", Scores: ScoreBreakdown{Combined: 0.1}},
+	}
+
+	board := BuildLeaderboard(results)
+	if len(board.Entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(board.Entries))
+	}
+	first := board.Entries[0]
+	if first.Model != "a" || first.Rank != 1 {
+		t.Errorf("first = %+v", first)
+	}
+	if math.Abs(first.AvgScore-0.7) > 1e-9 || first.CILow >= first.AvgScore || first.CIHigh <= first.AvgScore {
+		t.Errorf("avg/ci = %f [%f, %f]", first.AvgScore, first.CILow, first.CIHigh)
+	}
+
+	h := board.Matrix["a"]["b"]
+	if h.Shared != 2 || h.Wins != 1 || h.Ties != 1 || h.WinRate != 0.5 {
+		t.Errorf("a vs b = %+v", h)
+	}
+	if h := board.Matrix["b"]["a"]; h.Losses != 1 || h.WinRate != 0 {
+		t.Errorf("b vs a = %+v", h)
+	}
+}
@@ -2,15 +2,24 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"html/template"
+	"io"
 	"log"
+	"maps"
+	"math"
+	"math/rand/v2"
 	"net/http"
 	"os"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 // EvalResult represents a single evaluation result from JSONL
@@ -24,12 +33,36 @@ type EvalResult struct {
 	Scores         ScoreBreakdown `json:"scores"`
 	ResponseTimeMS int64          `json:"response_time_ms"`
 	Metadata       map[string]any `json:"metadata,omitempty"` // Can include run_id, session_id, etc.
+	Error          string         `json:"error,omitempty"`    // Timeout, refusal, API error... the scores are left out of averages
+	Weight         *float64       `json:"weight,omitempty"`   // How much the test counts in averages, see TestWeight
+	Config         map[string]any `json:"config,omitempty"`   // Hyperparameters; when set, they alone make up the config key, see Params
+
+	// Dataset the result was evaluated on, see Dataset; comparisons across versions are flagged
+	DatasetVersion string `json:"dataset_version,omitempty"`
+	DatasetHash    string `json:"dataset_hash,omitempty"` // Used when there is no dataset_version
 
 	// LLM-as-Judge fields
-	JudgeModel             string `json:"judge_model,omitempty"`
-	JudgeFactualReasoning  string `json:"judge_factual_reasoning,omitempty"`
-	JudgeFaithfulReasoning string `json:"judge_faithful_reasoning,omitempty"`
-	JudgeContextReasoning  string `json:"judge_context_reasoning,omitempty"`
+	JudgeModel             string            `json:"judge_model,omitempty"`
+	JudgeFactualReasoning  string            `json:"judge_factual_reasoning,omitempty"`
+	JudgeFaithfulReasoning string            `json:"judge_faithful_reasoning,omitempty"`
+	JudgeContextReasoning  string            `json:"judge_context_reasoning,omitempty"`
+	JudgeReasoning         map[string]string `json:"judge_reasoning,omitempty"` // Score name -> the judge's reasoning for it
+	// Judge model -> its scores, when several judges scored the result, see BuildJudgeReport
+	JudgeScores map[string]map[string]float64 `json:"judge_scores,omitempty"`
+
+	RetrievedContexts []RetrievedContext `json:"retrieved_contexts,omitempty"` // RAG chunks the response was generated from
+	Attachments       []Attachment       `json:"attachments,omitempty"`        // Images, audio or video of a multimodal eval
+	Trace             []TraceStep        `json:"trace,omitempty"`              // Tool calls of an agent, in order
+
+	// Safety evals, see BuildSafetyReport
+	Refused        *bool  `json:"refused,omitempty"`         // The model declined to answer, nil = not recorded
+	SafetyCategory string `json:"safety_category,omitempty"` // Harm category of the prompt; benign, safe or none = should be answered
+	ShouldRefuse   *bool  `json:"should_refuse,omitempty"`   // Overrides what safety_category implies
+
+	Integrity string   `json:"sha256,omitempty"`   // Content hash recorded at ingest, see contentHash
+	Redacted  []string `json:"redacted,omitempty"` // Names of the --redact patterns that masked text in the result
+
+	Origin string `json:"-"` // Name of the source (file path) the result was loaded from, set by the store
 
 	CustomFields map[string]any `json:"-"` // Captures any extra top-level fields dynamically
 }
@@ -46,10 +79,25 @@ var knownFields = map[string]bool{
 	"scores":                   true,
 	"response_time_ms":         true,
 	"metadata":                 true,
+	"error":                    true,
+	"weight":                   true,
+	"config":                   true,
+	"dataset_version":          true,
+	"dataset_hash":             true,
 	"judge_model":              true,
 	"judge_factual_reasoning":  true,
 	"judge_faithful_reasoning": true,
 	"judge_context_reasoning":  true,
+	"judge_reasoning":          true,
+	"judge_scores":             true,
+	"retrieved_contexts":       true,
+	"attachments":              true,
+	"trace":                    true,
+	"refused":                  true,
+	"safety_category":          true,
+	"should_refuse":            true,
+	"sha256":                   true,
+	"redacted":                 true,
 	// Removed from knownFields - now detected as CustomFields:
 	// "embedding_model", "chunk_size", "chunk_overlap", "top_k",
 	// "retrieval_method", "temperature", "test_run_date", "question_id"
@@ -61,6 +109,7 @@ func (er *EvalResult) UnmarshalJSON(data []byte) error {
 	type Alias EvalResult
 	aux := &struct {
 		*Alias
+		Config any `json:"config"` // Shadows Alias.Config, older records may have a config field that isn't an object
 	}{
 		Alias: (*Alias)(er),
 	}
@@ -83,6 +132,13 @@ func (er *EvalResult) UnmarshalJSON(data []byte) error {
 			er.CustomFields[key] = value
 		}
 	}
+	switch config := aux.Config.(type) {
+	case map[string]any:
+		er.Config = config
+	case nil:
+	default:
+		er.CustomFields["config"] = config
+	}
 
 	return nil
 }
@@ -111,6 +167,65 @@ func (er EvalResult) MarshalJSON() ([]byte, error) {
 	if er.Metadata != nil {
 		result["metadata"] = er.Metadata
 	}
+	if er.Error != "" {
+		result["error"] = er.Error
+	}
+	if er.Weight != nil {
+		result["weight"] = *er.Weight
+	}
+	if len(er.Config) > 0 {
+		result["config"] = er.Config
+	}
+	if er.DatasetVersion != "" {
+		result["dataset_version"] = er.DatasetVersion
+	}
+	if er.DatasetHash != "" {
+		result["dataset_hash"] = er.DatasetHash
+	}
+
+	if er.JudgeModel != "" {
+		result["judge_model"] = er.JudgeModel
+	}
+	if er.JudgeFactualReasoning != "" {
+		result["judge_factual_reasoning"] = er.JudgeFactualReasoning
+	}
+	if er.JudgeFaithfulReasoning != "" {
+		result["judge_faithful_reasoning"] = er.JudgeFaithfulReasoning
+	}
+	if er.JudgeContextReasoning != "" {
+		result["judge_context_reasoning"] = er.JudgeContextReasoning
+	}
+	if len(er.JudgeReasoning) > 0 {
+		result["judge_reasoning"] = er.JudgeReasoning
+	}
+	if len(er.JudgeScores) > 0 {
+		result["judge_scores"] = er.JudgeScores
+	}
+	if len(er.RetrievedContexts) > 0 {
+		result["retrieved_contexts"] = er.RetrievedContexts
+	}
+	if len(er.Attachments) > 0 {
+		result["attachments"] = er.Attachments
+	}
+	if len(er.Trace) > 0 {
+		result["trace"] = er.Trace
+	}
+	if er.Refused != nil {
+		result["refused"] = *er.Refused
+	}
+	if er.SafetyCategory != "" {
+		result["safety_category"] = er.SafetyCategory
+	}
+	if er.ShouldRefuse != nil {
+		result["should_refuse"] = *er.ShouldRefuse
+	}
+
+	if er.Integrity != "" {
+		result["sha256"] = er.Integrity
+	}
+	if len(er.Redacted) > 0 {
+		result["redacted"] = er.Redacted
+	}
 
 	// Add all custom fields
 	for key, value := range er.CustomFields {
@@ -120,6 +235,80 @@ func (er EvalResult) MarshalJSON() ([]byte, error) {
 	return json.Marshal(result)
 }
 
+// JudgeNote is the judge's reasoning for one score
+type JudgeNote struct {
+	Score    string // Score name, e.g. faithfulness
+	Label    string // Heading shown in the test modal
+	Text     string
+	Value    float64 // The score itself, when the result has it
+	HasValue bool
+}
+
+// Reasoning lists the judge's reasoning of a result: the legacy judge_*_reasoning fields first,
+// then judge_reasoning by score name
+func (er EvalResult) Reasoning() []JudgeNote {
+	var notes []JudgeNote
+	for _, legacy := range []JudgeNote{
+		{Score: "factual_correctness", Label: "Factual Correctness", Text: er.JudgeFactualReasoning},
+		{Score: "faithfulness", Label: "Faithfulness", Text: er.JudgeFaithfulReasoning},
+		{Score: "context_relevance", Label: "Context Relevance", Text: er.JudgeContextReasoning},
+	} {
+		if legacy.Text != "" {
+			notes = append(notes, legacy)
+		}
+	}
+	for _, score := range slices.Sorted(maps.Keys(er.JudgeReasoning)) {
+		if text := er.JudgeReasoning[score]; text != "" {
+			notes = append(notes, JudgeNote{Score: score, Label: strings.ReplaceAll(score, "_", " "), Text: text})
+		}
+	}
+	for i := range notes {
+		notes[i].Value, notes[i].HasValue = er.Scores.Custom[notes[i].Score]
+	}
+	return notes
+}
+
+// RetrievedContext is one chunk a RAG pipeline retrieved for the question
+// In JSONL it is either an object or just the chunk text
+type RetrievedContext struct {
+	Text       string   `json:"text"`
+	Source     string   `json:"source,omitempty"`     // Document or URL the chunk came from
+	Similarity *float64 `json:"similarity,omitempty"` // Retriever score, nil when not recorded
+}
+
+// UnmarshalJSON accepts a plain string as a chunk with only text
+func (rc *RetrievedContext) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*rc = RetrievedContext{Text: text}
+		return nil
+	}
+	type Alias RetrievedContext
+	return json.Unmarshal(data, (*Alias)(rc))
+}
+
+// Chars is the length of the chunk text in characters
+func (rc RetrievedContext) Chars() int {
+	return utf8.RuneCountInString(rc.Text)
+}
+
+// SimilarityValue is the retriever score, 0 when not recorded
+func (rc RetrievedContext) SimilarityValue() float64 {
+	if rc.Similarity == nil {
+		return 0
+	}
+	return *rc.Similarity
+}
+
+// ContextChars is the total length in characters of the retrieved chunks
+func (er EvalResult) ContextChars() int {
+	n := 0
+	for _, rc := range er.RetrievedContexts {
+		n += rc.Chars()
+	}
+	return n
+}
+
 // ScoreBreakdown contains all individual scores
 // Uses map for flexibility - any custom scorer can be added
 type ScoreBreakdown struct {
@@ -171,76 +360,134 @@ func (sb ScoreBreakdown) MarshalJSON() ([]byte, error) {
 // DashboardData holds aggregated stats for the dashboard
 type DashboardData struct {
 	TotalTests       int
+	Tampered         int // Results whose sha256 no longer matches, see isTampered
+	Errors           int // Results with an error, left out of the score statistics
 	AvgScore         float64
 	Models           []string
 	Results          []EvalResult
 	ModelStats       map[string]ModelStat
 	CustomScores     []string          // Names of all custom score types found
 	CustomFieldNames []string          // Names of all custom top-level fields found
-	CustomFieldTypes map[string]string // field_name -> type (string, number, bool)
+	CustomFieldTypes map[string]string // field_name -> type (number, bool, time or string) over all its values, see fieldType
+	MixedFields      []string          // Custom fields whose values have several JSON types, e.g. 500 and "500"
+	RunIDs           []string          // Distinct metadata.run_id values
+	Origins          []string          // Sources the results were loaded from
+	Datasets         map[string]int    // Dataset version -> results ("" = unversioned), nil when no result has one
 }
 
 // ModelStat holds statistics for a single model
 type ModelStat struct {
 	Model           string // Full config key (for internal use)
 	ActualModelName string // Just the model name (for display)
+	DisplayName     string // ActualModelName in the --models registry, ActualModelName without an entry
 	TestCount       int
-	AvgScore        float64
+	Errors          int     // Results with an error, not part of the score statistics
+	ErrorRate       float64 // Errors / TestCount
+	AvgScore        float64 // Weighted by the test weights, see EvalResult.TestWeight
+	ScoredWeight    float64 // Sum of the test weights of the results without an error
 	MinScore        float64
 	MaxScore        float64
+	CILow           float64            // 95% bootstrap confidence interval of AvgScore
+	CIHigh          float64            // (see bootstrapCI)
 	CustomScores    map[string]float64 // Average for each custom score type
 	AvgTimeMS       float64
+	SLAMS           int64             // Latency SLA of the model from --latency-sla, 0 = none
+	SLATimed        int               // Results with a response time, the base of WithinSLA
+	WithinSLA       float64           // Fraction of timed results within the SLA
+	ContextResults  int               // Results with retrieved_contexts, the base of the context averages
+	AvgContexts     float64           // Mean number of retrieved chunks per result
+	AvgContextChars float64           // Mean total length of the retrieved chunks per result
+	EstCost         float64           // Estimated USD from the --models cost rates, over CostResults results
+	CostResults     int               // Results with token counts to estimate the cost from
 	CustomFields    map[string]string // Custom field values (showing first unique value found)
+	Params          map[string]string // The custom fields making up the config key, see EvalResult.Params
+}
+
+// Flat custom fields left out of the aggregation key (test metadata, not RAG config)
+var excludedKeyFields = map[string]bool{
+	"question_id":   true, // Question identifier - tests should be aggregated across all questions
+	"test_run_date": true, // Test execution date - not a configuration parameter
+}
+
+// Params returns the hyperparameters that tell the result's config apart from others of its model:
+// its config object when it has one, else its flat custom fields (the older layout) except
+// question_id and test_run_date
+func (er EvalResult) Params() map[string]any {
+	if len(er.Config) > 0 {
+		return er.Config
+	}
+	params := make(map[string]any, len(er.CustomFields))
+	for name, value := range er.CustomFields {
+		if !excludedKeyFields[name] {
+			params[name] = value
+		}
+	}
+	return params
+}
+
+// Fields returns every custom field of the result, shown as table columns: its flat custom fields
+// and its config object, where the config object wins on names both have
+func (er EvalResult) Fields() map[string]any {
+	if len(er.Config) == 0 {
+		return er.CustomFields
+	}
+	fields := make(map[string]any, len(er.CustomFields)+len(er.Config))
+	maps.Copy(fields, er.CustomFields)
+	maps.Copy(fields, er.Config)
+	return fields
 }
 
 // buildConfigKey creates a unique key for aggregation based on model + RAG config params
 // This ensures that tests with the same model but different params (chunk_size, etc.) are grouped separately
-// EXCLUDES: question_id, test_run_date (test metadata, not config parameters)
+// The params are the config object, or the flat custom fields without one, see Params
 func buildConfigKey(result EvalResult) string {
 	// Start with model name
 	key := result.Model
 
-	// Fields to exclude from aggregation key (test metadata, not RAG config)
-	excludedFields := map[string]bool{
-		"question_id":   true, // Question identifier - tests should be aggregated across all questions
-		"test_run_date": true, // Test execution date - not a configuration parameter
-	}
-
-	// Add only RAG configuration fields in sorted order for consistency
-	var fields []string
-	for fieldName := range result.CustomFields {
-		if !excludedFields[fieldName] {
-			fields = append(fields, fieldName)
-		}
-	}
-	sort.Strings(fields)
-
-	for _, fieldName := range fields {
-		value := result.CustomFields[fieldName]
-		key += fmt.Sprintf("|%s=%v", fieldName, value)
+	// Add the params in sorted order for consistency
+	params := result.Params()
+	for _, name := range slices.Sorted(maps.Keys(params)) {
+		key += fmt.Sprintf("|%s=%v", name, params[name])
 	}
 
 	return key
 }
 
+// utf8BOM is the byte order mark some Windows editors put at the start of UTF-8 files
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
 // ParseJSONL reads and parses a JSONL file
 func ParseJSONL(filename string) ([]EvalResult, error) {
+	results, _, err := parseJSONLFile(filename)
+	return results, err
+}
+
+// parseJSONLFile is ParseJSONL that also returns how many lines were skipped as invalid
+func parseJSONLFile(filename string) ([]EvalResult, int, error) {
 	f, err := os.Open(filename)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+		return nil, 0, fmt.Errorf("failed to open file: %w", err)
 	}
 	defer f.Close()
+	return parseJSONL(f)
+}
 
+// parseJSONL reads JSONL results from r, skipping invalid lines and counting them
+func parseJSONL(r io.Reader) ([]EvalResult, int, error) {
 	var results []EvalResult
-	scanner := bufio.NewScanner(f)
+	invalid := 0
+	scanner := bufio.NewScanner(r)
 	lineNum := 0
 
 	for scanner.Scan() {
 		lineNum++
-		var result EvalResult
-
-		if err := json.Unmarshal(scanner.Bytes(), &result); err != nil {
-			log.Printf("Warning: Skipping invalid JSON at line %d: %v", lineNum, err)
+		result, blank, err := decodeJSONLLine(scanner.Bytes(), lineNum)
+		if blank {
+			continue
+		}
+		if err != nil {
+			log.Printf("Warning: Skipping invalid line %d: %v", lineNum, err)
+			invalid++
 			continue
 		}
 
@@ -248,277 +495,526 @@ func ParseJSONL(filename string) ([]EvalResult, error) {
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading file: %w", err)
+		return nil, invalid, fmt.Errorf("error reading file: %w", err)
 	}
 
-	return results, nil
+	return results, invalid, nil
 }
 
-// CalculateStats computes aggregate statistics from eval results
-func CalculateStats(results []EvalResult) DashboardData {
-	data := DashboardData{
-		TotalTests:       len(results),
-		Results:          results,
-		ModelStats:       make(map[string]ModelStat),
-		CustomFieldTypes: make(map[string]string),
-	}
-
-	if len(results) == 0 {
-		return data
-	}
-
-	// Track unique configs, custom score types, and custom fields
-	// Now aggregating by full config (model + all custom fields) instead of just model
-	configSet := make(map[string]bool)
-	customScoreSet := make(map[string]bool)
-	customFieldSet := make(map[string]bool)
-	configScores := make(map[string][]float64)
-	configTimes := make(map[string][]float64)
-	// configCustomScores[configKey][scoreType] = []scores
-	configCustomScores := make(map[string]map[string][]float64)
-	// configCustomFields[configKey][fieldName] = value (first seen value for that config)
-	configCustomFields := make(map[string]map[string]string)
-	totalScore := 0.0
-
-	for _, result := range results {
-		configKey := buildConfigKey(result)
-		configSet[configKey] = true
-		totalScore += result.Scores.Combined
-
-		configScores[configKey] = append(configScores[configKey], result.Scores.Combined)
-		configTimes[configKey] = append(configTimes[configKey], float64(result.ResponseTimeMS))
-
-		// Collect all custom scores
-		if configCustomScores[configKey] == nil {
-			configCustomScores[configKey] = make(map[string][]float64)
-		}
-		for scoreType, scoreValue := range result.Scores.Custom {
-			customScoreSet[scoreType] = true
-			configCustomScores[configKey][scoreType] = append(
-				configCustomScores[configKey][scoreType],
-				scoreValue,
-			)
-		}
+// decodeJSONLLine decodes line n of a JSONL file, blank is true for an empty line
+func decodeJSONLLine(line []byte, n int) (result EvalResult, blank bool, err error) {
+	if n == 1 {
+		line = bytes.TrimPrefix(line, utf8BOM) // Written by Notepad and PowerShell on Windows
+	}
+	if len(bytes.TrimSpace(line)) == 0 {
+		return EvalResult{}, true, nil
+	}
+	result, err = decodeResult(line)
+	return result, false, err
+}
 
-		// Collect all custom fields
-		if configCustomFields[configKey] == nil {
-			configCustomFields[configKey] = make(map[string]string)
-		}
-		for fieldName, fieldValue := range result.CustomFields {
-			customFieldSet[fieldName] = true
+// Global variables
+var store Store // Storage layer used by all handlers, see NewMemoryStore
 
-			// Store first value seen for this config+field (or most common pattern)
-			if _, exists := configCustomFields[configKey][fieldName]; !exists {
-				configCustomFields[configKey][fieldName] = fmt.Sprintf("%v", fieldValue)
-			}
+func main() {
+	os.Exit(runCLI(os.Args[1:]))
+}
 
-			// Detect field type from first occurrence
-			if _, exists := data.CustomFieldTypes[fieldName]; !exists {
-				switch fieldValue.(type) {
-				case float64:
-					data.CustomFieldTypes[fieldName] = "number"
-				case bool:
-					data.CustomFieldTypes[fieldName] = "bool"
-				default:
-					data.CustomFieldTypes[fieldName] = "string"
-				}
-			}
+// runServe implements `goevals serve`, loading the sources and serving the dashboard until
+// the process exits
+func runServe(args []string) int {
+	fs := newFlagSet("serve", "[flags] <source1> [source2] [...]\n       goevals serve --config dashboards.json\n       goevals serve --project search=search/*.jsonl --project chat=chat/*.jsonl",
+		"Serves the dashboard on $PORT (default 3000). Sources can be JSONL files, directories or",
+		"wildcards (runs/*.jsonl), s3://, gs:// and https:// URLs, redis:// stream URLs or postgres://",
+		"database URLs")
+	grpcPort := fs.String("grpc-port", "", "also accept results over gRPC (see goevals.proto) on this `port`, e.g. 50051")
+	otlpEndpoint := fs.String("otlp-endpoint", "", "export traces over OTLP/HTTP to this collector base `url` (spans go to <url>/v1/traces), overrides OTEL_EXPORTER_OTLP_ENDPOINT")
+	ingestLogDir := fs.String("ingest-log", "", "write results pushed to /api/ingest and gRPC to rotated, fsynced JSONL segments in this `dir`")
+	ingestLogSize := fs.Int("ingest-log-max-mb", defaultIngestLogSize, "rotate the ingest log segment after this many `MB`, 0 = no size limit")
+	ingestLogAge := fs.String("ingest-log-max-age", formatAge(defaultIngestLogAge), "rotate the ingest log segment after this `age`, e.g. 1d or 6h, 0 = no age limit")
+	ingestRate := fs.Float64("ingest-rate", 0, "allow each client IP this many ingest `requests` per second (HTTP and gRPC), 0 = unlimited")
+	ingestBurst := fs.Int("ingest-burst", 0, "let a client IP send this many ingest `requests` at once before --ingest-rate applies, 0 = twice the rate")
+	var hookCommands stringList
+	fs.Var(&hookCommands, "ingest-hook", "pipe every ingested batch through this `command`: results as JSONL on stdin, the transformed results as JSONL on stdout (repeatable, run in order)")
+	fs.BoolVar(&acceptIngest, "accept-ingest", false, "accept results posted to /api/ingest without a token (only behind a proxy that authenticates, or on a trusted network)")
+	fs.StringVar(&ingestToken, "ingest-token", os.Getenv("GOEVALS_INGEST_TOKEN"), "accept results posted to /api/ingest with this bearer `token` (default $GOEVALS_INGEST_TOKEN)")
+	maxIngestMB := fs.Int("max-ingest-mb", maxIngestBytes>>20, "reject /api/ingest request bodies larger than this many `MB` with 413")
+	fs.BoolVar(&behindProxy, "behind-proxy", false, "rate limit by the client IP a reverse proxy adds to X-Forwarded-For instead of the connection address")
+	replicateTo := fs.String("replicate-to", "", "forward results to another goevals instance's `url` (e.g. https://central/api/ingest)")
+	replicateToken := fs.String("replicate-token", os.Getenv("GOEVALS_REPLICATE_TOKEN"), "send this bearer `token` with --replicate-to batches, the target's --ingest-token (default $GOEVALS_REPLICATE_TOKEN)")
+	var federate stringList
+	fs.Var(&wsOrigins, "ws-origin", "also let browser pages at this `origin` (e.g. https://grafana.example.com) open /api/ws, besides the dashboard's own (repeatable)")
+	fs.Var(&federate, "federate", "merge results from a remote goevals instance, as `label=url` (repeatable)")
+	archiveTo := fs.String("archive-to", "", "move old results to gzipped objects at `url` (s3://bucket/prefix, gs://bucket/prefix or a directory)")
+	columns := fs.String("columns", "", "comma separated custom field/score `names` to show in the comparison table, in order (others start hidden)")
+	fs.StringVar(&stateFiles.Views, "views-file", "goevals-views.json", "JSON `file` where saved dashboard views are stored (each --config or --project dashboard gets <name>.<dashboard>.json)")
+	fs.StringVar(&stateFiles.Labels, "labels-file", "goevals-labels.json", "JSON `file` where friendly config labels are stored (one per dashboard like --views-file)")
+	fs.StringVar(&stateFiles.Alerts, "alerts-file", "goevals-alerts.json", "JSON `file` where acknowledged and dismissed score drop alerts are stored (one per dashboard like --views-file)")
+	fs.IntVar(&sparklineRuns, "sparkline-runs", sparklineRuns, "draw each config's average score over its last `n` runs in the comparison table's Trend column, 0 = no Trend column")
+	fs.Float64Var(&alertDrop, "alert-drop", alertDrop, "alert when a config's average score drops by more than this `delta` between consecutive runs, 0 = no alerts")
+	fs.StringVar(&stateFiles.Baselines, "baselines-file", "goevals-baselines.json", "JSON `file` where baseline snapshots of the dashboard stats are stored (one per dashboard like --views-file)")
+	fs.StringVar(&stateFiles.Activity, "activity-file", "goevals-activity.jsonl", "JSONL `file` where the audit trail of added sources, appended results and completed runs is stored (one per dashboard like --views-file)")
+	fs.StringVar(&stateFiles.Annotations, "annotations-file", "goevals-annotations.jsonl", "JSONL `file` where review decisions and annotations are stored (one per dashboard like --views-file)")
+	archiveAfter := fs.Int("archive-after", 30, "archive results older than this many `days` (with --archive-to, see --max-age)")
+	maxAge := fs.String("max-age", "", "archive results older than this `age`, e.g. 30d or 36h, 0 = no age limit (with --archive-to, overrides --archive-after)")
+	maxResults := fs.Int("max-results", 0, "archive all but the newest `n` results (with --archive-to)")
+	dedupe := fs.String("dedupe", string(DedupeKeepAll), "what to do with results sharing test_id, run_id and config: keep-all, latest or error (`policy`)")
+	weights := fs.String("weights", "", "recompute combined as a weighted mean of custom scores, e.g. faithfulness:2,accuracy:1 (`weights`)")
+	var formulaSpecs stringList
+	fs.Var(&formulaSpecs, "formula", "add a derived score as `name=expression`, e.g. quality=0.6*faithfulness+0.4*accuracy-0.001*response_time_ms (repeatable)")
+	modelsFile := fs.String("models", "", "YAML or JSON `file` of display names, providers, parameters, families and cost rates per model (default models.yaml when present)")
+	datasetFile := fs.String("dataset", "", "JSONL `file` of the golden set (test_id, question, expected, tags) joined into results by test_id")
+	latencySLA := fs.String("latency-sla", "", "per-model latency budget in ms, * for all other models, e.g. gpt-4:2000,*:1500 (`slas`)")
+	theme := fs.String("theme", "", "JSON `file` with a title, logo, accent color and footer to brand the dashboard")
+	redactFile := fs.String("redact", "", "JSON `file` of redaction rules masking emails, API keys and other sensitive text in results")
+	panelsFile := fs.String("panels", "", "JSON `file` declaring extra dashboard panels (Go template fragments or embedded URLs)")
+	configFile := fs.String("config", "", "JSON or YAML (.yaml, .yml) `file` defining several dashboards (sources, thresholds, auth) served on their own base paths")
+	var projects stringList
+	fs.Var(&projects, "project", "serve an independent project at /name, as `name=source` (repeatable, repeat a name for more sources)")
+	lazy := fs.Bool("lazy", false, "keep only aggregates in memory and read results from the JSONL files when a page needs them")
+	staticDir := fs.String("static-dir", "", "serve files from this `dir` under /static/ (logos and other theme assets)")
+	fs.StringVar(&mediaDir, "media-dir", "", "`dir` that attachment paths are relative to, its images, audio and video are served under /media/ (default none: only inline attachments show)")
+	args = parseFlags(fs, args)
+	if err := loadEncryptionKey(); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if atRest != nil {
+		log.Println("Encryption at rest enabled (AES-256-GCM)")
+	}
+	if err := initTracing(*otlpEndpoint); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	defaultColumns = parseColumns(*columns)
+	policy, err := parseDedupePolicy(*dedupe)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	dedupePolicy = policy
+	if scoreWeights, err = parseWeights(*weights); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if scoreWeights != nil {
+		log.Printf("Recomputing combined scores with weights %s", formatWeights(scoreWeights))
+	}
+	if formulas, err = parseFormulas(formulaSpecs); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	for _, f := range formulas {
+		log.Printf("Derived score %s = %s", f.Name, f.Expr)
+	}
+	if formulas != nil && *lazy {
+		log.Fatalf("Error: --formula needs every result in memory, it can't be combined with --lazy")
+	}
+	if latencySLAs, err = parseLatencySLA(*latencySLA); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if latencySLAs != nil {
+		log.Printf("Latency SLA %s", formatLatencySLA(latencySLAs))
+	}
+	if *modelsFile == "" {
+		if _, err := os.Stat(defaultModelsFile); err == nil {
+			*modelsFile = defaultModelsFile
 		}
 	}
-
-	// Calculate overall average
-	data.AvgScore = totalScore / float64(len(results))
-
-	// Get sorted config list (configs, not just models)
-	for configKey := range configSet {
-		data.Models = append(data.Models, configKey)
+	if *modelsFile != "" {
+		if modelRegistry, err = loadModelRegistry(*modelsFile); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		log.Printf("Model registry %s: %d models and %d patterns", *modelsFile, len(modelRegistry.exact), len(modelRegistry.patterns))
 	}
-	sort.Strings(data.Models)
-
-	// Get sorted custom score types
-	for scoreType := range customScoreSet {
-		data.CustomScores = append(data.CustomScores, scoreType)
+	if goldenSet, err = loadGoldenSet(*datasetFile); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if goldenSet != nil {
+		log.Printf("Dataset %s: %d tests", *datasetFile, len(goldenSet.Cases))
+	}
+	if branding, err = loadBranding(*theme); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if panels, err = loadPanels(*panelsFile); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if redaction, err = loadRedaction(*redactFile); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if redaction != nil {
+		log.Printf("Redacting %d pattern(s) at %s time", len(redaction.Patterns), redaction.At)
 	}
-	sort.Strings(data.CustomScores)
 
-	// Get sorted custom field names
-	for fieldName := range customFieldSet {
-		data.CustomFieldNames = append(data.CustomFieldNames, fieldName)
+	limiter, err := newRateLimiter(*ingestRate, *ingestBurst)
+	if err != nil {
+		log.Fatalf("Error: --ingest-rate: %v", err)
+	}
+	ingestLimiter = limiter
+	if ingestHooks, err = parseIngestHooks(hookCommands); err != nil {
+		log.Fatalf("Error: --ingest-hook: %v", err)
+	}
+	for _, hook := range ingestHooks {
+		log.Printf("Ingest hook: %s", hook.Name())
 	}
-	sort.Strings(data.CustomFieldNames)
+	if *maxIngestMB <= 0 {
+		log.Fatalf("Error: --max-ingest-mb must be positive, got %d", *maxIngestMB)
+	}
+	ingestBodyLimit = int64(*maxIngestMB) << 20
 
-	// Calculate per-config stats
-	for _, configKey := range data.Models {
-		scores := configScores[configKey]
-		times := configTimes[configKey]
+	if *configFile != "" && len(projects) > 0 {
+		log.Fatalf("Error: use either --config or --project, not both")
+	}
+	multi := "--config"
+	if len(projects) > 0 {
+		multi = "--project"
+	}
+	if (*configFile != "" || len(projects) > 0) && (len(args) > 0 || len(federate) > 0 || *archiveTo != "" || *replicateTo != "" || *ingestLogDir != "" || *lazy) {
+		log.Fatalf("Error: %s defines the sources; it can't be combined with source arguments, --federate, --archive-to, --replicate-to, --ingest-log or --lazy", multi)
+	}
+	if len(args) < 1 && len(federate) == 0 && *ingestLogDir == "" && *configFile == "" && len(projects) == 0 {
+		fs.Usage()
+		return 2
+	}
 
-		if len(scores) == 0 {
-			continue
+	var sources []Source
+	for _, arg := range args {
+		source, err := NewSource(arg)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
 		}
+		sources = append(sources, source)
+	}
 
-		// Calculate average score
-		sum := 0.0
-		min := scores[0]
-		max := scores[0]
-		for _, score := range scores {
-			sum += score
-			if score < min {
-				min = score
-			}
-			if score > max {
-				max = score
-			}
+	for _, spec := range federate {
+		source, err := newRemoteSource(spec)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
 		}
+		sources = append(sources, source)
+	}
 
-		// Calculate average time
-		timeSum := 0.0
-		for _, t := range times {
-			timeSum += t
+	if *ingestLogDir != "" {
+		age, err := parseAge(*ingestLogAge)
+		if err != nil {
+			log.Fatalf("Error: --ingest-log-max-age: %v", err)
 		}
-
-		// Calculate average for each custom score type
-		customAvgs := make(map[string]float64)
-		for scoreType, scoreValues := range configCustomScores[configKey] {
-			if len(scoreValues) > 0 {
-				customSum := 0.0
-				for _, v := range scoreValues {
-					customSum += v
-				}
-				customAvgs[scoreType] = customSum / float64(len(scoreValues))
-			}
+		ingestLog, err := NewIngestLog(*ingestLogDir, int64(*ingestLogSize)<<20, age)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
 		}
+		// First, so it is the ingest target even when a JSONL file argument could append too
+		sources = append([]Source{ingestLog}, sources...)
+		log.Printf("Writing ingested results to the ingest log in %s", *ingestLogDir)
+	}
 
-		// Get custom field values for this config
-		customFields := make(map[string]string)
-		if configFields, exists := configCustomFields[configKey]; exists {
-			for fieldName, fieldValue := range configFields {
-				customFields[fieldName] = fieldValue
+	hotSources := sources
+	if (*maxAge != "" || *maxResults != 0) && *archiveTo == "" {
+		log.Fatalf("Error: --max-age and --max-results need --archive-to, the place old results are moved to")
+	}
+	if *archiveTo != "" {
+		keep := Retention{MaxAge: time.Duration(*archiveAfter) * 24 * time.Hour, MaxResults: *maxResults}
+		if *maxAge != "" {
+			age, err := parseAge(*maxAge)
+			if err != nil {
+				log.Fatalf("Error: --max-age: %v", err)
 			}
+			keep.MaxAge = age
 		}
-
-		// Extract actual model name from config key (before first pipe)
-		actualModelName := configKey
-		if pipeIndex := strings.Index(configKey, "|"); pipeIndex != -1 {
-			actualModelName = configKey[:pipeIndex]
+		if keep.MaxAge <= 0 && keep.MaxResults <= 0 {
+			log.Fatalf("Error: --archive-to needs a retention limit, --max-age or --max-results")
 		}
-
-		data.ModelStats[configKey] = ModelStat{
-			Model:           configKey,
-			ActualModelName: actualModelName,
-			TestCount:       len(scores),
-			AvgScore:        sum / float64(len(scores)),
-			MinScore:        min,
-			MaxScore:        max,
-			CustomScores:    customAvgs,
-			AvgTimeMS:       timeSum / float64(len(times)),
-			CustomFields:    customFields,
+		a, err := NewArchive(*archiveTo, keep)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
 		}
+		archive = a
+		sources = append(sources, a) // Rehydrated results are merged like any other source
 	}
 
-	return data
-}
-
-// Global variables
-var evalData DashboardData
-var evalFilenames []string // Support multiple JSONL files
+	vs, err := newViewStore(stateFiles.Views)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	views = vs
 
-// reloadData reloads eval results from all JSONL files
-func reloadData() error {
-	var allResults []EvalResult
+	ls, err := newLabelStore(stateFiles.Labels)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	configLabels = ls
 
-	for _, filename := range evalFilenames {
-		results, err := ParseJSONL(filename)
-		if err != nil {
-			log.Printf("Warning: Failed to parse %s: %v", filename, err)
-			continue
-		}
-		allResults = append(allResults, results...)
+	als, err := newAlertStore(stateFiles.Alerts)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
 	}
+	alertStates = als
 
-	if len(allResults) == 0 {
-		log.Println("Warning: No results yet - dashboard will show empty until first eval")
-		// Initialize with empty data instead of crashing
-		evalData = CalculateStats([]EvalResult{})
-	} else {
-		log.Printf("Loaded %d eval results total", len(allResults))
-		evalData = CalculateStats(allResults)
+	bs, err := newBaselineStore(stateFiles.Baselines)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
 	}
-	return nil
-}
+	baselines = bs
 
-func main() {
-	// Check arguments
-	if len(os.Args) < 2 {
-		fmt.Println("Usage: goevals <file1.jsonl> [file2.jsonl] [...]")
-		fmt.Println("\nExamples:")
-		fmt.Println("  goevals evals.jsonl")
-		fmt.Println("  goevals run1.jsonl run2.jsonl run3.jsonl")
-		fmt.Println("  go run main.go evals.jsonl")
-		os.Exit(1)
-	}
-
-	// Collect all file arguments
-	evalFilenames = os.Args[1:]
-
-	// Handle legacy "serve" subcommand
-	if evalFilenames[0] == "serve" {
-		if len(evalFilenames) < 2 {
-			log.Fatal("Error: 'serve' requires at least one file argument")
-		}
-		evalFilenames = evalFilenames[1:] // Skip "serve"
+	as, err := newAnnotationStore(stateFiles.Annotations)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
 	}
+	annotations = as
 
-	// Load all files
-	log.Printf("Loading evals from %d file(s)...", len(evalFilenames))
-	var allResults []EvalResult
-	for _, filename := range evalFilenames {
-		results, err := ParseJSONL(filename)
-		if err != nil {
-			log.Printf("Warning: Failed to parse %s: %v", filename, err)
-			continue
-		}
-		log.Printf("  ✓ %s: %d results", filename, len(results))
-		allResults = append(allResults, results...)
+	al, err := newActivityLog(stateFiles.Activity)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
 	}
 
-	if len(allResults) == 0 {
-		log.Println("Warning: No results yet - starting with empty dashboard")
-		evalData = CalculateStats([]EvalResult{})
-	} else {
-		log.Printf("Loaded %d eval results total", len(allResults))
-		evalData = CalculateStats(allResults)
-		log.Printf("Models found: %v", evalData.Models)
-		log.Printf("Custom scores found: %v", evalData.CustomScores)
-		log.Printf("Custom fields found: %v", evalData.CustomFieldNames)
-		log.Printf("Overall avg score: %.2f", evalData.AvgScore)
-	}
-
-	// Setup HTTP handlers
-	http.HandleFunc("/", dashboardHandler)
-	http.HandleFunc("/tests", testsHandler)
-	http.HandleFunc("/api/evals", evalsAPIHandler)         // Full data API endpoint
-	http.HandleFunc("/api/evals/since", evalsSinceHandler) // Smart polling endpoint
-	http.HandleFunc("/health", healthHandler)
-
-	// Start server
+	// Listen right away so health checks get answers while the sources load
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "3000"
 	}
-	portStr := ":" + port
+	gate := &startupGate{}
+	go func() {
+		if err := http.ListenAndServe(":"+port, traceRequests(gate)); err != nil {
+			log.Fatalf("Server error: %v", err)
+		}
+	}()
+
+	var stats DashboardData
+	var dashboards []*Dashboard
+	if *configFile != "" {
+		// Several dashboards, each with its own store; handlers find theirs through the request
+		if dashboards, err = loadDashboards(*configFile); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		store = routedStore{}
+		log.Printf("Serving %d dashboards from %s", len(dashboards), *configFile)
+	} else if len(projects) > 0 {
+		config, err := projectConfig(projects)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		if dashboards, err = newDashboards(config, "invalid --project"); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		store = routedStore{}
+		log.Printf("Serving %d projects", len(dashboards))
+	} else if *lazy {
+		// Aggregates only, results stay on disk until a page asks for them
+		if dedupePolicy != DedupeKeepAll {
+			log.Fatalf("Error: --lazy can't be combined with --dedupe %s", dedupePolicy)
+		}
+		if store, err = NewLazyStore(sources); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		log.Printf("Scanning evals from %d source(s), keeping only aggregates in memory (--lazy)...", len(sources))
+		ctx, span := startSpan(context.Background(), "startup.load")
+		stats, _ = store.Stats(ctx) // Logs the result count
+		span.End()
+	} else {
+		// Load all sources, traced as one root span so slow initial loads show up too
+		log.Printf("Loading evals from %d source(s)...", len(sources))
+		ctx, span := startSpan(context.Background(), "startup.load")
+		var allResults []EvalResult
+		for _, source := range sources {
+			_, loadSpan := startSpan(ctx, "source.load")
+			loadSpan.SetAttr("goevals.source", source.Name())
+			results, err := source.Load()
+			loadSpan.SetAttr("goevals.results", len(results))
+			loadSpan.RecordError(err)
+			loadSpan.End()
+			if err != nil {
+				log.Printf("Warning: Failed to load %s: %v", source.Name(), err)
+			} else {
+				log.Printf("  ✓ %s: %d results", source.Name(), len(results))
+			}
+			allResults = append(allResults, results...)
+		}
+
+		store = NewMemoryStore(sources)
+
+		allResults, duplicates, err := applyDedupe(allResults, dedupePolicy)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		if duplicates > 0 {
+			log.Printf("Dropped %d duplicate result(s), keeping the latest (--dedupe latest)", duplicates)
+		}
+
+		_, calcSpan := startSpan(ctx, "stats.calculate")
+		stats = CalculateStats(allResults, latencySLAs)
+		calcSpan.SetAttr("goevals.results", len(allResults))
+		calcSpan.End()
+		span.End()
+		if len(allResults) == 0 {
+			log.Println("Warning: No results yet - starting with empty dashboard")
+		} else {
+			log.Printf("Loaded %d eval results total", len(allResults))
+		}
+	}
+	if stats.TotalTests > 0 {
+		log.Printf("Models found: %v", stats.Models)
+		log.Printf("Custom scores found: %v", stats.CustomScores)
+		log.Printf("Custom fields found: %v", stats.CustomFieldNames)
+		log.Printf("Overall avg score: %.2f", stats.AvgScore)
+	}
+
+	if goldenSet != nil {
+		store = joiningStore{Store: store, set: goldenSet}
+	}
+	if formulas != nil {
+		store = derivingStore{Store: store, formulas: formulas}
+	}
+	if redaction != nil && redaction.At == "render" {
+		store = redactingStore{Store: store, rules: redaction}
+	}
 
+	mux := http.NewServeMux()
+	registerRoutes(mux, *staticDir)
+	var handler http.Handler = mux
+	if dashboards != nil {
+		handler = serveDashboards(dashboards, mux)
+	}
+
+	activity = al
+	if dashboards == nil {
+		go newActivityTracker(al).Run(store, activityPollInterval)
+	}
+	for _, d := range dashboards {
+		go newActivityTracker(d.activity).Run(d.store, activityPollInterval)
+	}
+	if *replicateTo != "" {
+		go NewReplicator(*replicateTo, *replicateToken).Run(store, 5*time.Second)
+	}
+	if *grpcPort != "" {
+		if dashboards != nil {
+			log.Fatalf("Error: --grpc-port can't be combined with --config or --project, each dashboard ingests over HTTP")
+		}
+		go func() {
+			log.Printf("📡 gRPC ingest listening on :%s", *grpcPort)
+			if err := newGRPCServer(":" + *grpcPort).ListenAndServe(); err != nil {
+				log.Fatalf("gRPC server error: %v", err)
+			}
+		}()
+	}
+	if archive != nil {
+		go archive.Run(hotSources, time.Hour)
+	}
+
+	// Start serving
 	log.Printf("🐹 GoEvals dashboard starting on http://localhost:%s", port)
-	log.Printf("📊 Showing %d evals from %d models", evalData.TotalTests, len(evalData.Models))
+	if dashboards != nil {
+		log.Printf("📊 Serving %d dashboards", len(dashboards))
+	} else {
+		log.Printf("📊 Showing %d evals from %d models", stats.TotalTests, len(stats.Models))
+	}
+	gate.open(handler)
+	select {} // The listener goroutine exits the process on errors
+}
 
-	if err := http.ListenAndServe(portStr, nil); err != nil {
-		log.Fatalf("Server error: %v", err)
+// registerRoutes adds every page and API handler to mux
+func registerRoutes(mux *http.ServeMux, staticDir string) {
+	mux.HandleFunc("/", dashboardHandler)
+	mux.HandleFunc("/tests", testsHandler)
+	mux.HandleFunc("/tests/{test_id}", testsHandler)        // Permalink of one test, ?run= narrows it to a run
+	mux.HandleFunc("/api/tests/{id}/full", fullTextHandler) // One result in full, the tests page cuts long texts
+	mux.HandleFunc("/compare", compareHandler)
+	mux.HandleFunc("/leaderboard", leaderboardHandler)
+	mux.HandleFunc("/correlations", correlationsHandler)
+	mux.HandleFunc("/sweeps", sweepsHandler)
+	mux.HandleFunc("/latency", latencyHandler)
+	mux.HandleFunc("/tools", toolsHandler)
+	mux.HandleFunc("/safety", safetyHandler)
+	mux.HandleFunc("/judges", judgesHandler)
+	mux.HandleFunc("/stability", stabilityHandler)
+	mux.HandleFunc("/questions", questionsHandler)
+	mux.HandleFunc("/heatmap", heatmapHandler)
+	mux.HandleFunc("/coverage", coverageHandler)
+	mux.HandleFunc("/runs", runsHandler)
+	mux.HandleFunc("/matrix", matrixHandler)
+	mux.HandleFunc("/pivot", pivotHandler)
+	mux.HandleFunc("/config", configHandler) // Drill-down of one config
+	mux.HandleFunc("/scorecard", scorecardHandler)
+	mux.HandleFunc("/activity", activityHandler)          // Audit trail of ingested results
+	mux.HandleFunc("/api/evals", evalsAPIHandler)         // Full data API endpoint
+	mux.HandleFunc("/api/evals/since", evalsSinceHandler) // Smart polling endpoint
+	mux.HandleFunc("/api/ws", wsHandler)                  // Live results for programmatic consumers
+	mux.HandleFunc("/api/leaderboard", leaderboardAPIHandler)
+	mux.HandleFunc("/api/sweeps", sweepsAPIHandler)
+	mux.HandleFunc("/api/config", configAPIHandler)
+	mux.HandleFunc("/api/scorecard", scorecardAPIHandler)
+	mux.HandleFunc("/api/latency", latencyAPIHandler)
+	mux.HandleFunc("/api/tools", toolsAPIHandler)
+	mux.HandleFunc("/api/safety", safetyAPIHandler)
+	mux.HandleFunc("/api/judges", judgesAPIHandler)
+	mux.HandleFunc("/api/stability", stabilityAPIHandler)
+	mux.HandleFunc("/api/questions", questionsAPIHandler)
+	mux.HandleFunc("/api/dataset", datasetAPIHandler)
+	mux.HandleFunc("/api/heatmap", heatmapAPIHandler)
+	mux.HandleFunc("/api/coverage", coverageAPIHandler)
+	mux.HandleFunc("/api/runs", runsAPIHandler)
+	mux.HandleFunc("/api/matrix", matrixAPIHandler)
+	mux.HandleFunc("/api/stats", statsAPIHandler)           // Computed aggregates for notebooks
+	mux.HandleFunc("/api/stats/pivot", pivotAPIHandler)     // Wide table for notebooks
+	mux.HandleFunc("/api/timeseries", timeseriesAPIHandler) // Bucketed trends for charts and Grafana
+	mux.HandleFunc("/api/reproducibility", reproducibilityAPIHandler)
+	mux.HandleFunc("/api/export", exportHandler) // PDF report
+	mux.HandleFunc("/api/saturation", saturationAPIHandler)
+	mux.HandleFunc("/api/search", searchAPIHandler)           // Full-text search
+	mux.HandleFunc("/api/sample", sampleAPIHandler)           // Random results for spot checks
+	mux.HandleFunc("/api/ingest", limitIngest(ingestHandler)) // Receives results from replicas
+	mux.HandleFunc("/api/views", viewsAPIHandler)
+	mux.HandleFunc("/api/labels", labelsAPIHandler) // Friendly config names
+	mux.HandleFunc("/api/alerts", alertsAPIHandler) // Score drops between runs
+	mux.HandleFunc("/api/activity", activityAPIHandler)
+	mux.HandleFunc("/v/", viewHandler) // Saved view shortcuts
+	mux.HandleFunc("/api/baselines", baselinesAPIHandler)
+	mux.HandleFunc("/api/annotations", annotationsAPIHandler)
+	mux.HandleFunc("/api/annotations/export", annotationsExportHandler) // Annotations joined with results
+	mux.HandleFunc("/api/annotations/bulk", annotationsBulkHandler)     // One annotation for many results
+	mux.HandleFunc("/api/archive", archiveHandler)
+	mux.HandleFunc("/api/archive/", archiveHandler) // rehydrate, evict
+	mux.HandleFunc("/sources", sourcesHandler)
+	mux.HandleFunc("/api/sources", sourcesAPIHandler)
+	mux.HandleFunc("/health", healthHandler)
+	mux.HandleFunc("/healthz", healthzHandler) // Liveness
+	mux.HandleFunc("/readyz", readyzHandler)   // Readiness with load details, 503 until loaded
+	mux.HandleFunc("/media/", mediaHandler)    // Attachment files, see --media-dir
+	if staticDir != "" {
+		mux.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir(staticDir)))) // Theme assets
 	}
 }
 
 func dashboardHandler(w http.ResponseWriter, r *http.Request) {
-	// Reload latest data from file
-	if err := reloadData(); err != nil {
-		log.Printf("Error reloading data: %v", err)
+	filter := dashboardFilter(r)
+	weights, err := requestWeights(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Unfiltered and unweighted, the store's incrementally maintained stats cover everything;
+	// otherwise reload the latest data from all sources, narrowed by the filters in the URL
+	var data DashboardData
+	var filterModels, filterRuns, filterSources, filterDatasets []string
+	if filter == (Query{}) && weights == nil {
+		if data, err = store.Stats(r.Context()); err != nil {
+			http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+			return
+		}
+		filterModels, filterRuns, filterSources, filterDatasets = statsFilterOptions(data)
+	} else {
+		allResults, err := store.Query(r.Context(), Query{})
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+			return
+		}
+		filterModels, filterRuns, filterSources, filterDatasets = filterOptions(allResults)
+		allResults = reweight(allResults, weights)
+		var filtered []EvalResult
+		for _, result := range allResults {
+			if filter.Match(result) {
+				filtered = append(filtered, result)
+			}
+		}
+		_, calcSpan := startSpan(r.Context(), "stats.calculate")
+		calcSpan.SetAttr("goevals.results", len(filtered))
+		data = CalculateStats(filtered, slasFor(r.Context()))
+		calcSpan.End()
 	}
 
 	tmpl := `<!DOCTYPE html>
@@ -526,7 +1022,7 @@ func dashboardHandler(w http.ResponseWriter, r *http.Request) {
 <head>
     <meta charset="UTF-8">
     <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>GoEvals - LLM Evaluation Dashboard</title>
+    <title>{{ brand.Name }} - LLM Evaluation Dashboard</title>
     <style>
         :root {
             --bg-primary: #ffffff;
@@ -643,6 +1139,9 @@ func dashboardHandler(w http.ResponseWriter, r *http.Request) {
             font-weight: 700;
             letter-spacing: -0.025em;
         }
+        .num-compact {
+            cursor: help;
+        }
         .models-section {
             background: var(--bg-primary);
             padding: 2rem;
@@ -657,51 +1156,226 @@ func dashboardHandler(w http.ResponseWriter, r *http.Request) {
             align-items: center;
             margin-bottom: 1.5rem;
         }
+        .plugin-panel {
+            margin-top: 2rem;
+        }
+        .plugin-frame {
+            width: 100%;
+            border: none;
+        }
+        .panel-error {
+            color: var(--error);
+        }
         h2 {
             color: var(--text-primary);
             font-size: 1.5rem;
             font-weight: 700;
             letter-spacing: -0.025em;
         }
-        .auto-refresh-toggle {
-            display: flex;
-            align-items: center;
-            gap: 0.5rem;
-            font-size: 0.875rem;
-            color: var(--text-secondary);
-            cursor: pointer;
+        .ci {
+            display: none;
+            margin-left: 0.35rem;
+            font-size: 0.75rem;
+            font-weight: 400;
+            color: var(--text-tertiary);
         }
-        .status-indicator {
-            color: var(--success);
-            animation: pulse 2s ease-in-out infinite;
+        body.show-ci .ci {
+            display: inline;
         }
-        @keyframes pulse {
-            0%, 100% { opacity: 1; }
-            50% { opacity: 0.5; }
+        .label-edit {
+            border: none;
+            background: none;
+            padding: 0 0.25rem;
+            font-size: 0.75rem;
+            color: var(--text-tertiary);
+            cursor: pointer;
+            visibility: hidden;
         }
-        table {
-            width: 100%;
-            border-collapse: collapse;
+        tr:hover .label-edit {
+            visibility: visible;
         }
-        th, td {
-            padding: 1rem;
-            text-align: left;
-            border-bottom: 1px solid var(--border-color);
-            transition: background-color 0.2s ease;
+        .delta {
+            margin-left: 0.35rem;
+            font-size: 0.75rem;
+            font-weight: 500;
         }
-        th {
-            background: var(--bg-tertiary);
-            font-weight: 600;
+        .delta-better { color: var(--success); }
+        .delta-worse { color: var(--error); }
+        .delta-flat { color: var(--text-tertiary); }
+        .baseline-note {
+            font-size: 0.875rem;
+            font-weight: 400;
             color: var(--text-secondary);
+        }
+        .filter-bar {
+            display: flex;
+            flex-wrap: wrap;
+            align-items: center;
+            gap: 1rem;
+            margin-bottom: 1.5rem;
             font-size: 0.875rem;
-            text-transform: uppercase;
-            cursor: pointer;
-            user-select: none;
-            position: relative;
-            padding-right: 20px;
+            color: var(--text-secondary);
         }
-        th:hover {
-            background: var(--bg-secondary);
+        .filter-bar select {
+            margin-left: 0.5rem;
+            background: var(--bg-primary);
+            color: var(--text-primary);
+            border: 1px solid var(--border-color);
+            border-radius: 6px;
+            padding: 0.4rem 0.6rem;
+            font-size: 0.875rem;
+        }
+        .filter-chip {
+            background: var(--bg-tertiary);
+            border-radius: 999px;
+            padding: 0.25rem 0.75rem;
+            font-family: monospace;
+        }
+        .filter-spacer {
+            flex: 1;
+        }
+        .tamper-banner {
+            background: rgba(239, 68, 68, 0.1);
+            border: 1px solid var(--error);
+            color: var(--error);
+            padding: 1rem 1.5rem;
+            border-radius: 12px;
+            margin-bottom: 2rem;
+            font-weight: 500;
+        }
+        .warning-banner {
+            background: rgba(245, 158, 11, 0.1);
+            border: 1px solid var(--warning);
+            color: var(--warning);
+            padding: 1rem 1.5rem;
+            border-radius: 12px;
+            margin-bottom: 2rem;
+            font-weight: 500;
+        }
+        .alert-banner ul {
+            margin: 0.5rem 0 0 1.25rem;
+        }
+        .alert-banner li {
+            margin: 0.25rem 0;
+        }
+        .alert-action {
+            margin-left: 0.5rem;
+            padding: 0.1rem 0.5rem;
+            border: 1px solid var(--warning);
+            border-radius: 6px;
+            background: transparent;
+            color: var(--warning);
+            font-size: 0.8rem;
+            cursor: pointer;
+        }
+        .section-controls {
+            display: flex;
+            align-items: center;
+            gap: 1rem;
+        }
+        .col-hidden {
+            display: none;
+        }
+        .columns-menu {
+            position: relative;
+        }
+        .columns-panel {
+            display: none;
+            position: absolute;
+            right: 0;
+            top: calc(100% + 0.5rem);
+            z-index: 100;
+            min-width: 240px;
+            max-height: 60vh;
+            overflow-y: auto;
+            background: var(--bg-primary);
+            border: 1px solid var(--border-color);
+            border-radius: 8px;
+            box-shadow: var(--shadow-md);
+            padding: 0.75rem;
+        }
+        .columns-panel.show {
+            display: block;
+        }
+        .columns-panel ul {
+            list-style: none;
+            margin-bottom: 0.75rem;
+        }
+        .columns-panel li {
+            display: flex;
+            align-items: center;
+            gap: 0.25rem;
+            padding: 0.25rem 0;
+            font-size: 0.875rem;
+            color: var(--text-secondary);
+        }
+        .columns-panel li label {
+            flex: 1;
+            cursor: pointer;
+        }
+        .columns-panel li button {
+            background: none;
+            border: 1px solid var(--border-color);
+            border-radius: 4px;
+            color: var(--text-secondary);
+            cursor: pointer;
+            padding: 0 0.4rem;
+        }
+        .columns-panel li button:disabled {
+            opacity: 0.3;
+            cursor: default;
+        }
+        .weight-input {
+            width: 4.5rem;
+            padding: 0.2rem 0.4rem;
+            border: 1px solid var(--border-color);
+            border-radius: 4px;
+            background: var(--bg-secondary);
+            color: var(--text-primary);
+        }
+        .help-btn.weights-active {
+            border-color: var(--accent);
+            color: var(--accent);
+        }
+        .auto-refresh-toggle {
+            display: flex;
+            align-items: center;
+            gap: 0.5rem;
+            font-size: 0.875rem;
+            color: var(--text-secondary);
+            cursor: pointer;
+        }
+        .status-indicator {
+            color: var(--success);
+            animation: pulse 2s ease-in-out infinite;
+        }
+        @keyframes pulse {
+            0%, 100% { opacity: 1; }
+            50% { opacity: 0.5; }
+        }
+        table {
+            width: 100%;
+            border-collapse: collapse;
+        }
+        th, td {
+            padding: 1rem;
+            text-align: left;
+            border-bottom: 1px solid var(--border-color);
+            transition: background-color 0.2s ease;
+        }
+        th {
+            background: var(--bg-tertiary);
+            font-weight: 600;
+            color: var(--text-secondary);
+            font-size: 0.875rem;
+            text-transform: uppercase;
+            cursor: pointer;
+            user-select: none;
+            position: relative;
+            padding-right: 20px;
+        }
+        th:hover {
+            background: var(--bg-secondary);
         }
         th::after {
             content: '↕';
@@ -754,9 +1428,42 @@ func dashboardHandler(w http.ResponseWriter, r *http.Request) {
         tbody tr:hover {
             background-color: var(--bg-secondary);
         }
+        tr.group-row {
+            cursor: pointer;
+            background-color: var(--bg-secondary);
+        }
+        .group-toggle {
+            display: inline-block;
+            width: 1em;
+            color: var(--text-tertiary);
+            transition: transform 0.2s ease;
+        }
+        tr.group-row.expanded .group-toggle {
+            transform: rotate(90deg);
+        }
+        .group-count {
+            margin-left: 0.35rem;
+            font-size: 0.75rem;
+            color: var(--text-tertiary);
+        }
+        tr.group-child td:first-child {
+            padding-left: 2rem;
+        }
         .score {
             font-weight: 600;
         }
+        .sparkline {
+            display: block;
+        }
+        .sparkline polyline {
+            fill: none;
+            stroke: currentColor;
+            stroke-width: 1.5;
+            stroke-linejoin: round;
+        }
+        .sparkline circle {
+            fill: currentColor;
+        }
         .score-good { color: #10b981; }
         .score-fair { color: #f59e0b; }
         .score-poor { color: #ef4444; }
@@ -813,16 +1520,37 @@ func dashboardHandler(w http.ResponseWriter, r *http.Request) {
             font-weight: 600;
             color: var(--accent);
         }
+        {{ template "brand-style" }}
     </style>
 </head>
 <body>
     <div class="container">
         <header>
             <div class="header-left">
-                <h1>GoEvals Dashboard</h1>
+                <h1>{{ template "brand-logo" }}{{ brand.Heading }}</h1>
                 <p class="subtitle">Simple, self-hosted LLM evaluation visualization</p>
             </div>
             <div class="header-right">
+                {{- template "project-switcher" }}
+                <a href="/leaderboard" class="help-btn" style="text-decoration: none;">Leaderboard</a>
+                <a href="/compare" class="help-btn" style="text-decoration: none;">Compare</a>
+                <a href="/scorecard" class="help-btn" style="text-decoration: none;" title="Score profile of a model as a radar chart, with its strengths and weaknesses">Scorecards</a>
+                <a href="/correlations" class="help-btn" style="text-decoration: none;">Correlations</a>
+                <a href="/heatmap" class="help-btn" style="text-decoration: none;">Heatmap</a>
+                <a href="/coverage" class="help-btn" style="text-decoration: none;" title="Which tests each model has and hasn't run">Coverage</a>
+                <a href="/sweeps" class="help-btn" style="text-decoration: none;">Sweeps</a>
+                <a href="/pivot" class="help-btn" style="text-decoration: none;" title="One parameter against another as a heatmap table">Pivot</a>
+                <a href="/latency" class="help-btn" style="text-decoration: none;">Latency</a>
+                <a href="/tools" class="help-btn" style="text-decoration: none;" title="Tool calls per model, from agent traces">Tools</a>
+                <a href="/safety" class="help-btn" style="text-decoration: none;" title="Refusal rates, over- and under-refusal per model">Safety</a>
+                <a href="/judges" class="help-btn" style="text-decoration: none;" title="Agreement between judge models, per score and judge pair">Judges</a>
+                <a href="/stability" class="help-btn" style="text-decoration: none;" title="Score spread of repeated tests per config">Stability</a>
+                <a href="/runs" class="help-btn" style="text-decoration: none;">Runs</a>
+                <a href="/matrix" class="help-btn" style="text-decoration: none;" title="Models × runs score matrix">Run matrix</a>
+                <a href="/activity" class="help-btn" style="text-decoration: none;" title="When results were added and which runs they came from">Activity</a>
+                <a href="/questions" class="help-btn" style="text-decoration: none;">Questions</a>
+                <a href="{{ .SpotCheck }}" class="help-btn" style="text-decoration: none;" title="Open 20 random results spread over every model and score band for quick qualitative review">Sample 20</a>
+                <a href="/api/export?format=pdf{{ if .WeightsParam }}&weights={{ .WeightsParam }}{{ end }}" class="help-btn" style="text-decoration: none;" title="Download a PDF report with summary stats, the model comparison, regressions and failures">PDF report</a>
                 <button id="theme-toggle" class="theme-toggle">
                     <span id="theme-icon">Dark</span>
                 </button>
@@ -830,66 +1558,268 @@ func dashboardHandler(w http.ResponseWriter, r *http.Request) {
             </div>
         </header>
 
+        {{ if .FailingSources }}
+        <div class="tamper-banner">
+            ⚠ {{ len .FailingSources }} source(s) failing to load: {{ range $i, $name := .FailingSources }}{{ if $i }}, {{ end }}<code>{{ $name }}</code>{{ end }}.
+            Showing the last good data where available - see <a href="/sources">Sources</a>.
+        </div>
+        {{ end }}
+
+        {{ if .Tampered }}
+        <div class="tamper-banner">
+            ⚠ {{ .Tampered }} result(s) failed integrity verification - their content changed after ingest.
+            Run <code>goevals verify</code> on the sources for details.
+        </div>
+        {{ end }}
+
+        {{ if .Alerts.Alerts }}
+        <div class="warning-banner alert-banner">
+            ⚠ Score {{ if eq (len .Alerts.Alerts) 1 }}drop{{ else }}drops{{ end }} since the previous run:
+            <ul>
+                {{ range .Alerts.Alerts }}
+                <li data-alert="{{ .ID }}">
+                    <a href="{{ .URL }}">{{ configLabel .Config }}</a> fell from {{ printf "%.3f" .Previous }} to {{ printf "%.3f" .Average }}
+                    in <code>{{ .Run }}</code> (previous <code>{{ .PreviousRun }}</code>)
+                    <button class="alert-action" data-state="acknowledged">Acknowledge</button>
+                    <button class="alert-action" data-state="dismissed">Dismiss</button>
+                </li>
+                {{ end }}
+            </ul>
+            {{ if .Alerts.More }}<div>and {{ .Alerts.More }} more - see <a href="/api/alerts">/api/alerts</a>.</div>{{ end }}
+        </div>
+        {{ end }}
+
+        {{ if .Coverage.Rows }}
+        <div class="warning-banner">
+            ⚠ Not every config was scored on all {{ .Coverage.Tests }} tests:
+            {{ range $i, $row := .Coverage.Rows }}{{ if $i }}, {{ end }}{{ configLabel $row.Label }} ({{ $row.Covered }}/{{ $.Coverage.Tests }}){{ end }}{{ if .Coverage.More }} and {{ .Coverage.More }} more{{ end }}.
+            Their averages cover fewer tests than the others - see <a href="/coverage?rows=config&gaps=1{{ with .Filter.RunID }}&run_id={{ . }}{{ end }}{{ with .Filter.Origin }}&source={{ . }}{{ end }}{{ with .Filter.Dataset }}&dataset={{ . }}{{ end }}">which tests are missing</a>.
+        </div>
+        {{ end }}
+
+        {{ if .DatasetMix }}
+        <div class="warning-banner">
+            ⚠ This comparison mixes {{ len .DatasetMix }} dataset versions:
+            {{ range $i, $d := .DatasetMix }}{{ if $i }}, {{ end }}{{ if $d.URL }}<a href="{{ $d.URL }}"><code>{{ $d.Version }}</code></a>{{ else }}unversioned{{ end }} ({{ human $d.Results }} results){{ end }}.
+            Scores across dataset versions are not directly comparable - pick one version to compare like with like.
+        </div>
+        {{ end }}
+
+        {{ if .Saturation }}
+        <div class="warning-banner">
+            ⚠ Saturated {{ if eq (len .Saturation) 1 }}metric{{ else }}metrics{{ end }}:
+            {{ range $i, $s := .Saturation }}{{ if $i }}; {{ end }}<code>{{ $s.Score }}</code> at its {{ $s.Bound }} ({{ printf "%.0f" (percent $s.Share) }}% of {{ $s.Results }} results score {{ $s.Value }}, config averages within {{ printf "%.2f" $s.Spread }}){{ end }}.
+            Saturated metrics no longer discriminate between configs - consider harder tests or recalibrating the scorer.
+        </div>
+        {{ end }}
+
+        <div class="filter-bar">
+            <label>Model
+                <select id="filter-model" data-param="model">
+                    <option value="">All models</option>
+                    {{ range .FilterModels }}<option value="{{ . }}" {{ if eq . $.Filter.Model }}selected{{ end }}>{{ . }}</option>{{ end }}
+                </select>
+            </label>
+            {{ if .FilterRuns }}
+            <label>Run
+                <select id="filter-run" data-param="run_id">
+                    <option value="">All runs</option>
+                    {{ range .FilterRuns }}<option value="{{ . }}" {{ if eq . $.Filter.RunID }}selected{{ end }}>{{ . }}</option>{{ end }}
+                </select>
+            </label>
+            {{ end }}
+            {{ if gt (len .FilterSources) 1 }}
+            <label>Source
+                <select id="filter-source" data-param="source">
+                    <option value="">All sources</option>
+                    {{ range .FilterSources }}<option value="{{ . }}" {{ if eq . $.Filter.Origin }}selected{{ end }}>{{ . }}</option>{{ end }}
+                </select>
+            </label>
+            {{ end }}
+            {{ if or .FilterDatasets .Filter.Dataset }}
+            <label>Dataset
+                <select id="filter-dataset" data-param="dataset">
+                    <option value="">All versions</option>
+                    {{ range .FilterDatasets }}<option value="{{ . }}" {{ if eq . $.Filter.Dataset }}selected{{ end }}>{{ . }}</option>{{ end }}
+                </select>
+            </label>
+            {{ end }}
+            {{ if .Baselines }}
+            <label>Baseline
+                <select id="filter-baseline" data-param="baseline">
+                    <option value="">None</option>
+                    {{ range .Baselines }}<option value="{{ .Name }}" {{ if and $.Baseline (eq .Name $.Baseline.Name) }}selected{{ end }}>{{ .Name }}</option>{{ end }}
+                </select>
+            </label>
+            {{ end }}
+            {{ if .Filter.TestID }}<span class="filter-chip">test_id: {{ .Filter.TestID }}</span>{{ end }}
+            <span class="filter-spacer"></span>
+            <select id="saved-views">
+                <option value="">Saved views…</option>
+            </select>
+            <button id="save-view-btn" class="help-btn">Save view</button>
+            <button id="save-baseline-btn" class="help-btn" title="Snapshot the averages shown, to compare later results against">Save baseline</button>
+            <button id="copy-link-btn" class="help-btn">Copy link</button>
+        </div>
+
         <div class="stats-grid">
             <div class="stat-card">
                 <div class="stat-label">Total Tests</div>
-                <div class="stat-value">{{ .TotalTests }}</div>
+                <div class="stat-value">{{ human .TotalTests }}</div>
             </div>
             <div class="stat-card">
                 <div class="stat-label">Models Tested</div>
-                <div class="stat-value">{{ len .Models }}</div>
+                <div class="stat-value">{{ human (len .Models) }}</div>
             </div>
             <div class="stat-card">
                 <div class="stat-label">Average Score</div>
-                <div class="stat-value">{{ printf "%.2f" .AvgScore }}</div>
+                <div class="stat-value">{{ printf "%.2f" .AvgScore }}{{ with $.Baseline.Delta "" "combined" .AvgScore }}<span class="delta {{ .Class }}" title="Baseline: {{ printf "%.2f" .Was }}">{{ . }}</span>{{ end }}</div>
             </div>
         </div>
 
         <div class="models-section">
-            <h2>Model Comparison</h2>
+            <div class="section-header">
+                <h2>Model Comparison{{ with .Baseline }} <span class="baseline-note" title="Taken {{ .Created.Format "2006-01-02 15:04" }} UTC{{ with .Query }} with {{ . }}{{ end }}">vs baseline <strong>{{ .Name }}</strong></span>{{ end }}</h2>
+                <div class="section-controls">
+                    <label class="auto-refresh-toggle">
+                        <input type="checkbox" id="ci-toggle" style="cursor: pointer;">
+                        <span>Show 95% CI</span>
+                    </label>
+                    {{ if .CustomScores }}
+                    <div class="columns-menu">
+                        <button id="weights-btn" class="help-btn{{ if .Weights }} weights-active{{ end }}" title="{{ if .Weights }}Combined is recomputed from weighted custom scores{{ else }}Recompute combined from weighted custom scores{{ end }}">Weights{{ if .Weights }} ●{{ end }}</button>
+                        <div id="weights-panel" class="columns-panel">
+                            <ul>
+                                {{ range .CustomScores }}
+                                <li><label for="weight-{{ . }}">{{ . }}</label><input type="number" class="weight-input" id="weight-{{ . }}" data-score="{{ . }}" min="0" step="0.5" value="{{ if $.Weights }}{{ index $.Weights . }}{{ else }}1{{ end }}"></li>
+                                {{ end }}
+                            </ul>
+                            <button id="weights-apply" class="help-btn">Apply</button>
+                            <button id="weights-reset" class="help-btn">Recorded scores</button>
+                        </div>
+                    </div>
+                    {{ end }}
+                    {{ if .Columns }}
+                    <div class="columns-menu">
+                        <button id="columns-btn" class="help-btn">Columns</button>
+                        <div id="columns-panel" class="columns-panel">
+                            <ul id="columns-list"></ul>
+                            <button id="columns-reset" class="help-btn">Reset to default</button>
+                        </div>
+                    </div>
+                    {{ end }}
+                </div>
+            </div>
             <div style="overflow-x: auto;">
             <table id="comparison-table">
                 <thead>
                     <tr>
-                        <th onclick="sortTable(0)">Model</th>
-                        <th onclick="sortTable(1)" class="sorted-desc">Combined</th>
-                        {{ range $idx, $fieldName := $.CustomFieldNames }}
-                        <th onclick="sortTable({{ add 2 $idx }})">{{ $fieldName }}</th>
-                        {{ end }}
-                        {{ range $idx, $score := $.CustomScores }}
-                        <th onclick="sortTable({{ add (add 2 (len $.CustomFieldNames)) $idx }})" class="score-cell">{{ $score }}</th>
+                        <th onclick="sortBy(this)" data-sort="model" class="{{ $.Sort.Class "model" }}">Model</th>
+                        <th onclick="sortBy(this)" data-sort="combined" class="{{ $.Sort.Class "combined" }}">Combined</th>
+                        {{ range $.Columns }}
+                        <th onclick="sortBy(this)" data-sort="{{ .ID }}" data-col="{{ .ID }}" class="{{ if eq .Kind "score" }}score-cell{{ end }}{{ if .Hidden }} col-hidden{{ end }}{{ with $.Sort.Class .ID }} {{ . }}{{ end }}"{{ if eq .Kind "score" }}{{ with formula .Name }} title="Derived: {{ . }}"{{ end }}{{ else if isMixed .Name }} title="Values of several types, sorted as {{ index $.CustomFieldTypes .Name }}"{{ end }}>{{ .Name }}</th>
                         {{ end }}
-                        <th onclick="sortTable({{ add (add 2 (len $.CustomFieldNames)) (len $.CustomScores) }})">Tests</th>
-                        <th onclick="sortTable({{ add (add 3 (len $.CustomFieldNames)) (len $.CustomScores) }})">Min</th>
-                        <th onclick="sortTable({{ add (add 4 (len $.CustomFieldNames)) (len $.CustomScores) }})">Max</th>
-                        <th onclick="sortTable({{ add (add 5 (len $.CustomFieldNames)) (len $.CustomScores) }})">Time (ms)</th>
+                        {{ if .Sparklines }}<th onclick="sortBy(this)" data-sort="trend" class="{{ $.Sort.Class "trend" }}" title="Average combined score over the last {{ sparklineRuns }} runs of each config, sorted by the change from the first to the last">Trend</th>{{ end }}
+                        <th onclick="sortBy(this)" data-sort="tests" class="{{ $.Sort.Class "tests" }}">Tests</th>
+                        <th onclick="sortBy(this)" data-sort="min" class="{{ $.Sort.Class "min" }}">Min</th>
+                        <th onclick="sortBy(this)" data-sort="max" class="{{ $.Sort.Class "max" }}">Max</th>
+                        <th onclick="sortBy(this)" data-sort="time" class="{{ $.Sort.Class "time" }}">Time (ms)</th>
+                        {{ if .LatencySLA }}<th onclick="sortBy(this)" data-sort="sla" class="{{ $.Sort.Class "sla" }}" title="Share of timed results within the model's latency SLA">Within SLA</th>{{ end }}
+                        {{ if .RetrievedContexts }}<th onclick="sortBy(this)" data-sort="contexts" class="{{ $.Sort.Class "contexts" }}" title="Mean number of retrieved_contexts chunks per result">Contexts</th>
+                        <th onclick="sortBy(this)" data-sort="context_chars" class="{{ $.Sort.Class "context_chars" }}" title="Mean total length of the retrieved chunks per result, in characters">Context chars</th>{{ end }}
+                        {{ if .Costs }}<th onclick="sortBy(this)" data-sort="cost" class="{{ $.Sort.Class "cost" }}" title="Estimated from token counts and the cost rates in the model registry">Est. Cost</th>{{ end }}
+                        {{ if .Errors }}<th onclick="sortBy(this)" data-sort="errors" class="{{ $.Sort.Class "errors" }}" title="Share of results with an error (timeouts, refusals, API errors), left out of the scores">Errors</th>{{ end }}
                     </tr>
                 </thead>
                 <tbody id="table-body">
-                    {{ range .Models }}
-                    {{ $stat := index $.ModelStats . }}
-                    <tr style="cursor: pointer;" onclick="window.location='/tests?model={{ $stat.Model }}'">
-                        <td><strong>{{ $stat.ActualModelName }}</strong></td>
+                    {{ range $group := .Groups }}
+                    {{ if $group.Collapsible }}
+                    {{ $stat := $group.Stat }}
+                    <tr class="group-row" data-group="{{ $group.Model }}" onclick="toggleGroup(this)" title="Show or hide the {{ len $group.Configs }} configs of {{ $group.Model }}">
+                        <td><span class="group-toggle">▸</span> <strong>{{ modelLabel $group.Model }}</strong> <span class="group-count">{{ len $group.Configs }} configs</span></td>
                         <td class="score {{ if ge $stat.AvgScore 0.7 }}score-good{{ else if ge $stat.AvgScore 0.5 }}score-fair{{ else }}score-poor{{ end }}">{{ printf "%.2f" $stat.AvgScore }}</td>
-                        {{ range $fieldName := $.CustomFieldNames }}
-                        <td>{{ formatValue (index $stat.CustomFields $fieldName) }}</td>
+                        {{ range $.Columns }}
+                        {{ if eq .Kind "score" }}
+                        {{ $customScore := index $stat.CustomScores .Name }}
+                        <td data-col="{{ .ID }}" class="score-cell score {{ if ge $customScore 0.7 }}score-good{{ else if ge $customScore 0.4 }}score-fair{{ else }}score-poor{{ end }}{{ if .Hidden }} col-hidden{{ end }}">{{ printf "%.2f" $customScore }}</td>
+                        {{ else }}
+                        <td data-col="{{ .ID }}"{{ if .Hidden }} class="col-hidden"{{ end }}></td>
                         {{ end }}
-                        {{ range $scoreType := $.CustomScores }}
-                        {{ $customScore := index $stat.CustomScores $scoreType }}
-                        <td class="score-cell score {{ if ge $customScore 0.7 }}score-good{{ else if ge $customScore 0.4 }}score-fair{{ else }}score-poor{{ end }}">{{ printf "%.2f" $customScore }}</td>
                         {{ end }}
-                        <td>{{ $stat.TestCount }}</td>
+                        {{ if $.Sparklines }}<td></td>{{ end }}
+                        <td>{{ human $stat.TestCount }}</td>
                         <td>{{ printf "%.2f" $stat.MinScore }}</td>
                         <td>{{ printf "%.2f" $stat.MaxScore }}</td>
                         <td>{{ printf "%.0f" $stat.AvgTimeMS }}</td>
+                        {{ if $.LatencySLA }}
+                        <td>{{ if and $stat.SLAMS $stat.SLATimed }}<span class="score {{ if ge $stat.WithinSLA 0.95 }}score-good{{ else if ge $stat.WithinSLA 0.8 }}score-fair{{ else }}score-poor{{ end }}">{{ printf "%.0f" (percent $stat.WithinSLA) }}%</span>{{ else }}-{{ end }}</td>
+                        {{ end }}
+                        {{ if $.RetrievedContexts }}
+                        {{ if $stat.ContextResults }}<td>{{ printf "%.1f" $stat.AvgContexts }}</td>
+                        <td>{{ human $stat.AvgContextChars }}</td>{{ else }}<td>-</td><td>-</td>{{ end }}
+                        {{ end }}
+                        {{ if $.Costs }}
+                        <td>{{ if $stat.CostResults }}{{ formatCost $stat.EstCost }}{{ else }}-{{ end }}</td>
+                        {{ end }}
+                        {{ if $.Errors }}
+                        <td>{{ printf "%.1f" (percent $stat.ErrorRate) }}%</td>
+                        {{ end }}
+                    </tr>
+                    {{ end }}
+                    {{ range $group.Configs }}
+                    {{ $stat := index $.ModelStats . }}
+                    <tr style="cursor: pointer;"{{ if $group.Collapsible }} class="group-child" data-group="{{ $group.Model }}" hidden{{ end }} data-href="/config?key={{ $stat.Model }}{{ with $.Filter.Dataset }}&dataset={{ . }}{{ end }}{{ if $.WeightsParam }}&weights={{ $.WeightsParam }}{{ end }}">
+                        <td><strong>{{ statLabel $stat }}</strong> <button class="label-edit" data-config="{{ $stat.Model }}" title="Rename this config">✎</button></td>
+                        <td class="score {{ if ge $stat.AvgScore 0.7 }}score-good{{ else if ge $stat.AvgScore 0.5 }}score-fair{{ else }}score-poor{{ end }}">{{ printf "%.2f" $stat.AvgScore }}<span class="ci" title="95% bootstrap CI: {{ printf "%.3f" $stat.CILow }} – {{ printf "%.3f" $stat.CIHigh }}">±{{ printf "%.2f" (ciMargin $stat) }}</span>{{ with $.Baseline.Delta $stat.Model "combined" $stat.AvgScore }}<span class="delta {{ .Class }}" title="Baseline: {{ printf "%.2f" .Was }}">{{ . }}</span>{{ end }}</td>
+                        {{ range $.Columns }}
+                        {{ if eq .Kind "score" }}
+                        {{ $customScore := index $stat.CustomScores .Name }}
+                        <td data-col="{{ .ID }}" class="score-cell score {{ if ge $customScore 0.7 }}score-good{{ else if ge $customScore 0.4 }}score-fair{{ else }}score-poor{{ end }}{{ if .Hidden }} col-hidden{{ end }}">{{ printf "%.2f" $customScore }}{{ with $.Baseline.Delta $stat.Model .ID $customScore }}<span class="delta {{ .Class }}" title="Baseline: {{ printf "%.2f" .Was }}">{{ . }}</span>{{ end }}</td>
+                        {{ else }}
+                        {{ $value := index $stat.CustomFields .Name }}
+                        {{ $type := index $.CustomFieldTypes .Name }}
+                        <td data-col="{{ .ID }}"{{ if .Hidden }} class="col-hidden"{{ end }}>{{ if and (eq $type "number") (isCountField .Name) }}{{ human $value }}{{ else }}{{ formatField $type $value }}{{ end }}</td>
+                        {{ end }}
+                        {{ end }}
+                        {{ if $.Sparklines }}
+                        {{ with index $.Sparklines $stat.Model }}<td><svg class="sparkline {{ .Trend }}" width="{{ sparklineWidth }}" height="{{ sparklineHeight }}" viewBox="0 0 {{ sparklineWidth }} {{ sparklineHeight }}" role="img" aria-label="Score trend over {{ .Runs }} runs"><title>{{ .Title }}</title><polyline points="{{ .Points }}"/><circle cx="{{ .LastX }}" cy="{{ .LastY }}" r="2"/></svg></td>{{ else }}<td>-</td>{{ end }}
+                        {{ end }}
+                        <td>{{ human $stat.TestCount }}{{ if lt $stat.TestCount minTestsForCI }} <span class="ci" title="Only {{ $stat.TestCount }} tests - confidence interval is unreliable">⚠</span>{{ end }}</td>
+                        <td>{{ printf "%.2f" $stat.MinScore }}</td>
+                        <td>{{ printf "%.2f" $stat.MaxScore }}</td>
+                        <td>{{ printf "%.0f" $stat.AvgTimeMS }}{{ with $.Baseline.Delta $stat.Model "time" $stat.AvgTimeMS }}<span class="delta {{ .Class }}" title="Baseline: {{ printf "%.0f" .Was }}ms">{{ . }}</span>{{ end }}</td>
+                        {{ if $.LatencySLA }}
+                        <td>{{ if and $stat.SLAMS $stat.SLATimed }}<a href="/tests?model={{ $stat.Model }}&sla=breach{{ with $.Filter.Dataset }}&dataset={{ . }}{{ end }}{{ if $.WeightsParam }}&weights={{ $.WeightsParam }}{{ end }}" class="score {{ if ge $stat.WithinSLA 0.95 }}score-good{{ else if ge $stat.WithinSLA 0.8 }}score-fair{{ else }}score-poor{{ end }}" title="{{ printf "%.0f" (percent $stat.WithinSLA) }}% of {{ $stat.SLATimed }} timed results within {{ $stat.SLAMS }}ms - click for breaches">{{ printf "%.0f" (percent $stat.WithinSLA) }}%</a>{{ else }}-{{ end }}</td>
+                        {{ end }}
+                        {{ if $.RetrievedContexts }}
+                        {{ if $stat.ContextResults }}<td title="Over {{ $stat.ContextResults }} of {{ $stat.TestCount }} results with retrieved contexts">{{ printf "%.1f" $stat.AvgContexts }}</td>
+                        <td>{{ human $stat.AvgContextChars }}</td>{{ else }}<td>-</td><td>-</td>{{ end }}
+                        {{ end }}
+                        {{ if $.Costs }}
+                        <td>{{ if $stat.CostResults }}<span title="{{ formatCost (div $stat.EstCost $stat.CostResults) }} per result, over {{ $stat.CostResults }} of {{ $stat.TestCount }} results with token counts">{{ formatCost $stat.EstCost }}</span>{{ else }}-{{ end }}</td>
+                        {{ end }}
+                        {{ if $.Errors }}
+                        <td>{{ if $stat.Errors }}<a href="/tests?model={{ $stat.Model }}&errors=only{{ with $.Filter.Dataset }}&dataset={{ . }}{{ end }}{{ if $.WeightsParam }}&weights={{ $.WeightsParam }}{{ end }}" class="score {{ if le $stat.ErrorRate 0.01 }}score-good{{ else if le $stat.ErrorRate 0.05 }}score-fair{{ else }}score-poor{{ end }}" title="{{ $stat.Errors }} of {{ $stat.TestCount }} results errored, left out of the scores - click to see them">{{ printf "%.1f" (percent $stat.ErrorRate) }}%</a>{{ else }}0%{{ end }}</td>
+                        {{ end }}
                     </tr>
                     {{ end }}
+                    {{ end }}
                 </tbody>
             </table>
             </div>
         </div>
 
+        {{ range .Panels }}
+        <div class="models-section plugin-panel">
+            <div class="section-header">
+                <h2>{{ .Title }}</h2>
+            </div>
+            {{ if .URL }}<iframe class="plugin-frame" src="{{ .URL }}" height="{{ .Height }}" title="{{ .Title }}"></iframe>{{ else }}{{ .HTML }}{{ end }}
+        </div>
+        {{ end }}
+
         <footer>
+            {{ template "brand-footer" }}
             Built with Go stdlib + HTML + common sense<br>
             <a href="https://github.com/rchojn/goevals">github.com/rchojn/goevals</a><br>
             <div style="margin-top: 0.75rem; display: flex; align-items: center; justify-content: center; gap: 1rem;">
@@ -1027,52 +1957,295 @@ func dashboardHandler(w http.ResponseWriter, r *http.Request) {
         // Initial poll after 5 seconds
         setTimeout(pollForUpdates, pollInterval);
 
-        // Table sorting
-        let sortDirection = {};
-        function sortTable(colIndex) {
-            const table = document.getElementById('comparison-table');
-            const tbody = document.getElementById('table-body');
-            const rows = Array.from(tbody.querySelectorAll('tr'));
+        // Shareable URL: filters, sort, columns and the CI toggle live in the query string
+        const params = new URLSearchParams(location.search);
+        function updateURL() {
+            const query = params.toString();
+            history.replaceState(null, '', query ? '?' + query : location.pathname);
+        }
+
+        // Confidence interval toggle (URL wins over the saved preference)
+        const ciToggle = document.getElementById('ci-toggle');
+        ciToggle.checked = params.has('ci') ? params.get('ci') === '1' : localStorage.getItem('showCI') === 'true';
+        document.body.classList.toggle('show-ci', ciToggle.checked);
+        ciToggle.addEventListener('change', () => {
+            document.body.classList.toggle('show-ci', ciToggle.checked);
+            localStorage.setItem('showCI', ciToggle.checked);
+            params.set('ci', ciToggle.checked ? '1' : '0');
+            updateURL();
+        });
+
+        // The server sorts the table, see TableSort; a header click reloads it sorted by that column,
+        // in the opposite direction when it is already sorted by it
+        function sortBy(th) {
+            params.set('sort', th.dataset.sort);
+            params.set('dir', th.classList.contains('sorted-asc') ? 'desc' : 'asc');
+            location.search = params.toString();
+        }
+
+        // Model groups start collapsed, clicking the model row shows its configs
+        function toggleGroup(row) {
+            const expanded = row.classList.toggle('expanded');
+            document.querySelectorAll('#table-body tr.group-child').forEach(child => {
+                if (child.dataset.group === row.dataset.group) {
+                    child.hidden = !expanded;
+                }
+            });
+        }
 
-            // Toggle sort direction
-            sortDirection[colIndex] = sortDirection[colIndex] === 'asc' ? 'desc' : 'asc';
-            const direction = sortDirection[colIndex];
+        // Column selection and ordering (saved per browser, defaults come from --columns)
+        const columnsKey = 'dashboardColumns';
+        const columnsBtn = document.getElementById('columns-btn');
+        const columnsPanel = document.getElementById('columns-panel');
+        const columnsList = document.getElementById('columns-list');
+        const defaultColumns = Array.from(document.querySelectorAll('#comparison-table th[data-col]')).map(th => ({
+            id: th.dataset.col,
+            name: th.textContent.trim(),
+            hidden: th.classList.contains('col-hidden'),
+        }));
+
+        function loadColumns() {
+            if (params.has('cols')) {
+                return defaultColumns.map(c => ({ ...c })); // Already ordered by the server
+            }
+            try {
+                const saved = JSON.parse(localStorage.getItem(columnsKey));
+                if (saved && Array.isArray(saved.order) && Array.isArray(saved.hidden)) {
+                    const byId = Object.fromEntries(defaultColumns.map(c => [c.id, c]));
+                    const cols = saved.order.filter(id => byId[id]).map(id => ({ ...byId[id], hidden: saved.hidden.includes(id) }));
+                    // Columns that showed up after the preferences were saved go at the end
+                    defaultColumns.forEach(c => {
+                        if (!saved.order.includes(c.id)) cols.push({ ...c });
+                    });
+                    return cols;
+                }
+            } catch (e) {
+                console.error('Invalid column preferences:', e);
+            }
+            return defaultColumns.map(c => ({ ...c }));
+        }
+        let columns = loadColumns();
 
-            // Update header indicators
-            table.querySelectorAll('th').forEach(th => {
-                th.classList.remove('sorted-asc', 'sorted-desc');
+        function applyColumns() {
+            document.querySelectorAll('#comparison-table tr').forEach(row => {
+                const cells = {};
+                row.querySelectorAll('[data-col]').forEach(cell => { cells[cell.dataset.col] = cell; });
+                // Custom columns always sit between Combined and Tests
+                row.cells[1].after(...columns.map(c => cells[c.id]).filter(Boolean));
+                columns.forEach(c => cells[c.id] && cells[c.id].classList.toggle('col-hidden', c.hidden));
             });
-            const th = table.querySelectorAll('th')[colIndex];
-            th.classList.add(direction === 'asc' ? 'sorted-asc' : 'sorted-desc');
+            renderColumnsList();
+        }
+
+        function saveColumns() {
+            localStorage.setItem(columnsKey, JSON.stringify({
+                order: columns.map(c => c.id),
+                hidden: columns.filter(c => c.hidden).map(c => c.id),
+            }));
+            params.set('cols', columns.filter(c => !c.hidden).map(c => c.id).join(','));
+            updateURL();
+            applyColumns();
+        }
+
+        function moveColumn(i, delta) {
+            const [col] = columns.splice(i, 1);
+            columns.splice(i + delta, 0, col);
+            saveColumns();
+        }
+
+        function renderColumnsList() {
+            columnsList.innerHTML = '';
+            columns.forEach((c, i) => {
+                const li = document.createElement('li');
+                const label = document.createElement('label');
+                const box = document.createElement('input');
+                box.type = 'checkbox';
+                box.checked = !c.hidden;
+                box.addEventListener('change', () => {
+                    c.hidden = !box.checked;
+                    saveColumns();
+                });
+                label.append(box, ' ' + c.name);
+
+                const up = document.createElement('button');
+                up.textContent = '↑';
+                up.title = 'Move left';
+                up.disabled = i === 0;
+                up.addEventListener('click', () => moveColumn(i, -1));
+                const down = document.createElement('button');
+                down.textContent = '↓';
+                down.title = 'Move right';
+                down.disabled = i === columns.length - 1;
+                down.addEventListener('click', () => moveColumn(i, 1));
+
+                li.append(label, up, down);
+                columnsList.appendChild(li);
+            });
+        }
 
-            // Sort rows
-            rows.sort((a, b) => {
-                const aVal = a.cells[colIndex].textContent.trim();
-                const bVal = b.cells[colIndex].textContent.trim();
+        if (columnsBtn) {
+            columnsBtn.addEventListener('click', (e) => {
+                e.stopPropagation();
+                columnsPanel.classList.toggle('show');
+            });
+            columnsPanel.addEventListener('click', (e) => e.stopPropagation());
+            document.addEventListener('click', () => columnsPanel.classList.remove('show'));
+            document.getElementById('columns-reset').addEventListener('click', () => {
+                localStorage.removeItem(columnsKey);
+                if (params.has('cols')) {
+                    params.delete('cols');
+                    location.search = params.toString(); // Server order depends on cols
+                    return;
+                }
+                columns = defaultColumns.map(c => ({ ...c }));
+                applyColumns();
+            });
+            applyColumns();
+        }
 
-                // Try to parse as numbers
-                const aNum = parseFloat(aVal);
-                const bNum = parseFloat(bVal);
+        // Score weights reload the page, combined is recomputed on the server
+        const weightsBtn = document.getElementById('weights-btn');
+        if (weightsBtn) {
+            const weightsPanel = document.getElementById('weights-panel');
+            weightsBtn.addEventListener('click', (e) => {
+                e.stopPropagation();
+                weightsPanel.classList.toggle('show');
+            });
+            weightsPanel.addEventListener('click', (e) => e.stopPropagation());
+            document.addEventListener('click', () => weightsPanel.classList.remove('show'));
+            document.getElementById('weights-apply').addEventListener('click', () => {
+                const pairs = Array.from(document.querySelectorAll('.weight-input'))
+                    .filter(input => input.value !== '' && Number(input.value) > 0)
+                    .map(input => input.dataset.score + ':' + Number(input.value));
+                params.set('weights', pairs.length ? pairs.join(',') : 'off');
+                location.search = params.toString();
+            });
+            document.getElementById('weights-reset').addEventListener('click', () => {
+                params.set('weights', 'off');
+                location.search = params.toString();
+            });
+        }
 
-                if (!isNaN(aNum) && !isNaN(bNum)) {
-                    return direction === 'asc' ? aNum - bNum : bNum - aNum;
+        // Filters reload the page with the new query string
+        document.querySelectorAll('.filter-bar select[data-param]').forEach(select => {
+            select.addEventListener('change', () => {
+                if (select.value) {
+                    params.set(select.dataset.param, select.value);
+                } else {
+                    params.delete(select.dataset.param);
                 }
+                location.search = params.toString();
+            });
+        });
+
+        // Saved views (stored server-side, shared by everyone using this instance)
+        const savedViews = document.getElementById('saved-views');
+        fetch('/api/views').then(r => r.json()).then(list => {
+            list.forEach(view => {
+                const option = document.createElement('option');
+                option.value = view.name;
+                option.textContent = view.name;
+                savedViews.appendChild(option);
+            });
+        }).catch(err => console.error('Failed to load views:', err));
+        savedViews.addEventListener('change', () => {
+            if (savedViews.value) {
+                location.href = '/v/' + encodeURIComponent(savedViews.value);
+            }
+        });
+
+        // Config rows open their config page; links and buttons in a row keep their own action
+        // Untrusted values like config keys stay in data attributes instead of inline handlers
+        document.getElementById('table-body').addEventListener('click', (e) => {
+            const row = e.target.closest('tr[data-href]');
+            if (row && !e.target.closest('a, button')) {
+                location.href = row.dataset.href;
+            }
+        });
+        document.querySelectorAll('.label-edit').forEach(button => {
+            button.addEventListener('click', () => renameConfig(button.dataset.config));
+        });
 
-                // String comparison
-                return direction === 'asc'
-                    ? aVal.localeCompare(bVal)
-                    : bVal.localeCompare(aVal);
+        // Config labels (stored server-side), an empty name goes back to the automatic label
+        async function renameConfig(config) {
+            const label = prompt('Name for ' + config + ' (leave empty for the automatic name):');
+            if (label === null) {
+                return;
+            }
+            const response = await fetch('/api/labels', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ config: config, label: label }),
             });
+            if (!response.ok) {
+                alert('Failed to save label: ' + await response.text());
+                return;
+            }
+            location.reload();
+        }
 
-            // Re-append sorted rows
-            rows.forEach(row => tbody.appendChild(row));
+        // Score drop alerts, acknowledged and dismissed ones leave the banner for good
+        async function setAlertState(item, state) {
+            const response = await fetch('/api/alerts', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ id: item.dataset.alert, state: state }),
+            });
+            if (!response.ok) {
+                alert('Failed to update alert: ' + await response.text());
+                return;
+            }
+            const banner = item.closest('.alert-banner');
+            item.remove();
+            if (!banner.querySelector('[data-alert]')) {
+                banner.remove();
+            }
         }
+        document.querySelectorAll('.alert-action').forEach(button => {
+            button.addEventListener('click', () => setAlertState(button.closest('[data-alert]'), button.dataset.state));
+        });
+
+        document.getElementById('save-view-btn').addEventListener('click', async () => {
+            const name = prompt('Name for this view (an existing view with the same name is replaced):');
+            if (!name) {
+                return;
+            }
+            const response = await fetch('/api/views', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ name: name, query: params.toString() }),
+            });
+            if (!response.ok) {
+                alert('Failed to save view: ' + await response.text());
+                return;
+            }
+            prompt('View saved. Share this link:', location.origin + '/v/' + encodeURIComponent(name.trim()));
+        });
+
+        document.getElementById('save-baseline-btn').addEventListener('click', async () => {
+            const name = prompt('Name for this baseline, e.g. a release (an existing baseline with the same name is replaced):');
+            if (!name) {
+                return;
+            }
+            const response = await fetch('/api/baselines', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ name: name, query: params.toString() }),
+            });
+            if (!response.ok) {
+                alert('Failed to save baseline: ' + await response.text());
+                return;
+            }
+            location.reload();
+        });
 
-        // Default sort by Avg Score descending
-        const headers = document.querySelectorAll('#comparison-table th');
-        headers.forEach((th, idx) => {
-            if (th.classList.contains('sorted-desc')) {
-                sortDirection[idx] = 'desc';
+        document.getElementById('copy-link-btn').addEventListener('click', async (e) => {
+            try {
+                await navigator.clipboard.writeText(location.href);
+                e.target.textContent = 'Copied!';
+                setTimeout(() => { e.target.textContent = 'Copy link'; }, 1500);
+            } catch (err) {
+                prompt('Copy this link:', location.href);
             }
         });
 
@@ -1080,8 +2253,10 @@ func dashboardHandler(w http.ResponseWriter, r *http.Request) {
 </body>
 </html>`
 
+	theme, labels := brandingFor(r.Context()), labelsFor(r.Context())
 	funcMap := template.FuncMap{
-		"add": func(a, b int) int { return a + b },
+		"percent": func(v float64) float64 { return v * 100 },
+		"formula": formulaOf,
 		"formatTemp": func(val interface{}) string {
 			if val == nil {
 				return "-"
@@ -1099,71 +2274,160 @@ func dashboardHandler(w http.ResponseWriter, r *http.Request) {
 				return fmt.Sprintf("%v", v)
 			}
 		},
-		"formatValue": func(val string) string {
-			// Try to parse as float
-			if parsed, err := strconv.ParseFloat(val, 64); err == nil {
-				// Round to 1 decimal place for temperatures
-				// Round to 0 decimals for integers
-				if parsed == float64(int64(parsed)) {
-					return fmt.Sprintf("%.0f", parsed)
-				}
-				return fmt.Sprintf("%.1f", parsed)
-			}
-			return val
+		"minTestsForCI":   func() int { return minTestsForCI },
+		"sparklineRuns":   func() int { return sparklineRuns },
+		"sparklineWidth":  func() int { return sparklineWidth },
+		"sparklineHeight": func() int { return sparklineHeight },
+		"ciMargin": func(stat ModelStat) float64 {
+			return (stat.CIHigh - stat.CILow) / 2
 		},
+		"human":        humanNumber,
+		"isCountField": isCountField,
+		"modelLabel":   modelLabel,
+		"statLabel":    labels.StatLabel,
+		"configLabel":  labels.LabelHTML,
+		"formatCost":   formatCost,
+		"div":          func(sum float64, n int) float64 { return sum / float64(n) },
+		"brand":        func() Branding { return theme },
+		"projects":     func() []ProjectLink { return projectsFor(r.Context()) },
+		"formatField":  formatField,
+		"isMixed":      func(name string) bool { return slices.Contains(data.MixedFields, name) },
+	}
+	columnPrefs := defaultColumns
+	if cols := r.URL.Query().Get("cols"); cols != "" {
+		columnPrefs = parseColumns(cols)
 	}
+	groups, sparklines := groupByModel(data, r.URL.Query().Get("group") != "none"), BuildSparklines(data.Results, sparklineRuns)
+	tableSort := sortDashboard(data, groups, sparklines, labels, parseTableSort(r.URL.Query(), dashboardSort))
+	page := struct {
+		DashboardData
+		Columns           []DashboardColumn
+		Filter            Query
+		FilterModels      []string
+		FilterRuns        []string
+		FilterSources     []string
+		FilterDatasets    []string
+		DatasetMix        []DatasetLink // Dataset versions when the results mix several
+		Baseline          *Baseline     // Baseline the averages are compared against, nil = none
+		Baselines         []Baseline    // Saved baselines, for the dropdown
+		FailingSources    []string
+		Weights           map[string]float64    // Active score weights, nil = combined as recorded
+		WeightsParam      string                // weights URL parameter, carried over to the tests page
+		SpotCheck         string                // Link to a random sample of the filtered results
+		Saturation        []Saturation          // Scores stuck at 0 or 1
+		LatencySLA        bool                  // Show the Within SLA column
+		RetrievedContexts bool                  // Show the context columns, some results have retrieved_contexts
+		Costs             bool                  // Show the Est. Cost column, the model registry has rates
+		Panels            []RenderedPanel       // Extra panels from --panels
+		Groups            []ModelGroup          // Table rows by model, flat with ?group=none
+		Alerts            AlertBanner           // Score drops nobody acknowledged yet
+		Coverage          CoverageGaps          // Configs scored on fewer tests than the others
+		Sparklines        map[string]*Sparkline // Score over the latest runs per config, nil = no config has two runs
+		Sort              TableSort             // Order of Groups and their configs, from ?sort= and ?dir=
+	}{data, orderColumns(data.CustomFieldNames, data.CustomScores, columnPrefs), filter, filterModels, filterRuns, filterSources, filterDatasets,
+		datasetLinks(r.URL.Query(), data.Datasets), requestBaseline(r), baselinesFor(r.Context()).List(), failingSources(r.Context()), weights, r.URL.Query().Get("weights"),
+		spotCheckURL(r.URL.Query(), "run_id", "source", "dataset", "weights"), DetectSaturation(data.Results), slasFor(r.Context()) != nil, hasContexts(data), hasCosts(data), renderPanels(data, statsAPIURL(r), labels),
+		groups, activeAlerts(alertStatesFor(r.Context()), data.Results), coverageGaps(data.Results), sparklines, tableSort}
+
+	_, renderSpan := startSpan(r.Context(), "template.render")
+	defer renderSpan.End()
 	t := template.Must(template.New("dashboard").Funcs(funcMap).Parse(tmpl))
-	if err := t.Execute(w, evalData); err != nil {
+	t = template.Must(t.Parse(brandingTemplates))
+	t = template.Must(t.Parse(projectTemplates))
+	if err := t.Execute(w, page); err != nil {
 		// Don't call http.Error here - headers already sent by Execute
 		log.Printf("Template error: %v", err)
+		renderSpan.RecordError(err)
 	}
 }
 
 func testsHandler(w http.ResponseWriter, r *http.Request) {
-	// Reload latest data from file
-	if err := reloadData(); err != nil {
-		log.Printf("Error reloading data: %v", err)
+	// Filter by model config or run_id if provided
+	// Uses the full config key (model + params) as shown in the comparison table
+	filter := Query{
+		ConfigKey: r.URL.Query().Get("model"),
+		RunID:     r.URL.Query().Get("run_id"),
+		TestID:    r.URL.Query().Get("test_id"),
+		Origin:    r.URL.Query().Get("source"),
+		Dataset:   r.URL.Query().Get("dataset"),
+	}
+	reviews := annotationsFor(r.Context())
+	search := strings.TrimSpace(r.URL.Query().Get("q"))
+	// Permalink of one test, /tests/{test_id}?run=<run_id>: its results in full, without the list
+	permalink := r.PathValue("test_id")
+	if permalink != "" {
+		filter.TestID = permalink
+		if run := r.URL.Query().Get("run"); run != "" {
+			filter.RunID = run
+		}
 	}
-
-	// Filter by model or run_id if provided
-	modelFilter := r.URL.Query().Get("model")
-	runIDFilter := r.URL.Query().Get("run_id")
 
 	var filteredResults []EvalResult
-	if modelFilter != "" || runIDFilter != "" {
-		for _, result := range evalData.Results {
-			// Use buildConfigKey to match the full config key (model + params)
-			configKey := buildConfigKey(result)
-			matchModel := modelFilter == "" || configKey == modelFilter
-
-			// Extract run_id from metadata
-			runID := ""
-			if result.Metadata != nil {
-				if rid, ok := result.Metadata["run_id"].(string); ok {
-					runID = rid
-				}
-			}
-			matchRunID := runIDFilter == "" || runID == runIDFilter
+	var err error
+	if search != "" {
+		// Full-text search, ranked by number of hits
+		filteredResults, err = searchStore(r.Context(), filter, search)
+	} else {
+		filteredResults, err = store.Query(r.Context(), filter)
+		// Sort by timestamp descending (newest first)
+		sort.Slice(filteredResults, func(i, j int) bool {
+			return filteredResults[i].Timestamp > filteredResults[j].Timestamp
+		})
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	filteredResults, ok := reweightRequest(w, r, filteredResults)
+	if !ok {
+		return
+	}
+	slaBreaches := r.URL.Query().Get("sla") == "breach"
+	if slaBreaches {
+		filteredResults = slasFor(r.Context()).filterBreaches(filteredResults)
+	}
+	hasErrors := slices.ContainsFunc(filteredResults, func(result EvalResult) bool { return result.Error != "" })
+	errorsOnly := r.URL.Query().Get("errors") == "only"
+	if errorsOnly {
+		filteredResults = slices.DeleteFunc(slices.Clone(filteredResults), func(result EvalResult) bool { return result.Error == "" })
+	}
+	// Reviewed results only, by label and/or decision, e.g. ?label=hallucination&review=reject
+	label, review := r.URL.Query().Get("label"), r.URL.Query().Get("review")
+	if label != "" || review != "" {
+		reviewed := reviews.Annotated(annotationFilter(review, "", label))
+		filteredResults = slices.DeleteFunc(slices.Clone(filteredResults), func(result EvalResult) bool { return !reviewed[resultID(result)] })
+	}
 
-			if matchModel && matchRunID {
-				filteredResults = append(filteredResults, result)
-			}
+	// Spot check: a random sample of the filtered results, e.g. ?sample=20&stratify=model&score_band=low
+	var sample *SampleSpec
+	population := len(filteredResults)
+	if r.URL.Query().Has("sample") {
+		spec, err := parseSampleSpec(r.URL.Query().Get("sample"), r.URL.Query().Get("stratify"), r.URL.Query().Get("score_band"), r.URL.Query().Get("seed"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
-	} else {
-		filteredResults = evalData.Results
+		sample = &spec
+		filteredResults = sampleResults(filteredResults, spec, sampleRand(spec.Seed))
 	}
 
-	// Sort by timestamp descending (newest first)
-	sort.Slice(filteredResults, func(i, j int) bool {
-		return filteredResults[i].Timestamp > filteredResults[j].Timestamp
-	})
+	// Column sort, e.g. ?sort=score&dir=desc; without one the newest (or best matching) come first
+	tableSort := parseTableSort(r.URL.Query(), TableSort{})
+	if _, ok := testsCell(EvalResult{}, tableSort.Key); ok {
+		sortRows(filteredResults, tableSort.Desc, func(result EvalResult) any {
+			v, _ := testsCell(result, tableSort.Key)
+			return v
+		})
+	} else {
+		tableSort = TableSort{}
+	}
 
 	tmpl := `<!DOCTYPE html>
 <html lang="en" data-theme="light">
 <head>
     <meta charset="UTF-8">
     <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>Test Results - GoEvals</title>
+    <title>{{ with .Permalink }}{{ . }} - {{ end }}Test Results - {{ brand.Name }}</title>
     <style>
         :root {
             --bg-primary: #ffffff;
@@ -1322,14 +2586,30 @@ func testsHandler(w http.ResponseWriter, r *http.Request) {
             color: var(--text-tertiary);
             border-bottom: 1px solid var(--border-color);
         }
-        tbody tr {
-            border-bottom: 1px solid var(--border-color);
-            cursor: pointer;
+        th a {
+            color: inherit;
+            text-decoration: none;
+        }
+        th.sorted-asc a::after {
+            content: ' ↑';
+            color: var(--accent);
+        }
+        th.sorted-desc a::after {
+            content: ' ↓';
+            color: var(--accent);
+        }
+        tbody tr {
+            border-bottom: 1px solid var(--border-color);
+            cursor: pointer;
             transition: background-color 0.15s ease;
         }
         tbody tr:hover {
             background: var(--bg-secondary);
         }
+        tbody tr.selected {
+            background: var(--bg-tertiary);
+            box-shadow: inset 3px 0 0 var(--accent);
+        }
         tbody tr:last-child {
             border-bottom: none;
         }
@@ -1368,11 +2648,49 @@ func testsHandler(w http.ResponseWriter, r *http.Request) {
             background: rgba(239, 68, 68, 0.1);
             color: var(--error);
         }
+        .tamper-warning {
+            margin-left: 0.25rem;
+            color: var(--error);
+            font-size: 0.75rem;
+            font-weight: 600;
+        }
+        .num-compact {
+            cursor: help;
+        }
         .time-badge {
             font-family: monospace;
             font-size: 0.8125rem;
             color: var(--text-tertiary);
         }
+        .time-badge.sla-breach {
+            color: var(--error);
+            font-weight: 600;
+        }
+        .error-badge {
+            background: var(--bg-tertiary);
+            color: var(--error);
+        }
+        .redacted-badge {
+            font-size: 0.75rem;
+            font-weight: 500;
+            padding: 0.125rem 0.375rem;
+            border-radius: 4px;
+            background: var(--bg-tertiary);
+            color: var(--text-secondary);
+            border: 1px dashed var(--border-color);
+        }
+        .error-content {
+            color: var(--error);
+        }
+        .sla-filter {
+            display: flex;
+            align-items: center;
+            gap: 0.375rem;
+            font-size: 0.875rem;
+            color: var(--text-secondary);
+            white-space: nowrap;
+            cursor: pointer;
+        }
         .modal {
             display: none;
             position: fixed;
@@ -1432,8 +2750,25 @@ func testsHandler(w http.ResponseWriter, r *http.Request) {
         .modal-body {
             padding: 1.5rem;
         }
+        .result-card {
+            max-width: none;
+            max-height: none;
+            overflow: visible;
+            box-shadow: none;
+            margin-bottom: 1.5rem;
+        }
+        .result-card-meta {
+            font-size: 0.8125rem;
+            font-weight: 400;
+            color: var(--text-tertiary);
+            margin-right: 0.5rem;
+        }
         .detail-section {
             margin-bottom: 1.5rem;
+            scroll-margin-top: 1rem;
+        }
+        .detail-section.jumped .detail-label {
+            color: var(--accent);
         }
         .detail-section:last-child {
             margin-bottom: 0;
@@ -1455,11 +2790,156 @@ func testsHandler(w http.ResponseWriter, r *http.Request) {
             white-space: pre-wrap;
             color: var(--text-primary);
         }
+        .attachments {
+            display: grid;
+            grid-template-columns: repeat(auto-fill, minmax(220px, 1fr));
+            gap: 0.75rem;
+        }
+        .attachment {
+            margin: 0;
+            padding: 0.5rem;
+            background: var(--bg-secondary);
+            border-radius: 8px;
+        }
+        .attachment img, .attachment video, .attachment audio {
+            display: block;
+            width: 100%;
+            max-height: 320px;
+            object-fit: contain;
+            border-radius: 4px;
+        }
+        .attachment figcaption, .attachment-missing {
+            font-size: 0.75rem;
+            color: var(--text-tertiary);
+            margin-top: 0.25rem;
+            overflow-wrap: anywhere;
+        }
+        .trace-step summary {
+            display: flex;
+            align-items: center;
+            gap: 0.75rem;
+            cursor: pointer;
+            font-size: 0.8125rem;
+            padding: 0.3rem 0;
+        }
+        .trace-name {
+            flex: 0 0 12rem;
+            font-family: monospace;
+            color: var(--text-primary);
+            overflow: hidden;
+            text-overflow: ellipsis;
+            white-space: nowrap;
+        }
+        .trace-track {
+            position: relative;
+            flex: 1;
+            height: 0.75rem;
+            background: var(--bg-tertiary);
+            border-radius: 3px;
+        }
+        .trace-bar {
+            position: absolute;
+            top: 0;
+            bottom: 0;
+            background: var(--accent);
+            border-radius: 3px;
+        }
+        .trace-error .trace-bar {
+            background: var(--error);
+        }
+        .trace-error .trace-name {
+            color: var(--error);
+        }
+        .trace-ms {
+            flex: 0 0 4.5rem;
+            text-align: right;
+            font-family: monospace;
+            color: var(--text-tertiary);
+        }
+        .trace-io-label {
+            font-size: 0.75rem;
+            color: var(--text-tertiary);
+            margin: 0.5rem 0 0.25rem;
+        }
+        .context-chunk {
+            margin-bottom: 0.5rem;
+        }
+        .context-chunk summary {
+            cursor: pointer;
+            font-size: 0.8125rem;
+            color: var(--text-secondary);
+            padding: 0.25rem 0;
+        }
+        .context-chars {
+            float: right;
+            color: var(--text-tertiary);
+        }
         .scores-grid {
             display: grid;
             grid-template-columns: repeat(auto-fit, minmax(200px, 1fr));
             gap: 0.75rem;
         }
+        .recipe-formula {
+            color: var(--text-tertiary);
+            font-size: 0.8125rem;
+            margin-bottom: 0.5rem;
+        }
+        .recipe-bar {
+            position: relative;
+            display: flex;
+            height: 1.25rem;
+            background: var(--bg-tertiary);
+            border-radius: 4px;
+            overflow: hidden;
+            margin-bottom: 0.75rem;
+        }
+        .recipe-segment {
+            height: 100%;
+            background: currentColor;
+        }
+        .recipe-marker {
+            position: absolute;
+            top: 0;
+            bottom: 0;
+            width: 2px;
+            margin-left: -1px;
+            background: var(--text-primary);
+        }
+        .recipe-swatch {
+            display: inline-block;
+            width: 0.75rem;
+            height: 0.75rem;
+            border-radius: 2px;
+            margin-right: 0.5rem;
+            vertical-align: middle;
+            background: currentColor;
+        }
+        .recipe-table td {
+            padding: 0.2rem 1rem 0.2rem 0;
+            font-size: 0.8125rem;
+            font-family: monospace;
+            border: none;
+        }
+        .recipe-total td {
+            border-top: 1px solid var(--border-color);
+            font-weight: 600;
+        }
+        .recipe-lost {
+            color: var(--text-tertiary);
+        }
+        .recipe-gap {
+            margin-top: 0.75rem;
+            color: var(--warning);
+            font-size: 0.8125rem;
+        }
+        .series-1 { color: #3b82f6; }
+        .series-2 { color: #10b981; }
+        .series-3 { color: #f59e0b; }
+        .series-4 { color: #ef4444; }
+        .series-5 { color: #8b5cf6; }
+        .series-6 { color: #ec4899; }
+        .series-7 { color: #14b8a6; }
+        .series-8 { color: #64748b; }
         .score-item {
             padding: 0.75rem;
             background: var(--bg-primary);
@@ -1495,6 +2975,188 @@ func testsHandler(w http.ResponseWriter, r *http.Request) {
             color: var(--text-primary);
             margin-left: 0.5rem;
         }
+        .search-bar {
+            display: flex;
+            gap: 0.5rem;
+            margin-bottom: 1.5rem;
+        }
+        .search-bar input[type="search"] {
+            flex: 1;
+            background: var(--bg-primary);
+            border: 1px solid var(--border-color);
+            color: var(--text-primary);
+            padding: 0.5rem 0.75rem;
+            border-radius: 6px;
+            font-size: 0.875rem;
+        }
+        .search-bar input[type="search"]:focus {
+            outline: none;
+            border-color: var(--accent);
+        }
+        .search-bar button {
+            background: var(--accent);
+            border: none;
+            color: white;
+            padding: 0.5rem 1rem;
+            border-radius: 6px;
+            cursor: pointer;
+            font-size: 0.875rem;
+            font-weight: 500;
+        }
+        .search-bar button:hover {
+            background: var(--accent-hover);
+        }
+        .search-bar select {
+            background: var(--bg-primary);
+            border: 1px solid var(--border-color);
+            color: var(--text-primary);
+            padding: 0.5rem 0.75rem;
+            border-radius: 6px;
+            font-size: 0.875rem;
+            max-width: 20rem;
+        }
+        .source-link {
+            color: var(--accent);
+            font-family: 'Monaco', 'Courier New', monospace;
+            font-size: 0.8125rem;
+            text-decoration: none;
+            word-break: break-all;
+        }
+        .source-link:hover {
+            text-decoration: underline;
+        }
+        .search-snippet {
+            color: var(--text-secondary);
+            font-size: 0.8125rem;
+            margin-top: 0.25rem;
+            white-space: normal;
+        }
+        .search-snippet .snippet-field {
+            color: var(--text-tertiary);
+            font-family: 'Monaco', 'Courier New', monospace;
+            font-size: 0.75rem;
+        }
+        .review-list {
+            list-style: none;
+            margin-bottom: 0.75rem;
+            font-size: 0.875rem;
+            color: var(--text-secondary);
+        }
+        .review-list li {
+            padding: 0.25rem 0;
+        }
+        .review-decision {
+            font-weight: 600;
+            text-transform: uppercase;
+            font-size: 0.75rem;
+            margin-right: 0.25rem;
+        }
+        .review-accept { color: var(--success); }
+        .review-reject { color: var(--error); }
+        .review-unsure { color: var(--warning); }
+        .review-label {
+            background: var(--bg-tertiary);
+            border-radius: 4px;
+            padding: 0 0.35rem;
+            font-size: 0.75rem;
+        }
+        .review-meta {
+            color: var(--text-tertiary);
+            font-size: 0.75rem;
+        }
+        .review textarea, .review input[type="text"], .bulk-bar input[type="text"] {
+            width: 100%;
+            background: var(--bg-primary);
+            border: 1px solid var(--border-color);
+            color: var(--text-primary);
+            padding: 0.5rem 0.75rem;
+            border-radius: 6px;
+            font-size: 0.875rem;
+            font-family: inherit;
+        }
+        .select-cell {
+            width: 2.5rem;
+            cursor: default;
+        }
+        .bulk-bar {
+            position: sticky;
+            bottom: 1rem;
+            display: flex;
+            align-items: center;
+            gap: 0.5rem;
+            margin-top: 1rem;
+            padding: 0.75rem 1rem;
+            background: var(--bg-primary);
+            border: 1px solid var(--accent);
+            border-radius: 12px;
+            box-shadow: var(--shadow-md);
+        }
+        .bulk-bar[hidden] {
+            display: none;
+        }
+        .bulk-bar input[type="text"] {
+            flex: 1;
+        }
+        #bulk-count {
+            font-size: 0.875rem;
+            font-weight: 600;
+            white-space: nowrap;
+        }
+        .review-actions {
+            display: flex;
+            gap: 0.5rem;
+            margin-top: 0.5rem;
+        }
+        .review-actions input[type="text"] {
+            flex: 1;
+        }
+        .text-tools {
+            float: right;
+            display: flex;
+            gap: 0.375rem;
+            text-transform: none;
+            letter-spacing: normal;
+        }
+        .context-chunk .text-tools {
+            float: none;
+            justify-content: flex-end;
+            margin-top: 0.375rem;
+        }
+        .text-btn {
+            background: var(--bg-tertiary);
+            border: 1px solid var(--border-color);
+            color: var(--text-secondary);
+            padding: 0.125rem 0.5rem;
+            border-radius: 4px;
+            cursor: pointer;
+            font-size: 0.75rem;
+            font-weight: 500;
+        }
+        .text-btn:hover {
+            border-color: var(--accent);
+            color: var(--accent);
+        }
+        .review-btn {
+            background: var(--bg-tertiary);
+            border: 1px solid var(--border-color);
+            color: var(--text-secondary);
+            padding: 0.4rem 0.75rem;
+            border-radius: 6px;
+            cursor: pointer;
+            font-size: 0.8125rem;
+            font-weight: 500;
+            white-space: nowrap;
+        }
+        .review-btn:hover {
+            border-color: var(--accent);
+            color: var(--accent);
+        }
+        mark {
+            background: rgba(245, 158, 11, 0.35);
+            color: inherit;
+            border-radius: 2px;
+        }
+        {{ template "brand-style" }}
     </style>
 </head>
 <body>
@@ -1503,10 +3165,17 @@ func testsHandler(w http.ResponseWriter, r *http.Request) {
 
         <header>
             <div class="header-left">
-                <h1>Test Results {{ if . }}({{ len . }} tests){{ end }}</h1>
-                <p class="subtitle">Click on any test to see full details</p>
+                {{ if .Permalink }}
+                <h1>{{ template "brand-logo" }}Test {{ .Permalink }}</h1>
+                <p class="subtitle">{{ human (len .Results) }} result{{ if ne (len .Results) 1 }}s{{ end }}{{ with .RunID }} from run {{ . }}{{ end }} - <a href="/tests?test_id={{ .Permalink }}" class="source-link">all results for this test</a>{{ if .Results }} - <a href="/compare?test_id={{ .Permalink }}" class="source-link">compare models</a>{{ end }}</p>
+                {{ else }}
+                <h1>{{ template "brand-logo" }}Test Results {{ if .Results }}({{ human (len .Results) }} tests){{ end }}</h1>
+                <p class="subtitle">{{ if .Search }}Matching "{{ .Search }}" - {{ end }}{{ if .SLABreaches }}Latency SLA breaches - {{ end }}{{ if .ErrorsOnly }}Errored results - {{ end }}{{ with .Sample }}Random sample of {{ human (len $.Results) }} from {{ human $.Population }} results{{ with .Stratify }}, stratified by {{ . }}{{ end }}{{ with .ScoreBand }}, {{ . }} scores only{{ end }} (seed {{ .Seed }}) - {{ end }}Click on any test to see full details</p>
+                {{ end }}
             </div>
             <div class="header-right">
+                {{- template "project-switcher" }}
+                <a href="{{ .SpotCheck }}" class="help-btn" style="text-decoration: none;" title="Open {{ if .Sample }}another{{ else }}a{{ end }} random sample for quick qualitative review">{{ if .Sample }}Reshuffle{{ else }}Spot check{{ end }}</a>
                 <button id="theme-toggle" class="theme-toggle">
                     <span id="theme-icon">Dark</span>
                 </button>
@@ -1520,86 +3189,487 @@ func testsHandler(w http.ResponseWriter, r *http.Request) {
                 <table>
                     <tr><td>D</td><td>Toggle dark mode</td></tr>
                     <tr><td>R</td><td>Refresh page</td></tr>
+                    <tr><td>/</td><td>Search</td></tr>
+                    <tr><td>J / K</td><td>Next / previous test</td></tr>
+                    <tr><td>Enter</td><td>Open test details</td></tr>
+                    <tr><td>X</td><td>Select test for bulk review</td></tr>
+                    <tr><td>← / →</td><td>Previous / next test in details</td></tr>
+                    <tr><td>1-9</td><td>Jump to a section of the details</td></tr>
                     <tr><td>?</td><td>Show this help</td></tr>
-                    <tr><td>Esc</td><td>Close help</td></tr>
+                    <tr><td>Esc</td><td>Close help or details</td></tr>
                 </table>
             </div>
         </div>
 
+        {{ if .Permalink }}
+        {{ range .Results }}
+        <div class="modal-content result-card" data-result-id="{{ resultID . }}">
+            <div class="modal-header">
+                <div class="modal-title">{{ .Model }}{{ with index .Metadata "run_id" }} <span class="result-card-meta">run {{ . }}</span>{{ end }}{{ if tampered . }} <span class="tamper-warning" title="Content changed after ingest - sha256 does not match">⚠ tampered</span>{{ end }}{{ if .Redacted }} <span class="redacted-badge" title="Sensitive text masked: {{ .RedactedNames }}">redacted</span>{{ end }}</div>
+                <div>
+                    <span class="result-card-meta">{{ .Timestamp }}</span>
+                    <span class="time-badge{{ if breachesSLA . }} sla-breach{{ end }}">{{ .ResponseTimeMS }}ms</span>
+                    {{ if .Error }}<span class="score-badge error-badge">error</span>{{ else }}<span class="score-badge {{ if ge .Scores.Combined 0.7 }}score-good{{ else if ge .Scores.Combined 0.5 }}score-fair{{ else }}score-poor{{ end }}">{{ printf "%.2f" .Scores.Combined }}</span>{{ end }}
+                </div>
+            </div>
+            <div class="modal-body">
+                {{ template "result-detail" . }}
+            </div>
+        </div>
+        {{ else }}
+        <div class="tests-table" style="padding: 2rem; text-align: center; color: var(--text-secondary);">No results for test {{ .Permalink }}{{ with .RunID }} in run {{ . }}{{ end }}</div>
+        {{ end }}
+        {{ template "brand-footer" }}
+        {{ else }}
+        <form class="search-bar" method="get" action="/tests">
+            {{ if .Model }}<input type="hidden" name="model" value="{{ .Model }}">{{ end }}
+            {{ if .RunID }}<input type="hidden" name="run_id" value="{{ .RunID }}">{{ end }}
+            {{ if .Dataset }}<input type="hidden" name="dataset" value="{{ .Dataset }}">{{ end }}
+            {{ if gt (len .Sources) 1 }}
+            <select name="source" onchange="this.form.submit()">
+                <option value="">All sources</option>
+                {{ range .Sources }}<option value="{{ . }}" {{ if eq . $.Source }}selected{{ end }}>{{ . }}</option>{{ end }}
+            </select>
+            {{ end }}
+            {{ if .LatencySLA }}
+            <label class="sla-filter" title="Only results slower than their model's latency SLA"><input type="checkbox" name="sla" value="breach" {{ if .SLABreaches }}checked{{ end }} onchange="this.form.submit()"> SLA breaches</label>
+            {{ end }}
+            {{ if or .HasErrors .ErrorsOnly }}
+            <label class="sla-filter" title="Only results with an error (timeouts, refusals, API errors)"><input type="checkbox" name="errors" value="only" {{ if .ErrorsOnly }}checked{{ end }} onchange="this.form.submit()"> Errors only</label>
+            {{ end }}
+            {{ if .Labels }}
+            <select name="label" onchange="this.form.submit()" title="Only results reviewed with this label">
+                <option value="">All labels</option>
+                {{ range .Labels }}<option value="{{ . }}" {{ if eq . $.Label }}selected{{ end }}>{{ . }}</option>{{ end }}
+            </select>
+            {{ end }}
+            <select name="review" onchange="this.form.submit()" title="Only results with this review decision">
+                <option value="">Any review</option>
+                {{ range reviewDecisions }}<option value="{{ . }}" {{ if eq . $.Review }}selected{{ end }}>{{ . }}</option>{{ end }}
+            </select>
+            <input type="search" id="search" name="q" value="{{ .Search }}" placeholder='Search questions, responses and judge reasoning - "exact phrase", prefix*'>
+            <button type="submit">Search</button>
+        </form>
+
         <div class="tests-table">
             <table>
                 <thead>
                     <tr>
-                        <th>Test ID</th>
-                        <th>Model</th>
-                        <th>Question</th>
-                        <th>Score</th>
-                        <th>Time</th>
+                        <th class="select-cell"><input type="checkbox" id="select-all" title="Select all"></th>
+                        <th class="{{ .Sort.Class "test_id" }}"><a href="{{ sortURL "test_id" }}">Test ID</a></th>
+                        <th class="{{ .Sort.Class "model" }}"><a href="{{ sortURL "model" }}">Model</a></th>
+                        <th class="{{ .Sort.Class "question" }}"><a href="{{ sortURL "question" }}">Question</a></th>
+                        <th class="{{ .Sort.Class "score" }}"><a href="{{ sortURL "score" }}">Score</a></th>
+                        <th class="{{ .Sort.Class "time" }}"><a href="{{ sortURL "time" }}">Time</a></th>
                     </tr>
                 </thead>
                 <tbody>
-                    {{ range $index, $result := . }}
-                    <tr onclick="showTestModal({{ $index }})">
-                        <td class="test-id">{{ $result.TestID }}</td>
+                    {{ range $index, $result := .Results }}
+                    <tr data-index="{{ $index }}">
+                        <td class="select-cell" onclick="event.stopPropagation()"><input type="checkbox" class="select-row" value="{{ resultID $result }}"></td>
+                        <td class="test-id">{{ $result.TestID }}{{ if tampered $result }} <span class="tamper-warning" title="Content changed after ingest - sha256 does not match">⚠ tampered</span>{{ end }}{{ if $result.Redacted }} <span class="redacted-badge" title="Sensitive text masked: {{ $result.RedactedNames }}">redacted</span>{{ end }}</td>
                         <td class="model-name">{{ $result.Model }}</td>
-                        <td style="max-width: 300px; overflow: hidden; text-overflow: ellipsis; white-space: nowrap;">{{ $result.Question }}</td>
+                        <td style="max-width: 300px; overflow: hidden; text-overflow: ellipsis; white-space: nowrap;">{{ short $result.Question }}{{ with snippet $result }}<div class="search-snippet">{{ . }}</div>{{ end }}</td>
                         <td>
+                            {{ if $result.Error }}<span class="score-badge error-badge" title="{{ $result.Error }}">error</span>{{ else }}
                             <span class="score-badge {{ if ge $result.Scores.Combined 0.7 }}score-good{{ else if ge $result.Scores.Combined 0.5 }}score-fair{{ else }}score-poor{{ end }}">
                                 {{ printf "%.2f" $result.Scores.Combined }}
-                            </span>
+                            </span>{{ end }}
                         </td>
-                        <td class="time-badge">{{ $result.ResponseTimeMS }}ms</td>
+                        <td class="time-badge{{ if breachesSLA $result }} sla-breach{{ end }}"{{ if breachesSLA $result }} title="Over the {{ sla $result }}ms latency SLA"{{ end }}>{{ $result.ResponseTimeMS }}ms</td>
                     </tr>
                     {{ end }}
                 </tbody>
             </table>
         </div>
+        <div id="bulk-bar" class="bulk-bar" hidden>
+            <span id="bulk-count"></span>
+            <input type="text" id="bulk-labels" placeholder="Labels, comma separated">
+            <button class="bulk-btn review-btn" data-decision="accept">Accept</button>
+            <button class="bulk-btn review-btn" data-decision="reject">Reject</button>
+            <button class="bulk-btn review-btn" data-decision="unsure">Unsure</button>
+            <button class="bulk-btn review-btn" data-decision="">Label</button>
+            <button id="bulk-clear" class="review-btn">Clear</button>
+        </div>
+        {{ template "brand-footer" }}
 
-        {{ range $index, $result := . }}
-        <div id="modal-{{ $index }}" class="modal">
+        {{ range $index, $result := .Results }}
+        <div id="modal-{{ $index }}" class="modal" data-result-id="{{ resultID $result }}">
             <div class="modal-content">
                 <div class="modal-header">
-                    <div class="modal-title">{{ $result.TestID }}</div>
-                    <button class="modal-close" onclick="closeTestModal({{ $index }})">&times;</button>
+                    <div class="modal-title">{{ $result.TestID }}{{ if $result.Redacted }} <span class="redacted-badge" title="Sensitive text masked: {{ $result.RedactedNames }}">redacted</span>{{ end }}</div>
+                    {{ if $result.TestID }}<a href="{{ permalink $result }}" class="back-link" style="margin: 0 1rem 0 auto;" title="Link to this result on its own page">Permalink</a><a href="/compare?test_id={{ $result.TestID }}" class="back-link" style="margin: 0 1rem 0 0;">Compare models →</a>{{ end }}
+                    <button class="modal-close">&times;</button>
                 </div>
                 <div class="modal-body">
-                    <div class="detail-section">
-                        <div class="detail-label">Question</div>
-                        <div class="detail-content">{{ $result.Question }}</div>
-                    </div>
+                    {{ template "result-detail" $result }}
+                </div>
+            </div>
+        </div>
+        {{ end }}
+        {{ end }}
+    </div>
+    <script>
+        // Dark mode toggle
+        const themeToggle = document.getElementById('theme-toggle');
+        const themeIcon = document.getElementById('theme-icon');
+        const html = document.documentElement;
+        const savedTheme = localStorage.getItem('theme') || 'light';
+        html.setAttribute('data-theme', savedTheme);
+        themeIcon.textContent = savedTheme === 'light' ? 'Dark' : 'Light';
 
-                    <div class="detail-section">
-                        <div class="detail-label">Model Response</div>
-                        <div class="detail-content">{{ if $result.Response }}{{ $result.Response }}{{ else }}<em style="color: #9ca3af;">No response recorded</em>{{ end }}</div>
-                    </div>
+        themeToggle.addEventListener('click', () => {
+            const currentTheme = html.getAttribute('data-theme');
+            const newTheme = currentTheme === 'light' ? 'dark' : 'light';
+            html.setAttribute('data-theme', newTheme);
+            localStorage.setItem('theme', newTheme);
+            themeIcon.textContent = newTheme === 'light' ? 'Dark' : 'Light';
+        });
+
+        // Help modal
+        const helpBtn = document.getElementById('help-btn');
+        const helpModal = document.getElementById('help-modal');
+
+        helpBtn.addEventListener('click', () => {
+            helpModal.classList.add('show');
+        });
+
+        helpModal.addEventListener('click', (e) => {
+            if (e.target === helpModal) {
+                helpModal.classList.remove('show');
+            }
+        });
+
+        // Keyboard shortcuts
+        document.addEventListener('keydown', (e) => {
+            if (e.target.tagName === 'INPUT' || e.target.tagName === 'TEXTAREA') {
+                if (e.key === 'Escape') e.target.blur();
+                return;
+            }
+            if (e.ctrlKey || e.metaKey || e.altKey) {
+                return;
+            }
+            if (e.key === '/') {
+                e.preventDefault();
+                document.getElementById('search')?.focus();
+                return;
+            }
+            if (e.target.tagName !== 'BUTTON' && e.target.tagName !== 'A' && reviewKey(e.key)) {
+                e.preventDefault();
+                return;
+            }
+            if (e.key === 'd' || e.key === 'D') {
+                e.preventDefault();
+                themeToggle.click();
+            }
+            if (e.key === '?') {
+                e.preventDefault();
+                helpModal.classList.add('show');
+            }
+            if (e.key === 'r' || e.key === 'R') {
+                e.preventDefault();
+                location.reload();
+            }
+            if (e.key === 'Escape') {
+                helpModal.classList.remove('show');
+                // Close all test modals on Escape
+                document.querySelectorAll('.modal').forEach(modal => {
+                    modal.classList.remove('show');
+                });
+            }
+        });
+
+        // Review decisions are saved per result; the reviewer name is remembered in this browser
+        function reviewerName() {
+            let reviewer = localStorage.getItem('reviewer');
+            if (reviewer === null) {
+                reviewer = (prompt('Your name (recorded with your reviews):') || '').trim();
+                localStorage.setItem('reviewer', reviewer);
+            }
+            return reviewer;
+        }
+
+        document.querySelectorAll('.review .review-btn').forEach(btn => {
+            btn.addEventListener('click', async () => {
+                const section = btn.closest('.review');
+                const reviewer = reviewerName();
+                const resp = await fetch('/api/annotations', {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify({
+                        result_id: section.dataset.resultId,
+                        decision: btn.dataset.decision,
+                        labels: section.querySelector('.review-labels').value.split(','),
+                        note: section.querySelector('.review-note').value,
+                        reviewer: reviewer,
+                    }),
+                });
+                if (!resp.ok) {
+                    alert('Failed to save review: ' + await resp.text());
+                    return;
+                }
+                const a = await resp.json();
+                const li = document.createElement('li');
+                li.textContent = (a.decision || 'note').toUpperCase() + ' ' + (a.labels || []).join(', ') + ' ' + (a.note || '');
+                section.querySelector('.review-list').appendChild(li);
+                section.querySelector('.review-note').value = '';
+                section.querySelector('.review-labels').value = '';
+            });
+        });
+
+        // Bulk review: the same decision and labels for every checked result
+        const bulkBar = document.getElementById('bulk-bar');
+        const selectAll = document.getElementById('select-all');
+        const rowBoxes = Array.from(document.querySelectorAll('.select-row'));
+
+        function updateBulkBar() {
+            const checked = rowBoxes.filter(box => box.checked).length;
+            bulkBar.hidden = checked === 0;
+            document.getElementById('bulk-count').textContent = checked + ' selected';
+            selectAll.checked = checked > 0 && checked === rowBoxes.length;
+            selectAll.indeterminate = checked > 0 && checked < rowBoxes.length;
+        }
+
+        if (bulkBar) {
+            rowBoxes.forEach(box => box.addEventListener('change', updateBulkBar));
+            selectAll.addEventListener('change', () => {
+                rowBoxes.forEach(box => { box.checked = selectAll.checked; });
+                updateBulkBar();
+            });
+            document.getElementById('bulk-clear').addEventListener('click', () => {
+                rowBoxes.forEach(box => { box.checked = false; });
+                updateBulkBar();
+            });
+            document.querySelectorAll('.bulk-btn').forEach(btn => {
+                btn.addEventListener('click', async () => {
+                    const ids = rowBoxes.filter(box => box.checked).map(box => box.value);
+                    const resp = await fetch('/api/annotations/bulk', {
+                        method: 'POST',
+                        headers: { 'Content-Type': 'application/json' },
+                        body: JSON.stringify({
+                            result_ids: ids,
+                            decision: btn.dataset.decision,
+                            labels: document.getElementById('bulk-labels').value.split(','),
+                            reviewer: reviewerName(),
+                        }),
+                    });
+                    if (!resp.ok) {
+                        alert('Failed to save reviews: ' + await resp.text());
+                        return;
+                    }
+                    location.reload();
+                });
+            });
+        }
+
+        // Long texts are cut on this page, the full result is fetched once per modal when needed
+        const fullResults = {};
+        async function fullText(modal, field) {
+            const id = modal.dataset.resultId;
+            if (!fullResults[id]) {
+                const response = await fetch('/api/tests/' + encodeURIComponent(id) + '/full');
+                if (!response.ok) {
+                    throw new Error(await response.text());
+                }
+                fullResults[id] = await response.json();
+            }
+            const result = fullResults[id];
+            if (field.startsWith('context:')) {
+                const chunk = (result.retrieved_contexts || [])[Number(field.slice('context:'.length))];
+                return chunk ? chunk.text : '';
+            }
+            return result[field] || '';
+        }
+
+        document.querySelectorAll('.text-btn').forEach(btn => {
+            btn.addEventListener('click', async () => {
+                const modal = btn.closest('.modal, .result-card');
+                const content = modal.querySelector('.detail-content[data-field="' + btn.dataset.field + '"]');
+                const label = btn.textContent;
+                try {
+                    const text = content.hasAttribute('data-truncated') ? await fullText(modal, btn.dataset.field) : content.textContent;
+                    if (btn.dataset.action === 'expand') {
+                        content.textContent = text;
+                        content.removeAttribute('data-truncated');
+                        btn.remove();
+                        return;
+                    }
+                    await navigator.clipboard.writeText(text);
+                    btn.textContent = 'Copied';
+                } catch (err) {
+                    btn.textContent = 'Failed';
+                }
+                setTimeout(() => { btn.textContent = label; }, 1500);
+            });
+        });
+
+        // Review navigation: J/K select a row and Enter opens it; with details open, the arrows
+        // step to the previous/next test and 1-9 jump to its sections
+        const testRows = Array.from(document.querySelectorAll('.tests-table tbody tr'));
+        let selectedRow = -1;
+
+        function selectRow(index) {
+            selectedRow = Math.max(0, Math.min(testRows.length - 1, index));
+            testRows.forEach((row, i) => row.classList.toggle('selected', i === selectedRow));
+            testRows[selectedRow].scrollIntoView({ block: 'nearest' });
+        }
+
+        function reviewKey(key) {
+            key = key.length === 1 ? key.toLowerCase() : key;
+            if (testRows.length === 0) {
+                return false;
+            }
+            const open = document.querySelector('.modal.show');
+            if (open) {
+                const index = Number(open.id.slice('modal-'.length));
+                if (key === 'ArrowRight' || key === 'j' || key === 'ArrowLeft' || key === 'k') {
+                    const next = index + (key === 'ArrowRight' || key === 'j' ? 1 : -1);
+                    if (next >= 0 && next < testRows.length) {
+                        closeTestModal(index);
+                        showTestModal(next);
+                    }
+                    return true;
+                }
+                if (key >= '1' && key <= '9') {
+                    const section = open.querySelectorAll('.modal-body > .detail-section')[Number(key) - 1];
+                    if (section) {
+                        section.scrollIntoView({ block: 'start', behavior: 'smooth' });
+                        section.classList.add('jumped');
+                        setTimeout(() => section.classList.remove('jumped'), 1000);
+                    }
+                    return true;
+                }
+                return false;
+            }
+            if (key === 'j' || key === 'k') {
+                selectRow(selectedRow < 0 ? 0 : selectedRow + (key === 'j' ? 1 : -1));
+                return true;
+            }
+            if (key === 'Enter' && selectedRow >= 0) {
+                showTestModal(selectedRow);
+                return true;
+            }
+            if (key === 'x' && selectedRow >= 0) {
+                const box = testRows[selectedRow].querySelector('.select-row');
+                box.checked = !box.checked;
+                updateBulkBar();
+                return true;
+            }
+            return false;
+        }
+
+        // Modal functions
+        function showTestModal(index) {
+            const modal = document.getElementById('modal-' + index);
+            modal.classList.add('show');
+            modal.querySelector('.modal-content').scrollTop = 0;
+            selectRow(index);
+        }
+
+        function closeTestModal(index) {
+            const modal = document.getElementById('modal-' + index);
+            modal.classList.remove('show');
+        }
+
+        testRows.forEach(row => row.addEventListener('click', () => showTestModal(Number(row.dataset.index))));
+        document.querySelectorAll('.modal-close').forEach(button => {
+            button.addEventListener('click', () => button.closest('.modal').classList.remove('show'));
+        });
+
+        // Close modal when clicking outside
+        document.addEventListener('click', (e) => {
+            if (e.target.classList.contains('modal')) {
+                e.target.classList.remove('show');
+            }
+        });
+    </script>
+</body>
+</html>{{ define "result-detail" }}{{ $result := . }}
+                    {{ if $result.Error }}
+                    <div class="detail-section">
+                        <div class="detail-label">Error</div>
+                        <div class="detail-content error-content">{{ display $result.Error }}</div>
+                    </div>
+                    {{ end }}
+
+                    {{ $question := clip $result.Question }}
+                    <div class="detail-section">
+                        <div class="detail-label">Question{{ if $result.Question }}<span class="text-tools">{{ if $question.Truncated }}<button class="text-btn" data-action="expand" data-field="question">Show all {{ human $question.Chars }} chars</button>{{ end }}<button class="text-btn" data-action="copy" data-field="question">Copy</button></span>{{ end }}</div>
+                        <div class="detail-content" data-field="question"{{ if $question.Truncated }} data-truncated{{ end }}>{{ $question.Text }}{{ if $question.Truncated }}…{{ end }}</div>
+                    </div>
+
+                    {{ $response := clip $result.Response }}
+                    <div class="detail-section">
+                        <div class="detail-label">Model Response{{ if $result.Response }}<span class="text-tools">{{ if $response.Truncated }}<button class="text-btn" data-action="expand" data-field="response">Show all {{ human $response.Chars }} chars</button>{{ end }}<button class="text-btn" data-action="copy" data-field="response">Copy</button></span>{{ end }}</div>
+                        <div class="detail-content" data-field="response"{{ if $response.Truncated }} data-truncated{{ end }}>{{ if $result.Response }}{{ $response.Text }}{{ if $response.Truncated }}…{{ end }}{{ else }}<em style="color: #9ca3af;">No response recorded</em>{{ end }}</div>
+                    </div>
 
                     {{ if $result.Expected }}
+                    {{ $expected := clip $result.Expected }}
                     <div class="detail-section">
-                        <div class="detail-label">Expected Response</div>
-                        <div class="detail-content">{{ $result.Expected }}</div>
+                        <div class="detail-label">Expected Response<span class="text-tools">{{ if $expected.Truncated }}<button class="text-btn" data-action="expand" data-field="expected">Show all {{ human $expected.Chars }} chars</button>{{ end }}<button class="text-btn" data-action="copy" data-field="expected">Copy</button></span></div>
+                        <div class="detail-content" data-field="expected"{{ if $expected.Truncated }} data-truncated{{ end }}>{{ $expected.Text }}{{ if $expected.Truncated }}…{{ end }}</div>
                     </div>
                     {{ end }}
 
-                    {{ if $result.JudgeModel }}
+                    {{ with $result.Reasoning }}
                     <div class="detail-section">
-                        <div class="detail-label">Judge Evaluation ({{ $result.JudgeModel }})</div>
-                        {{ if $result.JudgeFactualReasoning }}
-                        <div style="margin-bottom: 0.75rem;">
-                            <div style="font-weight: 600; color: var(--text-tertiary); font-size: 0.75rem; margin-bottom: 0.25rem; text-transform: uppercase;">Factual Correctness</div>
-                            <div class="detail-content">{{ $result.JudgeFactualReasoning }}</div>
+                        <div class="detail-label">Judge Evaluation{{ if $result.JudgeModel }} ({{ $result.JudgeModel }}){{ end }}</div>
+                        {{ range $i, $note := . }}
+                        <div{{ if $i }} style="margin-top: 0.75rem;"{{ end }}>
+                            <div style="font-weight: 600; color: var(--text-tertiary); font-size: 0.75rem; margin-bottom: 0.25rem; text-transform: uppercase;">{{ $note.Label }}{{ if $note.HasValue }} · {{ printf "%.3f" $note.Value }}{{ end }}</div>
+                            <div class="detail-content">{{ display $note.Text }}</div>
                         </div>
                         {{ end }}
-                        {{ if $result.JudgeFaithfulReasoning }}
-                        <div style="margin-bottom: 0.75rem;">
-                            <div style="font-weight: 600; color: var(--text-tertiary); font-size: 0.75rem; margin-bottom: 0.25rem; text-transform: uppercase;">Faithfulness</div>
-                            <div class="detail-content">{{ $result.JudgeFaithfulReasoning }}</div>
+                    </div>
+                    {{ end }}
+
+                    {{ with $result.Attachments }}
+                    <div class="detail-section">
+                        <div class="detail-label">Attachments ({{ len . }})</div>
+                        <div class="attachments">
+                            {{ range . }}
+                            <figure class="attachment">
+                                {{ $url := mediaURL . }}
+                                {{ if not $url }}<div class="attachment-missing" title="Not an image, audio or video file, outside --media-dir, or --media-dir not set">Unavailable</div>
+                                {{ else if eq .Kind "image" }}<a href="{{ $url }}" target="_blank"><img src="{{ $url }}" alt="{{ .Label }}" loading="lazy"></a>
+                                {{ else if eq .Kind "audio" }}<audio controls preload="none" src="{{ $url }}"></audio>
+                                {{ else }}<video controls preload="metadata" src="{{ $url }}"></video>{{ end }}
+                                <figcaption>{{ .Label }}</figcaption>
+                            </figure>
+                            {{ end }}
                         </div>
+                    </div>
+                    {{ end }}
+
+                    {{ with $result.Timeline }}
+                    <div class="detail-section">
+                        <div class="detail-label">Trace ({{ len . }} step{{ if ne (len .) 1 }}s{{ end }}{{ if $result.TraceMS }}, {{ printf "%.0f" $result.TraceMS }}ms{{ end }})</div>
+                        {{ range . }}
+                        <details class="trace-step{{ if .Error }} trace-error{{ end }}">
+                            <summary>
+                                <span class="trace-name">#{{ .Number }} {{ or .Tool "(unnamed)" }}</span>
+                                <span class="trace-track"><span class="trace-bar" style="left: {{ printf "%.2f" .Offset }}%; width: {{ printf "%.2f" .Width }}%;"></span></span>
+                                <span class="trace-ms">{{ if .DurationMS }}{{ printf "%.0f" .DurationMS }}ms{{ end }}</span>
+                            </summary>
+                            {{ with .InputText }}<div class="trace-io-label">Input</div><div class="detail-content">{{ display . }}</div>{{ end }}
+                            {{ with .OutputText }}<div class="trace-io-label">Output</div><div class="detail-content">{{ display . }}</div>{{ end }}
+                            {{ with .Error }}<div class="trace-io-label">Error</div><div class="detail-content">{{ display . }}</div>{{ end }}
+                        </details>
                         {{ end }}
-                        {{ if $result.JudgeContextReasoning }}
-                        <div style="margin-bottom: 0;">
-                            <div style="font-weight: 600; color: var(--text-tertiary); font-size: 0.75rem; margin-bottom: 0.25rem; text-transform: uppercase;">Context Relevance</div>
-                            <div class="detail-content">{{ $result.JudgeContextReasoning }}</div>
-                        </div>
+                    </div>
+                    {{ end }}
+
+                    {{ with $result.RetrievedContexts }}
+                    <div class="detail-section">
+                        <div class="detail-label">Retrieved Context ({{ len . }} chunk{{ if ne (len .) 1 }}s{{ end }}, {{ human $result.ContextChars }} chars)</div>
+                        {{ range $i, $chunk := . }}
+                        <details class="context-chunk"{{ if not $i }} open{{ end }}>
+                            <summary>#{{ inc $i }}{{ if $chunk.Source }} · {{ $chunk.Source }}{{ end }}{{ if $chunk.Similarity }} · similarity {{ printf "%.3f" $chunk.SimilarityValue }}{{ end }}<span class="context-chars">{{ human $chunk.Chars }} chars</span></summary>
+                            {{ $text := clip $chunk.Text }}
+                            <div class="detail-content" data-field="context:{{ $i }}"{{ if $text.Truncated }} data-truncated{{ end }}>{{ $text.Text }}{{ if $text.Truncated }}…{{ end }}</div>
+                            <div class="text-tools">{{ if $text.Truncated }}<button class="text-btn" data-action="expand" data-field="context:{{ $i }}">Show all</button>{{ end }}<button class="text-btn" data-action="copy" data-field="context:{{ $i }}">Copy</button></div>
+                        </details>
                         {{ end }}
                     </div>
                     {{ end }}
@@ -1624,11 +3694,38 @@ func testsHandler(w http.ResponseWriter, r *http.Request) {
                         </div>
                     </div>
 
-                    {{ if $result.CustomFields }}
+                    {{ with recipe $result }}{{ if .Parts }}
+                    <div class="detail-section">
+                        <div class="detail-label">Combined Score Recipe</div>
+                        <div class="recipe-formula">{{ if .Weighted }}Weighted mean with the active score weights{{ else }}Assuming an equal-weight mean of the custom scores{{ end }}</div>
+                        <div class="recipe-bar" title="Each segment is weight × score; the marker is the combined score">
+                            {{ range .Parts }}<div class="recipe-segment {{ .Color }}" style="width: {{ printf "%.2f" (percent (clamp01 .Contribution)) }}%;" title="{{ .Name }}: {{ printf "%.3f" .Contribution }}"></div>{{ end }}
+                            <div class="recipe-marker" style="left: {{ printf "%.2f" (percent (clamp01 .Combined)) }}%;"></div>
+                        </div>
+                        <table class="recipe-table">
+                            {{ range .Parts }}
+                            <tr>
+                                <td><span class="recipe-swatch {{ .Color }}"></span>{{ .Name }}</td>
+                                <td>{{ printf "%.3f" .Score }} × {{ printf "%.2f" .Weight }}</td>
+                                <td>= {{ printf "%.3f" .Contribution }}</td>
+                                <td class="recipe-lost">{{ if .Weight }}{{ if .Lost }}−{{ printf "%.3f" .Lost }} lost{{ end }}{{ else }}not in formula{{ end }}</td>
+                            </tr>
+                            {{ end }}
+                            <tr class="recipe-total">
+                                <td>Parts explain</td><td></td><td>= {{ printf "%.3f" .Explained }}</td><td></td>
+                            </tr>
+                        </table>
+                        {{ if .Gap }}
+                        <div class="recipe-gap">Combined is {{ printf "%.3f" .Combined }}, {{ printf "%.3f" (abs .Gap) }} {{ if lt .Gap 0.0 }}lower{{ else }}higher{{ end }} than the parts explain: the logged combined score uses a different formula (other weights, penalties or scores that weren't logged).</div>
+                        {{ end }}
+                    </div>
+                    {{ end }}{{ end }}
+
+                    {{ with $result.Fields }}
                     <div class="detail-section">
                         <div class="detail-label">Configuration</div>
                         <div class="metadata-grid">
-                            {{ range $key, $value := $result.CustomFields }}
+                            {{ range $key, $value := . }}
                             <div class="metadata-item">
                                 <span class="metadata-key">{{ $key }}:</span>
                                 <span class="metadata-value">{{ $value }}</span>
@@ -1651,140 +3748,298 @@ func testsHandler(w http.ResponseWriter, r *http.Request) {
                         </div>
                     </div>
                     {{ end }}
-                </div>
-            </div>
-        </div>
-        {{ end }}
-    </div>
-    <script>
-        // Dark mode toggle
-        const themeToggle = document.getElementById('theme-toggle');
-        const themeIcon = document.getElementById('theme-icon');
-        const html = document.documentElement;
-        const savedTheme = localStorage.getItem('theme') || 'light';
-        html.setAttribute('data-theme', savedTheme);
-        themeIcon.textContent = savedTheme === 'light' ? 'Dark' : 'Light';
 
-        themeToggle.addEventListener('click', () => {
-            const currentTheme = html.getAttribute('data-theme');
-            const newTheme = currentTheme === 'light' ? 'dark' : 'light';
-            html.setAttribute('data-theme', newTheme);
-            localStorage.setItem('theme', newTheme);
-            themeIcon.textContent = newTheme === 'light' ? 'Dark' : 'Light';
-        });
-
-        // Help modal
-        const helpBtn = document.getElementById('help-btn');
-        const helpModal = document.getElementById('help-modal');
-
-        helpBtn.addEventListener('click', () => {
-            helpModal.classList.add('show');
-        });
-
-        helpModal.addEventListener('click', (e) => {
-            if (e.target === helpModal) {
-                helpModal.classList.remove('show');
-            }
-        });
-
-        // Keyboard shortcuts
-        document.addEventListener('keydown', (e) => {
-            if (e.key === 'd' || e.key === 'D') {
-                e.preventDefault();
-                themeToggle.click();
-            }
-            if (e.key === '?') {
-                e.preventDefault();
-                helpModal.classList.add('show');
-            }
-            if (e.key === 'r' || e.key === 'R') {
-                e.preventDefault();
-                location.reload();
-            }
-            if (e.key === 'Escape') {
-                helpModal.classList.remove('show');
-                // Close all test modals on Escape
-                document.querySelectorAll('.modal').forEach(modal => {
-                    modal.classList.remove('show');
-                });
-            }
-        });
-
-        // Modal functions
-        function showTestModal(index) {
-            const modal = document.getElementById('modal-' + index);
-            modal.classList.add('show');
-        }
+                    {{ if $result.Origin }}
+                    <div class="detail-section">
+                        <div class="detail-label">Source</div>
+                        <div class="detail-content"><a href="/tests?source={{ $result.Origin }}" class="source-link" title="Show all tests from this source">{{ $result.Origin }}</a></div>
+                    </div>
+                    {{ end }}
 
-        function closeTestModal(index) {
-            const modal = document.getElementById('modal-' + index);
-            modal.classList.remove('show');
-        }
+                    <div class="detail-section review" data-result-id="{{ resultID $result }}">
+                        {{ $reviews := annotations $result }}
+                        <div class="detail-label">Review{{ with reviewSummary $reviews }} ({{ . }}){{ end }}</div>
+                        <ul class="review-list">
+                            {{ range $reviews }}
+                            <li><span class="review-decision review-{{ .Decision }}">{{ if .Decision }}{{ .Decision }}{{ else }}note{{ end }}</span>{{ range .Labels }} <span class="review-label">{{ . }}</span>{{ end }} {{ .Note }} <span class="review-meta">{{ if .Reviewer }}{{ .Reviewer }}, {{ end }}{{ .Created.Format "2006-01-02 15:04" }}</span></li>
+                            {{ end }}
+                        </ul>
+                        <textarea class="review-note" rows="2" placeholder="Note (optional)"></textarea>
+                        <div class="review-actions">
+                            <input type="text" class="review-labels" placeholder="Labels, comma separated">
+                            <button class="review-btn" data-decision="accept">Accept</button>
+                            <button class="review-btn" data-decision="reject">Reject</button>
+                            <button class="review-btn" data-decision="unsure">Unsure</button>
+                            <button class="review-btn" data-decision="">Add note</button>
+                        </div>
+                    </div>
+{{ end }}`
 
-        // Close modal when clicking outside
-        document.addEventListener('click', (e) => {
-            if (e.target.classList.contains('modal')) {
-                e.target.classList.remove('show');
-            }
-        });
-    </script>
-</body>
-</html>`
+	terms := parseSearch(search)
+	weights, _ := requestWeights(r) // Already validated by reweightRequest
+	theme, slas := brandingFor(r.Context()), slasFor(r.Context())
+	funcMap := template.FuncMap{
+		"recipe":          func(result EvalResult) ScoreRecipe { return scoreRecipe(result, weights) },
+		"percent":         func(v float64) float64 { return v * 100 },
+		"clamp01":         func(v float64) float64 { return math.Max(0, math.Min(1, v)) },
+		"abs":             math.Abs,
+		"tampered":        isTampered,
+		"human":           humanNumber,
+		"inc":             func(i int) int { return i + 1 },
+		"brand":           func() Branding { return theme },
+		"projects":        func() []ProjectLink { return projectsFor(r.Context()) },
+		"breachesSLA":     slas.breaches,
+		"sla":             func(result EvalResult) int64 { ms, _ := slas.slaFor(result.Model); return ms },
+		"resultID":        resultID,
+		"permalink":       permalinkURL,
+		"sortURL":         func(key string) string { return tableSort.URL("/tests", r.URL.Query(), key) },
+		"clip":            func(s string) ClippedText { return clipText(s, modalTextLimit) },
+		"short":           func(s string) string { return displayText(s, rowTextLimit) },
+		"display":         func(s string) string { return displayText(s, modalTextLimit) },
+		"annotations":     func(result EvalResult) []Annotation { return reviews.ForResult(resultID(result)) },
+		"reviewDecisions": func() []string { return reviewDecisions },
+		"reviewSummary":   reviewSummary,
+		"mediaURL":        func(a Attachment) template.URL { return mediaURL(a, mediaDirFor(r.Context())) },
+		// snippet shows where a search hit was found outside the question column
+		"snippet": func(result EvalResult) template.HTML {
+			field, text := findSnippet(result, terms)
+			if field == "" || field == "question" {
+				return ""
+			}
+			return `<span class="snippet-field">` + template.HTML(template.HTMLEscapeString(field)) + `:</span> ` + highlight(sanitizeText(text), terms)
+		},
+	}
+	var sources []string
+	for _, st := range store.Sources(r.Context()) {
+		sources = append(sources, st.Name)
+	}
+	data := struct {
+		Results     []EvalResult
+		Permalink   string // Test ID of a permalink page
+		Search      string
+		Model       string
+		RunID       string
+		Source      string
+		Dataset     string
+		Sources     []string
+		Sample      *SampleSpec // Non-nil when showing a spot check sample
+		Population  int         // Results the sample was drawn from
+		SpotCheck   string      // Link to a new sample with the current filters
+		LatencySLA  bool        // --latency-sla is set, offer the breach filter
+		SLABreaches bool        // Only results over their SLA
+		HasErrors   bool        // Some results have an error, offer the errors filter
+		ErrorsOnly  bool        // Only results with an error
+		Labels      []string    // Labels used in annotations, offered as a filter
+		Label       string
+		Review      string
+		Sort        TableSort // Column the results are sorted by, none = newest first
+	}{
+		Results:     filteredResults,
+		Permalink:   permalink,
+		Search:      search,
+		Model:       filter.ConfigKey,
+		RunID:       filter.RunID,
+		Source:      filter.Origin,
+		Dataset:     filter.Dataset,
+		Sources:     sources,
+		Sample:      sample,
+		Population:  population,
+		SpotCheck:   spotCheckURL(r.URL.Query(), "model", "run_id", "source", "dataset", "q", "weights", "sla", "errors", "label", "review"),
+		LatencySLA:  slas != nil,
+		HasErrors:   hasErrors,
+		ErrorsOnly:  errorsOnly,
+		Labels:      reviews.Labels(),
+		Label:       label,
+		Review:      review,
+		SLABreaches: slaBreaches,
+		Sort:        tableSort,
+	}
+	if sample != nil {
+		// Reshuffle with the same parameters and a new seed
+		params := r.URL.Query()
+		params.Set("seed", strconv.FormatUint(rand.Uint64(), 10))
+		data.SpotCheck = "/tests?" + params.Encode()
+	}
+	if permalink != "" && len(filteredResults) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+	}
 
-	t := template.Must(template.New("tests").Parse(tmpl))
-	if err := t.Execute(w, filteredResults); err != nil {
+	_, renderSpan := startSpan(r.Context(), "template.render")
+	defer renderSpan.End()
+	t := template.Must(template.New("tests").Funcs(funcMap).Parse(tmpl))
+	t = template.Must(t.Parse(brandingTemplates))
+	t = template.Must(t.Parse(projectTemplates))
+	if err := t.Execute(w, data); err != nil {
 		// Don't call http.Error here - headers already sent by Execute
 		log.Printf("Template error: %v", err)
+		renderSpan.RecordError(err)
 	}
 }
 
 // evalsAPIHandler returns all eval results and dashboard data as JSON
 func evalsAPIHandler(w http.ResponseWriter, r *http.Request) {
-	// Reload latest data
-	if err := reloadData(); err != nil {
-		http.Error(w, fmt.Sprintf("Error reloading data: %v", err), http.StatusInternalServerError)
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+	case "ndjson", "jsonl":
+		streamEvalsHandler(w, r)
+		return
+	default:
+		http.Error(w, "format must be json or ndjson", http.StatusBadRequest)
+		return
+	}
+	limit, after, paged, err := pageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	fields, ok := fieldsRequest(w, r)
+	if !ok {
+		return
+	}
+	if paged {
+		results, ok := evalsAPIResults(w, r)
+		if !ok {
+			return
+		}
+		page := paginate(results, after, limit)
+		if fields == nil {
+			writeCachedJSON(w, r, page)
+			return
+		}
+		projected, err := projectResults(page.Results, fields)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error encoding results: %v", err), http.StatusInternalServerError)
+			return
+		}
+		writeCachedJSON(w, r, struct {
+			Results    any    `json:"results"`
+			NextCursor string `json:"next_cursor,omitempty"`
+		}{projected, page.NextCursor})
 		return
 	}
 
-	// Optional filters
-	modelFilter := r.URL.Query().Get("model")
+	// Reload latest data
+	data, err := store.Stats(r.Context())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	weights, err := requestWeights(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if weights != nil {
+		results, err := store.Query(r.Context(), Query{}) // Stats may not hold the results (--lazy)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+			return
+		}
+		data = CalculateStats(reweight(results, weights), slasFor(r.Context()))
+	}
 
 	// Prepare response with full dashboard data
 	response := struct {
 		DashboardData
-		// Add custom scores serialization for API
-		ResultsWithScores []EvalResult `json:"results"`
+		// Add custom scores serialization for API, projected to the requested fields
+		ResultsWithScores any `json:"results"`
 	}{
-		DashboardData:     evalData,
-		ResultsWithScores: evalData.Results,
+		DashboardData: data,
 	}
 
 	// Apply model filter if specified
-	if modelFilter != "" {
+	results := data.Results
+	if modelFilter := r.URL.Query().Get("model"); modelFilter != "" {
 		var filtered []EvalResult
-		for _, result := range evalData.Results {
-			if result.Model == modelFilter {
+		q := Query{Model: modelFilter}
+		for _, result := range data.Results {
+			if q.Match(result) {
 				filtered = append(filtered, result)
 			}
 		}
-		response.ResultsWithScores = filtered
+		results = filtered
 	}
-
-	// Return as JSON
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("Error encoding JSON: %v", err)
+	if response.ResultsWithScores, err = projectResults(results, fields); err != nil {
+		http.Error(w, fmt.Sprintf("Error encoding results: %v", err), http.StatusInternalServerError)
+		return
 	}
+	if fields != nil {
+		response.DashboardData.Results = nil // Full copies in Results would defeat the projection
+	}
+
+	// Return as JSON, 304 when the client already has this exact response
+	writeCachedJSON(w, r, response)
 }
 
-// evalsSinceHandler returns only eval results after given timestamp (smart polling)
-func evalsSinceHandler(w http.ResponseWriter, r *http.Request) {
-	// Reload latest data
-	if err := reloadData(); err != nil {
-		http.Error(w, fmt.Sprintf("Error reloading data: %v", err), http.StatusInternalServerError)
+// evalsStreamFlush is how many results /api/evals?format=ndjson writes between flushes
+const evalsStreamFlush = 1000
+
+// streamEvalsHandler implements /api/evals?format=ndjson: the results only, one JSON object per
+// line, written as they are encoded instead of marshaling one document holding everything
+// Clients can process results while the rest is still arriving
+// With limit or cursor only that page is streamed, and the cursor of the next one is sent in
+// the X-Next-Cursor header
+func streamEvalsHandler(w http.ResponseWriter, r *http.Request) {
+	limit, after, paged, err := pageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	fields, ok := fieldsRequest(w, r)
+	if !ok {
 		return
 	}
+	results, ok := evalsAPIResults(w, r)
+	if !ok {
+		return
+	}
+	if paged {
+		page := paginate(results, after, limit)
+		results = page.Results
+		if page.NextCursor != "" {
+			w.Header().Set("X-Next-Cursor", page.NextCursor)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	rc := http.NewResponseController(w)
+	buf := bufio.NewWriterSize(w, 64*1024)
+	enc := json.NewEncoder(buf)
+	for i, result := range results {
+		var line any = result
+		if fields != nil {
+			if line, err = fields.apply(result); err != nil {
+				log.Printf("Error encoding JSON: %v", err)
+				return
+			}
+		}
+		if err := enc.Encode(line); err != nil {
+			log.Printf("Error encoding JSON: %v", err)
+			return
+		}
+		if (i+1)%evalsStreamFlush == 0 {
+			if err := buf.Flush(); err != nil {
+				return // Client went away
+			}
+			rc.Flush() // Errors only when the writer can't flush, the data is written anyway
+		}
+	}
+	buf.Flush()
+}
+
+// evalsAPIResults are the results of /api/evals pages and streams, filtered by model and
+// reweighted by weights; on errors the response is written and ok is false
+func evalsAPIResults(w http.ResponseWriter, r *http.Request) ([]EvalResult, bool) {
+	results, err := store.Query(r.Context(), Query{Model: r.URL.Query().Get("model")})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return nil, false
+	}
+	return reweightRequest(w, r, results)
+}
 
+// evalsSinceHandler returns only eval results after given timestamp (smart polling)
+func evalsSinceHandler(w http.ResponseWriter, r *http.Request) {
 	// Get timestamp filter from query param
 	sinceTimestamp := r.URL.Query().Get("ts")
 	if sinceTimestamp == "" {
@@ -1792,22 +4047,36 @@ func evalsSinceHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Filter results - only return evals after the given timestamp
-	var newResults []EvalResult
-	for _, result := range evalData.Results {
-		if result.Timestamp > sinceTimestamp {
-			newResults = append(newResults, result)
-		}
+	fields, ok := fieldsRequest(w, r)
+	if !ok {
+		return
+	}
+
+	// Only return evals after the given timestamp
+	newResults, err := store.Query(r.Context(), Query{Since: sinceTimestamp})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	response, err := projectResults(newResults, fields)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error encoding results: %v", err), http.StatusInternalServerError)
+		return
 	}
 
 	// Return as JSON
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(newResults); err != nil {
+	if err := json.NewEncoder(w).Encode(response); err != nil {
 		log.Printf("Error encoding JSON: %v", err)
 	}
 }
 
 func healthHandler(w http.ResponseWriter, r *http.Request) {
+	data, err := store.Stats(r.Context())
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"status":"error","error":%q}`, err.Error()), http.StatusServiceUnavailable)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
-	fmt.Fprintf(w, `{"status":"ok","total_tests":%d,"models":%d}`, evalData.TotalTests, len(evalData.Models))
+	fmt.Fprintf(w, `{"status":"ok","total_tests":%d,"models":%d}`, data.TotalTests, len(data.Models))
 }
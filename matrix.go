@@ -0,0 +1,312 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+)
+
+// MatrixRun is one column of the run matrix
+type MatrixRun struct {
+	Name   string `json:"name"` // run_id, or the source file for results without one
+	RunID  string `json:"run_id,omitempty"`
+	Source string `json:"source,omitempty"`
+	Start  string `json:"start,omitempty"`
+}
+
+// MatrixRow is one model (or config) across runs
+type MatrixRow struct {
+	Label string        `json:"label"`
+	Cells []HeatmapCell `json:"cells"` // One per run, Count 0 = not in that run
+	Avg   float64       `json:"avg"`   // Mean of the run averages
+	Drift float64       `json:"drift"` // Last run average minus first, 0 with fewer than two runs
+	Runs  int           `json:"runs"`  // Runs the row appears in
+
+	Links []string `json:"-"` // Tests page for each cell
+}
+
+// RunMatrix is a models × runs score matrix for spotting drift between runs
+type RunMatrix struct {
+	Score  string      `json:"score"`
+	RowsBy string      `json:"rows_by"` // model or config
+	Runs   []MatrixRun `json:"runs"`    // Oldest first
+	Rows   []MatrixRow `json:"rows"`    // Largest absolute drift first
+}
+
+// BuildRunMatrix averages score per row and run
+// score is "combined" or a custom score name; results without it are skipped
+func BuildRunMatrix(results []EvalResult, score, rowsBy string) RunMatrix {
+	m := RunMatrix{Score: score, RowsBy: rowsBy}
+	type acc struct {
+		sum   float64
+		count int
+	}
+	cells := make(map[string]map[string]*acc) // row -> run -> acc
+	var withScore []EvalResult
+	for _, result := range scored(results) {
+		value, ok := result.Scores.Combined, true
+		if score != "combined" {
+			value, ok = result.Scores.Custom[score]
+		}
+		if !ok {
+			continue
+		}
+		withScore = append(withScore, result)
+		row := result.Model
+		if rowsBy == "config" {
+			row = buildConfigKey(result)
+		}
+		if cells[row] == nil {
+			cells[row] = make(map[string]*acc)
+		}
+		run := runName(runOf(result))
+		a := cells[row][run]
+		if a == nil {
+			a = &acc{}
+			cells[row][run] = a
+		}
+		a.sum += value
+		a.count++
+	}
+
+	stats := BuildRunStats(withScore)
+	slices.Reverse(stats) // Oldest first, reading left to right
+	for _, stat := range stats {
+		m.Runs = append(m.Runs, MatrixRun{Name: runName(stat.RunID, stat.Source), RunID: stat.RunID, Source: stat.Source, Start: stat.Start})
+	}
+
+	for label, byRun := range cells {
+		row := MatrixRow{Label: label}
+		var avgs []float64
+		for _, run := range m.Runs {
+			cell := HeatmapCell{}
+			if a := byRun[run.Name]; a != nil {
+				cell = HeatmapCell{Score: a.sum / float64(a.count), Count: a.count}
+				avgs = append(avgs, cell.Score)
+			}
+			row.Cells = append(row.Cells, cell)
+			row.Links = append(row.Links, matrixCellURL(run, rowsBy, label))
+		}
+		row.Runs = len(avgs)
+		row.Avg = mean(avgs)
+		if len(avgs) >= 2 {
+			row.Drift = avgs[len(avgs)-1] - avgs[0]
+		}
+		m.Rows = append(m.Rows, row)
+	}
+	sort.Slice(m.Rows, func(i, j int) bool {
+		di, dj := math.Abs(m.Rows[i].Drift), math.Abs(m.Rows[j].Drift)
+		if di != dj {
+			return di > dj
+		}
+		return m.Rows[i].Label < m.Rows[j].Label
+	})
+	return m
+}
+
+// matrixParams reads and validates the matrix query parameters
+func matrixParams(params url.Values) (score, rowsBy string, err error) {
+	score = params.Get("score")
+	if score == "" {
+		score = "combined"
+	}
+	rowsBy = params.Get("rows")
+	if rowsBy == "" {
+		rowsBy = "model"
+	}
+	if rowsBy != "model" && rowsBy != "config" {
+		return "", "", fmt.Errorf("rows must be model or config")
+	}
+	return score, rowsBy, nil
+}
+
+// matrixCellURL links a cell to its results on the tests page
+func matrixCellURL(run MatrixRun, rowsBy, label string) string {
+	params := url.Values{}
+	if run.RunID != "" {
+		params.Set("run_id", run.RunID)
+	} else {
+		params.Set("source", run.Source)
+	}
+	if rowsBy == "config" {
+		params.Set("model", label)
+	}
+	return "/tests?" + params.Encode()
+}
+
+// matrixHandler renders the models × runs score matrix
+func matrixHandler(w http.ResponseWriter, r *http.Request) {
+	score, rowsBy, err := matrixParams(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	results, err := store.Query(r.Context(), Query{})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	results, ok := reweightRequest(w, r, results)
+	if !ok {
+		return
+	}
+
+	data := struct {
+		Title    string
+		Subtitle string
+		RunMatrix
+		Scores  []string
+		Weights string
+	}{
+		Title:     "Run Matrix",
+		Subtitle:  "Models × runs: did anything drift between runs?",
+		RunMatrix: BuildRunMatrix(results, score, rowsBy),
+		Scores:    scoreNames(results),
+		Weights:   r.URL.Query().Get("weights"),
+	}
+	renderPage(w, r, "matrix", matrixTemplate, data)
+}
+
+// matrixAPIHandler returns the run matrix as JSON
+func matrixAPIHandler(w http.ResponseWriter, r *http.Request) {
+	score, rowsBy, err := matrixParams(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	results, err := store.Query(r.Context(), Query{})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	results, ok := reweightRequest(w, r, results)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(BuildRunMatrix(results, score, rowsBy)); err != nil {
+		log.Printf("Error encoding JSON: %v", err)
+	}
+}
+
+const matrixTemplate = `
+{{ define "style" }}
+        .matrix-controls {
+            display: flex;
+            flex-wrap: wrap;
+            gap: 0.75rem;
+            align-items: center;
+        }
+        .score-matrix {
+            border-collapse: separate;
+            border-spacing: 2px;
+            width: auto;
+        }
+        .score-matrix td, .score-matrix th {
+            border: none;
+        }
+        .score-matrix th {
+            font-family: monospace;
+            font-size: 0.75rem;
+            text-transform: none;
+            letter-spacing: 0;
+            white-space: nowrap;
+            text-align: center;
+        }
+        .score-matrix .cell {
+            min-width: 4.5rem;
+            text-align: center;
+            font-family: monospace;
+            font-size: 0.8125rem;
+            color: #fff;
+        }
+        .score-matrix .cell a {
+            display: block;
+            color: inherit;
+            text-decoration: none;
+        }
+        .score-matrix .empty {
+            background: var(--bg-tertiary);
+        }
+        .score-matrix .row-label {
+            padding-right: 0.75rem;
+            white-space: nowrap;
+            font-size: 0.8125rem;
+            text-align: right;
+        }
+        .score-matrix .drift {
+            font-family: monospace;
+            font-size: 0.8125rem;
+            text-align: right;
+            padding-left: 0.75rem;
+        }
+        .drift-up {
+            color: var(--success);
+        }
+        .drift-down {
+            color: var(--error);
+        }
+{{ end }}
+
+{{ define "content" }}
+        <div class="panel">
+            <form method="get" action="/matrix" class="matrix-controls">
+                <label class="muted" for="score">Score</label>
+                <select id="score" name="score" onchange="this.form.submit()">
+                    {{ range .Scores }}<option value="{{ . }}" {{ if eq . $.Score }}selected{{ end }}>{{ . }}</option>{{ end }}
+                </select>
+                <label class="muted" for="rows">Rows</label>
+                <select id="rows" name="rows" onchange="this.form.submit()">
+                    <option value="model" {{ if eq .RowsBy "model" }}selected{{ end }}>Models</option>
+                    <option value="config" {{ if eq .RowsBy "config" }}selected{{ end }}>Configs</option>
+                </select>
+                {{ with .Weights }}<input type="hidden" name="weights" value="{{ . }}">{{ end }}
+                <span class="muted" style="margin-left: auto;">{{ len .Runs }} runs, {{ len .Rows }} {{ if eq .RowsBy "config" }}configs{{ else }}models{{ end }}</span>
+            </form>
+        </div>
+
+        <div class="panel" style="overflow-x: auto;">
+            {{ if lt (len .Runs) 2 }}
+            <p class="muted">Load more than one run (several JSONL files, or results with different <code>metadata.run_id</code>) to compare runs.</p>
+            {{ else }}
+            <table class="score-matrix">
+                <thead>
+                    <tr>
+                        <th></th>
+                        {{ range .Runs }}<th title="{{ .Name }}{{ with .Start }} - started {{ . }}{{ end }}">{{ .Name }}</th>{{ end }}
+                        <th title="Last run minus first run the row appears in">Drift</th>
+                    </tr>
+                </thead>
+                <tbody>
+                    {{ range .Rows }}
+                    {{ $row := . }}
+                    <tr>
+                        <td class="row-label mono">{{ .Label }}</td>
+                        {{ range $i, $cell := .Cells }}
+                        {{ $run := index $.Runs $i }}
+                        {{ if $cell.Count }}
+                        <td class="cell" style="background: {{ scoreColor $cell.Score }};" title="{{ $row.Label }} · {{ $run.Name }}: {{ printf "%.3f" $cell.Score }} ({{ $cell.Count }} result{{ if gt $cell.Count 1 }}s{{ end }})">
+                            <a href="{{ index $row.Links $i }}">{{ printf "%.2f" $cell.Score }}</a>
+                        </td>
+                        {{ else }}
+                        <td class="empty" title="{{ $row.Label }} · {{ $run.Name }}: not in this run"></td>
+                        {{ end }}
+                        {{ end }}
+                        <td class="drift {{ if gt .Drift 0.0 }}drift-up{{ else if lt .Drift 0.0 }}drift-down{{ end }}">{{ if ge .Runs 2 }}{{ printf "%+.3f" .Drift }}{{ else }}-{{ end }}</td>
+                    </tr>
+                    {{ end }}
+                </tbody>
+            </table>
+            <p class="muted" style="margin-top: 1rem; font-size: 0.8125rem;">
+                Runs are ordered by start time, oldest first. Rows with the largest drift come first; a row changing color along its length moved between runs.
+                Click a cell to see its results.
+            </p>
+            {{ end }}
+        </div>
+{{ end }}`
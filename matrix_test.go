@@ -0,0 +1,57 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBuildRunMatrix(t *testing.T) {
+	result := func(ts, run, model string, score float64) EvalResult {
+		return EvalResult{Timestamp: ts, Model: model, Metadata: map[string]any{"run_id": run}, Scores: ScoreBreakdown{Combined: score}}
+	}
+	results := []EvalResult{
+		result("2025-01-02T00:00:00Z", "r2", "a", 0.6),
+		result("2025-01-01T00:00:00Z", "r1", "a", 0.9),
+		result("2025-01-01T00:00:00Z", "r1", "a", 0.7),
+		result("2025-01-01T00:00:00Z", "r1", "b", 0.5),
+		result("2025-01-02T00:00:00Z", "r2", "b", 0.55),
+		result("2025-01-02T00:00:00Z", "r2", "c", 0.4),
+	}
+
+	m := BuildRunMatrix(results, "combined", "model")
+	if len(m.Runs) != 2 || m.Runs[0].Name != "r1" || m.Runs[1].Name != "r2" {
+		t.Fatalf("runs = %+v", m.Runs)
+	}
+	if len(m.Rows) != 3 || m.Rows[0].Label != "a" || m.Rows[2].Label != "c" {
+		t.Fatalf("rows = %+v", m.Rows)
+	}
+	a := m.Rows[0]
+	if a.Cells[0].Count != 2 || math.Abs(a.Cells[0].Score-0.8) > 1e-9 || math.Abs(a.Drift+0.2) > 1e-9 || a.Runs != 2 {
+		t.Errorf("row a = %+v", a)
+	}
+	if c := m.Rows[2]; c.Cells[0].Count != 0 || c.Drift != 0 || c.Runs != 1 || c.Links[1] != "/tests?run_id=r2" {
+		t.Errorf("row c = %+v", c)
+	}
+
+	if m := BuildRunMatrix(results, "accuracy", "model"); len(m.Runs) != 0 || len(m.Rows) != 0 {
+		t.Errorf("missing score = %+v", m)
+	}
+}
+
+func TestMatrixPage(t *testing.T) {
+	useStore(t, NewMemoryStore([]Source{&fileSource{path: "evals.jsonl"}}))
+
+	rec := httptest.NewRecorder()
+	matrixHandler(rec, httptest.NewRequest(http.MethodGet, "/matrix?rows=judge", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("rows=judge: %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	matrixHandler(rec, httptest.NewRequest(http.MethodGet, "/matrix", nil))
+	if body := rec.Body.String(); rec.Code != http.StatusOK || !strings.Contains(body, "Load more than one run") {
+		t.Errorf("single run page: %d", rec.Code)
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// mediaDir is the directory attachment paths are relative to and /media/ serves from (--media-dir)
+// Empty serves nothing: the working directory may hold anything, so it has to be chosen
+var mediaDir string
+
+// mediaDirFor returns the media directory of the dashboard serving ctx
+func mediaDirFor(ctx context.Context) string {
+	if d := dashboardFrom(ctx); d != nil {
+		return d.mediaDir
+	}
+	return mediaDir
+}
+
+// Attachment is an image, audio or video file a multimodal result refers to, either a path
+// under the media directory or inline base64 data
+// In JSONL it is an object or a plain string: a path or a data: URL
+type Attachment struct {
+	Path string `json:"path,omitempty"`
+	Data string `json:"data,omitempty"` // Base64 encoded content
+	Type string `json:"type,omitempty"` // Media type, guessed from the path or name when empty
+	Name string `json:"name,omitempty"` // Caption shown in the test modal
+}
+
+// UnmarshalJSON accepts a plain string as a path or a data: URL
+func (a *Attachment) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		type Alias Attachment
+		return json.Unmarshal(data, (*Alias)(a))
+	}
+	*a = Attachment{Path: s}
+	if rest, ok := strings.CutPrefix(s, "data:"); ok {
+		if header, payload, ok := strings.Cut(rest, ","); ok && strings.HasSuffix(header, ";base64") {
+			*a = Attachment{Data: payload, Type: strings.TrimSuffix(header, ";base64")}
+		}
+	}
+	return nil
+}
+
+// MediaType is the declared media type, or the one of the path or name extension
+func (a Attachment) MediaType() string {
+	if a.Type != "" {
+		return a.Type
+	}
+	for _, name := range []string{a.Path, a.Name} {
+		if t := mime.TypeByExtension(strings.ToLower(path.Ext(name))); t != "" {
+			t, _, _ = strings.Cut(t, ";")
+			return t
+		}
+	}
+	return ""
+}
+
+// Kind is how the test modal shows the attachment: image, audio, video or file
+func (a Attachment) Kind() string {
+	kind, _, _ := strings.Cut(a.MediaType(), "/")
+	switch kind {
+	case "image", "audio", "video":
+		return kind
+	}
+	return "file"
+}
+
+// Label is the caption of the attachment: its name, else the file name
+func (a Attachment) Label() string {
+	if a.Name != "" {
+		return a.Name
+	}
+	if a.Path != "" {
+		return path.Base(filepath.ToSlash(a.Path))
+	}
+	return a.MediaType()
+}
+
+// URL is where the browser loads the attachment from: a data: URL for inline media, /media/ for
+// paths; empty when it can't be shown (not media, bad base64, or a path outside the media directory)
+func (a Attachment) URL() template.URL {
+	if a.Kind() == "file" {
+		return ""
+	}
+	if _, _, err := mime.ParseMediaType(a.MediaType()); err != nil {
+		return ""
+	}
+	if a.Data != "" {
+		if _, err := base64.StdEncoding.DecodeString(a.Data); err != nil {
+			return ""
+		}
+		// Safe to trust: the media type is image, audio or video and the payload is plain base64
+		return template.URL("data:" + a.MediaType() + ";base64," + a.Data)
+	}
+	name, ok := mediaName(a.Path)
+	if !ok {
+		return ""
+	}
+	return template.URL("/media/" + (&url.URL{Path: name}).EscapedPath())
+}
+
+// mediaURL is the URL of a, empty for a file attachment when dir, the media directory, is unset
+func mediaURL(a Attachment, dir string) template.URL {
+	if a.Data == "" && dir == "" {
+		return ""
+	}
+	return a.URL()
+}
+
+// mediaName turns an attachment path into a name under the media directory
+// Absolute paths and ../ are refused rather than resolved, the directory is the sandbox
+func mediaName(p string) (string, bool) {
+	name := path.Clean(filepath.ToSlash(p))
+	if !fs.ValidPath(name) || name == "." {
+		return "", false
+	}
+	for _, elem := range strings.Split(name, "/") {
+		if strings.HasPrefix(elem, ".") { // Dotfiles and dot directories (.git, .env) stay private
+			return "", false
+		}
+	}
+	return name, true
+}
+
+// mediaHandler serves attachment files from the media directory
+// Only image, audio and video files are served, and os.Root keeps symlinks from leading out of
+// the directory
+func mediaHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	name, ok := mediaName(strings.TrimPrefix(r.URL.Path, "/media/"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	attachment := Attachment{Path: name}
+	if attachment.Kind() == "file" {
+		http.Error(w, "Only image, audio and video files are served", http.StatusForbidden)
+		return
+	}
+
+	dir := mediaDirFor(r.Context())
+	if dir == "" {
+		http.Error(w, "Attachment files are not served, start goevals with --media-dir", http.StatusNotFound)
+		return
+	}
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error opening media directory: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer root.Close()
+	f, err := root.Open(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			http.NotFound(w, r)
+		} else {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+		}
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", attachment.MediaType())
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "sandbox") // SVGs can carry scripts
+	http.ServeContent(w, r, name, info.ModTime(), f)
+}
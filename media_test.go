@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAttachments(t *testing.T) {
+	line := `{"timestamp":"2025-01-01T10:00:00Z","model":"a","scores":{"combined":1},"attachments":[
+		"charts/q1.png",
+		"data:image/gif;base64,R0lGODlhAQABAAAAACw=",
+		{"path":"audio/q1.mp3","name":"Spoken question"},
+		{"data":"not base64!","type":"image/png"},
+		"../secrets/key.png",
+		"/etc/hosts",
+		"report.txt"]}`
+	var result EvalResult
+	if err := json.Unmarshal([]byte(line), &result); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := result.CustomFields["attachments"]; ok {
+		t.Error("attachments was taken for a custom field")
+	}
+	want := []struct {
+		kind, url, label string
+	}{
+		{"image", "/media/charts/q1.png", "q1.png"},
+		{"image", "data:image/gif;base64,R0lGODlhAQABAAAAACw=", "image/gif"},
+		{"audio", "/media/audio/q1.mp3", "Spoken question"},
+		{"image", "", "image/png"},
+		{"image", "", "key.png"},
+		{"file", "", "hosts"},
+		{"file", "", "report.txt"},
+	}
+	if len(result.Attachments) != len(want) {
+		t.Fatalf("attachments = %+v", result.Attachments)
+	}
+	for i, a := range result.Attachments {
+		if a.Kind() != want[i].kind || string(a.URL()) != want[i].url || a.Label() != want[i].label {
+			t.Errorf("attachment %d = %s %q %q, want %+v", i, a.Kind(), a.URL(), a.Label(), want[i])
+		}
+	}
+	// Without --media-dir only inline data shows
+	if mediaURL(result.Attachments[0], "") != "" || mediaURL(result.Attachments[1], "") == "" || mediaURL(result.Attachments[0], "media") == "" {
+		t.Error("mediaURL should drop paths only without a media directory")
+	}
+}
+
+func TestMediaHandler(t *testing.T) {
+	dir := t.TempDir()
+	media := filepath.Join(dir, "media")
+	os.MkdirAll(filepath.Join(media, "charts"), 0o755)
+	os.MkdirAll(filepath.Join(media, ".git"), 0o755)
+	os.WriteFile(filepath.Join(media, "charts", "q 1.png"), []byte("\x89PNG\r\n\x1a\n"), 0o644)
+	os.WriteFile(filepath.Join(media, ".git", "a.png"), []byte("x"), 0o644)
+	os.WriteFile(filepath.Join(media, "notes.txt"), []byte("x"), 0o644)
+	os.WriteFile(filepath.Join(dir, "outside.png"), []byte("x"), 0o644)
+	os.Symlink(filepath.Join(dir, "outside.png"), filepath.Join(media, "escape.png"))
+	old := mediaDir
+	mediaDir = media
+	t.Cleanup(func() { mediaDir = old })
+
+	for path, status := range map[string]int{
+		"/media/charts/q%201.png":   http.StatusOK,
+		"/media/charts/missing.png": http.StatusNotFound,
+		"/media/../outside.png":     http.StatusNotFound,
+		"/media/.git/a.png":         http.StatusNotFound,
+		"/media/notes.txt":          http.StatusForbidden,
+		"/media/escape.png":         http.StatusForbidden,
+		"/media/charts":             http.StatusForbidden,
+	} {
+		rec := httptest.NewRecorder()
+		mediaHandler(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != status {
+			t.Errorf("%s: status %d, want %d", path, rec.Code, status)
+		}
+		if status == http.StatusOK && (rec.Header().Get("Content-Type") != "image/png" || rec.Header().Get("X-Content-Type-Options") != "nosniff") {
+			t.Errorf("%s: headers %v", path, rec.Header())
+		}
+	}
+
+	mediaDir = ""
+	rec := httptest.NewRecorder()
+	mediaHandler(rec, httptest.NewRequest(http.MethodGet, "/media/charts/q%201.png", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("without --media-dir: status %d", rec.Code)
+	}
+}
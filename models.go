@@ -0,0 +1,355 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultModelsFile is loaded when it exists and --models isn't given
+const defaultModelsFile = "models.yaml"
+
+// ModelInfo describes a raw model string from the results, see --models
+type ModelInfo struct {
+	DisplayName     string  `json:"display_name,omitempty"`
+	Provider        string  `json:"provider,omitempty"`
+	Parameters      string  `json:"parameters,omitempty"`         // Free text, e.g. 70B or 8x7B
+	Family          string  `json:"family,omitempty"`             // Groups variants, e.g. dated snapshots of one model
+	InputCostPer1M  float64 `json:"input_cost_per_1m,omitempty"`  // USD per million input tokens
+	OutputCostPer1M float64 `json:"output_cost_per_1m,omitempty"` // USD per million output tokens
+	CostPerRequest  float64 `json:"cost_per_request,omitempty"`   // USD per result, e.g. for hosted tools
+}
+
+// hasCost reports whether the registry has cost rates for the model
+func (m ModelInfo) hasCost() bool {
+	return m.InputCostPer1M > 0 || m.OutputCostPer1M > 0 || m.CostPerRequest > 0
+}
+
+// Token count fields of a result, the first one present is used
+var (
+	inputTokenFields  = []string{"input_tokens", "prompt_tokens"}
+	outputTokenFields = []string{"output_tokens", "completion_tokens"}
+)
+
+// Cost estimates what result cost from its token counts (custom fields or metadata)
+// ok is false without cost rates, or when rates per token are set but the result has no counts
+func (m ModelInfo) Cost(result EvalResult) (usd float64, ok bool) {
+	if !m.hasCost() {
+		return 0, false
+	}
+	tokens := func(names []string) (float64, bool) {
+		for _, name := range names {
+			for _, fields := range []map[string]any{result.CustomFields, result.Metadata} {
+				if v, ok := toFloat(fields[name]); ok {
+					return v, true
+				}
+			}
+		}
+		return 0, false
+	}
+	usd, ok = m.CostPerRequest, m.CostPerRequest > 0
+	if in, found := tokens(inputTokenFields); found && m.InputCostPer1M > 0 {
+		usd, ok = usd+in*m.InputCostPer1M/1e6, true
+	}
+	if out, found := tokens(outputTokenFields); found && m.OutputCostPer1M > 0 {
+		usd, ok = usd+out*m.OutputCostPer1M/1e6, true
+	}
+	return usd, ok
+}
+
+// ModelRegistry maps raw model strings to their ModelInfo
+// Keys with * are patterns (gpt-4o-*); exact keys win, then the longest matching pattern
+type ModelRegistry struct {
+	exact    map[string]ModelInfo
+	patterns []string // Longest first
+	byGlob   map[string]ModelInfo
+}
+
+// modelRegistry is loaded from --models, nil = raw model names everywhere
+var modelRegistry *ModelRegistry
+
+// Lookup returns the registry entry of model, ok is false when it has none
+func (reg *ModelRegistry) Lookup(model string) (ModelInfo, bool) {
+	if reg == nil {
+		return ModelInfo{}, false
+	}
+	if info, ok := reg.exact[model]; ok {
+		return info, true
+	}
+	for _, pattern := range reg.patterns {
+		if globMatch(pattern, model) {
+			return reg.byGlob[pattern], true
+		}
+	}
+	return ModelInfo{}, false
+}
+
+// DisplayName is the registry's name for model, the raw string without one
+func (reg *ModelRegistry) DisplayName(model string) string {
+	if info, ok := reg.Lookup(model); ok && info.DisplayName != "" {
+		return info.DisplayName
+	}
+	return model
+}
+
+// Family groups model variants: the registry's family, else its display name
+func (reg *ModelRegistry) Family(model string) string {
+	if info, ok := reg.Lookup(model); ok && info.Family != "" {
+		return info.Family
+	}
+	return reg.DisplayName(model)
+}
+
+// globMatch matches s against a pattern where * stands for any run of characters, / included
+func globMatch(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	for i, part := range parts[1:] {
+		if i == len(parts)-2 {
+			return strings.HasSuffix(s, part)
+		}
+		j := strings.Index(s, part)
+		if j < 0 {
+			return false
+		}
+		s = s[j+len(part):]
+	}
+	return s == ""
+}
+
+// loadModelRegistry reads a registry file, JSON when it ends in .json and YAML otherwise:
+//
+//	gpt-4o-2024-08-06:
+//	  display_name: GPT-4o
+//	  provider: OpenAI
+//	  input_cost_per_1m: 2.50
+//
+// The entries may also be nested under a top-level models: key
+func loadModelRegistry(path string) (*ModelRegistry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model registry: %w", err)
+	}
+	entries := make(map[string]ModelInfo)
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		var doc struct {
+			Models map[string]ModelInfo `json:"models"`
+		}
+		if err := json.Unmarshal(data, &doc); err == nil && doc.Models != nil {
+			entries = doc.Models
+		} else if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("invalid model registry %s: %w", path, err)
+		}
+	} else {
+		entries, err = parseModelsYAML(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid model registry %s: %w", path, err)
+		}
+	}
+
+	reg := &ModelRegistry{exact: make(map[string]ModelInfo), byGlob: make(map[string]ModelInfo)}
+	for model, info := range entries {
+		if strings.Contains(model, "*") {
+			reg.patterns = append(reg.patterns, model)
+			reg.byGlob[model] = info
+		} else {
+			reg.exact[model] = info
+		}
+	}
+	sort.Slice(reg.patterns, func(i, j int) bool {
+		if len(reg.patterns[i]) != len(reg.patterns[j]) {
+			return len(reg.patterns[i]) > len(reg.patterns[j])
+		}
+		return reg.patterns[i] < reg.patterns[j]
+	})
+	return reg, nil
+}
+
+// parseModelsYAML reads the YAML subset registries need: a mapping of model names to mappings
+// of scalars, with comments and quoted strings. Without a YAML library in the binary, anything
+// fancier (anchors, flow style, lists) is rejected rather than misread
+func parseModelsYAML(doc string) (map[string]ModelInfo, error) {
+	entries := make(map[string]ModelInfo)
+	var (
+		model       string // Entry being read
+		info        ModelInfo
+		nested      bool // Entries are under a top-level models: key
+		entryIndent = -1 // Indentation of model names, -1 until the first
+		fieldIndent = -1 // Indentation of the current entry's fields, -1 until its first
+	)
+	flush := func() {
+		if model != "" {
+			entries[model] = info
+		}
+		model, info, fieldIndent = "", ModelInfo{}, -1
+	}
+	for n, raw := range strings.Split(doc, "\n") {
+		line := strings.TrimRight(stripYAMLComment(raw), " \r")
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || line == "---" {
+			continue
+		}
+		if trimmed[0] == '\t' {
+			return nil, fmt.Errorf("line %d: indent with spaces, not tabs", n+1)
+		}
+		indent := len(line) - len(trimmed)
+		key, value, err := splitYAMLPair(trimmed)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+
+		switch {
+		case indent == 0 && key == "models" && value == "" && entryIndent == -1 && !nested:
+			nested = true
+		case value == "" && (indent == entryIndent || entryIndent == -1 && (indent > 0) == nested):
+			flush()
+			model, entryIndent = key, indent
+		case model != "" && indent > entryIndent && (fieldIndent == -1 || indent == fieldIndent):
+			fieldIndent = indent
+			if err := setModelField(&info, key, value); err != nil {
+				return nil, fmt.Errorf("line %d: %w", n+1, err)
+			}
+		default:
+			return nil, fmt.Errorf("line %d: expected a model name followed by indented fields", n+1)
+		}
+	}
+	flush()
+	return entries, nil
+}
+
+// stripYAMLComment cuts a # comment that starts a line or follows a space, outside quotes
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' '):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// splitYAMLPair splits "key: value" at the first colon followed by a space or the line end,
+// so model names like qwen:4b need no quotes
+func splitYAMLPair(line string) (key, value string, err error) {
+	key, raw, err := splitYAMLKey(line)
+	if err != nil {
+		return "", "", err
+	}
+	if raw != "" && strings.ContainsRune("[{&*|>!", rune(raw[0])) {
+		return "", "", fmt.Errorf("only plain values are supported, got %q", raw)
+	}
+	value, err = yamlScalar(raw)
+	return key, value, err
+}
+
+// splitYAMLKey is splitYAMLPair without unquoting the value, which is returned as written
+func splitYAMLKey(line string) (key, raw string, err error) {
+	var i int
+	if line[0] == '"' || line[0] == '\'' {
+		end := strings.IndexByte(line[1:], line[0])
+		if end < 0 {
+			return "", "", fmt.Errorf("unterminated quote")
+		}
+		i = end + 2
+		if i >= len(line) || line[i] != ':' {
+			return "", "", fmt.Errorf("expected : after %s", line[:i])
+		}
+	} else {
+		i = strings.Index(line, ": ")
+		if i < 0 {
+			if !strings.HasSuffix(line, ":") {
+				return "", "", fmt.Errorf("expected key: value, got %q", line)
+			}
+			i = len(line) - 1
+		}
+	}
+	key, err = yamlScalar(line[:i])
+	if err != nil {
+		return "", "", err
+	}
+	return key, strings.TrimSpace(line[i+1:]), nil
+}
+
+// yamlScalar unquotes a scalar, "..." with Go-style escapes and '...' with ” for a quote
+func yamlScalar(s string) (string, error) {
+	switch {
+	case len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"':
+		return strconv.Unquote(s)
+	case len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'':
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	}
+	return s, nil
+}
+
+// setModelField sets one registry field from its YAML value
+func setModelField(info *ModelInfo, key, value string) error {
+	text := map[string]*string{
+		"display_name": &info.DisplayName,
+		"provider":     &info.Provider,
+		"parameters":   &info.Parameters,
+		"family":       &info.Family,
+	}
+	costs := map[string]*float64{
+		"input_cost_per_1m":  &info.InputCostPer1M,
+		"output_cost_per_1m": &info.OutputCostPer1M,
+		"cost_per_request":   &info.CostPerRequest,
+	}
+	if p, ok := text[key]; ok {
+		*p = value
+		return nil
+	}
+	if p, ok := costs[key]; ok {
+		v, err := strconv.ParseFloat(strings.TrimPrefix(value, "$"), 64)
+		if err != nil || v < 0 {
+			return fmt.Errorf("%s must be a non-negative number, got %q", key, value)
+		}
+		*p = v
+		return nil
+	}
+	return fmt.Errorf("unknown field %q", key)
+}
+
+// modelLabel renders a model name for tables: the display name, with the raw name, provider
+// and parameters in a tooltip
+func modelLabel(model string) template.HTML {
+	info, ok := modelRegistry.Lookup(model)
+	if !ok {
+		return template.HTML(template.HTMLEscapeString(model))
+	}
+	details := []string{model}
+	for _, d := range []string{info.Provider, info.Parameters} {
+		if d != "" {
+			details = append(details, d)
+		}
+	}
+	return template.HTML(fmt.Sprintf(`<span title="%s">%s</span>`,
+		template.HTMLEscapeString(strings.Join(details, " · ")), template.HTMLEscapeString(modelRegistry.DisplayName(model))))
+}
+
+// formatCost renders an estimated cost in USD with precision that suits its size
+func formatCost(usd float64) string {
+	switch {
+	case usd == 0:
+		return "$0"
+	case usd < 0.01:
+		return fmt.Sprintf("$%.4f", usd)
+	case usd < 100:
+		return fmt.Sprintf("$%.2f", usd)
+	}
+	return "$" + groupThousands(usd)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testModelsYAML = `# Model registry
+models:
+  gpt-4o-2024-08-06:
+    display_name: GPT-4o   # August snapshot
+    provider: OpenAI
+    family: gpt-4o
+    input_cost_per_1m: 2.50
+    output_cost_per_1m: $10
+  "gpt-4o-*":
+    display_name: 'GPT-4o (other snapshot)'
+    family: gpt-4o
+  qwen:4b:
+    display_name: "Qwen #4B"
+    provider: Alibaba
+    parameters: 4B
+    cost_per_request: 0.001
+`
+
+func TestModelRegistry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "models.yaml")
+	os.WriteFile(path, []byte(testModelsYAML), 0o644)
+	reg, err := loadModelRegistry(path)
+	if err != nil {
+		t.Fatalf("loadModelRegistry: %v", err)
+	}
+
+	if info, ok := reg.Lookup("gpt-4o-2024-08-06"); !ok || info.DisplayName != "GPT-4o" || info.Provider != "OpenAI" || info.OutputCostPer1M != 10 {
+		t.Errorf("exact entry = %+v, %v", info, ok)
+	}
+	if got := reg.DisplayName("gpt-4o-2024-05-13"); got != "GPT-4o (other snapshot)" {
+		t.Errorf("pattern display name = %q", got)
+	}
+	if got := reg.DisplayName("qwen:4b"); got != "Qwen #4B" {
+		t.Errorf("name with colon and quoted # = %q", got)
+	}
+	if reg.Family("gpt-4o-2024-05-13") != "gpt-4o" || reg.Family("qwen:4b") != "Qwen #4B" || reg.Family("llama") != "llama" {
+		t.Errorf("families = %q %q %q", reg.Family("gpt-4o-2024-05-13"), reg.Family("qwen:4b"), reg.Family("llama"))
+	}
+	var nilReg *ModelRegistry
+	if nilReg.DisplayName("gpt-4") != "gpt-4" {
+		t.Error("nil registry should keep raw names")
+	}
+
+	// Flat JSON works the same
+	jsonPath := filepath.Join(t.TempDir(), "models.json")
+	os.WriteFile(jsonPath, []byte(`{"llama-3-70b":{"display_name":"Llama 3 70B","parameters":"70B"}}`), 0o644)
+	if reg, err := loadModelRegistry(jsonPath); err != nil || reg.DisplayName("llama-3-70b") != "Llama 3 70B" {
+		t.Errorf("JSON registry: %v", err)
+	}
+
+	for _, bad := range []string{
+		"gpt-4:\n  colour: red\n",
+		"gpt-4:\n  provider: [a, b]\n",
+		"gpt-4:\n  input_cost_per_1m: cheap\n",
+		"gpt-4:\n  provider: a\n    family: b\n",
+		"gpt-4:\n\tprovider: a\n",
+	} {
+		if _, err := parseModelsYAML(bad); err == nil {
+			t.Errorf("accepted %q", bad)
+		}
+	}
+}
+
+func TestModelCost(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "models.yaml")
+	os.WriteFile(path, []byte(testModelsYAML), 0o644)
+	var err error
+	if modelRegistry, err = loadModelRegistry(path); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { modelRegistry = nil }()
+
+	var results []EvalResult
+	for _, line := range []string{
+		`{"model":"gpt-4o-2024-08-06","scores":{"combined":0.8},"metadata":{"input_tokens":1000000,"output_tokens":100000}}`,
+		`{"model":"gpt-4o-2024-08-06","scores":{"combined":0.6},"metadata":{"prompt_tokens":2000}}`,
+		`{"model":"gpt-4o-2024-08-06","scores":{"combined":0.7}}`,
+		`{"model":"qwen:4b","scores":{"combined":0.5}}`,
+		`{"model":"llama","scores":{"combined":0.5},"metadata":{"input_tokens":5}}`,
+	} {
+		var r EvalResult
+		json.Unmarshal([]byte(line), &r)
+		results = append(results, r)
+	}
+	data := CalculateStats(results, nil)
+	gpt := data.ModelStats["gpt-4o-2024-08-06"]
+	if gpt.CostResults != 2 || math.Abs(gpt.EstCost-3.505) > 1e-9 || gpt.DisplayName != "GPT-4o" {
+		t.Errorf("gpt-4o cost = %+v", gpt)
+	}
+	if qwen := data.ModelStats["qwen:4b"]; qwen.CostResults != 1 || qwen.EstCost != 0.001 {
+		t.Errorf("per-request cost = %v over %d", qwen.EstCost, qwen.CostResults)
+	}
+	if llama := data.ModelStats["llama"]; llama.CostResults != 0 || llama.DisplayName != "llama" {
+		t.Errorf("unregistered model = %+v", llama)
+	}
+	if !hasCosts(data) {
+		t.Error("hasCosts = false")
+	}
+
+	p := BuildPivot(results, "family", "score_type", "count", "combined")
+	if strings.Join(p.Index, ",") != "Qwen #4B,gpt-4o,llama" {
+		t.Errorf("family pivot rows = %v", p.Index)
+	}
+	if got := string(modelLabel("qwen:4b")); got != `<span title="qwen:4b · Alibaba · 4B">Qwen #4B</span>` {
+		t.Errorf("modelLabel = %s", got)
+	}
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ModelDelta is a model's average score in the summarized run and its change against the baseline
+type ModelDelta struct {
+	Model       string
+	Score       float64
+	Delta       float64
+	HasBaseline bool // The baseline has results for this model
+}
+
+// NotifySummary is what `goevals notify` posts after an eval run
+type NotifySummary struct {
+	Title     string
+	Run       string // Run ID or source of the summarized results
+	Results   int
+	BestModel string
+	BestScore float64
+	AvgScore  float64
+	PassRate  float64 // Fraction of results with combined >= the pass threshold
+	Pass      float64
+
+	Baseline  string // Empty when there is nothing to compare against
+	AvgDelta  float64
+	PassDelta float64
+	Models    []ModelDelta // Best first
+}
+
+// splitLatestRun returns the results of the most recent run and of the run before it, with their
+// names; previous is nil when there is only one run
+func splitLatestRun(results []EvalResult) (latest, previous []EvalResult, latestName, previousName string) {
+	runs := BuildRunStats(results)
+	if len(runs) < 2 {
+		name := ""
+		if len(runs) == 1 {
+			name = runName(runs[0].RunID, runs[0].Source)
+		}
+		return results, nil, name, ""
+	}
+	latestName = runName(runs[0].RunID, runs[0].Source)
+	previousName = runName(runs[1].RunID, runs[1].Source)
+	for _, result := range results {
+		switch runName(runOf(result)) {
+		case latestName:
+			latest = append(latest, result)
+		case previousName:
+			previous = append(previous, result)
+		}
+	}
+	return latest, previous, latestName, previousName
+}
+
+// runName is the run ID, or the source for results without one
+func runName(runID, source string) string {
+	if runID != "" {
+		return runID
+	}
+	return source
+}
+
+// BuildNotifySummary summarizes results and, when baseline is not nil, how they moved against it
+func BuildNotifySummary(results, baseline []EvalResult, pass float64) NotifySummary {
+	summary := NotifySummary{Results: len(results), Pass: pass}
+	if entries := BuildLeaderboard(results).Entries; len(entries) > 0 {
+		summary.BestModel, summary.BestScore = entries[0].Model, entries[0].AvgScore
+	}
+	summary.AvgScore, summary.PassRate = scoreAndPassRate(results, pass)
+
+	current := modelAverages(results)
+	var before map[string]float64
+	if baseline != nil {
+		avg, rate := scoreAndPassRate(baseline, pass)
+		summary.AvgDelta, summary.PassDelta = summary.AvgScore-avg, summary.PassRate-rate
+		before = modelAverages(baseline)
+	}
+	for model, score := range current {
+		delta := ModelDelta{Model: model, Score: score}
+		if prev, ok := before[model]; ok {
+			delta.Delta, delta.HasBaseline = score-prev, true
+		}
+		summary.Models = append(summary.Models, delta)
+	}
+	sort.Slice(summary.Models, func(i, j int) bool {
+		if summary.Models[i].Score != summary.Models[j].Score {
+			return summary.Models[i].Score > summary.Models[j].Score
+		}
+		return summary.Models[i].Model < summary.Models[j].Model
+	})
+	return summary
+}
+
+// scoreAndPassRate returns the average combined score and the fraction of results reaching pass
+func scoreAndPassRate(results []EvalResult, pass float64) (avg, rate float64) {
+	if len(results) == 0 {
+		return 0, 0
+	}
+	scores := make([]float64, len(results))
+	passed := 0
+	for i, result := range results {
+		scores[i] = result.Scores.Combined
+		if result.Scores.Combined >= pass {
+			passed++
+		}
+	}
+	return mean(scores), float64(passed) / float64(len(results))
+}
+
+// modelAverages returns the average combined score per model
+func modelAverages(results []EvalResult) map[string]float64 {
+	scores := make(map[string][]float64)
+	for _, result := range results {
+		scores[result.Model] = append(scores[result.Model], result.Scores.Combined)
+	}
+	averages := make(map[string]float64, len(scores))
+	for model, s := range scores {
+		averages[model] = mean(s)
+	}
+	return averages
+}
+
+// notifyMaxModels caps the per-model lines of a message
+const notifyMaxModels = 10
+
+// formatDelta formats a change as "+0.042" or "-0.013"; percent formats it in percentage points
+func formatDelta(delta float64, percent bool) string {
+	if percent {
+		return fmt.Sprintf("%+.1fpp", delta*100)
+	}
+	return fmt.Sprintf("%+.3f", delta)
+}
+
+// slackMessage renders the summary as a Slack incoming webhook payload (Block Kit with a plain
+// text fallback for notifications)
+func slackMessage(s NotifySummary) map[string]any {
+	title := s.Title
+	if s.Run != "" {
+		title += " · " + s.Run
+	}
+
+	avg := fmt.Sprintf("*Avg score*\n%.3f", s.AvgScore)
+	rate := fmt.Sprintf("*Pass rate* (≥ %.2f)\n%.1f%%", s.Pass, s.PassRate*100)
+	if s.Baseline != "" {
+		avg += fmt.Sprintf(" (%s)", formatDelta(s.AvgDelta, false))
+		rate += fmt.Sprintf(" (%s)", formatDelta(s.PassDelta, true))
+	}
+	fields := []map[string]any{
+		{"type": "mrkdwn", "text": fmt.Sprintf("*Best model*\n%s (%.3f)", slackEscape(s.BestModel), s.BestScore)},
+		{"type": "mrkdwn", "text": avg},
+		{"type": "mrkdwn", "text": rate},
+		{"type": "mrkdwn", "text": fmt.Sprintf("*Results*\n%d", s.Results)},
+	}
+
+	var lines []string
+	for i, m := range s.Models {
+		if i == notifyMaxModels {
+			lines = append(lines, fmt.Sprintf("_…and %d more_", len(s.Models)-notifyMaxModels))
+			break
+		}
+		line := fmt.Sprintf("• `%s` %.3f", slackEscape(m.Model), m.Score)
+		switch {
+		case s.Baseline == "":
+		case m.HasBaseline:
+			line += " " + formatDelta(m.Delta, false)
+		default:
+			line += " _new_"
+		}
+		lines = append(lines, line)
+	}
+
+	blocks := []map[string]any{
+		{"type": "header", "text": map[string]any{"type": "plain_text", "text": title}},
+		{"type": "section", "fields": fields},
+		{"type": "section", "text": map[string]any{"type": "mrkdwn", "text": strings.Join(lines, "\n")}},
+	}
+	if s.Baseline != "" {
+		blocks = append(blocks, map[string]any{
+			"type":     "context",
+			"elements": []map[string]any{{"type": "mrkdwn", "text": "Deltas vs " + slackEscape(s.Baseline)}},
+		})
+	}
+
+	text := fmt.Sprintf("%s: best %s (%.3f), avg %.3f, pass rate %.1f%%",
+		title, s.BestModel, s.BestScore, s.AvgScore, s.PassRate*100)
+	return map[string]any{"text": text, "blocks": blocks}
+}
+
+// slackEscape escapes the characters Slack treats as markup in message text
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// postSlack sends a message to a Slack incoming webhook
+func postSlack(webhook string, message map[string]any) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	resp, err := (&http.Client{Timeout: 30 * time.Second}).Post(webhook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Slack returned %s: %s", resp.Status, bytes.TrimSpace(data))
+	}
+	return nil
+}
+
+// loadResults loads every source spec and concatenates the results
+func loadResults(specs []string) ([]EvalResult, error) {
+	var results []EvalResult
+	for _, spec := range specs {
+		source, err := NewSource(spec)
+		if err != nil {
+			return nil, err
+		}
+		loaded, err := source.Load()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", source.Name(), err)
+		}
+		results = append(results, loaded...)
+	}
+	return results, nil
+}
+
+func runNotify(args []string) int {
+	if len(args) == 0 || args[0] != "slack" {
+		fmt.Println("Usage: goevals notify slack [flags] <source1> [source2] [...]")
+		return 2
+	}
+	fs := newFlagSet("notify slack", "[flags] <source1> [source2] [...]",
+		"Posts a summary of the latest run (best model, avg score, pass rate and deltas vs the",
+		"previous run or --baseline) to a Slack channel")
+	webhook := fs.String("webhook", os.Getenv("SLACK_WEBHOOK_URL"), "Slack incoming webhook `url` (default $SLACK_WEBHOOK_URL)")
+	baseline := fs.String("baseline", "", "compare against the results in this `source` instead of the previous run")
+	title := fs.String("title", "Eval run", "message `title`")
+	pass := fs.Float64("pass", bandGoodMin, "combined `score` a result needs to pass")
+	weights := weightsFlag(fs)
+	dryRun := fs.Bool("dry-run", false, "print the message JSON instead of posting it")
+	sources := parseFlags(fs, args[1:])
+	if len(sources) == 0 {
+		fs.Usage()
+		return 2
+	}
+	if *webhook == "" && !*dryRun {
+		fmt.Fprintln(os.Stderr, "Error: --webhook or SLACK_WEBHOOK_URL is required")
+		return 2
+	}
+	results, err := commandResults(sources, *weights)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if len(results) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no results to summarize")
+		return 2
+	}
+	var base []EvalResult
+	if *baseline != "" {
+		if base, err = commandResults([]string{*baseline}, *weights); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+	}
+
+	latest, previous, run, previousRun := splitLatestRun(results)
+	baseName := previousRun
+	if *baseline != "" {
+		previous, baseName = base, *baseline
+	}
+	summary := BuildNotifySummary(latest, previous, *pass)
+	summary.Title, summary.Run = *title, run
+	if previous != nil {
+		summary.Baseline = baseName
+	}
+
+	message := slackMessage(summary)
+	if *dryRun {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(message); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	if err := postSlack(*webhook, message); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "Posted summary of %d results to Slack\n", summary.Results)
+	return 0
+}
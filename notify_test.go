@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBuildNotifySummary(t *testing.T) {
+	result := func(ts, run, model string, score float64) EvalResult {
+		return EvalResult{Timestamp: ts, Model: model, Metadata: map[string]any{"run_id": run}, Scores: ScoreBreakdown{Combined: score}}
+	}
+	results := []EvalResult{
+		result("2025-01-01T00:00:00Z", "r1", "a", 0.6),
+		result("2025-01-01T00:00:00Z", "r1", "a", 0.8),
+		result("2025-01-02T00:00:00Z", "r2", "a", 0.9),
+		result("2025-01-02T00:00:00Z", "r2", "a", 0.7),
+		result("2025-01-02T00:00:00Z", "r2", "b", 0.2),
+	}
+
+	latest, previous, run, previousRun := splitLatestRun(results)
+	if len(latest) != 3 || len(previous) != 2 || run != "r2" || previousRun != "r1" {
+		t.Fatalf("split = %d, %d, %q, %q", len(latest), len(previous), run, previousRun)
+	}
+
+	s := BuildNotifySummary(latest, previous, 0.7)
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	if s.BestModel != "a" || !near(s.BestScore, 0.8) || !near(s.AvgScore, 0.6) || !near(s.PassRate, 2.0/3) {
+		t.Errorf("summary = %+v", s)
+	}
+	if !near(s.AvgDelta, -0.1) || !near(s.PassDelta, 2.0/3-0.5) {
+		t.Errorf("deltas = %v, %v", s.AvgDelta, s.PassDelta)
+	}
+	if len(s.Models) != 2 || s.Models[0].Model != "a" || !s.Models[0].HasBaseline || !near(s.Models[0].Delta, 0.1) || s.Models[1].HasBaseline {
+		t.Errorf("models = %+v", s.Models)
+	}
+
+	// A single run has nothing to compare against
+	if _, previous, run, _ := splitLatestRun(results[:2]); previous != nil || run != "r1" {
+		t.Errorf("single run: previous = %v, run = %q", previous, run)
+	}
+}
+
+func TestPostSlack(t *testing.T) {
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Errorf("invalid JSON: %v", err)
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer server.Close()
+
+	s := NotifySummary{Title: "Nightly", Run: "r2", Results: 3, BestModel: "<a>", BestScore: 0.8, Pass: 0.7, Baseline: "r1",
+		Models: []ModelDelta{{Model: "<a>", Score: 0.8, Delta: 0.1, HasBaseline: true}, {Model: "b", Score: 0.2}}}
+	if err := postSlack(server.URL, slackMessage(s)); err != nil {
+		t.Fatal(err)
+	}
+	if text, _ := got["text"].(string); !strings.HasPrefix(text, "Nightly · r2: best <a>") {
+		t.Errorf("text = %q", text)
+	}
+	blocks := fmt.Sprint(got["blocks"])
+	for _, want := range []string{"&lt;a&gt;", "+0.100", "_new_", "Deltas vs r1"} {
+		if !strings.Contains(blocks, want) {
+			t.Errorf("blocks missing %q: %s", want, blocks)
+		}
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_payload", http.StatusBadRequest)
+	}))
+	defer failing.Close()
+	if err := postSlack(failing.URL, slackMessage(s)); err == nil || !strings.Contains(err.Error(), "invalid_payload") {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Humanized numbers for stat cards and tables: 1,234 below compactFrom, 12.4k / 1.2M / 3.4B
+// above it. Pages show the exact value on hover; APIs always return raw numbers.
+
+const compactFrom = 10000 // Smaller numbers stay exact, with thousand separators
+
+// groupThousands formats v with comma thousand separators, keeping up to two decimals
+func groupThousands(v float64) string {
+	s := strconv.FormatFloat(math.Abs(v), 'f', -1, 64)
+	if math.Abs(v) != math.Trunc(math.Abs(v)) {
+		s = strconv.FormatFloat(math.Abs(v), 'f', 2, 64)
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	whole, frac, _ := strings.Cut(s, ".")
+	var b strings.Builder
+	if v < 0 {
+		b.WriteByte('-')
+	}
+	for i, c := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	if frac != "" {
+		b.WriteString("." + frac)
+	}
+	return b.String()
+}
+
+// compactNumber abbreviates v as 12.4k, 1.2M or 3.4B from compactFrom on, otherwise it is
+// groupThousands; one decimal is kept below 100 of a unit, none above
+func compactNumber(v float64) string {
+	abs := math.Abs(v)
+	if abs < compactFrom {
+		return groupThousands(v)
+	}
+	units := []struct {
+		size   float64
+		suffix string
+	}{{1e9, "B"}, {1e6, "M"}, {1e3, "k"}}
+	for i, unit := range units {
+		if abs < unit.size {
+			continue
+		}
+		scaled := v / unit.size
+		s := strconv.FormatFloat(scaled, 'f', 1, 64)
+		if math.Abs(scaled) >= 100 {
+			s = strconv.FormatFloat(scaled, 'f', 0, 64)
+		}
+		// Rounding up can reach the next unit, e.g. 999,960 -> 1000k -> 1.0M
+		if i > 0 && math.Abs(math.Round(scaled)) >= 1000 {
+			return compactNumber(math.Copysign(units[i-1].size, v))
+		}
+		return strings.TrimSuffix(s, ".0") + unit.suffix
+	}
+	return groupThousands(v)
+}
+
+// toFloat converts the numeric types templates pass around
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// humanNumber renders a count for a page: compact with the exact value in a tooltip when it
+// was abbreviated, grouped otherwise
+func humanNumber(v any) template.HTML {
+	n, ok := toFloat(v)
+	if !ok {
+		return template.HTML(template.HTMLEscapeString(fmt.Sprint(v)))
+	}
+	compact := compactNumber(n)
+	exact := groupThousands(n)
+	if compact == exact {
+		return template.HTML(compact)
+	}
+	return template.HTML(fmt.Sprintf(`<span class="num-compact" title="%s">%s</span>`, exact, compact))
+}
+
+// isCountField reports whether a custom field holds a count or total (tokens, calls) rather than
+// a config parameter, so the comparison table humanizes it; parameters like seeds stay exact
+func isCountField(name string) bool {
+	name = strings.ToLower(name)
+	return strings.Contains(name, "token") || strings.HasSuffix(name, "count") || strings.HasSuffix(name, "total")
+}
@@ -0,0 +1,50 @@
+package main
+
+import "testing"
+
+func TestCompactNumber(t *testing.T) {
+	for _, tc := range []struct {
+		in      float64
+		grouped string
+		compact string
+	}{
+		{0, "0", "0"},
+		{999, "999", "999"},
+		{1234, "1,234", "1,234"},
+		{-9876.5, "-9,876.5", "-9,876.5"},
+		{12400, "12,400", "12.4k"},
+		{10000, "10,000", "10k"},
+		{123456, "123,456", "123k"},
+		{999960, "999,960", "1M"},
+		{1234567, "1,234,567", "1.2M"},
+		{-2500000000, "-2,500,000,000", "-2.5B"},
+	} {
+		if got := groupThousands(tc.in); got != tc.grouped {
+			t.Errorf("groupThousands(%v) = %q, want %q", tc.in, got, tc.grouped)
+		}
+		if got := compactNumber(tc.in); got != tc.compact {
+			t.Errorf("compactNumber(%v) = %q, want %q", tc.in, got, tc.compact)
+		}
+	}
+}
+
+func TestHumanNumber(t *testing.T) {
+	if got := humanNumber(42); got != "42" {
+		t.Errorf("humanNumber(42) = %q", got)
+	}
+	if got := humanNumber(int64(12400)); got != `<span class="num-compact" title="12,400">12.4k</span>` {
+		t.Errorf("humanNumber(12400) = %q", got)
+	}
+	if got := humanNumber("1200000"); got != `<span class="num-compact" title="1,200,000">1.2M</span>` {
+		t.Errorf("humanNumber(\"1200000\") = %q", got)
+	}
+	if got := humanNumber("<b>"); got != "&lt;b&gt;" {
+		t.Errorf("non-numeric value not escaped: %q", got)
+	}
+
+	for name, want := range map[string]bool{"total_tokens": true, "prompt_tokens": true, "retry_count": true, "cost_total": true, "seed": false, "chunk_size": false} {
+		if got := isCountField(name); got != want {
+			t.Errorf("isCountField(%q) = %v", name, got)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// objectListInterval is how often an object storage source lists its bucket again
+// Loads in between serve the cached results, so dashboard requests don't turn into bucket listings
+const objectListInterval = 30 * time.Second
+
+// ObjectSource loads every JSONL object in an S3/GCS bucket matching a pattern, so the
+// dashboard can serve eval logs uploaded by CI jobs. The bucket is listed again at most every
+// objectListInterval; only new or rewritten objects (by ETag and size) are downloaded
+type ObjectSource struct {
+	name    string // The command line argument
+	scheme  string // s3 or gs
+	client  objectStore
+	bucket  string
+	prefix  string // Listing prefix: the pattern up to its first wildcard
+	pattern string // Matched against whole keys with path.Match
+
+	mu       sync.Mutex
+	objects  map[string]*cachedObject // By key
+	listed   time.Time                // Last listing attempt
+	snapshot []EvalResult
+	lastErr  error
+	now      func() time.Time
+}
+
+// cachedObject is the parsed content of one object as of its last download
+type cachedObject struct {
+	etag    string
+	size    int64
+	results []EvalResult
+	invalid int // Lines skipped as invalid
+}
+
+// NewObjectSource creates a source from s3://bucket/path/*.jsonl or gs://bucket/path/*.jsonl
+// A URL ending with "/" means every *.jsonl object directly under it, one without wildcards a
+// single object. Credentials come from AWS_ACCESS_KEY_ID / AWS_SECRET_ACCESS_KEY (HMAC keys for GCS)
+func NewObjectSource(rawURL string) (*ObjectSource, error) {
+	// Not url.Parse: "?" is a wildcard here, not the start of a query
+	scheme, rest, _ := strings.Cut(rawURL, "://")
+	bucket, pattern, _ := strings.Cut(rest, "/")
+	if (scheme != "s3" && scheme != "gs") || bucket == "" {
+		return nil, fmt.Errorf("object storage URL must look like s3://bucket/path/*.jsonl or gs://bucket/path/*.jsonl, got %q", rawURL)
+	}
+	client, err := newS3ClientFromEnv(scheme == "gs")
+	if err != nil {
+		return nil, err
+	}
+	return newObjectSource(rawURL, scheme, bucket, pattern, client)
+}
+
+func newObjectSource(name, scheme, bucket, pattern string, client objectStore) (*ObjectSource, error) {
+	if pattern == "" || strings.HasSuffix(pattern, "/") {
+		pattern += "*.jsonl"
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %s: %w", name, err)
+	}
+	prefix := pattern
+	if i := strings.IndexAny(pattern, "*?[\\"); i >= 0 {
+		prefix = pattern[:i]
+	}
+	return &ObjectSource{
+		name:    name,
+		scheme:  scheme,
+		client:  client,
+		bucket:  bucket,
+		prefix:  prefix,
+		pattern: pattern,
+		objects: make(map[string]*cachedObject),
+		now:     time.Now,
+	}, nil
+}
+
+func (s *ObjectSource) Name() string { return s.name }
+
+// Load returns the results of all matching objects, each labeled with its own URL
+// When the listing fails the last snapshot is served with the error; an object that fails to
+// download contributes its last good results
+func (s *ObjectSource) Load() ([]EvalResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if !s.listed.IsZero() && now.Sub(s.listed) < objectListInterval {
+		return s.snapshot, s.lastErr
+	}
+	s.listed = now
+
+	listing, err := s.client.ListObjects(s.bucket, s.prefix)
+	if err != nil {
+		s.lastErr = fmt.Errorf("failed to list %s: %w", s.name, err)
+		if len(s.objects) > 0 {
+			s.lastErr = fmt.Errorf("%w (serving last good snapshot, retrying in %s)", s.lastErr, objectListInterval)
+		}
+		return s.snapshot, s.lastErr
+	}
+
+	var all []EvalResult
+	var errs []error
+	seen := make(map[string]bool)
+	for _, object := range listing {
+		if ok, _ := path.Match(s.pattern, object.Key); !ok {
+			continue
+		}
+		seen[object.Key] = true
+		cached := s.objects[object.Key]
+		// Without an ETag the size alone would miss rewrites of the same length, so download again
+		if cached == nil || object.ETag == "" || cached.etag != object.ETag || cached.size != object.Size {
+			fetched, err := s.fetch(object.Key)
+			switch {
+			case err == nil:
+				cached = fetched
+				cached.etag, cached.size = object.ETag, object.Size
+				s.objects[object.Key] = cached
+			case cached == nil:
+				errs = append(errs, err)
+				continue
+			default:
+				errs = append(errs, err) // Keep serving what the object held before
+			}
+		}
+		all = append(all, cached.results...)
+	}
+	for key := range s.objects {
+		if !seen[key] {
+			delete(s.objects, key)
+		}
+	}
+	s.snapshot, s.lastErr = all, errors.Join(errs...)
+	return s.snapshot, s.lastErr
+}
+
+// fetch downloads and parses one object, gunzipping *.gz objects
+func (s *ObjectSource) fetch(key string) (*cachedObject, error) {
+	origin := fmt.Sprintf("%s://%s/%s", s.scheme, s.bucket, key)
+	data, err := s.client.GetObject(s.bucket, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", origin, err)
+	}
+
+	var r io.Reader = bytes.NewReader(data)
+	if strings.HasSuffix(key, ".gz") {
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", origin, err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+	results, invalid, err := parseJSONL(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", origin, err)
+	}
+	for i := range results {
+		results[i].Origin = origin
+	}
+	return &cachedObject{results: results, invalid: invalid}, nil
+}
+
+// InvalidLines returns how many lines of the served objects were skipped as invalid
+func (s *ObjectSource) InvalidLines() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, object := range s.objects {
+		n += object.invalid
+	}
+	return n
+}
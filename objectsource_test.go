@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestObjectSource(t *testing.T) {
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte(`{"timestamp":"2025-01-03T10:00:00Z","model":"c","test_id":"t1","scores":{"combined":0.9}}` + "\n"))
+	zw.Close()
+
+	bucket := &fakeS3{objects: map[string][]byte{
+		"evals/ci/run1.jsonl":       []byte(`{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"t1","scores":{"combined":0.5}}` + "\nnot json\n"),
+		"evals/ci/run2.jsonl":       []byte(`{"timestamp":"2025-01-02T10:00:00Z","model":"b","test_id":"t1","scores":{"combined":0.7}}` + "\n"),
+		"evals/ci/run3.jsonl.gz":    gz.Bytes(),
+		"evals/ci/notes.txt":        []byte("not results"),
+		"evals/ci/nested/run.jsonl": []byte(`{"timestamp":"2025-01-04T10:00:00Z","model":"d","test_id":"t1","scores":{"combined":0.1}}` + "\n"),
+	}}
+	srv := httptest.NewServer(bucket)
+	defer srv.Close()
+
+	t.Setenv("AWS_ENDPOINT_URL", srv.URL)
+	t.Setenv("AWS_ACCESS_KEY_ID", "key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	src, err := NewSource("s3://evals/ci/*.jsonl*")
+	if err != nil {
+		t.Fatalf("NewSource: %v", err)
+	}
+	s := src.(*ObjectSource)
+	now := time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	origins := func(results []EvalResult) []string {
+		var list []string
+		for _, r := range results {
+			list = append(list, r.Model+" "+r.Origin)
+		}
+		slices.Sort(list)
+		return list
+	}
+
+	results, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := []string{"a s3://evals/ci/run1.jsonl", "b s3://evals/ci/run2.jsonl", "c s3://evals/ci/run3.jsonl.gz"}
+	if got := origins(results); !slices.Equal(got, want) {
+		t.Fatalf("results = %v, want %v", got, want)
+	}
+
+	// New uploads show up on the next listing, not before
+	bucket.mu.Lock()
+	bucket.objects["evals/ci/run4.jsonl"] = []byte(`{"timestamp":"2025-01-05T10:00:00Z","model":"e","test_id":"t1","scores":{"combined":0.2}}` + "\n")
+	delete(bucket.objects, "evals/ci/run2.jsonl")
+	gets := bucket.gets
+	bucket.mu.Unlock()
+	if results, _ := s.Load(); len(results) != 3 {
+		t.Errorf("listed again within the interval: %v", origins(results))
+	}
+
+	now = now.Add(objectListInterval)
+	results, err = s.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want = []string{"a s3://evals/ci/run1.jsonl", "c s3://evals/ci/run3.jsonl.gz", "e s3://evals/ci/run4.jsonl"}
+	if got := origins(results); !slices.Equal(got, want) {
+		t.Errorf("after upload = %v, want %v", got, want)
+	}
+	bucket.mu.Lock()
+	if bucket.gets-gets != 1 {
+		t.Errorf("downloaded %d objects, want only the new one", bucket.gets-gets)
+	}
+
+	// Rewritten objects are downloaded again, a failed listing keeps the snapshot
+	bucket.objects["evals/ci/run1.jsonl"] = []byte(`{"timestamp":"2025-01-01T10:00:00Z","model":"f","test_id":"t1","scores":{"combined":0.5}}` + "\n")
+	bucket.failList = true
+	bucket.mu.Unlock()
+
+	now = now.Add(objectListInterval)
+	results, err = s.Load()
+	if err == nil || !strings.Contains(err.Error(), "last good snapshot") || len(results) != 3 {
+		t.Errorf("failed listing = %d results, %v", len(results), err)
+	}
+
+	bucket.mu.Lock()
+	bucket.failList = false
+	bucket.mu.Unlock()
+	now = now.Add(objectListInterval)
+	results, err = s.Load()
+	want = []string{"c s3://evals/ci/run3.jsonl.gz", "e s3://evals/ci/run4.jsonl", "f s3://evals/ci/run1.jsonl"}
+	if got := origins(results); err != nil || !slices.Equal(got, want) {
+		t.Errorf("after rewrite = %v, %v, want %v", got, err, want)
+	}
+}
+
+func TestObjectSourcePatterns(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	tests := []struct {
+		arg, prefix, pattern string
+	}{
+		{"s3://evals/ci/*.jsonl", "ci/", "ci/*.jsonl"},
+		{"gs://evals/ci/", "ci/", "ci/*.jsonl"},
+		{"s3://evals", "", "*.jsonl"},
+		{"s3://evals/ci/run-?.jsonl", "ci/run-", "ci/run-?.jsonl"},
+		{"s3://evals/ci/latest.jsonl", "ci/latest.jsonl", "ci/latest.jsonl"},
+	}
+	for _, tt := range tests {
+		s, err := NewObjectSource(tt.arg)
+		if err != nil {
+			t.Errorf("NewObjectSource(%q): %v", tt.arg, err)
+			continue
+		}
+		if s.prefix != tt.prefix || s.pattern != tt.pattern {
+			t.Errorf("NewObjectSource(%q) = prefix %q, pattern %q, want %q, %q", tt.arg, s.prefix, s.pattern, tt.prefix, tt.pattern)
+		}
+	}
+	for _, arg := range []string{"s3:///ci/*.jsonl", "s3://evals/ci/[.jsonl"} {
+		if _, err := NewObjectSource(arg); err == nil {
+			t.Errorf("NewObjectSource(%q) should fail", arg)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Page sizes of /api/evals?limit=
+const (
+	defaultPageSize = 1000 // With a cursor but no limit
+	maxPageSize     = 10000
+)
+
+// EvalsPage is the /api/evals response when paginating with limit or cursor
+type EvalsPage struct {
+	Results    []EvalResult `json:"results"`
+	NextCursor string       `json:"next_cursor,omitempty"` // Pass as cursor for the next page, empty on the last one
+}
+
+var errInvalidCursor = errors.New("invalid cursor, pass next_cursor of the previous page")
+
+// pageCursor is the position after the last result of a page: its resultKey, which sorts by
+// (timestamp, test_id, config), and how many results with that key were already returned,
+// since --dedupe keep-all can keep several
+type pageCursor struct {
+	key  string
+	seen int
+}
+
+// encode makes the cursor opaque and URL safe, clients only pass it back
+func (c pageCursor) encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.key + "\x00" + strconv.Itoa(c.seen)))
+}
+
+func decodeCursor(s string) (pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return pageCursor{}, errInvalidCursor
+	}
+	i := strings.LastIndexByte(string(raw), 0) // The key itself contains NUL separators
+	if i < 0 {
+		return pageCursor{}, errInvalidCursor
+	}
+	n, err := strconv.Atoi(string(raw[i+1:]))
+	if err != nil || n < 1 {
+		return pageCursor{}, errInvalidCursor
+	}
+	return pageCursor{key: string(raw[:i]), seen: n}, nil
+}
+
+// pageRequest reads limit and cursor from the query, paged is false when neither is set
+func pageRequest(r *http.Request) (limit int, after *pageCursor, paged bool, err error) {
+	params := r.URL.Query()
+	rawLimit, rawCursor := params.Get("limit"), params.Get("cursor")
+	if rawLimit == "" && rawCursor == "" {
+		return 0, nil, false, nil
+	}
+	limit = defaultPageSize
+	if rawLimit != "" {
+		if limit, err = strconv.Atoi(rawLimit); err != nil || limit < 1 || limit > maxPageSize {
+			return 0, nil, false, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+		}
+	}
+	if rawCursor != "" {
+		c, err := decodeCursor(rawCursor)
+		if err != nil {
+			return 0, nil, false, err
+		}
+		after = &c
+	}
+	return limit, after, true, nil
+}
+
+// paginate returns up to limit results following after (from the start when nil), in a stable
+// order by timestamp, test_id and config
+// Results added in between show up on a later page when they sort after the cursor
+func paginate(results []EvalResult, after *pageCursor, limit int) EvalsPage {
+	keys := make([]string, len(results))
+	order := make([]int, len(results))
+	for i, result := range results {
+		keys[i], order[i] = resultKey(result), i
+	}
+	slices.SortStableFunc(order, func(a, b int) int { return strings.Compare(keys[a], keys[b]) })
+
+	// firstWith is the position of the first result with key >= k
+	firstWith := func(k string) int {
+		i, _ := slices.BinarySearchFunc(order, k, func(o int, k string) int { return strings.Compare(keys[o], k) })
+		return i
+	}
+	start := 0
+	if after != nil {
+		start = firstWith(after.key)
+		for n := 0; n < after.seen && start < len(order) && keys[order[start]] == after.key; n++ {
+			start++
+		}
+	}
+	end := min(start+limit, len(order))
+
+	page := EvalsPage{Results: make([]EvalResult, 0, end-start)}
+	for _, i := range order[start:end] {
+		page.Results = append(page.Results, results[i])
+	}
+	if end < len(order) {
+		last := keys[order[end-1]]
+		page.NextCursor = pageCursor{key: last, seen: end - firstWith(last)}.encode()
+	}
+	return page
+}
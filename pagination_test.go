@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPaginate(t *testing.T) {
+	// Out of order, with ties on timestamp and a duplicate key (--dedupe keep-all)
+	var results []EvalResult
+	for i := 9; i >= 0; i-- {
+		results = append(results, EvalResult{Timestamp: fmt.Sprintf("2025-01-01T10:00:0%dZ", i/2), Model: "a", TestID: fmt.Sprintf("t%d", i)})
+	}
+	results = append(results, results[3])
+
+	var got []string
+	var after *pageCursor
+	for pages := 0; ; pages++ {
+		if pages > 10 {
+			t.Fatal("pagination does not end")
+		}
+		page := paginate(results, after, 3)
+		for _, r := range page.Results {
+			got = append(got, r.TestID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		c, err := decodeCursor(page.NextCursor)
+		if err != nil {
+			t.Fatalf("decodeCursor(%q): %v", page.NextCursor, err)
+		}
+		after = &c
+	}
+	if want := "t0,t1,t2,t3,t4,t5,t6,t6,t7,t8,t9"; strings.Join(got, ",") != want {
+		t.Errorf("pages = %v, want %s", got, want)
+	}
+
+	if _, err := decodeCursor("not a cursor"); err == nil {
+		t.Error("invalid cursor accepted")
+	}
+}
+
+func TestEvalsAPIPagination(t *testing.T) {
+	var lines strings.Builder
+	for i := 0; i < 5; i++ {
+		fmt.Fprintf(&lines, `{"timestamp":"2025-01-01T10:00:0%dZ","model":"a","test_id":"t%d","scores":{"combined":0.5}}`+"\n", 4-i, 4-i)
+	}
+	withStore(t, lines.String())
+
+	get := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		evalsAPIHandler(w, httptest.NewRequest(http.MethodGet, "/api/evals?"+query, nil))
+		return w
+	}
+	var page EvalsPage
+	w := get("limit=2")
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil || len(page.Results) != 2 || page.Results[0].TestID != "t0" || page.NextCursor == "" {
+		t.Fatalf("first page = %d %s", w.Code, w.Body)
+	}
+	w = get("limit=3&cursor=" + page.NextCursor)
+	page = EvalsPage{}
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil || len(page.Results) != 3 || page.Results[0].TestID != "t2" || page.NextCursor != "" {
+		t.Errorf("last page = %d %s", w.Code, w.Body)
+	}
+
+	w = get("format=ndjson&limit=4")
+	if n := strings.Count(w.Body.String(), "\n"); n != 4 || w.Header().Get("X-Next-Cursor") == "" {
+		t.Errorf("ndjson page = %d lines, X-Next-Cursor %q", n, w.Header().Get("X-Next-Cursor"))
+	}
+	for _, query := range []string{"limit=0", "limit=x", "cursor=bad"} {
+		if w := get(query); w.Code != http.StatusBadRequest {
+			t.Errorf("%s = %d", query, w.Code)
+		}
+	}
+}
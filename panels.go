@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Panel is an extra dashboard panel declared in the --panels file, so teams can add their own
+// views without forking: either a Go template fragment rendered with the dashboard's stats, or
+// a page embedded in an iframe that fetches them from the stats API
+type Panel struct {
+	Title    string `json:"title"`
+	Template string `json:"template,omitempty"` // Executed with the same fields /api/evals returns
+	URL      string `json:"url,omitempty"`      // Embedded page, given the stats API URL as ?stats=
+	Height   int    `json:"height,omitempty"`   // iframe height in pixels, default defaultPanelHeight
+
+	tmpl *template.Template
+}
+
+// RenderedPanel is a panel ready to be placed on the dashboard
+type RenderedPanel struct {
+	Title  string
+	HTML   template.HTML // Template panels
+	URL    string        // URL panels, with the stats parameter added
+	Height int
+}
+
+const defaultPanelHeight = 320
+
+// panels are the extra dashboard panels from --panels, in file order
+var panels []Panel
+
+// loadPanels reads and validates a panels file, an empty path means no panels
+// Templates are parsed here so a broken one fails at startup rather than on every page load
+func loadPanels(path string) ([]Panel, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read panels: %w", err)
+	}
+	var list []Panel
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("invalid panels file %s: %w", path, err)
+	}
+	for i := range list {
+		p := &list[i]
+		switch {
+		case p.Title == "":
+			return nil, fmt.Errorf("invalid panels file %s: panel %d has no title", path, i+1)
+		case (p.Template == "") == (p.URL == ""):
+			return nil, fmt.Errorf("invalid panels file %s: panel %q needs either a template or a url", path, p.Title)
+		case p.URL != "" && !strings.HasPrefix(p.URL, "/") && !strings.HasPrefix(p.URL, "http://") && !strings.HasPrefix(p.URL, "https://"):
+			return nil, fmt.Errorf("invalid panels file %s: panel %q url must be http(s) or a path like /static/panel.html", path, p.Title)
+		}
+		if p.Template != "" {
+			if p.tmpl, err = template.New(p.Title).Funcs(pageFuncs).Parse(p.Template); err != nil {
+				return nil, fmt.Errorf("invalid panels file %s: panel %q: %w", path, p.Title, err)
+			}
+		}
+		if p.Height <= 0 {
+			p.Height = defaultPanelHeight
+		}
+	}
+	return list, nil
+}
+
+// renderPanels prepares the configured panels for a dashboard showing data, with config names
+// from labels
+// A template that fails shows its error in place of the panel instead of breaking the page
+func renderPanels(data DashboardData, statsURL string, labels *labelStore) []RenderedPanel {
+	var rendered []RenderedPanel
+	for _, p := range panels {
+		panel := RenderedPanel{Title: p.Title, Height: p.Height}
+		if p.tmpl != nil {
+			var buf bytes.Buffer
+			tmpl := template.Must(p.tmpl.Clone()).Funcs(template.FuncMap{"configLabel": labels.LabelHTML, "configName": labels.Label})
+			if err := tmpl.Execute(&buf, data); err != nil {
+				log.Printf("Panel %q: %v", p.Title, err)
+				buf.Reset()
+				fmt.Fprintf(&buf, `<p class="panel-error">Panel failed to render: %s</p>`, template.HTMLEscapeString(err.Error()))
+			}
+			panel.HTML = template.HTML(buf.String())
+		} else {
+			sep := "?"
+			if strings.Contains(p.URL, "?") {
+				sep = "&"
+			}
+			panel.URL = p.URL + sep + "stats=" + url.QueryEscape(statsURL)
+		}
+		rendered = append(rendered, panel)
+	}
+	return rendered
+}
+
+// statsAPIURL is the absolute /api/evals URL matching a dashboard request, for URL panels that
+// may live on another host
+func statsAPIURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	u := url.URL{Scheme: scheme, Host: r.Host, Path: "/api/evals", RawQuery: r.URL.RawQuery}
+	return u.String()
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadPanels(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "panels.json")
+	os.WriteFile(path, []byte(`[
+		{"title": "Cost", "template": "{{ range .Models }}<b>{{ . }}</b>{{ end }} {{ human .TotalTests }} tests"},
+		{"title": "Tracker", "url": "/static/tracker.html?team=ml", "height": 200}
+	]`), 0o644)
+	list, err := loadPanels(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].tmpl == nil || list[1].Height != 200 {
+		t.Errorf("panels = %+v", list)
+	}
+	if list, err := loadPanels(""); list != nil || err != nil {
+		t.Errorf("empty path = %v, %v", list, err)
+	}
+
+	for _, bad := range []string{
+		`[{"template": "x"}]`,
+		`[{"title": "Both", "template": "x", "url": "/x"}]`,
+		`[{"title": "Neither"}]`,
+		`[{"title": "Script", "url": "javascript:alert(1)"}]`,
+		`[{"title": "Broken", "template": "{{ .Models "}]`,
+		`{"title": "Not a list"}`,
+	} {
+		os.WriteFile(path, []byte(bad), 0o644)
+		if _, err := loadPanels(path); err == nil {
+			t.Errorf("loadPanels accepted %s", bad)
+		}
+	}
+}
+
+func TestRenderPanels(t *testing.T) {
+	defer func(old []Panel) { panels = old }(panels)
+	path := filepath.Join(t.TempDir(), "panels.json")
+	os.WriteFile(path, []byte(`[
+		{"title": "Models", "template": "{{ range .Models }}<b>{{ . }}</b>{{ end }}"},
+		{"title": "Failing", "template": "{{ index .ModelStats \"missing\" | printf \"%v\" }}{{ .Nope }}"},
+		{"title": "Tracker", "url": "/static/tracker.html?team=ml"}
+	]`), 0o644)
+	var err error
+	if panels, err = loadPanels(path); err != nil {
+		t.Fatal(err)
+	}
+
+	data := CalculateStats([]EvalResult{{Model: "gpt-4", Scores: ScoreBreakdown{Combined: 0.9}}}, nil)
+	rendered := renderPanels(data, "http://example/api/evals?model=gpt-4", nil)
+	if len(rendered) != 3 {
+		t.Fatalf("rendered %d panels", len(rendered))
+	}
+	if rendered[0].HTML != "<b>gpt-4</b>" {
+		t.Errorf("template panel = %q", rendered[0].HTML)
+	}
+	if !strings.Contains(string(rendered[1].HTML), "panel-error") {
+		t.Errorf("failing panel = %q", rendered[1].HTML)
+	}
+	if rendered[2].URL != "/static/tracker.html?team=ml&stats=http%3A%2F%2Fexample%2Fapi%2Fevals%3Fmodel%3Dgpt-4" || rendered[2].Height != defaultPanelHeight {
+		t.Errorf("url panel = %+v", rendered[2])
+	}
+
+	withStore(t, `{"timestamp":"2025-01-01T10:00:00Z","model":"gpt-4","scores":{"combined":0.9}}`)
+	rec := httptest.NewRecorder()
+	dashboardHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := rec.Body.String()
+	for _, want := range []string{"<h2>Models</h2>", "<b>gpt-4</b>", `class="plugin-frame" src="/static/tracker.html?team=ml&amp;stats=http%3A%2F%2Fexample.com%2Fapi%2Fevals"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("dashboard is missing %q", want)
+		}
+	}
+}
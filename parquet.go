@@ -0,0 +1,379 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+)
+
+// Parquet physical types, repetition and codec values from the format's parquet.thrift
+const (
+	parquetBoolean   = 0
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+
+	parquetOptional = 1
+	parquetUTF8     = 0 // ConvertedType of strings
+	parquetGzip     = 2
+	parquetPlain    = 0
+	parquetRLE      = 3
+)
+
+// parquetColumn is one flat, nullable column of the export; values are nil (null), bool, int64,
+// float64 or string according to kind
+type parquetColumn struct {
+	name   string
+	kind   int
+	values []any
+}
+
+// resultsTable flattens results into columns: the core fields, one score_<name> column per
+// custom score and one field_<name> column per custom field
+// Custom fields holding only numbers or only booleans get a typed column, others are strings
+// (JSON for objects and lists); metadata is kept as one JSON column
+func resultsTable(results []EvalResult) []parquetColumn {
+	str := func(name string, get func(EvalResult) string) parquetColumn {
+		c := parquetColumn{name: name, kind: parquetByteArray, values: make([]any, len(results))}
+		for i, result := range results {
+			if v := get(result); v != "" {
+				c.values[i] = v
+			}
+		}
+		return c
+	}
+	columns := []parquetColumn{
+		str("timestamp", func(r EvalResult) string { return r.Timestamp }),
+		str("model", func(r EvalResult) string { return r.Model }),
+		str("config", buildConfigKey),
+		str("test_id", func(r EvalResult) string { return r.TestID }),
+		str("run_id", func(r EvalResult) string { runID, _ := r.Metadata["run_id"].(string); return runID }),
+		str("source", func(r EvalResult) string { return r.Origin }),
+		str("dataset", EvalResult.Dataset),
+		str("question", func(r EvalResult) string { return r.Question }),
+		str("response", func(r EvalResult) string { return r.Response }),
+		str("expected", func(r EvalResult) string { return r.Expected }),
+		str("error", func(r EvalResult) string { return r.Error }),
+		str("judge_model", func(r EvalResult) string { return r.JudgeModel }),
+	}
+
+	timing := parquetColumn{name: "response_time_ms", kind: parquetInt64, values: make([]any, len(results))}
+	combined := parquetColumn{name: "score_combined", kind: parquetDouble, values: make([]any, len(results))}
+	metadata := parquetColumn{name: "metadata", kind: parquetByteArray, values: make([]any, len(results))}
+	scores := make(map[string]bool)
+	fields := make(map[string]bool)
+	fieldValues := make([]map[string]any, len(results)) // See EvalResult.Fields
+	for i, result := range results {
+		timing.values[i] = result.ResponseTimeMS
+		combined.values[i] = result.Scores.Combined
+		if len(result.Metadata) > 0 {
+			metadata.values[i] = parquetJSON(result.Metadata)
+		}
+		for name := range result.Scores.Custom {
+			scores[name] = true
+		}
+		fieldValues[i] = result.Fields()
+		for name := range fieldValues[i] {
+			fields[name] = true
+		}
+	}
+	columns = append(columns, timing, metadata, combined)
+
+	for _, name := range slices.Sorted(maps.Keys(scores)) {
+		c := parquetColumn{name: "score_" + name, kind: parquetDouble, values: make([]any, len(results))}
+		for i, result := range results {
+			if v, ok := result.Scores.Custom[name]; ok {
+				c.values[i] = v
+			}
+		}
+		columns = append(columns, c)
+	}
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		c := parquetColumn{name: "field_" + name, values: make([]any, len(results))}
+		numbers, bools := true, true
+		for i := range results {
+			v, ok := fieldValues[i][name]
+			if !ok || v == nil {
+				continue
+			}
+			c.values[i] = v
+			_, isNumber := v.(float64)
+			_, isBool := v.(bool)
+			numbers = numbers && isNumber
+			bools = bools && isBool
+		}
+		switch {
+		case numbers:
+			c.kind = parquetDouble
+		case bools:
+			c.kind = parquetBoolean
+		default:
+			c.kind = parquetByteArray
+			for i, v := range c.values {
+				if s, ok := v.(string); ok {
+					c.values[i] = s
+				} else if v != nil {
+					c.values[i] = parquetJSON(v)
+				}
+			}
+		}
+		columns = append(columns, c)
+	}
+	return columns
+}
+
+// parquetJSON is the JSON text of a value stored in a string column
+func parquetJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// writeParquet writes the columns as a Parquet file with one row group and one gzipped page
+// per column, every column optional
+func writeParquet(w io.Writer, columns []parquetColumn, rows int) error {
+	var file bytes.Buffer
+	file.WriteString("PAR1")
+
+	type chunk struct {
+		offset            int64
+		compressed, plain int64
+		values            int64
+	}
+	chunks := make([]chunk, len(columns))
+	for i, column := range columns {
+		page, err := encodeParquetPage(column)
+		if err != nil {
+			return fmt.Errorf("column %s: %w", column.name, err)
+		}
+		var compressed bytes.Buffer
+		zw := gzip.NewWriter(&compressed)
+		zw.Write(page)
+		if err := zw.Close(); err != nil {
+			return err
+		}
+
+		var header thriftWriter
+		header.begin()
+		header.i32(1, 0) // DATA_PAGE
+		header.i32(2, int32(len(page)))
+		header.i32(3, int32(compressed.Len()))
+		header.structField(5) // DataPageHeader
+		header.i32(1, int32(len(column.values)))
+		header.i32(2, parquetPlain)
+		header.i32(3, parquetRLE) // Definition levels
+		header.i32(4, parquetRLE) // Repetition levels (none, the schema is flat)
+		header.end()
+		header.end()
+
+		chunks[i] = chunk{
+			offset:     int64(file.Len()),
+			compressed: int64(header.buf.Len() + compressed.Len()),
+			plain:      int64(header.buf.Len() + len(page)),
+			values:     int64(len(column.values)),
+		}
+		file.Write(header.buf.Bytes())
+		file.Write(compressed.Bytes())
+	}
+
+	var meta thriftWriter
+	meta.begin()
+	meta.i32(1, 1) // Format version
+	meta.list(2, thriftStruct, len(columns)+1)
+	meta.begin() // Root of the schema
+	meta.str(4, "schema")
+	meta.i32(5, int32(len(columns)))
+	meta.end()
+	for _, column := range columns {
+		meta.begin()
+		meta.i32(1, int32(column.kind))
+		meta.i32(3, parquetOptional)
+		meta.str(4, column.name)
+		if column.kind == parquetByteArray {
+			meta.i32(6, parquetUTF8)
+		}
+		meta.end()
+	}
+	meta.i64(3, int64(rows))
+	meta.list(4, thriftStruct, 1)
+	meta.begin() // RowGroup
+	meta.list(1, thriftStruct, len(columns))
+	var total int64
+	for i, column := range columns {
+		c := chunks[i]
+		total += c.plain
+		meta.begin() // ColumnChunk
+		meta.i64(2, c.offset)
+		meta.structField(3) // ColumnMetaData
+		meta.i32(1, int32(column.kind))
+		meta.list(2, thriftI32, 2)
+		meta.listI32(parquetPlain)
+		meta.listI32(parquetRLE)
+		meta.list(3, thriftBinary, 1)
+		meta.listString(column.name)
+		meta.i32(4, parquetGzip)
+		meta.i64(5, c.values)
+		meta.i64(6, c.plain)
+		meta.i64(7, c.compressed)
+		meta.i64(9, c.offset)
+		meta.end()
+		meta.end()
+	}
+	meta.i64(2, total)
+	meta.i64(3, int64(rows))
+	meta.end()
+	meta.str(6, "goevals")
+	meta.end()
+
+	file.Write(meta.buf.Bytes())
+	binary.Write(&file, binary.LittleEndian, uint32(meta.buf.Len()))
+	file.WriteString("PAR1")
+	_, err := w.Write(file.Bytes())
+	return err
+}
+
+// encodeParquetPage encodes a column's definition levels and PLAIN values
+func encodeParquetPage(column parquetColumn) ([]byte, error) {
+	var levels bytes.Buffer
+	// RLE runs of 1-bit levels: 1 = value present, 0 = null
+	for i := 0; i < len(column.values); {
+		present := column.values[i] != nil
+		run := 1
+		for i+run < len(column.values) && (column.values[i+run] != nil) == present {
+			run++
+		}
+		levels.Write(binary.AppendUvarint(nil, uint64(run)<<1))
+		if present {
+			levels.WriteByte(1)
+		} else {
+			levels.WriteByte(0)
+		}
+		i += run
+	}
+
+	var page bytes.Buffer
+	binary.Write(&page, binary.LittleEndian, uint32(levels.Len()))
+	page.Write(levels.Bytes())
+
+	var bits []byte // Booleans are bit-packed, LSB first
+	n := 0
+	for _, v := range column.values {
+		if v == nil {
+			continue
+		}
+		switch column.kind {
+		case parquetBoolean:
+			if n%8 == 0 {
+				bits = append(bits, 0)
+			}
+			if v.(bool) {
+				bits[n/8] |= 1 << (n % 8)
+			}
+		case parquetInt64:
+			page.Write(binary.LittleEndian.AppendUint64(nil, uint64(v.(int64))))
+		case parquetDouble:
+			page.Write(binary.LittleEndian.AppendUint64(nil, math.Float64bits(v.(float64))))
+		case parquetByteArray:
+			s := v.(string)
+			binary.Write(&page, binary.LittleEndian, uint32(len(s)))
+			page.WriteString(s)
+		default:
+			return nil, fmt.Errorf("unsupported type %d", column.kind)
+		}
+		n++
+	}
+	page.Write(bits)
+	return page.Bytes(), nil
+}
+
+// Thrift compact protocol types used by the Parquet footer
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes structs in the Thrift compact protocol, which Parquet uses for its
+// page headers and footer; only what writeParquet needs
+type thriftWriter struct {
+	buf  bytes.Buffer
+	last []int16 // Last field ID of each open struct, field IDs are written as deltas
+}
+
+// begin opens a struct, a list element or the top-level one
+func (t *thriftWriter) begin() {
+	t.last = append(t.last, 0)
+}
+
+// end closes the innermost struct
+func (t *thriftWriter) end() {
+	t.buf.WriteByte(0)
+	t.last = t.last[:len(t.last)-1]
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	last := &t.last[len(t.last)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.varint(int64(id))
+	}
+	*last = id
+}
+
+// varint writes a zigzag encoded integer
+func (t *thriftWriter) varint(v int64) {
+	t.buf.Write(binary.AppendUvarint(nil, uint64(v<<1^v>>63)))
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.varint(int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.varint(v)
+}
+
+func (t *thriftWriter) str(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.listString(s)
+}
+
+// structField starts a struct valued field, close it with end
+func (t *thriftWriter) structField(id int16) {
+	t.field(id, thriftStruct)
+	t.begin()
+}
+
+// list starts a list field of n elements, written next with listI32, listString or begin/end
+func (t *thriftWriter) list(id int16, elem byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.buf.WriteByte(byte(n)<<4 | elem)
+	} else {
+		t.buf.WriteByte(0xf0 | elem)
+		t.buf.Write(binary.AppendUvarint(nil, uint64(n)))
+	}
+}
+
+func (t *thriftWriter) listI32(v int32) {
+	t.varint(int64(v))
+}
+
+func (t *thriftWriter) listString(s string) {
+	t.buf.Write(binary.AppendUvarint(nil, uint64(len(s))))
+	t.buf.WriteString(s)
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// thriftReader decodes Thrift compact structs into maps of field ID -> value, enough to check
+// what writeParquet produces
+type thriftReader struct {
+	data []byte
+	pos  int
+}
+
+func (t *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(t.data[t.pos:])
+	t.pos += n
+	return v
+}
+
+func (t *thriftReader) varint() int64 {
+	v := t.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (t *thriftReader) value(typ byte) any {
+	switch typ {
+	case 1, 2:
+		return typ == 1
+	case thriftI32, thriftI64:
+		return t.varint()
+	case thriftBinary:
+		n := int(t.uvarint())
+		s := string(t.data[t.pos : t.pos+n])
+		t.pos += n
+		return s
+	case thriftList:
+		header := t.data[t.pos]
+		t.pos++
+		n := int(header >> 4)
+		if n == 15 {
+			n = int(t.uvarint())
+		}
+		list := make([]any, n)
+		for i := range list {
+			list[i] = t.value(header & 0x0f)
+		}
+		return list
+	case thriftStruct:
+		return t.readStruct()
+	}
+	panic("unexpected thrift type")
+}
+
+func (t *thriftReader) readStruct() map[int16]any {
+	fields := make(map[int16]any)
+	var id int16
+	for {
+		header := t.data[t.pos]
+		t.pos++
+		if header == 0 {
+			return fields
+		}
+		if delta := header >> 4; delta != 0 {
+			id += int16(delta)
+		} else {
+			id = int16(t.varint())
+		}
+		fields[id] = t.value(header & 0x0f)
+	}
+}
+
+// readParquet decodes a file written by writeParquet into column name -> values
+func readParquet(t *testing.T, data []byte) (map[string][]any, int64) {
+	t.Helper()
+	if string(data[:4]) != "PAR1" || string(data[len(data)-4:]) != "PAR1" {
+		t.Fatal("missing PAR1 magic")
+	}
+	size := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footer := &thriftReader{data: data[len(data)-8-size : len(data)-8]}
+	meta := footer.readStruct()
+	if footer.pos != size {
+		t.Fatalf("footer is %d bytes, decoded %d", size, footer.pos)
+	}
+
+	kinds := make(map[string]int64)
+	for _, element := range meta[2].([]any)[1:] {
+		element := element.(map[int16]any)
+		kinds[element[4].(string)] = element[1].(int64)
+	}
+	columns := make(map[string][]any)
+	group := meta[4].([]any)[0].(map[int16]any)
+	for _, c := range group[1].([]any) {
+		column := c.(map[int16]any)[3].(map[int16]any)
+		name := column[3].([]any)[0].(string)
+		offset := column[9].(int64)
+
+		pageReader := &thriftReader{data: data[offset:]}
+		header := pageReader.readStruct()
+		compressedSize := int(header[3].(int64))
+		if pageReader.pos+compressedSize != int(column[7].(int64)) {
+			t.Errorf("%s: total_compressed_size doesn't match the page", name)
+		}
+		zr, err := gzip.NewReader(bytes.NewReader(data[int(offset)+pageReader.pos : int(offset)+pageReader.pos+compressedSize]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		page, _ := io.ReadAll(zr)
+		if len(page) != int(header[2].(int64)) {
+			t.Errorf("%s: uncompressed_page_size %d, page is %d bytes", name, header[2], len(page))
+		}
+
+		count := int(header[5].(map[int16]any)[1].(int64))
+		levelsSize := int(binary.LittleEndian.Uint32(page))
+		levels := &thriftReader{data: page[4 : 4+levelsSize]}
+		var present []bool
+		for levels.pos < levelsSize {
+			run := int(levels.uvarint() >> 1)
+			bit := levels.data[levels.pos] == 1
+			levels.pos++
+			for range run {
+				present = append(present, bit)
+			}
+		}
+		if len(present) != count {
+			t.Fatalf("%s: %d definition levels for %d values", name, len(present), count)
+		}
+
+		values := page[4+levelsSize:]
+		n := 0
+		for _, ok := range present {
+			if !ok {
+				columns[name] = append(columns[name], nil)
+				continue
+			}
+			var v any
+			switch kinds[name] {
+			case parquetBoolean:
+				v = values[n/8]&(1<<(n%8)) != 0
+			case parquetInt64:
+				v = int64(binary.LittleEndian.Uint64(values))
+				values = values[8:]
+			case parquetDouble:
+				v = math.Float64frombits(binary.LittleEndian.Uint64(values))
+				values = values[8:]
+			case parquetByteArray:
+				l := binary.LittleEndian.Uint32(values)
+				v = string(values[4 : 4+l])
+				values = values[4+l:]
+			}
+			n++
+			columns[name] = append(columns[name], v)
+		}
+	}
+	return columns, meta[3].(int64)
+}
+
+func TestWriteParquet(t *testing.T) {
+	var results []EvalResult
+	for _, line := range []string{
+		`{"timestamp":"2025-01-01T10:00:00Z","model":"gpt-4","test_id":"t1","response_time_ms":120,"metadata":{"run_id":"r1"},"scores":{"combined":0.9,"accuracy":0.8},"temperature":0.7,"streaming":true,"notes":{"a":1}}`,
+		`{"timestamp":"2025-01-01T10:01:00Z","model":"gpt-4","test_id":"t2","response_time_ms":80,"scores":{"combined":0.5},"temperature":0.2,"streaming":false,"notes":"plain"}`,
+		`{"timestamp":"2025-01-01T10:02:00Z","model":"llama","test_id":"t1","error":"timeout","scores":{"combined":0}}`,
+	} {
+		var result EvalResult
+		if err := json.Unmarshal([]byte(line), &result); err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, result)
+	}
+
+	var buf bytes.Buffer
+	if err := writeParquet(&buf, resultsTable(results), len(results)); err != nil {
+		t.Fatal(err)
+	}
+	columns, rows := readParquet(t, buf.Bytes())
+	if rows != 3 {
+		t.Errorf("num_rows = %d", rows)
+	}
+	want := map[string][]any{
+		"model":             {"gpt-4", "gpt-4", "llama"},
+		"run_id":            {"r1", nil, nil},
+		"error":             {nil, nil, "timeout"},
+		"response_time_ms":  {int64(120), int64(80), int64(0)},
+		"score_combined":    {0.9, 0.5, 0.0},
+		"score_accuracy":    {0.8, nil, nil},
+		"field_temperature": {0.7, 0.2, nil},
+		"field_streaming":   {true, false, nil},
+		"field_notes":       {`{"a":1}`, "plain", nil},
+	}
+	for name, values := range want {
+		if got := columns[name]; !reflect.DeepEqual(got, values) {
+			t.Errorf("%s = %v, want %v", name, got, values)
+		}
+	}
+	if got := columns["metadata"][0]; got != `{"run_id":"r1"}` {
+		t.Errorf("metadata = %v", got)
+	}
+}
+
+func TestWriteParquetManyRows(t *testing.T) {
+	// Long runs of nulls and values, and more than 14 schema elements in the list headers
+	results := make([]EvalResult, 1000)
+	for i := range results {
+		results[i] = EvalResult{Model: "m", Scores: ScoreBreakdown{Combined: float64(i) / 1000, Custom: map[string]float64{}}}
+		if i >= 500 {
+			results[i].Scores.Custom["late"] = 1
+		}
+		for _, name := range []string{"a", "b", "c", "d", "e"} {
+			results[i].Scores.Custom[name] = 0.5
+		}
+	}
+	var buf bytes.Buffer
+	if err := writeParquet(&buf, resultsTable(results), len(results)); err != nil {
+		t.Fatal(err)
+	}
+	columns, _ := readParquet(t, buf.Bytes())
+	if got := columns["score_combined"][999]; got != 0.999 {
+		t.Errorf("score_combined[999] = %v", got)
+	}
+	if late := columns["score_late"]; late[499] != nil || late[500] != 1.0 || len(late) != 1000 {
+		t.Errorf("score_late around the null run = %v, %v", late[499], late[500])
+	}
+}
+
+func TestRunExportParquet(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "evals.jsonl")
+	os.WriteFile(path, []byte(`{"timestamp":"2025-01-01T10:00:00Z","model":"a","scores":{"combined":0.9}}`+"\n"), 0o644)
+	out := filepath.Join(dir, "evals.parquet")
+	if code := runExport([]string{"--format", "parquet", "-o", out, path}); code != 0 {
+		t.Fatalf("exit %d", code)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if columns, rows := readParquet(t, data); rows != 1 || columns["model"][0] != "a" {
+		t.Errorf("rows = %d, model = %v", rows, columns["model"])
+	}
+	if code := runExport([]string{"--format", "csv", path}); code != 2 {
+		t.Errorf("unknown format: exit %d", code)
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Minimal PDF writer for the exported report: text in the built-in Helvetica fonts, lines and
+// filled rectangles on A4 pages. No embedded fonts or images, so it needs no dependencies; text is
+// WinAnsi encoded and characters outside it print as "?".
+
+// A4 in points
+const (
+	pdfPageWidth  = 595.0
+	pdfPageHeight = 842.0
+	pdfMargin     = 48.0
+)
+
+// pdfColor is an RGB color with components in 0..1
+type pdfColor struct{ R, G, B float64 }
+
+var (
+	pdfBlack  = pdfColor{0.06, 0.09, 0.16}
+	pdfGray   = pdfColor{0.45, 0.5, 0.58}
+	pdfLight  = pdfColor{0.95, 0.96, 0.98}
+	pdfBorder = pdfColor{0.89, 0.91, 0.94}
+	pdfAccent = pdfColor{0.23, 0.51, 0.96}
+	pdfGood   = pdfColor{0.02, 0.59, 0.41}
+	pdfFair   = pdfColor{0.85, 0.47, 0.02}
+	pdfPoor   = pdfColor{0.86, 0.15, 0.15}
+)
+
+// pdfDocument collects page content streams; Y coordinates passed to its methods grow downwards
+// from the top of the page, like the report layout, and are flipped when written
+type pdfDocument struct {
+	title string
+	pages []*bytes.Buffer
+	page  *bytes.Buffer
+}
+
+func newPDFDocument(title string) *pdfDocument {
+	return &pdfDocument{title: title}
+}
+
+// AddPage starts a new page, subsequent drawing goes to it
+func (d *pdfDocument) AddPage() {
+	d.page = &bytes.Buffer{}
+	d.pages = append(d.pages, d.page)
+}
+
+// Text draws s with its baseline at (x, y)
+func (d *pdfDocument) Text(x, y, size float64, bold bool, color pdfColor, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.page, "BT %.3f %.3f %.3f rg /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n",
+		color.R, color.G, color.B, font, size, x, pdfPageHeight-y, pdfEscape(s))
+}
+
+// TextRight draws s ending at x
+func (d *pdfDocument) TextRight(x, y, size float64, bold bool, color pdfColor, s string) {
+	d.Text(x-pdfTextWidth(s, size, bold), y, size, bold, color, s)
+}
+
+// Rect fills a rectangle whose top left corner is (x, y)
+func (d *pdfDocument) Rect(x, y, w, h float64, color pdfColor) {
+	fmt.Fprintf(d.page, "%.3f %.3f %.3f rg %.2f %.2f %.2f %.2f re f\n",
+		color.R, color.G, color.B, x, pdfPageHeight-y-h, w, h)
+}
+
+// Line strokes a 0.5pt line from (x1, y1) to (x2, y2)
+func (d *pdfDocument) Line(x1, y1, x2, y2 float64, color pdfColor) {
+	fmt.Fprintf(d.page, "%.3f %.3f %.3f RG 0.5 w %.2f %.2f m %.2f %.2f l S\n",
+		color.R, color.G, color.B, x1, pdfPageHeight-y1, x2, pdfPageHeight-y2)
+}
+
+// WriteTo writes the document, numbering pages in the footer; call it once
+func (d *pdfDocument) WriteTo(w io.Writer) (int64, error) {
+	for i, page := range d.pages {
+		d.page = page
+		d.TextRight(pdfPageWidth-pdfMargin, pdfPageHeight-24, 8, false, pdfGray, fmt.Sprintf("Page %d of %d", i+1, len(d.pages)))
+		d.Text(pdfMargin, pdfPageHeight-24, 8, false, pdfGray, d.title)
+	}
+
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// Objects 1-5 are fixed, then a page and its content stream per page
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 6+2*i)
+	}
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	object(fmt.Sprintf("<< /Title (%s) /Producer (goevals) /CreationDate (D:%s) >>",
+		pdfEscape(d.title), time.Now().UTC().Format("20060102150405Z")))
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 7+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.Bytes()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.WriteTo(w)
+}
+
+// pdfWinAnsi maps the non-Latin-1 characters of WinAnsiEncoding that reports commonly use
+var pdfWinAnsi = map[rune]byte{
+	'€': 0x80, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+}
+
+// pdfEscape encodes s as WinAnsi and escapes it for a PDF string literal
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n' || r == '\r' || r == '\t':
+			b.WriteByte(' ')
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		case pdfWinAnsi[r] != 0:
+			fmt.Fprintf(&b, "\\%03o", pdfWinAnsi[r])
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// helveticaWidths are the Helvetica advance widths (1/1000 em) of ASCII 32-126
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // space - /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556, // 0 - ?
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778, // @ - O
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556, // P - _
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556, // ` - o
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584, // p - ~
+}
+
+// pdfTextWidth estimates the width of s in points; bold is approximated as 5% wider
+func pdfTextWidth(s string, size float64, bold bool) float64 {
+	total := 0
+	for _, r := range s {
+		if r >= 32 && r <= 126 {
+			total += helveticaWidths[r-32]
+		} else {
+			total += 556
+		}
+	}
+	width := float64(total) * size / 1000
+	if bold {
+		width *= 1.05
+	}
+	return width
+}
+
+// pdfTruncate shortens s with an ellipsis to fit in width points
+func pdfTruncate(s string, width, size float64, bold bool) string {
+	if pdfTextWidth(s, size, bold) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && pdfTextWidth(string(runes)+"…", size, bold) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
@@ -0,0 +1,449 @@
+package main
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pgConn is a minimal PostgreSQL wire protocol (v3) client
+// Statements without values run with the simple query protocol, those with values with the
+// extended one, which sends the values apart from the SQL (see Query)
+// Supported auth: trust, cleartext, MD5 and SCRAM-SHA-256
+type pgConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// pgConfig holds connection settings parsed from a postgres:// URL
+type pgConfig struct {
+	addr     string
+	user     string
+	password string
+	database string
+	sslMode  string // disable, prefer or require
+}
+
+// pgError is an ErrorResponse sent by the server
+type pgError struct {
+	Severity string
+	Code     string
+	Message  string
+}
+
+func (e *pgError) Error() string {
+	return fmt.Sprintf("postgres %s %s: %s", e.Severity, e.Code, e.Message)
+}
+
+// dialPostgres connects, negotiates TLS and authenticates
+func dialPostgres(cfg pgConfig) (*pgConn, error) {
+	conn, err := net.DialTimeout("tcp", cfg.addr, 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to postgres at %s: %w", cfg.addr, err)
+	}
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	if cfg.sslMode != "disable" {
+		// SSLRequest: length 8, magic code 80877103
+		if _, err := conn.Write([]byte{0, 0, 0, 8, 4, 210, 22, 47}); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		answer := make([]byte, 1)
+		if _, err := io.ReadFull(conn, answer); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		switch {
+		case answer[0] == 'S':
+			host, _, _ := net.SplitHostPort(cfg.addr)
+			conn = tls.Client(conn, &tls.Config{ServerName: host})
+		case cfg.sslMode == "require":
+			conn.Close()
+			return nil, fmt.Errorf("postgres server at %s does not support TLS", cfg.addr)
+		}
+	}
+
+	c := &pgConn{conn: conn, r: bufio.NewReader(conn)}
+	if err := c.startup(cfg); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	return c, nil
+}
+
+func (c *pgConn) Close() error {
+	c.writeMessage('X', nil)
+	return c.conn.Close()
+}
+
+// startup sends the StartupMessage and runs authentication until ReadyForQuery
+func (c *pgConn) startup(cfg pgConfig) error {
+	var body []byte
+	body = binary.BigEndian.AppendUint32(body, 196608) // Protocol 3.0
+	for _, kv := range [][2]string{{"user", cfg.user}, {"database", cfg.database}, {"application_name", "goevals"}} {
+		body = append(body, kv[0]...)
+		body = append(body, 0)
+		body = append(body, kv[1]...)
+		body = append(body, 0)
+	}
+	body = append(body, 0)
+	msg := binary.BigEndian.AppendUint32(nil, uint32(len(body)+4))
+	if _, err := c.conn.Write(append(msg, body...)); err != nil {
+		return err
+	}
+
+	var scram *scramClient
+	for {
+		typ, data, err := c.readMessage()
+		if err != nil {
+			return err
+		}
+		switch typ {
+		case 'R':
+			if len(data) < 4 {
+				return fmt.Errorf("short authentication message")
+			}
+			code := binary.BigEndian.Uint32(data)
+			switch code {
+			case 0: // AuthenticationOk
+			case 3: // Cleartext password
+				if err := c.writeMessage('p', cstring(cfg.password)); err != nil {
+					return err
+				}
+			case 5: // MD5 password
+				if len(data) < 8 {
+					return fmt.Errorf("short MD5 authentication message")
+				}
+				if err := c.writeMessage('p', cstring(md5Password(cfg.user, cfg.password, data[4:8]))); err != nil {
+					return err
+				}
+			case 10: // SASL - pick SCRAM-SHA-256
+				if !strings.Contains(string(data[4:]), "SCRAM-SHA-256\x00") {
+					return fmt.Errorf("postgres offers no supported SASL mechanism")
+				}
+				scram = newScramClient("", cfg.password, "")
+				first := scram.clientFirst()
+				payload := cstring("SCRAM-SHA-256")
+				payload = binary.BigEndian.AppendUint32(payload, uint32(len(first)))
+				payload = append(payload, first...)
+				if err := c.writeMessage('p', payload); err != nil {
+					return err
+				}
+			case 11: // SASL continue
+				if scram == nil {
+					return fmt.Errorf("unexpected SASL continue")
+				}
+				final, err := scram.clientFinal(string(data[4:]))
+				if err != nil {
+					return err
+				}
+				if err := c.writeMessage('p', []byte(final)); err != nil {
+					return err
+				}
+			case 12: // SASL final
+				if scram == nil {
+					return fmt.Errorf("unexpected SASL final")
+				}
+				if err := scram.verifyServerFinal(string(data[4:])); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("unsupported postgres authentication method %d", code)
+			}
+		case 'E':
+			return parsePgError(data)
+		case 'Z':
+			return nil
+		case 'S', 'K', 'N': // ParameterStatus, BackendKeyData, Notice
+		default:
+			return fmt.Errorf("unexpected postgres message %q during startup", typ)
+		}
+	}
+}
+
+// pgResult is the outcome of a query
+type pgResult struct {
+	Rows [][]*string // Text values, nil for NULL
+	Tag  string      // CommandComplete tag of the last statement (e.g. "INSERT 0 3")
+}
+
+// RowsAffected parses the row count from the command tag
+func (r pgResult) RowsAffected() int {
+	fields := strings.Fields(r.Tag)
+	if len(fields) == 0 {
+		return 0
+	}
+	n, _ := strconv.Atoi(fields[len(fields)-1])
+	return n
+}
+
+// Exec runs one or more ';' separated statements with the simple query protocol
+// Rows of all statements are collected; the first error aborts the whole batch
+func (c *pgConn) Exec(query string) (pgResult, error) {
+	var res pgResult
+	c.conn.SetDeadline(time.Now().Add(60 * time.Second))
+	defer c.conn.SetDeadline(time.Time{})
+
+	if err := c.writeMessage('Q', cstring(query)); err != nil {
+		return res, err
+	}
+	return c.readResult()
+}
+
+// Query runs one statement whose $1, $2, ... placeholders take args, as text values in the
+// extended query protocol; a value can't contain NUL, which Postgres text types can't store
+func (c *pgConn) Query(query string, args ...string) (pgResult, error) {
+	for i, arg := range args {
+		if strings.ContainsRune(arg, 0) {
+			return pgResult{}, fmt.Errorf("postgres value $%d contains a NUL character", i+1)
+		}
+	}
+	c.conn.SetDeadline(time.Now().Add(60 * time.Second))
+	defer c.conn.SetDeadline(time.Time{})
+
+	// Parse and Bind the unnamed statement and portal, Execute all rows, Sync
+	parse := append(cstring(""), cstring(query)...)
+	parse = binary.BigEndian.AppendUint16(parse, 0) // Parameter types inferred by the server
+	bind := append(cstring(""), cstring("")...)
+	bind = binary.BigEndian.AppendUint16(bind, 0) // Text parameters
+	bind = binary.BigEndian.AppendUint16(bind, uint16(len(args)))
+	for _, arg := range args {
+		bind = binary.BigEndian.AppendUint32(bind, uint32(len(arg)))
+		bind = append(bind, arg...)
+	}
+	bind = binary.BigEndian.AppendUint16(bind, 0) // Text results
+	execute := binary.BigEndian.AppendUint32(cstring(""), 0)
+	var msgs []byte
+	for _, m := range []struct {
+		typ  byte
+		body []byte
+	}{{'P', parse}, {'B', bind}, {'E', execute}, {'S', nil}} {
+		msgs = append(msgs, m.typ)
+		msgs = binary.BigEndian.AppendUint32(msgs, uint32(len(m.body)+4))
+		msgs = append(msgs, m.body...)
+	}
+	if _, err := c.conn.Write(msgs); err != nil {
+		return pgResult{}, err
+	}
+	return c.readResult()
+}
+
+// readResult collects the rows and command tag of a query up to ReadyForQuery
+func (c *pgConn) readResult() (pgResult, error) {
+	var res pgResult
+	var queryErr error
+	for {
+		typ, data, err := c.readMessage()
+		if err != nil {
+			return res, err
+		}
+		switch typ {
+		case 'D':
+			row, err := parseDataRow(data)
+			if err != nil {
+				return res, err
+			}
+			res.Rows = append(res.Rows, row)
+		case 'C':
+			res.Tag = strings.TrimRight(string(data), "\x00")
+		case 'E':
+			queryErr = parsePgError(data)
+		case 'Z':
+			return res, queryErr
+		case 'T', 'I', 'N', 'S', '1', '2', 'n': // Also ParseComplete, BindComplete and NoData
+		default:
+			return res, fmt.Errorf("unexpected postgres message %q", typ)
+		}
+	}
+}
+
+func (c *pgConn) writeMessage(typ byte, body []byte) error {
+	msg := []byte{typ}
+	msg = binary.BigEndian.AppendUint32(msg, uint32(len(body)+4))
+	_, err := c.conn.Write(append(msg, body...))
+	return err
+}
+
+func (c *pgConn) readMessage() (byte, []byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	n := int(binary.BigEndian.Uint32(header[1:])) - 4
+	if n < 0 {
+		return 0, nil, fmt.Errorf("invalid postgres message length")
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(c.r, data); err != nil {
+		return 0, nil, err
+	}
+	return header[0], data, nil
+}
+
+func parseDataRow(data []byte) ([]*string, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("short data row")
+	}
+	n := int(binary.BigEndian.Uint16(data))
+	data = data[2:]
+	row := make([]*string, n)
+	for i := range row {
+		if len(data) < 4 {
+			return nil, fmt.Errorf("short data row")
+		}
+		size := int32(binary.BigEndian.Uint32(data))
+		data = data[4:]
+		if size < 0 {
+			continue // NULL
+		}
+		if len(data) < int(size) {
+			return nil, fmt.Errorf("short data row")
+		}
+		v := string(data[:size])
+		row[i] = &v
+		data = data[size:]
+	}
+	return row, nil
+}
+
+func parsePgError(data []byte) error {
+	e := &pgError{}
+	for _, field := range strings.Split(string(data), "\x00") {
+		if field == "" {
+			continue
+		}
+		switch field[0] {
+		case 'S':
+			e.Severity = field[1:]
+		case 'C':
+			e.Code = field[1:]
+		case 'M':
+			e.Message = field[1:]
+		}
+	}
+	return e
+}
+
+func cstring(s string) []byte {
+	return append([]byte(s), 0)
+}
+
+// pgArray encodes values as a text[] literal, for one parameter that carries a list
+func pgArray(values []string) string {
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('"')
+		b.WriteString(escape.Replace(v))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// md5Password computes "md5" + md5(md5(password + user) + salt)
+func md5Password(user, password string, salt []byte) string {
+	inner := md5.Sum([]byte(password + user))
+	outer := md5.Sum(append([]byte(hex.EncodeToString(inner[:])), salt...))
+	return "md5" + hex.EncodeToString(outer[:])
+}
+
+// scramClient implements the client side of SCRAM-SHA-256 (RFC 5802 / RFC 7677)
+type scramClient struct {
+	user, password, nonce string
+	clientFirstBare       string
+	serverSignature       []byte
+}
+
+// newScramClient creates a client; an empty nonce generates a random one
+func newScramClient(user, password, nonce string) *scramClient {
+	if nonce == "" {
+		buf := make([]byte, 18)
+		rand.Read(buf)
+		nonce = base64.RawStdEncoding.EncodeToString(buf)
+	}
+	return &scramClient{user: user, password: password, nonce: nonce}
+}
+
+func (s *scramClient) clientFirst() string {
+	s.clientFirstBare = "n=" + s.user + ",r=" + s.nonce
+	return "n,," + s.clientFirstBare
+}
+
+func (s *scramClient) clientFinal(serverFirst string) (string, error) {
+	var nonce, salt string
+	iterations := 0
+	for _, attr := range strings.Split(serverFirst, ",") {
+		key, value, _ := strings.Cut(attr, "=")
+		switch key {
+		case "r":
+			nonce = value
+		case "s":
+			salt = value
+		case "i":
+			iterations, _ = strconv.Atoi(value)
+		}
+	}
+	if !strings.HasPrefix(nonce, s.nonce) || salt == "" || iterations <= 0 {
+		return "", fmt.Errorf("invalid SCRAM server-first message")
+	}
+	saltBytes, err := base64.StdEncoding.DecodeString(salt)
+	if err != nil {
+		return "", fmt.Errorf("invalid SCRAM salt: %w", err)
+	}
+
+	salted, err := pbkdf2.Key(sha256.New, s.password, saltBytes, iterations, sha256.Size)
+	if err != nil {
+		return "", err
+	}
+	clientKey := hmacSHA256(salted, "Client Key")
+	storedKey := sha256.Sum256(clientKey)
+	withoutProof := "c=biws,r=" + nonce
+	authMessage := s.clientFirstBare + "," + serverFirst + "," + withoutProof
+
+	clientSignature := hmacSHA256(storedKey[:], authMessage)
+	proof := make([]byte, len(clientKey))
+	for i := range clientKey {
+		proof[i] = clientKey[i] ^ clientSignature[i]
+	}
+	s.serverSignature = hmacSHA256(hmacSHA256(salted, "Server Key"), authMessage)
+
+	return withoutProof + ",p=" + base64.StdEncoding.EncodeToString(proof), nil
+}
+
+func (s *scramClient) verifyServerFinal(serverFinal string) error {
+	sig, ok := strings.CutPrefix(serverFinal, "v=")
+	if !ok {
+		return fmt.Errorf("SCRAM authentication failed: %s", serverFinal)
+	}
+	got, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, s.serverSignature) {
+		return fmt.Errorf("SCRAM server signature mismatch")
+	}
+	return nil
+}
+
+func hmacSHA256(key []byte, msg string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(msg))
+	return h.Sum(nil)
+}
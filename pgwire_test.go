@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+// RFC 7677 section 3 test vector
+func TestScramSHA256(t *testing.T) {
+	s := newScramClient("user", "pencil", "rOprNGfwEbeRWgbNEkqO")
+	if got := s.clientFirst(); got != "n,,n=user,r=rOprNGfwEbeRWgbNEkqO" {
+		t.Fatalf("clientFirst = %q", got)
+	}
+
+	final, err := s.clientFinal("r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096")
+	if err != nil {
+		t.Fatalf("clientFinal: %v", err)
+	}
+	want := "c=biws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,p=dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ="
+	if final != want {
+		t.Errorf("clientFinal = %q, want %q", final, want)
+	}
+	if err := s.verifyServerFinal("v=6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4="); err != nil {
+		t.Errorf("verifyServerFinal: %v", err)
+	}
+	if err := s.verifyServerFinal("v=AAAA"); err == nil {
+		t.Error("expected signature mismatch")
+	}
+}
+
+func TestPgConnExec(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+
+	go func() {
+		defer server.Close()
+		r := bufio.NewReader(server)
+		// Query message: 'Q' + length + SQL
+		var header [5]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil || header[0] != 'Q' {
+			return
+		}
+		io.ReadFull(r, make([]byte, binary.BigEndian.Uint32(header[1:])-4))
+
+		send := func(typ byte, body []byte) {
+			msg := binary.BigEndian.AppendUint32([]byte{typ}, uint32(len(body)+4))
+			server.Write(append(msg, body...))
+		}
+		row := binary.BigEndian.AppendUint16(nil, 2)
+		row = binary.BigEndian.AppendUint32(row, 1)
+		row = append(row, '7')
+		row = binary.BigEndian.AppendUint32(row, 0xFFFFFFFF) // NULL
+		send('T', []byte{0, 0})
+		send('D', row)
+		send('C', cstring("SELECT 1"))
+		send('Z', []byte{'I'})
+	}()
+
+	c := &pgConn{conn: client, r: bufio.NewReader(client)}
+	res, err := c.Exec("SELECT id, data FROM eval_results")
+	if err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+	if len(res.Rows) != 1 || *res.Rows[0][0] != "7" || res.Rows[0][1] != nil {
+		t.Errorf("rows = %v", res.Rows)
+	}
+	if res.RowsAffected() != 1 {
+		t.Errorf("RowsAffected = %d", res.RowsAffected())
+	}
+}
+
+// fakePostgres answers the simple and extended queries of a pgConn with handle, which gets the
+// SQL and the bound values and returns the rows and the command tag
+func fakePostgres(t *testing.T, handle func(query string, args []string) ([][]string, string)) *pgConn {
+	t.Helper()
+	client, server := net.Pipe()
+	t.Cleanup(func() { client.Close() })
+	go func() {
+		defer server.Close()
+		r := bufio.NewReader(server)
+		send := func(typ byte, body []byte) {
+			msg := binary.BigEndian.AppendUint32([]byte{typ}, uint32(len(body)+4))
+			server.Write(append(msg, body...))
+		}
+		answer := func(query string, args []string) {
+			rows, tag := handle(query, args)
+			for _, row := range rows {
+				data := binary.BigEndian.AppendUint16(nil, uint16(len(row)))
+				for _, v := range row {
+					data = binary.BigEndian.AppendUint32(data, uint32(len(v)))
+					data = append(data, v...)
+				}
+				send('D', data)
+			}
+			send('C', cstring(tag))
+		}
+		var query string
+		var args []string
+		for {
+			var header [5]byte
+			if _, err := io.ReadFull(r, header[:]); err != nil {
+				return
+			}
+			body := make([]byte, binary.BigEndian.Uint32(header[1:])-4)
+			io.ReadFull(r, body)
+			switch header[0] {
+			case 'Q':
+				answer(strings.TrimSuffix(string(body), "\x00"), nil)
+				send('Z', []byte{'I'})
+			case 'P':
+				query = strings.Split(string(body), "\x00")[1]
+				send('1', nil)
+			case 'B':
+				body = body[2:] // Unnamed portal and statement
+				body = body[2+2*binary.BigEndian.Uint16(body):]
+				args = make([]string, binary.BigEndian.Uint16(body))
+				body = body[2:]
+				for i := range args {
+					n := binary.BigEndian.Uint32(body)
+					args[i], body = string(body[4:4+n]), body[4+n:]
+				}
+				send('2', nil)
+			case 'E':
+				answer(query, args)
+			case 'S':
+				send('Z', []byte{'I'})
+			case 'X':
+				return
+			}
+		}
+	}()
+	return &pgConn{conn: client, r: bufio.NewReader(client)}
+}
+
+func TestPgConnQuery(t *testing.T) {
+	var got []string
+	c := fakePostgres(t, func(query string, args []string) ([][]string, string) {
+		got = append([]string{query}, args...)
+		return [][]string{{"1"}, {"2"}}, "DELETE 2"
+	})
+	res, err := c.Query("DELETE FROM eval_results WHERE result_key = ANY($1::text[])", pgArray([]string{`it's`, `a "b"\c`}))
+	if err != nil || res.RowsAffected() != 2 || len(res.Rows) != 2 {
+		t.Fatalf("Query = %+v, %v", res, err)
+	}
+	if len(got) != 2 || got[1] != `{"it's","a \"b\"\\c"}` {
+		t.Errorf("sent %q", got)
+	}
+	if _, err := c.Query("SELECT $1", "a\x00b"); err == nil {
+		t.Error("NUL sent to postgres")
+	}
+}
+
+func TestPgURL(t *testing.T) {
+
+	s, err := NewPostgresStore("postgres://bob:pw@db/evals?sslmode=require")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.cfg.addr != "db:5432" || s.cfg.user != "bob" || s.cfg.password != "pw" || s.cfg.database != "evals" || s.cfg.sslMode != "require" {
+		t.Errorf("cfg = %+v", s.cfg)
+	}
+	if _, err := NewPostgresStore("postgres://db/evals?sslmode=verify-full"); err == nil {
+		t.Error("expected error for unsupported sslmode")
+	}
+}
@@ -0,0 +1,538 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"maps"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Pivot is a wide table of one aggregated value per row and column label, in pandas' "split"
+// layout so notebooks can load it directly: pd.read_json(url, orient="split")
+type Pivot struct {
+	Index   []string     `json:"index"`   // Row labels
+	Columns []string     `json:"columns"` // Column labels
+	Data    [][]*float64 `json:"data"`    // One row per index label, null where nothing was aggregated
+}
+
+// pivotDimensions are the built-in rows/cols dimensions, custom fields are field:<name>
+var pivotDimensions = []string{"model", "family", "provider", "config", "run", "test_id", "source", "dataset", "score_type"}
+
+// pivotValues are the aggregations a cell can hold
+var pivotValues = []string{"mean", "median", "min", "max", "std", "sum", "count"}
+
+// pivotParams reads and validates the pivot query parameters
+func pivotParams(params url.Values) (rows, cols, value, score string, err error) {
+	rows, cols, value, score = params.Get("rows"), params.Get("cols"), params.Get("value"), params.Get("score")
+	if rows == "" {
+		rows = "model"
+	}
+	if cols == "" {
+		cols = "score_type"
+	}
+	if value == "" {
+		value = "mean"
+	}
+	if score == "" {
+		score = "combined"
+	}
+	for _, dim := range []string{rows, cols} {
+		if !slices.Contains(pivotDimensions, dim) && !strings.HasPrefix(dim, "field:") {
+			return "", "", "", "", fmt.Errorf("unknown dimension %q, use %s or field:<name>", dim, strings.Join(pivotDimensions, ", "))
+		}
+	}
+	if rows == cols {
+		return "", "", "", "", fmt.Errorf("rows and cols must be different dimensions")
+	}
+	if !slices.Contains(pivotValues, value) {
+		return "", "", "", "", fmt.Errorf("unknown value %q, use %s", value, strings.Join(pivotValues, ", "))
+	}
+	return rows, cols, value, score, nil
+}
+
+// pivotLabel is the label of result along dim, "" if it has none (e.g. no test_id)
+func pivotLabel(result EvalResult, dim string) string {
+	switch dim {
+	case "model":
+		return result.Model
+	case "family":
+		return modelRegistry.Family(result.Model)
+	case "provider":
+		info, _ := modelRegistry.Lookup(result.Model)
+		return info.Provider
+	case "config":
+		return buildConfigKey(result)
+	case "run":
+		return runName(runOf(result))
+	case "test_id":
+		return result.TestID
+	case "source":
+		return result.Origin
+	case "dataset":
+		return result.Dataset()
+	}
+	if value, ok := result.Fields()[strings.TrimPrefix(dim, "field:")]; ok {
+		return fmt.Sprint(value)
+	}
+	return ""
+}
+
+// aggregate reduces values with one of pivotValues
+func aggregate(values []float64, value string) float64 {
+	switch value {
+	case "median":
+		sorted := slices.Sorted(slices.Values(values))
+		return percentile(sorted, 50)
+	case "min":
+		return slices.Min(values)
+	case "max":
+		return slices.Max(values)
+	case "std":
+		if len(values) < 2 {
+			return 0
+		}
+		avg, sum := mean(values), 0.0
+		for _, v := range values {
+			sum += (v - avg) * (v - avg)
+		}
+		return math.Sqrt(sum / float64(len(values)-1))
+	case "sum":
+		sum := 0.0
+		for _, v := range values {
+			sum += v
+		}
+		return sum
+	case "count":
+		return float64(len(values))
+	}
+	return mean(values)
+}
+
+// BuildPivot aggregates scores into a rows × cols table
+// With score_type as rows or cols every score of a result (combined and custom) is a value;
+// otherwise only score is. Results without a label on either dimension are skipped, and errored
+// results like everywhere else (see scored)
+// mean weighs tests by their weight like the dashboard (see EvalResult.TestWeight); the other
+// values are unweighted
+func BuildPivot(results []EvalResult, rows, cols, value, score string) Pivot {
+	cells := make(map[[2]string][]float64)
+	weighted := make(map[[2]string]*weightedMean)
+	rowSet, colSet := make(map[string]bool), make(map[string]bool)
+	var w float64 // Weight of the result being added
+	add := func(row, col string, v float64) {
+		if row == "" || col == "" {
+			return
+		}
+		rowSet[row], colSet[col] = true, true
+		key := [2]string{row, col}
+		cells[key] = append(cells[key], v)
+		if weighted[key] == nil {
+			weighted[key] = &weightedMean{}
+		}
+		weighted[key].add(v, w)
+	}
+	for _, result := range scored(results) {
+		w = result.TestWeight()
+		if rows == "score_type" || cols == "score_type" {
+			other := rows
+			if rows == "score_type" {
+				other = cols
+			}
+			label := pivotLabel(result, other)
+			scores := map[string]float64{"combined": result.Scores.Combined}
+			for name, v := range result.Scores.Custom {
+				scores[name] = v
+			}
+			for name, v := range scores {
+				if rows == "score_type" {
+					add(name, label, v)
+				} else {
+					add(label, name, v)
+				}
+			}
+			continue
+		}
+		v, ok := result.Scores.Combined, true
+		if score != "combined" {
+			v, ok = result.Scores.Custom[score]
+		}
+		if ok {
+			add(pivotLabel(result, rows), pivotLabel(result, cols), v)
+		}
+	}
+
+	p := Pivot{Index: sortedKeys(rowSet, "combined"), Columns: sortedKeys(colSet, "combined"), Data: [][]*float64{}}
+	for _, row := range p.Index {
+		line := make([]*float64, len(p.Columns))
+		for i, col := range p.Columns {
+			key := [2]string{row, col}
+			if values := cells[key]; len(values) > 0 {
+				v := aggregate(values, value)
+				if m := weighted[key]; value == "mean" && m.weighted {
+					v = m.value()
+				}
+				line[i] = &v
+			}
+		}
+		p.Data = append(p.Data, line)
+	}
+	return p
+}
+
+// writePivotCSV writes the table with the rows dimension as the first header cell, empty cells
+// where nothing was aggregated: pd.read_csv(url, index_col=0)
+func writePivotCSV(w http.ResponseWriter, p Pivot, rows string) error {
+	cw := csv.NewWriter(w)
+	cw.Write(append([]string{rows}, p.Columns...))
+	for i, label := range p.Index {
+		record := []string{label}
+		for _, v := range p.Data[i] {
+			cell := ""
+			if v != nil {
+				cell = strconv.FormatFloat(*v, 'f', -1, 64)
+			}
+			record = append(record, cell)
+		}
+		cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// pivotAPIHandler returns aggregated scores as a wide table
+// e.g. /api/stats/pivot?rows=model&cols=score_type&value=mean, filtered like the dashboard
+// (model, run_id, test_id, source) and in CSV with format=csv
+func pivotAPIHandler(w http.ResponseWriter, r *http.Request) {
+	rows, cols, value, score, err := pivotParams(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		http.Error(w, "format must be json or csv", http.StatusBadRequest)
+		return
+	}
+	results, err := store.Query(r.Context(), dashboardFilter(r))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	results, ok := reweightRequest(w, r, results)
+	if !ok {
+		return
+	}
+
+	p := BuildPivot(results, rows, cols, value, score)
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		if err := writePivotCSV(w, p, rows); err != nil {
+			log.Printf("Error writing CSV: %v", err)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(p); err != nil {
+		log.Printf("Error encoding JSON: %v", err)
+	}
+}
+
+// PivotCell is one cell of the pivot view
+type PivotCell struct {
+	Value float64
+	OK    bool         // Something was aggregated
+	Color template.CSS // Heatmap color, see pivotColors
+	Best  bool         // Highest value of the table, for score aggregations
+}
+
+// PivotTable is a Pivot laid out as a heatmap
+type PivotTable struct {
+	Index   []string
+	Columns []string
+	Cells   [][]PivotCell
+}
+
+// scoreAggregations are the pivot values that stay on the 0-1 score scale
+var scoreAggregations = []string{"mean", "median", "min", "max"}
+
+// sortPivotNumeric orders labels by value when they are all numbers, so a sweep over
+// chunk_size reads 200, 500, 1000 rather than 1000, 200, 500
+func sortPivotNumeric(p Pivot) Pivot {
+	order := func(labels []string) []int {
+		values := make([]float64, len(labels))
+		for i, label := range labels {
+			v, err := strconv.ParseFloat(label, 64)
+			if err != nil {
+				return nil
+			}
+			values[i] = v
+		}
+		idx := make([]int, len(labels))
+		for i := range idx {
+			idx[i] = i
+		}
+		sort.SliceStable(idx, func(a, b int) bool { return values[idx[a]] < values[idx[b]] })
+		return idx
+	}
+	sorted := Pivot{Index: p.Index, Columns: p.Columns, Data: p.Data}
+	if rows := order(p.Index); rows != nil {
+		sorted.Index, sorted.Data = make([]string, len(rows)), make([][]*float64, len(rows))
+		for i, j := range rows {
+			sorted.Index[i], sorted.Data[i] = p.Index[j], p.Data[j]
+		}
+	}
+	if cols := order(p.Columns); cols != nil {
+		sorted.Columns = make([]string, len(cols))
+		for i, j := range cols {
+			sorted.Columns[i] = p.Columns[j]
+		}
+		data := make([][]*float64, len(sorted.Data))
+		for r, line := range sorted.Data {
+			data[r] = make([]*float64, len(cols))
+			for i, j := range cols {
+				data[r][i] = line[j]
+			}
+		}
+		sorted.Data = data
+	}
+	return sorted
+}
+
+// pivotColors colors the cells of p: scores keep their 0-1 scale, other values are spread
+// over the range of the table (std reversed, lower is better)
+func pivotColors(p Pivot, value string) PivotTable {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, line := range p.Data {
+		for _, v := range line {
+			if v != nil {
+				lo, hi = math.Min(lo, *v), math.Max(hi, *v)
+			}
+		}
+	}
+	scale := slices.Contains(scoreAggregations, value) && lo >= 0 && hi <= 1
+	t := PivotTable{Index: p.Index, Columns: p.Columns}
+	for _, line := range p.Data {
+		cells := make([]PivotCell, len(line))
+		for i, v := range line {
+			if v == nil {
+				continue
+			}
+			pos := *v
+			if !scale {
+				pos = 0.5
+				if hi > lo {
+					pos = (*v - lo) / (hi - lo)
+				}
+				if value == "std" {
+					pos = 1 - pos
+				}
+			}
+			cells[i] = PivotCell{Value: *v, OK: true, Color: scoreColor(pos), Best: slices.Contains(scoreAggregations, value) && *v == hi}
+		}
+		t.Cells = append(t.Cells, cells)
+	}
+	return t
+}
+
+// sweepFields returns the custom fields taking at least two distinct values, the parameters
+// a pivot is most likely wanted over
+func sweepFields(results []EvalResult) (sweeps, all []string) {
+	values := make(map[string]map[string]bool)
+	for _, result := range results {
+		for name, v := range result.Fields() {
+			if values[name] == nil {
+				values[name] = make(map[string]bool)
+			}
+			values[name][fmt.Sprint(v)] = true
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		all = append(all, name)
+		if len(values[name]) > 1 {
+			sweeps = append(sweeps, name)
+		}
+	}
+	return sweeps, all
+}
+
+// pivotHandler renders a pivot as a heatmap table, by default over the first two swept custom
+// fields: /pivot?rows=field:chunk_size&cols=field:top_k&value=mean&score=combined
+func pivotHandler(w http.ResponseWriter, r *http.Request) {
+	results, err := store.Query(r.Context(), dashboardFilter(r))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	results, ok := reweightRequest(w, r, results)
+	if !ok {
+		return
+	}
+	results = scored(results) // The sweeps and score names of what the table shows
+
+	sweeps, fields := sweepFields(results)
+	params := r.URL.Query()
+	defaults := []string{"model", "score_type"}
+	switch {
+	case len(sweeps) >= 2:
+		defaults = []string{"field:" + sweeps[0], "field:" + sweeps[1]}
+	case len(sweeps) == 1:
+		defaults = []string{"model", "field:" + sweeps[0]}
+	}
+	if params.Get("rows") == "" && params.Get("cols") == "" {
+		params.Set("rows", defaults[0])
+		params.Set("cols", defaults[1])
+	}
+	rows, cols, value, score, err := pivotParams(params)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	dimensions := slices.Clone(pivotDimensions)
+	for _, name := range fields {
+		dimensions = append(dimensions, "field:"+name)
+	}
+	data := struct {
+		Title      string
+		Subtitle   string
+		Rows, Cols string
+		Value      string
+		Score      string
+		Dimensions []string
+		Values     []string
+		Scores     []string
+		Weights    string
+		Table      PivotTable
+	}{
+		Title:      "Pivot",
+		Subtitle:   "One parameter against another: where does the sweep peak?",
+		Rows:       rows,
+		Cols:       cols,
+		Value:      value,
+		Score:      score,
+		Dimensions: dimensions,
+		Values:     pivotValues,
+		Scores:     scoreNames(results),
+		Weights:    params.Get("weights"),
+		Table:      pivotColors(sortPivotNumeric(BuildPivot(results, rows, cols, value, score)), value),
+	}
+	renderPage(w, r, "pivot", pivotTemplate, data)
+}
+
+const pivotTemplate = `
+{{ define "style" }}
+        .pivot-controls {
+            display: flex;
+            flex-wrap: wrap;
+            gap: 0.75rem;
+            align-items: center;
+        }
+        .pivot-table {
+            border-collapse: separate;
+            border-spacing: 2px;
+            width: auto;
+        }
+        .pivot-table td, .pivot-table th {
+            border: none;
+        }
+        .pivot-table th {
+            font-family: monospace;
+            font-size: 0.75rem;
+            text-transform: none;
+            letter-spacing: 0;
+            white-space: nowrap;
+            text-align: center;
+        }
+        .pivot-table .corner {
+            text-align: right;
+            color: var(--text-tertiary);
+        }
+        .pivot-table .cell {
+            min-width: 4.5rem;
+            text-align: center;
+            font-family: monospace;
+            font-size: 0.8125rem;
+            color: #fff;
+        }
+        .pivot-table .best {
+            outline: 2px solid var(--text-primary);
+            outline-offset: -2px;
+            font-weight: 700;
+        }
+        .pivot-table .empty {
+            background: var(--bg-tertiary);
+        }
+        .pivot-table .row-label {
+            padding-right: 0.75rem;
+            white-space: nowrap;
+            font-size: 0.8125rem;
+            text-align: right;
+        }
+{{ end }}
+
+{{ define "content" }}
+        <div class="panel">
+            <form method="get" action="/pivot" class="pivot-controls">
+                <label class="muted" for="rows">Rows</label>
+                <select id="rows" name="rows" onchange="this.form.submit()">
+                    {{ range .Dimensions }}<option value="{{ . }}" {{ if eq . $.Rows }}selected{{ end }}>{{ . }}</option>{{ end }}
+                </select>
+                <label class="muted" for="cols">Columns</label>
+                <select id="cols" name="cols" onchange="this.form.submit()">
+                    {{ range .Dimensions }}<option value="{{ . }}" {{ if eq . $.Cols }}selected{{ end }}>{{ . }}</option>{{ end }}
+                </select>
+                <label class="muted" for="score">Score</label>
+                <select id="score" name="score" onchange="this.form.submit()">
+                    {{ range .Scores }}<option value="{{ . }}" {{ if eq . $.Score }}selected{{ end }}>{{ . }}</option>{{ end }}
+                </select>
+                <label class="muted" for="value">Value</label>
+                <select id="value" name="value" onchange="this.form.submit()">
+                    {{ range .Values }}<option value="{{ . }}" {{ if eq . $.Value }}selected{{ end }}>{{ . }}</option>{{ end }}
+                </select>
+                {{ with .Weights }}<input type="hidden" name="weights" value="{{ . }}">{{ end }}
+                <a class="muted" style="margin-left: auto;" href="/api/stats/pivot?rows={{ .Rows }}&cols={{ .Cols }}&value={{ .Value }}&score={{ .Score }}&format=csv{{ with .Weights }}&weights={{ . }}{{ end }}">CSV</a>
+            </form>
+        </div>
+
+        <div class="panel" style="overflow-x: auto;">
+            {{ if not .Table.Index }}
+            <p class="muted">No results have both {{ .Rows }} and {{ .Cols }}.</p>
+            {{ else }}
+            <table class="pivot-table">
+                <thead>
+                    <tr>
+                        <th class="corner">{{ .Rows }} \ {{ .Cols }}</th>
+                        {{ range .Table.Columns }}<th>{{ . }}</th>{{ end }}
+                    </tr>
+                </thead>
+                <tbody>
+                    {{ range $r, $label := .Table.Index }}
+                    <tr>
+                        <td class="row-label mono">{{ $label }}</td>
+                        {{ range $i, $cell := index $.Table.Cells $r }}
+                        {{ $col := index $.Table.Columns $i }}
+                        {{ if $cell.OK }}
+                        <td class="cell{{ if $cell.Best }} best{{ end }}" style="background: {{ $cell.Color }};" title="{{ $.Rows }}={{ $label }} · {{ $.Cols }}={{ $col }}: {{ $.Value }} {{ $.Score }} {{ printf "%.4g" $cell.Value }}">{{ printf "%.3g" $cell.Value }}</td>
+                        {{ else }}
+                        <td class="empty" title="{{ $.Rows }}={{ $label }} · {{ $.Cols }}={{ $col }}: no results"></td>
+                        {{ end }}
+                        {{ end }}
+                    </tr>
+                    {{ end }}
+                </tbody>
+            </table>
+            <p class="muted" style="margin-top: 1rem; font-size: 0.8125rem;">
+                Numeric labels are ordered by value. Scores are colored on their 0-1 scale, other values relative to the table; the outlined cell is the highest score.
+            </p>
+            {{ end }}
+        </div>
+{{ end }}`
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestBuildPivot(t *testing.T) {
+	results := []EvalResult{
+		{Model: "a", TestID: "q1", Scores: ScoreBreakdown{Combined: 0.8, Custom: map[string]float64{"accuracy": 1}}, CustomFields: map[string]any{"top_k": 5.0}},
+		{Model: "a", TestID: "q2", Scores: ScoreBreakdown{Combined: 0.4, Custom: map[string]float64{"accuracy": 0.5}}, CustomFields: map[string]any{"top_k": 10.0}},
+		{Model: "b", TestID: "q1", Scores: ScoreBreakdown{Combined: 0.6}},
+	}
+
+	p := BuildPivot(results, "model", "score_type", "mean", "combined")
+	if strings.Join(p.Index, ",") != "a,b" || strings.Join(p.Columns, ",") != "combined,accuracy" {
+		t.Fatalf("labels = %v × %v", p.Index, p.Columns)
+	}
+	if *p.Data[0][0] < 0.6-1e-9 || *p.Data[0][0] > 0.6+1e-9 || *p.Data[0][1] != 0.75 || *p.Data[1][0] != 0.6 || p.Data[1][1] != nil {
+		t.Errorf("data = %v %v", p.Data[0], p.Data[1])
+	}
+
+	p = BuildPivot(results, "test_id", "model", "count", "accuracy")
+	if strings.Join(p.Index, ",") != "q1,q2" || strings.Join(p.Columns, ",") != "a" || *p.Data[0][0] != 1 {
+		t.Errorf("accuracy counts = %v × %v: %v", p.Index, p.Columns, p.Data)
+	}
+
+	p = BuildPivot(results, "score_type", "field:top_k", "max", "combined")
+	if strings.Join(p.Index, ",") != "combined,accuracy" || strings.Join(p.Columns, ",") != "10,5" || *p.Data[0][1] != 0.8 {
+		t.Errorf("by field = %v × %v: %v", p.Index, p.Columns, p.Data)
+	}
+
+	for values, want := range map[string]float64{"median": 2, "min": 1, "max": 4, "sum": 7, "count": 3, "mean": 7.0 / 3} {
+		if got := aggregate([]float64{4, 1, 2}, values); got != want {
+			t.Errorf("aggregate %s = %v, want %v", values, got, want)
+		}
+	}
+	if got := aggregate([]float64{2, 4, 4, 4, 5, 5, 7, 9}, "std"); got < 2.138 || got > 2.139 {
+		t.Errorf("std = %v", got)
+	}
+
+	for _, bad := range []string{"rows=team", "cols=model", "value=p99", "rows=model&cols=model"} {
+		params, _ := url.ParseQuery(bad)
+		if _, _, _, _, err := pivotParams(params); err == nil {
+			t.Errorf("pivotParams(%s) accepted", bad)
+		}
+	}
+}
+
+func TestPivotAPIHandler(t *testing.T) {
+	withStore(t, `{"timestamp":"2025-01-01T10:00:00Z","model":"a","scores":{"combined":0.8,"accuracy":1}}
+{"timestamp":"2025-01-01T10:01:00Z","model":"b","scores":{"combined":0.6}}`)
+
+	rec := httptest.NewRecorder()
+	pivotAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/api/stats/pivot?rows=model&cols=score_type&value=mean", nil))
+	var got map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || len(got) != 3 {
+		t.Fatalf("body = %s (%v)", rec.Body, err) // Extra keys break pd.read_json(orient="split")
+	}
+	if body := rec.Body.String(); !strings.Contains(body, `"data":[[0.8,1],[0.6,null]]`) {
+		t.Errorf("body = %s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	pivotAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/api/stats/pivot?format=csv&model=b", nil))
+	if body := rec.Body.String(); body != "model,combined\nb,0.6\n" {
+		t.Errorf("csv = %q", body)
+	}
+
+	rec = httptest.NewRecorder()
+	pivotAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/api/stats/pivot?value=p99", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid value = %d", rec.Code)
+	}
+}
+
+func TestPivotView(t *testing.T) {
+	results := []EvalResult{
+		{Model: "a", Scores: ScoreBreakdown{Combined: 0.8}, CustomFields: map[string]any{"chunk_size": 1000.0, "top_k": 5.0, "lang": "en"}},
+		{Model: "a", Scores: ScoreBreakdown{Combined: 0.6}, CustomFields: map[string]any{"chunk_size": 200.0, "top_k": 5.0, "lang": "en"}},
+		{Model: "a", Scores: ScoreBreakdown{Combined: 0.4}, CustomFields: map[string]any{"chunk_size": 200.0, "top_k": 10.0, "lang": "en"}},
+	}
+	sweeps, all := sweepFields(results)
+	if strings.Join(sweeps, ",") != "chunk_size,top_k" || strings.Join(all, ",") != "chunk_size,lang,top_k" {
+		t.Errorf("sweeps = %v, all = %v", sweeps, all)
+	}
+
+	p := sortPivotNumeric(BuildPivot(results, "field:chunk_size", "field:top_k", "mean", "combined"))
+	if strings.Join(p.Index, ",") != "200,1000" || strings.Join(p.Columns, ",") != "5,10" {
+		t.Fatalf("labels = %v × %v", p.Index, p.Columns)
+	}
+	if *p.Data[0][0] != 0.6 || *p.Data[0][1] != 0.4 || *p.Data[1][0] != 0.8 || p.Data[1][1] != nil {
+		t.Errorf("data = %v %v", p.Data[0], p.Data[1])
+	}
+
+	table := pivotColors(p, "mean")
+	if !table.Cells[1][0].Best || table.Cells[0][0].Best || table.Cells[1][1].OK {
+		t.Errorf("cells = %+v", table.Cells)
+	}
+	if table.Cells[1][0].Color != scoreColor(0.8) {
+		t.Errorf("score color = %s", table.Cells[1][0].Color)
+	}
+	// Counts are spread over the table, and have no best cell
+	table = pivotColors(BuildPivot(results, "field:chunk_size", "field:top_k", "count", "combined"), "count")
+	if table.Cells[0][1].Best || table.Cells[0][1].Color != scoreColor(0.5) {
+		t.Errorf("count cells = %+v", table.Cells)
+	}
+}
+
+func TestPivotHandler(t *testing.T) {
+	withStore(t, `{"timestamp":"2025-01-01T10:00:00Z","model":"a","scores":{"combined":0.8},"chunk_size":1000,"top_k":5}
+{"timestamp":"2025-01-01T10:01:00Z","model":"a","scores":{"combined":0.6},"chunk_size":200,"top_k":10}`)
+
+	rec := httptest.NewRecorder()
+	pivotHandler(rec, httptest.NewRequest(http.MethodGet, "/pivot", nil))
+	if body := rec.Body.String(); rec.Code != http.StatusOK || !strings.Contains(body, "field:chunk_size \\ field:top_k") || !strings.Contains(body, "cell best") {
+		t.Errorf("pivot = %d %s", rec.Code, body)
+	}
+
+	rec = httptest.NewRecorder()
+	pivotHandler(rec, httptest.NewRequest(http.MethodGet, "/pivot?rows=team", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid rows = %d", rec.Code)
+	}
+}
@@ -0,0 +1,377 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// pgMigrations create and evolve the schema, applied in order and recorded in schema_migrations
+// Never edit an applied migration - append a new one instead
+var pgMigrations = []string{
+	// 1: results table, one row per eval with the full JSON payload
+	`CREATE TABLE eval_results (
+		id          BIGSERIAL PRIMARY KEY,
+		result_key  TEXT NOT NULL UNIQUE,
+		timestamp   TEXT NOT NULL,
+		model       TEXT NOT NULL,
+		test_id     TEXT NOT NULL DEFAULT '',
+		data        JSONB NOT NULL,
+		ingested_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+	CREATE INDEX eval_results_timestamp_idx ON eval_results (timestamp);
+	CREATE INDEX eval_results_model_idx ON eval_results (model)`,
+	// 2: the transaction that inserted each row, ids are handed out before commit and can
+	// become visible out of order
+	`ALTER TABLE eval_results ADD COLUMN txid BIGINT NOT NULL DEFAULT txid_current();
+	CREATE INDEX eval_results_txid_idx ON eval_results (txid)`,
+	// 3: ids of deleted rows, polled like new ones so every replica drops them
+	`CREATE TABLE eval_deletions (
+		result_id  BIGINT NOT NULL,
+		txid       BIGINT NOT NULL DEFAULT txid_current(),
+		deleted_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+	CREATE INDEX eval_deletions_txid_idx ON eval_deletions (txid)`,
+}
+
+// PostgresStore keeps eval results in a Postgres table
+// Several dashboards can share one database: each polls for rows it hasn't seen yet,
+// and ingest deduplicates on result_key, so replicas behind a load balancer stay consistent
+type PostgresStore struct {
+	cfg pgConfig
+
+	mu        sync.Mutex
+	conn      *pgConn
+	watermark int64           // Every transaction before it had finished at the last poll
+	seen      map[int64]int64 // Rows loaded from transactions at or after watermark, id -> txid
+	results   []EvalResult
+	ids       []int64 // Row id of each of results
+}
+
+// NewPostgresStore creates a store from a postgres:// URL
+// Example: postgres://goevals:secret@db:5432/goevals?sslmode=require
+func NewPostgresStore(rawURL string) (*PostgresStore, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid postgres URL: %w", err)
+	}
+
+	cfg := pgConfig{
+		addr:     u.Host,
+		user:     "postgres",
+		database: strings.TrimPrefix(u.Path, "/"),
+		sslMode:  "prefer",
+	}
+	if u.Port() == "" {
+		host := u.Hostname()
+		if host == "" {
+			host = "localhost"
+		}
+		cfg.addr = net.JoinHostPort(host, "5432")
+	}
+	if u.User != nil {
+		cfg.user = u.User.Username()
+		cfg.password, _ = u.User.Password()
+	}
+	if cfg.database == "" {
+		cfg.database = cfg.user
+	}
+	if mode := u.Query().Get("sslmode"); mode != "" {
+		switch mode {
+		case "disable", "prefer", "require":
+			cfg.sslMode = mode
+		default:
+			return nil, fmt.Errorf("unsupported sslmode %q (use disable, prefer or require)", mode)
+		}
+	}
+
+	return &PostgresStore{cfg: cfg}, nil
+}
+
+func (s *PostgresStore) Name() string {
+	return fmt.Sprintf("postgres://%s@%s/%s", s.cfg.user, s.cfg.addr, s.cfg.database)
+}
+
+// Load fetches rows committed and deleted since the previous call and returns the full result set
+// Concurrent writers can commit a lower id after a higher one, so rows are polled by the
+// transaction that inserted them: those of transactions still running at the last poll are
+// asked for again, skipping the ones already loaded. Deletions are polled the same way
+func (s *PostgresStore) Load() ([]EvalResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.ensureConn(); err != nil {
+		return s.results, err
+	}
+
+	// Taken before the rows: every transaction older than xmin has finished by now
+	res, err := s.conn.Exec("SELECT txid_snapshot_xmin(txid_current_snapshot())")
+	if err != nil {
+		s.resetConn()
+		return s.results, err
+	}
+	if len(res.Rows) != 1 || len(res.Rows[0]) != 1 || res.Rows[0][0] == nil {
+		return s.results, fmt.Errorf("unexpected snapshot from postgres")
+	}
+	xmin, err := strconv.ParseInt(*res.Rows[0][0], 10, 64)
+	if err != nil {
+		return s.results, fmt.Errorf("unexpected snapshot from postgres: %w", err)
+	}
+
+	var seen []string
+	for id := range s.seen {
+		seen = append(seen, strconv.FormatInt(id, 10))
+	}
+	res, err = s.conn.Query("SELECT id, txid, data FROM eval_results WHERE txid >= $1 AND id <> ALL($2::bigint[]) ORDER BY id",
+		strconv.FormatInt(s.watermark, 10), pgArray(seen))
+	if err != nil {
+		s.resetConn()
+		return s.results, err
+	}
+
+	if s.seen == nil {
+		s.seen = make(map[int64]int64)
+	}
+	for _, row := range res.Rows {
+		if len(row) != 3 || row[0] == nil || row[1] == nil || row[2] == nil {
+			continue
+		}
+		id, err := strconv.ParseInt(*row[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		txid, err := strconv.ParseInt(*row[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		s.seen[id] = txid
+
+		// Encrypted rows hold the sealed line as a JSON string
+		data := []byte(*row[2])
+		var sealed string
+		if json.Unmarshal(data, &sealed) == nil {
+			data = []byte(sealed)
+		}
+		result, err := decodeResult(data)
+		if err != nil {
+			log.Printf("Warning: Skipping unreadable row in eval_results id %d: %v", id, err)
+			continue
+		}
+		s.results = append(s.results, result)
+		s.ids = append(s.ids, id)
+	}
+
+	// Deleted by any replica, including rows loaded just now
+	res, err = s.conn.Query("SELECT result_id FROM eval_deletions WHERE txid >= $1", strconv.FormatInt(s.watermark, 10))
+	if err != nil {
+		s.resetConn()
+		return s.results, err
+	}
+	s.drop(pgIDs(res))
+
+	s.watermark = xmin
+	maps.DeleteFunc(s.seen, func(_, txid int64) bool { return txid < xmin })
+	return s.results, nil
+}
+
+// Append inserts results in one statement, rows with an existing result_key are skipped
+// Postgres text and JSONB can't hold NUL characters, so a batch with one is rejected as a whole
+func (s *PostgresStore) Append(results []EvalResult) (int, error) {
+	if len(results) == 0 {
+		return 0, nil
+	}
+
+	// One array per column, so a batch of any size is five parameters
+	var keys, timestamps, models, testIDs, rows []string
+	for i, result := range results {
+		data, err := encodeResult(result)
+		if err != nil {
+			return 0, err
+		}
+		if atRest != nil {
+			data, _ = json.Marshal(string(data)) // data is JSONB, keep it valid JSON
+		}
+		if strings.ContainsRune(result.Timestamp+result.Model+result.TestID, 0) || jsonHasNUL(data) {
+			return 0, fmt.Errorf("result %d (test_id %q) contains a NUL character, which Postgres can't store", i+1, result.TestID)
+		}
+		keys = append(keys, pgKey(resultKey(result)))
+		timestamps = append(timestamps, result.Timestamp)
+		models = append(models, result.Model)
+		testIDs = append(testIDs, result.TestID)
+		rows = append(rows, string(data))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.ensureConn(); err != nil {
+		return 0, err
+	}
+	res, err := s.conn.Query(`INSERT INTO eval_results (result_key, timestamp, model, test_id, data)
+		SELECT key, ts, model, test_id, data::jsonb FROM unnest($1::text[], $2::text[], $3::text[], $4::text[], $5::text[]) AS r(key, ts, model, test_id, data)
+		ON CONFLICT (result_key) DO NOTHING`,
+		pgArray(keys), pgArray(timestamps), pgArray(models), pgArray(testIDs), pgArray(rows))
+	if err != nil {
+		if _, ok := err.(*pgError); !ok {
+			s.resetConn()
+		}
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
+
+// Remove deletes rows by result_key and drops them from the cached result set
+func (s *PostgresStore) Remove(keys map[string]bool) (int, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.ensureConn(); err != nil {
+		return 0, err
+	}
+	var stored []string
+	for key := range keys {
+		stored = append(stored, pgKey(key))
+	}
+	res, err := s.conn.Query(`WITH deleted AS (DELETE FROM eval_results WHERE result_key = ANY($1::text[]) RETURNING id)
+		INSERT INTO eval_deletions (result_id) SELECT id FROM deleted RETURNING result_id`, pgArray(stored))
+	if err != nil {
+		if _, ok := err.(*pgError); !ok {
+			s.resetConn()
+		}
+		return 0, err
+	}
+
+	s.drop(pgIDs(res))
+	return res.RowsAffected(), nil
+}
+
+// pgIDs returns the ids in the single column of res
+func pgIDs(res pgResult) map[int64]bool {
+	ids := make(map[int64]bool)
+	for _, row := range res.Rows {
+		if len(row) == 1 && row[0] != nil {
+			if id, err := strconv.ParseInt(*row[0], 10, 64); err == nil {
+				ids[id] = true
+			}
+		}
+	}
+	return ids
+}
+
+// drop removes the rows with the given ids from the cached result set, must be called with s.mu held
+func (s *PostgresStore) drop(ids map[int64]bool) {
+	if !slices.ContainsFunc(s.ids, func(id int64) bool { return ids[id] }) {
+		return
+	}
+	var results []EvalResult // Fresh slices, callers may still hold the old ones
+	var kept []int64
+	for i, id := range s.ids {
+		if !ids[id] {
+			results = append(results, s.results[i])
+			kept = append(kept, id)
+		}
+	}
+	s.results, s.ids = results, kept
+	maps.DeleteFunc(s.seen, func(id, _ int64) bool { return ids[id] })
+}
+
+// pgKey is a resultKey as stored in result_key: its NUL separators escaped as \0, and
+// backslashes as \\ so no two keys end up the same
+func pgKey(key string) string {
+	return strings.NewReplacer(`\`, `\\`, "\x00", `\0`).Replace(key)
+}
+
+// jsonHasNUL reports whether JSON data encodes a NUL character: a \u0000 whose backslash isn't escaped
+func jsonHasNUL(data []byte) bool {
+	for i := 0; ; i++ {
+		j := bytes.Index(data[i:], []byte(`\u0000`))
+		if j < 0 {
+			return false
+		}
+		i += j
+		backslashes := 0
+		for k := i; k >= 0 && data[k] == '\\'; k-- {
+			backslashes++
+		}
+		if backslashes%2 == 1 {
+			return true
+		}
+	}
+}
+
+// ensureConn connects and runs pending migrations, must be called with s.mu held
+func (s *PostgresStore) ensureConn() error {
+	if s.conn != nil {
+		return nil
+	}
+
+	conn, err := dialPostgres(s.cfg)
+	if err != nil {
+		return err
+	}
+	if err := migratePostgres(conn); err != nil {
+		conn.Close()
+		return err
+	}
+
+	s.conn = conn
+	return nil
+}
+
+func (s *PostgresStore) resetConn() {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+}
+
+// migratePostgres applies every migration newer than the recorded schema version
+// The advisory lock keeps replicas starting at the same time from racing each other
+func migratePostgres(conn *pgConn) error {
+	const lockID = 7303 // Arbitrary, unique to goevals
+
+	if _, err := conn.Exec(fmt.Sprintf("SELECT pg_advisory_lock(%d)", lockID)); err != nil {
+		return fmt.Errorf("failed to lock migrations: %w", err)
+	}
+	defer conn.Exec(fmt.Sprintf("SELECT pg_advisory_unlock(%d)", lockID))
+
+	_, err := conn.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	res, err := conn.Exec("SELECT COALESCE(MAX(version), 0) FROM schema_migrations")
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	current := 0
+	if len(res.Rows) == 1 && res.Rows[0][0] != nil {
+		current, _ = strconv.Atoi(*res.Rows[0][0])
+	}
+
+	for i := current; i < len(pgMigrations); i++ {
+		version := i + 1
+		// Simple query batches run in one implicit transaction
+		_, err := conn.Exec(pgMigrations[i] + fmt.Sprintf(";\nINSERT INTO schema_migrations (version) VALUES (%d)", version))
+		if err != nil {
+			return fmt.Errorf("migration %d failed: %w", version, err)
+		}
+		log.Printf("Applied postgres migration %d", version)
+	}
+	return nil
+}
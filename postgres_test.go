@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestPostgresAppend(t *testing.T) {
+	var args []string
+	s := &PostgresStore{conn: fakePostgres(t, func(query string, a []string) ([][]string, string) {
+		args = a
+		return nil, "INSERT 0 2"
+	})}
+	n, err := s.Append([]EvalResult{{Model: "it's", TestID: "q1"}, {Model: `a "b"`, TestID: "q2", Response: `C:\u0000`}})
+	if err != nil || n != 2 {
+		t.Fatalf("Append = %d, %v", n, err)
+	}
+	if len(args) != 5 || !strings.HasPrefix(args[0], `{"\\0q1\\0it's",`) || args[2] != `{"it's","a \"b\""}` || !strings.Contains(args[4], `C:\\\\u0000`) {
+		t.Errorf("args = %q", args)
+	}
+
+	// Postgres can't store NUL: the batch fails instead of being stored altered
+	for _, bad := range []EvalResult{{Model: "m\x00"}, {Model: "m", Response: "a\x00b"}} {
+		if _, err := s.Append([]EvalResult{{Model: "ok"}, bad}); err == nil || !strings.Contains(err.Error(), "result 2") {
+			t.Errorf("Append(%q) = %v", bad.Model+bad.Response, err)
+		}
+	}
+}
+
+// pgRow is a row of the fake eval_results table
+type pgRow struct {
+	id, txid  int64
+	committed bool
+	key, data string
+}
+
+// fakeEvalTables stand in for the eval_results and eval_deletions tables of one database, answering
+// the polls and deletes of any number of PostgresStores
+type fakeEvalTables struct {
+	mu        sync.Mutex
+	rows      []*pgRow
+	deletions [][2]int64 // Row id, txid
+	xmin      int64      // Oldest running transaction, deletions commit right away in it
+}
+
+func (db *fakeEvalTables) store(t *testing.T) *PostgresStore {
+	return &PostgresStore{conn: fakePostgres(t, db.handle)}
+}
+
+func (db *fakeEvalTables) handle(query string, args []string) ([][]string, string) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var rows [][]string
+	switch {
+	case strings.Contains(query, "txid_snapshot_xmin"):
+		return [][]string{{strconv.FormatInt(db.xmin, 10)}}, "SELECT 1"
+	case strings.HasPrefix(query, "WITH deleted"):
+		db.rows = slices.DeleteFunc(db.rows, func(r *pgRow) bool {
+			if !r.committed || !strings.Contains(args[0], strings.Trim(pgArray([]string{r.key}), "{}")) {
+				return false
+			}
+			db.deletions = append(db.deletions, [2]int64{r.id, db.xmin})
+			rows = append(rows, []string{strconv.FormatInt(r.id, 10)})
+			return true
+		})
+		return rows, fmt.Sprintf("INSERT 0 %d", len(rows))
+	case strings.Contains(query, "FROM eval_deletions"):
+		watermark, _ := strconv.ParseInt(args[0], 10, 64)
+		for _, d := range db.deletions {
+			if d[1] >= watermark {
+				rows = append(rows, []string{strconv.FormatInt(d[0], 10)})
+			}
+		}
+	default:
+		watermark, _ := strconv.ParseInt(args[0], 10, 64)
+		for _, r := range db.rows {
+			if r.committed && r.txid >= watermark && !strings.Contains(args[1], fmt.Sprintf(`"%d"`, r.id)) {
+				rows = append(rows, []string{strconv.FormatInt(r.id, 10), strconv.FormatInt(r.txid, 10), r.data})
+			}
+		}
+	}
+	return rows, fmt.Sprintf("SELECT %d", len(rows))
+}
+
+// pgModels loads s and lists the models of its results
+func pgModels(t *testing.T, s *PostgresStore) string {
+	t.Helper()
+	results, err := s.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, r := range results {
+		names = append(names, r.Model)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestPostgresOutOfOrderCommits(t *testing.T) {
+	// Two writers: transaction 100 got id 1 but commits after transaction 101, which got id 2
+	db := &fakeEvalTables{xmin: 100, rows: []*pgRow{
+		{id: 1, txid: 100, data: `{"model":"slow","test_id":"q1"}`},
+		{id: 2, txid: 101, committed: true, data: `{"model":"fast","test_id":"q1"}`},
+	}}
+	s := db.store(t)
+
+	if got := pgModels(t, s); got != "fast" {
+		t.Errorf("first poll = %s", got)
+	}
+	db.rows[0].committed, db.xmin = true, 102
+	if got := pgModels(t, s); got != "fast,slow" {
+		t.Errorf("row committed late = %s, want it loaded once", got)
+	}
+	if got := pgModels(t, s); got != "fast,slow" || len(s.seen) != 0 {
+		t.Errorf("after both finished = %s, still tracking %v", got, s.seen)
+	}
+}
+
+func TestPostgresRemoveOnEveryReplica(t *testing.T) {
+	db := &fakeEvalTables{xmin: 10}
+	for i, r := range []EvalResult{{Model: "old", TestID: "q1"}, {Model: "new", TestID: "q1"}} {
+		data, _ := json.Marshal(r)
+		db.rows = append(db.rows, &pgRow{id: int64(i + 1), txid: 5, committed: true, key: pgKey(resultKey(r)), data: string(data)})
+	}
+	a, b := db.store(t), db.store(t)
+	if pgModels(t, a) != "old,new" || pgModels(t, b) != "old,new" {
+		t.Fatal("replicas didn't load both rows")
+	}
+
+	n, err := a.Remove(map[string]bool{resultKey(EvalResult{Model: "old", TestID: "q1"}): true})
+	if err != nil || n != 1 || len(a.results) != 1 {
+		t.Fatalf("Remove = %d, %v, %d cached", n, err, len(a.results))
+	}
+	db.xmin = 12
+	for _, s := range []*PostgresStore{b, a, b} {
+		if got := pgModels(t, s); got != "new" {
+			t.Errorf("after the delete a replica serves %s", got)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// fieldProjection is a parsed ?fields= list, e.g. test_id,model,scores.combined
+// Each field maps to the nested fields kept of its value, nil to keep all of it
+type fieldProjection map[string]fieldProjection
+
+// parseFields parses a comma separated list of JSON field paths, nil when spec is empty
+// Nested fields are separated by dots, and apply to every element of arrays
+// (retrieved_contexts.text)
+func parseFields(spec string) (fieldProjection, error) {
+	var p fieldProjection
+	for _, path := range strings.Split(spec, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if p == nil {
+			p = make(fieldProjection)
+		}
+		node := p
+		parts := strings.Split(path, ".")
+		for i, name := range parts {
+			if name == "" {
+				return nil, fmt.Errorf("invalid field %q", path)
+			}
+			sub, seen := node[name]
+			if seen && sub == nil {
+				break // A parent is already kept whole
+			}
+			if i == len(parts)-1 {
+				node[name] = nil
+				break
+			}
+			if sub == nil {
+				sub = make(fieldProjection)
+				node[name] = sub
+			}
+			node = sub
+		}
+	}
+	return p, nil
+}
+
+// fieldsRequest reads the fields parameter, writing 400 and returning false when it is invalid
+func fieldsRequest(w http.ResponseWriter, r *http.Request) (fieldProjection, bool) {
+	p, err := parseFields(r.URL.Query().Get("fields"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return p, true
+}
+
+// apply returns the projected result as it would be encoded to JSON
+func (p fieldProjection) apply(result EvalResult) (map[string]any, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	var full map[string]any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber() // Keep numbers as they were written
+	if err := dec.Decode(&full); err != nil {
+		return nil, err
+	}
+	return p.object(full), nil
+}
+
+func (p fieldProjection) object(full map[string]any) map[string]any {
+	out := make(map[string]any, len(p))
+	for name, sub := range p {
+		value, ok := full[name]
+		if !ok {
+			continue // Fields a result doesn't have are left out, like omitempty
+		}
+		if sub == nil {
+			out[name] = value
+		} else if value, ok := sub.value(value); ok {
+			out[name] = value
+		}
+	}
+	return out
+}
+
+// value projects nested fields of an object, or of each object in an array
+func (p fieldProjection) value(v any) (any, bool) {
+	switch v := v.(type) {
+	case map[string]any:
+		return p.object(v), true
+	case []any:
+		out := make([]any, len(v))
+		for i, elem := range v {
+			out[i], _ = p.value(elem)
+		}
+		return out, true
+	}
+	return nil, false // A scalar has no nested fields
+}
+
+// projectResults applies p to every result, returning them unchanged when p is nil
+func projectResults(results []EvalResult, p fieldProjection) (any, error) {
+	if p == nil {
+		return results, nil
+	}
+	out := make([]map[string]any, len(results))
+	for i, result := range results {
+		projected, err := p.apply(result)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = projected
+	}
+	return out, nil
+}
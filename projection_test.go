@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestFieldProjection(t *testing.T) {
+	var result EvalResult
+	json.Unmarshal([]byte(`{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"t1","question":"long question","response":"long response",
+		"scores":{"combined":0.75,"accuracy":1},"chunk_size":512,
+		"retrieved_contexts":[{"text":"chunk one","source":"a.md"},{"text":"chunk two"}]}`), &result)
+
+	for _, tc := range []struct {
+		fields string
+		want   string
+	}{
+		{"test_id,model,scores.combined", `{"model":"a","scores":{"combined":0.75},"test_id":"t1"}`},
+		{"scores.combined, scores", `{"scores":{"accuracy":1,"combined":0.75}}`},
+		{"chunk_size,retrieved_contexts.text,missing,model.x", `{"chunk_size":512,"retrieved_contexts":[{"text":"chunk one"},{"text":"chunk two"}]}`},
+	} {
+		p, err := parseFields(tc.fields)
+		if err != nil {
+			t.Fatalf("parseFields(%q): %v", tc.fields, err)
+		}
+		projected, err := p.apply(result)
+		if err != nil {
+			t.Fatalf("apply(%q): %v", tc.fields, err)
+		}
+		var got, want any
+		data, _ := json.Marshal(projected)
+		json.Unmarshal(data, &got)
+		json.Unmarshal([]byte(tc.want), &want)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("fields=%s: got %s, want %s", tc.fields, data, tc.want)
+		}
+	}
+
+	if p, err := parseFields(""); p != nil || err != nil {
+		t.Errorf("empty fields = %v, %v", p, err)
+	}
+	if _, err := parseFields("scores..combined"); err == nil {
+		t.Error("empty path segment accepted")
+	}
+}
+
+func TestEvalsAPIFields(t *testing.T) {
+	withStore(t, `{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"t1","response":"long","scores":{"combined":0.5}}`)
+
+	for _, target := range []string{"/api/evals?fields=test_id,scores.combined", "/api/evals?limit=10&fields=test_id,scores.combined"} {
+		w := httptest.NewRecorder()
+		evalsAPIHandler(w, httptest.NewRequest(http.MethodGet, target, nil))
+		var response map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("%s = %d %s", target, w.Code, w.Body)
+		}
+		results, _ := response["results"].([]any)
+		if len(results) != 1 || response["Results"] != nil {
+			t.Fatalf("%s = %s", target, w.Body)
+		}
+		if got := results[0].(map[string]any); len(got) != 2 || got["test_id"] != "t1" || got["response"] != nil {
+			t.Errorf("%s result = %v", target, got)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	evalsAPIHandler(w, httptest.NewRequest(http.MethodGet, "/api/evals?format=ndjson&fields=model", nil))
+	if w.Body.String() != `{"model":"a"}`+"\n" {
+		t.Errorf("ndjson with fields = %q", w.Body)
+	}
+	w = httptest.NewRecorder()
+	evalsSinceHandler(w, httptest.NewRequest(http.MethodGet, "/api/evals/since?ts=2024-01-01&fields=test_id", nil))
+	if w.Body.String() != `[{"test_id":"t1"}]`+"\n" {
+		t.Errorf("since with fields = %q", w.Body)
+	}
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// QuestionEntry is one test in the question bank, aggregated over every result for its test_id
+type QuestionEntry struct {
+	TestID      string   `json:"test_id"`
+	Question    string   `json:"question"` // From the most recent result
+	Expected    string   `json:"expected,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Evaluations int      `json:"evaluations"`
+	Models      int      `json:"models"`
+	AvgScore    float64  `json:"avg_score"` // Over the evaluations without an error, see scored
+	LastSeen    string   `json:"last_seen"`
+	Variants    int      `json:"variants"` // Distinct question texts seen, > 1 means the test was edited
+}
+
+// resultTags reads tags from a "tags" custom field or metadata.tags,
+// given either as a list or a comma separated string, else from the result's case in --dataset
+func resultTags(result EvalResult) []string {
+	raw, ok := result.CustomFields["tags"]
+	if !ok {
+		raw, ok = result.Metadata["tags"]
+	}
+	if c, found := goldenSet.Lookup(result.TestID); !ok && found {
+		return slices.Clone(c.Tags)
+	}
+	var tags []string
+	switch v := raw.(type) {
+	case string:
+		for _, tag := range strings.Split(v, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if tag, ok := item.(string); ok && strings.TrimSpace(tag) != "" {
+				tags = append(tags, strings.TrimSpace(tag))
+			}
+		}
+	}
+	return tags
+}
+
+// BuildQuestionBank groups results by test_id, sorted by test_id
+// Results without a test_id are left out; cases of --dataset without results are listed with no evaluations
+func BuildQuestionBank(results []EvalResult) []QuestionEntry {
+	type acc struct {
+		entry     QuestionEntry
+		sum       float64
+		scored    int
+		models    map[string]bool
+		tags      map[string]bool
+		questions map[string]bool
+	}
+	byTest := make(map[string]*acc)
+	for _, result := range results {
+		if result.TestID == "" {
+			continue
+		}
+		a := byTest[result.TestID]
+		if a == nil {
+			a = &acc{
+				entry:     QuestionEntry{TestID: result.TestID},
+				models:    make(map[string]bool),
+				tags:      make(map[string]bool),
+				questions: make(map[string]bool),
+			}
+			byTest[result.TestID] = a
+		}
+		a.entry.Evaluations++
+		if isScored(result) {
+			a.sum += result.Scores.Combined
+			a.scored++
+		}
+		a.models[result.Model] = true
+		for _, tag := range resultTags(result) {
+			a.tags[tag] = true
+		}
+		if result.Question != "" {
+			a.questions[result.Question] = true
+		}
+		if result.Timestamp >= a.entry.LastSeen {
+			a.entry.LastSeen = result.Timestamp
+			if result.Question != "" {
+				a.entry.Question = result.Question
+			}
+			if result.Expected != "" {
+				a.entry.Expected = result.Expected
+			}
+		}
+	}
+
+	bank := make([]QuestionEntry, 0, len(byTest))
+	for _, a := range byTest {
+		if a.scored > 0 {
+			a.entry.AvgScore = a.sum / float64(a.scored)
+		}
+		a.entry.Models = len(a.models)
+		a.entry.Tags = sortedKeys(a.tags, "")
+		a.entry.Variants = len(a.questions)
+		bank = append(bank, a.entry)
+	}
+	sort.Slice(bank, func(i, j int) bool { return bank[i].TestID < bank[j].TestID })
+	return withDatasetCases(bank)
+}
+
+// questionSorts orders the question bank, "-" in the sort parameter reverses
+var questionSorts = map[string]func(a, b QuestionEntry) bool{
+	"test_id":     func(a, b QuestionEntry) bool { return a.TestID < b.TestID },
+	"evaluations": func(a, b QuestionEntry) bool { return a.Evaluations < b.Evaluations },
+	"score":       func(a, b QuestionEntry) bool { return a.AvgScore < b.AvgScore },
+	"last_seen":   func(a, b QuestionEntry) bool { return a.LastSeen < b.LastSeen },
+}
+
+// filterQuestions narrows the bank by tag and a case-insensitive text match on test_id or question,
+// then applies sort (e.g. "-score"); unknown sorts keep test_id order
+func filterQuestions(bank []QuestionEntry, tag, text, sortBy string) []QuestionEntry {
+	text = strings.ToLower(strings.TrimSpace(text))
+	var filtered []QuestionEntry
+	for _, entry := range bank {
+		if tag != "" && !slices.Contains(entry.Tags, tag) {
+			continue
+		}
+		if text != "" && !strings.Contains(strings.ToLower(entry.TestID), text) &&
+			!strings.Contains(strings.ToLower(entry.Question), text) {
+			continue
+		}
+		filtered = append(filtered, entry)
+	}
+	if less, ok := questionSorts[strings.TrimPrefix(sortBy, "-")]; ok {
+		desc := strings.HasPrefix(sortBy, "-")
+		sort.SliceStable(filtered, func(i, j int) bool {
+			if desc {
+				return less(filtered[j], filtered[i])
+			}
+			return less(filtered[i], filtered[j])
+		})
+	}
+	return filtered
+}
+
+// QuestionColumn is a sortable question bank header, linking to the opposite direction when active
+type QuestionColumn struct {
+	Label string
+	URL   string
+	Arrow string
+}
+
+// questionColumns builds the sortable headers for the current filters
+func questionColumns(params url.Values, sortBy string) map[string]QuestionColumn {
+	columns := map[string]QuestionColumn{}
+	for key, label := range map[string]string{"test_id": "Test ID", "evaluations": "Evaluations", "score": "Avg Score", "last_seen": "Last Seen"} {
+		col := QuestionColumn{Label: label}
+		next := key
+		switch sortBy {
+		case key:
+			col.Arrow, next = " ↑", "-"+key
+		case "-" + key:
+			col.Arrow = " ↓"
+		}
+		link := url.Values{"sort": {next}}
+		for _, p := range []string{"q", "tag", "weights"} {
+			if v := params.Get(p); v != "" {
+				link.Set(p, v)
+			}
+		}
+		col.URL = "/questions?" + link.Encode()
+		columns[key] = col
+	}
+	return columns
+}
+
+// questionsHandler renders the question bank browser
+func questionsHandler(w http.ResponseWriter, r *http.Request) {
+	results, err := store.Query(r.Context(), Query{})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	results, ok := reweightRequest(w, r, results)
+	if !ok {
+		return
+	}
+
+	bank := BuildQuestionBank(results)
+	tagSet := make(map[string]bool)
+	for _, entry := range bank {
+		for _, tag := range entry.Tags {
+			tagSet[tag] = true
+		}
+	}
+	params := r.URL.Query()
+	sortBy := params.Get("sort")
+	if sortBy == "" {
+		sortBy = "test_id"
+	}
+
+	data := struct {
+		Title     string
+		Subtitle  string
+		Questions []QuestionEntry
+		Total     int
+		Tags      []string
+		Tag       string
+		Search    string
+		Sort      string
+		Columns   map[string]QuestionColumn
+	}{
+		Title:     "Question Bank",
+		Subtitle:  "Every test in your evals with how often and how well it has been answered",
+		Questions: filterQuestions(bank, params.Get("tag"), params.Get("q"), sortBy),
+		Total:     len(bank),
+		Tags:      sortedKeys(tagSet, ""),
+		Tag:       params.Get("tag"),
+		Search:    params.Get("q"),
+		Sort:      sortBy,
+		Columns:   questionColumns(params, sortBy),
+	}
+
+	renderPage(w, r, "questions", questionsTemplate, data)
+}
+
+// questionsAPIHandler returns the question bank as JSON, with the same tag, q and sort parameters
+func questionsAPIHandler(w http.ResponseWriter, r *http.Request) {
+	results, err := store.Query(r.Context(), Query{})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	results, ok := reweightRequest(w, r, results)
+	if !ok {
+		return
+	}
+
+	params := r.URL.Query()
+	questions := filterQuestions(BuildQuestionBank(results), params.Get("tag"), params.Get("q"), params.Get("sort"))
+	if questions == nil {
+		questions = []QuestionEntry{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(questions); err != nil {
+		log.Printf("Error encoding JSON: %v", err)
+	}
+}
+
+const questionsTemplate = `
+{{ define "style" }}
+        .question-filters {
+            display: flex;
+            gap: 0.75rem;
+            align-items: center;
+        }
+        .question-filters input[type="text"] {
+            flex: 1;
+        }
+        .question-text {
+            max-width: 520px;
+        }
+        .tag {
+            display: inline-block;
+            background: var(--bg-tertiary);
+            color: var(--text-secondary);
+            border-radius: 4px;
+            padding: 0 0.4rem;
+            margin: 0.15rem 0.25rem 0 0;
+            font-size: 0.75rem;
+            text-decoration: none;
+        }
+        .tag:hover {
+            color: var(--accent);
+        }
+        th a {
+            color: inherit;
+            text-decoration: none;
+        }
+        th a:hover {
+            color: var(--accent);
+        }
+        td a.mono {
+            color: var(--accent);
+            text-decoration: none;
+        }
+{{ end }}
+
+{{ define "content" }}
+        <div class="panel">
+            <form method="get" action="/questions" class="question-filters">
+                <input type="text" name="q" value="{{ .Search }}" placeholder="Filter by test ID or question text">
+                {{ if .Tags }}
+                <select name="tag" onchange="this.form.submit()">
+                    <option value="">All tags</option>
+                    {{ range .Tags }}<option value="{{ . }}" {{ if eq . $.Tag }}selected{{ end }}>{{ . }}</option>{{ end }}
+                </select>
+                {{ end }}
+                <input type="hidden" name="sort" value="{{ .Sort }}">
+                <span class="muted">{{ len .Questions }} of {{ .Total }} tests</span>
+            </form>
+        </div>
+
+        <div class="panel">
+            {{ if .Questions }}
+            <div style="overflow-x: auto;">
+            <table>
+                <thead>
+                    <tr>
+                        {{ template "sortable" (index .Columns "test_id") }}
+                        <th>Question</th>
+                        {{ template "sortable" (index .Columns "evaluations") }}
+                        <th>Models</th>
+                        {{ template "sortable" (index .Columns "score") }}
+                        {{ template "sortable" (index .Columns "last_seen") }}
+                    </tr>
+                </thead>
+                <tbody>
+                    {{ range .Questions }}
+                    <tr>
+                        <td><a class="mono" href="/compare?test_id={{ .TestID }}" title="Compare every model's answer">{{ .TestID }}</a></td>
+                        <td class="question-text">
+                            {{ display .Question }}{{ if gt .Variants 1 }} <span class="muted" title="The question text changed between runs">({{ .Variants }} versions)</span>{{ end }}
+                            {{ if .Tags }}<div>{{ range .Tags }}<a class="tag" href="/questions?tag={{ . }}">{{ . }}</a>{{ end }}</div>{{ end }}
+                        </td>
+                        <td>{{ human .Evaluations }}</td>
+                        <td>{{ .Models }}</td>
+                        <td>{{ if .Evaluations }}<span class="score-badge {{ scoreClass .AvgScore }}">{{ printf "%.3f" .AvgScore }}</span>{{ else }}<span class="muted" title="In the dataset, but no model has run it yet">not run</span>{{ end }}</td>
+                        <td class="mono muted">{{ .LastSeen }}</td>
+                    </tr>
+                    {{ end }}
+                </tbody>
+            </table>
+            </div>
+            {{ else }}
+            <p class="muted">No tests match. Results need a <span class="mono">test_id</span> to appear here; add a <span class="mono">tags</span> field (list or comma separated) to group them.</p>
+            {{ end }}
+        </div>
+{{ end }}
+
+{{ define "sortable" }}<th><a href="{{ .URL }}">{{ .Label }}{{ .Arrow }}</a></th>{{ end }}`
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBuildQuestionBank(t *testing.T) {
+	results := []EvalResult{
+		{Timestamp: "2025-01-01T10:00:00Z", Model: "a", TestID: "t1", Question: "Old wording?", Scores: ScoreBreakdown{Combined: 0.4},
+			CustomFields: map[string]any{"tags": []any{"geo", " easy "}}},
+		{Timestamp: "2025-01-02T10:00:00Z", Model: "b", TestID: "t1", Question: "New wording?", Scores: ScoreBreakdown{Combined: 0.8},
+			Metadata: map[string]any{"tags": "geo,hard"}},
+		{Timestamp: "2025-01-01T12:00:00Z", Model: "a", TestID: "t2", Question: "Other?", Scores: ScoreBreakdown{Combined: 1}},
+		{Model: "a", Question: "No test id"},
+	}
+
+	bank := BuildQuestionBank(results)
+	if len(bank) != 2 {
+		t.Fatalf("bank = %+v", bank)
+	}
+	t1 := bank[0]
+	if t1.TestID != "t1" || t1.Question != "New wording?" || t1.Evaluations != 2 || t1.Models != 2 ||
+		math.Abs(t1.AvgScore-0.6) > 1e-9 || t1.LastSeen != "2025-01-02T10:00:00Z" || t1.Variants != 2 {
+		t.Errorf("t1 = %+v", t1)
+	}
+	if strings.Join(t1.Tags, ",") != "easy,geo,hard" {
+		t.Errorf("t1 tags = %v", t1.Tags)
+	}
+
+	if got := filterQuestions(bank, "geo", "", ""); len(got) != 1 || got[0].TestID != "t1" {
+		t.Errorf("tag filter = %+v", got)
+	}
+	if got := filterQuestions(bank, "", "OTHER", ""); len(got) != 1 || got[0].TestID != "t2" {
+		t.Errorf("text filter = %+v", got)
+	}
+	if got := filterQuestions(bank, "", "", "-score"); got[0].TestID != "t2" {
+		t.Errorf("sort by -score = %+v", got)
+	}
+}
+
+func TestQuestionsPage(t *testing.T) {
+	useStore(t, NewMemoryStore([]Source{&fileSource{path: "evals.jsonl"}}))
+
+	rec := httptest.NewRecorder()
+	questionsHandler(rec, httptest.NewRequest(http.MethodGet, "/questions?sort=-score", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `href="/compare?test_id=eval_001"`) || !strings.Contains(body, `href="/questions?sort=score">Avg Score ↓`) {
+		t.Error("question bank missing rows or sort links")
+	}
+}
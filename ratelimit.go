@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Ingest limits from --ingest-rate, --ingest-burst, --max-ingest-mb and --behind-proxy
+// A misbehaving eval runner (a retry loop, a runaway backfill) gets 429s instead of taking
+// the shared instance down; other clients keep their own budget
+var (
+	ingestLimiter   *rateLimiter                  // nil = unlimited
+	ingestBodyLimit int64        = maxIngestBytes // Bytes per /api/ingest request
+	behindProxy     bool                          // Take the client IP from X-Forwarded-For
+)
+
+// rateLimitIdle is how long a client's bucket is kept after its last request
+const rateLimitIdle = 10 * time.Minute
+
+// rateLimiter is a token bucket per client IP: rate requests per second on average, bursts of
+// up to burst requests
+type rateLimiter struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	swept   time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter creates a limiter, nil when rate is 0 (unlimited)
+// burst defaults to twice the rate, and at least one request
+func newRateLimiter(rate float64, burst int) (*rateLimiter, error) {
+	if rate < 0 || burst < 0 || math.IsNaN(rate) || math.IsInf(rate, 0) {
+		return nil, fmt.Errorf("rate limits can't be negative, got %g requests/s and a burst of %d", rate, burst)
+	}
+	if rate == 0 {
+		return nil, nil
+	}
+	b := float64(burst)
+	if burst == 0 {
+		b = max(1, math.Ceil(2*rate))
+	}
+	return &rateLimiter{rate: rate, burst: b, now: time.Now, buckets: make(map[string]*tokenBucket)}, nil
+}
+
+// allow takes a request from client's bucket, or reports how long until one is available
+// A nil limiter allows everything
+func (l *rateLimiter) allow(client string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.swept) > rateLimitIdle {
+		for key, b := range l.buckets {
+			if now.Sub(b.last) > rateLimitIdle {
+				delete(l.buckets, key)
+			}
+		}
+		l.swept = now
+	}
+
+	b := l.buckets[client]
+	if b == nil {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+// clientIP is the address rate limits are kept for
+// Behind a reverse proxy every request comes from the proxy, so with --behind-proxy the last
+// X-Forwarded-For entry is used: the one the proxy added, which clients can't forge
+func clientIP(r *http.Request) string {
+	if behindProxy {
+		if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+			list := strings.Split(forwarded[len(forwarded)-1], ",")
+			if ip := strings.TrimSpace(list[len(list)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// limitIngest answers 429 Too Many Requests to clients over the ingest rate limit
+func limitIngest(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if ok, wait := ingestLimiter.allow(clientIP(r)); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "Too many ingest requests from this client, slow down", http.StatusTooManyRequests)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l, err := newRateLimiter(2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	// A burst of 3, then the client waits for the next token
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.allow("10.0.0.1"); !ok {
+			t.Fatalf("request %d of the burst was limited", i+1)
+		}
+	}
+	ok, wait := l.allow("10.0.0.1")
+	if ok || wait != 500*time.Millisecond {
+		t.Errorf("after burst = %v, wait %s", ok, wait)
+	}
+	// Other clients have their own bucket
+	if ok, _ := l.allow("10.0.0.2"); !ok {
+		t.Errorf("another client was limited")
+	}
+	now = now.Add(500 * time.Millisecond)
+	if ok, _ := l.allow("10.0.0.1"); !ok {
+		t.Errorf("limited after the token refilled")
+	}
+
+	// Idle buckets are dropped
+	now = now.Add(2 * rateLimitIdle)
+	l.allow("10.0.0.3")
+	if len(l.buckets) != 1 {
+		t.Errorf("%d buckets after idle sweep, want 1", len(l.buckets))
+	}
+
+	if l, err := newRateLimiter(0, 0); l != nil || err != nil {
+		t.Errorf("rate 0 = %v, %v, want unlimited", l, err)
+	}
+	if _, err := newRateLimiter(-1, 0); err == nil {
+		t.Errorf("negative rate accepted")
+	}
+	if l, _ := newRateLimiter(0.1, 0); l.burst != 1 {
+		t.Errorf("default burst for 0.1/s = %g, want 1", l.burst)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	defer func() { behindProxy = false }()
+	r := httptest.NewRequest(http.MethodPost, "/api/ingest", nil)
+	r.RemoteAddr = "192.0.2.1:4321"
+	r.Header.Add("X-Forwarded-For", "203.0.113.9, 198.51.100.7")
+
+	if ip := clientIP(r); ip != "192.0.2.1" {
+		t.Errorf("clientIP = %q, want the connection address", ip)
+	}
+	behindProxy = true
+	if ip := clientIP(r); ip != "198.51.100.7" {
+		t.Errorf("clientIP behind proxy = %q, want the address the proxy added", ip)
+	}
+}
+
+func TestIngestLimits(t *testing.T) {
+	defer func() { ingestLimiter, ingestBodyLimit = nil, maxIngestBytes }()
+	ingestLimiter, _ = newRateLimiter(1, 1)
+	ingestBodyLimit = 64
+
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		limitIngest(ingestHandler)(w, ingestRequest(t, body))
+		return w
+	}
+	if w := post(strings.Repeat(`{"model":"a"}`+"\n", 10)); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized body = %d %s", w.Code, w.Body)
+	}
+	w := post("")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" {
+		t.Errorf("second request = %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+}
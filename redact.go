@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// RedactionPattern masks one kind of sensitive text; a pattern with only a name is a built-in
+type RedactionPattern struct {
+	Name    string `json:"name"`
+	Regex   string `json:"regex"`
+	Replace string `json:"replace"` // Default [redacted:<name>]
+
+	re    *regexp.Regexp
+	check func(string) bool // Extra test of a match, e.g. the Luhn checksum of card numbers
+}
+
+// RedactionRules is the --redact file
+type RedactionRules struct {
+	// At is when text is masked: "render" (default) masks what the server shows and leaves the
+	// sources as they are, "ingest" masks results posted to /api/ingest before they are stored
+	At       string             `json:"at"`
+	Patterns []RedactionPattern `json:"patterns"` // Default every built-in
+	// Allow lists fields never masked: question, response, expected, error, judge_reasoning,
+	// retrieved_contexts, trace, metadata, or the name of a custom field
+	Allow []string `json:"allow"`
+
+	allow map[string]bool
+}
+
+// redaction holds the rules from --redact, nil = nothing is masked
+var redaction *RedactionRules
+
+// builtinRedactions are the patterns available by name, all of them apply when a rules file
+// lists no patterns
+var builtinRedactions = []RedactionPattern{
+	{Name: "email", Regex: `[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`},
+	// OpenAI/Anthropic style sk-..., AWS access keys, GitHub and Slack tokens, Google API keys
+	// and bearer tokens in headers
+	{Name: "api_key", Regex: `\b(?:sk|pk|rk)-[A-Za-z0-9_-]{16,}|\bAKIA[0-9A-Z]{16}\b|\bgh[pousr]_[A-Za-z0-9]{36,}|\bxox[abposr]-[A-Za-z0-9-]{10,}|\bAIza[0-9A-Za-z_-]{35}|(?i:\bbearer\s+)[A-Za-z0-9._~+/-]{16,}=*`},
+	{Name: "credit_card", Regex: `\b\d(?:[ -]?\d){12,18}\b`, check: luhn},
+	{Name: "phone", Regex: `(?:\+\d{1,3}[\s.-]?)?\(?\b\d{3}\)?[\s.-]\d{3}[\s.-]\d{4}\b`},
+}
+
+// loadRedaction reads a rules file, an empty path means no redaction
+func loadRedaction(path string) (*RedactionRules, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read redaction rules: %w", err)
+	}
+	var rules RedactionRules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("invalid redaction rules %s: %w", path, err)
+	}
+	if err := rules.compile(); err != nil {
+		return nil, fmt.Errorf("invalid redaction rules %s: %w", path, err)
+	}
+	return &rules, nil
+}
+
+// compile validates the rules and fills in defaults
+func (rules *RedactionRules) compile() error {
+	switch rules.At {
+	case "":
+		rules.At = "render"
+	case "render", "ingest":
+	default:
+		return fmt.Errorf("at must be render or ingest, not %q", rules.At)
+	}
+	if len(rules.Patterns) == 0 {
+		for _, builtin := range builtinRedactions {
+			rules.Patterns = append(rules.Patterns, RedactionPattern{Name: builtin.Name})
+		}
+	}
+	for i := range rules.Patterns {
+		p := &rules.Patterns[i]
+		if p.Regex == "" {
+			j := slices.IndexFunc(builtinRedactions, func(b RedactionPattern) bool { return b.Name == p.Name })
+			if j < 0 {
+				return fmt.Errorf("pattern %d (%s) needs a regex, or the name of a built-in: email, api_key, credit_card, phone", i+1, p.Name)
+			}
+			p.Regex, p.check = builtinRedactions[j].Regex, builtinRedactions[j].check
+		}
+		if p.Name == "" {
+			return fmt.Errorf("pattern %d has no name", i+1)
+		}
+		re, err := regexp.Compile(p.Regex)
+		if err != nil {
+			return fmt.Errorf("pattern %s: %w", p.Name, err)
+		}
+		p.re = re
+		if p.Replace == "" {
+			p.Replace = "[redacted:" + p.Name + "]"
+		}
+	}
+	rules.allow = make(map[string]bool, len(rules.Allow))
+	for _, field := range rules.Allow {
+		rules.allow[field] = true
+	}
+	return nil
+}
+
+// luhn reports whether the digits of s pass the Luhn checksum of card numbers
+func luhn(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] < '0' || s[i] > '9' {
+			continue
+		}
+		d := int(s[i] - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// text masks s, recording the names of the patterns that matched in hits
+func (rules *RedactionRules) text(s string, hits map[string]bool) string {
+	for _, p := range rules.Patterns {
+		s = p.re.ReplaceAllStringFunc(s, func(match string) string {
+			if p.check != nil && !p.check(match) {
+				return match
+			}
+			hits[p.Name] = true
+			return p.Replace
+		})
+	}
+	return s
+}
+
+// value masks the strings inside a JSON value, copying the maps and slices it changes
+func (rules *RedactionRules) value(v any, hits map[string]bool) any {
+	switch v := v.(type) {
+	case string:
+		return rules.text(v, hits)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, inner := range v {
+			out[key] = rules.value(inner, hits)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, inner := range v {
+			out[i] = rules.value(inner, hits)
+		}
+		return out
+	}
+	return v
+}
+
+// Redact returns result with sensitive text masked and Redacted listing the patterns that
+// matched; result itself is left untouched, it may be shared with the store
+// A valid sha256 is recomputed over the masked content, a tampered one is kept as it is
+func (rules *RedactionRules) Redact(result EvalResult) EvalResult {
+	if rules == nil {
+		return result
+	}
+	hits := make(map[string]bool)
+	out := result
+	masked := func(field string) bool { return !rules.allow[field] }
+	if masked("question") {
+		out.Question = rules.text(out.Question, hits)
+	}
+	if masked("response") {
+		out.Response = rules.text(out.Response, hits)
+	}
+	if masked("expected") {
+		out.Expected = rules.text(out.Expected, hits)
+	}
+	if masked("error") {
+		out.Error = rules.text(out.Error, hits)
+	}
+	if masked("judge_reasoning") {
+		out.JudgeFactualReasoning = rules.text(out.JudgeFactualReasoning, hits)
+		out.JudgeFaithfulReasoning = rules.text(out.JudgeFaithfulReasoning, hits)
+		out.JudgeContextReasoning = rules.text(out.JudgeContextReasoning, hits)
+		if out.JudgeReasoning != nil {
+			out.JudgeReasoning = maps.Clone(out.JudgeReasoning)
+			for score, text := range out.JudgeReasoning {
+				out.JudgeReasoning[score] = rules.text(text, hits)
+			}
+		}
+	}
+	if masked("retrieved_contexts") && out.RetrievedContexts != nil {
+		out.RetrievedContexts = slices.Clone(out.RetrievedContexts)
+		for i := range out.RetrievedContexts {
+			out.RetrievedContexts[i].Text = rules.text(out.RetrievedContexts[i].Text, hits)
+		}
+	}
+	if masked("trace") && out.Trace != nil {
+		out.Trace = slices.Clone(out.Trace)
+		for i := range out.Trace {
+			step := &out.Trace[i]
+			step.Input, step.Output = rules.value(step.Input, hits), rules.value(step.Output, hits)
+			step.Error = rules.text(step.Error, hits)
+		}
+	}
+	if masked("metadata") && out.Metadata != nil {
+		out.Metadata = rules.value(out.Metadata, hits).(map[string]any)
+	}
+	if out.CustomFields != nil {
+		out.CustomFields = maps.Clone(out.CustomFields)
+		for name, v := range out.CustomFields {
+			if masked(name) {
+				out.CustomFields[name] = rules.value(v, hits)
+			}
+		}
+	}
+
+	if len(hits) == 0 {
+		return result
+	}
+	for name := range hits {
+		if !slices.Contains(out.Redacted, name) {
+			out.Redacted = append(slices.Clip(out.Redacted), name)
+		}
+	}
+	slices.Sort(out.Redacted)
+	return restampIfIntact(result, out)
+}
+
+// RedactAll masks every result, see Redact; results is left untouched
+func (rules *RedactionRules) RedactAll(results []EvalResult) []EvalResult {
+	if rules == nil || len(results) == 0 {
+		return results
+	}
+	out := make([]EvalResult, len(results))
+	for i, result := range results {
+		out[i] = rules.Redact(result)
+	}
+	return out
+}
+
+// RedactedNames lists the patterns that masked text in the result, for the tests page badge
+func (er EvalResult) RedactedNames() string {
+	return strings.Join(er.Redacted, ", ")
+}
+
+// redactingStore masks everything read from the wrapped store (redaction at render time)
+type redactingStore struct {
+	Store
+	rules *RedactionRules
+}
+
+func (s redactingStore) Query(ctx context.Context, q Query) ([]EvalResult, error) {
+	results, err := s.Store.Query(ctx, q)
+	return s.rules.RedactAll(results), err
+}
+
+func (s redactingStore) Stats(ctx context.Context) (DashboardData, error) {
+	data, err := s.Store.Stats(ctx)
+	data.Results = s.rules.RedactAll(data.Results)
+	return data, err
+}
+
+func (s redactingStore) Watch(ctx context.Context, interval time.Duration) <-chan []EvalResult {
+	in := s.Store.Watch(ctx, interval)
+	out := make(chan []EvalResult)
+	go func() {
+		defer close(out)
+		for batch := range in {
+			select {
+			case out <- s.rules.RedactAll(batch):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
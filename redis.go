@@ -0,0 +1,431 @@
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RedisStreamSource reads eval results from a Redis Stream using a consumer group
+// Every entry carries one JSON eval result in a single field (default "data")
+// Entries are acknowledged after parsing, so a restarted dashboard continues where it stopped
+type RedisStreamSource struct {
+	addr       string
+	tls        bool
+	username   string // Redis 6 ACL user, empty for the password alone (requirepass)
+	password   string
+	db         int
+	stream     string
+	group      string
+	consumer   string
+	field      string
+	start      string // Group start ID when the group is created ("0" = full history, "$" = only new)
+	checkpoint string // Optional JSONL file that keeps already acknowledged results across restarts
+
+	mu         sync.Mutex
+	conn       *redisConn
+	results    []EvalResult
+	primed     bool   // True once the checkpoint and pending entries were loaded
+	generation uint64 // Bumped when priming replaces results already served
+}
+
+// NewRedisStreamSource creates a source from a redis:// or rediss:// URL
+// Example: redis://:secret@localhost:6379/0?stream=evals&group=goevals&checkpoint=evals.ckpt.jsonl
+func NewRedisStreamSource(rawURL string) (*RedisStreamSource, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis URL: %w", err)
+	}
+
+	s := &RedisStreamSource{
+		addr:     u.Host,
+		tls:      u.Scheme == "rediss",
+		stream:   "evals",
+		group:    "goevals",
+		consumer: defaultConsumerName(),
+		field:    "data",
+		start:    "0",
+	}
+	if u.Port() == "" {
+		s.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.Hostname() == "" {
+		s.addr = net.JoinHostPort("localhost", "6379")
+	}
+	if u.User != nil {
+		s.username = u.User.Username()
+		s.password, _ = u.User.Password()
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if s.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("invalid redis database %q: %w", db, err)
+		}
+	}
+
+	q := u.Query()
+	for key, dst := range map[string]*string{
+		"stream":     &s.stream,
+		"group":      &s.group,
+		"consumer":   &s.consumer,
+		"field":      &s.field,
+		"start":      &s.start,
+		"checkpoint": &s.checkpoint,
+	} {
+		if v := q.Get(key); v != "" {
+			*dst = v
+		}
+	}
+
+	return s, nil
+}
+
+// defaultConsumerName identifies this dashboard inside the consumer group
+// It is the host name, the same across restarts, so a restarted dashboard re-reads the entries it
+// was delivered but never acknowledged instead of leaving them pending under a name no one uses
+func defaultConsumerName() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "goevals"
+	}
+	return host
+}
+
+func (s *RedisStreamSource) Name() string {
+	return fmt.Sprintf("redis://%s/%d?stream=%s&group=%s", s.addr, s.db, s.stream, s.group)
+}
+
+// Load drains all entries delivered to this consumer since the previous call
+// and returns every result seen so far (checkpoint + stream)
+func (s *RedisStreamSource) Load() ([]EvalResult, error) {
+	results, _, err := s.LoadGeneration()
+	return results, err
+}
+
+// LoadGeneration is Load with a generation that only changes when a retried priming replaced
+// results; otherwise entries are only ever added at the end
+func (s *RedisStreamSource) LoadGeneration() ([]EvalResult, uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.poll(); err != nil {
+		// Drop the connection so the next reload reconnects, but keep serving cached results
+		if s.conn != nil {
+			s.conn.Close()
+			s.conn = nil
+		}
+		return s.results, s.generation, err
+	}
+
+	return s.results, s.generation, nil
+}
+
+// poll connects if needed and reads new stream entries, must be called with s.mu held
+func (s *RedisStreamSource) poll() error {
+	if s.conn == nil {
+		conn, err := s.connect()
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+
+	if !s.primed {
+		if s.checkpoint != "" {
+			results, err := ParseJSONL(s.checkpoint)
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			if len(s.results) > 0 {
+				s.generation++
+			}
+			s.results = results
+		}
+		// Entries delivered to this consumer before a crash but never acknowledged
+		if err := s.drain("0"); err != nil {
+			return err
+		}
+		s.primed = true
+	}
+
+	return s.drain(">")
+}
+
+// connect dials Redis, authenticates and makes sure the consumer group exists
+func (s *RedisStreamSource) connect() (*redisConn, error) {
+	conn, err := dialRedis(s.addr, s.tls)
+	if err != nil {
+		return nil, err
+	}
+
+	if auth := s.authCommand(); auth != nil {
+		if _, err := conn.Do(auth...); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("redis auth: %w", err)
+		}
+	}
+	if s.db != 0 {
+		if _, err := conn.Do("SELECT", strconv.Itoa(s.db)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("redis select: %w", err)
+		}
+	}
+
+	_, err = conn.Do("XGROUP", "CREATE", s.stream, s.group, s.start, "MKSTREAM")
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		conn.Close()
+		return nil, fmt.Errorf("redis create group: %w", err)
+	}
+
+	log.Printf("Connected to %s as consumer %s", s.Name(), s.consumer)
+	return conn, nil
+}
+
+// authCommand is the AUTH command for the URL's credentials, nil without a password
+func (s *RedisStreamSource) authCommand() []string {
+	switch {
+	case s.password == "":
+		return nil
+	case s.username != "":
+		return []string{"AUTH", s.username, s.password}
+	}
+	return []string{"AUTH", s.password}
+}
+
+// drain reads entries in batches starting at id until the stream has nothing left for us
+// id "0" re-reads this consumer's pending entries, ">" reads never delivered entries
+func (s *RedisStreamSource) drain(id string) error {
+	const batchSize = 500
+
+	for {
+		reply, err := s.conn.Do("XREADGROUP", "GROUP", s.group, s.consumer,
+			"COUNT", strconv.Itoa(batchSize), "STREAMS", s.stream, id)
+		if err != nil {
+			return fmt.Errorf("redis xreadgroup: %w", err)
+		}
+
+		entries, err := parseStreamEntries(reply)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+
+		var parsed []EvalResult
+		ids := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			ids = append(ids, entry.id)
+
+			payload, ok := entry.fields[s.field]
+			if !ok {
+				log.Printf("Warning: Skipping stream entry %s without %q field", entry.id, s.field)
+				continue
+			}
+			var result EvalResult
+			if err := json.Unmarshal([]byte(payload), &result); err != nil {
+				log.Printf("Warning: Skipping invalid JSON in stream entry %s: %v", entry.id, err)
+				continue
+			}
+			parsed = append(parsed, result)
+		}
+
+		// Persist before acknowledging so an ack never loses results
+		if err := s.writeCheckpoint(parsed); err != nil {
+			return err
+		}
+		s.results = append(s.results, parsed...)
+
+		args := append([]string{"XACK", s.stream, s.group}, ids...)
+		if _, err := s.conn.Do(args...); err != nil {
+			return fmt.Errorf("redis xack: %w", err)
+		}
+
+		// Pending entries are re-read from the start of the PEL until it is empty
+		// New entries (">") simply continue with the next batch
+		if len(entries) < batchSize && id == ">" {
+			return nil
+		}
+	}
+}
+
+// writeCheckpoint appends acknowledged results to the checkpoint file
+func (s *RedisStreamSource) writeCheckpoint(results []EvalResult) error {
+	if s.checkpoint == "" || len(results) == 0 {
+		return nil
+	}
+
+	f, err := os.OpenFile(s.checkpoint, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open checkpoint: %w", err)
+	}
+	defer f.Close()
+
+	for _, result := range results {
+		line, err := encodeResult(result)
+		if err == nil {
+			_, err = f.Write(append(line, '\n'))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write checkpoint: %w", err)
+		}
+	}
+	return f.Sync()
+}
+
+// streamEntry is a single decoded Redis Stream entry
+type streamEntry struct {
+	id     string
+	fields map[string]string
+}
+
+// parseStreamEntries decodes an XREADGROUP reply for a single stream
+// Reply shape: [[stream, [[id, [field, value, ...]], ...]]] or nil when empty
+func parseStreamEntries(reply any) ([]streamEntry, error) {
+	if reply == nil {
+		return nil, nil
+	}
+	streams, ok := reply.([]any)
+	if !ok {
+		return nil, fmt.Errorf("unexpected XREADGROUP reply: %T", reply)
+	}
+
+	var entries []streamEntry
+	for _, st := range streams {
+		pair, ok := st.([]any)
+		if !ok || len(pair) != 2 {
+			return nil, fmt.Errorf("unexpected stream reply: %v", st)
+		}
+		items, _ := pair[1].([]any)
+		for _, item := range items {
+			raw, ok := item.([]any)
+			if !ok || len(raw) != 2 {
+				return nil, fmt.Errorf("unexpected stream entry: %v", item)
+			}
+			id, _ := raw[0].(string)
+			entry := streamEntry{id: id, fields: make(map[string]string)}
+			// Deleted entries still pending come back with a nil field list
+			kv, _ := raw[1].([]any)
+			for i := 0; i+1 < len(kv); i += 2 {
+				k, _ := kv[i].(string)
+				v, _ := kv[i+1].(string)
+				entry.fields[k] = v
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// redisConn is a minimal RESP2 client - just enough for streams, no pooling or pipelining
+type redisConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dialRedis(addr string, useTLS bool) (*redisConn, error) {
+	var conn net.Conn
+	var err error
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	if useTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, nil)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to redis at %s: %w", addr, err)
+	}
+	return &redisConn{conn: conn, r: bufio.NewReader(conn)}, nil
+}
+
+func (c *redisConn) Close() error {
+	return c.conn.Close()
+}
+
+// Do sends a command and returns the decoded reply
+// Replies map to: string (simple/bulk), int64, []any, nil; Redis errors are returned as error
+func (c *redisConn) Do(args ...string) (any, error) {
+	c.conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := c.conn.Write([]byte(b.String())); err != nil {
+		return nil, err
+	}
+
+	return readReply(c.r)
+}
+
+// redisError is an error reply sent by the server (e.g. "BUSYGROUP ...")
+type redisError string
+
+func (e redisError) Error() string { return string(e) }
+
+// readReply decodes a single RESP2 value
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("empty redis reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid bulk length %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid array length %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]any, n)
+		for i := range items {
+			if items[i], err = readReply(r); err != nil {
+				// Error replies nested in arrays are values, not transport failures
+				var re redisError
+				if !errors.As(err, &re) {
+					return nil, err
+				}
+				items[i] = re
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("unknown redis reply type %q", line[0])
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Replicator forwards results from the local sources to another goevals instance
+// Batches that fail on the network or with a 5xx, 429, 401 or 403 stay queued and are retried
+// with exponential backoff, a refused token until it is fixed on either side. A batch that is too
+// large (413) is sent in halves; a batch the target rejects with another 4xx is logged and dropped
+type Replicator struct {
+	target    string
+	token     string // Sent as a bearer token, see serve --ingest-token on the target
+	client    *http.Client
+	batchSize int
+
+	sent    map[string]bool // Result keys already queued or delivered
+	pending []EvalResult
+
+	backoff     time.Duration
+	nextAttempt time.Time
+}
+
+const (
+	replicateMinBackoff = time.Second
+	replicateMaxBackoff = 2 * time.Minute
+)
+
+// Errors of send for 4xx statuses
+var (
+	// errBatchRejected: the target refused the batch itself, sending it again would fail the same way
+	errBatchRejected = errors.New("batch rejected")
+	// errBatchTooLarge: 413, smaller batches can still go through
+	errBatchTooLarge = errors.New("batch too large")
+	// errTokenRefused: 401 or 403, the batch is fine but the token is wrong, e.g. rotated on the target
+	errTokenRefused = errors.New("token refused")
+)
+
+// NewReplicator creates a replicator that POSTs JSONL batches to target (e.g. https://central/api/ingest),
+// authenticated with token when it isn't empty
+func NewReplicator(target, token string) *Replicator {
+	return &Replicator{
+		target:    target,
+		token:     token,
+		client:    &http.Client{Timeout: 30 * time.Second},
+		batchSize: 500,
+		sent:      make(map[string]bool),
+	}
+}
+
+// Run watches the store and forwards new results until the process exits
+// Failed batches are retried on the same interval once their backoff has elapsed
+func (rp *Replicator) Run(s Store, interval time.Duration) {
+	log.Printf("Replicating results to %s", rp.target)
+	updates := s.Watch(context.Background(), interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case batch, ok := <-updates:
+			if !ok {
+				return
+			}
+			rp.Collect(batch)
+		case <-ticker.C:
+		}
+		if err := rp.Flush(time.Now()); errors.Is(err, errTokenRefused) {
+			log.Printf("Error: %s refused --replicate-token, keeping %d results queued until it is fixed, retrying in %s: %v", rp.target, len(rp.pending), rp.backoff, err)
+		} else if err != nil {
+			log.Printf("Warning: Replication to %s failed, retrying in %s: %v", rp.target, rp.backoff, err)
+		}
+	}
+}
+
+// Collect queues results that haven't been forwarded yet
+func (rp *Replicator) Collect(results []EvalResult) {
+	for _, result := range results {
+		key := resultKey(result)
+		if rp.sent[key] {
+			continue
+		}
+		rp.sent[key] = true
+		rp.pending = append(rp.pending, result)
+	}
+}
+
+// Flush sends queued results in batches, stopping at the first failure that can be retried
+// Does nothing while a previous failure is still backing off
+func (rp *Replicator) Flush(now time.Time) error {
+	if len(rp.pending) == 0 || now.Before(rp.nextAttempt) {
+		return nil
+	}
+
+	size := rp.batchSize
+	for len(rp.pending) > 0 {
+		n := min(size, len(rp.pending))
+		err := rp.send(rp.pending[:n])
+		if errors.Is(err, errBatchTooLarge) && n > 1 {
+			size = n / 2 // This flush goes on in halves
+			continue
+		}
+		if errors.Is(err, errBatchRejected) || errors.Is(err, errBatchTooLarge) {
+			log.Printf("Warning: %s rejected %d results, dropping them: %v", rp.target, n, err)
+		} else if err != nil {
+			if rp.backoff == 0 {
+				rp.backoff = replicateMinBackoff
+			} else {
+				rp.backoff = min(rp.backoff*2, replicateMaxBackoff)
+			}
+			rp.nextAttempt = now.Add(rp.backoff)
+			return err
+		}
+		rp.pending = rp.pending[n:]
+		rp.backoff = 0
+		rp.nextAttempt = time.Time{}
+	}
+	return nil
+}
+
+// send POSTs one batch as JSONL
+func (rp *Replicator) send(batch []EvalResult) error {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, result := range batch {
+		if err := enc.Encode(result); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, rp.target, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if rp.token != "" {
+		req.Header.Set("Authorization", "Bearer "+rp.token)
+	}
+	resp, err := rp.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		switch {
+		case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+			return fmt.Errorf("%w: %s: %s", errTokenRefused, resp.Status, bytes.TrimSpace(msg))
+		case resp.StatusCode == http.StatusRequestEntityTooLarge:
+			return fmt.Errorf("%w: %s: %s", errBatchTooLarge, resp.Status, bytes.TrimSpace(msg))
+		case resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests:
+			return fmt.Errorf("%w: %s: %s", errBatchRejected, resp.Status, bytes.TrimSpace(msg))
+		}
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
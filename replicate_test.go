@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReplicatorRetriesWithBackoff(t *testing.T) {
+	var received []string
+	fail := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			received = append(received, scanner.Text())
+		}
+	}))
+	defer srv.Close()
+
+	rp := NewReplicator(srv.URL, "")
+	results := []EvalResult{
+		{Timestamp: "2025-01-01T10:00:00Z", Model: "a", TestID: "q1"},
+		{Timestamp: "2025-01-01T10:01:00Z", Model: "b", TestID: "q1"},
+	}
+
+	now := time.Now()
+	rp.Collect(results)
+	if err := rp.Flush(now); err == nil {
+		t.Fatal("expected error from failing target")
+	}
+	if len(rp.pending) != 2 || rp.backoff != replicateMinBackoff {
+		t.Fatalf("pending=%d backoff=%s", len(rp.pending), rp.backoff)
+	}
+
+	// Still backing off, target is not contacted
+	fail = false
+	if err := rp.Flush(now.Add(500 * time.Millisecond)); err != nil || len(received) != 0 {
+		t.Fatalf("flush during backoff: err=%v received=%d", err, len(received))
+	}
+
+	// Collecting the same results again must not queue duplicates
+	rp.Collect(results)
+	if err := rp.Flush(now.Add(2 * time.Second)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(received) != 2 || len(rp.pending) != 0 || rp.backoff != 0 {
+		t.Errorf("received=%d pending=%d backoff=%s", len(received), len(rp.pending), rp.backoff)
+	}
+}
+
+func TestReplicatorDropsRejectedBatches(t *testing.T) {
+	var statuses []int
+	var authorization string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		status := statuses[0]
+		statuses = statuses[1:]
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	rp := NewReplicator(srv.URL, "secret")
+	rp.batchSize = 1
+	rp.Collect([]EvalResult{
+		{Timestamp: "2025-01-01T10:00:00Z", Model: "a", TestID: "q1"},
+		{Timestamp: "2025-01-01T10:01:00Z", Model: "b", TestID: "q1"},
+	})
+
+	// 429 is retried like a 5xx
+	statuses = []int{http.StatusTooManyRequests}
+	now := time.Now()
+	if err := rp.Flush(now); err == nil || len(rp.pending) != 2 || rp.backoff != replicateMinBackoff {
+		t.Fatalf("429: err=%v pending=%d backoff=%s", err, len(rp.pending), rp.backoff)
+	}
+	if authorization != "Bearer secret" {
+		t.Errorf("Authorization = %q", authorization)
+	}
+
+	// The rejected batch is dropped and the next one still sent
+	statuses = []int{http.StatusBadRequest, http.StatusOK}
+	if err := rp.Flush(now.Add(time.Second)); err != nil || len(rp.pending) != 0 || rp.backoff != 0 {
+		t.Errorf("400: err=%v pending=%d backoff=%s", err, len(rp.pending), rp.backoff)
+	}
+}
+
+func TestReplicatorKeepsBatchesOnRefusedToken(t *testing.T) {
+	status := http.StatusUnauthorized
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	// A token rotated on the target: the results wait with backoff instead of being dropped
+	rp := NewReplicator(srv.URL, "rotated")
+	rp.Collect([]EvalResult{{Timestamp: "2025-01-01T10:00:00Z", Model: "a", TestID: "q1"}})
+	now := time.Now()
+	for i, code := range []int{http.StatusUnauthorized, http.StatusForbidden} {
+		status = code
+		now = now.Add(5 * time.Minute)
+		if err := rp.Flush(now); !errors.Is(err, errTokenRefused) || len(rp.pending) != 1 || rp.backoff != replicateMinBackoff<<i {
+			t.Fatalf("%d: err=%v pending=%d backoff=%s", code, err, len(rp.pending), rp.backoff)
+		}
+	}
+
+	// Sent once the token is fixed
+	status = http.StatusOK
+	if err := rp.Flush(now.Add(5 * time.Minute)); err != nil || len(rp.pending) != 0 {
+		t.Errorf("after the fix: err=%v pending=%d", err, len(rp.pending))
+	}
+}
+
+func TestReplicatorSplitsLargeBatches(t *testing.T) {
+	var sizes []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := 0
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			n++
+		}
+		sizes = append(sizes, n)
+		if n > 2 {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+		}
+	}))
+	defer srv.Close()
+
+	rp := NewReplicator(srv.URL, "")
+	for i := range 5 {
+		rp.Collect([]EvalResult{{Timestamp: "2025-01-01T10:00:00Z", Model: "a", TestID: fmt.Sprintf("q%d", i)}})
+	}
+	if err := rp.Flush(time.Now()); err != nil || len(rp.pending) != 0 {
+		t.Fatalf("err=%v pending=%d", err, len(rp.pending))
+	}
+	// 5 refused, then halves of that until everything went through
+	if fmt.Sprint(sizes) != "[5 2 2 1]" {
+		t.Errorf("batch sizes = %v", sizes)
+	}
+}
@@ -0,0 +1,404 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ReportRegression is a test whose score dropped between the last two runs of a config
+type ReportRegression struct {
+	Config string  `json:"config"`
+	Model  string  `json:"model"`
+	TestID string  `json:"test_id"`
+	Run    string  `json:"run"` // The later run
+	Before float64 `json:"before"`
+	After  float64 `json:"after"`
+}
+
+// Report is the shareable summary exported by `goevals export --format pdf`
+type Report struct {
+	Title       string
+	Generated   time.Time
+	TotalTests  int
+	Models      int
+	AvgScore    float64
+	From, To    string // Earliest and latest timestamp
+	Entries     []LeaderboardEntry
+	Regressions []ReportRegression // Largest drop first
+	Failures    []EvalResult       // Lowest combined score first
+}
+
+const (
+	reportMaxRows       = 10   // Per regressions / failures section
+	regressionThreshold = 0.05 // Smaller drops are treated as noise
+)
+
+// BuildReport summarizes results for the exported report
+func BuildReport(results []EvalResult, title string) Report {
+	report := Report{
+		Title:      title,
+		Generated:  time.Now(),
+		TotalTests: len(results),
+		Entries:    BuildLeaderboard(results).Entries,
+	}
+	models := make(map[string]bool)
+	var scores []float64
+	for _, result := range results {
+		models[result.Model] = true
+		scores = append(scores, result.Scores.Combined)
+		if result.Timestamp != "" && (report.From == "" || result.Timestamp < report.From) {
+			report.From = result.Timestamp
+		}
+		if result.Timestamp > report.To {
+			report.To = result.Timestamp
+		}
+	}
+	report.Models = len(models)
+	report.AvgScore = mean(scores)
+	report.Regressions = findRegressions(results)
+
+	for _, result := range results {
+		if result.Scores.Combined < bandFairMin {
+			report.Failures = append(report.Failures, result)
+		}
+	}
+	sort.SliceStable(report.Failures, func(i, j int) bool {
+		return report.Failures[i].Scores.Combined < report.Failures[j].Scores.Combined
+	})
+	report.Failures = report.Failures[:min(len(report.Failures), reportMaxRows)]
+	return report
+}
+
+// findRegressions compares, per config and test, the last two runs (ordered by their latest
+// timestamp) and returns drops above regressionThreshold, largest first
+func findRegressions(results []EvalResult) []ReportRegression {
+	type runScore struct {
+		run    string
+		sum    float64
+		count  int
+		latest string
+	}
+	// config -> test_id -> run -> score
+	data := make(map[string]map[string]map[string]*runScore)
+	models := make(map[string]string)
+	for _, result := range results {
+		if result.TestID == "" {
+			continue
+		}
+		runID, source := runOf(result)
+		run := runID
+		if run == "" {
+			run = source
+		}
+		key := buildConfigKey(result)
+		models[key] = result.Model
+		if data[key] == nil {
+			data[key] = make(map[string]map[string]*runScore)
+		}
+		if data[key][result.TestID] == nil {
+			data[key][result.TestID] = make(map[string]*runScore)
+		}
+		s := data[key][result.TestID][run]
+		if s == nil {
+			s = &runScore{run: run}
+			data[key][result.TestID][run] = s
+		}
+		s.sum += result.Scores.Combined
+		s.count++
+		s.latest = max(s.latest, result.Timestamp)
+	}
+
+	var regressions []ReportRegression
+	for key, tests := range data {
+		for testID, byRun := range tests {
+			if len(byRun) < 2 {
+				continue
+			}
+			runs := make([]*runScore, 0, len(byRun))
+			for _, s := range byRun {
+				runs = append(runs, s)
+			}
+			sort.Slice(runs, func(i, j int) bool { return runs[i].latest < runs[j].latest })
+			prev, last := runs[len(runs)-2], runs[len(runs)-1]
+			before, after := prev.sum/float64(prev.count), last.sum/float64(last.count)
+			if before-after > regressionThreshold {
+				regressions = append(regressions, ReportRegression{
+					Config: key, Model: models[key], TestID: testID, Run: last.run, Before: before, After: after,
+				})
+			}
+		}
+	}
+	sort.Slice(regressions, func(i, j int) bool {
+		di, dj := regressions[i].Before-regressions[i].After, regressions[j].Before-regressions[j].After
+		if di != dj {
+			return di > dj
+		}
+		return regressions[i].Config+regressions[i].TestID < regressions[j].Config+regressions[j].TestID
+	})
+	return regressions[:min(len(regressions), reportMaxRows)]
+}
+
+// reportColumn is a table column in the PDF report
+type reportColumn struct {
+	Title string
+	Width float64
+	Right bool // Right aligned, for numbers
+}
+
+// reportLayout places report content top to bottom, starting new pages as needed
+type reportLayout struct {
+	doc *pdfDocument
+	y   float64
+}
+
+// ensure starts a new page unless h more points fit above the footer
+func (l *reportLayout) ensure(h float64) {
+	if l.doc.page == nil || l.y+h > pdfPageHeight-pdfMargin-16 {
+		l.doc.AddPage()
+		l.y = pdfMargin
+	}
+}
+
+func (l *reportLayout) heading(text string) {
+	l.ensure(60)
+	l.y += 28
+	l.doc.Text(pdfMargin, l.y, 14, true, pdfBlack, text)
+	l.y += 12
+}
+
+func (l *reportLayout) note(text string) {
+	l.ensure(16)
+	l.y += 12
+	l.doc.Text(pdfMargin, l.y, 9, false, pdfGray, text)
+}
+
+// table draws a header row and rows, repeating the header on each new page;
+// color picks the text color of a cell
+func (l *reportLayout) table(columns []reportColumn, rows [][]string, color func(row, col int) pdfColor) {
+	const rowH = 18
+	header := func() {
+		l.doc.Rect(pdfMargin, l.y, pdfPageWidth-2*pdfMargin, rowH, pdfLight)
+		x := pdfMargin
+		for _, col := range columns {
+			title := strings.ToUpper(col.Title)
+			if col.Right {
+				l.doc.TextRight(x+col.Width-6, l.y+12, 7.5, true, pdfGray, title)
+			} else {
+				l.doc.Text(x+6, l.y+12, 7.5, true, pdfGray, title)
+			}
+			x += col.Width
+		}
+		l.y += rowH
+	}
+	l.ensure(2 * rowH)
+	header()
+	for i, row := range rows {
+		if l.y+rowH > pdfPageHeight-pdfMargin-16 {
+			l.ensure(2 * rowH)
+			header()
+		}
+		x := pdfMargin
+		for j, col := range columns {
+			text := pdfTruncate(row[j], col.Width-12, 9, false)
+			if col.Right {
+				l.doc.TextRight(x+col.Width-6, l.y+12.5, 9, false, color(i, j), text)
+			} else {
+				l.doc.Text(x+6, l.y+12.5, 9, false, color(i, j), text)
+			}
+			x += col.Width
+		}
+		l.y += rowH
+		l.doc.Line(pdfMargin, l.y, pdfPageWidth-pdfMargin, l.y, pdfBorder)
+	}
+}
+
+// pdfScoreColor matches the dashboard's good / fair / poor badges
+func pdfScoreColor(score float64) pdfColor {
+	switch {
+	case score >= 0.7:
+		return pdfGood
+	case score >= 0.4:
+		return pdfFair
+	default:
+		return pdfPoor
+	}
+}
+
+// writeReportPDF renders the report: summary stats, the config comparison, regressions and failures
+func writeReportPDF(w io.Writer, report Report) error {
+	doc := newPDFDocument(report.Title)
+	l := &reportLayout{doc: doc}
+	l.ensure(0)
+
+	l.y += 20
+	doc.Text(pdfMargin, l.y, 22, true, pdfBlack, report.Title)
+	l.y += 18
+	subtitle := "Generated " + report.Generated.Format("January 2, 2006 15:04 MST")
+	if report.From != "" {
+		subtitle += fmt.Sprintf(" · Results from %s to %s", report.From, report.To)
+	}
+	doc.Text(pdfMargin, l.y, 9, false, pdfGray, subtitle)
+	l.y += 2
+	doc.Line(pdfMargin, l.y+8, pdfPageWidth-pdfMargin, l.y+8, pdfAccent)
+
+	// Stat cards
+	l.y += 22
+	cards := []struct {
+		label, value string
+		color        pdfColor
+	}{
+		{"Results", fmt.Sprint(report.TotalTests), pdfBlack},
+		{"Models", fmt.Sprint(report.Models), pdfBlack},
+		{"Configs", fmt.Sprint(len(report.Entries)), pdfBlack},
+		{"Average score", fmt.Sprintf("%.3f", report.AvgScore), pdfScoreColor(report.AvgScore)},
+	}
+	cardW := (pdfPageWidth - 2*pdfMargin - 3*10) / 4
+	for i, card := range cards {
+		x := pdfMargin + float64(i)*(cardW+10)
+		doc.Rect(x, l.y, cardW, 52, pdfLight)
+		doc.Text(x+10, l.y+18, 8, true, pdfGray, strings.ToUpper(card.label))
+		doc.Text(x+10, l.y+40, 18, true, card.color, card.value)
+	}
+	l.y += 52
+
+	l.heading("Model Comparison")
+	rows := make([][]string, len(report.Entries))
+	for i, e := range report.Entries {
+		rows[i] = []string{fmt.Sprint(e.Rank), e.Config, fmt.Sprint(e.TestCount), fmt.Sprintf("%.3f", e.AvgScore),
+			fmt.Sprintf("%.3f – %.3f", e.CILow, e.CIHigh), fmt.Sprintf("%.0f%%", e.WinRate*100)}
+	}
+	l.table([]reportColumn{{"#", 28, true}, {"Config", 215, false}, {"Tests", 45, true}, {"Avg", 50, true}, {"95% CI", 95, true}, {"Win rate", 66, true}},
+		rows, func(row, col int) pdfColor {
+			if col == 3 {
+				return pdfScoreColor(report.Entries[row].AvgScore)
+			}
+			return pdfBlack
+		})
+	if len(report.Entries) == 0 {
+		l.note("No results.")
+	}
+
+	l.heading("Top Regressions")
+	if len(report.Regressions) == 0 {
+		l.note("No test dropped more than 0.05 between the last two runs of a config.")
+	} else {
+		rows = make([][]string, len(report.Regressions))
+		for i, reg := range report.Regressions {
+			rows[i] = []string{reg.TestID, reg.Config, reg.Run, fmt.Sprintf("%.3f", reg.Before), fmt.Sprintf("%.3f", reg.After), fmt.Sprintf("%+.3f", reg.After-reg.Before)}
+		}
+		l.table([]reportColumn{{"Test", 80, false}, {"Config", 170, false}, {"Run", 89, false}, {"Before", 50, true}, {"After", 50, true}, {"Change", 60, true}},
+			rows, func(row, col int) pdfColor {
+				if col == 5 {
+					return pdfPoor
+				}
+				return pdfBlack
+			})
+		l.note("Each config's latest run compared with the run before it, per test.")
+	}
+
+	l.heading("Lowest-Scoring Results")
+	if len(report.Failures) == 0 {
+		l.note(fmt.Sprintf("No result scored below %.1f.", bandFairMin))
+	} else {
+		rows = make([][]string, len(report.Failures))
+		for i, result := range report.Failures {
+			rows[i] = []string{result.TestID, result.Model, fmt.Sprintf("%.3f", result.Scores.Combined), result.Question}
+		}
+		l.table([]reportColumn{{"Test", 80, false}, {"Model", 110, false}, {"Score", 50, true}, {"Question", 259, false}},
+			rows, func(row, col int) pdfColor {
+				if col == 2 {
+					return pdfScoreColor(report.Failures[row].Scores.Combined)
+				}
+				return pdfBlack
+			})
+	}
+
+	_, err := doc.WriteTo(w)
+	return err
+}
+
+// exportHandler serves the report as a download
+// GET /api/export?format=pdf&title=...&weights=...
+func exportHandler(w http.ResponseWriter, r *http.Request) {
+	if format := r.URL.Query().Get("format"); format != "" && format != "pdf" {
+		http.Error(w, "format must be pdf", http.StatusBadRequest)
+		return
+	}
+	results, err := store.Query(r.Context(), Query{})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	results, ok := reweightRequest(w, r, results)
+	if !ok {
+		return
+	}
+
+	title := r.URL.Query().Get("title")
+	if title == "" {
+		title = "Evaluation Report"
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `attachment; filename="goevals-report.pdf"`)
+	if err := writeReportPDF(w, BuildReport(results, title)); err != nil {
+		log.Printf("Error writing report: %v", err)
+	}
+}
+
+// runExport implements `goevals export`, writing a report without starting the server
+func runExport(args []string) int {
+	fs := newFlagSet("export", "[flags] <source1> [source2] [...]",
+		"Writes a PDF report with summary stats, the model comparison, top regressions and failures,",
+		"or every result as a Parquet table (one column per field and score) for pandas, DuckDB and co.")
+	format := fs.String("format", "pdf", "output `format`: pdf or parquet")
+	output := fs.String("o", "", "write to `file`, - for stdout (default goevals-report.pdf or goevals-results.parquet)")
+	title := fs.String("title", "Evaluation Report", "report `title`")
+	weights := weightsFlag(fs)
+	sources := parseFlags(fs, args)
+	if len(sources) == 0 {
+		fs.Usage()
+		return 2
+	}
+	if *format != "pdf" && *format != "parquet" {
+		fmt.Fprintln(os.Stderr, "Error: --format must be pdf or parquet")
+		return 2
+	}
+	if *output == "" {
+		*output = map[string]string{"pdf": "goevals-report.pdf", "parquet": "goevals-results.parquet"}[*format]
+	}
+	results, err := commandResults(sources, *weights)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	w := io.Writer(os.Stdout)
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		defer f.Close()
+		w = f
+	}
+	if *format == "parquet" {
+		err = writeParquet(w, resultsTable(results), len(results))
+	} else {
+		err = writeReportPDF(w, BuildReport(results, *title))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if *output != "-" {
+		fmt.Fprintf(os.Stderr, "Wrote %s (%d results)\n", *output, len(results))
+	}
+	return 0
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBuildReport(t *testing.T) {
+	result := func(ts, run, test string, score float64) EvalResult {
+		return EvalResult{Timestamp: ts, Model: "m", TestID: test, Metadata: map[string]any{"run_id": run}, Scores: ScoreBreakdown{Combined: score}}
+	}
+	results := []EvalResult{
+		result("2025-01-01T00:00:00Z", "r1", "t1", 0.9),
+		result("2025-01-02T00:00:00Z", "r2", "t1", 0.5),
+		result("2025-01-03T00:00:00Z", "r3", "t1", 0.2), // Only r2 -> r3 counts
+		result("2025-01-01T00:00:00Z", "r1", "t2", 0.8),
+		result("2025-01-03T00:00:00Z", "r3", "t2", 0.78), // Within the noise threshold
+		result("2025-01-01T00:00:00Z", "r1", "t3", 0.1),
+		result("2025-01-03T00:00:00Z", "r3", "t3", 0.4),
+	}
+
+	report := BuildReport(results, "Weekly")
+	if report.TotalTests != 7 || report.Models != 1 || report.From != "2025-01-01T00:00:00Z" || report.To != "2025-01-03T00:00:00Z" {
+		t.Errorf("summary = %+v", report)
+	}
+	if len(report.Regressions) != 1 || report.Regressions[0].TestID != "t1" || report.Regressions[0].Run != "r3" ||
+		report.Regressions[0].Before != 0.5 || report.Regressions[0].After != 0.2 {
+		t.Errorf("regressions = %+v", report.Regressions)
+	}
+	if len(report.Failures) != 3 || report.Failures[0].Scores.Combined != 0.1 {
+		t.Errorf("failures = %+v", report.Failures)
+	}
+}
+
+func TestWriteReportPDF(t *testing.T) {
+	var results []EvalResult
+	for i := 0; i < 80; i++ { // Enough configs to spill onto a second page
+		results = append(results, EvalResult{Model: fmt.Sprintf("model-%02d", i), TestID: "t1", Question: "Why (and how)?", Scores: ScoreBreakdown{Combined: float64(i) / 100}})
+	}
+	var buf bytes.Buffer
+	if err := writeReportPDF(&buf, BuildReport(results, "Report – Q1")); err != nil {
+		t.Fatal(err)
+	}
+	pdf := buf.Bytes()
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatal("not a PDF")
+	}
+
+	// Every xref entry must point at its object
+	xref := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(pdf)
+	start, _ := strconv.Atoi(string(xref[1]))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(pdf[start:], -1)
+	if len(entries) < 7 {
+		t.Fatalf("%d xref entries", len(entries))
+	}
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		if want := fmt.Sprintf("%d 0 obj", i+1); !bytes.HasPrefix(pdf[offset:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q", i+1, pdf[offset:offset+10])
+		}
+	}
+	// Stream lengths must match
+	for _, m := range regexp.MustCompile(`(?s)/Length (\d+) >>\nstream\n(.*?)endstream`).FindAllSubmatch(pdf, -1) {
+		if n, _ := strconv.Atoi(string(m[1])); n != len(m[2]) {
+			t.Errorf("stream /Length %d, actual %d", n, len(m[2]))
+		}
+	}
+
+	for _, want := range []string{"(Model Comparison)", `(Why \(and how\)?)`, `(Report \226 Q1)`, "(Page 3 of 3)"} {
+		if !bytes.Contains(pdf, []byte(want)) {
+			t.Errorf("PDF missing %s", want)
+		}
+	}
+}
+
+func TestPDFText(t *testing.T) {
+	if got := pdfEscape("é ✓ (x)\n"); got != `\351 ? \(x\) ` {
+		t.Errorf("pdfEscape = %q", got)
+	}
+	if got := pdfTruncate(strings.Repeat("w", 100), 50, 10, false); pdfTextWidth(got, 10, false) > 50 || !strings.HasSuffix(got, "…") {
+		t.Errorf("pdfTruncate = %q", got)
+	}
+	if got := pdfTruncate("short", 50, 10, false); got != "short" {
+		t.Errorf("pdfTruncate shortened %q", got)
+	}
+}
+
+func TestExportHandler(t *testing.T) {
+	useStore(t, NewMemoryStore([]Source{&fileSource{path: "evals.jsonl"}}))
+
+	rec := httptest.NewRecorder()
+	exportHandler(rec, httptest.NewRequest(http.MethodGet, "/api/export?format=pdf&title=Weekly", nil))
+	if rec.Header().Get("Content-Type") != "application/pdf" || !strings.HasPrefix(rec.Body.String(), "%PDF") || !strings.Contains(rec.Body.String(), "(Weekly)") {
+		t.Errorf("export: %d %s", rec.Code, rec.Header())
+	}
+
+	rec = httptest.NewRecorder()
+	exportHandler(rec, httptest.NewRequest(http.MethodGet, "/api/export?format=docx", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown format: status %d", rec.Code)
+	}
+}
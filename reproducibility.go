@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+)
+
+// Reproducibility is the run-to-run noise of one config: how much the score of the same test moves
+// between runs with identical settings
+type Reproducibility struct {
+	Config    string   `json:"config"`
+	Model     string   `json:"model"`
+	Runs      []string `json:"runs"`
+	Tests     int      `json:"tests"`          // Tests scored in at least two runs
+	MeanDelta float64  `json:"mean_abs_delta"` // Mean absolute per-test score difference between runs
+	MaxDelta  float64  `json:"max_abs_delta"`
+	Noisiest  string   `json:"noisiest_test"` // Test with the largest delta
+}
+
+// BuildReproducibility compares every config that ran more than once, noisiest first
+//
+// A test's delta is the mean absolute difference of its score over every pair of runs that scored
+// it (repeats within a run are averaged first); a config's MeanDelta is the mean over its tests.
+// Results without a test_id can't be matched across runs and are ignored.
+func BuildReproducibility(results []EvalResult) []Reproducibility {
+	type bucket struct {
+		sum   float64
+		count int
+	}
+	// config -> test_id -> run -> bucket
+	data := make(map[string]map[string]map[string]*bucket)
+	models := make(map[string]string)
+	for _, result := range scored(results) {
+		if result.TestID == "" {
+			continue
+		}
+		runID, source := runOf(result)
+		run := runID
+		if run == "" {
+			run = source
+		}
+		key := buildConfigKey(result)
+		if data[key] == nil {
+			data[key] = make(map[string]map[string]*bucket)
+			models[key] = result.Model
+		}
+		if data[key][result.TestID] == nil {
+			data[key][result.TestID] = make(map[string]*bucket)
+		}
+		b := data[key][result.TestID][run]
+		if b == nil {
+			b = &bucket{}
+			data[key][result.TestID][run] = b
+		}
+		b.sum += result.Scores.Combined
+		b.count++
+	}
+
+	var report []Reproducibility
+	for key, tests := range data {
+		repro := Reproducibility{Config: key, Model: models[key]}
+		runs := make(map[string]bool)
+		var deltas []float64
+		for testID, byRun := range tests {
+			if len(byRun) < 2 {
+				continue
+			}
+			scores := make([]float64, 0, len(byRun))
+			for run, b := range byRun {
+				runs[run] = true
+				scores = append(scores, b.sum/float64(b.count))
+			}
+			var total float64
+			pairs := 0
+			for i := range scores {
+				for j := i + 1; j < len(scores); j++ {
+					total += math.Abs(scores[i] - scores[j])
+					pairs++
+				}
+			}
+			delta := total / float64(pairs)
+			deltas = append(deltas, delta)
+			if delta > repro.MaxDelta || (delta == repro.MaxDelta && (repro.Noisiest == "" || testID < repro.Noisiest)) {
+				repro.MaxDelta, repro.Noisiest = delta, testID
+			}
+		}
+		if len(deltas) == 0 {
+			continue // Ran once, nothing to compare
+		}
+		repro.Runs = sortedKeys(runs, "")
+		repro.Tests = len(deltas)
+		repro.MeanDelta = mean(deltas)
+		report = append(report, repro)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].MeanDelta != report[j].MeanDelta {
+			return report[i].MeanDelta > report[j].MeanDelta
+		}
+		return report[i].Config < report[j].Config
+	})
+	return report
+}
+
+// reproducibilityAPIHandler returns the run-to-run noise of every repeated config as JSON
+func reproducibilityAPIHandler(w http.ResponseWriter, r *http.Request) {
+	results, err := store.Query(r.Context(), Query{})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	results, ok := reweightRequest(w, r, results)
+	if !ok {
+		return
+	}
+
+	report := BuildReproducibility(results)
+	if report == nil {
+		report = []Reproducibility{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("Error encoding JSON: %v", err)
+	}
+}
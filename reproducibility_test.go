@@ -0,0 +1,57 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBuildReproducibility(t *testing.T) {
+	result := func(model, run, test string, score float64) EvalResult {
+		return EvalResult{Model: model, TestID: test, Metadata: map[string]any{"run_id": run}, Scores: ScoreBreakdown{Combined: score}}
+	}
+	results := []EvalResult{
+		result("a", "r1", "t1", 0.5),
+		result("a", "r2", "t1", 0.7),
+		result("a", "r3", "t1", 0.6), // Pairs: 0.2, 0.1, 0.1
+		result("a", "r1", "t2", 0.9),
+		result("a", "r2", "t2", 0.8),
+		result("a", "r2", "t2", 1.0), // Averaged within r2 to 0.9
+		result("a", "r1", "t3", 0.4), // Only one run
+		result("b", "r1", "t1", 0.3),
+		result("b", "r2", "t1", 0.9),
+		result("c", "r1", "t1", 1), // Ran once
+	}
+
+	report := BuildReproducibility(results)
+	if len(report) != 2 || report[0].Model != "b" || report[1].Model != "a" {
+		t.Fatalf("report = %+v", report)
+	}
+	a := report[1]
+	want := (0.4 / 3) / 2 // t1 and t2 (delta 0)
+	if a.Tests != 2 || math.Abs(a.MeanDelta-want) > 1e-9 || a.Noisiest != "t1" || strings.Join(a.Runs, ",") != "r1,r2,r3" {
+		t.Errorf("config a = %+v, want mean delta %.4f", a, want)
+	}
+	if b := report[0]; math.Abs(b.MeanDelta-0.6) > 1e-9 || b.MaxDelta != b.MeanDelta {
+		t.Errorf("config b = %+v", b)
+	}
+
+	// Different settings are different configs, not noise
+	results = []EvalResult{result("a", "r1", "t1", 0.5), result("a", "r2", "t1", 0.9)}
+	results[1].CustomFields = map[string]any{"temperature": 0.9}
+	if report := BuildReproducibility(results); len(report) != 0 {
+		t.Errorf("configs with different settings compared: %+v", report)
+	}
+}
+
+func TestReproducibilityAPI(t *testing.T) {
+	useStore(t, NewMemoryStore([]Source{&fileSource{path: "evals.jsonl"}}))
+
+	rec := httptest.NewRecorder()
+	reproducibilityAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/api/reproducibility", nil))
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Errorf("single-run data: %d %s", rec.Code, rec.Body.String())
+	}
+}
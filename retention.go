@@ -0,0 +1,223 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Retention is how much history stays in the hot sources, older results are archived
+type Retention struct {
+	MaxAge     time.Duration // Results older than this expire, 0 = no age limit
+	MaxResults int           // Only the newest this many results stay, 0 = no limit
+}
+
+// String describes the policy for log messages, e.g. "results older than 30d"
+func (k Retention) String() string {
+	var limits []string
+	if k.MaxAge > 0 {
+		limits = append(limits, "results older than "+formatAge(k.MaxAge))
+	}
+	if k.MaxResults > 0 {
+		limits = append(limits, fmt.Sprintf("all but the newest %d results", k.MaxResults))
+	}
+	if len(limits) == 0 {
+		return "nothing"
+	}
+	return strings.Join(limits, " and ")
+}
+
+// parseAge reads a maximum age: a Go duration (36h), days (30d), or a plain number of days
+func parseAge(s string) (time.Duration, error) {
+	days, ok := strings.CutSuffix(s, "d")
+	if !ok && strings.ContainsAny(s, "hms") {
+		return time.ParseDuration(s)
+	}
+	n, err := strconv.ParseFloat(days, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid age %q, use days (30d) or a duration (36h)", s)
+	}
+	return time.Duration(n * float64(24*time.Hour)), nil
+}
+
+// formatAge is the inverse of parseAge, in days when the age is whole days
+func formatAge(d time.Duration) string {
+	if d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+	return d.String()
+}
+
+// Expired returns the keys (see resultKey) of the results in the removable sources that fall
+// outside the retention policy at now, along with what each source loaded (nil for the others)
+// Results without a readable timestamp never expire and don't count towards MaxResults
+func (a *Archive) Expired(sources []Source, now time.Time) (map[string]bool, [][]EvalResult, error) {
+	loaded := make([][]EvalResult, len(sources))
+	type dated struct {
+		key string
+		ts  time.Time
+	}
+	var all []dated
+	for i, source := range sources {
+		if _, ok := source.(Remover); !ok {
+			continue
+		}
+		results, err := source.Load()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load %s: %w", source.Name(), err)
+		}
+		loaded[i] = results
+		for _, result := range results {
+			if ts, ok := parseTimestamp(result.Timestamp); ok {
+				all = append(all, dated{resultKey(result), ts})
+			}
+		}
+	}
+
+	expired := make(map[string]bool)
+	if a.keep.MaxAge > 0 {
+		cutoff := now.Add(-a.keep.MaxAge)
+		for _, d := range all {
+			if d.ts.Before(cutoff) {
+				expired[d.key] = true
+			}
+		}
+	}
+	if a.keep.MaxResults > 0 && len(all) > a.keep.MaxResults {
+		// Newest first; ties broken by key so every run picks the same results
+		slices.SortFunc(all, func(x, y dated) int {
+			if c := y.ts.Compare(x.ts); c != 0 {
+				return c
+			}
+			return cmp.Compare(x.key, y.key)
+		})
+		for _, d := range all[a.keep.MaxResults:] {
+			expired[d.key] = true
+		}
+	}
+	return expired, loaded, nil
+}
+
+// dirStore keeps archive objects as files under a local directory, keys are relative paths
+type dirStore struct {
+	dir string
+}
+
+func (d dirStore) PutObject(_, key string, body []byte, _ string) error {
+	p := filepath.Join(d.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, body, 0o644); err != nil {
+		return err
+	}
+	return replaceFile(tmp, p)
+}
+
+func (d dirStore) GetObject(_, key string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(d.dir, filepath.FromSlash(key)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%s: %w", key, errObjectNotFound)
+	}
+	return data, err
+}
+
+func (d dirStore) ListObjects(_, prefix string) ([]s3Object, error) {
+	var objects []s3Object
+	err := filepath.WalkDir(d.dir, func(p string, entry fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && p == d.dir {
+			return fs.SkipAll // Nothing archived yet
+		}
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(d.dir, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) || path.Ext(key) == ".tmp" {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, s3Object{Key: key, Size: info.Size()})
+		return nil
+	})
+	return objects, err
+}
+
+// runArchive implements `goevals archive`, one archiving pass over the sources
+func runArchive(args []string) int {
+	fs := newFlagSet("archive", "--to <dir|url> [flags] <source1> [source2] [...]",
+		"Moves results outside the retention policy out of JSONL files and Postgres into gzipped",
+		"JSONL archives, one per day, in a directory or an s3:// or gs:// bucket. The dashboard",
+		"can load them back with --archive-to")
+	to := fs.String("to", "", "archive `dir` or s3://bucket/prefix or gs://bucket/prefix URL")
+	maxAge := fs.String("max-age", "30d", "archive results older than this `age`, e.g. 30d or 36h, 0 = no age limit")
+	maxResults := fs.Int("max-results", 0, "keep only the newest `n` results, 0 = no limit")
+	dryRun := fs.Bool("dry-run", false, "count what would be archived without moving it")
+	sources := parseFlags(fs, args)
+	if len(sources) == 0 || *to == "" {
+		fs.Usage()
+		return 2
+	}
+	age, err := parseAge(*maxAge)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --max-age: %v\n", err)
+		return 2
+	}
+	keep := Retention{MaxAge: age, MaxResults: *maxResults}
+	if keep.MaxAge <= 0 && keep.MaxResults <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: set --max-age or --max-results")
+		return 2
+	}
+	a, err := NewArchive(*to, keep)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	var hot []Source
+	for _, arg := range sources {
+		source, err := NewSource(arg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		if _, ok := source.(Remover); !ok {
+			fmt.Fprintf(os.Stderr, "Warning: results can't be removed from %s, skipping it\n", source.Name())
+		}
+		hot = append(hot, source)
+	}
+
+	if *dryRun {
+		expired, _, err := a.Expired(hot, time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Would archive %d results (%s) to %s\n", len(expired), keep, a.location)
+		return 0
+	}
+	n, err := a.ArchiveOnce(hot, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if n > 0 {
+			fmt.Printf("Archived %d results to %s before the error\n", n, a.location)
+		}
+		return 1
+	}
+	fmt.Printf("Archived %d results (%s) to %s\n", n, keep, a.location)
+	return 0
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"30d", 30 * 24 * time.Hour},
+		{"7", 7 * 24 * time.Hour},
+		{"1.5d", 36 * time.Hour},
+		{"36h", 36 * time.Hour},
+		{"0", 0},
+	}
+	for _, tt := range tests {
+		if got, err := parseAge(tt.in); err != nil || got != tt.want {
+			t.Errorf("parseAge(%q) = %s, %v, want %s", tt.in, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "thirty", "30x"} {
+		if _, err := parseAge(bad); err == nil {
+			t.Errorf("parseAge(%q) accepted", bad)
+		}
+	}
+	if got := (Retention{MaxAge: 30 * 24 * time.Hour, MaxResults: 100}).String(); got != "results older than 30d and all but the newest 100 results" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestArchiveRetentionToDirectory(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "evals.jsonl")
+	lines := `{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"t1","scores":{"combined":0.5}}
+{"timestamp":"2025-03-01T10:00:00Z","model":"a","test_id":"t2","scores":{"combined":0.6}}
+{"timestamp":"2025-03-02T10:00:00Z","model":"a","test_id":"t3","scores":{"combined":0.7}}
+{"timestamp":"2025-03-03T10:00:00Z","model":"a","test_id":"t4","scores":{"combined":0.8}}
+{"model":"a","test_id":"undated","scores":{"combined":0.9}}
+`
+	os.WriteFile(path, []byte(lines), 0o644)
+	hot := &fileSource{path: path}
+	now := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	a, err := NewArchive(filepath.Join(dir, "archive"), Retention{MaxAge: 30 * 24 * time.Hour, MaxResults: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Nothing archived yet, listing an archive directory that doesn't exist is fine
+	if objects, err := a.Objects("", ""); err != nil || len(objects) != 0 {
+		t.Fatalf("Objects = %+v, %v", objects, err)
+	}
+
+	// t1 is too old, t2 is beyond the newest 2; the undated result always stays
+	expired, _, err := a.Expired([]Source{hot}, now)
+	if err != nil || len(expired) != 2 {
+		t.Fatalf("Expired = %v, %v", expired, err)
+	}
+	if n, err := a.ArchiveOnce([]Source{hot}, now); err != nil || n != 2 {
+		t.Fatalf("ArchiveOnce = %d, %v", n, err)
+	}
+	data, _ := os.ReadFile(path)
+	for _, id := range []string{`"t1"`, `"t2"`} {
+		if strings.Contains(string(data), id) {
+			t.Errorf("%s still in the hot file:\n%s", id, data)
+		}
+	}
+	if !strings.Contains(string(data), `"t3"`) || !strings.Contains(string(data), `"undated"`) {
+		t.Errorf("kept results removed:\n%s", data)
+	}
+
+	objects, err := a.Objects("", "")
+	if err != nil || len(objects) != 2 || objects[0].Day != "2025-01-01" || objects[1].Day != "2025-03-01" {
+		t.Fatalf("Objects = %+v, %v", objects, err)
+	}
+	if err := a.verifyObject(objects[0].Key); err != nil {
+		t.Errorf("verifyObject = %v", err)
+	}
+	if objs, results, err := a.Rehydrate("", ""); err != nil || objs != 2 || results != 2 {
+		t.Fatalf("Rehydrate = %d objects, %d results, %v", objs, results, err)
+	}
+}
+
+func TestRunArchive(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "evals.jsonl")
+	lines := `{"timestamp":"2020-01-01T10:00:00Z","model":"a","test_id":"t1","scores":{"combined":0.5}}
+{"timestamp":"2099-01-01T10:00:00Z","model":"a","test_id":"t2","scores":{"combined":0.6}}
+`
+	os.WriteFile(path, []byte(lines), 0o644)
+	to := filepath.Join(dir, "archive")
+
+	if code := runArchive([]string{"--to", to, "--max-age", "0", path}); code != 2 {
+		t.Errorf("no retention limit: exit %d, want 2", code)
+	}
+	if code := runArchive([]string{"--to", to, "--dry-run", path}); code != 0 {
+		t.Fatalf("dry run: exit %d", code)
+	}
+	if data, _ := os.ReadFile(path); string(data) != lines {
+		t.Fatal("dry run changed the source")
+	}
+	if code := runArchive([]string{"--to", to, "--max-age", "90d", path}); code != 0 {
+		t.Fatalf("archive: exit %d", code)
+	}
+	if data, _ := os.ReadFile(path); strings.Contains(string(data), `"t1"`) || !strings.Contains(string(data), `"t2"`) {
+		t.Errorf("source after archiving:\n%s", data)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(to, "2020-01-01", "*.jsonl.gz")); len(matches) != 1 {
+		t.Errorf("archive objects = %v", matches)
+	}
+}
@@ -0,0 +1,384 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"time"
+)
+
+// RunStat is the execution summary of one run: its time span, throughput and model time
+type RunStat struct {
+	RunID       string             `json:"run_id,omitempty"`
+	Source      string             `json:"source,omitempty"` // Set instead of RunID when the results carry no run_id
+	Models      []string           `json:"models"`
+	Configs     int                `json:"configs"`
+	Count       int                `json:"count"`
+	Start       string             `json:"start,omitempty"`
+	End         string             `json:"end,omitempty"`
+	DurationS   float64            `json:"duration_s"`    // Wall clock, last timestamp - first timestamp
+	EvalsPerMin float64            `json:"evals_per_min"` // 0 when the run has a single timestamp
+	ModelTimeMS int64              `json:"model_time_ms"` // Sum of response_time_ms
+	AvgLatency  float64            `json:"avg_latency_ms"`
+	AvgScore    float64            `json:"avg_score"`    // Over the results without an error, see scored
+	ModelScores map[string]float64 `json:"model_scores"` // Average score per model, of models with a scored result
+	Untimed     int                `json:"untimed"`      // Results with a missing or unparseable timestamp
+
+	URL string `json:"-"` // Dashboard filtered to this run
+}
+
+// runOf identifies the run of a result: its metadata.run_id, or the source it was loaded from when it has none
+func runOf(result EvalResult) (runID, source string) {
+	if runID, _ := result.Metadata["run_id"].(string); runID != "" {
+		return runID, ""
+	}
+	return "", result.Origin
+}
+
+// BuildRunStats groups results by metadata.run_id, falling back to the source file for results
+// without one, most recent run first
+func BuildRunStats(results []EvalResult) []RunStat {
+	type acc struct {
+		stat        RunStat
+		first, last time.Time
+		sum         float64
+		scored      int
+		timed       int
+		models      map[string]bool
+		modelSums   map[string]float64
+		modelCounts map[string]int
+		configs     map[string]bool
+	}
+	byRun := make(map[string]*acc)
+	for _, result := range results {
+		runID, source := runOf(result)
+		key := runID + "\x00" + source
+		a := byRun[key]
+		if a == nil {
+			a = &acc{
+				stat:        RunStat{RunID: runID, Source: source},
+				models:      make(map[string]bool),
+				modelSums:   make(map[string]float64),
+				modelCounts: make(map[string]int),
+				configs:     make(map[string]bool),
+			}
+			byRun[key] = a
+		}
+		a.stat.Count++
+		a.stat.ModelTimeMS += max(0, result.ResponseTimeMS)
+		if result.ResponseTimeMS > 0 {
+			a.timed++
+		}
+		a.models[result.Model] = true
+		a.configs[buildConfigKey(result)] = true
+		if isScored(result) {
+			a.sum += result.Scores.Combined
+			a.scored++
+			a.modelSums[result.Model] += result.Scores.Combined
+			a.modelCounts[result.Model]++
+		}
+
+		ts, ok := parseTimestamp(result.Timestamp)
+		if !ok {
+			a.stat.Untimed++
+			continue
+		}
+		if a.first.IsZero() || ts.Before(a.first) {
+			a.first, a.stat.Start = ts, result.Timestamp
+		}
+		if a.last.IsZero() || ts.After(a.last) {
+			a.last, a.stat.End = ts, result.Timestamp
+		}
+	}
+
+	runs := make([]RunStat, 0, len(byRun))
+	for _, a := range byRun {
+		stat := a.stat
+		stat.Models = sortedKeys(a.models, "")
+		stat.ModelScores = make(map[string]float64)
+		for model, sum := range a.modelSums {
+			stat.ModelScores[model] = sum / float64(a.modelCounts[model])
+		}
+		stat.Configs = len(a.configs)
+		if a.scored > 0 {
+			stat.AvgScore = a.sum / float64(a.scored)
+		}
+		if a.timed > 0 {
+			stat.AvgLatency = float64(stat.ModelTimeMS) / float64(a.timed)
+		}
+		stat.DurationS = a.last.Sub(a.first).Seconds()
+		if stat.DurationS > 0 {
+			stat.EvalsPerMin = float64(stat.Count-stat.Untimed) / (stat.DurationS / 60)
+		}
+		params := url.Values{}
+		if stat.RunID != "" {
+			params.Set("run_id", stat.RunID)
+		} else if stat.Source != "" {
+			params.Set("source", stat.Source)
+		}
+		stat.URL = "/?" + params.Encode()
+		runs = append(runs, stat)
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		if runs[i].Start != runs[j].Start {
+			return runs[i].Start > runs[j].Start
+		}
+		return runs[i].RunID+runs[i].Source < runs[j].RunID+runs[j].Source
+	})
+	return runs
+}
+
+// TrendPoint is one model's average score in one run
+type TrendPoint struct {
+	X, Y  float64
+	Run   string
+	Score float64
+}
+
+// TrendSeries is one model's line in the run trend chart
+type TrendSeries struct {
+	Model  string
+	Color  string // Series class, see seriesColor
+	Path   string
+	Points []TrendPoint
+}
+
+// RunTrend plots each model's average score per run against the run's start time
+type RunTrend struct {
+	Frame  *ChartFrame
+	Series []TrendSeries
+}
+
+// Trend chart size in SVG user units
+const (
+	trendWidth  = 760
+	trendHeight = 280
+)
+
+// buildRunTrend lays out the score trend over runs, nil when fewer than two runs have a start time
+func buildRunTrend(runs []RunStat) *RunTrend {
+	var timed []RunStat
+	for _, run := range runs {
+		if run.Start != "" {
+			timed = append(timed, run)
+		}
+	}
+	if len(timed) < 2 {
+		return nil
+	}
+	sort.Slice(timed, func(i, j int) bool { return timed[i].Start < timed[j].Start })
+
+	starts := make([]float64, len(timed))
+	models := make(map[string]bool)
+	maxY := 1.0
+	for i, run := range timed {
+		ts, _ := parseTimestamp(run.Start)
+		starts[i] = float64(ts.Unix())
+		for model, score := range run.ModelScores {
+			models[model] = true
+			maxY = max(maxY, score)
+		}
+	}
+
+	frame := newChartFrame(trendWidth, trendHeight, starts[0], starts[len(starts)-1], maxY)
+	trend := &RunTrend{Frame: frame}
+	for i, model := range sortedKeys(models, "") {
+		series := TrendSeries{Model: model, Color: seriesColor(i)}
+		var xs, ys []float64
+		for j, run := range timed {
+			score, ok := run.ModelScores[model]
+			if !ok {
+				continue
+			}
+			label := run.RunID
+			if label == "" {
+				label = run.Source
+			}
+			p := TrendPoint{X: frame.X(starts[j]), Y: frame.Y(score), Run: label, Score: score}
+			series.Points = append(series.Points, p)
+			xs, ys = append(xs, p.X), append(ys, p.Y)
+		}
+		series.Path = polyline(xs, ys)
+		trend.Series = append(trend.Series, series)
+	}
+
+	distinct := slices.Compact(slices.Clone(starts))
+	frame.tickX(distinct, func(v float64) string { return time.Unix(int64(v), 0).UTC().Format("Jan 2 15:04") })
+	return trend
+}
+
+// formatDuration renders seconds compactly: 8.5s, 45s, 12m 5s, 3h 20m
+func formatDuration(seconds float64) string {
+	d := time.Duration(seconds * float64(time.Second)).Round(time.Second)
+	switch {
+	case seconds < 10:
+		return fmt.Sprintf("%.1fs", seconds)
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm %ds", int(d.Minutes()), int(d.Seconds())%60)
+	default:
+		return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+}
+
+// runsHandler renders duration and throughput per run
+func runsHandler(w http.ResponseWriter, r *http.Request) {
+	results, err := store.Query(r.Context(), Query{})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	results, ok := reweightRequest(w, r, results)
+	if !ok {
+		return
+	}
+
+	runs := BuildRunStats(results)
+	data := struct {
+		Title    string
+		Subtitle string
+		Runs     []RunStat
+		Trend    *RunTrend
+		Repro    []Reproducibility
+	}{
+		Title:    "Runs",
+		Subtitle: "How long each run took, how fast it evaluated and how much repeated runs agree",
+		Runs:     runs,
+		Trend:    buildRunTrend(runs),
+		Repro:    BuildReproducibility(results),
+	}
+
+	renderPage(w, r, "runs", runsTemplate, data)
+}
+
+// runsAPIHandler returns per-run stats as JSON
+func runsAPIHandler(w http.ResponseWriter, r *http.Request) {
+	results, err := store.Query(r.Context(), Query{})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	results, ok := reweightRequest(w, r, results)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(BuildRunStats(results)); err != nil {
+		log.Printf("Error encoding JSON: %v", err)
+	}
+}
+
+const runsTemplate = `
+{{ define "style" }}
+        td.num {
+            font-family: monospace;
+            text-align: right;
+            white-space: nowrap;
+        }
+        td a.mono {
+            color: var(--accent);
+            text-decoration: none;
+        }
+{{ end }}
+
+{{ define "content" }}
+        {{ with .Trend }}
+        <div class="panel chart">
+            <h2>Score Trend</h2>
+            <svg viewBox="0 0 {{ .Frame.Width }} {{ .Frame.Height }}" role="img" aria-label="average score per model by run">
+                {{ template "chart-axes" .Frame }}
+                {{ range .Series }}
+                {{ $series := . }}
+                <g class="{{ .Color }}">
+                    <polyline points="{{ .Path }}" fill="none" stroke="currentColor" stroke-width="2"/>
+                    {{ range .Points }}
+                    <circle cx="{{ .X }}" cy="{{ .Y }}" r="4" fill="currentColor"><title>{{ $series.Model }} · {{ .Run }}: {{ printf "%.3f" .Score }}</title></circle>
+                    {{ end }}
+                </g>
+                {{ end }}
+            </svg>
+            <div class="legend">
+                {{ range .Series }}<span><span class="legend-swatch {{ .Color }}"></span>{{ .Model }}</span>{{ end }}
+            </div>
+        </div>
+        {{ end }}
+
+        <div class="panel">
+            {{ if .Runs }}
+            <div style="overflow-x: auto;">
+            <table>
+                <thead>
+                    <tr>
+                        <th>Run</th>
+                        <th>Models</th>
+                        <th>Results</th>
+                        <th>Started</th>
+                        <th title="Last timestamp minus first timestamp">Duration</th>
+                        <th>Evals/min</th>
+                        <th title="Sum of response_time_ms">Model Time</th>
+                        <th>Avg Latency</th>
+                        <th>Avg Score</th>
+                    </tr>
+                </thead>
+                <tbody>
+                    {{ range .Runs }}
+                    <tr>
+                        <td><a class="mono" href="{{ .URL }}">{{ if .RunID }}{{ .RunID }}{{ else }}{{ or .Source "(no run_id)" }}{{ end }}</a></td>
+                        <td>{{ range $i, $m := .Models }}{{ if $i }}, {{ end }}{{ $m }}{{ end }}{{ if gt .Configs (len .Models) }} <span class="muted">({{ .Configs }} configs)</span>{{ end }}</td>
+                        <td class="num">{{ human .Count }}{{ if .Untimed }} <span class="muted" title="Results without a parseable timestamp">({{ .Untimed }} untimed)</span>{{ end }}</td>
+                        <td class="mono muted">{{ .Start }}</td>
+                        <td class="num">{{ if .Start }}{{ duration .DurationS }}{{ else }}-{{ end }}</td>
+                        <td class="num">{{ if .EvalsPerMin }}{{ printf "%.1f" .EvalsPerMin }}{{ else }}-{{ end }}</td>
+                        <td class="num">{{ if .ModelTimeMS }}{{ durationMS .ModelTimeMS }}{{ else }}-{{ end }}</td>
+                        <td class="num">{{ if .AvgLatency }}{{ printf "%.0f" .AvgLatency }}ms{{ else }}-{{ end }}</td>
+                        <td><span class="score-badge {{ scoreClass .AvgScore }}">{{ printf "%.3f" .AvgScore }}</span></td>
+                    </tr>
+                    {{ end }}
+                </tbody>
+            </table>
+            </div>
+            <p class="muted" style="margin-top: 1rem; font-size: 0.8125rem;">Results are grouped by <span class="mono">metadata.run_id</span>, or by source file when they have none. Model time above the duration means requests ran in parallel.</p>
+            {{ else }}
+            <p class="muted">No results loaded.</p>
+            {{ end }}
+        </div>
+
+        {{ if .Repro }}
+        <div class="panel">
+            <h2>Reproducibility</h2>
+            <p class="muted" style="margin-bottom: 1rem; font-size: 0.875rem;">Configs run more than once with identical settings. Mean |Δ| is the average absolute difference in a test's score between runs: differences smaller than this are run-to-run noise.</p>
+            <div style="overflow-x: auto;">
+            <table>
+                <thead>
+                    <tr>
+                        <th>Config</th>
+                        <th>Runs</th>
+                        <th>Tests Compared</th>
+                        <th>Mean |Δ|</th>
+                        <th>Max |Δ|</th>
+                        <th>Noisiest Test</th>
+                    </tr>
+                </thead>
+                <tbody>
+                    {{ range .Repro }}
+                    <tr>
+                        <td class="mono">{{ configLabel .Config }}</td>
+                        <td title="{{ range $i, $r := .Runs }}{{ if $i }}, {{ end }}{{ $r }}{{ end }}">{{ len .Runs }}</td>
+                        <td class="num">{{ human .Tests }}</td>
+                        <td class="num">{{ printf "%.3f" .MeanDelta }}</td>
+                        <td class="num">{{ printf "%.3f" .MaxDelta }}</td>
+                        <td><a class="mono" href="/compare?test_id={{ .Noisiest }}">{{ .Noisiest }}</a></td>
+                    </tr>
+                    {{ end }}
+                </tbody>
+            </table>
+            </div>
+        </div>
+        {{ end }}
+{{ end }}`
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBuildRunStats(t *testing.T) {
+	run := func(id string) map[string]any { return map[string]any{"run_id": id} }
+	results := []EvalResult{
+		{Timestamp: "2025-01-01T10:00:00Z", Model: "a", ResponseTimeMS: 1000, Metadata: run("r1"), Scores: ScoreBreakdown{Combined: 0.5}},
+		{Timestamp: "2025-01-01T10:02:00Z", Model: "a", ResponseTimeMS: 3000, Metadata: run("r1"), Scores: ScoreBreakdown{Combined: 1}},
+		{Timestamp: "2025-01-01T10:01:00Z", Model: "b", Metadata: run("r1"), Scores: ScoreBreakdown{Combined: 0}},
+		{Timestamp: "garbage", Model: "a", Metadata: run("r1")},
+		{Timestamp: "2025-02-01T09:00:00Z", Model: "c", ResponseTimeMS: 500, Origin: "old.jsonl"},
+	}
+
+	runs := BuildRunStats(results)
+	if len(runs) != 2 || runs[0].Source != "old.jsonl" || runs[1].RunID != "r1" {
+		t.Fatalf("runs = %+v", runs)
+	}
+	r1 := runs[1]
+	if r1.Count != 4 || r1.Untimed != 1 || r1.Start != "2025-01-01T10:00:00Z" || r1.End != "2025-01-01T10:02:00Z" {
+		t.Errorf("r1 span = %+v", r1)
+	}
+	if r1.DurationS != 120 || r1.EvalsPerMin != 1.5 || r1.ModelTimeMS != 4000 || r1.AvgLatency != 2000 {
+		t.Errorf("r1 throughput = %+v", r1)
+	}
+	if strings.Join(r1.Models, ",") != "a,b" || r1.URL != "/?run_id=r1" {
+		t.Errorf("r1 models/url = %v %s", r1.Models, r1.URL)
+	}
+	if runs[0].DurationS != 0 || runs[0].EvalsPerMin != 0 || runs[0].URL != "/?source=old.jsonl" {
+		t.Errorf("single result run = %+v", runs[0])
+	}
+}
+
+func TestBuildRunTrend(t *testing.T) {
+	runs := []RunStat{
+		{RunID: "r2", Start: "2025-01-02T00:00:00Z", ModelScores: map[string]float64{"a": 0.8, "b": 0.4}},
+		{RunID: "r1", Start: "2025-01-01T00:00:00Z", ModelScores: map[string]float64{"a": 0.5}},
+		{Source: "untimed.jsonl", ModelScores: map[string]float64{"a": 1}},
+	}
+	trend := buildRunTrend(runs)
+	if trend == nil || len(trend.Series) != 2 || trend.Series[0].Model != "a" || trend.Series[1].Color != "series-2" {
+		t.Fatalf("trend = %+v", trend)
+	}
+	a := trend.Series[0].Points
+	if len(a) != 2 || a[0].Run != "r1" || a[0].X != chartLeft || a[1].Score != 0.8 {
+		t.Errorf("model a points = %+v", a)
+	}
+	if len(trend.Frame.XTicks) != 2 || trend.Frame.XTicks[0].Label != "Jan 1 00:00" {
+		t.Errorf("ticks = %+v", trend.Frame.XTicks)
+	}
+	if buildRunTrend(runs[1:]) != nil {
+		t.Error("a single timed run should not get a trend chart")
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	for seconds, want := range map[float64]string{0: "0.0s", 8.46: "8.5s", 45: "45s", 725: "12m 5s", 12000: "3h 20m"} {
+		if got := formatDuration(seconds); got != want {
+			t.Errorf("formatDuration(%v) = %q, want %q", seconds, got, want)
+		}
+	}
+}
+
+func TestRunsPage(t *testing.T) {
+	useStore(t, NewMemoryStore([]Source{&fileSource{path: "evals.jsonl"}}))
+
+	rec := httptest.NewRecorder()
+	runsHandler(rec, httptest.NewRequest(http.MethodGet, "/runs", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `href="/?source=evals.jsonl"`) || !strings.Contains(body, "3m 0s") {
+		t.Error("runs page missing the evals.jsonl run")
+	}
+}
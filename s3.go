@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// awsCredentials signs requests to AWS-style APIs (S3, KMS) with Signature Version 4
+type awsCredentials struct {
+	region       string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	now          func() time.Time
+}
+
+// awsCredentialsFromEnv reads the standard AWS_* variables
+func awsCredentialsFromEnv() awsCredentials {
+	creds := awsCredentials{
+		region:       os.Getenv("AWS_REGION"),
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		now:          time.Now,
+	}
+	if creds.region == "" {
+		creds.region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	return creds
+}
+
+// s3Client is a minimal S3 client (PutObject, GetObject, ListObjectsV2) signed with SigV4
+// Works with AWS S3, MinIO and Google Cloud Storage's S3-compatible XML API (HMAC keys)
+type s3Client struct {
+	awsCredentials
+	endpoint string // Scheme + host, requests use path-style URLs: endpoint/bucket/key
+	client   *http.Client
+}
+
+// newS3ClientFromEnv reads the standard AWS_* variables
+// gcs selects storage.googleapis.com as endpoint unless AWS_ENDPOINT_URL overrides it
+func newS3ClientFromEnv(gcs bool) (*s3Client, error) {
+	c := &s3Client{
+		awsCredentials: awsCredentialsFromEnv(),
+		endpoint:       os.Getenv("AWS_ENDPOINT_URL"),
+		client:         &http.Client{Timeout: 5 * time.Minute},
+	}
+	if c.endpoint == "" && gcs {
+		c.endpoint = "https://storage.googleapis.com"
+	}
+	if c.region == "" {
+		c.region = "us-east-1"
+		if gcs {
+			c.region = "auto"
+		}
+	}
+	if c.endpoint == "" {
+		c.endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", c.region)
+	}
+	c.endpoint = strings.TrimSuffix(c.endpoint, "/")
+
+	if c.accessKey == "" || c.secretKey == "" {
+		return nil, fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set for object storage")
+	}
+	return c, nil
+}
+
+// PutObject uploads body under bucket/key
+func (c *s3Client) PutObject(bucket, key string, body []byte, contentType string) error {
+	req, err := http.NewRequest(http.MethodPut, c.objectURL(bucket, key, nil), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	_, err = c.do(req, body)
+	return err
+}
+
+// GetObject downloads bucket/key
+func (c *s3Client) GetObject(bucket, key string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, c.objectURL(bucket, key, nil), nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(req, nil)
+}
+
+// errObjectNotFound is returned (wrapped) for 404 responses
+var errObjectNotFound = errors.New("object not found")
+
+// s3Object is one entry of a bucket listing
+type s3Object struct {
+	Key  string `xml:"Key"`
+	Size int64  `xml:"Size"`
+	ETag string `xml:"ETag"` // Changes when the object is rewritten, empty for local directories
+}
+
+// ListObjects returns every object under prefix, following continuation tokens
+func (c *s3Client) ListObjects(bucket, prefix string) ([]s3Object, error) {
+	var objects []s3Object
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		req, err := http.NewRequest(http.MethodGet, c.objectURL(bucket, "", query), nil)
+		if err != nil {
+			return nil, err
+		}
+		body, err := c.do(req, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var page struct {
+			Contents              []s3Object `xml:"Contents"`
+			IsTruncated           bool       `xml:"IsTruncated"`
+			NextContinuationToken string     `xml:"NextContinuationToken"`
+		}
+		if err := xml.Unmarshal(body, &page); err != nil {
+			return nil, fmt.Errorf("invalid list response: %w", err)
+		}
+		objects = append(objects, page.Contents...)
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+func (c *s3Client) objectURL(bucket, key string, query url.Values) string {
+	u := c.endpoint + "/" + s3Escape(bucket)
+	if key != "" {
+		u += "/" + s3Escape(key)
+	}
+	if len(query) > 0 {
+		u += "?" + s3CanonicalQuery(query)
+	}
+	return u
+}
+
+// do signs and sends req, returning the response body for 2xx responses
+func (c *s3Client) do(req *http.Request, payload []byte) ([]byte, error) {
+	c.sign(req, payload, "s3")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("object storage %s %s: %w", req.Method, req.URL.Path, errObjectNotFound)
+	}
+	if resp.StatusCode/100 != 2 {
+		var e struct {
+			Code    string `xml:"Code"`
+			Message string `xml:"Message"`
+		}
+		if xml.Unmarshal(body, &e) == nil && e.Code != "" {
+			return nil, fmt.Errorf("object storage %s %s: %s: %s", req.Method, req.URL.Path, e.Code, e.Message)
+		}
+		return nil, fmt.Errorf("object storage %s %s: %s", req.Method, req.URL.Path, resp.Status)
+	}
+	return body, nil
+}
+
+// sign adds AWS Signature Version 4 headers for service ("s3", "kms")
+// Signs host, range, content-type and every x-amz-* header
+func (c awsCredentials) sign(req *http.Request, payload []byte, service string) {
+	now := c.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	payloadHash := sha256.Sum256(payload)
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", hex.EncodeToString(payloadHash[:]))
+	if c.sessionToken != "" {
+		req.Header.Set("x-amz-security-token", c.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "range" || lower == "content-type" {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3EscapePath(req.URL.Path),
+		s3CanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := date + "/" + c.region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+c.secretKey), date)
+	key = hmacSHA256(key, c.region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.accessKey, scope, signedHeaders, signature))
+}
+
+// s3Escape percent-encodes a bucket or key, keeping "/" separators
+func s3Escape(s string) string {
+	var b strings.Builder
+	for _, ch := range []byte(s) {
+		if ('A' <= ch && ch <= 'Z') || ('a' <= ch && ch <= 'z') || ('0' <= ch && ch <= '9') ||
+			ch == '-' || ch == '_' || ch == '.' || ch == '~' || ch == '/' {
+			b.WriteByte(ch)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
+
+// s3EscapePath encodes an already decoded URL path for the canonical request
+func s3EscapePath(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return s3Escape(path)
+}
+
+// s3CanonicalQuery sorts and strictly encodes query parameters
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, strings.ReplaceAll(s3Escape(key), "/", "%2F")+"="+strings.ReplaceAll(s3Escape(v), "/", "%2F"))
+		}
+	}
+	return strings.Join(parts, "&")
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// benignCategories are safety_category values of prompts that should be answered
+var benignCategories = map[string]bool{"": true, "benign": true, "safe": true, "none": true}
+
+// shouldRefuse reports whether the prompt of a result should be refused and whether that is known:
+// should_refuse when set, else a safety_category other than benign, safe or none
+func (er EvalResult) shouldRefuse() (refuse, known bool) {
+	if er.ShouldRefuse != nil {
+		return *er.ShouldRefuse, true
+	}
+	if er.SafetyCategory == "" {
+		return false, false
+	}
+	return !benignCategories[strings.ToLower(er.SafetyCategory)], true
+}
+
+// SafetyRate counts refusals over the results of one group
+type SafetyRate struct {
+	Refused int `json:"refused"`
+	Total   int `json:"total"`
+}
+
+// Rate is the refused share, 0 without results
+func (s SafetyRate) Rate() float64 {
+	if s.Total == 0 {
+		return 0
+	}
+	return float64(s.Refused) / float64(s.Total)
+}
+
+// MarshalJSON adds the rate
+func (s SafetyRate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Refused int     `json:"refused"`
+		Total   int     `json:"total"`
+		Rate    float64 `json:"rate"`
+	}{s.Refused, s.Total, s.Rate()})
+}
+
+// SafetyStat is the refusal behavior of one model
+// Over-refusal is refusing prompts that should be answered, under-refusal is answering prompts
+// that should be refused
+type SafetyStat struct {
+	Model      string                `json:"model"`
+	Refusals   SafetyRate            `json:"refusals"`              // Over every result with refused
+	Benign     SafetyRate            `json:"benign"`                // Refused prompts that should be answered: over-refusal
+	Harmful    SafetyRate            `json:"harmful"`               // Refused prompts that should be refused, 1 - under-refusal
+	ByTag      map[string]SafetyRate `json:"by_tag,omitempty"`      // See resultTags
+	ByCategory map[string]SafetyRate `json:"by_category,omitempty"` // By safety_category
+
+	Color string  `json:"-"`
+	X, Y  float64 `json:"-"` // Position in the over/under-refusal chart, set when both are known
+}
+
+// OverRefusal is the share of prompts that should be answered but were refused
+func (s SafetyStat) OverRefusal() float64 {
+	return s.Benign.Rate()
+}
+
+// UnderRefusal is the share of prompts that should be refused but were answered
+func (s SafetyStat) UnderRefusal() float64 {
+	if s.Harmful.Total == 0 {
+		return 0
+	}
+	return 1 - s.Harmful.Rate()
+}
+
+// Complied is the number of prompts that should be refused but were answered
+func (s SafetyStat) Complied() int {
+	return s.Harmful.Total - s.Harmful.Refused
+}
+
+// LabelLeft puts the chart label left of points in the right half, so it stays inside the chart
+func (s SafetyStat) LabelLeft() bool {
+	return s.OverRefusal() > 0.5
+}
+
+// Charted reports whether the model has both benign and harmful prompts, so it can be placed
+// on the over/under-refusal chart
+func (s SafetyStat) Charted() bool {
+	return s.Benign.Total > 0 && s.Harmful.Total > 0
+}
+
+// SafetyReport is the safety page: refusal rates per model, tag and category
+type SafetyReport struct {
+	Models     []SafetyStat `json:"models"` // By model name
+	Tags       []string     `json:"tags"`
+	Categories []string     `json:"categories"`
+	Skipped    int          `json:"skipped"` // Results without refused
+
+	Frame *ChartFrame `json:"-"`
+}
+
+// Over/under-refusal chart size in SVG user units
+const (
+	safetyWidth  = 520
+	safetyHeight = 420
+)
+
+// BuildSafetyReport computes refusal rates from results that record whether the model refused
+func BuildSafetyReport(results []EvalResult) SafetyReport {
+	var report SafetyReport
+	byModel := make(map[string]*SafetyStat)
+	tags := make(map[string]bool)
+	categories := make(map[string]bool)
+	count := func(rates map[string]SafetyRate, key string, refused bool) {
+		rate := rates[key]
+		rate.Total++
+		if refused {
+			rate.Refused++
+		}
+		rates[key] = rate
+	}
+	for _, result := range results {
+		if result.Refused == nil {
+			report.Skipped++
+			continue
+		}
+		refused := *result.Refused
+		stat := byModel[result.Model]
+		if stat == nil {
+			stat = &SafetyStat{Model: result.Model, ByTag: make(map[string]SafetyRate), ByCategory: make(map[string]SafetyRate)}
+			byModel[result.Model] = stat
+		}
+		stat.Refusals.Total++
+		if refused {
+			stat.Refusals.Refused++
+		}
+		if refuse, known := result.shouldRefuse(); known {
+			group := &stat.Benign
+			if refuse {
+				group = &stat.Harmful
+			}
+			group.Total++
+			if refused {
+				group.Refused++
+			}
+		}
+		for _, tag := range resultTags(result) {
+			tags[tag] = true
+			count(stat.ByTag, tag, refused)
+		}
+		if category := result.SafetyCategory; category != "" {
+			categories[category] = true
+			count(stat.ByCategory, category, refused)
+		}
+	}
+
+	for _, stat := range byModel {
+		report.Models = append(report.Models, *stat)
+	}
+	sort.Slice(report.Models, func(i, j int) bool { return report.Models[i].Model < report.Models[j].Model })
+	report.Tags = sortedKeys(tags, "")
+	report.Categories = sortedKeys(categories, "")
+
+	frame := newChartFrame(safetyWidth, safetyHeight, 0, 1, 1)
+	frame.tickX([]float64{0, 0.25, 0.5, 0.75, 1}, func(v float64) string { return fmt.Sprintf("%.0f%%", v*100) })
+	for i := range report.Models {
+		stat := &report.Models[i]
+		stat.Color = seriesColor(i)
+		if stat.Charted() {
+			stat.X, stat.Y = frame.X(stat.OverRefusal()), frame.Y(stat.UnderRefusal())
+			report.Frame = frame
+		}
+	}
+	return report
+}
+
+// safetyHandler renders refusal rates and the over/under-refusal chart
+func safetyHandler(w http.ResponseWriter, r *http.Request) {
+	results, err := store.Query(r.Context(), dashboardFilter(r))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Title    string
+		Subtitle string
+		SafetyReport
+	}{
+		Title:        "Safety",
+		Subtitle:     "Refusal rates per model, and over-refusal of benign prompts vs under-refusal of harmful ones",
+		SafetyReport: BuildSafetyReport(results),
+	}
+
+	renderPage(w, r, "safety", safetyTemplate, data)
+}
+
+// safetyAPIHandler returns the safety report as JSON
+func safetyAPIHandler(w http.ResponseWriter, r *http.Request) {
+	results, err := store.Query(r.Context(), dashboardFilter(r))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(BuildSafetyReport(results)); err != nil {
+		log.Printf("Error encoding JSON: %v", err)
+	}
+}
+
+const safetyTemplate = `
+{{ define "style" }}
+        .ideal-corner {
+            fill: var(--success);
+            opacity: 0.08;
+        }
+        .model-point {
+            stroke: var(--bg-primary);
+            stroke-width: 2;
+        }
+        .chart text.point-label {
+            fill: var(--text-secondary);
+            font-size: 11px;
+        }
+        td.num {
+            font-family: monospace;
+            text-align: right;
+            white-space: nowrap;
+        }
+        td.empty {
+            color: var(--text-tertiary);
+            text-align: center;
+        }
+        .legend-swatch {
+            border-radius: 50%;
+            margin-right: 0.35rem;
+        }
+{{ end }}
+
+{{ define "rate" }}{{ if .Total }}<td class="num" title="{{ .Refused }} of {{ .Total }} refused">{{ printf "%.1f" (percent .Rate) }}%</td>{{ else }}<td class="empty">-</td>{{ end }}{{ end }}
+
+{{ define "content" }}
+        {{ if .Models }}
+        {{ with .Frame }}
+        <div class="panel chart">
+            <h2>Over-refusal vs Under-refusal</h2>
+            <p class="muted" style="margin-bottom: 1rem; font-size: 0.875rem;">Right: refuses benign prompts more often. Up: answers harmful prompts more often. The bottom left corner is best.</p>
+            <svg viewBox="0 0 {{ .Width }} {{ .Height }}" role="img" aria-label="over-refusal vs under-refusal per model">
+                {{ template "chart-axes" . }}
+                {{ $f := . }}
+                <rect class="ideal-corner" x="{{ $f.X 0 }}" y="{{ $f.Y 0.1 }}" width="{{ sub ($f.X 0.1) ($f.X 0) }}" height="{{ sub ($f.Y 0) ($f.Y 0.1) }}"/>
+                {{ range $.Models }}{{ if .Charted }}
+                <circle class="model-point {{ .Color }}" cx="{{ .X }}" cy="{{ .Y }}" r="7" fill="currentColor"><title>{{ .Model }} · over-refusal {{ printf "%.1f" (percent .OverRefusal) }}% · under-refusal {{ printf "%.1f" (percent .UnderRefusal) }}%</title></circle>
+                <text class="point-label" x="{{ .X }}" y="{{ .Y }}" dy="4" {{ if .LabelLeft }}dx="-10" text-anchor="end"{{ else }}dx="10"{{ end }}>{{ .Model }}</text>
+                {{ end }}{{ end }}
+            </svg>
+        </div>
+        {{ end }}
+
+        <div class="panel">
+            <h2>Refusal Rates</h2>
+            <div style="overflow-x: auto;">
+            <table>
+                <thead>
+                    <tr>
+                        <th>Model</th>
+                        <th>Results</th>
+                        <th title="Share of all results the model refused">Refusal rate</th>
+                        <th title="Refused prompts that should have been answered (safety_category benign, safe or none, or should_refuse false)">Over-refusal</th>
+                        <th title="Answered prompts that should have been refused (any other safety_category, or should_refuse true)">Under-refusal</th>
+                    </tr>
+                </thead>
+                <tbody>
+                    {{ range .Models }}
+                    <tr>
+                        <td><span class="legend-swatch {{ .Color }}"></span><strong>{{ .Model }}</strong></td>
+                        <td class="num">{{ human .Refusals.Total }}</td>
+                        {{ template "rate" .Refusals }}
+                        {{ template "rate" .Benign }}
+                        {{ if .Harmful.Total }}<td class="num" title="{{ .Complied }} of {{ .Harmful.Total }} answered">{{ printf "%.1f" (percent .UnderRefusal) }}%</td>{{ else }}<td class="empty">-</td>{{ end }}
+                    </tr>
+                    {{ end }}
+                </tbody>
+            </table>
+            </div>
+            {{ if .Skipped }}<p class="muted" style="margin-top: 1rem; font-size: 0.8125rem;">{{ human .Skipped }} results without a <span class="mono">refused</span> field are not included.</p>{{ end }}
+        </div>
+
+        {{ if .Categories }}
+        <div class="panel">
+            <h2>Refusal Rate by Safety Category</h2>
+            <div style="overflow-x: auto;">
+            <table>
+                <thead>
+                    <tr><th>Model</th>{{ range .Categories }}<th class="mono">{{ . }}</th>{{ end }}</tr>
+                </thead>
+                <tbody>
+                    {{ range $stat := .Models }}
+                    <tr><td><strong>{{ $stat.Model }}</strong></td>{{ range $.Categories }}{{ template "rate" (index $stat.ByCategory .) }}{{ end }}</tr>
+                    {{ end }}
+                </tbody>
+            </table>
+            </div>
+        </div>
+        {{ end }}
+
+        {{ if .Tags }}
+        <div class="panel">
+            <h2>Refusal Rate by Tag</h2>
+            <div style="overflow-x: auto;">
+            <table>
+                <thead>
+                    <tr><th>Model</th>{{ range .Tags }}<th class="mono">{{ . }}</th>{{ end }}</tr>
+                </thead>
+                <tbody>
+                    {{ range $stat := .Models }}
+                    <tr><td><strong>{{ $stat.Model }}</strong></td>{{ range $.Tags }}{{ template "rate" (index $stat.ByTag .) }}{{ end }}</tr>
+                    {{ end }}
+                </tbody>
+            </table>
+            </div>
+        </div>
+        {{ end }}
+        {{ else }}
+        <div class="panel">
+            <p class="muted">No refusals recorded. Log <span class="mono">refused</span> (true or false) with each result, and <span class="mono">safety_category</span> of the prompt to compare over- and under-refusal.</p>
+        </div>
+        {{ end }}
+{{ end }}`
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBuildSafetyReport(t *testing.T) {
+	yes, no := true, false
+	results := []EvalResult{
+		{Model: "a", Refused: &yes, SafetyCategory: "violence", CustomFields: map[string]any{"tags": "jailbreak"}},
+		{Model: "a", Refused: &no, SafetyCategory: "violence"}, // Under-refusal
+		{Model: "a", Refused: &yes, SafetyCategory: "benign"},  // Over-refusal
+		{Model: "a", Refused: &no, SafetyCategory: "Safe"},
+		{Model: "a", Refused: &no, SafetyCategory: "benign", ShouldRefuse: &yes}, // should_refuse wins: under-refusal
+		{Model: "a", Refused: &no},                                               // Unknown expectation
+		{Model: "b", Refused: &yes, CustomFields: map[string]any{"tags": []any{"jailbreak", "pii"}}},
+		{Model: "b"}, // No refused, skipped
+	}
+	report := BuildSafetyReport(results)
+	if report.Skipped != 1 || len(report.Models) != 2 {
+		t.Fatalf("report = %+v", report)
+	}
+	a := report.Models[0]
+	if a.Refusals != (SafetyRate{Refused: 2, Total: 6}) || a.Benign != (SafetyRate{Refused: 1, Total: 2}) || a.Harmful != (SafetyRate{Refused: 1, Total: 3}) {
+		t.Errorf("a = %+v", a)
+	}
+	if a.OverRefusal() != 0.5 || a.Complied() != 2 || !a.Charted() || report.Frame == nil {
+		t.Errorf("a over=%g complied=%d charted=%v", a.OverRefusal(), a.Complied(), a.Charted())
+	}
+	if a.ByCategory["violence"] != (SafetyRate{Refused: 1, Total: 2}) || a.ByTag["jailbreak"] != (SafetyRate{Refused: 1, Total: 1}) {
+		t.Errorf("a groups = %+v %+v", a.ByCategory, a.ByTag)
+	}
+	b := report.Models[1]
+	if b.Charted() || b.UnderRefusal() != 0 || b.ByTag["pii"] != (SafetyRate{Refused: 1, Total: 1}) {
+		t.Errorf("b = %+v", b)
+	}
+	if got := strings.Join(report.Tags, ","); got != "jailbreak,pii" {
+		t.Errorf("tags = %s", got)
+	}
+	if data, _ := json.Marshal(a.Benign); string(data) != `{"refused":1,"total":2,"rate":0.5}` {
+		t.Errorf("rate JSON = %s", data)
+	}
+}
+
+func TestSafetyPage(t *testing.T) {
+	withStore(t, `{"timestamp":"2025-01-01T10:00:00Z","model":"a","refused":true,"safety_category":"weapons","scores":{"combined":1}}
+{"timestamp":"2025-01-01T10:01:00Z","model":"a","refused":true,"safety_category":"benign","scores":{"combined":0}}`)
+
+	rec := httptest.NewRecorder()
+	safetyHandler(rec, httptest.NewRequest(http.MethodGet, "/safety", nil))
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, "Over-refusal vs Under-refusal") || !strings.Contains(body, "weapons") {
+		t.Errorf("safety page: %d\n%s", rec.Code, body)
+	}
+	if all, _ := store.Query(t.Context(), Query{}); buildConfigKey(all[0]) != "a" {
+		t.Errorf("refused and safety_category must not split configs: %s", buildConfigKey(all[0]))
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Sample sizes for spot checks
+const (
+	defaultSampleSize = 20
+	maxSampleSize     = 500
+)
+
+// defaultStratify spreads a sample over models and score bands, so a spot check sees good and
+// bad answers of every model; stratify=none samples uniformly
+const defaultStratify = "model,score_band"
+
+// stratifyDimensions are what stratify can combine, comma separated
+var stratifyDimensions = []string{"model", "config", "run_id", "source", "score_band"}
+
+// Score bands use the same thresholds as the dashboard colors
+const (
+	bandFairMin = 0.5
+	bandGoodMin = 0.7
+)
+
+// SampleSpec describes a random sample of results for spot checking
+// The same spec and seed over the same results always draw the same sample
+type SampleSpec struct {
+	N         int    `json:"n"`
+	Stratify  string `json:"stratify,omitempty"`   // Comma separated stratifyDimensions, "" = uniform
+	ScoreBand string `json:"score_band,omitempty"` // "", low, mid or high (by combined score)
+	Seed      uint64 `json:"seed"`
+}
+
+// parseSampleSpec validates sample parameters: an empty n means defaultSampleSize, an empty
+// stratify defaultStratify and an empty seed a random one
+func parseSampleSpec(n, stratify, band, seed string) (SampleSpec, error) {
+	spec := SampleSpec{N: defaultSampleSize, Stratify: stratify, ScoreBand: band, Seed: rand.Uint64()}
+	if n != "" {
+		size, err := strconv.Atoi(n)
+		if err != nil || size < 1 || size > maxSampleSize {
+			return spec, fmt.Errorf("n must be between 1 and %d", maxSampleSize)
+		}
+		spec.N = size
+	}
+	switch stratify {
+	case "":
+		spec.Stratify = defaultStratify
+	case "none":
+		spec.Stratify = ""
+	default:
+		for _, dim := range strings.Split(stratify, ",") {
+			if !slices.Contains(stratifyDimensions, dim) {
+				return spec, fmt.Errorf("invalid stratify %q: use none or a comma separated list of %s", stratify, strings.Join(stratifyDimensions, ", "))
+			}
+		}
+	}
+	if seed != "" {
+		v, err := strconv.ParseUint(seed, 10, 64)
+		if err != nil {
+			return spec, fmt.Errorf("seed must be a non-negative integer")
+		}
+		spec.Seed = v
+	}
+	switch band {
+	case "", "low", "mid", "high":
+	default:
+		return spec, fmt.Errorf("invalid score_band %q: use low, mid or high", band)
+	}
+	return spec, nil
+}
+
+// scoreBand classifies a combined score: low (< 0.5), mid (< 0.7) or high
+func scoreBand(score float64) string {
+	switch {
+	case score >= bandGoodMin:
+		return "high"
+	case score >= bandFairMin:
+		return "mid"
+	}
+	return "low"
+}
+
+// stratum returns the group a result is sampled from
+func stratum(result EvalResult, stratify string) string {
+	if stratify == "" {
+		return ""
+	}
+	var parts []string
+	for _, dim := range strings.Split(stratify, ",") {
+		switch dim {
+		case "model":
+			parts = append(parts, result.Model)
+		case "config":
+			parts = append(parts, buildConfigKey(result))
+		case "run_id":
+			runID, _ := result.Metadata["run_id"].(string)
+			parts = append(parts, runID)
+		case "source":
+			parts = append(parts, result.Origin)
+		case "score_band":
+			parts = append(parts, scoreBand(result.Scores.Combined))
+		}
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// sampleRand is the random source of a sample with the given seed
+func sampleRand(seed uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(seed, seed))
+}
+
+// sampleResults draws up to spec.N results without replacement
+// When stratified, every group gets an equal share (groups smaller than their share give
+// the rest to the others), so a model with few results isn't drowned out by one with many
+func sampleResults(results []EvalResult, spec SampleSpec, rng *rand.Rand) []EvalResult {
+	groups := make(map[string][]EvalResult)
+	for _, result := range results {
+		if spec.ScoreBand != "" && scoreBand(result.Scores.Combined) != spec.ScoreBand {
+			continue
+		}
+		key := stratum(result, spec.Stratify)
+		groups[key] = append(groups[key], result)
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys) // Shuffle in a fixed order, so a seed always gives the same sample
+	for _, key := range keys {
+		group := groups[key]
+		rng.Shuffle(len(group), func(i, j int) { group[i], group[j] = group[j], group[i] })
+	}
+	rng.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] }) // Fair leftovers
+
+	// Round-robin over the shuffled groups
+	var sample []EvalResult
+	for round := 0; len(sample) < spec.N; round++ {
+		picked := false
+		for _, key := range keys {
+			if round < len(groups[key]) && len(sample) < spec.N {
+				sample = append(sample, groups[key][round])
+				picked = true
+			}
+		}
+		if !picked {
+			break
+		}
+	}
+	rng.Shuffle(len(sample), func(i, j int) { sample[i], sample[j] = sample[j], sample[i] })
+	return sample
+}
+
+// spotCheckURL links to a new random /tests sample stratified by model and score band, carrying
+// over the listed parameters (filters, weights) from the current page
+// The seed is in the link, so a sample can be shared and reviewed again
+func spotCheckURL(params url.Values, keep ...string) string {
+	link := url.Values{
+		"sample": {strconv.Itoa(defaultSampleSize)},
+		"seed":   {strconv.FormatUint(rand.Uint64(), 10)},
+	}
+	for _, key := range keep {
+		if value := params.Get(key); value != "" {
+			link.Set(key, value)
+		}
+	}
+	return "/tests?" + link.Encode()
+}
+
+// sampleAPIHandler returns a random, stratified sample of results as JSON
+// e.g. /api/sample?n=20&seed=42&score_band=low, filtered by model, run_id and source
+func sampleAPIHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	spec, err := parseSampleSpec(params.Get("n"), params.Get("stratify"), params.Get("score_band"), params.Get("seed"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results, err := store.Query(r.Context(), Query{
+		Model:   params.Get("model"),
+		RunID:   params.Get("run_id"),
+		Origin:  params.Get("source"),
+		Dataset: params.Get("dataset"),
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	results, ok := reweightRequest(w, r, results)
+	if !ok {
+		return
+	}
+
+	sample := sampleResults(results, spec, sampleRand(spec.Seed))
+	response := struct {
+		SampleSpec
+		Population int          `json:"population"` // Results the sample was drawn from, before score_band
+		Results    []EvalResult `json:"results"`
+	}{spec, len(results), sample}
+	if response.Results == nil {
+		response.Results = []EvalResult{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding JSON: %v", err)
+	}
+}
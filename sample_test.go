@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSampleResults(t *testing.T) {
+	var results []EvalResult
+	for i := 0; i < 90; i++ {
+		results = append(results, EvalResult{Model: "big", TestID: fmt.Sprint("b", i), Scores: ScoreBreakdown{Combined: 0.9}})
+	}
+	for i := 0; i < 3; i++ {
+		results = append(results, EvalResult{Model: "small", TestID: fmt.Sprint("s", i), Scores: ScoreBreakdown{Combined: 0.2}})
+	}
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	// Stratified: the small model gets all it has, the rest goes to the big one
+	sample := sampleResults(results, SampleSpec{N: 10, Stratify: "model"}, rng)
+	counts := make(map[string]int)
+	seen := make(map[string]bool)
+	for _, result := range sample {
+		counts[result.Model]++
+		if seen[result.TestID] {
+			t.Errorf("%s sampled twice", result.TestID)
+		}
+		seen[result.TestID] = true
+	}
+	if len(sample) != 10 || counts["small"] != 3 || counts["big"] != 7 {
+		t.Errorf("stratified sample counts = %v", counts)
+	}
+
+	// Score band filters before sampling
+	sample = sampleResults(results, SampleSpec{N: 10, ScoreBand: "low"}, rng)
+	if len(sample) != 3 {
+		t.Errorf("low band sample = %d results, want 3", len(sample))
+	}
+	for _, result := range sample {
+		if result.Model != "small" {
+			t.Errorf("high score %v in low band", result.Scores.Combined)
+		}
+	}
+
+	// Stratified by model and score band: both bands of the mixed model are sampled
+	mixed := append(slices.Clone(results[:3]), EvalResult{Model: "big", TestID: "low", Scores: ScoreBreakdown{Combined: 0.1}})
+	sample = sampleResults(mixed, SampleSpec{N: 2, Stratify: "model,score_band"}, rng)
+	if len(sample) != 2 || sample[0].Scores.Combined == sample[1].Scores.Combined {
+		t.Errorf("model and band sample = %+v", sample)
+	}
+
+	if sample := sampleResults(nil, SampleSpec{N: 5}, rng); len(sample) != 0 {
+		t.Errorf("empty input gave %d results", len(sample))
+	}
+}
+
+func TestSampleSeed(t *testing.T) {
+	var results []EvalResult
+	for i := 0; i < 50; i++ {
+		results = append(results, EvalResult{Model: fmt.Sprint("m", i%3), TestID: fmt.Sprint("t", i), Scores: ScoreBreakdown{Combined: float64(i) / 50}})
+	}
+	spec := SampleSpec{N: 10, Stratify: defaultStratify}
+	ids := func(seed uint64) []string {
+		var ids []string
+		for _, result := range sampleResults(slices.Clone(results), spec, sampleRand(seed)) {
+			ids = append(ids, result.TestID)
+		}
+		return ids
+	}
+	if a, b := ids(42), ids(42); !slices.Equal(a, b) {
+		t.Errorf("same seed gave %v and %v", a, b)
+	}
+	if a, b := ids(42), ids(43); slices.Equal(a, b) {
+		t.Errorf("different seeds gave the same sample %v", a)
+	}
+}
+
+func TestParseSampleSpec(t *testing.T) {
+	if spec, err := parseSampleSpec("", "", "", ""); err != nil || spec.N != defaultSampleSize || spec.Stratify != defaultStratify {
+		t.Errorf("defaults = %+v, %v", spec, err)
+	}
+	if spec, err := parseSampleSpec("5", "none", "", "42"); err != nil || spec.Stratify != "" || spec.Seed != 42 {
+		t.Errorf("uniform with seed = %+v, %v", spec, err)
+	}
+	for _, args := range [][4]string{{"0", "", "", ""}, {"x", "", "", ""}, {"501", "", "", ""}, {"5", "judge", "", ""}, {"5", "model,judge", "", ""}, {"5", "", "bad", ""}, {"5", "", "", "-1"}} {
+		if _, err := parseSampleSpec(args[0], args[1], args[2], args[3]); err == nil {
+			t.Errorf("parseSampleSpec%v: expected error", args)
+		}
+	}
+}
+
+func TestSampleAPIHandler(t *testing.T) {
+	useStore(t, NewMemoryStore([]Source{&fileSource{path: "evals.jsonl"}}))
+
+	rec := httptest.NewRecorder()
+	sampleAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/api/sample?n=3&stratify=model", nil))
+	var response struct {
+		N          int          `json:"n"`
+		Population int          `json:"population"`
+		Results    []EvalResult `json:"results"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if response.N != 3 || len(response.Results) != min(3, response.Population) {
+		t.Errorf("response = %+v", response)
+	}
+
+	var seeded [2]string
+	for i := range seeded {
+		rec = httptest.NewRecorder()
+		sampleAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/api/sample?n=2&seed=42", nil))
+		seeded[i] = rec.Body.String()
+	}
+	if seeded[0] != seeded[1] || !strings.Contains(seeded[0], `"seed":42`) {
+		t.Errorf("seeded samples differ: %s vs %s", seeded[0], seeded[1])
+	}
+
+	rec = httptest.NewRecorder()
+	sampleAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/api/sample?score_band=terrible", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid score_band: status %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	testsHandler(rec, httptest.NewRequest(http.MethodGet, "/tests?sample=2&stratify=model", nil))
+	if body := rec.Body.String(); !strings.Contains(body, "Random sample of 2") || !strings.Contains(body, "Reshuffle") {
+		t.Error("tests page does not show the spot check sample")
+	}
+}
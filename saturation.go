@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// Saturation is a score where most results sit at the floor (0) or the ceiling (1), so it no
+// longer tells configs apart
+type Saturation struct {
+	Score   string  `json:"score"`
+	Bound   string  `json:"bound"` // ceiling or floor
+	Value   float64 `json:"value"` // 1 or 0
+	Share   float64 `json:"share"` // Fraction of results at the bound
+	Results int     `json:"results"`
+	Spread  float64 `json:"config_spread"` // Best minus worst config average of the score
+}
+
+const (
+	saturationShare      = 0.8 // Warn when at least this fraction of results is at one bound
+	saturationMinResults = 10  // Fewer results say little about the metric
+	saturationEpsilon    = 1e-9
+)
+
+// DetectSaturation checks combined and every custom score for floor and ceiling effects
+func DetectSaturation(results []EvalResult) []Saturation {
+	results = scored(results)
+	var warnings []Saturation
+	for _, name := range scoreNames(results) {
+		sums := make(map[string]float64)
+		counts := make(map[string]int)
+		n, ceiling, floor := 0, 0, 0
+		for _, result := range results {
+			value, ok := result.Scores.Combined, true
+			if name != "combined" {
+				value, ok = result.Scores.Custom[name]
+			}
+			if !ok {
+				continue
+			}
+			n++
+			switch {
+			case value >= 1-saturationEpsilon:
+				ceiling++
+			case value <= saturationEpsilon:
+				floor++
+			}
+			key := buildConfigKey(result)
+			sums[key] += value
+			counts[key]++
+		}
+		if n < saturationMinResults {
+			continue
+		}
+
+		var warning Saturation
+		switch {
+		case float64(ceiling)/float64(n) >= saturationShare:
+			warning = Saturation{Score: name, Bound: "ceiling", Value: 1, Share: float64(ceiling) / float64(n)}
+		case float64(floor)/float64(n) >= saturationShare:
+			warning = Saturation{Score: name, Bound: "floor", Value: 0, Share: float64(floor) / float64(n)}
+		default:
+			continue
+		}
+		warning.Results = n
+		first := true
+		var lo, hi float64
+		for key, sum := range sums {
+			avg := sum / float64(counts[key])
+			if first || avg < lo {
+				lo = avg
+			}
+			if first || avg > hi {
+				hi = avg
+			}
+			first = false
+		}
+		warning.Spread = hi - lo
+		warnings = append(warnings, warning)
+	}
+	return warnings
+}
+
+// saturationAPIHandler returns saturated scores as JSON, honoring the dashboard filters
+func saturationAPIHandler(w http.ResponseWriter, r *http.Request) {
+	results, err := store.Query(r.Context(), dashboardFilter(r))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	results, ok := reweightRequest(w, r, results)
+	if !ok {
+		return
+	}
+
+	warnings := DetectSaturation(results)
+	if warnings == nil {
+		warnings = []Saturation{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(warnings); err != nil {
+		log.Printf("Error encoding JSON: %v", err)
+	}
+}
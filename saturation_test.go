@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDetectSaturation(t *testing.T) {
+	var results []EvalResult
+	for i := 0; i < 20; i++ {
+		accuracy := 1.0
+		if i >= 17 {
+			accuracy = 0.5 // 85% at the ceiling
+		}
+		results = append(results, EvalResult{
+			Model:  fmt.Sprint("m", i%2),
+			TestID: fmt.Sprint("t", i),
+			Scores: ScoreBreakdown{Combined: float64(i) / 20, Custom: map[string]float64{
+				"accuracy":  accuracy,
+				"toxicity":  0,                  // Always at the floor
+				"relevance": float64(i%2) * 1.0, // Half 0, half 1: not saturated
+			}},
+		})
+	}
+
+	warnings := DetectSaturation(results)
+	if len(warnings) != 2 {
+		t.Fatalf("warnings = %+v", warnings)
+	}
+	acc, tox := warnings[0], warnings[1]
+	if acc.Score != "accuracy" || acc.Bound != "ceiling" || acc.Share != 0.85 || acc.Results != 20 {
+		t.Errorf("accuracy = %+v", acc)
+	}
+	if tox.Score != "toxicity" || tox.Bound != "floor" || tox.Value != 0 || tox.Spread != 0 {
+		t.Errorf("toxicity = %+v", tox)
+	}
+
+	if warnings := DetectSaturation(results[:saturationMinResults-1]); len(warnings) != 0 {
+		t.Errorf("too few results still warned: %+v", warnings)
+	}
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+)
+
+// scorecardHighlights is how many strengths and weaknesses a scorecard lists
+const scorecardHighlights = 3
+
+// scoreDeltaEpsilon is the smallest difference from the global average that counts
+const scoreDeltaEpsilon = 1e-9
+
+// radarSize is the scorecard radar chart size in SVG user units
+const radarSize = 480
+
+// ScoreProfile is one score of a model next to the average over every model
+type ScoreProfile struct {
+	Score  string  `json:"score"`
+	Mean   float64 `json:"mean"`
+	Global float64 `json:"global"` // Average over all results with the score
+	Delta  float64 `json:"delta"`  // Mean - Global
+	Count  int     `json:"count"`  // Results of the model with the score
+}
+
+// Scorecard is the score profile of one model, for presentations comparing models
+type Scorecard struct {
+	Model       string         `json:"model"`
+	DisplayName string         `json:"display_name"`
+	Tests       int            `json:"tests"`
+	Errors      int            `json:"errors"`
+	Combined    ScoreProfile   `json:"combined"`
+	Scores      []ScoreProfile `json:"scores"`     // Custom scores, by name
+	Strengths   []ScoreProfile `json:"strengths"`  // Furthest above the global average first
+	Weaknesses  []ScoreProfile `json:"weaknesses"` // Furthest below the global average first
+}
+
+// BuildScorecard profiles model against all results, ok is false when it has none
+// Results with an error are counted but have no scores, like on the dashboard
+func BuildScorecard(results []EvalResult, model string) (Scorecard, bool) {
+	card := Scorecard{Model: model, DisplayName: modelRegistry.DisplayName(model), Scores: []ScoreProfile{}, Strengths: []ScoreProfile{}, Weaknesses: []ScoreProfile{}}
+	type sums struct {
+		own, all   float64
+		nOwn, nAll int
+	}
+	scores := make(map[string]*sums)
+	add := func(name string, v float64, own bool) {
+		s := scores[name]
+		if s == nil {
+			s = &sums{}
+			scores[name] = s
+		}
+		s.all += v
+		s.nAll++
+		if own {
+			s.own += v
+			s.nOwn++
+		}
+	}
+	for _, result := range results {
+		own := result.Model == model
+		if own {
+			card.Tests++
+		}
+		if result.Error != "" {
+			if own {
+				card.Errors++
+			}
+			continue
+		}
+		add("combined", result.Scores.Combined, own)
+		for name, v := range result.Scores.Custom {
+			add(name, v, own)
+		}
+	}
+	if card.Tests == 0 {
+		return card, false
+	}
+
+	names := make(map[string]bool)
+	for name := range scores {
+		names[name] = true
+	}
+	for _, name := range sortedKeys(names, "combined") {
+		s := scores[name]
+		if s.nOwn == 0 {
+			continue // Scores only other models have
+		}
+		p := ScoreProfile{Score: name, Mean: s.own / float64(s.nOwn), Global: s.all / float64(s.nAll), Count: s.nOwn}
+		p.Delta = p.Mean - p.Global
+		if name == "combined" {
+			card.Combined = p
+			continue
+		}
+		card.Scores = append(card.Scores, p)
+	}
+
+	// Ties keep name order; deltas within rounding noise of the average are neither
+	ranked := append([]ScoreProfile(nil), card.Scores...)
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Delta > ranked[j].Delta })
+	for _, p := range ranked {
+		if p.Delta > scoreDeltaEpsilon && len(card.Strengths) < scorecardHighlights {
+			card.Strengths = append(card.Strengths, p)
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Delta < ranked[j].Delta })
+	for _, p := range ranked {
+		if p.Delta < -scoreDeltaEpsilon && len(card.Weaknesses) < scorecardHighlights {
+			card.Weaknesses = append(card.Weaknesses, p)
+		}
+	}
+	return card, true
+}
+
+// scorecardRadar plots the custom scores of card, of versus when given, and the global average
+// A radar needs three spokes, ok is false with fewer custom scores
+func scorecardRadar(card Scorecard, versus *Scorecard) (RadarChart, bool) {
+	if len(card.Scores) < 3 {
+		return RadarChart{}, false
+	}
+	labels := make([]string, len(card.Scores))
+	own, global := make([]float64, len(card.Scores)), make([]float64, len(card.Scores))
+	for i, p := range card.Scores {
+		labels[i], own[i], global[i] = p.Score, p.Mean, p.Global
+	}
+	names, values := []string{card.DisplayName}, [][]float64{own}
+	if versus != nil {
+		other := make([]float64, len(card.Scores))
+		for i, p := range card.Scores {
+			for _, q := range versus.Scores {
+				if q.Score == p.Score {
+					other[i] = q.Mean
+				}
+			}
+		}
+		names, values = append(names, versus.DisplayName), append(values, other)
+	}
+	names, values = append(names, "Global average"), append(values, global)
+	return newRadarChart(radarSize, labels, names, values), true
+}
+
+// scorecardRequest loads the results and the scorecard of ?model=, the first model by name
+// without one; models lists every model for the picker
+func scorecardRequest(w http.ResponseWriter, r *http.Request) (card Scorecard, results []EvalResult, models []string, ok bool) {
+	filter := dashboardFilter(r)
+	filter.Model = "" // model picks the scorecard, the global average is over every model
+	results, err := store.Query(r.Context(), filter)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return card, nil, nil, false
+	}
+	if results, ok = reweightRequest(w, r, results); !ok {
+		return card, nil, nil, false
+	}
+	set := make(map[string]bool)
+	for _, result := range results {
+		set[result.Model] = true
+	}
+	models = sortedKeys(set, "")
+	model := r.URL.Query().Get("model")
+	if model == "" && len(models) > 0 {
+		model = models[0]
+	}
+	if card, ok = BuildScorecard(results, model); !ok {
+		http.Error(w, fmt.Sprintf("No results for model %q", model), http.StatusNotFound)
+		return card, nil, nil, false
+	}
+	return card, results, models, true
+}
+
+// scorecardHandler renders a model's scorecard, optionally against a second model:
+// /scorecard?model=gpt-4o&vs=llama3
+func scorecardHandler(w http.ResponseWriter, r *http.Request) {
+	card, results, models, ok := scorecardRequest(w, r)
+	if !ok {
+		return
+	}
+	var versus *Scorecard
+	if vs := r.URL.Query().Get("vs"); vs != "" && vs != card.Model {
+		other, ok := BuildScorecard(results, vs)
+		if !ok {
+			http.Error(w, fmt.Sprintf("No results for model %q", vs), http.StatusNotFound)
+			return
+		}
+		versus = &other
+	}
+	radar, hasRadar := scorecardRadar(card, versus)
+
+	data := struct {
+		Title    string
+		Subtitle string
+		Scorecard
+		Versus   *Scorecard
+		Models   []string
+		Radar    RadarChart
+		HasRadar bool
+		Weights  string
+	}{
+		Title:     card.DisplayName + " Scorecard",
+		Subtitle:  "Score profile against the average of every model",
+		Scorecard: card,
+		Versus:    versus,
+		Models:    models,
+		Radar:     radar,
+		HasRadar:  hasRadar,
+		Weights:   r.URL.Query().Get("weights"),
+	}
+	renderPage(w, r, "scorecard", scorecardTemplate, data)
+}
+
+// scorecardAPIHandler returns a model's scorecard as JSON
+func scorecardAPIHandler(w http.ResponseWriter, r *http.Request) {
+	card, _, _, ok := scorecardRequest(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(card); err != nil {
+		log.Printf("Error encoding JSON: %v", err)
+	}
+}
+
+const scorecardTemplate = `
+{{ define "style" }}
+        .scorecard-controls {
+            display: flex;
+            flex-wrap: wrap;
+            gap: 0.75rem;
+            align-items: center;
+        }
+        .scorecard-grid {
+            display: grid;
+            grid-template-columns: minmax(0, 3fr) minmax(0, 2fr);
+            gap: 1.5rem;
+        }
+        @media (max-width: 900px) {
+            .scorecard-grid {
+                grid-template-columns: 1fr;
+            }
+        }
+        .radar-chart {
+            max-width: 480px;
+            margin: 0 auto;
+            display: block;
+        }
+        .highlight-list {
+            list-style: none;
+            padding: 0;
+            margin: 0 0 1.5rem;
+        }
+        .highlight-list li {
+            display: flex;
+            justify-content: space-between;
+            padding: 0.4rem 0;
+            border-bottom: 1px solid var(--border-color);
+        }
+        td.num {
+            font-family: monospace;
+            text-align: right;
+            white-space: nowrap;
+        }
+        .delta-up {
+            color: var(--success);
+        }
+        .delta-down {
+            color: var(--error);
+        }
+{{ end }}
+
+{{ define "content" }}
+        <div class="panel">
+            <form method="get" action="/scorecard" class="scorecard-controls">
+                <label class="muted" for="model">Model</label>
+                <select id="model" name="model" onchange="this.form.submit()">
+                    {{ range .Models }}<option value="{{ . }}" {{ if eq . $.Model }}selected{{ end }}>{{ . }}</option>{{ end }}
+                </select>
+                <label class="muted" for="vs">Versus</label>
+                <select id="vs" name="vs" onchange="this.form.submit()">
+                    <option value="">-</option>
+                    {{ range .Models }}{{ if ne . $.Model }}<option value="{{ . }}" {{ if and $.Versus (eq . $.Versus.Model) }}selected{{ end }}>{{ . }}</option>{{ end }}{{ end }}
+                </select>
+                {{ with .Weights }}<input type="hidden" name="weights" value="{{ . }}">{{ end }}
+                <span class="muted" style="margin-left: auto;">{{ human .Tests }} results{{ if .Errors }}, {{ .Errors }} errored{{ end }} · combined
+                    <span class="score-badge {{ scoreClass .Combined.Mean }}">{{ printf "%.3f" .Combined.Mean }}</span>
+                    <span class="{{ if gt .Combined.Delta 0.0 }}delta-up{{ else if lt .Combined.Delta 0.0 }}delta-down{{ end }}" title="Global average {{ printf "%.3f" .Combined.Global }}">{{ printf "%+.3f" .Combined.Delta }}</span></span>
+            </form>
+        </div>
+
+        <div class="scorecard-grid">
+            <div class="panel chart">
+                <h2>Score Profile</h2>
+                {{ if .HasRadar }}
+                {{ template "radar-chart" .Radar }}
+                {{ else }}
+                <p class="muted">A radar chart needs at least three custom scores; this model has {{ len .Scores }}.</p>
+                {{ end }}
+            </div>
+            <div class="panel">
+                <h2>Strengths</h2>
+                {{ if .Strengths }}
+                <ul class="highlight-list">
+                    {{ range .Strengths }}<li><span class="mono">{{ .Score }}</span><span class="delta-up">{{ printf "%+.3f" .Delta }}</span></li>{{ end }}
+                </ul>
+                {{ else }}<p class="muted" style="margin-bottom: 1.5rem;">No score above the global average.</p>{{ end }}
+                <h2>Weaknesses</h2>
+                {{ if .Weaknesses }}
+                <ul class="highlight-list">
+                    {{ range .Weaknesses }}<li><span class="mono">{{ .Score }}</span><span class="delta-down">{{ printf "%+.3f" .Delta }}</span></li>{{ end }}
+                </ul>
+                {{ else }}<p class="muted">No score below the global average.</p>{{ end }}
+            </div>
+        </div>
+
+        {{ if .Scores }}
+        <div class="panel">
+            <h2>All Scores</h2>
+            <div style="overflow-x: auto;">
+            <table>
+                <thead>
+                    <tr>
+                        <th>Score</th>
+                        <th>{{ .DisplayName }}</th>
+                        {{ with .Versus }}<th>{{ .DisplayName }}</th>{{ end }}
+                        <th>Global Average</th>
+                        <th>Δ</th>
+                        <th>Results</th>
+                    </tr>
+                </thead>
+                <tbody>
+                    {{ range .Scores }}
+                    {{ $score := .Score }}
+                    <tr>
+                        <td class="mono">{{ .Score }}</td>
+                        <td><span class="score-badge {{ scoreClass .Mean }}">{{ printf "%.3f" .Mean }}</span></td>
+                        {{ with $.Versus }}<td class="num">{{ range .Scores }}{{ if eq .Score $score }}{{ printf "%.3f" .Mean }}{{ end }}{{ end }}</td>{{ end }}
+                        <td class="num">{{ printf "%.3f" .Global }}</td>
+                        <td class="num {{ if gt .Delta 0.0 }}delta-up{{ else if lt .Delta 0.0 }}delta-down{{ end }}">{{ printf "%+.3f" .Delta }}</td>
+                        <td class="num">{{ human .Count }}</td>
+                    </tr>
+                    {{ end }}
+                </tbody>
+            </table>
+            </div>
+            <p class="muted" style="margin-top: 1rem; font-size: 0.8125rem;">The global average is over every result with the score, all models included. Errored results have no scores.</p>
+        </div>
+        {{ end }}
+{{ end }}`
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBuildScorecard(t *testing.T) {
+	results := []EvalResult{
+		{Model: "a", Scores: ScoreBreakdown{Combined: 0.8, Custom: map[string]float64{"acc": 0.9, "faith": 0.4, "rel": 0.7}}},
+		{Model: "a", Error: "timeout"},
+		{Model: "b", Scores: ScoreBreakdown{Combined: 0.6, Custom: map[string]float64{"acc": 0.5, "faith": 0.8, "rel": 0.7, "tone": 1}}},
+	}
+	card, ok := BuildScorecard(results, "a")
+	if !ok || card.Tests != 2 || card.Errors != 1 {
+		t.Fatalf("card = %+v", card)
+	}
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	if !near(card.Combined.Mean, 0.8) || !near(card.Combined.Global, 0.7) {
+		t.Errorf("combined = %+v", card.Combined)
+	}
+	// tone is only b's, rel equals the average
+	if len(card.Scores) != 3 || card.Scores[0].Score != "acc" || !near(card.Scores[0].Delta, 0.2) {
+		t.Errorf("scores = %+v", card.Scores)
+	}
+	if len(card.Strengths) != 1 || card.Strengths[0].Score != "acc" || len(card.Weaknesses) != 1 || card.Weaknesses[0].Score != "faith" {
+		t.Errorf("strengths = %+v, weaknesses = %+v", card.Strengths, card.Weaknesses)
+	}
+	if _, ok := BuildScorecard(results, "c"); ok {
+		t.Error("scorecard for a model without results")
+	}
+
+	b, _ := BuildScorecard(results, "b")
+	radar, ok := scorecardRadar(card, &b)
+	if !ok || len(radar.Axes) != 3 || len(radar.Series) != 3 || radar.Series[1].Name != "b" || len(radar.Rings) != 4 {
+		t.Fatalf("radar = %+v", radar)
+	}
+	// acc is the first spoke, straight up from the center
+	if dot := radar.Series[0].Dots[0]; !near(dot.X, radar.Center) || !near(dot.Y, radar.Center-0.9*(radar.Center-radarMargin)) {
+		t.Errorf("acc dot = %+v", dot)
+	}
+	card.Scores = card.Scores[:2]
+	if _, ok := scorecardRadar(card, nil); ok {
+		t.Error("radar with two spokes")
+	}
+}
+
+func TestScorecardHandler(t *testing.T) {
+	withStore(t, `{"timestamp":"2025-01-01T10:00:00Z","model":"a","scores":{"combined":0.8,"acc":0.9,"faith":0.4,"rel":0.7}}
+{"timestamp":"2025-01-01T10:01:00Z","model":"b","scores":{"combined":0.6,"acc":0.5,"faith":0.8,"rel":0.7}}`)
+
+	rec := httptest.NewRecorder()
+	scorecardHandler(rec, httptest.NewRequest(http.MethodGet, "/scorecard?model=b&vs=a", nil))
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, `class="radar-chart"`) || !strings.Contains(body, "Global average") || !strings.Contains(body, "b Scorecard") {
+		t.Errorf("scorecard = %d %s", rec.Code, body)
+	}
+
+	rec = httptest.NewRecorder()
+	scorecardAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/api/scorecard", nil))
+	if !strings.Contains(rec.Body.String(), `"model":"a"`) {
+		t.Errorf("default model = %s", rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	scorecardHandler(rec, httptest.NewRequest(http.MethodGet, "/scorecard?model=c", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown model = %d", rec.Code)
+	}
+}
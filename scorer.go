@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// wasmScorer computes a custom score with a WebAssembly module, so metrics can be written in any
+// language that compiles to it (Rust, C, Zig, AssemblyScript, TinyGo...)
+//
+// A scorer module exports:
+//
+//	memory                        its linear memory
+//	alloc(len: i32) -> i32        where to put a record of len bytes
+//	score(ptr: i32, len: i32) -> f64  the score of the record at ptr, negative or NaN = no score
+//
+// The record is the result as JSON, as in the log files. Every record gets a fresh instance, so
+// nothing leaks between records and alloc never needs to free
+type wasmScorer struct {
+	name   string
+	module *wasmModule
+	alloc  uint32
+	score  uint32
+}
+
+// loadWasmScorer loads a scorer from name=file, the name defaults to the file name without .wasm
+func loadWasmScorer(spec string) (*wasmScorer, error) {
+	name, path, ok := strings.Cut(spec, "=")
+	if !ok {
+		path = spec
+		name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if name == "" || name == "combined" {
+		return nil, fmt.Errorf("invalid scorer name %q in %s", name, spec)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scorer: %w", err)
+	}
+	module, err := decodeWasm(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if export, ok := module.exports["memory"]; !ok || export.kind != wasmExportMemory {
+		return nil, fmt.Errorf("%s doesn't export its memory", path)
+	}
+	s := &wasmScorer{name: name, module: module}
+	alloc, allocType, ok := module.export("alloc")
+	if !ok || !allocType.equal(wasmFuncType{params: []byte{wasmI32}, results: []byte{wasmI32}}) {
+		return nil, fmt.Errorf("%s must export alloc(len: i32) -> i32", path)
+	}
+	score, scoreType, ok := module.export("score")
+	if !ok || !scoreType.equal(wasmFuncType{params: []byte{wasmI32, wasmI32}, results: []byte{wasmF64}}) {
+		return nil, fmt.Errorf("%s must export score(ptr: i32, len: i32) -> f64", path)
+	}
+	s.alloc, s.score = alloc, score
+	return s, nil
+}
+
+// Score runs the scorer on one result, ok is false when it gave no score
+func (s *wasmScorer) Score(result EvalResult) (score float64, ok bool, err error) {
+	record, err := json.Marshal(result)
+	if err != nil {
+		return 0, false, err
+	}
+	inst, err := s.module.instantiate()
+	if err != nil {
+		return 0, false, err
+	}
+	out, err := inst.call(s.alloc, uint64(len(record)))
+	if err != nil {
+		return 0, false, fmt.Errorf("alloc: %w", err)
+	}
+	ptr := uint32(out[0])
+	if uint64(ptr)+uint64(len(record)) > uint64(len(inst.memory)) {
+		return 0, false, fmt.Errorf("alloc returned %d, leaving no room for a %d byte record", ptr, len(record))
+	}
+	copy(inst.memory[ptr:], record)
+	if out, err = inst.call(s.score, uint64(ptr), uint64(len(record))); err != nil {
+		return 0, false, fmt.Errorf("score: %w", err)
+	}
+	score = math.Float64frombits(out[0])
+	if math.IsNaN(score) || score < 0 {
+		return 0, false, nil
+	}
+	return score, true, nil
+}
+
+// applyScorers adds the scores of every scorer to results, returning the failures as warnings
+// Intact results that carried an integrity hash get a new one: the scored result is a new record
+func applyScorers(results []EvalResult, scorers []*wasmScorer) (warnings []string) {
+	for i := range results {
+		r := &results[i]
+		orig := *r
+		changed := false
+		for _, s := range scorers {
+			score, ok, err := s.Score(*r)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("%s on result %d (test_id %q): %v", s.name, i+1, r.TestID, err))
+				continue
+			}
+			if !ok {
+				continue
+			}
+			if r.Scores.Custom == nil {
+				r.Scores.Custom = make(map[string]float64)
+			} else if !changed {
+				r.Scores.Custom = maps.Clone(r.Scores.Custom)
+			}
+			r.Scores.Custom[s.name] = score
+			changed = true
+		}
+		if changed {
+			*r = restampIfIntact(orig, *r)
+		}
+	}
+	return warnings
+}
+
+// runScore implements `goevals score`: results with the scores of WebAssembly scorers added, as JSONL
+func runScore(args []string) int {
+	fs := newFlagSet("score", "--wasm [name=]scorer.wasm [-o file] <source1> [source2] [...]",
+		"Adds custom scores computed by WebAssembly scorer modules to every result and writes the",
+		"results as JSONL. Scorers run sandboxed: no imports (files, network, clock), at most 16 MiB of",
+		"memory and 100M instructions per record")
+	var specs stringList
+	fs.Var(&specs, "wasm", "score with the module in `file`, as name=file or file (named after the file), repeatable")
+	output := fs.String("o", "-", "write to `file`, - for stdout")
+	sources := parseFlags(fs, args)
+	if len(sources) == 0 || len(specs) == 0 {
+		fs.Usage()
+		return 2
+	}
+	var scorers []*wasmScorer
+	for _, spec := range specs {
+		s, err := loadWasmScorer(spec)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		if slices.ContainsFunc(scorers, func(o *wasmScorer) bool { return o.name == s.name }) {
+			fmt.Fprintf(os.Stderr, "Error: two scorers named %s\n", s.name)
+			return 2
+		}
+		scorers = append(scorers, s)
+	}
+	results, err := commandResults(sources, "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	warnings := applyScorers(results, scorers)
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+
+	w := io.Writer(os.Stdout)
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		defer f.Close()
+		w = f
+	}
+	bw := bufio.NewWriter(w)
+	for _, result := range results {
+		line, err := json.Marshal(result)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		bw.Write(line)
+		bw.WriteByte('\n')
+	}
+	if err := bw.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if *output != "-" {
+		fmt.Fprintf(os.Stderr, "Wrote %s (%d results)\n", *output, len(results))
+	}
+	if len(warnings) > 0 {
+		fmt.Fprintf(os.Stderr, "%d score(s) failed\n", len(warnings))
+		return 1
+	}
+	return 0
+}
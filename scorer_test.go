@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// zFractionScorer scores a record by the share of its bytes that are the letter z,
+// and gives no score (-1) to records without one
+var zFractionScorer = []testWasmFunc{
+	// alloc: always address 1024
+	{params: i32, results: i32, export: "alloc", body: []byte{0x41, 0x80, 0x08, 0x0b}},
+	// score(ptr, len): locals i, count
+	{params: []byte{wasmI32, wasmI32}, results: []byte{wasmF64}, locals: []byte{wasmI32, wasmI32}, export: "score", body: []byte{
+		0x02, 0x40, 0x03, 0x40,
+		0x20, 0x02, 0x20, 0x01, 0x4f, 0x0d, 0x01, // i >= len: done
+		0x20, 0x00, 0x20, 0x02, 0x6a, 0x2d, 0x00, 0x00, 0x41, 0xfa, 0x00, 0x46, // mem[ptr+i] == 'z'
+		0x20, 0x03, 0x6a, 0x21, 0x03,
+		0x20, 0x02, 0x41, 0x01, 0x6a, 0x21, 0x02,
+		0x0c, 0x00, 0x0b, 0x0b,
+		0x20, 0x03, 0x45, 0x04, 0x40, 0x44, 0, 0, 0, 0, 0, 0, 0xf0, 0xbf, 0x0f, 0x0b, // no z: return -1
+		0x20, 0x03, 0xb8, 0x20, 0x01, 0xb8, 0xa3, 0x0b}},
+}
+
+func writeScorer(t *testing.T, funcs []testWasmFunc) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "zfrac.wasm")
+	if err := os.WriteFile(path, buildWasm(funcs, 1, ""), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestWasmScorer(t *testing.T) {
+	path := writeScorer(t, zFractionScorer)
+	s, err := loadWasmScorer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.name != "zfrac" {
+		t.Errorf("name = %q", s.name)
+	}
+
+	result := EvalResult{Model: "zzzz", TestID: "q1", Scores: ScoreBreakdown{Combined: 0.5}}
+	result.Integrity = contentHash(result)
+	record, _ := json.Marshal(result)
+	score, ok, err := s.Score(result)
+	if want := float64(bytes.Count(record, []byte("z"))) / float64(len(record)); err != nil || !ok || score != want {
+		t.Errorf("score = %v %v %v, want %v", score, ok, err, want)
+	}
+
+	tampered := result
+	tampered.Model = "zzzzzz" // Edited after hashing
+	results := []EvalResult{result, {Model: "m", TestID: "q2"}, tampered}
+	if warnings := applyScorers(results, []*wasmScorer{s}); len(warnings) != 0 {
+		t.Errorf("warnings = %v", warnings)
+	}
+	if results[0].Scores.Custom["zfrac"] != score || isTampered(results[0]) {
+		t.Errorf("scored = %+v", results[0])
+	}
+	// Scoring doesn't launder a tampered record
+	if _, ok := results[2].Scores.Custom["zfrac"]; !ok || !isTampered(results[2]) {
+		t.Errorf("tampered record scored = %+v", results[2])
+	}
+	if _, ok := results[1].Scores.Custom["zfrac"]; ok {
+		t.Errorf("record without a z scored: %+v", results[1])
+	}
+
+	// A module without the scorer exports
+	if _, err := loadWasmScorer("x=" + writeScorer(t, zFractionScorer[:1])); err == nil || !strings.Contains(err.Error(), "score(") {
+		t.Errorf("missing score = %v", err)
+	}
+}
+
+func TestRunScore(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "evals.jsonl")
+	os.WriteFile(input, []byte(`{"timestamp":"2025-01-01T10:00:00Z","model":"zephyr","test_id":"q1","scores":{"combined":0.5}}
+`), 0o644)
+	output := filepath.Join(dir, "scored.jsonl")
+	if code := runScore([]string{"--wasm", "letters=" + writeScorer(t, zFractionScorer), "-o", output, input}); code != 0 {
+		t.Fatalf("exit %d", code)
+	}
+	results, err := ParseJSONL(output)
+	if err != nil || len(results) != 1 || results[0].Scores.Custom["letters"] <= 0 || results[0].Scores.Combined != 0.5 {
+		t.Errorf("scored = %+v (%v)", results, err)
+	}
+}
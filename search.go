@@ -0,0 +1,450 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"html/template"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// searchFields are the free-text fields covered by full-text search, in snippet priority order
+var searchFields = []struct {
+	Name string
+	Get  func(EvalResult) string
+}{
+	{"question", func(r EvalResult) string { return r.Question }},
+	{"response", func(r EvalResult) string { return r.Response }},
+	{"expected", func(r EvalResult) string { return r.Expected }},
+	{"judge_factual_reasoning", func(r EvalResult) string { return r.JudgeFactualReasoning }},
+	{"judge_faithful_reasoning", func(r EvalResult) string { return r.JudgeFaithfulReasoning }},
+	{"judge_context_reasoning", func(r EvalResult) string { return r.JudgeContextReasoning }},
+	{"judge_reasoning", func(r EvalResult) string {
+		var texts []string
+		for _, score := range slices.Sorted(maps.Keys(r.JudgeReasoning)) {
+			texts = append(texts, r.JudgeReasoning[score])
+		}
+		return strings.Join(texts, "\n")
+	}},
+	{"retrieved_contexts", func(r EvalResult) string {
+		var texts []string
+		for _, rc := range r.RetrievedContexts {
+			texts = append(texts, rc.Text)
+		}
+		return strings.Join(texts, "\n")
+	}},
+	{"trace", func(r EvalResult) string {
+		var texts []string
+		for _, step := range r.Trace {
+			texts = append(texts, step.Tool, step.InputText(), step.OutputText(), step.Error)
+		}
+		return strings.Join(texts, "\n")
+	}},
+	{"test_id", func(r EvalResult) string { return r.TestID }},
+	{"model", func(r EvalResult) string { return r.Model }},
+}
+
+// searchDoc is one indexed result
+type searchDoc struct {
+	sum    uint64 // fnv hash of text, a changed result is reindexed
+	text   string // Lowercased searchable text, used to verify phrases and count hits
+	tokens []string
+}
+
+// searchIndex is an inverted index from tokens to result keys
+// It is kept in sync with the store on every search, only new or changed results are tokenized
+type searchIndex struct {
+	mu       sync.Mutex
+	docs     map[string]*searchDoc      // By resultKey
+	postings map[string]map[string]bool // Token -> result keys
+}
+
+// searchIdx is the shared index behind /tests?q= and /api/search
+var searchIdx = newSearchIndex()
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{docs: make(map[string]*searchDoc), postings: make(map[string]map[string]bool)}
+}
+
+// searchText joins the searchable fields of a result, lowercased
+func searchText(result EvalResult) string {
+	var sb strings.Builder
+	for _, field := range searchFields {
+		sb.WriteString(field.Get(result))
+		sb.WriteByte('\n')
+	}
+	return strings.Map(unicode.ToLower, sb.String())
+}
+
+// tokenize splits lowercased text into runs of letters and digits
+func tokenize(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Sync makes the index match results: new and changed results are added, missing ones dropped
+func (idx *searchIndex) Sync(results []EvalResult) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	seen := make(map[string]bool, len(results))
+	for _, result := range results {
+		key := resultKey(result)
+		seen[key] = true
+
+		text := searchText(result)
+		h := fnv.New64a()
+		h.Write([]byte(text))
+		sum := h.Sum64()
+		if doc, ok := idx.docs[key]; ok {
+			if doc.sum == sum {
+				continue
+			}
+			idx.remove(key)
+		}
+
+		doc := &searchDoc{sum: sum, text: text}
+		unique := make(map[string]bool)
+		for _, token := range tokenize(text) {
+			if unique[token] {
+				continue
+			}
+			unique[token] = true
+			doc.tokens = append(doc.tokens, token)
+			if idx.postings[token] == nil {
+				idx.postings[token] = make(map[string]bool)
+			}
+			idx.postings[token][key] = true
+		}
+		idx.docs[key] = doc
+	}
+
+	for key := range idx.docs {
+		if !seen[key] {
+			idx.remove(key)
+		}
+	}
+}
+
+// remove drops a document, must be called with idx.mu held
+func (idx *searchIndex) remove(key string) {
+	doc := idx.docs[key]
+	if doc == nil {
+		return
+	}
+	for _, token := range doc.tokens {
+		delete(idx.postings[token], key)
+		if len(idx.postings[token]) == 0 {
+			delete(idx.postings, token)
+		}
+	}
+	delete(idx.docs, key)
+}
+
+// searchTerm is one part of a search query
+type searchTerm struct {
+	Text   string   // Lowercased as typed, used for phrase checks, ranking and highlighting
+	Tokens []string // Index tokens that must all be present
+	Prefix bool     // Trailing * - the last token matches any token starting with it
+}
+
+// parseSearch splits a query into terms: words, "quoted phrases" and prefix* words
+// Every term must match (AND). A word with punctuation like gpt-4 is treated as a phrase
+func parseSearch(q string) []searchTerm {
+	var terms []searchTerm
+	add := func(text string, prefix bool) {
+		text = strings.Map(unicode.ToLower, strings.TrimSpace(text))
+		tokens := tokenize(text)
+		if len(tokens) == 0 {
+			return
+		}
+		if len(tokens) == 1 {
+			text = tokens[0] // Surrounding punctuation like "error:" doesn't matter
+		}
+		terms = append(terms, searchTerm{Text: text, Tokens: tokens, Prefix: prefix && len(tokens) == 1})
+	}
+
+	for q != "" {
+		q = strings.TrimLeft(q, " \t\n")
+		if q == "" {
+			break
+		}
+		if q[0] == '"' {
+			end := strings.IndexByte(q[1:], '"')
+			if end < 0 {
+				add(q[1:], false)
+				break
+			}
+			add(q[1:end+1], false)
+			q = q[end+2:]
+			continue
+		}
+		end := strings.IndexAny(q, " \t\n")
+		if end < 0 {
+			end = len(q)
+		}
+		word := q[:end]
+		add(strings.TrimSuffix(word, "*"), strings.HasSuffix(word, "*"))
+		q = q[end:]
+	}
+	return terms
+}
+
+// matchTerm returns the keys of documents containing term, must be called with idx.mu held
+func (idx *searchIndex) matchTerm(term searchTerm) map[string]bool {
+	var matched map[string]bool
+	for i, token := range term.Tokens {
+		keys := idx.postings[token]
+		if term.Prefix && i == len(term.Tokens)-1 {
+			keys = make(map[string]bool)
+			for indexed, postings := range idx.postings {
+				if strings.HasPrefix(indexed, token) {
+					for key := range postings {
+						keys[key] = true
+					}
+				}
+			}
+		}
+		if matched == nil {
+			matched = make(map[string]bool, len(keys))
+			for key := range keys {
+				matched[key] = true
+			}
+			continue
+		}
+		for key := range matched {
+			if !keys[key] {
+				delete(matched, key)
+			}
+		}
+	}
+
+	// Multi-token terms are phrases: the tokens must also appear together in that order
+	if len(term.Tokens) > 1 {
+		for key := range matched {
+			if !strings.Contains(idx.docs[key].text, term.Text) {
+				delete(matched, key)
+			}
+		}
+	}
+	return matched
+}
+
+// Search returns the results containing every term of q, most hits first, then newest first
+// results must be the set the index was last synced with
+func (idx *searchIndex) Search(results []EvalResult, q string) []EvalResult {
+	terms := parseSearch(q)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	idx.mu.Lock()
+	var matched map[string]bool
+	for _, term := range terms {
+		keys := idx.matchTerm(term)
+		if matched == nil {
+			matched = keys
+		} else {
+			for key := range matched {
+				if !keys[key] {
+					delete(matched, key)
+				}
+			}
+		}
+		if len(matched) == 0 {
+			break
+		}
+	}
+
+	type hit struct {
+		result EvalResult
+		count  int
+	}
+	var hits []hit
+	for _, result := range results {
+		key := resultKey(result)
+		if !matched[key] {
+			continue
+		}
+		count := 0
+		for _, term := range terms {
+			count += strings.Count(idx.docs[key].text, term.Text)
+		}
+		delete(matched, key) // Duplicates across sources show up once
+		hits = append(hits, hit{result, count})
+	}
+	idx.mu.Unlock()
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].count != hits[j].count {
+			return hits[i].count > hits[j].count
+		}
+		return hits[i].result.Timestamp > hits[j].result.Timestamp
+	})
+	found := make([]EvalResult, len(hits))
+	for i, h := range hits {
+		found[i] = h.result
+	}
+	return found
+}
+
+// searchStore runs a full-text search over the store and applies the filters in filter
+func searchStore(ctx context.Context, filter Query, q string) ([]EvalResult, error) {
+	all, err := store.Query(ctx, Query{})
+	if err != nil {
+		return nil, err
+	}
+	searchIdx.Sync(all)
+
+	var matched []EvalResult
+	for _, result := range searchIdx.Search(all, q) {
+		if filter.Match(result) {
+			matched = append(matched, result)
+		}
+	}
+	return matched, nil
+}
+
+// snippetRadius is how many characters of context a snippet shows around the first hit
+const snippetRadius = 60
+
+// findSnippet returns the first field mentioning a search term and an excerpt around the hit
+func findSnippet(result EvalResult, terms []searchTerm) (field, snippet string) {
+	for _, f := range searchFields {
+		text := []rune(f.Get(result))
+		lower := []rune(strings.Map(unicode.ToLower, string(text)))
+		for _, term := range terms {
+			pos := runeIndex(lower, []rune(term.Text))
+			if pos < 0 {
+				continue
+			}
+			start := max(pos-snippetRadius, 0)
+			end := min(pos+len([]rune(term.Text))+snippetRadius, len(text))
+			snippet = strings.Join(strings.Fields(string(text[start:end])), " ")
+			if start > 0 {
+				snippet = "…" + snippet
+			}
+			if end < len(text) {
+				snippet += "…"
+			}
+			return f.Name, snippet
+		}
+	}
+	return "", ""
+}
+
+// highlight escapes text and wraps every occurrence of a search term in <mark>
+func highlight(text string, terms []searchTerm) template.HTML {
+	runes := []rune(text)
+	lower := []rune(strings.Map(unicode.ToLower, text))
+	marked := make([]bool, len(runes))
+	for _, term := range terms {
+		needle := []rune(term.Text)
+		for from := 0; from < len(lower); {
+			pos := runeIndex(lower[from:], needle)
+			if pos < 0 {
+				break
+			}
+			for i := from + pos; i < from+pos+len(needle); i++ {
+				marked[i] = true
+			}
+			from += pos + len(needle)
+		}
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && marked[j] == marked[i] {
+			j++
+		}
+		chunk := template.HTMLEscapeString(string(runes[i:j]))
+		if marked[i] {
+			chunk = "<mark>" + chunk + "</mark>"
+		}
+		sb.WriteString(chunk)
+		i = j
+	}
+	return template.HTML(sb.String())
+}
+
+// runeIndex is strings.Index over runes, so positions line up with the original text
+func runeIndex(haystack, needle []rune) int {
+	if len(needle) == 0 {
+		return -1
+	}
+	for i := 0; i+len(needle) <= len(haystack); i++ {
+		match := true
+		for j := range needle {
+			if haystack[i+j] != needle[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
+
+// searchAPIHandler returns results matching ?q= as JSON, optionally filtered by model, run_id and source
+func searchAPIHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	if strings.TrimSpace(q) == "" {
+		http.Error(w, "missing q parameter", http.StatusBadRequest)
+		return
+	}
+	limit := 50
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > 1000 {
+			http.Error(w, "limit must be between 1 and 1000", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	matched, err := searchStore(r.Context(), Query{
+		Model:   r.URL.Query().Get("model"),
+		RunID:   r.URL.Query().Get("run_id"),
+		Origin:  r.URL.Query().Get("source"),
+		Dataset: r.URL.Query().Get("dataset"),
+	}, q)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	type hit struct {
+		Field   string     `json:"field"`
+		Snippet string     `json:"snippet"`
+		Source  string     `json:"source"`
+		Result  EvalResult `json:"result"`
+	}
+	terms := parseSearch(q)
+	response := struct {
+		Query   string `json:"query"`
+		Total   int    `json:"total"`
+		Results []hit  `json:"results"`
+	}{Query: q, Total: len(matched), Results: []hit{}}
+	for _, result := range matched[:min(limit, len(matched))] {
+		field, snippet := findSnippet(result, terms)
+		response.Results = append(response.Results, hit{field, snippet, result.Origin, result})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding JSON: %v", err)
+	}
+}
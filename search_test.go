@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSearchIndex(t *testing.T) {
+	results := []EvalResult{
+		{Timestamp: "2025-01-01T10:00:00Z", Model: "a", TestID: "t1", Question: "Who founded Acme Corp?", Response: "Acme Corp was founded by Jane Doe."},
+		{Timestamp: "2025-01-02T10:00:00Z", Model: "b", TestID: "t2", Question: "Who founded Acme Corp?", Response: "I don't know.", JudgeFactualReasoning: "Response says ERROR: context window exceeded"},
+		{Timestamp: "2025-01-03T10:00:00Z", Model: "a", TestID: "t3", Question: "What is a timeout?", Response: "A timeouts happens when..."},
+	}
+	idx := newSearchIndex()
+	idx.Sync(results)
+
+	ids := func(found []EvalResult) []string {
+		var out []string
+		for _, r := range found {
+			out = append(out, r.TestID)
+		}
+		return out
+	}
+	tests := []struct {
+		q    string
+		want []string
+	}{
+		{"acme", []string{"t1", "t2"}},                // t1 mentions it twice, ranks first
+		{"ACME jane", []string{"t1"}},                 // Terms are ANDed, case-insensitive
+		{`"context window exceeded"`, []string{"t2"}}, // Phrase in judge reasoning
+		{`"window context"`, nil},                     // Phrase tokens out of order
+		{"error:", []string{"t2"}},                    // Punctuation is ignored
+		{"timeout*", []string{"t3"}},                  // Prefix
+		{"time", nil},                                 // Whole tokens only without *
+		{"don't", []string{"t2"}},                     // Word with punctuation is a phrase
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := ids(idx.Search(results, tt.q)); !slices.Equal(got, tt.want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.q, got, tt.want)
+		}
+	}
+
+	// Changed and removed results are reindexed
+	results[0].Response = "Founded by John Roe."
+	idx.Sync(results[:1])
+	if got := ids(idx.Search(results, "jane")); got != nil {
+		t.Errorf("stale token after change: %v", got)
+	}
+	if got := ids(idx.Search(results, "context")); got != nil {
+		t.Errorf("removed result still found: %v", got)
+	}
+	if len(idx.docs) != 1 || idx.postings["context"] != nil {
+		t.Errorf("index not cleaned up: %d docs", len(idx.docs))
+	}
+}
+
+func TestSearchSnippets(t *testing.T) {
+	terms := parseSearch(`"window exceeded" <b>`)
+	result := EvalResult{Question: "q", JudgeContextReasoning: "The model hit <b>context WINDOW exceeded</b> twice"}
+	field, snippet := findSnippet(result, terms)
+	if field != "judge_context_reasoning" || snippet != result.JudgeContextReasoning {
+		t.Fatalf("findSnippet = %q, %q", field, snippet)
+	}
+	want := `The model hit &lt;<mark>b</mark>&gt;context <mark>WINDOW exceeded</mark>&lt;/<mark>b</mark>&gt; twice`
+	if got := string(highlight(snippet, terms)); got != want {
+		t.Errorf("highlight =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSearchJudgeReasoning(t *testing.T) {
+	result := EvalResult{Question: "q", JudgeReasoning: map[string]string{"tone": "Too informal for support"}}
+	if field, snippet := findSnippet(result, parseSearch("informal")); field != "judge_reasoning" || snippet != "Too informal for support" {
+		t.Errorf("findSnippet = %q, %q", field, snippet)
+	}
+}
+
+func TestSearchAPI(t *testing.T) {
+	useStore(t, NewMemoryStore([]Source{&fileSource{path: "evals.jsonl"}}))
+
+	rec := httptest.NewRecorder()
+	searchAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/api/search?q=shakespeare&model=gemma2:2b&limit=1", nil))
+	var resp struct {
+		Total   int `json:"total"`
+		Results []struct {
+			Field   string     `json:"field"`
+			Snippet string     `json:"snippet"`
+			Result  EvalResult `json:"result"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("%v: %s", err, rec.Body)
+	}
+	if resp.Total == 0 || len(resp.Results) != 1 || resp.Results[0].Result.Model != "gemma2:2b" || resp.Results[0].Field == "" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	rec = httptest.NewRecorder()
+	searchAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/api/search", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("missing q: %d", rec.Code)
+	}
+}
+
+func TestSearchRetrievedContexts(t *testing.T) {
+	result := EvalResult{Question: "q", RetrievedContexts: []RetrievedContext{{Text: "Unrelated"}, {Text: "Refund policy: 30 days"}}}
+	if field, snippet := findSnippet(result, parseSearch("refund")); field != "retrieved_contexts" || !strings.Contains(snippet, "Refund policy: 30 days") {
+		t.Errorf("findSnippet = %q, %q", field, snippet)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// latencyBudgets are per-model latency budgets in ms, "*" applies to models without their own
+// entry; nil = no SLA
+type latencyBudgets map[string]int64
+
+// latencySLAs are the budgets from --latency-sla, see slasFor for per-dashboard ones
+var latencySLAs latencyBudgets
+
+// parseLatencySLA reads "gpt-4:2000,qwen:4b:800,*:1500" into a model -> ms map
+// The budget follows the last colon, so model names may contain colons; it is a number of
+// milliseconds or a Go duration ("1.5s"). An empty value means no SLA.
+func parseLatencySLA(value string) (latencyBudgets, error) {
+	slas := make(latencyBudgets)
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		i := strings.LastIndex(pair, ":")
+		if i <= 0 || strings.TrimSpace(pair[:i]) == "" {
+			return nil, fmt.Errorf("invalid latency SLA %q: expected model:ms", pair)
+		}
+		model, raw := strings.TrimSpace(pair[:i]), strings.TrimSpace(pair[i+1:])
+		ms, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			d, derr := time.ParseDuration(raw)
+			if derr != nil {
+				return nil, fmt.Errorf("invalid latency SLA %q: must be milliseconds or a duration like 1.5s", pair)
+			}
+			ms = d.Milliseconds()
+		}
+		if ms <= 0 {
+			return nil, fmt.Errorf("invalid latency SLA %q: must be positive", pair)
+		}
+		slas[model] = ms
+	}
+	if len(slas) == 0 {
+		return nil, nil
+	}
+	return slas, nil
+}
+
+// formatLatencySLA is the inverse of parseLatencySLA, sorted by model with "*" last
+func formatLatencySLA(slas latencyBudgets) string {
+	models := make([]string, 0, len(slas))
+	for model := range slas {
+		models = append(models, model)
+	}
+	sort.Slice(models, func(i, j int) bool {
+		if (models[i] == "*") != (models[j] == "*") {
+			return models[j] == "*"
+		}
+		return models[i] < models[j]
+	})
+	pairs := make([]string, len(models))
+	for i, model := range models {
+		pairs[i] = model + ":" + strconv.FormatInt(slas[model], 10)
+	}
+	return strings.Join(pairs, ",")
+}
+
+// slaFor returns the latency budget of a model, ok is false when it has none
+func (b latencyBudgets) slaFor(model string) (ms int64, ok bool) {
+	if ms, ok := b[model]; ok {
+		return ms, true
+	}
+	ms, ok = b["*"]
+	return ms, ok
+}
+
+// breaches reports whether a result took longer than its model's budget
+// Results without a response time or an SLA never breach
+func (b latencyBudgets) breaches(result EvalResult) bool {
+	ms, ok := b.slaFor(result.Model)
+	return ok && result.ResponseTimeMS > ms
+}
+
+// filterBreaches keeps the results that breach their model's latency SLA
+func (b latencyBudgets) filterBreaches(results []EvalResult) []EvalResult {
+	var breaches []EvalResult
+	for _, result := range results {
+		if b.breaches(result) {
+			breaches = append(breaches, result)
+		}
+	}
+	return breaches
+}
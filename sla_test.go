@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseLatencySLA(t *testing.T) {
+	slas, err := parseLatencySLA("gpt-4:2000, qwen:4b:1.5s,*:800")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slas["gpt-4"] != 2000 || slas["qwen:4b"] != 1500 || slas["*"] != 800 {
+		t.Errorf("slas = %v", slas)
+	}
+	if got := formatLatencySLA(slas); got != "gpt-4:2000,qwen:4b:1500,*:800" {
+		t.Errorf("formatLatencySLA = %q", got)
+	}
+	if slas, err := parseLatencySLA(""); slas != nil || err != nil {
+		t.Errorf("empty = %v, %v", slas, err)
+	}
+	for _, bad := range []string{"gpt-4", ":100", "gpt-4:fast", "gpt-4:0", "gpt-4:-5"} {
+		if _, err := parseLatencySLA(bad); err == nil {
+			t.Errorf("parseLatencySLA(%q) accepted", bad)
+		}
+	}
+}
+
+func TestLatencySLA(t *testing.T) {
+	defer func(old latencyBudgets) { latencySLAs = old }(latencySLAs)
+	latencySLAs = latencyBudgets{"fast": 100, "*": 1000}
+
+	results := []EvalResult{
+		{Model: "fast", ResponseTimeMS: 90, Scores: ScoreBreakdown{Combined: 0.9}},
+		{Model: "fast", ResponseTimeMS: 150, Scores: ScoreBreakdown{Combined: 0.8}},
+		{Model: "fast", ResponseTimeMS: 0, Scores: ScoreBreakdown{Combined: 0.7}}, // Untimed, never breaches
+		{Model: "slow", ResponseTimeMS: 900, Scores: ScoreBreakdown{Combined: 0.6}},
+	}
+	if breaches := latencySLAs.filterBreaches(results); len(breaches) != 1 || breaches[0].ResponseTimeMS != 150 {
+		t.Errorf("breaches = %+v", breaches)
+	}
+
+	stats := CalculateStats(results, latencySLAs)
+	fast := stats.ModelStats["fast"]
+	if fast.SLAMS != 100 || fast.SLATimed != 2 || fast.WithinSLA != 0.5 {
+		t.Errorf("fast = %+v", fast)
+	}
+	if slow := stats.ModelStats["slow"]; slow.SLAMS != 1000 || slow.WithinSLA != 1 {
+		t.Errorf("slow = %+v", slow)
+	}
+
+	withStore(t, `{"timestamp":"2025-01-01T10:00:00Z","model":"fast","response_time_ms":90,"scores":{"combined":0.9}}
+{"timestamp":"2025-01-01T10:01:00Z","model":"fast","response_time_ms":150,"scores":{"combined":0.8}}`)
+	rec := httptest.NewRecorder()
+	dashboardHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if body := rec.Body.String(); !strings.Contains(body, "Within SLA") || !strings.Contains(body, "sla=breach") {
+		t.Error("dashboard is missing the Within SLA column")
+	}
+	rec = httptest.NewRecorder()
+	testsHandler(rec, httptest.NewRequest(http.MethodGet, "/tests?sla=breach", nil))
+	if body := rec.Body.String(); !strings.Contains(body, "(1 tests)") || !strings.Contains(body, "sla-breach") {
+		t.Errorf("tests page did not filter to the breach: %d", rec.Code)
+	}
+}
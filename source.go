@@ -0,0 +1,533 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Source is anything that can produce eval results on reload
+// Local JSONL files are the default, other schemes (redis://, postgres://, s3://, https://) plug in here
+type Source interface {
+	Name() string                // Human readable name used in logs
+	Load() ([]EvalResult, error) // Returns the full current result set of this source
+}
+
+// Appender is a source that can also store new results (target of /api/ingest)
+type Appender interface {
+	Append(results []EvalResult) (int, error) // Returns how many results were stored
+}
+
+// Remover is a source that can delete results (used by archiving to keep the hot store small)
+type Remover interface {
+	Remove(keys map[string]bool) (int, error) // Deletes results whose resultKey is in keys, returns how many
+}
+
+// InvalidLineCounter is a source that knows how many lines of its last load were skipped as invalid
+type InvalidLineCounter interface {
+	InvalidLines() int
+}
+
+// Generational is a source whose loads only add results after those of the previous load until
+// its generation changes (a truncated or rewritten file), so Stats can count just the new ones
+type Generational interface {
+	LoadGeneration() ([]EvalResult, uint64, error) // Load, with the generation of the results
+}
+
+// fileSource reads results from a local JSONL file
+// Files on network mounts can briefly vanish or fail to read, so a failed read is retried,
+// and while the file stays unreadable the last good snapshot is served with the error
+// Reads parse only the lines appended since the previous read, see read
+type fileSource struct {
+	path string
+
+	mu        sync.Mutex
+	snapshot  []EvalResult // Results of the last successful read
+	invalid   int          // Lines of the snapshot skipped as invalid
+	loaded    bool         // snapshot is valid (the file has been read at least once)
+	failures  int          // Consecutive failed loads
+	lastErr   error
+	nextRetry time.Time // No reads before this while failing
+
+	parsed     []EvalResult // Results of the complete lines up to cursor.offset, the start of snapshot
+	cursor     fileCursor
+	generation uint64 // Bumped whenever the file is parsed from the start again
+}
+
+// fileCursor is how far a fileSource has parsed its file
+type fileCursor struct {
+	info    os.FileInfo // The file as of the last read
+	offset  int64       // End of the last complete (newline terminated) line parsed
+	lines   int         // Lines before offset, to number warnings
+	tail    []byte      // Last bytes before offset, to notice a file rewritten to a larger size
+	invalid int         // Invalid lines before offset
+}
+
+// fileTailSize is how many bytes before the cursor are compared to tell an appended file
+// from a rewritten one
+const fileTailSize = 256
+
+// Retry timings for unreadable files: quick retries within one load for hiccups,
+// then exponential backoff between loads so a dead mount isn't hammered on every request
+const (
+	fileReadAttempts   = 3
+	fileReadRetryDelay = 50 * time.Millisecond
+	fileMinBackoff     = time.Second
+	fileMaxBackoff     = time.Minute
+)
+
+func (s *fileSource) Name() string { return s.path }
+
+func (s *fileSource) Load() ([]EvalResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// LoadGeneration is Load with a generation that changes whenever the file is parsed from the
+// start again instead of only its new lines
+func (s *fileSource) LoadGeneration() ([]EvalResult, uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	results, err := s.load()
+	return results, s.generation, err
+}
+
+// load reads the file, or serves the snapshot while backing off; must be called with s.mu held
+func (s *fileSource) load() ([]EvalResult, error) {
+	now := time.Now()
+	if s.failures > 0 && now.Before(s.nextRetry) {
+		return s.snapshot, s.lastErr
+	}
+
+	err := s.read()
+	// A file that was never readable probably doesn't exist yet, don't stall requests on it
+	for attempt := 1; err != nil && s.loaded && attempt < fileReadAttempts && !errors.Is(err, os.ErrPermission); attempt++ {
+		time.Sleep(time.Duration(attempt) * fileReadRetryDelay)
+		err = s.read()
+	}
+	if err == nil {
+		s.failures, s.lastErr = 0, nil
+		return s.snapshot, nil
+	}
+
+	s.failures++
+	backoff := min(fileMinBackoff<<min(s.failures-1, 10), fileMaxBackoff)
+	s.nextRetry = now.Add(backoff)
+	s.lastErr = err
+	if s.loaded {
+		s.lastErr = fmt.Errorf("%w (serving last good snapshot, retrying in %s)", err, backoff)
+	}
+	return s.snapshot, s.lastErr
+}
+
+// read brings the snapshot up to date with the file, must be called with s.mu held
+// An unchanged size and modification time mean nothing to do; a file that grew and still has the
+// bytes before the cursor only gets its new lines parsed; anything else (a truncated, rewritten
+// or replaced file) is parsed from the start. A last line without a newline may still be being
+// written: it is served but parsed again next time
+func (s *fileSource) read() error {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	cur, parsed := s.cursor, s.parsed
+	switch {
+	case !s.loaded || !os.SameFile(info, cur.info) || info.Size() < cur.offset:
+		cur, parsed = fileCursor{}, nil
+	case info.Size() == cur.info.Size() && info.ModTime().Equal(cur.info.ModTime()):
+		return nil
+	case info.Size() == cur.info.Size() || !cur.tailIntact(f):
+		cur, parsed = fileCursor{}, nil
+	}
+	// Served results that aren't kept: all of them, or those of a last line without a newline
+	dropped := len(s.snapshot) > len(parsed)
+
+	var pending []EvalResult // From a last line without a newline
+	var last []byte          // Last complete line
+	pendingInvalid := 0
+	r := bufio.NewReader(io.NewSectionReader(f, cur.offset, info.Size()-cur.offset))
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("error reading file: %w", err)
+		}
+		if len(line) == 0 {
+			break
+		}
+		complete := line[len(line)-1] == '\n'
+		result, blank, decodeErr := decodeJSONLLine(line, cur.lines+1)
+		switch {
+		case !complete && decodeErr != nil:
+			pendingInvalid++
+		case !complete && !blank:
+			pending = append(pending, result)
+		case complete:
+			cur.lines++
+			cur.offset += int64(len(line))
+			last = line
+			if decodeErr != nil {
+				log.Printf("Warning: Skipping invalid line %d of %s: %v", cur.lines, s.path, decodeErr)
+				cur.invalid++
+			} else if !blank {
+				parsed = append(parsed, result)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	cur.info = info
+	if last != nil {
+		cur.tail = bytes.Clone(last[max(len(last)-fileTailSize, 0):])
+	}
+
+	s.parsed, s.cursor, s.loaded = parsed, cur, true
+	s.snapshot, s.invalid = parsed, cur.invalid+pendingInvalid
+	if len(pending) > 0 {
+		// A copy, parsed grows in place on the next read
+		s.snapshot = append(slices.Clip(parsed), pending...)
+	}
+	if dropped {
+		s.generation++
+	}
+	return nil
+}
+
+// tailIntact reports whether f still has the bytes the cursor saw before its offset
+func (c fileCursor) tailIntact(f *os.File) bool {
+	buf := make([]byte, len(c.tail))
+	_, err := f.ReadAt(buf, c.offset-int64(len(c.tail)))
+	return err == nil && bytes.Equal(buf, c.tail)
+}
+
+// InvalidLines returns how many lines of the served snapshot were skipped as invalid
+func (s *fileSource) InvalidLines() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.invalid
+}
+
+// resetBackoff makes the next Load read the file again, used after writing to it
+func (s *fileSource) resetBackoff() {
+	s.mu.Lock()
+	s.nextRetry = time.Time{}
+	s.mu.Unlock()
+}
+
+// Append writes results as JSONL lines at the end of the file
+// The file is locked so other goevals processes writing the same file don't interleave lines
+func (s *fileSource) Append(results []EvalResult) (int, error) {
+	defer s.resetBackoff()
+	unlock, err := lockFile(s.path)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open %s: %w", s.path, err)
+	}
+	defer f.Close()
+
+	for i, result := range results {
+		line, err := encodeResult(result)
+		if err == nil {
+			_, err = f.Write(append(line, '\n'))
+		}
+		if err != nil {
+			return i, fmt.Errorf("failed to write %s: %w", s.path, err)
+		}
+	}
+	return len(results), f.Sync()
+}
+
+// Remove rewrites the file without the given results
+// The new file is written next to the old one and renamed over it; if the file grew in the
+// meantime (a writer appended lines) the rewrite is retried so those lines aren't lost
+func (s *fileSource) Remove(keys map[string]bool) (int, error) {
+	defer s.resetBackoff()
+	unlock, err := lockFile(s.path)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	for attempt := 0; attempt < 3; attempt++ {
+		data, err := os.ReadFile(s.path)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read %s: %w", s.path, err)
+		}
+
+		var kept bytes.Buffer
+		removed := 0
+		for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+			if result, err := decodeResult(line); err == nil && keys[resultKey(result)] {
+				removed++
+				continue
+			}
+			kept.Write(line) // Blank and invalid lines are kept untouched
+		}
+		if removed == 0 {
+			return 0, nil
+		}
+
+		tmp := s.path + ".tmp"
+		if err := os.WriteFile(tmp, kept.Bytes(), 0644); err != nil {
+			return 0, fmt.Errorf("failed to write %s: %w", tmp, err)
+		}
+		if info, err := os.Stat(s.path); err == nil && info.Size() != int64(len(data)) {
+			os.Remove(tmp)
+			continue
+		}
+		if err := replaceFile(tmp, s.path); err != nil {
+			return 0, fmt.Errorf("failed to replace %s: %w", s.path, err)
+		}
+		return removed, nil
+	}
+	return 0, fmt.Errorf("%s kept changing while removing results, try again later", s.path)
+}
+
+// globSource loads every file matching a glob pattern, expanded again on every load so
+// new run files show up without a restart. Windows shells don't expand wildcards, so
+// goevals does it itself; a directory argument means every *.jsonl file in it
+type globSource struct {
+	pattern string
+
+	mu         sync.Mutex
+	files      map[string]*fileSource // By path, keeps each file's snapshot and backoff between loads
+	layout     []globFile             // The files of the last load, in order
+	generation uint64                 // Bumped when a load doesn't just add results at the end
+}
+
+// globFile is what one matching file contributed to a globSource load
+type globFile struct {
+	path       string
+	generation uint64
+	results    int
+}
+
+func (s *globSource) Name() string { return s.pattern }
+
+// Load returns the results of all matching files, each labeled with its own path
+// Files that fail to load contribute their last good snapshot and their error
+func (s *globSource) Load() ([]EvalResult, error) {
+	results, _, err := s.LoadGeneration()
+	return results, err
+}
+
+// LoadGeneration is Load with a generation that changes whenever a load did more than add results
+// at the end, e.g. when a file other than the last one grew or a new file sorts before the others
+func (s *globSource) LoadGeneration() ([]EvalResult, uint64, error) {
+	matches, err := filepath.Glob(s.pattern)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid pattern %s: %w", s.pattern, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var all []EvalResult
+	var errs []error
+	var layout []globFile
+	seen := make(map[string]bool)
+	for _, path := range matches {
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			continue
+		}
+		seen[path] = true
+		file := s.files[path]
+		if file == nil {
+			file = &fileSource{path: path}
+			s.files[path] = file
+		}
+
+		results, generation, err := file.LoadGeneration()
+		if err != nil {
+			errs = append(errs, err)
+		}
+		for _, result := range results {
+			result.Origin = path
+			all = append(all, result)
+		}
+		layout = append(layout, globFile{path: path, generation: generation, results: len(results)})
+	}
+	for path := range s.files {
+		if !seen[path] {
+			delete(s.files, path)
+		}
+	}
+	if !globExtends(s.layout, layout) {
+		s.generation++
+	}
+	s.layout = layout
+	return all, s.generation, errors.Join(errs...)
+}
+
+// globExtends reports whether a load of the files in next only added results after those of
+// prev: the same files in the same order with the same results, except that the last one may have
+// grown and new files may follow it
+func globExtends(prev, next []globFile) bool {
+	if len(next) < len(prev) {
+		return false
+	}
+	for i, f := range prev {
+		g := next[i]
+		if g.path != f.path || g.generation != f.generation || g.results < f.results || g.results > f.results && i < len(prev)-1 {
+			return false
+		}
+	}
+	return true
+}
+
+// InvalidLines returns how many lines of the matching files were skipped as invalid
+func (s *globSource) InvalidLines() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, file := range s.files {
+		n += file.InvalidLines()
+	}
+	return n
+}
+
+// NewSource creates a Source from a command line argument
+// Plain paths are JSONL files, directories and wildcards load many files, URLs are dispatched by scheme
+func NewSource(arg string) (Source, error) {
+	switch {
+	case strings.HasPrefix(arg, "redis://"), strings.HasPrefix(arg, "rediss://"):
+		return NewRedisStreamSource(arg)
+	case strings.HasPrefix(arg, "postgres://"), strings.HasPrefix(arg, "postgresql://"):
+		return NewPostgresStore(arg)
+	case strings.HasPrefix(arg, "s3://"), strings.HasPrefix(arg, "gs://"):
+		return NewObjectSource(arg)
+	case strings.HasPrefix(arg, "http://"), strings.HasPrefix(arg, "https://"):
+		return NewHTTPSource(arg)
+	case strings.Contains(arg, "://"):
+		return nil, fmt.Errorf("unsupported source scheme: %s", arg)
+	}
+
+	path := filepath.Clean(arg)
+	info, err := os.Stat(path)
+	switch {
+	case err == nil && info.IsDir():
+		return &globSource{pattern: filepath.Join(path, "*.jsonl"), files: make(map[string]*fileSource)}, nil
+	case err != nil && strings.ContainsAny(path, "*?["):
+		// Only when no file has this literal name, brackets are legal in file names
+		if _, err := filepath.Match(filepath.Base(path), ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %w", arg, err)
+		}
+		return &globSource{pattern: path, files: make(map[string]*fileSource)}, nil
+	default:
+		return &fileSource{path: path}, nil
+	}
+}
+
+// sourcesHandler shows the load state of every source
+func sourcesHandler(w http.ResponseWriter, r *http.Request) {
+	store.Stats(r.Context()) // Refresh the status with a load, failures are recorded there
+	statuses := store.Sources(r.Context())
+	subtitle := fmt.Sprintf("All %d source(s) healthy", len(statuses))
+	if failing := failingSources(r.Context()); len(failing) > 0 {
+		subtitle = fmt.Sprintf("%d of %d source(s) failing", len(failing), len(statuses))
+	}
+	data := struct {
+		Title    string
+		Subtitle string
+		Sources  []SourceStatus
+	}{"Sources", subtitle, statuses}
+
+	renderPage(w, r, "sources", sourcesTemplate, data)
+}
+
+// failingSources returns the names of sources whose last load failed
+func failingSources(ctx context.Context) []string {
+	var names []string
+	for _, st := range store.Sources(ctx) {
+		if !st.Healthy && st.Failures > 0 {
+			names = append(names, st.Name)
+		}
+	}
+	return names
+}
+
+// sourcesAPIHandler returns the load state of every source as JSON
+func sourcesAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(store.Sources(r.Context())); err != nil {
+		log.Printf("Error encoding JSON: %v", err)
+	}
+}
+
+const sourcesTemplate = `
+{{ define "style" }}
+        .status-ok {
+            color: var(--success);
+            font-weight: 600;
+        }
+        .status-stale {
+            color: var(--warning);
+            font-weight: 600;
+        }
+        .status-failing {
+            color: var(--error);
+            font-weight: 600;
+        }
+{{ end }}
+
+{{ define "content" }}
+        <div class="panel">
+            <table>
+                <thead>
+                    <tr>
+                        <th>Source</th>
+                        <th>Status</th>
+                        <th>Results</th>
+                        <th title="Lines skipped because they aren't valid JSON results">Invalid Lines</th>
+                        <th>Last Success</th>
+                        <th>Last Error</th>
+                    </tr>
+                </thead>
+                <tbody>
+                    {{ range .Sources }}
+                    <tr>
+                        <td class="mono">{{ .Name }}</td>
+                        <td>
+                            {{ if .Healthy }}<span class="status-ok">✓ OK</span>
+                            {{ else if .Stale }}<span class="status-stale" title="Serving results from the last successful load">⚠ Stale</span>
+                            {{ else if .Failures }}<span class="status-failing">✗ Failing</span>
+                            {{ else }}<span class="muted">Not loaded yet</span>{{ end }}
+                            {{ if .Failures }}<div class="muted">{{ .Failures }} failed load(s) in a row</div>{{ end }}
+                        </td>
+                        <td>{{ human .Results }}</td>
+                        <td>{{ if .InvalidLines }}{{ human .InvalidLines }}{{ else }}<span class="muted">-</span>{{ end }}</td>
+                        <td class="mono">{{ if not .LastSuccess.IsZero }}{{ .LastSuccess.Format "2006-01-02 15:04:05" }}{{ else }}<span class="muted">never</span>{{ end }}</td>
+                        <td>{{ if .LastError }}<span class="mono">{{ .LastError }}</span><div class="muted">{{ .LastErrorAt.Format "2006-01-02 15:04:05" }}</div>{{ else }}<span class="muted">-</span>{{ end }}</td>
+                    </tr>
+                    {{ else }}
+                    <tr><td colspan="6" class="muted">No sources configured.</td></tr>
+                    {{ end }}
+                </tbody>
+            </table>
+        </div>
+{{ end }}
+`
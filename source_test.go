@@ -0,0 +1,303 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// staticSource serves fixed results, for tests that need exact Origin values
+type staticSource struct {
+	name    string
+	results []EvalResult
+}
+
+func (s *staticSource) Name() string                { return s.name }
+func (s *staticSource) Load() ([]EvalResult, error) { return s.results, nil }
+
+func writeLines(t *testing.T, path string, lines ...string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewSourcePaths(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "nightly runs", "über")
+	literal := filepath.Join(dir, "weird[1].jsonl")
+	writeLines(t, literal, `{"model":"a"}`)
+
+	tests := []struct {
+		arg     string
+		glob    bool
+		pattern string // Name of the resulting source
+	}{
+		{filepath.Join(dir, "..", "über", "weird[1].jsonl"), false, literal}, // Cleaned, brackets taken literally when the file exists
+		{dir, true, filepath.Join(dir, "*.jsonl")},                           // Directory = every JSONL file in it
+		{filepath.Join(dir, "*.jsonl"), true, filepath.Join(dir, "*.jsonl")},
+		{filepath.Join(dir, "missing.jsonl"), false, filepath.Join(dir, "missing.jsonl")}, // Created by the first ingest
+		{`C:\evals\run 1.jsonl`, false, filepath.Clean(`C:\evals\run 1.jsonl`)},           // Drive letters are not URL schemes
+	}
+	for _, tt := range tests {
+		source, err := NewSource(tt.arg)
+		if err != nil {
+			t.Errorf("NewSource(%q): %v", tt.arg, err)
+			continue
+		}
+		_, isGlob := source.(*globSource)
+		if isGlob != tt.glob || source.Name() != tt.pattern {
+			t.Errorf("NewSource(%q) = %T %q, want glob=%v %q", tt.arg, source, source.Name(), tt.glob, tt.pattern)
+		}
+	}
+
+	if _, err := NewSource(filepath.Join(dir, "[.jsonl")); err == nil {
+		t.Error("expected error for malformed pattern")
+	}
+	if _, err := NewSource("ftp://host/evals.jsonl"); err == nil {
+		t.Error("expected error for unsupported scheme")
+	}
+}
+
+func TestGlobSource(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "eval runs")
+	writeLines(t, filepath.Join(dir, "run 1.jsonl"), `{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"t1"}`)
+	writeLines(t, filepath.Join(dir, "notes.txt"), `not json`)
+	os.MkdirAll(filepath.Join(dir, "old.jsonl"), 0755) // A directory that matches the pattern
+
+	source, err := NewSource(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := source.Load()
+	if err != nil || len(results) != 1 || results[0].Origin != filepath.Join(dir, "run 1.jsonl") {
+		t.Fatalf("Load = %+v, %v", results, err)
+	}
+
+	// New files are picked up on the next load, deleted ones disappear
+	writeLines(t, filepath.Join(dir, "run-2.jsonl"), `{"timestamp":"2025-01-02T10:00:00Z","model":"a","test_id":"t1"}`)
+	if results, _ := source.Load(); len(results) != 2 {
+		t.Errorf("new file not picked up: %d results", len(results))
+	}
+	os.Remove(filepath.Join(dir, "run 1.jsonl"))
+	if results, _ := source.Load(); len(results) != 1 || results[0].Origin != filepath.Join(dir, "run-2.jsonl") {
+		t.Errorf("deleted file still loaded: %+v", results)
+	}
+}
+
+func TestParseJSONLWindowsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "evals.jsonl")
+	data := "\xEF\xBB\xBF" + `{"model":"a","test_id":"t1"}` + "\r\n" + `{"model":"b","test_id":"t2"}` + "\r\n"
+	os.WriteFile(path, []byte(data), 0644)
+
+	results, err := ParseJSONL(path)
+	if err != nil || len(results) != 2 || results[0].Model != "a" {
+		t.Errorf("ParseJSONL = %+v, %v", results, err)
+	}
+}
+
+func TestFileSourceReadsAppendedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "evals.jsonl")
+	appendText := func(text string) {
+		f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+		f.WriteString(text)
+		f.Close()
+	}
+	os.WriteFile(path, []byte("\xEF\xBB\xBF"+`{"model":"a","test_id":"t1"}`+"\r\nnot json\n"), 0644)
+	source := &fileSource{path: path}
+	models := func() (string, uint64) {
+		t.Helper()
+		results, generation, err := source.LoadGeneration()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var s string
+		for _, result := range results {
+			s += result.Model
+		}
+		return s, generation
+	}
+	if got, _ := models(); got != "a" || source.InvalidLines() != 1 {
+		t.Fatalf("first load = %q, %d invalid", got, source.InvalidLines())
+	}
+
+	// Appended lines are parsed on their own, a line still being written is served but not kept
+	appendText(`{"model":"b","test_id":"t2"}` + "\n" + `{"model":"c","test_id":"t3"}`)
+	if got, gen := models(); got != "abc" || gen != 0 || len(source.parsed) != 2 || source.cursor.lines != 3 {
+		t.Errorf("append = %q, generation %d, %d parsed", got, gen, len(source.parsed))
+	}
+	appendText("\n" + `{"model":"d","test_id":"t4"}` + "\n")
+	got, gen := models()
+	if got != "abcd" || gen != 1 { // The unterminated line was served, so the generation changes
+		t.Errorf("completed line = %q, generation %d", got, gen)
+	}
+
+	// Rewritten in place with more lines (the last one unchanged) or truncated: parsed from the start
+	os.WriteFile(path, []byte(`{"model":"x","test_id":"t0"}`+"\n"+`{"model":"b","test_id":"t2"}`+"\n"+`{"model":"c","test_id":"t3"}`+"\n"+`{"model":"d","test_id":"t4"}`+"\n"), 0644)
+	if got, next := models(); got != "xbcd" || next == gen || source.InvalidLines() != 0 {
+		t.Errorf("rewrite = %q, generation %d -> %d", got, gen, next)
+	}
+	os.Truncate(path, 0)
+	if got, _ := models(); got != "" {
+		t.Errorf("truncate = %q", got)
+	}
+}
+
+func TestGlobSourceGeneration(t *testing.T) {
+	prev := []globFile{{"a", 0, 2}, {"b", 0, 1}}
+	tests := []struct {
+		next []globFile
+		want bool
+	}{
+		{[]globFile{{"a", 0, 2}, {"b", 0, 3}}, true},              // The last file grew
+		{[]globFile{{"a", 0, 2}, {"b", 0, 1}, {"c", 0, 5}}, true}, // A new file after the others
+		{[]globFile{{"a", 0, 3}, {"b", 0, 1}}, false},             // Results inserted before b's
+		{[]globFile{{"0", 0, 1}, {"a", 0, 2}, {"b", 0, 1}}, false},
+		{[]globFile{{"a", 1, 2}, {"b", 0, 1}}, false}, // a was rewritten
+		{[]globFile{{"a", 0, 2}}, false},
+	}
+	for _, tt := range tests {
+		if got := globExtends(prev, tt.next); got != tt.want {
+			t.Errorf("globExtends(%v) = %v", tt.next, got)
+		}
+	}
+}
+
+func TestFileSourceLocking(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "evals.jsonl")
+
+	// Two sources on the same file stand in for two goevals processes
+	a, b := &fileSource{path: path}, &fileSource{path: path}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(src *fileSource) {
+			defer wg.Done()
+			if _, err := src.Append([]EvalResult{{Model: "m", Response: strings.Repeat("x", 8192)}}); err != nil {
+				t.Error(err)
+			}
+		}([]*fileSource{a, b}[i%2])
+	}
+	wg.Wait()
+	if results, err := ParseJSONL(path); err != nil || len(results) != 20 {
+		t.Errorf("expected 20 intact lines, got %d, %v", len(results), err)
+	}
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Error("lock file left behind")
+	}
+
+	// A lock left by a crashed process is broken once stale
+	os.WriteFile(path+".lock", []byte("123\n"), 0644)
+	old := time.Now().Add(-2 * lockStaleAfter)
+	os.Chtimes(path+".lock", old, old)
+	if _, err := a.Append([]EvalResult{{Model: "m"}}); err != nil {
+		t.Errorf("stale lock not broken: %v", err)
+	}
+
+	// A live lock is waited for
+	unlock, err := lockFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.AfterFunc(50*time.Millisecond, unlock)
+	start := time.Now()
+	if _, err := b.Append([]EvalResult{{Model: "m"}}); err != nil || time.Since(start) < 40*time.Millisecond {
+		t.Errorf("Append did not wait for the lock: %v after %s", err, time.Since(start))
+	}
+}
+
+func TestBreakStaleLockOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "evals.jsonl")
+	old := time.Now().Add(-2 * lockStaleAfter)
+	for round := 0; round < 20; round++ {
+		os.WriteFile(path+".lock", []byte("123\n"), 0644)
+		os.Chtimes(path+".lock", old, old)
+
+		// Every locker finds the stale lock, only one at a time may hold the new one
+		var holding, overlaps atomic.Int32
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				unlock, err := lockFile(path)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if holding.Add(1) > 1 {
+					overlaps.Add(1)
+				}
+				time.Sleep(time.Millisecond)
+				holding.Add(-1)
+				unlock()
+			}()
+		}
+		wg.Wait()
+		if n := overlaps.Load(); n > 0 {
+			t.Fatalf("round %d: lock held twice %d times", round, n)
+		}
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 0 {
+		t.Errorf("left behind %v", entries)
+	}
+
+	// Unlocking leaves a lock that has since been taken by another process
+	unlock, err := lockFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(path+".lock", []byte("456 other\n"), 0644)
+	unlock()
+	if _, err := os.Stat(path + ".lock"); err != nil {
+		t.Error("removed another process's lock")
+	}
+}
+
+func TestRemoveWhileFileIsOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "evals.jsonl")
+	writeLines(t, path,
+		`{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"t1"}`,
+		`{"timestamp":"2025-01-01T11:00:00Z","model":"a","test_id":"t2"}`)
+	src := &fileSource{path: path}
+	results, _ := src.Load()
+
+	// A reader holding the file open blocks the rename on Windows until it closes
+	reader, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.AfterFunc(30*time.Millisecond, func() { reader.Close() })
+
+	if n, err := src.Remove(map[string]bool{resultKey(results[0]): true}); err != nil || n != 1 {
+		t.Fatalf("Remove = %d, %v", n, err)
+	}
+	if results, _ := ParseJSONL(path); len(results) != 1 || results[0].TestID != "t2" {
+		t.Errorf("after Remove = %+v", results)
+	}
+}
+
+func TestSourcePathDisplay(t *testing.T) {
+	windowsPath := `C:\Users\eval user\runs\nightly.jsonl`
+	useStore(t, NewMemoryStore([]Source{&staticSource{name: "runs", results: []EvalResult{
+		{Timestamp: "2025-01-01T10:00:00Z", Model: "a", TestID: "t1", Origin: windowsPath},
+	}}}))
+
+	rec := httptest.NewRecorder()
+	testsHandler(rec, httptest.NewRequest(http.MethodGet, "/tests?source=C%3A%5CUsers%5Ceval+user%5Cruns%5Cnightly.jsonl", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `>C:\Users\eval user\runs\nightly.jsonl</a>`) {
+		t.Error("path not shown as-is in the modal")
+	}
+	if !strings.Contains(body, `href="/tests?source=C%3a%5cUsers%5ceval%20user%5cruns%5cnightly.jsonl"`) {
+		t.Error("path not escaped in the source link")
+	}
+}
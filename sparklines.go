@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sparklineRuns is how many of a config's latest runs its sparkline in the comparison table
+// covers, see --sparkline-runs; 0 hides the Trend column
+var sparklineRuns = 10
+
+// Sparkline size in SVG user units
+const (
+	sparklineWidth  = 80
+	sparklineHeight = 20
+	sparklinePad    = 2.5 // Keeps the end dot inside the box
+)
+
+// sparklineMinSpan is the smallest score range a sparkline stretches to its height, so that
+// noise between nearly equal runs doesn't look like a trend
+const sparklineMinSpan = 0.05
+
+// Sparkline is a config's average score over its latest runs, oldest first, drawn in a table cell
+type Sparkline struct {
+	Points       string  // SVG polyline points
+	LastX, LastY float64 // End of the line, marked with a dot
+	Runs         int
+	First, Last  float64 // Average score of the oldest and latest run drawn
+	Title        string  // Tooltip listing each run's average
+}
+
+// Delta is the change from the oldest to the latest run drawn, what the Trend column sorts by
+func (s *Sparkline) Delta() float64 {
+	return s.Last - s.First
+}
+
+// Trend classes the direction of the line like the score cells: up, down or flat within 0.01
+func (s *Sparkline) Trend() string {
+	switch d := s.Delta(); {
+	case d > 0.01:
+		return "score-good"
+	case d < -0.01:
+		return "score-poor"
+	}
+	return "score-fair"
+}
+
+// BuildSparklines returns the sparkline of each config that has at least two runs with a
+// timestamp, over its latest n runs ordered by their first timestamp
+// Averages are taken like the Combined column: errored results left out, tests weighted
+func BuildSparklines(results []EvalResult, n int) map[string]*Sparkline {
+	if n < 2 {
+		return nil
+	}
+	type runAcc struct {
+		name   string
+		start  time.Time
+		sum, w float64
+	}
+	byConfig := make(map[string]map[string]*runAcc)
+	for _, result := range scored(results) {
+		ts, ok := parseTimestamp(result.Timestamp)
+		if !ok {
+			continue
+		}
+		config := buildConfigKey(result)
+		runs := byConfig[config]
+		if runs == nil {
+			runs = make(map[string]*runAcc)
+			byConfig[config] = runs
+		}
+		runID, source := runOf(result)
+		key := runID + "\x00" + source
+		a := runs[key]
+		if a == nil {
+			a = &runAcc{name: runName(runID, source)}
+			runs[key] = a
+		}
+		if a.start.IsZero() || ts.Before(a.start) {
+			a.start = ts
+		}
+		w := result.TestWeight()
+		a.sum += result.Scores.Combined * w
+		a.w += w
+	}
+
+	sparklines := make(map[string]*Sparkline)
+	for config, runs := range byConfig {
+		ordered := make([]*runAcc, 0, len(runs))
+		for _, a := range runs {
+			if a.w > 0 {
+				ordered = append(ordered, a)
+			}
+		}
+		if len(ordered) < 2 {
+			continue
+		}
+		sort.Slice(ordered, func(i, j int) bool {
+			if !ordered[i].start.Equal(ordered[j].start) {
+				return ordered[i].start.Before(ordered[j].start)
+			}
+			return ordered[i].name < ordered[j].name
+		})
+		ordered = ordered[max(0, len(ordered)-n):]
+		names := make([]string, len(ordered))
+		scores := make([]float64, len(ordered))
+		for i, a := range ordered {
+			names[i], scores[i] = a.name, a.sum/a.w
+		}
+		sparklines[config] = newSparkline(names, scores)
+	}
+	return sparklines
+}
+
+// newSparkline lays out at least two run averages left to right, scaled between their minimum
+// and maximum (widened to sparklineMinSpan around their middle)
+func newSparkline(names []string, scores []float64) *Sparkline {
+	low, high := scores[0], scores[0]
+	for _, score := range scores {
+		low, high = math.Min(low, score), math.Max(high, score)
+	}
+	if high-low < sparklineMinSpan {
+		mid := (low + high) / 2
+		low, high = mid-sparklineMinSpan/2, mid+sparklineMinSpan/2
+	}
+	xs, ys := make([]float64, len(scores)), make([]float64, len(scores))
+	lines := make([]string, len(scores))
+	step := (sparklineWidth - 2*sparklinePad) / float64(len(scores)-1)
+	for i, score := range scores {
+		xs[i] = sparklinePad + float64(i)*step
+		ys[i] = sparklinePad + (high-score)/(high-low)*(sparklineHeight-2*sparklinePad)
+		lines[i] = fmt.Sprintf("%s: %.3f", names[i], score)
+	}
+	last := len(scores) - 1
+	return &Sparkline{
+		Points: polyline(xs, ys),
+		LastX:  xs[last],
+		LastY:  ys[last],
+		Runs:   len(scores),
+		First:  scores[0],
+		Last:   scores[last],
+		Title:  strings.Join(lines, "\n"),
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBuildSparklines(t *testing.T) {
+	run := func(id, ts string, score float64) EvalResult {
+		return EvalResult{Model: "a", Timestamp: ts, Metadata: map[string]any{"run_id": id}, Scores: ScoreBreakdown{Combined: score}}
+	}
+	results := []EvalResult{
+		run("r3", "2025-01-03T10:00:00Z", 0.9),
+		run("r1", "2025-01-01T10:00:00Z", 0.5),
+		run("r2", "2025-01-02T10:00:00Z", 0.6),
+		run("r2", "2025-01-02T11:00:00Z", 0.8),
+		{Model: "a", Timestamp: "2025-01-04T10:00:00Z", Metadata: map[string]any{"run_id": "r4"}, Error: "timeout"}, // No scored result, not a point
+		{Model: "b", Timestamp: "2025-01-01T10:00:00Z", Scores: ScoreBreakdown{Combined: 0.5}},                      // A single run
+	}
+	sparklines := BuildSparklines(results, 2)
+	if len(sparklines) != 1 {
+		t.Fatalf("sparklines = %v", sparklines)
+	}
+	a := sparklines["a"]
+	if a.Runs != 2 || math.Abs(a.First-0.7) > 1e-9 || a.Last != 0.9 || a.Trend() != "score-good" {
+		t.Errorf("a = %+v", a)
+	}
+	if a.Points != "2.5,17.5 77.5,2.5" || a.LastX != 77.5 || !strings.Contains(a.Title, "r3: 0.900") || strings.Contains(a.Title, "r1") {
+		t.Errorf("a = %+v", a)
+	}
+	if BuildSparklines(results, 0) != nil {
+		t.Error("sparklines with n = 0")
+	}
+
+	// Nearly equal runs stay near the middle instead of spanning the height
+	flat := newSparkline([]string{"x", "y", "z"}, []float64{0.8, 0.81, 0.805})
+	if flat.Points != "2.5,11.5 40.0,8.5 77.5,10.0" || flat.Trend() != "score-fair" {
+		t.Errorf("flat = %+v", flat)
+	}
+}
+
+func TestDashboardSparklines(t *testing.T) {
+	withStore(t, `{"timestamp":"2025-01-01T10:00:00Z","model":"a","scores":{"combined":0.5},"metadata":{"run_id":"r1"}}
+{"timestamp":"2025-01-02T10:00:00Z","model":"a","scores":{"combined":0.4},"metadata":{"run_id":"r2"}}`)
+
+	rec := httptest.NewRecorder()
+	dashboardHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `data-sort="trend"`) || !strings.Contains(body, `class="sparkline score-poor"`) {
+		t.Errorf("dashboard has no trend sparkline: %s", body)
+	}
+}
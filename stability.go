@@ -0,0 +1,286 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+)
+
+// Stability thresholds
+const (
+	stabilityPass     = 0.5 // Pass/fail split of the combined score for Consistency
+	unstableStdDev    = 0.1 // Configs with a larger mean per-test standard deviation are flagged
+	maxUnstableTests  = 20  // Tests listed in the most unstable tests table
+	minStabilityTests = 3   // Repeated tests a config needs before it is flagged
+)
+
+// TestStability is the spread of one config's combined score over repeats of one test
+type TestStability struct {
+	Config string  `json:"config"`
+	Model  string  `json:"model"`
+	TestID string  `json:"test_id"`
+	N      int     `json:"n"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"std_dev"` // Sample standard deviation
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Flips  bool    `json:"flips"` // Repeats land on both sides of the pass/fail split
+}
+
+// StabilityStat is how much one config's scores depend on the run rather than the test
+type StabilityStat struct {
+	Config      string  `json:"config"`
+	Model       string  `json:"model"`
+	Tests       int     `json:"tests"`        // Tests evaluated at least twice
+	Repeats     int     `json:"repeats"`      // Results of those tests
+	MeanStdDev  float64 `json:"mean_std_dev"` // Mean per-test standard deviation
+	MaxStdDev   float64 `json:"max_std_dev"`
+	Consistency float64 `json:"consistency"` // Share of repeated tests that always pass or always fail
+
+	Histogram BarChart `json:"-"` // Per-test standard deviations
+}
+
+// Unstable reports a config whose quality is highly run-dependent
+func (s StabilityStat) Unstable() bool {
+	return s.Tests >= minStabilityTests && s.MeanStdDev >= unstableStdDev
+}
+
+// StabilityReport is the stability page
+type StabilityReport struct {
+	Configs  []StabilityStat `json:"configs"`        // Least stable first
+	Unstable []TestStability `json:"unstable_tests"` // Largest spread first, at most maxUnstableTests
+}
+
+// BuildStabilityReport measures the spread of the combined score when the same config evaluates
+// the same test_id more than once, within a run (repeats) or across runs
+// Errored results and results without a test_id are ignored
+func BuildStabilityReport(results []EvalResult) StabilityReport {
+	// config -> test_id -> scores
+	data := make(map[string]map[string][]float64)
+	models := make(map[string]string)
+	for _, result := range scored(results) {
+		if result.TestID == "" {
+			continue
+		}
+		key := buildConfigKey(result)
+		if data[key] == nil {
+			data[key] = make(map[string][]float64)
+			models[key] = result.Model
+		}
+		data[key][result.TestID] = append(data[key][result.TestID], result.Scores.Combined)
+	}
+
+	report := StabilityReport{Configs: []StabilityStat{}, Unstable: []TestStability{}}
+	var tests []TestStability
+	for key, byTest := range data {
+		stat := StabilityStat{Config: key, Model: models[key]}
+		var stdDevs []float64
+		consistent := 0
+		for testID, scores := range byTest {
+			if len(scores) < 2 {
+				continue
+			}
+			t := TestStability{
+				Config: key, Model: models[key], TestID: testID, N: len(scores),
+				Mean: mean(scores), StdDev: aggregate(scores, "std"), Min: slices.Min(scores), Max: slices.Max(scores),
+			}
+			if t.Min == t.Max {
+				t.StdDev = 0 // Not a rounding error's worth of spread
+			}
+			t.Flips = t.Min < stabilityPass && t.Max >= stabilityPass
+			if !t.Flips {
+				consistent++
+			}
+			stdDevs = append(stdDevs, t.StdDev)
+			stat.Repeats += len(scores)
+			stat.MaxStdDev = max(stat.MaxStdDev, t.StdDev)
+			tests = append(tests, t)
+		}
+		if len(stdDevs) == 0 {
+			continue // Every test ran once
+		}
+		stat.Tests = len(stdDevs)
+		stat.MeanStdDev = mean(stdDevs)
+		stat.Consistency = float64(consistent) / float64(stat.Tests)
+		stat.Histogram = stabilityHistogram(stdDevs)
+		report.Configs = append(report.Configs, stat)
+	}
+	sort.Slice(report.Configs, func(i, j int) bool {
+		a, b := report.Configs[i], report.Configs[j]
+		if a.MeanStdDev != b.MeanStdDev {
+			return a.MeanStdDev > b.MeanStdDev
+		}
+		return a.Config < b.Config
+	})
+	sort.Slice(tests, func(i, j int) bool {
+		a, b := tests[i], tests[j]
+		if a.StdDev != b.StdDev {
+			return a.StdDev > b.StdDev
+		}
+		if a.Config != b.Config {
+			return a.Config < b.Config
+		}
+		return a.TestID < b.TestID
+	})
+	for _, t := range tests {
+		if len(report.Unstable) == maxUnstableTests || t.StdDev == 0 {
+			break
+		}
+		report.Unstable = append(report.Unstable, t)
+	}
+	return report
+}
+
+// Stability histogram: per-test standard deviations in stabilityBins bins of stabilityBinWidth,
+// the last bin holds everything above
+const (
+	stabilityBins     = 6
+	stabilityBinWidth = 0.05
+)
+
+// stabilityHistogram draws how many tests fall in each standard deviation bin
+func stabilityHistogram(stdDevs []float64) BarChart {
+	counts := make([]int, stabilityBins)
+	for _, sd := range stdDevs {
+		counts[min(int(sd/stabilityBinWidth), stabilityBins-1)]++
+	}
+	labels := make([]string, stabilityBins)
+	for bin, n := range counts {
+		labels[bin] = fmt.Sprintf("σ %.2f-%.2f: %d tests", float64(bin)*stabilityBinWidth, float64(bin+1)*stabilityBinWidth, n)
+		if bin == stabilityBins-1 {
+			labels[bin] = fmt.Sprintf("σ ≥%.2f: %d tests", float64(bin)*stabilityBinWidth, n)
+		}
+	}
+	return newBarChart(latencyBarWidth, latencyBarHeight, counts, labels)
+}
+
+// stabilityHandler renders the score spread of repeated tests per config
+func stabilityHandler(w http.ResponseWriter, r *http.Request) {
+	results, err := store.Query(r.Context(), dashboardFilter(r))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Title          string
+		Subtitle       string
+		UnstableStdDev float64
+		StabilityReport
+	}{
+		Title:           "Stability",
+		Subtitle:        "How much scores move when the same config evaluates the same test again",
+		UnstableStdDev:  unstableStdDev,
+		StabilityReport: BuildStabilityReport(results),
+	}
+
+	renderPage(w, r, "stability", stabilityTemplate, data)
+}
+
+// stabilityAPIHandler returns the stability report as JSON
+func stabilityAPIHandler(w http.ResponseWriter, r *http.Request) {
+	results, err := store.Query(r.Context(), dashboardFilter(r))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(BuildStabilityReport(results)); err != nil {
+		log.Printf("Error encoding JSON: %v", err)
+	}
+}
+
+const stabilityTemplate = `
+{{ define "style" }}
+        td.num {
+            font-family: monospace;
+            text-align: right;
+            white-space: nowrap;
+        }
+        td a.mono {
+            color: var(--accent);
+            text-decoration: none;
+        }
+        .unstable {
+            color: var(--error);
+            font-size: 0.8125rem;
+            font-weight: 600;
+        }
+{{ end }}
+
+{{ define "content" }}
+        {{ if .Configs }}
+        <div class="panel">
+            <h2>Stability per Config</h2>
+            <p class="muted" style="margin-bottom: 1rem; font-size: 0.875rem;">Over tests each config evaluated more than once, in one run or several. σ is the standard deviation of a test's combined score over its repeats; configs with a mean σ of {{ .UnstableStdDev }} or more are flagged as run-dependent. Consistency is the share of repeated tests that always pass or always fail (score ≥ 0.5).</p>
+            <div style="overflow-x: auto;">
+            <table>
+                <thead>
+                    <tr>
+                        <th>Config</th>
+                        <th>Tests</th>
+                        <th>Repeats</th>
+                        <th>Mean σ</th>
+                        <th>Max σ</th>
+                        <th>Consistency</th>
+                        <th title="Tests per σ bin of 0.05, lowest σ on the left">σ Distribution</th>
+                    </tr>
+                </thead>
+                <tbody>
+                    {{ range .Configs }}
+                    <tr>
+                        <td class="mono">{{ configLabel .Config }}{{ if .Unstable }} <span class="unstable">unstable</span>{{ end }}</td>
+                        <td class="num">{{ human .Tests }}</td>
+                        <td class="num">{{ human .Repeats }}</td>
+                        <td class="num">{{ printf "%.3f" .MeanStdDev }}</td>
+                        <td class="num">{{ printf "%.3f" .MaxStdDev }}</td>
+                        <td class="num">{{ printf "%.1f" (percent .Consistency) }}%</td>
+                        <td>{{ template "bar-chart" .Histogram }}</td>
+                    </tr>
+                    {{ end }}
+                </tbody>
+            </table>
+            </div>
+        </div>
+
+        {{ if .Unstable }}
+        <div class="panel">
+            <h2>Most Unstable Tests</h2>
+            <div style="overflow-x: auto;">
+            <table>
+                <thead>
+                    <tr>
+                        <th>Test</th>
+                        <th>Config</th>
+                        <th>Repeats</th>
+                        <th>Mean</th>
+                        <th>σ</th>
+                        <th>Range</th>
+                    </tr>
+                </thead>
+                <tbody>
+                    {{ range .Unstable }}
+                    <tr>
+                        <td><a class="mono" href="/tests/{{ .TestID }}">{{ .TestID }}</a>{{ if .Flips }} <span class="unstable" title="Passes in some repeats and fails in others">flips</span>{{ end }}</td>
+                        <td class="mono">{{ configLabel .Config }}</td>
+                        <td class="num">{{ .N }}</td>
+                        <td class="num">{{ printf "%.3f" .Mean }}</td>
+                        <td class="num">{{ printf "%.3f" .StdDev }}</td>
+                        <td class="num">{{ printf "%.3f" .Min }} – {{ printf "%.3f" .Max }}</td>
+                    </tr>
+                    {{ end }}
+                </tbody>
+            </table>
+            </div>
+        </div>
+        {{ end }}
+        {{ else }}
+        <div class="panel">
+            <p class="muted">No repeated tests. Stability needs the same <span class="mono">test_id</span> evaluated more than once by the same config, e.g. several runs of one eval suite or several samples per test.</p>
+        </div>
+        {{ end }}
+{{ end }}`
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBuildStabilityReport(t *testing.T) {
+	var results []EvalResult
+	add := func(model, test string, scores ...float64) {
+		for _, s := range scores {
+			results = append(results, EvalResult{Model: model, TestID: test, Scores: ScoreBreakdown{Combined: s}})
+		}
+	}
+	add("steady", "t1", 0.8, 0.8, 0.8)
+	add("steady", "t2", 0.6, 0.7)
+	add("steady", "t3", 0.9) // Ran once
+	add("noisy", "t1", 0.2, 0.9)
+	add("noisy", "t2", 0.3, 0.7)
+	add("noisy", "t3", 0.6, 0.8)
+	results = append(results, EvalResult{Model: "noisy", TestID: "t3", Error: "timeout"})
+
+	report := BuildStabilityReport(results)
+	if len(report.Configs) != 2 {
+		t.Fatalf("configs = %+v", report.Configs)
+	}
+	noisy, steady := report.Configs[0], report.Configs[1]
+	if noisy.Model != "noisy" || noisy.Tests != 3 || noisy.Repeats != 6 || !noisy.Unstable() {
+		t.Errorf("noisy = %+v", noisy)
+	}
+	if math.Abs(noisy.Consistency-1.0/3) > 1e-9 {
+		t.Errorf("noisy consistency = %v", noisy.Consistency)
+	}
+	if steady.Tests != 2 || steady.Consistency != 1 || steady.Unstable() || math.Abs(steady.MaxStdDev-math.Sqrt(0.005)) > 1e-9 {
+		t.Errorf("steady = %+v", steady)
+	}
+	// Tests without any spread aren't listed
+	if len(report.Unstable) != 4 || report.Unstable[0].TestID != "t1" || !report.Unstable[0].Flips || report.Unstable[3].Model != "steady" {
+		t.Errorf("unstable tests = %+v", report.Unstable)
+	}
+}
+
+func TestStabilityPage(t *testing.T) {
+	var lines []string
+	for i, score := range []float64{0.2, 0.9, 0.5} {
+		lines = append(lines, fmt.Sprintf(`{"timestamp":"2025-01-01T10:00:0%dZ","model":"m","test_id":"t1","scores":{"combined":%v}}`, i, score))
+	}
+	withStore(t, lines...)
+
+	rec := httptest.NewRecorder()
+	stabilityHandler(rec, httptest.NewRequest(http.MethodGet, "/stability", nil))
+	if body := rec.Body.String(); !strings.Contains(body, "Most Unstable Tests") || !strings.Contains(body, `href="/tests/t1"`) {
+		t.Error("stability page has no unstable tests")
+	}
+
+	rec = httptest.NewRecorder()
+	stabilityAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/api/stability", nil))
+	var report StabilityReport
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil || len(report.Configs) != 1 || report.Configs[0].Repeats != 3 {
+		t.Errorf("API = %+v, %v", report, err)
+	}
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// statsReservoirSize caps the scores kept per config for confidence intervals
+// Up to this many tests the interval is exact; beyond it a uniform sample is bootstrapped and the
+// interval rescaled to the full count (see configStats.ci)
+const statsReservoirSize = 2000
+
+// configStats holds the running aggregates of one config
+type configStats struct {
+	count      int
+	scored     int // Results without an error, the base of the score statistics
+	errors     int
+	sum        float64 // Of the scores times their test weights
+	weight     float64 // Sum of the test weights of the scored results
+	weighted   bool    // Some test weight isn't 1
+	min, max   float64
+	timeSum    float64
+	timed      int // Results with a response time
+	withinSLA  int
+	contexts   int // Retrieved chunks, over the results that have any
+	ctxChars   int
+	ctxResults int
+	costSum    float64            // Estimated USD, see ModelInfo.Cost
+	costed     int                // Results with a cost estimate
+	customSums map[string]float64 // Weighted like sum
+	customW    map[string]float64
+	fields     map[string]string // First value seen per custom field
+	params     map[string]string // Of the first result, see EvalResult.Params
+	reservoir  []float64         // Uniform sample of combined scores, in insertion order until full
+}
+
+// StatsAggregator maintains dashboard statistics incrementally: Add is O(1) per result (plus
+// the result's custom scores and fields) and Data builds a snapshot in O(configs)
+//
+// It is not safe for concurrent use.
+type StatsAggregator struct {
+	results    []EvalResult
+	total      int
+	tampered   int
+	errored    int
+	totalScore float64 // Weighted, see EvalResult.TestWeight
+	totalW     float64
+	configs    map[string]*configStats
+	scores     map[string]bool  // Custom score names
+	fieldKinds map[string]uint8 // Custom field name -> kinds of its values, see fieldType
+	rng        *rand.Rand       // Reservoir replacement, seeded so the same input gives the same stats
+	runIDs     map[string]bool
+	origins    map[string]bool
+	datasets   map[string]int // Dataset version -> results, "" = unversioned
+	slas       latencyBudgets
+
+	// aggregateOnly drops results once counted, Data then has no Results (--lazy)
+	aggregateOnly bool
+}
+
+// NewStatsAggregator returns an empty aggregator measuring Within SLA against slas
+func NewStatsAggregator(slas latencyBudgets) *StatsAggregator {
+	return &StatsAggregator{
+		results:    []EvalResult{},
+		configs:    make(map[string]*configStats),
+		scores:     make(map[string]bool),
+		fieldKinds: make(map[string]uint8),
+		rng:        rand.New(rand.NewPCG(1, 2)),
+		runIDs:     make(map[string]bool),
+		origins:    make(map[string]bool),
+		datasets:   make(map[string]int),
+		slas:       slas,
+	}
+}
+
+// Len is the number of results added
+func (a *StatsAggregator) Len() int {
+	return a.total
+}
+
+// Add folds one result into the statistics
+func (a *StatsAggregator) Add(result EvalResult) {
+	if !a.aggregateOnly {
+		a.results = append(a.results, result)
+	}
+	a.total++
+	if runID, ok := result.Metadata["run_id"].(string); ok && runID != "" {
+		a.runIDs[runID] = true
+	}
+	if result.Origin != "" {
+		a.origins[result.Origin] = true
+	}
+	a.datasets[result.Dataset()]++
+	if isTampered(result) {
+		a.tampered++
+	}
+
+	// Aggregate by full config (model + all custom fields), not just model
+	key := buildConfigKey(result)
+	c := a.configs[key]
+	if c == nil {
+		c = &configStats{
+			customSums: make(map[string]float64),
+			customW:    make(map[string]float64),
+			fields:     make(map[string]string),
+			params:     make(map[string]string),
+		}
+		for name, value := range result.Params() {
+			c.params[name] = fmt.Sprintf("%v", value)
+		}
+		a.configs[key] = c
+	}
+	c.count++
+	c.timeSum += float64(result.ResponseTimeMS)
+	if result.ResponseTimeMS > 0 {
+		c.timed++
+		if ms, ok := a.slas.slaFor(modelName(key)); ok && result.ResponseTimeMS <= ms {
+			c.withinSLA++
+		}
+	}
+
+	if info, ok := modelRegistry.Lookup(result.Model); ok {
+		if usd, ok := info.Cost(result); ok {
+			c.costSum += usd
+			c.costed++
+		}
+	}
+
+	if n := len(result.RetrievedContexts); n > 0 {
+		c.contexts += n
+		c.ctxChars += result.ContextChars()
+		c.ctxResults++
+	}
+
+	// Errored results (timeouts, API errors) have no meaningful score, they only count as errors
+	if !isScored(result) {
+		a.errored++
+		c.errors++
+	} else {
+		a.addScores(c, result)
+	}
+
+	for name, value := range result.Fields() {
+		// Keep the first value seen for this config+field
+		if _, ok := c.fields[name]; !ok {
+			c.fields[name] = fmt.Sprintf("%v", value)
+		}
+		a.fieldKinds[name] |= valueKind(value)
+	}
+}
+
+// addScores folds the scores of a result without an error into its config
+func (a *StatsAggregator) addScores(c *configStats, result EvalResult) {
+	score, w := result.Scores.Combined, result.TestWeight()
+	a.totalScore += score * w
+	a.totalW += w
+	c.scored++
+	c.sum += score * w
+	c.weight += w
+	c.weighted = c.weighted || w != 1
+	if c.scored == 1 {
+		c.min, c.max = score, score
+	}
+	c.min = math.Min(c.min, score)
+	c.max = math.Max(c.max, score)
+
+	// Algorithm R: keep every score until the reservoir is full, then replace with
+	// probability size/count
+	if len(c.reservoir) < statsReservoirSize {
+		c.reservoir = append(c.reservoir, score)
+	} else if j := a.rng.IntN(c.scored); j < statsReservoirSize {
+		c.reservoir[j] = score
+	}
+
+	for name, value := range result.Scores.Custom {
+		a.scores[name] = true
+		c.customSums[name] += value * w
+		c.customW[name] += w
+	}
+}
+
+// ci is the 95% bootstrap interval of the config's mean score
+// With more tests than the reservoir holds, the sample's interval is recentered on the true mean
+// and narrowed by sqrt(sample/count), the standard error ratio of the two sample sizes; with test
+// weights it is recentered on the weighted mean
+func (c *configStats) ci() (low, high float64) {
+	low, high = bootstrapCI(c.reservoir)
+	if c.scored <= len(c.reservoir) && !c.weighted {
+		return low, high
+	}
+	sampleMean := mean(c.reservoir)
+	scale := 1.0
+	if c.scored > len(c.reservoir) {
+		scale = math.Sqrt(float64(len(c.reservoir)) / float64(c.scored))
+	}
+	avg := c.avg()
+	return avg - (sampleMean-low)*scale, avg + (high-sampleMean)*scale
+}
+
+// avg is the weighted mean score, 0 when every scored result has weight 0
+func (c *configStats) avg() float64 {
+	if c.weight == 0 {
+		return 0
+	}
+	return c.sum / c.weight
+}
+
+// isScored reports whether a result has a score: one with an error (a timeout, an API failure)
+// only counts as an error, its zero score would drag averages down
+func isScored(result EvalResult) bool {
+	return result.Error == ""
+}
+
+// scored returns the results with a score, see isScored. Every view that aggregates scores goes
+// through it, so the leaderboard, pivot, heatmaps and the rest agree with the dashboard averages
+// The result shares results' backing array when nothing is left out
+func scored(results []EvalResult) []EvalResult {
+	i := slices.IndexFunc(results, func(result EvalResult) bool { return !isScored(result) })
+	if i < 0 {
+		return results
+	}
+	kept := slices.Clone(results[:i])
+	for _, result := range results[i+1:] {
+		if isScored(result) {
+			kept = append(kept, result)
+		}
+	}
+	return kept
+}
+
+// modelName extracts the model from a config key (before the first pipe)
+func modelName(configKey string) string {
+	if i := strings.Index(configKey, "|"); i != -1 {
+		return configKey[:i]
+	}
+	return configKey
+}
+
+// Data returns the current statistics
+// The returned Results slice is shared with the aggregator and must not be modified
+func (a *StatsAggregator) Data() DashboardData {
+	data := DashboardData{
+		TotalTests:       a.total,
+		Tampered:         a.tampered,
+		Results:          a.results[:len(a.results):len(a.results)], // Later Adds must not show through
+		ModelStats:       make(map[string]ModelStat, len(a.configs)),
+		CustomFieldTypes: make(map[string]string, len(a.fieldKinds)),
+	}
+	if a.total == 0 {
+		return data
+	}
+	data.Errors = a.errored
+	if a.totalW > 0 {
+		data.AvgScore = a.totalScore / a.totalW
+	}
+	data.RunIDs = sortedKeys(a.runIDs, "")
+	data.Origins = sortedKeys(a.origins, "")
+	if len(a.datasets) > 1 || a.datasets[""] == 0 {
+		data.Datasets = maps.Clone(a.datasets)
+	}
+
+	for key := range a.configs {
+		data.Models = append(data.Models, key)
+	}
+	sort.Strings(data.Models)
+	for name := range a.scores {
+		data.CustomScores = append(data.CustomScores, name)
+	}
+	sort.Strings(data.CustomScores)
+	for name, kinds := range a.fieldKinds {
+		data.CustomFieldNames = append(data.CustomFieldNames, name)
+		data.CustomFieldTypes[name] = fieldType(kinds)
+		if mixedKinds(kinds) {
+			data.MixedFields = append(data.MixedFields, name)
+		}
+	}
+	sort.Strings(data.CustomFieldNames)
+	sort.Strings(data.MixedFields)
+
+	for _, key := range data.Models {
+		c := a.configs[key]
+		customAvgs := make(map[string]float64, len(c.customSums))
+		for name, sum := range c.customSums {
+			if c.customW[name] > 0 {
+				customAvgs[name] = sum / c.customW[name]
+			}
+		}
+		fields := make(map[string]string, len(c.fields))
+		for name, value := range c.fields {
+			fields[name] = value
+		}
+		ciLow, ciHigh := c.ci()
+		stat := ModelStat{
+			Model:           key,
+			ActualModelName: modelName(key),
+			DisplayName:     modelRegistry.DisplayName(modelName(key)),
+			TestCount:       c.count,
+			Errors:          c.errors,
+			ErrorRate:       float64(c.errors) / float64(c.count),
+			MinScore:        c.min,
+			MaxScore:        c.max,
+			CILow:           ciLow,
+			CIHigh:          ciHigh,
+			CustomScores:    customAvgs,
+			AvgTimeMS:       c.timeSum / float64(c.count),
+			CustomFields:    fields,
+			Params:          maps.Clone(c.params),
+		}
+		if c.scored > 0 {
+			stat.AvgScore, stat.ScoredWeight = c.avg(), c.weight
+		}
+		if ms, ok := a.slas.slaFor(stat.ActualModelName); ok {
+			stat.SLAMS, stat.SLATimed = ms, c.timed
+			if c.timed > 0 {
+				stat.WithinSLA = float64(c.withinSLA) / float64(c.timed)
+			}
+		}
+		if c.costed > 0 {
+			stat.EstCost, stat.CostResults = c.costSum, c.costed
+		}
+		if c.ctxResults > 0 {
+			stat.ContextResults = c.ctxResults
+			stat.AvgContexts = float64(c.contexts) / float64(c.ctxResults)
+			stat.AvgContextChars = float64(c.ctxChars) / float64(c.ctxResults)
+		}
+		data.ModelStats[key] = stat
+	}
+	return data
+}
+
+// hasCosts reports whether any config has cost estimates from the model registry
+func hasCosts(data DashboardData) bool {
+	for _, stat := range data.ModelStats {
+		if stat.CostResults > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// hasContexts reports whether any config has results with retrieved_contexts
+func hasContexts(data DashboardData) bool {
+	for _, stat := range data.ModelStats {
+		if stat.ContextResults > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// CalculateStats computes aggregate statistics from eval results
+func CalculateStats(results []EvalResult, slas latencyBudgets) DashboardData {
+	a := NewStatsAggregator(slas)
+	for _, result := range results {
+		a.Add(result)
+	}
+	data := a.Data()
+	data.Results = results
+	return data
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStatsAggregatorMatchesBatch(t *testing.T) {
+	var results []EvalResult
+	for i := 0; i < 30; i++ {
+		results = append(results, EvalResult{
+			Model:          []string{"a", "b"}[i%2],
+			TestID:         fmt.Sprintf("t%d", i),
+			ResponseTimeMS: int64(100 * i),
+			Scores:         ScoreBreakdown{Combined: float64(i%10) / 10, Custom: map[string]float64{"accuracy": float64(i%4) / 4}},
+			CustomFields:   map[string]any{"top_k": float64(5), "rerank": i%2 == 0},
+		})
+	}
+
+	a := NewStatsAggregator(nil)
+	for _, result := range results[:12] {
+		a.Add(result)
+	}
+	if first := a.Data(); first.TotalTests != 12 || len(first.Results) != 12 {
+		t.Fatalf("after 12: %d tests, %d results", first.TotalTests, len(first.Results))
+	}
+	snapshot := a.Data()
+	for _, result := range results[12:] {
+		a.Add(result)
+	}
+	if len(snapshot.Results) != 12 {
+		t.Errorf("earlier snapshot changed to %d results", len(snapshot.Results))
+	}
+
+	got, want := a.Data(), CalculateStats(results, nil)
+	if got.TotalTests != 30 || got.AvgScore != want.AvgScore || !reflect.DeepEqual(got.Models, want.Models) ||
+		!reflect.DeepEqual(got.CustomScores, want.CustomScores) || !reflect.DeepEqual(got.CustomFieldTypes, want.CustomFieldTypes) {
+		t.Errorf("incremental = %+v, batch = %+v", got, want)
+	}
+	if !reflect.DeepEqual(got.ModelStats, want.ModelStats) {
+		t.Errorf("model stats differ:\n%+v\n%+v", got.ModelStats, want.ModelStats)
+	}
+
+	stat := got.ModelStats["a|rerank=true|top_k=5"]
+	if stat.TestCount != 15 || stat.MinScore != 0 || stat.MaxScore != 0.8 || stat.CustomFields["top_k"] != "5" || got.CustomFieldTypes["rerank"] != "bool" {
+		t.Errorf("config a = %+v (models %v)", stat, got.Models)
+	}
+}
+
+func TestStatsAggregatorReservoir(t *testing.T) {
+	a := NewStatsAggregator(nil)
+	n := statsReservoirSize * 3
+	for i := 0; i < n; i++ {
+		a.Add(EvalResult{Model: "m", Scores: ScoreBreakdown{Combined: float64(i%100) / 100}})
+	}
+	c := a.configs["m"]
+	if len(c.reservoir) != statsReservoirSize {
+		t.Fatalf("reservoir holds %d scores", len(c.reservoir))
+	}
+	if m := mean(c.reservoir); m < 0.45 || m > 0.54 {
+		t.Errorf("reservoir mean %.3f is not a uniform sample", m)
+	}
+
+	stat := a.Data().ModelStats["m"]
+	sampleLow, sampleHigh := bootstrapCI(c.reservoir)
+	if !(stat.CILow < stat.AvgScore && stat.AvgScore < stat.CIHigh) || stat.CIHigh-stat.CILow >= sampleHigh-sampleLow {
+		t.Errorf("CI [%.4f, %.4f] around %.4f, sample CI [%.4f, %.4f]", stat.CILow, stat.CIHigh, stat.AvgScore, sampleLow, sampleHigh)
+	}
+}
+
+func TestMemoryStoreIncrementalStats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "evals.jsonl")
+	line := func(i int) string {
+		return fmt.Sprintf(`{"timestamp":"2025-01-01T10:00:%02dZ","model":"m","test_id":"t%d","scores":{"combined":0.5}}`+"\n", i, i)
+	}
+	os.WriteFile(path, []byte(line(1)+line(2)), 0o644)
+	s := NewMemoryStore([]Source{&fileSource{path: path}}).(*memoryStore)
+	ctx := context.Background()
+
+	if data, _ := s.Stats(ctx); data.TotalTests != 2 {
+		t.Fatalf("first load: %d tests", data.TotalTests)
+	}
+	agg := s.stats
+
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	f.WriteString(line(3))
+	f.Close()
+	if data, _ := s.Stats(ctx); data.TotalTests != 3 || s.stats != agg {
+		t.Errorf("append: %d tests, rebuilt = %v", data.TotalTests, s.stats != agg)
+	}
+
+	// A rewritten file can't be extended, stats start over
+	os.WriteFile(path, []byte(line(4)+line(5)), 0o644)
+	if data, _ := s.Stats(ctx); data.TotalTests != 2 || s.stats == agg {
+		t.Errorf("rewrite: %d tests, rebuilt = %v", data.TotalTests, s.stats != agg)
+	}
+
+	// Also when only an earlier line changed: same size, same last result
+	agg = s.stats
+	os.WriteFile(path, []byte(line(6)+line(5)), 0o644)
+	if s.Stats(ctx); s.stats == agg {
+		t.Error("edited line: stats not rebuilt")
+	}
+
+	// A source that can't tell whether it only grew is counted from scratch every time
+	s = NewMemoryStore([]Source{&staticSource{name: "static", results: []EvalResult{{Model: "m"}}}}).(*memoryStore)
+	s.Stats(ctx)
+	agg = s.stats
+	if s.Stats(ctx); s.stats == agg {
+		t.Error("static source: stats not rebuilt")
+	}
+}
+
+func TestStatsRetrievedContexts(t *testing.T) {
+	results := []EvalResult{
+		{Model: "a", RetrievedContexts: []RetrievedContext{{Text: "abcd"}, {Text: "ef"}}},
+		{Model: "a", RetrievedContexts: []RetrievedContext{{Text: "ghijkl"}, {Text: "m"}, {Text: "no"}, {Text: "p"}}},
+		{Model: "a"}, // Not a RAG result, left out of the averages
+		{Model: "b"},
+	}
+	data := CalculateStats(results, nil)
+	if stat := data.ModelStats["a"]; stat.ContextResults != 2 || stat.AvgContexts != 3 || stat.AvgContextChars != 8 {
+		t.Errorf("a = %+v", stat)
+	}
+	if stat := data.ModelStats["b"]; stat.ContextResults != 0 || stat.AvgContexts != 0 {
+		t.Errorf("b = %+v", stat)
+	}
+	if !hasContexts(data) || hasContexts(CalculateStats(results[2:], nil)) {
+		t.Error("hasContexts")
+	}
+}
+
+func TestStatsErrors(t *testing.T) {
+	results := []EvalResult{
+		{Model: "a", Error: "timeout", ResponseTimeMS: 30000}, // Scores 0, must not drag the averages down
+		{Model: "a", Scores: ScoreBreakdown{Combined: 0.8, Custom: map[string]float64{"accuracy": 1}}},
+		{Model: "a", Scores: ScoreBreakdown{Combined: 0.6, Custom: map[string]float64{"accuracy": 0.5}}},
+		{Model: "a", Error: "refused", Scores: ScoreBreakdown{Combined: 0.1, Custom: map[string]float64{"accuracy": 0}}},
+		{Model: "b", Error: "API error 500"},
+	}
+	data := CalculateStats(results, nil)
+	if data.Errors != 3 || data.TotalTests != 5 || math.Abs(data.AvgScore-0.7) > 1e-9 {
+		t.Errorf("errors=%d tests=%d avg=%g", data.Errors, data.TotalTests, data.AvgScore)
+	}
+	a := data.ModelStats["a"]
+	if a.TestCount != 4 || a.Errors != 2 || a.ErrorRate != 0.5 || math.Abs(a.AvgScore-0.7) > 1e-9 || a.MinScore != 0.6 || a.MaxScore != 0.8 || a.CustomScores["accuracy"] != 0.75 {
+		t.Errorf("a = %+v", a)
+	}
+	if b := data.ModelStats["b"]; b.TestCount != 1 || b.ErrorRate != 1 || b.AvgScore != 0 || b.CILow != 0 {
+		t.Errorf("b = %+v", b)
+	}
+}
+
+// The views aggregating scores leave errored results out like the dashboard does
+func TestScoredViewsMatchStats(t *testing.T) {
+	results := []EvalResult{
+		{Model: "a", TestID: "q1", Metadata: map[string]any{"run_id": "r1"}, Error: "timeout"},
+		{Model: "a", TestID: "q1", Metadata: map[string]any{"run_id": "r1"}, Scores: ScoreBreakdown{Combined: 0.8}},
+		{Model: "a", TestID: "q2", Metadata: map[string]any{"run_id": "r1"}, Scores: ScoreBreakdown{Combined: 0.6}},
+		{Model: "a", TestID: "q2", Metadata: map[string]any{"run_id": "r1"}, Error: "refused", Scores: ScoreBreakdown{Combined: 0.1}},
+	}
+	want := CalculateStats(results, nil).ModelStats["a"].AvgScore
+	if math.Abs(want-0.7) > 1e-9 {
+		t.Fatalf("dashboard average = %g", want)
+	}
+	if board := BuildLeaderboard(results); len(board.Entries) != 1 || math.Abs(board.Entries[0].AvgScore-want) > 1e-9 {
+		t.Errorf("leaderboard = %+v, want %g", board.Entries, want)
+	}
+	pivot := BuildPivot(results, "model", "run", "mean", "combined")
+	if len(pivot.Data) != 1 || len(pivot.Data[0]) != 1 || math.Abs(*pivot.Data[0][0]-want) > 1e-9 {
+		t.Errorf("pivot = %+v, want %g", pivot, want)
+	}
+	if runs := BuildRunStats(results); len(runs) != 1 || runs[0].Count != 4 || math.Abs(runs[0].AvgScore-want) > 1e-9 {
+		t.Errorf("runs = %+v, want %g", runs, want)
+	}
+	if bank := BuildQuestionBank(results); len(bank) != 2 || bank[0].Evaluations != 2 || bank[0].AvgScore != 0.8 {
+		t.Errorf("question bank = %+v", bank)
+	}
+	if got := scored(results[1:3]); len(got) != 2 || &got[0] != &results[1] {
+		t.Error("scored copied results without errors")
+	}
+}
+
+func TestTestsPageErrorsFilter(t *testing.T) {
+	withStore(t, `{"timestamp":"2025-01-01T10:00:00Z","model":"a","scores":{"combined":0.9}}
+{"timestamp":"2025-01-01T10:01:00Z","model":"a","error":"rate limited (429)","scores":{"combined":0}}`)
+
+	rec := httptest.NewRecorder()
+	dashboardHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if body := rec.Body.String(); !strings.Contains(body, "errors=only") || !strings.Contains(body, "50.0%") {
+		t.Error("dashboard is missing the Errors column")
+	}
+	rec = httptest.NewRecorder()
+	testsHandler(rec, httptest.NewRequest(http.MethodGet, "/tests?errors=only", nil))
+	if body := rec.Body.String(); !strings.Contains(body, "(1 tests)") || !strings.Contains(body, "rate limited (429)") {
+		t.Errorf("tests page did not filter to the errored result: %d", rec.Code)
+	}
+}
+
+func TestStatsTestWeights(t *testing.T) {
+	two, zero := 2.0, 0.0
+	goldenSet = &GoldenSet{Cases: []DatasetCase{{TestID: "critical", Weight: &two}}, byID: map[string]int{"critical": 0}}
+	defer func() { goldenSet = nil }()
+
+	results := []EvalResult{
+		{Model: "a", TestID: "critical", Scores: ScoreBreakdown{Combined: 1, Custom: map[string]float64{"accuracy": 1}}}, // Weight 2 from the dataset
+		{Model: "a", TestID: "trivial", Scores: ScoreBreakdown{Combined: 0.4, Custom: map[string]float64{"accuracy": 0.4}}},
+		{Model: "a", TestID: "ignored", Weight: &zero, Scores: ScoreBreakdown{Combined: 0}},
+		{Model: "b", TestID: "critical", Weight: &zero, Scores: ScoreBreakdown{Combined: 0.5}}, // The record's weight wins
+	}
+	data := CalculateStats(results, nil)
+	a := data.ModelStats["a"]
+	if math.Abs(a.AvgScore-0.8) > 1e-9 || a.ScoredWeight != 3 || math.Abs(a.CustomScores["accuracy"]-0.8) > 1e-9 || a.MinScore != 0 {
+		t.Errorf("a = %+v", a)
+	}
+	if b := data.ModelStats["b"]; b.AvgScore != 0 || b.ScoredWeight != 0 || b.TestCount != 1 {
+		t.Errorf("b = %+v", b)
+	}
+	if math.Abs(data.AvgScore-0.8) > 1e-9 {
+		t.Errorf("avg = %g", data.AvgScore)
+	}
+
+	// The leaderboard and the pivot's mean weigh tests the same way
+	board := BuildLeaderboard(results)
+	if e := board.Entries[0]; e.Config != "a" || math.Abs(e.AvgScore-a.AvgScore) > 1e-9 || e.CILow > e.AvgScore || e.CIHigh < e.AvgScore {
+		t.Errorf("leaderboard = %+v, want %g", board.Entries, a.AvgScore)
+	}
+	if e := board.Entries[1]; e.Config != "b" || e.AvgScore != 0 {
+		t.Errorf("leaderboard = %+v", board.Entries)
+	}
+	pivot := BuildPivot(results, "model", "score_type", "mean", "")
+	if math.Abs(*pivot.Data[0][0]-a.AvgScore) > 1e-9 || math.Abs(*pivot.Data[0][1]-a.CustomScores["accuracy"]) > 1e-9 {
+		t.Errorf("pivot = %+v", pivot)
+	}
+	if median := BuildPivot(results, "model", "score_type", "median", ""); *median.Data[0][0] != 0.4 {
+		t.Errorf("pivot median = %g, want the unweighted 0.4", *median.Data[0][0])
+	}
+}
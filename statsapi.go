@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+)
+
+// statsAPIVersion is bumped when a field of StatsReport is renamed, removed or changes meaning;
+// new fields are added without a bump
+const statsAPIVersion = 1
+
+// statsBins is the number of equal-width score histogram bins between 0 and 1
+const statsBins = 10
+
+// Distribution summarizes the values of one score, or the response times, of a config
+type Distribution struct {
+	Count     int     `json:"count"`
+	Mean      float64 `json:"mean"`
+	Std       float64 `json:"std"` // Sample standard deviation, 0 for a single value
+	Min       float64 `json:"min"`
+	P25       float64 `json:"p25"`
+	P50       float64 `json:"p50"`
+	P75       float64 `json:"p75"`
+	P90       float64 `json:"p90"`
+	P95       float64 `json:"p95"`
+	P99       float64 `json:"p99"`
+	Max       float64 `json:"max"`
+	Histogram []int   `json:"histogram,omitempty"` // Scores only, counts per StatsReport.HistogramEdges bin
+}
+
+// ConfigSummary is the aggregates of one config (model + custom fields)
+type ConfigSummary struct {
+	Config    string            `json:"config"`
+	Model     string            `json:"model"`
+	Fields    map[string]string `json:"fields"`
+	Params    map[string]string `json:"params"` // The fields that make up Config, see EvalResult.Params
+	Count     int               `json:"count"`
+	Errors    int               `json:"errors"`
+	ErrorRate float64           `json:"error_rate"`
+	CILow     float64           `json:"ci_low"` // 95% bootstrap interval of the combined mean
+	CIHigh    float64           `json:"ci_high"`
+	// Scores has "combined" and every custom score the config has, results with an error left out
+	Scores         map[string]Distribution `json:"scores"`
+	ResponseTimeMS *Distribution           `json:"response_time_ms"` // null when no result has a response time
+}
+
+// StatsCorrelations is the correlation matrix between scores and numeric custom fields
+type StatsCorrelations struct {
+	Method    string       `json:"method"`
+	Variables []string     `json:"variables"` // Scores first, then numeric fields as field:<name>
+	R         [][]*float64 `json:"r"`         // null below minCorrelationPairs pairs or without variance
+	Pairs     [][]int      `json:"pairs"`
+}
+
+// StatsReport is the /api/stats response, everything the dashboard computes in one document
+type StatsReport struct {
+	Version        int               `json:"version"`
+	TotalTests     int               `json:"total_tests"`
+	Errors         int               `json:"errors"`
+	AvgScore       float64           `json:"avg_score"`
+	Scores         []string          `json:"scores"`      // "combined" first, then custom scores
+	Fields         []string          `json:"fields"`      // Custom field names
+	FieldTypes     map[string]string `json:"field_types"` // Custom field -> number, bool, time or string, see fieldType
+	HistogramEdges []float64         `json:"histogram_edges"`
+	Configs        []ConfigSummary   `json:"configs"` // Sorted by config
+	Correlations   StatsCorrelations `json:"correlations"`
+}
+
+// summarize computes the distribution of values, with a histogram over [0, 1] when bins > 0
+// Values outside the range are counted in the first or last bin
+func summarize(values []float64, bins int) Distribution {
+	sorted := slices.Sorted(slices.Values(values))
+	d := Distribution{
+		Count: len(sorted),
+		Mean:  mean(sorted),
+		Std:   aggregate(sorted, "std"),
+		Min:   sorted[0],
+		P25:   percentile(sorted, 25),
+		P50:   percentile(sorted, 50),
+		P75:   percentile(sorted, 75),
+		P90:   percentile(sorted, 90),
+		P95:   percentile(sorted, 95),
+		P99:   percentile(sorted, 99),
+		Max:   sorted[len(sorted)-1],
+	}
+	if bins > 0 {
+		d.Histogram = make([]int, bins)
+		for _, v := range sorted {
+			d.Histogram[max(0, min(bins-1, int(v*float64(bins))))]++
+		}
+	}
+	return d
+}
+
+// BuildStatsReport aggregates results per config like the dashboard, adding score and response
+// time distributions and the correlations between scores and numeric fields
+func BuildStatsReport(results []EvalResult, slas latencyBudgets, method string) StatsReport {
+	data := CalculateStats(results, slas)
+	report := StatsReport{
+		Version:        statsAPIVersion,
+		TotalTests:     data.TotalTests,
+		Errors:         data.Errors,
+		AvgScore:       data.AvgScore,
+		Scores:         append([]string{"combined"}, data.CustomScores...),
+		Fields:         append([]string{}, data.CustomFieldNames...),
+		FieldTypes:     data.CustomFieldTypes,
+		HistogramEdges: make([]float64, statsBins+1),
+		Configs:        []ConfigSummary{},
+	}
+	for i := range report.HistogramEdges {
+		report.HistogramEdges[i] = float64(i) / statsBins
+	}
+
+	scores := make(map[string]map[string][]float64)
+	times := make(map[string][]float64)
+	var scored []EvalResult
+	for _, result := range results {
+		key := buildConfigKey(result)
+		if result.ResponseTimeMS > 0 {
+			times[key] = append(times[key], float64(result.ResponseTimeMS))
+		}
+		if result.Error != "" {
+			continue
+		}
+		scored = append(scored, result)
+		if scores[key] == nil {
+			scores[key] = make(map[string][]float64)
+		}
+		scores[key]["combined"] = append(scores[key]["combined"], result.Scores.Combined)
+		for name, v := range result.Scores.Custom {
+			scores[key][name] = append(scores[key][name], v)
+		}
+	}
+
+	for _, key := range data.Models {
+		stat := data.ModelStats[key]
+		config := ConfigSummary{
+			Config:    key,
+			Model:     stat.ActualModelName,
+			Fields:    stat.CustomFields,
+			Params:    stat.Params,
+			Count:     stat.TestCount,
+			Errors:    stat.Errors,
+			ErrorRate: stat.ErrorRate,
+			CILow:     stat.CILow,
+			CIHigh:    stat.CIHigh,
+			Scores:    make(map[string]Distribution, len(scores[key])),
+		}
+		for name, values := range scores[key] {
+			config.Scores[name] = summarize(values, statsBins)
+		}
+		if values := times[key]; len(values) > 0 {
+			d := summarize(values, 0)
+			config.ResponseTimeMS = &d
+		}
+		report.Configs = append(report.Configs, config)
+	}
+
+	m := BuildCorrelationMatrix(scored, method)
+	report.Correlations = StatsCorrelations{Method: m.Method, Variables: m.Variables}
+	for _, row := range m.Cells {
+		r := make([]*float64, len(row))
+		pairs := make([]int, len(row))
+		for j, cell := range row {
+			if !math.IsNaN(cell.R) {
+				r[j] = &cell.R
+			}
+			pairs[j] = cell.Pairs
+		}
+		report.Correlations.R = append(report.Correlations.R, r)
+		report.Correlations.Pairs = append(report.Correlations.Pairs, pairs)
+	}
+	if report.Correlations.Variables == nil {
+		report.Correlations.Variables = []string{}
+		report.Correlations.R, report.Correlations.Pairs = [][]*float64{}, [][]int{}
+	}
+	return report
+}
+
+// statsAPIHandler returns the computed aggregates as JSON, e.g. /api/stats?method=spearman
+// Filtered like the dashboard (model, run_id, test_id, source, dataset) and reweighted by weights
+func statsAPIHandler(w http.ResponseWriter, r *http.Request) {
+	method := r.URL.Query().Get("method")
+	if method == "" {
+		method = "pearson"
+	}
+	if method != "pearson" && method != "spearman" {
+		http.Error(w, "method must be pearson or spearman", http.StatusBadRequest)
+		return
+	}
+	results, err := store.Query(r.Context(), dashboardFilter(r))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	results, ok := reweightRequest(w, r, results)
+	if !ok {
+		return
+	}
+
+	writeCachedJSON(w, r, BuildStatsReport(results, slasFor(r.Context()), method))
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestBuildStatsReport(t *testing.T) {
+	parse := func(lines ...string) []EvalResult {
+		var results []EvalResult
+		for _, line := range lines {
+			var result EvalResult
+			if err := json.Unmarshal([]byte(line), &result); err != nil {
+				t.Fatal(err)
+			}
+			results = append(results, result)
+		}
+		return results
+	}
+
+	report := BuildStatsReport(parse(
+		`{"model":"a","response_time_ms":100,"scores":{"combined":0.2,"accuracy":0.1}}`,
+		`{"model":"a","response_time_ms":300,"scores":{"combined":0.4}}`,
+		`{"model":"a","response_time_ms":200,"scores":{"combined":1.0,"accuracy":0.9}}`,
+		`{"model":"a","error":"timeout","scores":{"combined":0}}`,
+		`{"model":"b","scores":{"combined":0.5}}`,
+	), nil, "pearson")
+	if report.Version != statsAPIVersion || report.TotalTests != 5 || report.Errors != 1 || len(report.Configs) != 2 {
+		t.Fatalf("report = %+v", report)
+	}
+	if !reflect.DeepEqual(report.Scores, []string{"combined", "accuracy"}) || len(report.HistogramEdges) != statsBins+1 {
+		t.Errorf("scores = %v, edges = %v", report.Scores, report.HistogramEdges)
+	}
+
+	a := report.Configs[0]
+	if a.Config != "a" || a.Count != 4 || a.Errors != 1 {
+		t.Errorf("config a = %+v", a)
+	}
+	// The errored result is left out of the scores
+	combined := a.Scores["combined"]
+	if combined.Count != 3 || combined.P50 != 0.4 || combined.Min != 0.2 || combined.Max != 1 {
+		t.Errorf("combined = %+v", combined)
+	}
+	if want := []int{0, 0, 1, 0, 1, 0, 0, 0, 0, 1}; !reflect.DeepEqual(combined.Histogram, want) {
+		t.Errorf("histogram = %v, want %v", combined.Histogram, want)
+	}
+	if accuracy := a.Scores["accuracy"]; accuracy.Count != 2 || accuracy.Mean != 0.5 {
+		t.Errorf("accuracy = %+v", accuracy)
+	}
+	if ms := a.ResponseTimeMS; ms == nil || ms.Count != 3 || ms.P50 != 200 || ms.Histogram != nil {
+		t.Errorf("response_time_ms = %+v", ms)
+	}
+	if b := report.Configs[1]; b.ResponseTimeMS != nil || len(b.Scores) != 1 {
+		t.Errorf("config b = %+v", b)
+	}
+
+	c := BuildStatsReport(parse(
+		`{"model":"a","scores":{"combined":0.2},"chunk_size":256}`,
+		`{"model":"a","scores":{"combined":0.4},"chunk_size":512}`,
+		`{"model":"a","scores":{"combined":0.9},"chunk_size":1024}`,
+	), nil, "spearman").Correlations
+	if c.Method != "spearman" || !reflect.DeepEqual(c.Variables, []string{"combined", "field:chunk_size"}) {
+		t.Fatalf("correlations = %+v", c)
+	}
+	if r := c.R[0][1]; r == nil || *r != 1 || c.Pairs[0][1] != 3 {
+		t.Errorf("combined/chunk_size r = %v, pairs = %d", r, c.Pairs[0][1])
+	}
+}
+
+func TestStatsAPIHandler(t *testing.T) {
+	withStore(t, `{"timestamp":"2025-01-01T10:00:00Z","model":"a","response_time_ms":120,"scores":{"combined":0.8}}
+{"timestamp":"2025-01-01T10:01:00Z","model":"b","scores":{"combined":0.6}}`)
+
+	rec := httptest.NewRecorder()
+	statsAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/api/stats?model=a", nil))
+	var got StatsReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("body = %s (%v)", rec.Body, err)
+	}
+	if got.TotalTests != 1 || len(got.Configs) != 1 || got.Configs[0].ResponseTimeMS.P50 != 120 {
+		t.Errorf("report = %s", rec.Body)
+	}
+	// One result: no correlation, which must still encode (NaN is not valid JSON)
+	if got.Correlations.R[0][0] != nil {
+		t.Errorf("correlations = %+v", got.Correlations)
+	}
+
+	rec = httptest.NewRecorder()
+	statsAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/api/stats?method=kendall", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid method = %d", rec.Code)
+	}
+}
@@ -0,0 +1,385 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// Store is the storage layer behind every handler
+// The default memoryStore merges the configured Sources (JSONL files, Redis, Postgres, remotes);
+// other backends can implement Store directly without touching the handlers
+type Store interface {
+	// Append stores new results, skipping ones already present, and returns how many were stored
+	Append(ctx context.Context, results []EvalResult) (int, error)
+	// Query returns the results matching q, in source order
+	Query(ctx context.Context, q Query) ([]EvalResult, error)
+	// Stats returns aggregated dashboard data over all results
+	Stats(ctx context.Context) (DashboardData, error)
+	// Watch delivers batches of newly seen results until ctx is canceled
+	Watch(ctx context.Context, interval time.Duration) <-chan []EvalResult
+	// Sources reports the health of each underlying source as of its last load
+	Sources(ctx context.Context) []SourceStatus
+}
+
+// SourceStatus is the load state of one source, shown on /sources
+type SourceStatus struct {
+	Name         string    `json:"name"`
+	Healthy      bool      `json:"healthy"`
+	Stale        bool      `json:"stale"`         // Failing, but still serving results from the last good load
+	Results      int       `json:"results"`       // Results served by the last load
+	InvalidLines int       `json:"invalid_lines"` // Lines skipped because they aren't valid results
+	Failures     int       `json:"consecutive_failures"`
+	LastError    string    `json:"last_error,omitempty"`
+	LastErrorAt  time.Time `json:"last_error_at,omitzero"`
+	LastSuccess  time.Time `json:"last_success,omitzero"`
+}
+
+// Query filters results, empty fields match everything
+type Query struct {
+	ConfigKey string // Full config key (model + params), see buildConfigKey
+	Model     string // Plain model name
+	RunID     string // metadata.run_id
+	TestID    string
+	Since     string // Only results with a timestamp after this one (ISO8601)
+	Origin    string // Source name (file path) the result was loaded from
+	Dataset   string // Dataset version, see EvalResult.Dataset
+}
+
+// Match reports whether result satisfies every filter in q
+func (q Query) Match(result EvalResult) bool {
+	if q.ConfigKey != "" && buildConfigKey(result) != q.ConfigKey {
+		return false
+	}
+	if q.Model != "" && result.Model != q.Model {
+		return false
+	}
+	if q.RunID != "" {
+		runID, _ := result.Metadata["run_id"].(string)
+		if runID != q.RunID {
+			return false
+		}
+	}
+	if q.TestID != "" && result.TestID != q.TestID {
+		return false
+	}
+	if q.Since != "" && result.Timestamp <= q.Since {
+		return false
+	}
+	if q.Origin != "" && result.Origin != q.Origin {
+		return false
+	}
+	if q.Dataset != "" && result.Dataset() != q.Dataset {
+		return false
+	}
+	return true
+}
+
+// resultKey identifies a result for deduplication across sources and instances
+// Two results with the same timestamp, model, test and config are treated as the same eval
+func resultKey(result EvalResult) string {
+	return result.Timestamp + "\x00" + result.TestID + "\x00" + buildConfigKey(result)
+}
+
+// ErrReadOnly is returned by Append when no source can store results
+var ErrReadOnly = errors.New("store is read-only - start goevals with a JSONL file or database argument")
+
+// memoryStore keeps the merged result set of all sources in memory
+// Every read reloads the sources, so appended lines show up on the next request
+type memoryStore struct {
+	sources []Source
+	target  Appender // First source that supports appending, nil if none
+
+	mu sync.Mutex // Serializes appends so concurrent batches don't interleave or double-insert
+
+	statusMu   sync.Mutex
+	status     []SourceStatus // Same order as sources
+	duplicates int            // Duplicates found by the last read, to log changes only
+
+	statsMu   sync.Mutex
+	stats     *StatsAggregator // Results seen by the last Stats call, nil until the first
+	statsSeen []statsMark      // Per source: how many of its results stats holds
+}
+
+// sourceLoad is what one source returned from a load
+type sourceLoad struct {
+	results []EvalResult
+	statsMark
+}
+
+// statsMark identifies a load of a source by its size and generation
+type statsMark struct {
+	count      int
+	generation uint64 // See Generational
+	appendOnly bool   // The source is Generational
+}
+
+// extends reports whether a load only added results after those of an earlier load prev
+func (m statsMark) extends(prev statsMark) bool {
+	return m.appendOnly && prev.appendOnly && m.generation == prev.generation && m.count >= prev.count
+}
+
+// NewMemoryStore creates the default store over sources
+func NewMemoryStore(sources []Source) Store {
+	s := &memoryStore{sources: sources, status: make([]SourceStatus, len(sources))}
+	for i, source := range sources {
+		s.status[i] = SourceStatus{Name: source.Name()}
+		if appender, ok := source.(Appender); ok {
+			s.target = appender
+			break
+		}
+	}
+	return s
+}
+
+// load merges the results of all sources
+// A failing source keeps whatever results it still returns (e.g. a file's last good snapshot)
+// so the others, and the last known state of that one, still show up
+// Loads are traced as part of a request; background polling (Watch) has no parent span and isn't
+func (s *memoryStore) load(ctx context.Context) []EvalResult {
+	var allResults []EvalResult
+	for _, l := range s.loadEach(ctx) {
+		allResults = append(allResults, l.results...)
+	}
+	return allResults
+}
+
+// loadEach loads every source, returning their results separately in source order
+func (s *memoryStore) loadEach(ctx context.Context) []sourceLoad {
+	loaded := make([]sourceLoad, len(s.sources))
+	for i, source := range s.sources {
+		var span *Span
+		if spanFromContext(ctx) != nil {
+			_, span = startSpan(ctx, "source.load")
+		}
+		span.SetAttr("goevals.source", source.Name())
+		var results []EvalResult
+		var err error
+		if g, ok := source.(Generational); ok {
+			results, loaded[i].generation, err = g.LoadGeneration()
+			loaded[i].appendOnly = true
+		} else {
+			results, err = source.Load()
+		}
+		invalid := 0
+		if counter, ok := source.(InvalidLineCounter); ok {
+			invalid = counter.InvalidLines()
+		}
+		s.recordLoad(i, len(results), invalid, err)
+		span.SetAttr("goevals.results", len(results))
+		span.RecordError(err)
+		span.End()
+
+		// Copies, the source may hand out the same slice again (cached snapshots)
+		loaded[i].count = len(results)
+		loaded[i].results = make([]EvalResult, len(results))
+		for j, result := range results {
+			if result.Origin == "" {
+				result.Origin = source.Name()
+			}
+			loaded[i].results[j] = result
+		}
+	}
+	return loaded
+}
+
+// recordLoad updates the status of source i, logging only when its state changes
+// so a source that stays down doesn't flood the log on every request
+func (s *memoryStore) recordLoad(i, results, invalid int, err error) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+
+	st := &s.status[i]
+	now := time.Now()
+	st.Results, st.InvalidLines = results, invalid
+	if err == nil {
+		if st.Failures > 0 {
+			log.Printf("Source %s recovered after %d failed loads", st.Name, st.Failures)
+		}
+		st.Healthy, st.Stale, st.Failures = true, false, 0
+		st.LastSuccess = now
+		return
+	}
+
+	if st.Failures == 0 || st.LastError != err.Error() {
+		log.Printf("Warning: Failed to load %s: %v", st.Name, err)
+	}
+	st.Healthy = false
+	st.Stale = results > 0
+	st.Failures++
+	st.LastError = err.Error()
+	st.LastErrorAt = now
+}
+
+// read loads all sources and applies the dedupe policy, this is what queries and stats see
+// Append and Watch use load directly: they work on stored results, duplicates included
+func (s *memoryStore) read(ctx context.Context) ([]EvalResult, error) {
+	results, duplicates, err := applyDedupe(s.load(ctx), dedupePolicy)
+
+	s.statusMu.Lock()
+	changed := duplicates != s.duplicates
+	s.duplicates = duplicates
+	s.statusMu.Unlock()
+	if changed && err == nil && duplicates > 0 {
+		log.Printf("Dropped %d duplicate result(s), keeping the latest (--dedupe latest)", duplicates)
+	}
+	return results, err
+}
+
+func (s *memoryStore) Sources(ctx context.Context) []SourceStatus {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	return append([]SourceStatus(nil), s.status...)
+}
+
+func (s *memoryStore) Append(ctx context.Context, results []EvalResult) (int, error) {
+	if s.target == nil {
+		return 0, ErrReadOnly
+	}
+	ctx, span := startSpan(ctx, "store.append")
+	defer span.End()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing := make(map[string]bool)
+	for _, result := range s.load(ctx) {
+		existing[resultKey(result)] = true
+	}
+
+	var fresh []EvalResult
+	for _, result := range results {
+		key := resultKey(result)
+		if existing[key] {
+			continue
+		}
+		existing[key] = true
+		fresh = append(fresh, result)
+	}
+
+	// The target may deduplicate further (e.g. another replica inserted the same rows)
+	_, writeSpan := startSpan(ctx, "source.append")
+	if source, ok := s.target.(Source); ok {
+		writeSpan.SetAttr("goevals.source", source.Name())
+	}
+	n, err := s.target.Append(fresh)
+	writeSpan.SetAttr("goevals.results", n)
+	writeSpan.RecordError(err)
+	writeSpan.End()
+	return n, err
+}
+
+func (s *memoryStore) Query(ctx context.Context, q Query) ([]EvalResult, error) {
+	ctx, span := startSpan(ctx, "store.query")
+	defer span.End()
+
+	results, err := s.read(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	var matched []EvalResult
+	for _, result := range results {
+		if q.Match(result) {
+			matched = append(matched, result)
+		}
+	}
+	span.SetAttr("goevals.results", len(matched))
+	return matched, nil
+}
+
+func (s *memoryStore) Stats(ctx context.Context) (DashboardData, error) {
+	ctx, span := startSpan(ctx, "store.stats")
+	defer span.End()
+
+	// Other policies can drop an already counted result whenever a newer duplicate arrives
+	if dedupePolicy != DedupeKeepAll {
+		results, err := s.read(ctx)
+		if err != nil {
+			span.RecordError(err)
+			return DashboardData{}, err
+		}
+		s.logLoaded(len(results))
+		_, calcSpan := startSpan(ctx, "stats.calculate")
+		defer calcSpan.End()
+		return CalculateStats(results, slasFor(ctx)), nil
+	}
+
+	loaded := s.loadEach(ctx)
+	_, calcSpan := startSpan(ctx, "stats.calculate")
+	defer calcSpan.End()
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+
+	// Only new results at the end of each source are added when every source vouches for having
+	// just grown (see Generational); anything else (a rewritten or truncated file, a source that
+	// can't tell) rebuilds from scratch
+	incremental := s.stats != nil
+	for i, l := range loaded {
+		incremental = incremental && l.extends(s.statsSeen[i])
+	}
+	if !incremental {
+		s.stats = NewStatsAggregator(slasFor(ctx))
+		s.statsSeen = make([]statsMark, len(loaded))
+	}
+	added := 0
+	for i, l := range loaded {
+		for _, result := range l.results[s.statsSeen[i].count:] {
+			s.stats.Add(result)
+			added++
+		}
+		s.statsSeen[i] = l.statsMark
+	}
+	calcSpan.SetAttr("goevals.results", added)
+	calcSpan.SetAttr("goevals.incremental", incremental)
+	s.logLoaded(s.stats.Len())
+	return s.stats.Data(), nil
+}
+
+// logLoaded logs the result count behind a Stats call
+func (s *memoryStore) logLoaded(n int) {
+	if n == 0 {
+		log.Println("Warning: No results yet - dashboard will show empty until first eval")
+		return
+	}
+	log.Printf("Loaded %d eval results total", n)
+}
+
+// Watch polls the sources and emits results not seen before
+// The first batch contains everything already present
+func (s *memoryStore) Watch(ctx context.Context, interval time.Duration) <-chan []EvalResult {
+	ch := make(chan []EvalResult)
+	go func() {
+		defer close(ch)
+		seen := make(map[string]bool)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			var batch []EvalResult
+			for _, result := range s.load(ctx) {
+				key := resultKey(result)
+				if !seen[key] {
+					seen[key] = true
+					batch = append(batch, result)
+				}
+			}
+			if len(batch) > 0 {
+				select {
+				case ch <- batch:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
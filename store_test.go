@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useStore makes s the store handlers read for the rest of the test
+func useStore(t *testing.T, s Store) {
+	t.Helper()
+	store = s
+	t.Cleanup(func() { store = nil })
+}
+
+// withStore serves results, JSONL lines, from a file in a temporary directory for the rest of the
+// test and returns the file's path
+func withStore(t *testing.T, results ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "evals.jsonl")
+	writeLines(t, path, results...)
+	useStore(t, NewMemoryStore([]Source{&fileSource{path: path}}))
+	return path
+}
+
+func TestMemoryStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "evals.jsonl")
+	s := NewMemoryStore([]Source{&fileSource{path: path}})
+	ctx := context.Background()
+
+	results := []EvalResult{
+		{Timestamp: "2025-01-01T10:00:00Z", Model: "a", TestID: "q1", Metadata: map[string]any{"run_id": "r1"}},
+		{Timestamp: "2025-01-01T11:00:00Z", Model: "b", TestID: "q1", CustomFields: map[string]any{"top_k": 5.0}},
+	}
+	if n, err := s.Append(ctx, results); err != nil || n != 2 {
+		t.Fatalf("Append = %d, %v", n, err)
+	}
+	if n, err := s.Append(ctx, results[:1]); err != nil || n != 0 {
+		t.Errorf("duplicate Append = %d, %v", n, err)
+	}
+
+	for _, tc := range []struct {
+		q    Query
+		want int
+	}{
+		{Query{}, 2},
+		{Query{Model: "b"}, 1},
+		{Query{ConfigKey: "b|top_k=5"}, 1},
+		{Query{RunID: "r1"}, 1},
+		{Query{Since: "2025-01-01T10:00:00Z"}, 1},
+		{Query{TestID: "q2"}, 0},
+	} {
+		got, err := s.Query(ctx, tc.q)
+		if err != nil || len(got) != tc.want {
+			t.Errorf("Query(%+v) = %d results, %v; want %d", tc.q, len(got), err, tc.want)
+		}
+	}
+
+	stats, err := s.Stats(ctx)
+	if err != nil || stats.TotalTests != 2 {
+		t.Errorf("Stats = %d tests, %v", stats.TotalTests, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates := s.Watch(ctx, 10*time.Millisecond)
+	if batch := <-updates; len(batch) != 2 {
+		t.Errorf("first Watch batch = %d results", len(batch))
+	}
+	s.Append(ctx, []EvalResult{{Timestamp: "2025-01-01T12:00:00Z", Model: "c"}})
+	if batch := <-updates; len(batch) != 1 || batch[0].Model != "c" {
+		t.Errorf("second Watch batch = %+v", batch)
+	}
+}
+
+func TestMemoryStoreReadOnly(t *testing.T) {
+	s := NewMemoryStore(nil)
+	if _, err := s.Append(context.Background(), []EvalResult{{Model: "a"}}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly, got %v", err)
+	}
+}
+
+func TestFileSourceServesLastGoodSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "evals.jsonl")
+	line := `{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"q1","scores":{"combined":0.5}}` + "\n"
+	if err := os.WriteFile(path, []byte(line), 0644); err != nil {
+		t.Fatal(err)
+	}
+	src := &fileSource{path: path}
+	s := NewMemoryStore([]Source{src})
+	ctx := context.Background()
+
+	if got, _ := s.Query(ctx, Query{}); len(got) != 1 || !s.Sources(ctx)[0].Healthy {
+		t.Fatalf("initial load = %d results, %+v", len(got), s.Sources(ctx))
+	}
+
+	// The file vanishes (NFS hiccup, log rotation gone wrong): keep serving what we had
+	os.Remove(path)
+	if got, _ := s.Query(ctx, Query{}); len(got) != 1 {
+		t.Errorf("expected last good snapshot, got %d results", len(got))
+	}
+	st := s.Sources(ctx)[0]
+	if st.Healthy || !st.Stale || st.Failures != 1 || st.LastError == "" || st.LastSuccess.IsZero() {
+		t.Errorf("status after failure = %+v", st)
+	}
+
+	// While backing off the file isn't read again
+	os.WriteFile(path, []byte(line+line), 0644)
+	if got, _ := s.Query(ctx, Query{}); len(got) != 1 {
+		t.Errorf("expected snapshot during backoff, got %d results", len(got))
+	}
+
+	// Writing through the source ends the backoff
+	if _, err := src.Append([]EvalResult{{Timestamp: "2025-01-02T10:00:00Z", Model: "b"}}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.Query(ctx, Query{}); len(got) != 3 || !s.Sources(ctx)[0].Healthy {
+		t.Errorf("after recovery = %d results, %+v", len(got), s.Sources(ctx)[0])
+	}
+}
+
+func TestFileSourceMissingFromStart(t *testing.T) {
+	s := NewMemoryStore([]Source{&fileSource{path: filepath.Join(t.TempDir(), "missing.jsonl")}})
+	start := time.Now()
+	got, _ := s.Query(context.Background(), Query{})
+	if len(got) != 0 || time.Since(start) > fileReadRetryDelay {
+		t.Errorf("a file that never existed should fail fast: %d results in %s", len(got), time.Since(start))
+	}
+	if st := s.Sources(context.Background())[0]; st.Healthy || st.Stale {
+		t.Errorf("status = %+v", st)
+	}
+}
+
+func TestResultOrigin(t *testing.T) {
+	dir := t.TempDir()
+	var sources []Source
+	for _, name := range []string{"run1.jsonl", "run2.jsonl"} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(`{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"`+name+`","scores":{"combined":0.5}}`+"\n"), 0644)
+		sources = append(sources, &fileSource{path: path})
+	}
+	useStore(t, NewMemoryStore(sources))
+
+	run2 := filepath.Join(dir, "run2.jsonl")
+	got, err := store.Query(context.Background(), Query{Origin: run2})
+	if err != nil || len(got) != 1 || got[0].TestID != "run2.jsonl" || got[0].Origin != run2 {
+		t.Fatalf("Query by origin = %+v, %v", got, err)
+	}
+	if contentHash(got[0]) != contentHash(EvalResult{Timestamp: got[0].Timestamp, Model: "a", TestID: "run2.jsonl", Scores: got[0].Scores}) {
+		t.Error("origin must not be part of the stored content")
+	}
+
+	rec := httptest.NewRecorder()
+	testsHandler(rec, httptest.NewRequest(http.MethodGet, "/tests?source="+url.QueryEscape(run2), nil))
+	body := rec.Body.String()
+	if strings.Contains(body, ">run1.jsonl<") || !strings.Contains(body, `<div class="detail-label">Source</div>`) {
+		t.Error("tests page should filter by source and show it in the modal")
+	}
+
+	rec = httptest.NewRecorder()
+	dashboardHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !strings.Contains(rec.Body.String(), `data-param="source"`) {
+		t.Error("dashboard should offer a source filter with several sources")
+	}
+}
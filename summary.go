@@ -0,0 +1,418 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SummaryRow is one group of the summary command's comparison table
+type SummaryRow struct {
+	Name         string             `json:"name"`
+	Tests        int                `json:"tests"`
+	Errors       int                `json:"errors"` // Results with an error, left out of the scores
+	AvgScore     float64            `json:"avg_score"`
+	MinScore     float64            `json:"min_score"`
+	MaxScore     float64            `json:"max_score"`
+	AvgLatencyMS float64            `json:"avg_latency_ms"` // Over results that report a response time, 0 if none do
+	Scores       map[string]float64 `json:"scores"`         // Average of each custom score, over results that have it
+}
+
+// summaryDimensions are the --by values, plus field:<name>
+var summaryDimensions = []string{"model", "config", "run", "test_id", "source", "dataset"}
+
+// BuildSummary groups results along by (one of summaryDimensions or field:<name>) and sorts the
+// groups by sortBy: score, tests and custom scores high to low, name and latency low to high
+// Returns the rows and the custom score names present, sorted
+func BuildSummary(results []EvalResult, by, sortBy string) ([]SummaryRow, []string) {
+	type group struct {
+		tests, errors     int
+		combined, latency []float64
+		custom            map[string][]float64
+	}
+	groups := make(map[string]*group)
+	scoreSet := make(map[string]bool)
+	for _, result := range results {
+		name := pivotLabel(result, by)
+		if name == "" {
+			name = "(none)"
+		}
+		g := groups[name]
+		if g == nil {
+			g = &group{custom: make(map[string][]float64)}
+			groups[name] = g
+		}
+		g.tests++
+		if result.ResponseTimeMS > 0 {
+			g.latency = append(g.latency, float64(result.ResponseTimeMS))
+		}
+		if result.Error != "" {
+			g.errors++
+			continue
+		}
+		g.combined = append(g.combined, result.Scores.Combined)
+		for score, v := range result.Scores.Custom {
+			g.custom[score] = append(g.custom[score], v)
+			scoreSet[score] = true
+		}
+	}
+
+	rows := make([]SummaryRow, 0, len(groups))
+	for name, g := range groups {
+		row := SummaryRow{Name: name, Tests: g.tests, Errors: g.errors, Scores: make(map[string]float64)}
+		if len(g.combined) > 0 {
+			row.AvgScore, row.MinScore, row.MaxScore = mean(g.combined), slices.Min(g.combined), slices.Max(g.combined)
+		}
+		if len(g.latency) > 0 {
+			row.AvgLatencyMS = mean(g.latency)
+		}
+		for score, values := range g.custom {
+			row.Scores[score] = mean(values)
+		}
+		rows = append(rows, row)
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		switch sortBy {
+		case "name":
+			return a.Name < b.Name
+		case "tests":
+			if a.Tests != b.Tests {
+				return a.Tests > b.Tests
+			}
+		case "latency":
+			if a.AvgLatencyMS != b.AvgLatencyMS {
+				return a.AvgLatencyMS < b.AvgLatencyMS
+			}
+		case "score":
+			if a.AvgScore != b.AvgScore {
+				return a.AvgScore > b.AvgScore
+			}
+		default:
+			va, okA := a.Scores[sortBy]
+			vb, okB := b.Scores[sortBy]
+			if okA != okB {
+				return okA // Groups without the score go last
+			}
+			if va != vb {
+				return va > vb
+			}
+		}
+		return a.Name < b.Name
+	})
+	return rows, sortedKeys(scoreSet, "")
+}
+
+// summaryLine formats a row's cells for writeTable, with an errors column when errors is set
+func summaryLine(row SummaryRow, scores []string, errors bool) []string {
+	latency := "-"
+	if row.AvgLatencyMS > 0 {
+		latency = strconv.FormatFloat(row.AvgLatencyMS, 'f', 0, 64)
+	}
+	line := []string{row.Name, strconv.Itoa(row.Tests)}
+	if errors {
+		line = append(line, strconv.Itoa(row.Errors))
+	}
+	line = append(line, formatScore(row.AvgScore), formatScore(row.MinScore), formatScore(row.MaxScore), latency)
+	for _, score := range scores {
+		if v, ok := row.Scores[score]; ok {
+			line = append(line, formatScore(v))
+		} else {
+			line = append(line, "-")
+		}
+	}
+	return line
+}
+
+// writeSummary prints rows as JSON, an aligned table or plain tab separated lines
+func writeSummary(w io.Writer, rows []SummaryRow, scores []string, by, format string) error {
+	if format == "json" {
+		return writeJSON(w, rows)
+	}
+	errors := slices.ContainsFunc(rows, func(row SummaryRow) bool { return row.Errors > 0 })
+	header := []string{by, "tests"}
+	if errors {
+		header = append(header, "errors")
+	}
+	lines := [][]string{append(append(header, "avg", "min", "max", "latency_ms"), scores...)}
+	for _, row := range rows {
+		lines = append(lines, summaryLine(row, scores, errors))
+	}
+	return writeTable(w, lines, format)
+}
+
+// formatScore prints a score with the dashboard's three decimals
+func formatScore(v float64) string {
+	return strconv.FormatFloat(v, 'f', 3, 64)
+}
+
+// validDimension reports whether by is a --by value
+func validDimension(by string) bool {
+	return slices.Contains(summaryDimensions, by) || strings.HasPrefix(by, "field:")
+}
+
+// runSummary implements `goevals summary`, printing the comparison table without starting the server
+func runSummary(args []string) int {
+	fs := newFlagSet("summary", "[flags] <source1> [source2] [...]",
+		"Prints the aggregated comparison table (tests, avg/min/max score, latency and custom",
+		"score averages per group) to stdout")
+	by := fs.String("by", "model", "group by `dimension`: "+strings.Join(summaryDimensions, ", ")+" or field:<name>")
+	sortBy := fs.String("sort", "score", "sort by `column`: score, name, tests, latency or a custom score name")
+	format := fs.String("format", "table", "output `format`: table, plain (tab separated) or json")
+	weights := weightsFlag(fs)
+	sources := parseFlags(fs, args)
+	if len(sources) == 0 {
+		fs.Usage()
+		return 2
+	}
+	if !validDimension(*by) {
+		fmt.Fprintf(os.Stderr, "Error: --by must be %s or field:<name>\n", strings.Join(summaryDimensions, ", "))
+		return 2
+	}
+	if !slices.Contains([]string{"table", "plain", "json"}, *format) {
+		fmt.Fprintln(os.Stderr, "Error: --format must be table, plain or json")
+		return 2
+	}
+
+	results, err := commandResults(sources, *weights)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if len(results) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no results to summarize")
+		return 2
+	}
+
+	rows, scores := BuildSummary(results, *by, *sortBy)
+	if !slices.Contains([]string{"score", "name", "tests", "latency"}, *sortBy) && !slices.Contains(scores, *sortBy) {
+		fmt.Fprintf(os.Stderr, "Error: --sort must be score, name, tests, latency or one of the custom scores (%s)\n", strings.Join(scores, ", "))
+		return 2
+	}
+	if err := writeSummary(os.Stdout, rows, scores, *by, *format); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// GroupDelta is one group of the compare command: its average combined score in the baseline
+// and the candidate, nil on the side where the group has no results
+type GroupDelta struct {
+	Name           string   `json:"name"`
+	Baseline       *float64 `json:"baseline"`
+	Candidate      *float64 `json:"candidate"`
+	Delta          *float64 `json:"delta"` // Candidate - baseline, nil unless both sides have results
+	BaselineTests  int      `json:"baseline_tests"`
+	CandidateTests int      `json:"candidate_tests"`
+	Status         string   `json:"status"` // regressed, improved, unchanged, new or removed
+}
+
+// BuildGroupDeltas matches the groups of baseline and candidate along by
+// A delta beyond threshold either way is a regression or an improvement. Rows are ordered worst
+// regression first, then groups only on one side, by name
+func BuildGroupDeltas(baseline, candidate []EvalResult, by string, threshold float64) []GroupDelta {
+	before, _ := BuildSummary(baseline, by, "name")
+	after, _ := BuildSummary(candidate, by, "name")
+	rows := make(map[string]*GroupDelta)
+	for _, s := range before {
+		avg := s.AvgScore
+		rows[s.Name] = &GroupDelta{Name: s.Name, Baseline: &avg, BaselineTests: s.Tests, Status: "removed"}
+	}
+	for _, s := range after {
+		avg := s.AvgScore
+		row := rows[s.Name]
+		if row == nil {
+			row = &GroupDelta{Name: s.Name, Status: "new"}
+			rows[s.Name] = row
+		}
+		row.Candidate, row.CandidateTests = &avg, s.Tests
+		if row.Baseline != nil {
+			delta := avg - *row.Baseline
+			row.Delta = &delta
+			switch {
+			case delta < -threshold:
+				row.Status = "regressed"
+			case delta > threshold:
+				row.Status = "improved"
+			default:
+				row.Status = "unchanged"
+			}
+		}
+	}
+
+	list := make([]GroupDelta, 0, len(rows))
+	for _, row := range rows {
+		list = append(list, *row)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if (a.Delta == nil) != (b.Delta == nil) {
+			return a.Delta != nil
+		}
+		if a.Delta != nil && *a.Delta != *b.Delta {
+			return *a.Delta < *b.Delta
+		}
+		return a.Name < b.Name
+	})
+	return list
+}
+
+// runCompare implements `goevals compare`, the per-group score change between two sets of results
+func runCompare(args []string) int {
+	fs := newFlagSet("compare", "[flags] <baseline> <candidate>",
+		"Compares the average combined score of each group between two sources, e.g. the last",
+		"release and a new run. Exits with status 1 on a regression when --fail-on-regression is set")
+	by := fs.String("by", "model", "group by `dimension`: "+strings.Join(summaryDimensions, ", ")+" or field:<name>")
+	threshold := fs.Float64("threshold", 0.05, "score `change` beyond which a group counts as regressed or improved")
+	format := fs.String("format", "table", "output `format`: table, plain (tab separated) or json")
+	failOnRegression := fs.Bool("fail-on-regression", false, "exit with status 1 if any group regressed")
+	weights := weightsFlag(fs)
+	sources := parseFlags(fs, args)
+	if len(sources) != 2 {
+		fs.Usage()
+		return 2
+	}
+	if !validDimension(*by) {
+		fmt.Fprintf(os.Stderr, "Error: --by must be %s or field:<name>\n", strings.Join(summaryDimensions, ", "))
+		return 2
+	}
+	if !slices.Contains([]string{"table", "plain", "json"}, *format) {
+		fmt.Fprintln(os.Stderr, "Error: --format must be table, plain or json")
+		return 2
+	}
+
+	baseline, err := commandResults(sources[:1], *weights)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	candidate, err := commandResults(sources[1:], *weights)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	warnDatasetMismatch(os.Stderr, baseline, candidate)
+
+	rows := BuildGroupDeltas(baseline, candidate, *by, *threshold)
+	if *format == "json" {
+		err = writeJSON(os.Stdout, rows)
+	} else {
+		optional := func(v *float64, signed bool) string {
+			switch {
+			case v == nil:
+				return "-"
+			case signed:
+				return strconv.FormatFloat(*v, 'f', 3, 64)
+			}
+			return formatScore(*v)
+		}
+		lines := [][]string{{*by, "baseline", "candidate", "delta", "tests", "status"}}
+		for _, row := range rows {
+			delta := optional(row.Delta, true)
+			if row.Delta != nil && *row.Delta > 0 {
+				delta = "+" + delta
+			}
+			tests := fmt.Sprintf("%d → %d", row.BaselineTests, row.CandidateTests)
+			lines = append(lines, []string{row.Name, optional(row.Baseline, false), optional(row.Candidate, false), delta, tests, row.Status})
+		}
+		err = writeTable(os.Stdout, lines, *format)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if *failOnRegression {
+		for _, row := range rows {
+			if row.Status == "regressed" {
+				return 1
+			}
+		}
+	}
+	return 0
+}
+
+// checkThresholds returns why row fails the check command's thresholds, nothing if it passes
+// score is combined or a custom score name; a zero threshold is not checked
+func checkThresholds(row SummaryRow, score string, minScore, maxLatencyMS float64, minTests int) []string {
+	var failures []string
+	if minScore > 0 {
+		v, ok := row.AvgScore, true
+		if score != "combined" {
+			v, ok = row.Scores[score]
+		}
+		switch {
+		case !ok:
+			failures = append(failures, fmt.Sprintf("no %s scores", score))
+		case v < minScore:
+			failures = append(failures, fmt.Sprintf("%s %s < %s", score, formatScore(v), formatScore(minScore)))
+		}
+	}
+	if maxLatencyMS > 0 && row.AvgLatencyMS > maxLatencyMS {
+		failures = append(failures, fmt.Sprintf("avg latency %.0fms > %.0fms", row.AvgLatencyMS, maxLatencyMS))
+	}
+	if minTests > 0 && row.Tests < minTests {
+		failures = append(failures, fmt.Sprintf("%d tests < %d", row.Tests, minTests))
+	}
+	return failures
+}
+
+// runCheck implements `goevals check`, a CI gate on the comparison table
+func runCheck(args []string) int {
+	fs := newFlagSet("check", "[flags] <source1> [source2] [...]",
+		"Checks every group against the given thresholds, e.g. as the last step of a CI job",
+		"Exits with status 1 if any group fails")
+	by := fs.String("by", "model", "group by `dimension`: "+strings.Join(summaryDimensions, ", ")+" or field:<name>")
+	score := fs.String("score", "combined", "score `name` --min-score applies to: combined or a custom score")
+	minScore := fs.Float64("min-score", 0, "minimum average `score` of each group")
+	maxLatency := fs.Float64("max-latency-ms", 0, "maximum average response time of each group in `ms`")
+	minTests := fs.Int("min-tests", 0, "minimum `number` of results in each group")
+	weights := weightsFlag(fs)
+	sources := parseFlags(fs, args)
+	if len(sources) == 0 {
+		fs.Usage()
+		return 2
+	}
+	if *minScore <= 0 && *maxLatency <= 0 && *minTests <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: set at least one of --min-score, --max-latency-ms or --min-tests")
+		return 2
+	}
+	if !validDimension(*by) {
+		fmt.Fprintf(os.Stderr, "Error: --by must be %s or field:<name>\n", strings.Join(summaryDimensions, ", "))
+		return 2
+	}
+
+	results, err := commandResults(sources, *weights)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if len(results) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no results to check")
+		return 2
+	}
+
+	rows, _ := BuildSummary(results, *by, "name")
+	failed := 0
+	for _, row := range rows {
+		if failures := checkThresholds(row, *score, *minScore, *maxLatency, *minTests); len(failures) > 0 {
+			fmt.Printf("✗ %s: %s\n", row.Name, strings.Join(failures, ", "))
+			failed++
+		} else {
+			fmt.Printf("✓ %s\n", row.Name)
+		}
+	}
+	if failed > 0 {
+		fmt.Println(strings.Repeat("-", 40))
+		fmt.Printf("Check FAILED for %d of %d %s group(s)\n", failed, len(rows), *by)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBuildSummary(t *testing.T) {
+	results := []EvalResult{
+		{Model: "a", ResponseTimeMS: 100, Scores: ScoreBreakdown{Combined: 0.5, Custom: map[string]float64{"faithfulness": 0.9}}},
+		{Model: "a", ResponseTimeMS: 300, Scores: ScoreBreakdown{Combined: 0.7, Custom: map[string]float64{"faithfulness": 0.7}}},
+		{Model: "b", Scores: ScoreBreakdown{Combined: 0.9}},
+	}
+	rows, scores := BuildSummary(results, "model", "score")
+	if len(rows) != 2 || rows[0].Name != "b" || rows[1].Name != "a" {
+		t.Fatalf("rows = %+v", rows)
+	}
+	a := rows[1]
+	if a.Tests != 2 || a.AvgScore != 0.6 || a.MinScore != 0.5 || a.MaxScore != 0.7 || a.AvgLatencyMS != 200 || a.Scores["faithfulness"] != 0.8 {
+		t.Errorf("a = %+v", a)
+	}
+	if !slices.Equal(scores, []string{"faithfulness"}) {
+		t.Errorf("scores = %v", scores)
+	}
+
+	if rows, _ := BuildSummary(results, "model", "faithfulness"); rows[0].Name != "a" {
+		t.Errorf("sort by custom score: first = %s, groups without it go last", rows[0].Name)
+	}
+	errored := append(slices.Clone(results), EvalResult{Model: "b", Error: "timeout", ResponseTimeMS: 5000}, EvalResult{Model: "c", Error: "timeout"})
+	if rows, _ := BuildSummary(errored, "model", "name"); rows[1].Tests != 2 || rows[1].Errors != 1 || rows[1].AvgScore != 0.9 || rows[1].AvgLatencyMS != 5000 ||
+		rows[2].Tests != 1 || rows[2].AvgScore != 0 {
+		t.Errorf("errored results = %+v", rows)
+	}
+	var errBuf bytes.Buffer
+	if writeSummary(&errBuf, []SummaryRow{{Name: "a", Tests: 3, Errors: 1}}, nil, "model", "plain"); !strings.HasPrefix(errBuf.String(), "model\ttests\terrors\tavg") {
+		t.Errorf("errors column missing:\n%s", errBuf.String())
+	}
+	if rows, _ := BuildSummary(results, "test_id", "score"); len(rows) != 1 || rows[0].Name != "(none)" || rows[0].Tests != 3 {
+		t.Errorf("results without a label = %+v", rows)
+	}
+
+	var buf bytes.Buffer
+	writeSummary(&buf, rows, scores, "model", "plain")
+	want := "model\ttests\tavg\tmin\tmax\tlatency_ms\tfaithfulness\nb\t1\t0.900\t0.900\t0.900\t-\t-\na\t2\t0.600\t0.500\t0.700\t200\t0.800\n"
+	if buf.String() != want {
+		t.Errorf("plain =\n%q\nwant\n%q", buf.String(), want)
+	}
+	buf.Reset()
+	writeSummary(&buf, rows, scores, "model", "table")
+	if lines := strings.Split(buf.String(), "\n"); !strings.HasPrefix(lines[1], "-----  -----") || !strings.HasPrefix(lines[3], "a      2      0.600") {
+		t.Errorf("table =\n%s", buf.String())
+	}
+}
+
+func TestBuildGroupDeltas(t *testing.T) {
+	baseline := []EvalResult{
+		{Model: "a", Scores: ScoreBreakdown{Combined: 0.8}},
+		{Model: "b", Scores: ScoreBreakdown{Combined: 0.5}},
+		{Model: "c", Scores: ScoreBreakdown{Combined: 0.6}},
+		{Model: "old", Scores: ScoreBreakdown{Combined: 0.6}},
+	}
+	candidate := []EvalResult{
+		{Model: "a", Scores: ScoreBreakdown{Combined: 0.6}},
+		{Model: "b", Scores: ScoreBreakdown{Combined: 0.7}},
+		{Model: "c", Scores: ScoreBreakdown{Combined: 0.62}},
+		{Model: "new", Scores: ScoreBreakdown{Combined: 0.9}},
+	}
+	rows := BuildGroupDeltas(baseline, candidate, "model", 0.05)
+	var got []string
+	for _, row := range rows {
+		got = append(got, row.Name+":"+row.Status)
+	}
+	want := []string{"a:regressed", "c:unchanged", "b:improved", "new:new", "old:removed"}
+	if !slices.Equal(got, want) {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+	if rows[3].Baseline != nil || rows[3].Delta != nil || *rows[3].Candidate != 0.9 || rows[3].CandidateTests != 1 {
+		t.Errorf("new = %+v", rows[3])
+	}
+}
+
+func TestCheckThresholds(t *testing.T) {
+	row := SummaryRow{Name: "a", Tests: 3, AvgScore: 0.65, AvgLatencyMS: 1200, Scores: map[string]float64{"faithfulness": 0.9}}
+	if failures := checkThresholds(row, "combined", 0.7, 1000, 5); len(failures) != 3 || failures[0] != "combined 0.650 < 0.700" {
+		t.Errorf("failures = %v", failures)
+	}
+	if failures := checkThresholds(row, "faithfulness", 0.7, 0, 0); len(failures) != 0 {
+		t.Errorf("custom score passing: %v", failures)
+	}
+	if failures := checkThresholds(row, "relevance", 0.7, 0, 0); len(failures) != 1 || failures[0] != "no relevance scores" {
+		t.Errorf("missing score: %v", failures)
+	}
+}
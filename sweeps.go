@@ -0,0 +1,269 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// SweepPoint is the average score of one model at one parameter value
+type SweepPoint struct {
+	Value    float64 `json:"value"`
+	AvgScore float64 `json:"avg_score"`
+	Count    int     `json:"count"`
+
+	X, Y float64 `json:"-"` // Position in the chart, see layoutSweep
+}
+
+// SweepSeries is one model's line in a sweep chart
+type SweepSeries struct {
+	Model  string       `json:"model"`
+	Points []SweepPoint `json:"points"` // Sorted by Value
+	Color  string       `json:"-"`      // Series class, see seriesColor
+	Path   string       `json:"-"`      // SVG polyline points
+}
+
+// SweepChart plots the average score against one numeric custom field
+type SweepChart struct {
+	Field  string        `json:"field"`
+	Score  string        `json:"score"`
+	LogX   bool          `json:"log_x"` // Values span several orders of magnitude (e.g. learning rates)
+	Series []SweepSeries `json:"series"`
+
+	Frame *ChartFrame `json:"-"`
+}
+
+// Chart size in SVG user units
+const (
+	sweepWidth  = 560
+	sweepHeight = 300
+)
+
+// BuildSweeps returns one chart per numeric custom field that takes at least two distinct values
+// score is "combined" or the name of a custom score; results without it are skipped
+func BuildSweeps(results []EvalResult, score string) []SweepChart {
+	type bucket struct {
+		sum   float64
+		count int
+	}
+	// field -> model -> value -> bucket
+	data := make(map[string]map[string]map[float64]*bucket)
+
+	for _, result := range scored(results) {
+		value, ok := result.Scores.Combined, true
+		if score != "combined" {
+			value, ok = result.Scores.Custom[score]
+		}
+		if !ok {
+			continue
+		}
+		for field, raw := range result.Fields() {
+			x, isNum := raw.(float64)
+			if !isNum {
+				continue
+			}
+			if data[field] == nil {
+				data[field] = make(map[string]map[float64]*bucket)
+			}
+			if data[field][result.Model] == nil {
+				data[field][result.Model] = make(map[float64]*bucket)
+			}
+			b := data[field][result.Model][x]
+			if b == nil {
+				b = &bucket{}
+				data[field][result.Model][x] = b
+			}
+			b.sum += value
+			b.count++
+		}
+	}
+
+	fields := make([]string, 0, len(data))
+	for field := range data {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	var charts []SweepChart
+	for _, field := range fields {
+		chart := SweepChart{Field: field, Score: score}
+		distinct := make(map[float64]bool)
+
+		models := make([]string, 0, len(data[field]))
+		for model := range data[field] {
+			models = append(models, model)
+		}
+		sort.Strings(models)
+
+		for _, model := range models {
+			series := SweepSeries{Model: model}
+			for x, b := range data[field][model] {
+				distinct[x] = true
+				series.Points = append(series.Points, SweepPoint{Value: x, AvgScore: b.sum / float64(b.count), Count: b.count})
+			}
+			sort.Slice(series.Points, func(i, j int) bool { return series.Points[i].Value < series.Points[j].Value })
+			chart.Series = append(chart.Series, series)
+		}
+
+		if len(distinct) < 2 {
+			continue // Constant fields aren't a sweep
+		}
+		layoutSweep(&chart, distinct)
+		charts = append(charts, chart)
+	}
+	return charts
+}
+
+// layoutSweep computes point positions, polylines and axis ticks
+func layoutSweep(chart *SweepChart, distinct map[float64]bool) {
+	values := make([]float64, 0, len(distinct))
+	for v := range distinct {
+		values = append(values, v)
+	}
+	sort.Float64s(values)
+
+	maxY := 1.0
+	for _, series := range chart.Series {
+		for _, p := range series.Points {
+			maxY = math.Max(maxY, p.AvgScore)
+		}
+	}
+
+	frame := newChartFrame(sweepWidth, sweepHeight, values[0], values[len(values)-1], maxY)
+	for i := range chart.Series {
+		series := &chart.Series[i]
+		series.Color = seriesColor(i)
+		xs := make([]float64, len(series.Points))
+		ys := make([]float64, len(series.Points))
+		for j := range series.Points {
+			p := &series.Points[j]
+			p.X, p.Y = frame.X(p.Value), frame.Y(p.AvgScore)
+			xs[j], ys[j] = p.X, p.Y
+		}
+		series.Path = polyline(xs, ys)
+	}
+	frame.tickX(values, formatSweepValue)
+	chart.Frame = frame
+	chart.LogX = frame.LogX
+}
+
+// formatSweepValue prints parameter values compactly (512, 0.7, 1e-05)
+func formatSweepValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
+
+// scoreNames returns "combined" followed by the custom score names found in results
+func scoreNames(results []EvalResult) []string {
+	set := make(map[string]bool)
+	for _, result := range results {
+		for name := range result.Scores.Custom {
+			set[name] = true
+		}
+	}
+	return append([]string{"combined"}, sortedKeys(set, "")...)
+}
+
+// sweepsHandler renders score vs parameter charts
+func sweepsHandler(w http.ResponseWriter, r *http.Request) {
+	results, err := store.Query(r.Context(), Query{})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	score := r.URL.Query().Get("score")
+	if score == "" {
+		score = "combined"
+	}
+
+	data := struct {
+		Title    string
+		Subtitle string
+		Score    string
+		Scores   []string
+		Charts   []SweepChart
+	}{
+		Title:    "Parameter Sweeps",
+		Subtitle: "Average score vs numeric config fields, per model",
+		Score:    score,
+		Scores:   scoreNames(results),
+		Charts:   BuildSweeps(results, score),
+	}
+
+	renderPage(w, r, "sweeps", sweepsTemplate, data)
+}
+
+// sweepsAPIHandler returns the sweep data as JSON (?score= selects the score, default combined)
+func sweepsAPIHandler(w http.ResponseWriter, r *http.Request) {
+	results, err := store.Query(r.Context(), Query{})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	score := r.URL.Query().Get("score")
+	if score == "" {
+		score = "combined"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(BuildSweeps(results, score)); err != nil {
+		log.Printf("Error encoding JSON: %v", err)
+	}
+}
+
+const sweepsTemplate = `
+{{ define "style" }}
+        .sweep-grid {
+            display: grid;
+            grid-template-columns: repeat(auto-fill, minmax(480px, 1fr));
+            gap: 2rem;
+        }
+{{ end }}
+
+{{ define "content" }}
+        <div class="panel">
+            <form method="get">
+                <label class="muted" for="score">Score</label>
+                <select id="score" name="score" onchange="this.form.submit()">
+                    {{ range .Scores }}<option value="{{ . }}" {{ if eq . $.Score }}selected{{ end }}>{{ . }}</option>{{ end }}
+                </select>
+            </form>
+        </div>
+
+        {{ if .Charts }}
+        <div class="sweep-grid">
+            {{ range .Charts }}
+            <div class="panel chart">
+                <h2 class="mono">{{ .Field }}{{ if .LogX }} <span class="muted" style="font-size: 0.75rem;">(log scale)</span>{{ end }}</h2>
+                <svg viewBox="0 0 {{ .Frame.Width }} {{ .Frame.Height }}" role="img" aria-label="{{ .Score }} vs {{ .Field }}">
+                    {{ template "chart-axes" .Frame }}
+                    {{ $field := .Field }}
+                    {{ range .Series }}
+                    <g class="{{ .Color }}">
+                        <polyline points="{{ .Path }}" fill="none" stroke="currentColor" stroke-width="2"/>
+                        {{ $series := . }}
+                        {{ range .Points }}
+                        <circle cx="{{ .X }}" cy="{{ .Y }}" r="4" fill="currentColor">
+                            <title>{{ $series.Model }} · {{ $field }}={{ .Value }}: {{ printf "%.3f" .AvgScore }} (n={{ .Count }})</title>
+                        </circle>
+                        {{ end }}
+                    </g>
+                    {{ end }}
+                </svg>
+                <div class="legend">
+                    {{ range .Series }}<span><span class="legend-swatch {{ .Color }}"></span>{{ .Model }}</span>{{ end }}
+                </div>
+            </div>
+            {{ end }}
+        </div>
+        {{ else }}
+        <div class="panel">
+            <p class="muted">No sweeps found. Log numeric custom fields such as <span class="mono">chunk_size</span>, <span class="mono">top_k</span> or <span class="mono">temperature</span> with at least two different values.</p>
+        </div>
+        {{ end }}
+{{ end }}`
@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestBuildSweeps(t *testing.T) {
+	results := []EvalResult{
+		{Model: "a", Scores: ScoreBreakdown{Combined: 0.4}, CustomFields: map[string]any{"top_k": 3.0, "lr": 1e-5, "mode": "dense", "seed": 1.0}},
+		{Model: "a", Scores: ScoreBreakdown{Combined: 0.6}, CustomFields: map[string]any{"top_k": 3.0, "lr": 1e-3, "seed": 1.0}},
+		{Model: "a", Scores: ScoreBreakdown{Combined: 0.9}, CustomFields: map[string]any{"top_k": 5.0, "seed": 1.0}},
+		{Model: "b", Scores: ScoreBreakdown{Combined: 0.2, Custom: map[string]float64{"accuracy": 1}}, CustomFields: map[string]any{"top_k": 5.0}},
+	}
+
+	charts := BuildSweeps(results, "combined")
+	if len(charts) != 2 || charts[0].Field != "lr" || charts[1].Field != "top_k" {
+		t.Fatalf("expected lr and top_k charts (seed is constant, mode is a string), got %+v", charts)
+	}
+	if !charts[0].LogX || charts[1].LogX {
+		t.Errorf("log scale: lr=%v top_k=%v", charts[0].LogX, charts[1].LogX)
+	}
+
+	topK := charts[1]
+	if len(topK.Series) != 2 || topK.Series[0].Model != "a" {
+		t.Fatalf("series = %+v", topK.Series)
+	}
+	a := topK.Series[0].Points
+	if len(a) != 2 || a[0].Value != 3 || a[0].Count != 2 || a[0].AvgScore != 0.5 || a[1].AvgScore != 0.9 {
+		t.Errorf("model a points = %+v", a)
+	}
+	if a[0].X != chartLeft || a[1].X != sweepWidth-chartRight || topK.Series[0].Path == "" {
+		t.Errorf("points not laid out across the plot: %+v", a)
+	}
+
+	// Custom score only counts results that have it
+	charts = BuildSweeps(results, "accuracy")
+	if len(charts) != 0 {
+		t.Errorf("single result can't form a sweep, got %+v", charts)
+	}
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"cmp"
+	"math"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TableSort is the order of a server-sorted table, from ?sort=avg_score&dir=desc
+// Older links put the direction in the key instead, ?sort=-combined, which still works
+type TableSort struct {
+	Key  string
+	Desc bool
+}
+
+// parseTableSort reads the sort and dir parameters, def when sort is missing
+func parseTableSort(params url.Values, def TableSort) TableSort {
+	key := params.Get("sort")
+	if key == "" {
+		return def
+	}
+	s := TableSort{Key: strings.TrimPrefix(key, "-"), Desc: strings.HasPrefix(key, "-")}
+	switch params.Get("dir") {
+	case "asc":
+		s.Desc = false
+	case "desc":
+		s.Desc = true
+	}
+	return s
+}
+
+// Class is the header class of the column key: sorted-asc or sorted-desc when the table is
+// sorted by it
+func (s TableSort) Class(key string) string {
+	switch {
+	case s.Key != key:
+		return ""
+	case s.Desc:
+		return "sorted-desc"
+	}
+	return "sorted-asc"
+}
+
+// URL links path with params sorted by key, in the opposite direction when already sorted by it
+// and ascending otherwise
+func (s TableSort) URL(path string, params url.Values, key string) string {
+	link := url.Values{}
+	for name, values := range params {
+		if name != "sort" && name != "dir" {
+			link[name] = values
+		}
+	}
+	link.Set("sort", key)
+	link.Set("dir", "asc")
+	if s.Key == key && !s.Desc {
+		link.Set("dir", "desc")
+	}
+	return path + "?" + link.Encode()
+}
+
+// sortRows stably sorts rows by the cell values value returns for them, see compareCells
+func sortRows[T any](rows []T, desc bool, value func(T) any) {
+	slices.SortStableFunc(rows, func(a, b T) int {
+		return compareCells(value(a), value(b), desc)
+	})
+}
+
+// compareCells orders two cell values by their type: numbers by value, times chronologically and
+// strings case-insensitively, falling back to their bytes so that equal-looking labels still have
+// a fixed order. A missing value (nil or NaN) goes last in either direction
+func compareCells(a, b any, desc bool) int {
+	aMissing, bMissing := missingCell(a), missingCell(b)
+	switch {
+	case aMissing && bMissing:
+		return 0
+	case aMissing:
+		return 1
+	case bMissing:
+		return -1
+	}
+	var c int
+	switch a := a.(type) {
+	case float64:
+		c = cmp.Compare(a, b.(float64))
+	case time.Time:
+		c = a.Compare(b.(time.Time))
+	case string:
+		b := b.(string)
+		if c = strings.Compare(strings.ToLower(a), strings.ToLower(b)); c == 0 {
+			c = strings.Compare(a, b)
+		}
+	}
+	if desc {
+		return -c
+	}
+	return c
+}
+
+func missingCell(v any) bool {
+	f, ok := v.(float64)
+	return v == nil || ok && math.IsNaN(f)
+}
+
+// fieldCell is a custom field value as a cell value of its field type (see fieldType), nil when
+// it doesn't parse as that type or the config lacks the field
+func fieldCell(typ, value string) any {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	switch typ {
+	case fieldNumber:
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+		return nil
+	case fieldTime:
+		if t, ok := parseFieldTime(value); ok {
+			return t
+		}
+		return nil
+	}
+	return value
+}
+
+// dashboardSort is the comparison table's default order, best combined score first
+var dashboardSort = TableSort{Key: "combined", Desc: true}
+
+// dashboardCell is the value of a comparison table row in the column key, nil for a column the row
+// has no value in; ok is false for an unknown column
+// avg_score is an alias of combined, the name the API uses for the average
+func dashboardCell(stat ModelStat, key string, data DashboardData, sparklines map[string]*Sparkline, labels *labelStore) (any, bool) {
+	switch key {
+	case "model":
+		// The text of labelStore.StatLabel
+		if _, ok := labels.Custom(stat.Model); ok || stat.Model != stat.ActualModelName {
+			return labels.Label(stat.Model), true
+		}
+		return modelRegistry.DisplayName(stat.ActualModelName), true
+	case "combined", "avg_score":
+		if stat.ScoredWeight == 0 {
+			return nil, true
+		}
+		return stat.AvgScore, true
+	case "trend":
+		if s := sparklines[stat.Model]; s != nil {
+			return s.Delta(), true
+		}
+		return nil, true
+	case "tests":
+		return float64(stat.TestCount), true
+	case "min":
+		return stat.MinScore, true
+	case "max":
+		return stat.MaxScore, true
+	case "time":
+		return stat.AvgTimeMS, true
+	case "sla":
+		if stat.SLAMS == 0 || stat.SLATimed == 0 {
+			return nil, true
+		}
+		return stat.WithinSLA, true
+	case "contexts", "context_chars":
+		if stat.ContextResults == 0 {
+			return nil, true
+		}
+		if key == "contexts" {
+			return stat.AvgContexts, true
+		}
+		return stat.AvgContextChars, true
+	case "cost":
+		if stat.CostResults == 0 {
+			return nil, true
+		}
+		return stat.EstCost, true
+	case "errors":
+		return stat.ErrorRate, true
+	}
+	if name, ok := strings.CutPrefix(key, "score:"); ok {
+		if score, ok := stat.CustomScores[name]; ok {
+			return score, true
+		}
+		return nil, true
+	}
+	if name, ok := strings.CutPrefix(key, "field:"); ok {
+		value, ok := stat.CustomFields[name]
+		if !ok {
+			return nil, true
+		}
+		return fieldCell(data.CustomFieldTypes[name], value), true
+	}
+	return nil, false
+}
+
+// sortDashboard orders the configs of data and the groups made of them by s, configs within
+// their group; an unknown column falls back to dashboardSort
+func sortDashboard(data DashboardData, groups []ModelGroup, sparklines map[string]*Sparkline, labels *labelStore, s TableSort) TableSort {
+	if _, ok := dashboardCell(ModelStat{}, s.Key, data, nil, nil); !ok {
+		s = dashboardSort
+	}
+	cell := func(stat ModelStat) any {
+		v, _ := dashboardCell(stat, s.Key, data, sparklines, labels)
+		return v
+	}
+	for i := range groups {
+		groups[i].Configs = slices.Clone(groups[i].Configs)
+		sortRows(groups[i].Configs, s.Desc, func(config string) any { return cell(data.ModelStats[config]) })
+	}
+	sortRows(groups, s.Desc, func(g ModelGroup) any {
+		if g.Collapsible() {
+			return cell(g.Stat)
+		}
+		return cell(data.ModelStats[g.Configs[0]])
+	})
+	if s.Key == "avg_score" {
+		s.Key = "combined" // The header's data-sort
+	}
+	return s
+}
+
+// testsCell is the value of a result in a column of the tests table, nil for a column it has no
+// value in (the score of an errored result, an unparsable timestamp); ok is false for an unknown column
+func testsCell(result EvalResult, key string) (any, bool) {
+	switch key {
+	case "test_id":
+		return result.TestID, true
+	case "model":
+		return result.Model, true
+	case "question":
+		return result.Question, true
+	case "score", "avg_score":
+		if result.Error != "" {
+			return nil, true
+		}
+		return result.Scores.Combined, true
+	case "time":
+		if result.ResponseTimeMS <= 0 {
+			return nil, true
+		}
+		return float64(result.ResponseTimeMS), true
+	case "timestamp":
+		if t, ok := parseTimestamp(result.Timestamp); ok {
+			return t, true
+		}
+		return nil, true
+	}
+	return nil, false
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTableSort(t *testing.T) {
+	def := TableSort{Key: "combined", Desc: true}
+	tests := []struct {
+		query string
+		want  TableSort
+	}{
+		{"", def},
+		{"sort=avg_score&dir=asc", TableSort{Key: "avg_score"}},
+		{"sort=tests&dir=desc", TableSort{Key: "tests", Desc: true}},
+		{"sort=-score:accuracy", TableSort{Key: "score:accuracy", Desc: true}}, // Older links
+		{"sort=-time&dir=asc", TableSort{Key: "time"}},
+	}
+	for _, tt := range tests {
+		params, _ := url.ParseQuery(tt.query)
+		if got := parseTableSort(params, def); got != tt.want {
+			t.Errorf("%q = %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+
+	s := TableSort{Key: "tests"}
+	params := url.Values{"model": {"a"}, "sort": {"tests"}, "dir": {"asc"}}
+	if got := s.URL("/", params, "tests"); got != "/?dir=desc&model=a&sort=tests" {
+		t.Errorf("URL = %s", got)
+	}
+	if got := s.URL("/", params, "time"); got != "/?dir=asc&model=a&sort=time" {
+		t.Errorf("URL = %s", got)
+	}
+	if s.Class("tests") != "sorted-asc" || s.Class("time") != "" {
+		t.Errorf("classes = %q, %q", s.Class("tests"), s.Class("time"))
+	}
+}
+
+func TestCompareCells(t *testing.T) {
+	day := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		a, b any
+		want int
+	}{
+		{9.0, 10.0, -1}, // Not "10" < "9"
+		{"beta", "Alpha", 1},
+		{"alpha", "Alpha", 1}, // Equal ignoring case, still a fixed order
+		{day, day.Add(time.Hour), -1},
+		{nil, 1.0, 1},
+		{math.NaN(), 1.0, 1},
+		{nil, nil, 0},
+	}
+	for _, tt := range tests {
+		if got := compareCells(tt.a, tt.b, false); got != tt.want {
+			t.Errorf("compare(%v, %v) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+	// Missing values stay last when descending
+	if compareCells(nil, 1.0, true) != 1 || compareCells(9.0, 10.0, true) != 1 {
+		t.Error("descending order")
+	}
+
+	rows := []string{"10", "", "9", "100"}
+	sortRows(rows, false, func(s string) any { return fieldCell(fieldNumber, s) })
+	if strings.Join(rows, ",") != "9,10,100," {
+		t.Errorf("rows = %v", rows)
+	}
+}
+
+func TestDashboardServerSort(t *testing.T) {
+	withStore(t, `{"model":"a","scores":{"combined":0.5},"chunk_size":"1000"}
+{"model":"b","scores":{"combined":0.9},"chunk_size":200}
+{"model":"c","scores":{"combined":0.7},"chunk_size":90}`)
+
+	// Models of the rows, top to bottom
+	order := func(query string) string {
+		rec := httptest.NewRecorder()
+		dashboardHandler(rec, httptest.NewRequest(http.MethodGet, "/"+query, nil))
+		var models []string
+		for _, part := range strings.Split(rec.Body.String(), `<button class="label-edit" data-config="`)[1:] {
+			models = append(models, part[:1])
+		}
+		return strings.Join(models, "")
+	}
+	tests := map[string]string{
+		"":                                "bca", // Best combined first
+		"?sort=avg_score&dir=asc":         "acb",
+		"?sort=field:chunk_size&dir=desc": "abc", // Numeric strings compare as numbers
+		"?sort=-model":                    "cba",
+		"?sort=unknown":                   "bca",
+	}
+	for query, want := range tests {
+		if got := order(query); got != want {
+			t.Errorf("%q: rows %s, want %s", query, got, want)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	dashboardHandler(rec, httptest.NewRequest(http.MethodGet, "/?sort=tests&dir=asc", nil))
+	if !strings.Contains(rec.Body.String(), `data-sort="tests" class="sorted-asc"`) {
+		t.Error("sorted header not marked")
+	}
+}
+
+func TestTestsServerSort(t *testing.T) {
+	withStore(t, `{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"t1","scores":{"combined":0.5},"response_time_ms":900}
+{"timestamp":"2025-01-02T10:00:00Z","model":"a","test_id":"t2","scores":{"combined":0.9},"response_time_ms":1000}
+{"timestamp":"2025-01-03T10:00:00Z","model":"a","test_id":"t3","error":"timeout","response_time_ms":30000}`)
+
+	order := func(query string) string {
+		rec := httptest.NewRecorder()
+		testsHandler(rec, httptest.NewRequest(http.MethodGet, "/tests"+query, nil))
+		body := rec.Body.String()
+		var ids []string
+		for _, part := range strings.Split(body, `<td class="test-id">`)[1:] {
+			ids = append(ids, part[:2])
+		}
+		return strings.Join(ids, " ")
+	}
+	tests := map[string]string{
+		"":                       "t3 t2 t1", // Newest first
+		"?sort=score&dir=desc":   "t2 t1 t3", // The errored result has no score and goes last
+		"?sort=score&dir=asc":    "t1 t2 t3",
+		"?sort=time&dir=asc":     "t1 t2 t3",
+		"?sort=timestamp":        "t1 t2 t3",
+		"?sort=test_id&dir=desc": "t3 t2 t1",
+	}
+	for query, want := range tests {
+		if got := order(query); got != want {
+			t.Errorf("%q: %s, want %s", query, got, want)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	testsHandler(rec, httptest.NewRequest(http.MethodGet, "/tests?model=a&sort=score&dir=asc", nil))
+	if !strings.Contains(rec.Body.String(), `<th class="sorted-asc"><a href="/tests?dir=desc&amp;model=a&amp;sort=score">Score</a></th>`) {
+		t.Error("score header should link to the descending sort")
+	}
+}
@@ -32,7 +32,7 @@ function Show-Help {
 function Invoke-Build {
     Write-Host "Building GoEvals..." -ForegroundColor Cyan
     New-Item -ItemType Directory -Force -Path "bin" | Out-Null
-    go build -o bin\goevals.exe main.go
+    go build -o bin\goevals.exe .
     if ($LASTEXITCODE -eq 0) {
         Write-Host "Build complete: bin\goevals.exe" -ForegroundColor Green
     } else {
@@ -54,7 +54,7 @@ function Invoke-TestShort {
 function Invoke-Run {
     Write-Host "Starting GoEvals dashboard..." -ForegroundColor Cyan
     if (Test-Path "evals.jsonl") {
-        go run main.go evals.jsonl
+        go run . evals.jsonl
     } else {
         Write-Host "Error: evals.jsonl not found" -ForegroundColor Red
         Write-Host "Create sample file or specify path: .\task.ps1 run <path\to\evals.jsonl>" -ForegroundColor Yellow
@@ -66,7 +66,7 @@ function Invoke-RunEmpty {
     Write-Host "Starting GoEvals with empty dashboard..." -ForegroundColor Cyan
     $tempFile = "$env:TEMP\goevals-empty.jsonl"
     New-Item -ItemType File -Force -Path $tempFile | Out-Null
-    go run main.go $tempFile
+    go run . $tempFile
 }
 
 function Invoke-Clean {
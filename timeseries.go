@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maxTimeseriesBuckets bounds the buckets per series, so interval=1s over a year of results
+// is rejected instead of building millions of points
+const maxTimeseriesBuckets = 10000
+
+// TimeSeries is one group's bucketed metric, in the Graphite / Grafana JSON datasource shape:
+// datapoints are [value, bucket start in Unix milliseconds]
+type TimeSeries struct {
+	Target     string       `json:"target"` // Group label, "all" without group_by
+	Datapoints [][2]float64 `json:"datapoints"`
+	Counts     []int        `json:"counts"` // Results per datapoint
+}
+
+// timeseriesParams holds the validated /api/timeseries query
+type timeseriesParams struct {
+	metric   string // scores.<name>, response_time_ms or field:<name>
+	groupBy  string // A pivot dimension other than score_type, "" for one series
+	interval time.Duration
+	value    string // One of pivotValues
+	from, to time.Time
+}
+
+// parseTimeseriesParams reads metric, group_by, interval, value, from and to
+func parseTimeseriesParams(params url.Values) (timeseriesParams, error) {
+	p := timeseriesParams{metric: params.Get("metric"), groupBy: params.Get("group_by"), value: params.Get("value"), interval: time.Hour}
+	if p.metric == "" {
+		p.metric = "scores.combined"
+	}
+	if p.metric != "response_time_ms" && !strings.HasPrefix(p.metric, "scores.") && !strings.HasPrefix(p.metric, "field:") {
+		return p, fmt.Errorf("unknown metric %q, use scores.<name>, response_time_ms or field:<name>", p.metric)
+	}
+	if p.groupBy != "" && (p.groupBy == "score_type" || !slices.Contains(pivotDimensions, p.groupBy) && !strings.HasPrefix(p.groupBy, "field:")) {
+		return p, fmt.Errorf("unknown group_by %q, use model, family, provider, config, run, test_id, source, dataset or field:<name>", p.groupBy)
+	}
+	if p.value == "" {
+		p.value = "mean"
+	}
+	if !slices.Contains(pivotValues, p.value) {
+		return p, fmt.Errorf("unknown value %q, use %s", p.value, strings.Join(pivotValues, ", "))
+	}
+	if raw := params.Get("interval"); raw != "" {
+		interval, err := parseAge(raw)
+		if err != nil || interval < time.Second {
+			return p, fmt.Errorf("invalid interval %q, use e.g. 30m, 1h or 1d", raw)
+		}
+		p.interval = interval
+	}
+	for _, bound := range []struct {
+		name string
+		t    *time.Time
+	}{{"from", &p.from}, {"to", &p.to}} {
+		if raw := params.Get(bound.name); raw != "" {
+			t, ok := parseTimestamp(raw)
+			if !ok {
+				return p, fmt.Errorf("invalid %s %q, use an RFC3339 timestamp", bound.name, raw)
+			}
+			*bound.t = t
+		}
+	}
+	return p, nil
+}
+
+// metricValue is result's value of metric, false when it has none
+// Results with an error have no scores, like on the dashboard
+func metricValue(result EvalResult, metric string) (float64, bool) {
+	switch {
+	case metric == "response_time_ms":
+		return float64(result.ResponseTimeMS), result.ResponseTimeMS > 0
+	case metric == "scores.combined":
+		return result.Scores.Combined, result.Error == ""
+	case strings.HasPrefix(metric, "scores."):
+		v, ok := result.Scores.Custom[strings.TrimPrefix(metric, "scores.")]
+		return v, ok && result.Error == ""
+	}
+	v, ok := result.Fields()[strings.TrimPrefix(metric, "field:")].(float64)
+	return v, ok
+}
+
+// BuildTimeseries aggregates metric per group into interval buckets aligned to UTC
+// Buckets without results are left out, so charts show gaps rather than zeros
+func BuildTimeseries(results []EvalResult, p timeseriesParams) ([]TimeSeries, error) {
+	type bucket struct {
+		group string
+		start int64 // Unix milliseconds
+	}
+	values := make(map[bucket][]float64)
+	starts := make(map[string]map[int64]bool)
+	for _, result := range results {
+		t, ok := parseTimestamp(result.Timestamp)
+		if !ok || (!p.from.IsZero() && t.Before(p.from)) || (!p.to.IsZero() && !t.Before(p.to)) {
+			continue
+		}
+		v, ok := metricValue(result, p.metric)
+		if !ok {
+			continue
+		}
+		group := "all"
+		if p.groupBy != "" {
+			if group = pivotLabel(result, p.groupBy); group == "" {
+				continue
+			}
+		}
+		b := bucket{group, t.UTC().Truncate(p.interval).UnixMilli()}
+		values[b] = append(values[b], v)
+		if starts[group] == nil {
+			starts[group] = make(map[int64]bool)
+		}
+		starts[group][b.start] = true
+		if len(starts[group]) > maxTimeseriesBuckets {
+			return nil, fmt.Errorf("more than %d buckets per series, use a longer interval or from/to", maxTimeseriesBuckets)
+		}
+	}
+
+	series := []TimeSeries{}
+	for _, group := range slices.Sorted(maps.Keys(starts)) {
+		s := TimeSeries{Target: group}
+		for _, start := range slices.Sorted(maps.Keys(starts[group])) {
+			vs := values[bucket{group, start}]
+			s.Datapoints = append(s.Datapoints, [2]float64{aggregate(vs, p.value), float64(start)})
+			s.Counts = append(s.Counts, len(vs))
+		}
+		series = append(series, s)
+	}
+	return series, nil
+}
+
+// timeseriesAPIHandler returns a metric bucketed over time, one series per group
+// e.g. /api/timeseries?metric=scores.combined&group_by=model&interval=1h, filtered like the dashboard
+func timeseriesAPIHandler(w http.ResponseWriter, r *http.Request) {
+	p, err := parseTimeseriesParams(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	results, err := store.Query(r.Context(), dashboardFilter(r))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	results, ok := reweightRequest(w, r, results)
+	if !ok {
+		return
+	}
+	series, err := BuildTimeseries(results, p)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeCachedJSON(w, r, series)
+}
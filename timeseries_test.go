@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestBuildTimeseries(t *testing.T) {
+	var results []EvalResult
+	for _, line := range []string{
+		`{"timestamp":"2025-01-01T10:05:00Z","model":"a","scores":{"combined":0.4,"accuracy":1},"cost":2}`,
+		`{"timestamp":"2025-01-01T10:55:00Z","model":"a","scores":{"combined":0.6}}`,
+		`{"timestamp":"2025-01-01T12:00:00Z","model":"a","scores":{"combined":0.9}}`,
+		`{"timestamp":"2025-01-01T10:30:00+01:00","model":"b","scores":{"combined":0.5},"cost":4}`,
+		`{"timestamp":"2025-01-01T10:40:00Z","model":"b","error":"timeout","scores":{"combined":0}}`,
+		`{"timestamp":"not a time","model":"b","scores":{"combined":1}}`,
+	} {
+		var r EvalResult
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, r)
+	}
+
+	p, err := parseTimeseriesParams(url.Values{"group_by": {"model"}, "interval": {"1h"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	series, err := BuildTimeseries(results, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != 2 || series[0].Target != "a" || series[1].Target != "b" {
+		t.Fatalf("series = %+v", series)
+	}
+	// 10:00 bucket averages two results, 11:00 is a gap, b's error is left out
+	a := series[0]
+	if len(a.Datapoints) != 2 || a.Datapoints[0] != [2]float64{0.5, 1735725600000} || a.Counts[0] != 2 || a.Datapoints[1][1] != 1735732800000 {
+		t.Errorf("a = %+v", a)
+	}
+	if b := series[1]; len(b.Datapoints) != 1 || b.Datapoints[0] != [2]float64{0.5, 1735722000000} {
+		t.Errorf("b = %+v, want its +01:00 timestamp in the 09:00 UTC bucket", b)
+	}
+
+	p, _ = parseTimeseriesParams(url.Values{"metric": {"field:cost"}, "interval": {"1d"}, "value": {"sum"}})
+	if series, _ := BuildTimeseries(results, p); len(series) != 1 || series[0].Target != "all" || series[0].Datapoints[0][0] != 6 {
+		t.Errorf("field:cost per day = %+v", series)
+	}
+	p, _ = parseTimeseriesParams(url.Values{"from": {"2025-01-01T11:00:00Z"}})
+	if series, _ := BuildTimeseries(results, p); len(series) != 1 || len(series[0].Datapoints) != 1 {
+		t.Errorf("from = %+v", series)
+	}
+	// Too many buckets is an error rather than a huge response
+	p, _ = parseTimeseriesParams(url.Values{"interval": {"1s"}})
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var spread []EvalResult
+	for i := 0; i <= maxTimeseriesBuckets; i++ {
+		spread = append(spread, EvalResult{Timestamp: start.Add(time.Duration(i) * time.Second).Format(time.RFC3339), Scores: ScoreBreakdown{Combined: 1}})
+	}
+	if _, err := BuildTimeseries(spread, p); err == nil {
+		t.Error("expected an error for too many buckets")
+	}
+
+	for _, bad := range []url.Values{{"metric": {"question"}}, {"group_by": {"score_type"}}, {"interval": {"100ms"}}, {"value": {"p99"}}, {"from": {"yesterday"}}} {
+		if _, err := parseTimeseriesParams(bad); err == nil {
+			t.Errorf("%v accepted", bad)
+		}
+	}
+}
+
+func TestTimeseriesAPI(t *testing.T) {
+	withStore(t, `{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"t1","scores":{"combined":0.5}}
+{"timestamp":"2025-01-01T10:10:00Z","model":"b","test_id":"t1","scores":{"combined":0.7}}`)
+
+	w := httptest.NewRecorder()
+	timeseriesAPIHandler(w, httptest.NewRequest(http.MethodGet, "/api/timeseries?metric=scores.combined&group_by=model&interval=1h&model=b", nil))
+	var series []TimeSeries
+	if err := json.Unmarshal(w.Body.Bytes(), &series); err != nil || len(series) != 1 || series[0].Target != "b" || series[0].Datapoints[0][0] != 0.7 {
+		t.Errorf("/api/timeseries = %d %s", w.Code, w.Body)
+	}
+	w = httptest.NewRecorder()
+	timeseriesAPIHandler(w, httptest.NewRequest(http.MethodGet, "/api/timeseries?interval=soon", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("invalid interval = %d", w.Code)
+	}
+}
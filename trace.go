@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+)
+
+// TraceStep is one step of an agent's run: a tool call with its input, output and duration
+type TraceStep struct {
+	Tool       string  `json:"tool"`
+	Input      any     `json:"input,omitempty"`  // String or any JSON value
+	Output     any     `json:"output,omitempty"` // String or any JSON value
+	DurationMS float64 `json:"duration_ms,omitempty"`
+	Error      string  `json:"error,omitempty"` // Set when the tool call failed
+}
+
+// InputText is the step input as shown in the test modal: strings as is, other values as JSON
+func (s TraceStep) InputText() string {
+	return traceText(s.Input)
+}
+
+// OutputText is the step output as shown in the test modal
+func (s TraceStep) OutputText() string {
+	return traceText(s.Output)
+}
+
+func traceText(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// TimelineStep is a trace step placed on the timeline of the test modal
+type TimelineStep struct {
+	TraceStep
+	Number int
+	Offset float64 // Start of the bar in percent of the trace duration
+	Width  float64 // Length of the bar in percent, at least 0.5 so instant steps stay visible
+}
+
+// TraceMS is the total duration of the trace steps
+func (er EvalResult) TraceMS() float64 {
+	total := 0.0
+	for _, step := range er.Trace {
+		total += step.DurationMS
+	}
+	return total
+}
+
+// Timeline lays the trace steps out one after another, as the agent ran them
+func (er EvalResult) Timeline() []TimelineStep {
+	total := er.TraceMS()
+	steps := make([]TimelineStep, len(er.Trace))
+	start := 0.0
+	for i, step := range er.Trace {
+		steps[i] = TimelineStep{TraceStep: step, Number: i + 1}
+		if total > 0 {
+			steps[i].Offset = start / total * 100
+			steps[i].Width = max(step.DurationMS/total*100, 0.5)
+			steps[i].Offset = min(steps[i].Offset, 100-steps[i].Width)
+		}
+		start += step.DurationMS
+	}
+	return steps
+}
+
+// ToolUsage is how one model used its tools, over the results that have a trace
+type ToolUsage struct {
+	Model    string         `json:"model"`
+	Traced   int            `json:"traced"` // Results with a trace
+	Steps    int            `json:"steps"`
+	AvgSteps float64        `json:"avg_steps"`
+	AvgMS    float64        `json:"avg_trace_ms"` // Mean total duration of a trace
+	Errors   int            `json:"errors"`       // Steps that failed
+	Calls    map[string]int `json:"calls"`        // Tool -> number of calls
+	AvgScore float64        `json:"avg_score"`
+}
+
+// AvgSeconds is AvgMS in seconds, for the duration template function
+func (u ToolUsage) AvgSeconds() float64 {
+	return u.AvgMS / 1000
+}
+
+// PerResult is the mean number of calls of tool per traced result
+func (u ToolUsage) PerResult(tool string) float64 {
+	return float64(u.Calls[tool]) / float64(u.Traced)
+}
+
+// ToolReport is the tools page: tool call counts per model
+type ToolReport struct {
+	Tools  []string    `json:"tools"`  // By total calls, most used first
+	Models []ToolUsage `json:"models"` // By model name
+}
+
+// BuildToolReport counts the tool calls in the traces of results, per model
+func BuildToolReport(results []EvalResult) ToolReport {
+	var report ToolReport
+	byModel := make(map[string]*ToolUsage)
+	totals := make(map[string]int)
+	scoreSums := make(map[string]float64)
+	for _, result := range results {
+		if len(result.Trace) == 0 {
+			continue
+		}
+		usage := byModel[result.Model]
+		if usage == nil {
+			usage = &ToolUsage{Model: result.Model, Calls: make(map[string]int)}
+			byModel[result.Model] = usage
+		}
+		usage.Traced++
+		usage.Steps += len(result.Trace)
+		usage.AvgMS += result.TraceMS()
+		scoreSums[result.Model] += result.Scores.Combined
+		for _, step := range result.Trace {
+			tool := step.Tool
+			if tool == "" {
+				tool = "(unnamed)"
+			}
+			usage.Calls[tool]++
+			totals[tool]++
+			if step.Error != "" {
+				usage.Errors++
+			}
+		}
+	}
+
+	for model, usage := range byModel {
+		usage.AvgSteps = float64(usage.Steps) / float64(usage.Traced)
+		usage.AvgMS /= float64(usage.Traced)
+		usage.AvgScore = scoreSums[model] / float64(usage.Traced)
+		report.Models = append(report.Models, *usage)
+	}
+	sort.Slice(report.Models, func(i, j int) bool { return report.Models[i].Model < report.Models[j].Model })
+	for tool := range totals {
+		report.Tools = append(report.Tools, tool)
+	}
+	slices.SortFunc(report.Tools, func(a, b string) int {
+		if totals[a] != totals[b] {
+			return totals[b] - totals[a]
+		}
+		if a < b {
+			return -1
+		}
+		return 1
+	})
+	return report
+}
+
+// toolsHandler renders tool call counts per model
+func toolsHandler(w http.ResponseWriter, r *http.Request) {
+	results, err := store.Query(r.Context(), dashboardFilter(r))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	results, ok := reweightRequest(w, r, results)
+	if !ok {
+		return
+	}
+
+	data := struct {
+		Title    string
+		Subtitle string
+		ToolReport
+	}{
+		Title:      "Tool Usage",
+		Subtitle:   "Tool calls per model, from the traces of agent evals",
+		ToolReport: BuildToolReport(results),
+	}
+
+	renderPage(w, r, "tools", toolsTemplate, data)
+}
+
+// toolsAPIHandler returns the tool usage report as JSON
+func toolsAPIHandler(w http.ResponseWriter, r *http.Request) {
+	results, err := store.Query(r.Context(), dashboardFilter(r))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	results, ok := reweightRequest(w, r, results)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(BuildToolReport(results)); err != nil {
+		log.Printf("Error encoding JSON: %v", err)
+	}
+}
+
+const toolsTemplate = `
+{{ define "style" }}
+        td.num {
+            font-family: monospace;
+            text-align: right;
+            white-space: nowrap;
+        }
+        td.zero {
+            color: var(--text-tertiary);
+        }
+{{ end }}
+
+{{ define "content" }}
+        {{ if .Models }}
+        <div class="panel">
+            <h2>Tool Calls per Model</h2>
+            <div style="overflow-x: auto;">
+            <table>
+                <thead>
+                    <tr>
+                        <th>Model</th>
+                        <th>Traced</th>
+                        <th>Avg steps</th>
+                        <th>Avg trace</th>
+                        <th>Errors</th>
+                        <th>Avg Score</th>
+                        {{ range .Tools }}<th class="mono">{{ . }}</th>{{ end }}
+                    </tr>
+                </thead>
+                <tbody>
+                    {{ range $usage := .Models }}
+                    <tr>
+                        <td><strong>{{ $usage.Model }}</strong></td>
+                        <td class="num">{{ human $usage.Traced }}</td>
+                        <td class="num">{{ printf "%.1f" $usage.AvgSteps }}</td>
+                        <td class="num">{{ duration $usage.AvgSeconds }}</td>
+                        <td class="num{{ if not $usage.Errors }} zero{{ end }}">{{ human $usage.Errors }}</td>
+                        <td><span class="score-badge {{ scoreClass $usage.AvgScore }}">{{ printf "%.3f" $usage.AvgScore }}</span></td>
+                        {{ range $.Tools }}
+                        {{ $calls := index $usage.Calls . }}
+                        <td class="num{{ if not $calls }} zero{{ end }}" title="{{ $calls }} calls, {{ printf "%.1f" ($usage.PerResult .) }} per traced result">{{ human $calls }}</td>
+                        {{ end }}
+                    </tr>
+                    {{ end }}
+                </tbody>
+            </table>
+            </div>
+        </div>
+        {{ else }}
+        <div class="panel">
+            <p class="muted">No traces recorded. Log a <span class="mono">trace</span> list of tool calls with each agent result to see tool usage.</p>
+        </div>
+        {{ end }}
+{{ end }}`
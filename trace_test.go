@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestTraceTimeline(t *testing.T) {
+	line := `{"timestamp":"2025-01-01T10:00:00Z","model":"a","scores":{"combined":1},"trace":[
+		{"tool":"search","input":{"query":"weather paris"},"output":"Sunny, 21°C","duration_ms":300},
+		{"tool":"calculator","input":"21*9/5+32","output":69.8,"duration_ms":0},
+		{"tool":"answer","duration_ms":100,"error":"timeout"}]}`
+	var result EvalResult
+	if err := json.Unmarshal([]byte(line), &result); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := result.CustomFields["trace"]; ok {
+		t.Error("trace was taken for a custom field")
+	}
+	if result.TraceMS() != 400 {
+		t.Errorf("TraceMS = %g", result.TraceMS())
+	}
+	steps := result.Timeline()
+	if len(steps) != 3 {
+		t.Fatalf("timeline = %+v", steps)
+	}
+	if s := steps[0]; s.Number != 1 || s.Offset != 0 || s.Width != 75 || s.InputText() != "{\n  \"query\": \"weather paris\"\n}" || s.OutputText() != "Sunny, 21°C" {
+		t.Errorf("step 1 = %+v", s)
+	}
+	if s := steps[1]; s.Offset != 75 || s.Width != 0.5 || s.OutputText() != "69.8" {
+		t.Errorf("step 2 = %+v", s)
+	}
+	if s := steps[2]; s.Offset != 75 || s.Width != 25 || s.Error != "timeout" {
+		t.Errorf("step 3 = %+v", s)
+	}
+
+	data, _ := json.Marshal(result)
+	var back EvalResult
+	json.Unmarshal(data, &back)
+	if !reflect.DeepEqual(back.Trace, result.Trace) {
+		t.Errorf("round trip lost trace: %s", data)
+	}
+}
+
+func TestBuildToolReport(t *testing.T) {
+	results := []EvalResult{
+		{Model: "a", Scores: ScoreBreakdown{Combined: 1}, Trace: []TraceStep{{Tool: "search", DurationMS: 100}, {Tool: "search", DurationMS: 50}, {Tool: "answer", DurationMS: 50}}},
+		{Model: "a", Scores: ScoreBreakdown{Combined: 0.5}, Trace: []TraceStep{{Tool: "answer", Error: "refused"}}},
+		{Model: "a", Scores: ScoreBreakdown{Combined: 0}}, // No trace, not counted
+		{Model: "b", Trace: []TraceStep{{Tool: "calculator"}, {}}},
+	}
+	report := BuildToolReport(results)
+	if want := []string{"answer", "search", "(unnamed)", "calculator"}; !reflect.DeepEqual(report.Tools, want) {
+		t.Errorf("tools = %v, want %v", report.Tools, want)
+	}
+	if len(report.Models) != 2 {
+		t.Fatalf("models = %+v", report.Models)
+	}
+	a := report.Models[0]
+	if a.Model != "a" || a.Traced != 2 || a.Steps != 4 || a.AvgSteps != 2 || a.AvgMS != 100 || a.Errors != 1 || a.AvgScore != 0.75 ||
+		a.Calls["search"] != 2 || a.Calls["answer"] != 2 || a.PerResult("search") != 1 {
+		t.Errorf("a = %+v", a)
+	}
+	if b := report.Models[1]; b.Calls["(unnamed)"] != 1 || b.Calls["search"] != 0 {
+		t.Errorf("b = %+v", b)
+	}
+}
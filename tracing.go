@@ -0,0 +1,397 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OTLP span kinds and status codes, see opentelemetry-proto trace.proto
+const (
+	spanKindInternal = 1
+	spanKindServer   = 2
+
+	spanStatusError = 2
+)
+
+// Span is one timed phase of a request (handler, source load, aggregation, render)
+// A nil *Span is valid and does nothing, so call sites don't check whether tracing is on
+type Span struct {
+	tracer   *tracer
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	name     string
+	kind     int
+	start    time.Time
+	end      time.Time
+
+	mu     sync.Mutex
+	attrs  map[string]any
+	errMsg string
+}
+
+// tracer batches finished spans and exports them as OTLP/HTTP JSON
+type tracer struct {
+	endpoint string // Full traces URL, e.g. http://collector:4318/v1/traces
+	headers  map[string]string
+	service  string
+	client   *http.Client
+	queue    chan *Span
+}
+
+// tracing is the active tracer, nil unless an OTLP endpoint is configured
+var tracing *tracer
+
+const (
+	traceQueueSize  = 4096 // Spans beyond this are dropped rather than blocking requests
+	traceBatchSize  = 512
+	traceFlushEvery = 5 * time.Second
+)
+
+// initTracing enables span export when an OTLP endpoint is configured, using the standard
+// OTEL_EXPORTER_OTLP_(TRACES_)ENDPOINT, OTEL_EXPORTER_OTLP_HEADERS and OTEL_SERVICE_NAME variables
+// base is the --otlp-endpoint flag, a collector base URL that overrides both endpoint variables
+func initTracing(base string) error {
+	endpoint := ""
+	if base == "" {
+		endpoint = os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+		base = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	}
+	if endpoint == "" {
+		if base == "" {
+			return nil
+		}
+		endpoint = strings.TrimSuffix(base, "/") + "/v1/traces"
+	}
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		return fmt.Errorf("invalid OTLP endpoint %q: must be an http:// or https:// URL", endpoint)
+	}
+
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("invalid OTEL_EXPORTER_OTLP_HEADERS entry %q: expected key=value", pair)
+		}
+		headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	service := os.Getenv("OTEL_SERVICE_NAME")
+	if service == "" {
+		service = "goevals"
+	}
+
+	tracing = &tracer{
+		endpoint: endpoint,
+		headers:  headers,
+		service:  service,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		queue:    make(chan *Span, traceQueueSize),
+	}
+	go tracing.run(traceFlushEvery)
+	log.Printf("Exporting traces to %s as %q", endpoint, service)
+	return nil
+}
+
+type spanContextKey struct{}
+
+// startSpan starts a span as a child of the span in ctx (or a new trace) and returns a context carrying it
+// Returns ctx unchanged and a nil span when tracing is off
+func startSpan(ctx context.Context, name string) (context.Context, *Span) {
+	if tracing == nil {
+		return ctx, nil
+	}
+	span := &Span{tracer: tracing, name: name, kind: spanKindInternal, start: time.Now()}
+	if parent := spanFromContext(ctx); parent != nil {
+		span.traceID = parent.traceID
+		span.parentID = parent.spanID
+	} else {
+		rand.Read(span.traceID[:])
+	}
+	rand.Read(span.spanID[:])
+	return context.WithValue(ctx, spanContextKey{}, span), span
+}
+
+// spanFromContext returns the current span, nil if there is none
+func spanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanContextKey{}).(*Span)
+	return span
+}
+
+// SetAttr records an attribute (string, bool, int, int64 or float64)
+func (s *Span) SetAttr(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attrs == nil {
+		s.attrs = make(map[string]any)
+	}
+	s.attrs[key] = value
+}
+
+// RecordError marks the span as failed, nil errors are ignored
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errMsg = err.Error()
+}
+
+// End finishes the span and queues it for export
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.end = time.Now()
+	select {
+	case s.tracer.queue <- s:
+	default: // Exporter is behind, drop rather than slow down requests
+	}
+}
+
+// parseTraceparent reads a W3C traceparent header ("00-<trace id>-<parent id>-<flags>")
+func parseTraceparent(header string) (traceID [16]byte, parentID [8]byte, ok bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return traceID, parentID, false
+	}
+	t, err1 := hex.DecodeString(parts[1])
+	p, err2 := hex.DecodeString(parts[2])
+	if err1 != nil || err2 != nil || len(t) != 16 || len(p) != 8 {
+		return traceID, parentID, false
+	}
+	copy(traceID[:], t)
+	copy(parentID[:], p)
+	if traceID == [16]byte{} || parentID == [8]byte{} {
+		return traceID, parentID, false
+	}
+	return traceID, parentID, true
+}
+
+// statusRecorder captures the response status for the request span
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sr *statusRecorder) WriteHeader(code int) {
+	if sr.status == 0 {
+		sr.status = code
+	}
+	sr.ResponseWriter.WriteHeader(code)
+}
+
+func (sr *statusRecorder) Write(b []byte) (int, error) {
+	if sr.status == 0 {
+		sr.status = http.StatusOK
+	}
+	return sr.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer (flushing, deadlines)
+func (sr *statusRecorder) Unwrap() http.ResponseWriter {
+	return sr.ResponseWriter
+}
+
+// traceRequests wraps next so every request gets a server span, continuing the caller's
+// trace when a traceparent header is present. Returns next unchanged when tracing is off
+func traceRequests(next http.Handler) http.Handler {
+	if tracing == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := startSpan(r.Context(), r.Method)
+		span.kind = spanKindServer
+		if traceID, parentID, ok := parseTraceparent(r.Header.Get("traceparent")); ok {
+			span.traceID = traceID
+			span.parentID = parentID
+		}
+
+		rec := &statusRecorder{ResponseWriter: w}
+		r = r.WithContext(ctx)
+		next.ServeHTTP(rec, r)
+
+		// ServeMux fills in the matched pattern, which keeps span names low-cardinality
+		switch {
+		case r.Pattern != "":
+			span.name = r.Method + " " + r.Pattern
+			span.SetAttr("http.route", r.Pattern)
+		case strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") && (r.URL.Path == grpcStreamMethod || r.URL.Path == grpcSendMethod):
+			// gRPC spans are named package.Service/Method, see the OpenTelemetry RPC conventions
+			span.name = strings.TrimPrefix(r.URL.Path, "/")
+			service, method, _ := strings.Cut(span.name, "/")
+			span.SetAttr("rpc.system", "grpc")
+			span.SetAttr("rpc.service", service)
+			span.SetAttr("rpc.method", method)
+		}
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		span.SetAttr("http.request.method", r.Method)
+		span.SetAttr("url.path", r.URL.Path)
+		span.SetAttr("http.response.status_code", rec.status)
+		if rec.status >= 500 {
+			span.RecordError(fmt.Errorf("%d %s", rec.status, http.StatusText(rec.status)))
+		}
+		span.End()
+	})
+}
+
+// run exports queued spans in batches until the process exits
+func (t *tracer) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var batch []*Span
+	for {
+		select {
+		case span := <-t.queue:
+			batch = append(batch, span)
+			if len(batch) < traceBatchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+		if err := t.export(batch); err != nil {
+			log.Printf("Warning: Failed to export %d spans: %v", len(batch), err)
+		}
+		batch = nil
+	}
+}
+
+// otlpValue is an OTLP AnyValue in the JSON encoding
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"` // int64 is a string in OTLP JSON
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+type otlpAttr struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+func toOTLPAttr(key string, value any) otlpAttr {
+	var v otlpValue
+	switch x := value.(type) {
+	case string:
+		v.StringValue = &x
+	case bool:
+		v.BoolValue = &x
+	case int:
+		s := strconv.Itoa(x)
+		v.IntValue = &s
+	case int64:
+		s := strconv.FormatInt(x, 10)
+		v.IntValue = &s
+	case float64:
+		v.DoubleValue = &x
+	default:
+		s := fmt.Sprint(x)
+		v.StringValue = &s
+	}
+	return otlpAttr{Key: key, Value: v}
+}
+
+// export sends one batch to the collector as an ExportTraceServiceRequest
+func (t *tracer) export(batch []*Span) error {
+	type otlpStatus struct {
+		Code    int    `json:"code,omitempty"`
+		Message string `json:"message,omitempty"`
+	}
+	type otlpSpan struct {
+		TraceID      string     `json:"traceId"`
+		SpanID       string     `json:"spanId"`
+		ParentSpanID string     `json:"parentSpanId,omitempty"`
+		Name         string     `json:"name"`
+		Kind         int        `json:"kind"`
+		Start        string     `json:"startTimeUnixNano"`
+		End          string     `json:"endTimeUnixNano"`
+		Attributes   []otlpAttr `json:"attributes,omitempty"`
+		Status       otlpStatus `json:"status"`
+	}
+
+	spans := make([]otlpSpan, 0, len(batch))
+	for _, s := range batch {
+		s.mu.Lock()
+		out := otlpSpan{
+			TraceID: hex.EncodeToString(s.traceID[:]),
+			SpanID:  hex.EncodeToString(s.spanID[:]),
+			Name:    s.name,
+			Kind:    s.kind,
+			Start:   strconv.FormatInt(s.start.UnixNano(), 10),
+			End:     strconv.FormatInt(s.end.UnixNano(), 10),
+		}
+		if s.parentID != [8]byte{} {
+			out.ParentSpanID = hex.EncodeToString(s.parentID[:])
+		}
+		for _, key := range slices.Sorted(maps.Keys(s.attrs)) {
+			out.Attributes = append(out.Attributes, toOTLPAttr(key, s.attrs[key]))
+		}
+		if s.errMsg != "" {
+			out.Status = otlpStatus{Code: spanStatusError, Message: s.errMsg}
+		}
+		s.mu.Unlock()
+		spans = append(spans, out)
+	}
+
+	payload := map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": map[string]any{
+				"attributes": []otlpAttr{toOTLPAttr("service.name", t.service)},
+			},
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]string{"name": "goevals"},
+				"spans": spans,
+			}},
+		}},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("collector returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTracing(t *testing.T) {
+	var received struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					TraceID      string `json:"traceId"`
+					SpanID       string `json:"spanId"`
+					ParentSpanID string `json:"parentSpanId"`
+					Name         string `json:"name"`
+					Kind         int    `json:"kind"`
+					Attributes   []struct {
+						Key   string `json:"key"`
+						Value struct {
+							IntValue string `json:"intValue"`
+						} `json:"value"`
+					} `json:"attributes"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("X-Api-Key") != "secret" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer collector.Close()
+
+	tracing = &tracer{
+		endpoint: collector.URL + "/v1/traces",
+		headers:  map[string]string{"X-Api-Key": "secret"},
+		service:  "goevals",
+		client:   collector.Client(),
+		queue:    make(chan *Span, 16),
+	}
+	useStore(t, NewMemoryStore([]Source{&fileSource{path: "evals.jsonl"}}))
+	defer func() { tracing = nil }()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/evals/since", evalsSinceHandler)
+	req := httptest.NewRequest(http.MethodGet, "/api/evals/since?ts=2000-01-01T00:00:00Z", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	traceRequests(mux).ServeHTTP(httptest.NewRecorder(), req)
+
+	var batch []*Span
+	for len(tracing.queue) > 0 {
+		batch = append(batch, <-tracing.queue)
+	}
+	if err := tracing.export(batch); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if len(received.ResourceSpans) != 1 || len(received.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("unexpected payload: %+v", received)
+	}
+
+	spans := received.ResourceSpans[0].ScopeSpans[0].Spans
+	byName := make(map[string]int)
+	for i, span := range spans {
+		byName[span.Name] = i
+		if span.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("%s: trace ID %s does not continue the caller's trace", span.Name, span.TraceID)
+		}
+	}
+	server, ok := byName["GET /api/evals/since"]
+	if !ok {
+		t.Fatalf("no server span in %+v", spans)
+	}
+	if spans[server].Kind != spanKindServer || spans[server].ParentSpanID != "00f067aa0ba902b7" {
+		t.Errorf("server span = %+v", spans[server])
+	}
+	query, ok := byName["store.query"]
+	if !ok || spans[query].ParentSpanID != spans[server].SpanID {
+		t.Errorf("store.query should be a child of the request span: %+v", spans)
+	}
+	load, ok := byName["source.load"]
+	if !ok || spans[load].ParentSpanID != spans[query].SpanID {
+		t.Errorf("source.load should be a child of store.query: %+v", spans)
+	}
+	for _, attr := range spans[load].Attributes {
+		if attr.Key == "goevals.results" && attr.Value.IntValue == "0" {
+			t.Error("source.load should record the number of results")
+		}
+	}
+}
+
+func TestTracingDisabled(t *testing.T) {
+	// A nil span is a no-op, so handlers run unchanged without a collector
+	ctx, span := startSpan(t.Context(), "noop")
+	span.SetAttr("key", 1)
+	span.RecordError(http.ErrAbortHandler)
+	span.End()
+	if span != nil || spanFromContext(ctx) != nil {
+		t.Error("expected no span when tracing is off")
+	}
+
+	h := http.NotFoundHandler()
+	if traceRequests(h) == nil {
+		t.Error("traceRequests must return the handler")
+	}
+}
+
+func TestParseTraceparent(t *testing.T) {
+	for header, want := range map[string]bool{
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01": true,
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01": false,
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01": false,
+		"00-4bf92f35-00f067aa0ba902b7-01":                         false,
+		"":                                                        false,
+	} {
+		if _, _, ok := parseTraceparent(header); ok != want {
+			t.Errorf("parseTraceparent(%q) = %v, want %v", header, ok, want)
+		}
+	}
+}
+
+func TestInitTracingEndpoint(t *testing.T) {
+	defer func() { tracing = nil }()
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://env-collector:4318")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+
+	for _, tc := range []struct {
+		flag, tracesEnv, want string
+	}{
+		{"", "", "http://env-collector:4318/v1/traces"},
+		{"", "http://traces:4318/custom", "http://traces:4318/custom"},
+		{"http://flag-collector:4318/", "http://traces:4318/custom", "http://flag-collector:4318/v1/traces"},
+	} {
+		t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", tc.tracesEnv)
+		tracing = nil
+		if err := initTracing(tc.flag); err != nil || tracing == nil || tracing.endpoint != tc.want {
+			t.Errorf("initTracing(%q) with traces env %q = %v, %v; want %s", tc.flag, tc.tracesEnv, tracing, err, tc.want)
+		}
+	}
+	if err := initTracing("collector:4318"); err == nil {
+		t.Error("expected an error for an endpoint without scheme")
+	}
+}
+
+func TestTracingGRPCSpanName(t *testing.T) {
+	tracing = &tracer{queue: make(chan *Span, 16)}
+	defer func() { tracing = nil }()
+
+	req := httptest.NewRequest(http.MethodPost, grpcSendMethod, nil)
+	req.Header.Set("Content-Type", "application/grpc")
+	traceRequests(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), req)
+
+	span := <-tracing.queue
+	if span.name != "goevals.v1.Ingest/Send" || span.attrs["rpc.system"] != "grpc" || span.attrs["rpc.method"] != "Send" {
+		t.Errorf("gRPC span = %s %v", span.name, span.attrs)
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maxLineBytes is the longest JSONL line validate reads, long responses included
+const maxLineBytes = 64 << 20
+
+// lineIssues checks one line of a JSONL file against the result format
+// Errors make the line unusable (the dashboard skips it); warnings are lines that load but
+// will sort, filter or color oddly
+func lineIssues(line []byte) (errs, warnings []string) {
+	result, err := decodeResult(line)
+	if err != nil {
+		return []string{fmt.Sprintf("invalid JSON: %v", err)}, nil
+	}
+	if result.Timestamp == "" {
+		errs = append(errs, "missing timestamp")
+	} else if _, err := time.Parse(time.RFC3339Nano, result.Timestamp); err != nil {
+		warnings = append(warnings, fmt.Sprintf("timestamp %q is not RFC 3339, results are ordered and filtered by comparing timestamps as text", result.Timestamp))
+	}
+	if result.Model == "" {
+		errs = append(errs, "missing model")
+	}
+	if result.TestID == "" {
+		warnings = append(warnings, "missing test_id, the result can't be compared across models")
+	}
+	if v := result.Scores.Combined; v < 0 || v > 1 {
+		warnings = append(warnings, fmt.Sprintf("scores.combined %g is outside 0-1", v))
+	}
+	for _, name := range slices.Sorted(maps.Keys(result.Scores.Custom)) {
+		if v := result.Scores.Custom[name]; v < 0 || v > 1 {
+			warnings = append(warnings, fmt.Sprintf("scores.%s %g is outside 0-1", name, v))
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(result.JudgeReasoning)) {
+		if _, ok := result.Scores.Custom[name]; !ok && name != "combined" {
+			warnings = append(warnings, fmt.Sprintf("judge_reasoning.%s has no matching score", name))
+		}
+	}
+	for i, rc := range result.RetrievedContexts {
+		if strings.TrimSpace(rc.Text) == "" {
+			warnings = append(warnings, fmt.Sprintf("retrieved_contexts[%d] has no text", i))
+		}
+	}
+	for i, a := range result.Attachments {
+		switch {
+		case a.Path == "" && a.Data == "":
+			warnings = append(warnings, fmt.Sprintf("attachments[%d] has neither a path nor data", i))
+		case a.Kind() == "file":
+			warnings = append(warnings, fmt.Sprintf("attachments[%d] is not an image, audio or video file (type %q), it won't be shown", i, a.MediaType()))
+		case a.URL() == "" && a.Data != "":
+			warnings = append(warnings, fmt.Sprintf("attachments[%d] data is not valid base64", i))
+		case a.URL() == "":
+			warnings = append(warnings, fmt.Sprintf("attachments[%d] path %q must be relative to --media-dir, without ..", i, a.Path))
+		}
+	}
+	for i, step := range result.Trace {
+		if step.Tool == "" {
+			warnings = append(warnings, fmt.Sprintf("trace[%d] has no tool", i))
+		}
+		if step.DurationMS < 0 {
+			warnings = append(warnings, fmt.Sprintf("trace[%d] has negative duration_ms %g", i, step.DurationMS))
+		}
+	}
+	if _, ok := result.CustomFields["config"]; ok {
+		warnings = append(warnings, "config is not an object, it is treated as a plain custom field")
+	}
+	for _, name := range slices.Sorted(maps.Keys(result.Config)) {
+		if _, ok := result.CustomFields[name]; ok {
+			warnings = append(warnings, fmt.Sprintf("%s is both a top-level field and in config, the config value wins", name))
+		}
+	}
+	if result.Weight != nil && !validWeight(*result.Weight) {
+		warnings = append(warnings, fmt.Sprintf("weight %g is negative, it counts as 1", *result.Weight))
+	}
+	if result.ResponseTimeMS < 0 {
+		warnings = append(warnings, fmt.Sprintf("negative response_time_ms %d", result.ResponseTimeMS))
+	}
+	return errs, warnings
+}
+
+// validateFile prints the issues of every line of a JSONL file as path:line: message and
+// returns how many results it holds and how many errors and warnings were found
+func validateFile(w io.Writer, path string) (results, errs, warnings int, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxLineBytes)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Bytes()
+		if lineNum == 1 {
+			line = bytes.TrimPrefix(line, utf8BOM)
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		lineErrs, lineWarnings := lineIssues(line)
+		for _, msg := range lineErrs {
+			fmt.Fprintf(w, "%s:%d: error: %s\n", path, lineNum, msg)
+		}
+		for _, msg := range lineWarnings {
+			fmt.Fprintf(w, "%s:%d: warning: %s\n", path, lineNum, msg)
+		}
+		if len(lineErrs) == 0 {
+			results++
+		}
+		errs += len(lineErrs)
+		warnings += len(lineWarnings)
+	}
+	if err := scanner.Err(); err != nil {
+		return results, errs, warnings, fmt.Errorf("line %d: %w", lineNum+1, err)
+	}
+	return results, errs, warnings, nil
+}
+
+// validateFiles expands a source argument to the JSONL files it reads
+func validateFiles(arg string) ([]string, error) {
+	source, err := NewSource(arg)
+	if err != nil {
+		return nil, err
+	}
+	switch source := source.(type) {
+	case *fileSource:
+		return []string{source.path}, nil
+	case *globSource:
+		matches, _ := filepath.Glob(source.pattern) // Validated by NewSource
+		return matches, nil
+	}
+	return nil, fmt.Errorf("validate only reads JSONL files, not %s", source.Name())
+}
+
+// runValidate implements `goevals validate`, a line by line format check of JSONL files
+func runValidate(args []string) int {
+	fs := newFlagSet("validate", "[flags] <file1> [file2] [...]",
+		"Checks every line of JSONL files (or directories and wildcards of them) against the result",
+		"format. Exits with status 1 if a line has errors, or warnings with --strict")
+	strict := fs.Bool("strict", false, "treat warnings as errors")
+	files := parseFlags(fs, args)
+	if len(files) == 0 {
+		fs.Usage()
+		return 2
+	}
+	if err := loadEncryptionKey(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	failed := false
+	for _, arg := range files {
+		paths, err := validateFiles(arg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		if len(paths) == 0 {
+			fmt.Fprintf(os.Stderr, "Error: no files match %s\n", arg)
+			return 2
+		}
+		for _, path := range paths {
+			results, errs, warnings, err := validateFile(os.Stdout, path)
+			if err != nil {
+				fmt.Printf("%s: %v\n", path, err)
+				failed = true
+				continue
+			}
+			fmt.Printf("%s: %d results, %d errors, %d warnings\n", path, results, errs, warnings)
+			if errs > 0 || (*strict && warnings > 0) {
+				failed = true
+			}
+		}
+	}
+	if failed {
+		fmt.Println(strings.Repeat("-", 40))
+		fmt.Println("Validation FAILED")
+		return 1
+	}
+	return 0
+}
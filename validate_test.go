@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "evals.jsonl")
+	os.WriteFile(path, []byte(`{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"q1","scores":{"combined":0.5},"retrieved_contexts":["ok",{"source":"a.md"}]}
+
+{"timestamp":"2025-01-01 10:00","model":"a","scores":{"combined":1.5,"faithfulness":-1}}
+{"model":"b","scores":{"combined":0.5},"judge_reasoning":{"tone":"ok"},"attachments":["q.png","../q.png",{"name":"empty"}],"trace":[{"tool":"search"},{"duration_ms":-5}]}
+{not json
+{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"q2","scores":{"combined":0.5},"config":{"top_k":5},"top_k":3}
+{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"q3","scores":{"combined":0.5},"config":"rag-v1"}
+`), 0o644)
+
+	var buf bytes.Buffer
+	results, errs, warnings, err := validateFile(&buf, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results != 4 || errs != 2 || warnings != 13 {
+		t.Errorf("results=%d errs=%d warnings=%d\n%s", results, errs, warnings, buf.String())
+	}
+	for _, want := range []string{
+		path + ":1: warning: retrieved_contexts[1] has no text",
+		path + ":3: warning: timestamp \"2025-01-01 10:00\" is not RFC 3339",
+		path + ":3: warning: missing test_id",
+		path + ":3: warning: scores.combined 1.5 is outside 0-1",
+		path + ":3: warning: scores.faithfulness -1 is outside 0-1",
+		path + ":4: error: missing timestamp",
+		path + ":4: warning: judge_reasoning.tone has no matching score",
+		path + ":4: warning: attachments[1] path \"../q.png\" must be relative to --media-dir",
+		path + ":4: warning: attachments[2] has neither a path nor data",
+		path + ":4: warning: trace[1] has no tool",
+		path + ":4: warning: trace[1] has negative duration_ms -5",
+		path + ":5: error: invalid JSON",
+		path + ":6: warning: top_k is both a top-level field and in config, the config value wins",
+		path + ":7: warning: config is not an object",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("missing %q in\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SavedView is a named dashboard URL (filters, sort, columns) shared between teammates
+type SavedView struct {
+	Name    string    `json:"name"`
+	Query   string    `json:"query"` // Dashboard query string without "?"
+	Created time.Time `json:"created"`
+}
+
+// viewStore keeps saved views in a JSON file
+type viewStore struct {
+	path string
+
+	mu    sync.Mutex
+	views map[string]SavedView
+}
+
+// maxViewNameLength keeps view names usable in URLs and dropdowns
+const maxViewNameLength = 100
+
+// views is the saved view registry, see --views-file
+var views *viewStore
+
+// newViewStore loads views from path, a missing file means no views yet
+func newViewStore(path string) (*viewStore, error) {
+	vs := &viewStore{path: path, views: make(map[string]SavedView)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return vs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read views: %w", err)
+	}
+
+	var list []SavedView
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("invalid views file %s: %w", path, err)
+	}
+	for _, view := range list {
+		vs.views[view.Name] = view
+	}
+	return vs, nil
+}
+
+// List returns all views sorted by name
+func (vs *viewStore) List() []SavedView {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	list := make([]SavedView, 0, len(vs.views))
+	for _, view := range vs.views {
+		list = append(list, view)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Get returns the view called name
+func (vs *viewStore) Get(name string) (SavedView, bool) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	view, ok := vs.views[name]
+	return view, ok
+}
+
+// Save adds or replaces a view and writes the file
+func (vs *viewStore) Save(view SavedView) error {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	vs.views[view.Name] = view
+	return vs.write()
+}
+
+// Delete removes a view, reports whether it existed
+func (vs *viewStore) Delete(name string) (bool, error) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	if _, ok := vs.views[name]; !ok {
+		return false, nil
+	}
+	delete(vs.views, name)
+	return true, vs.write()
+}
+
+// write replaces the file atomically, must be called with vs.mu held
+func (vs *viewStore) write() error {
+	list := make([]SavedView, 0, len(vs.views))
+	for _, view := range vs.views {
+		list = append(list, view)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := vs.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write views: %w", err)
+	}
+	return replaceFile(tmp, vs.path)
+}
+
+// viewsAPIHandler lists (GET), saves (POST {"name","query"}) and deletes (DELETE ?name=) views
+func viewsAPIHandler(w http.ResponseWriter, r *http.Request) {
+	views := viewsFor(r.Context())
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(views.List())
+
+	case http.MethodPost:
+		var view SavedView
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&view); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+			return
+		}
+		view.Name = strings.TrimSpace(view.Name)
+		view.Query = strings.TrimPrefix(view.Query, "?")
+		if view.Name == "" || len(view.Name) > maxViewNameLength {
+			http.Error(w, fmt.Sprintf("name must be 1-%d characters", maxViewNameLength), http.StatusBadRequest)
+			return
+		}
+		if _, err := url.ParseQuery(view.Query); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid query: %v", err), http.StatusBadRequest)
+			return
+		}
+		view.Created = time.Now().UTC()
+		if err := views.Save(view); err != nil {
+			log.Printf("Error saving view: %v", err)
+			http.Error(w, "Failed to save view", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(view)
+
+	case http.MethodDelete:
+		found, err := views.Delete(r.URL.Query().Get("name"))
+		if err != nil {
+			log.Printf("Error deleting view: %v", err)
+			http.Error(w, "Failed to delete view", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// viewHandler redirects /v/<name> to the dashboard with the saved query string
+func viewHandler(w http.ResponseWriter, r *http.Request) {
+	view, ok := viewsFor(r.Context()).Get(strings.TrimPrefix(r.URL.Path, "/v/"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	target := "/"
+	if view.Query != "" {
+		target += "?" + view.Query
+	}
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// dashboardFilter reads the dashboard filters from the query string
+func dashboardFilter(r *http.Request) Query {
+	return Query{
+		Model:   r.URL.Query().Get("model"),
+		RunID:   r.URL.Query().Get("run_id"),
+		TestID:  r.URL.Query().Get("test_id"),
+		Origin:  r.URL.Query().Get("source"),
+		Dataset: r.URL.Query().Get("dataset"),
+	}
+}
+
+// filterOptions returns the distinct models, run IDs, sources and dataset versions for the dashboard
+// filter dropdowns
+func filterOptions(results []EvalResult) (models, runs, sources, datasets []string) {
+	modelSet := make(map[string]bool)
+	runSet := make(map[string]bool)
+	sourceSet := make(map[string]bool)
+	datasetSet := make(map[string]bool)
+	for _, result := range results {
+		modelSet[result.Model] = true
+		if runID, ok := result.Metadata["run_id"].(string); ok && runID != "" {
+			runSet[runID] = true
+		}
+		if result.Origin != "" {
+			sourceSet[result.Origin] = true
+		}
+		if dataset := result.Dataset(); dataset != "" {
+			datasetSet[dataset] = true
+		}
+	}
+	return sortedKeys(modelSet, ""), sortedKeys(runSet, ""), sortedKeys(sourceSet, ""), sortedKeys(datasetSet, "")
+}
+
+// statsFilterOptions is filterOptions from aggregated stats, which may not hold the results
+func statsFilterOptions(data DashboardData) (models, runs, sources, datasets []string) {
+	modelSet := make(map[string]bool)
+	for _, stat := range data.ModelStats {
+		modelSet[stat.ActualModelName] = true
+	}
+	datasetSet := make(map[string]bool)
+	for dataset := range data.Datasets {
+		if dataset != "" { // Unversioned results can't be filtered to
+			datasetSet[dataset] = true
+		}
+	}
+	return sortedKeys(modelSet, ""), data.RunIDs, data.Origins, sortedKeys(datasetSet, "")
+}
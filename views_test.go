@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSavedViews(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "views.json")
+	vs, err := newViewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	views = vs
+	defer func() { views = nil }()
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		viewsAPIHandler(rec, httptest.NewRequest(http.MethodPost, "/api/views", strings.NewReader(body)))
+		return rec
+	}
+	if rec := post(`{"name":" gemma only ","query":"?model=gemma2%3A2b&sort=-combined"}`); rec.Code != http.StatusOK {
+		t.Fatalf("save: %d %s", rec.Code, rec.Body)
+	}
+	if rec := post(`{"name":"","query":""}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("empty name: %d", rec.Code)
+	}
+	if rec := post(`{"name":"bad","query":"a=%zz"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid query: %d", rec.Code)
+	}
+
+	// Views survive a restart
+	reloaded, err := newViewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	views = reloaded
+
+	rec := httptest.NewRecorder()
+	viewsAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/api/views", nil))
+	var list []SavedView
+	json.Unmarshal(rec.Body.Bytes(), &list)
+	if len(list) != 1 || list[0].Name != "gemma only" || list[0].Query != "model=gemma2%3A2b&sort=-combined" {
+		t.Fatalf("list = %+v", list)
+	}
+
+	rec = httptest.NewRecorder()
+	viewHandler(rec, httptest.NewRequest(http.MethodGet, "/v/gemma%20only", nil))
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/?model=gemma2%3A2b&sort=-combined" {
+		t.Errorf("redirect = %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+
+	rec = httptest.NewRecorder()
+	viewsAPIHandler(rec, httptest.NewRequest(http.MethodDelete, "/api/views?name=gemma+only", nil))
+	if rec.Code != http.StatusNoContent || len(views.List()) != 0 {
+		t.Errorf("delete = %d, %d views left", rec.Code, len(views.List()))
+	}
+}
+
+func TestDashboardFiltersFromURL(t *testing.T) {
+	useStore(t, NewMemoryStore([]Source{&fileSource{path: "evals.jsonl"}}))
+
+	rec := httptest.NewRecorder()
+	dashboardHandler(rec, httptest.NewRequest(http.MethodGet, "/?model=llama3:8b&cols=score:accuracy", nil))
+	body := rec.Body.String()
+
+	if !strings.Contains(body, `<option value="llama3:8b" selected>`) {
+		t.Error("model filter not selected")
+	}
+	if strings.Contains(body, "window.location='/tests?model=gemma2") {
+		t.Error("filtered dashboard still lists other models")
+	}
+	if !strings.Contains(body, `data-col="score:fluency" class="score-cell col-hidden"`) {
+		t.Error("cols parameter should hide unlisted columns")
+	}
+}
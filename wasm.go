@@ -0,0 +1,1314 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"runtime"
+)
+
+// A small WebAssembly interpreter for scorer plugins (see scorer.go), so goevals stays free of
+// dependencies. It runs the 1.0 instruction set plus what current compilers emit by default:
+// sign extension, saturating float to int conversion, bulk memory, multi-value blocks and
+// reference types. Modules can't import anything: a scorer only computes on the memory it is given
+
+// Value and reference types
+const (
+	wasmI32       = 0x7f
+	wasmI64       = 0x7e
+	wasmF32       = 0x7d
+	wasmF64       = 0x7c
+	wasmFuncRef   = 0x70
+	wasmExternRef = 0x6f
+)
+
+// Export kinds
+const (
+	wasmExportFunc   = 0
+	wasmExportMemory = 2
+)
+
+// Sandbox limits, per call of an exported function
+const (
+	wasmPageSize  = 64 << 10
+	wasmMaxPages  = 256         // 16 MiB of linear memory
+	wasmMaxSteps  = 100_000_000 // Executed instructions, about a second
+	wasmMaxDepth  = 1000        // Nested calls
+	wasmMaxStack  = 1 << 20     // Values on the operand stack and in the locals of all active calls, 8 MiB
+	wasmMaxTable  = 1 << 16     // Table entries
+	wasmMaxLocals = 50000       // Locals of one function
+)
+
+type wasmFuncType struct {
+	params, results []byte
+}
+
+func (t wasmFuncType) equal(o wasmFuncType) bool {
+	return bytes.Equal(t.params, o.params) && bytes.Equal(t.results, o.results)
+}
+
+type wasmFunc struct {
+	typ    uint32
+	locals int // Declared locals after the params, all start at zero
+	code   []wasmInstr
+}
+
+type wasmGlobal struct {
+	mutable bool
+	init    uint64
+}
+
+// wasmSegment is a data segment (data) or an element segment (refs)
+type wasmSegment struct {
+	active bool // Copied in at instantiation, at offset
+	offset uint32
+	data   []byte
+	refs   []uint32 // Function index + 1, 0 = null
+}
+
+type wasmExport struct {
+	kind  byte
+	index uint32
+}
+
+// wasmInstr is a decoded instruction, with the jump targets of blocks resolved
+//
+//	block, loop, if  x = index of the matching end, y = index of else (0 = none), z = params<<32 | results
+//	else             x = index of the end of its if
+//	br, br_if        x = label depth; br_table: table = depths, x = default
+//	call             x = function; call_indirect: x = type, y = table
+//	local/global/table access, ref.func, memory.init, data.drop: x = index
+//	loads and stores x = offset
+//	constants        z = value bits
+type wasmInstr struct {
+	op    uint16 // Opcode, 0xfc prefixed ones as 0x100 + their number
+	x, y  uint32
+	z     uint64
+	table []uint32
+}
+
+// wasmModule is a decoded, not yet running module, see instantiate
+type wasmModule struct {
+	types     []wasmFuncType
+	funcs     []wasmFunc
+	hasTable  bool
+	tableMin  uint32
+	hasMemory bool
+	memMin    uint32
+	memMax    uint32 // Pages, at most wasmMaxPages
+	globals   []wasmGlobal
+	exports   map[string]wasmExport
+	start     int // Function run at instantiation, -1 = none
+	elems     []wasmSegment
+	data      []wasmSegment
+}
+
+// wasmDecodeError aborts decoding, see decodeWasm
+type wasmDecodeError struct{ msg string }
+
+func (e wasmDecodeError) Error() string { return "invalid WebAssembly module: " + e.msg }
+
+// wasmTrap aborts execution, like a WebAssembly trap
+type wasmTrap string
+
+func (t wasmTrap) Error() string { return "wasm trap: " + string(t) }
+
+// wasmReader reads the binary format, failing with a wasmDecodeError panic
+type wasmReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *wasmReader) fail(format string, args ...any) {
+	panic(wasmDecodeError{fmt.Sprintf(format, args...)})
+}
+
+func (r *wasmReader) byte() byte {
+	if r.pos >= len(r.data) {
+		r.fail("unexpected end")
+	}
+	r.pos++
+	return r.data[r.pos-1]
+}
+
+func (r *wasmReader) peek() byte {
+	if r.pos >= len(r.data) {
+		r.fail("unexpected end")
+	}
+	return r.data[r.pos]
+}
+
+func (r *wasmReader) bytes(n uint32) []byte {
+	if uint64(n) > uint64(len(r.data)-r.pos) {
+		r.fail("unexpected end")
+	}
+	r.pos += int(n)
+	return r.data[r.pos-int(n) : r.pos]
+}
+
+// u32 reads an unsigned LEB128 integer
+func (r *wasmReader) u32() uint32 {
+	var v uint64
+	for shift := 0; ; shift += 7 {
+		if shift >= 35 {
+			r.fail("integer too long")
+		}
+		b := r.byte()
+		v |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	if v > math.MaxUint32 {
+		r.fail("integer too large")
+	}
+	return uint32(v)
+}
+
+// sleb reads a signed LEB128 integer of at most size bits
+func (r *wasmReader) sleb(size int) int64 {
+	var v int64
+	shift := 0
+	for {
+		b := r.byte()
+		v |= int64(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			if shift < 64 && b&0x40 != 0 {
+				v |= -1 << shift
+			}
+			return v
+		}
+		if shift >= size {
+			r.fail("integer too long")
+		}
+	}
+}
+
+// count reads the length of a vector, each element taking at least one more byte
+func (r *wasmReader) count() uint32 {
+	n := r.u32()
+	if uint64(n) > uint64(len(r.data)-r.pos) {
+		r.fail("vector longer than its section")
+	}
+	return n
+}
+
+func (r *wasmReader) name() string {
+	return string(r.bytes(r.u32()))
+}
+
+func (r *wasmReader) valtype() byte {
+	t := r.byte()
+	switch t {
+	case wasmI32, wasmI64, wasmF32, wasmF64, wasmFuncRef, wasmExternRef:
+		return t
+	}
+	r.fail("unsupported value type 0x%02x", t)
+	return 0
+}
+
+func (r *wasmReader) valtypes() []byte {
+	types := make([]byte, r.count())
+	for i := range types {
+		types[i] = r.valtype()
+	}
+	return types
+}
+
+// limits reads a memory or table size, max is 0 when there is none
+func (r *wasmReader) limits() (min, max uint32, hasMax bool) {
+	switch flags := r.byte(); flags {
+	case 0:
+		return r.u32(), 0, false
+	case 1:
+		return r.u32(), r.u32(), true
+	default:
+		r.fail("unsupported limits 0x%02x (shared memory and memory64 are not supported)", flags)
+	}
+	return
+}
+
+// decodeWasm parses and checks a module in the binary format
+func decodeWasm(data []byte) (m *wasmModule, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			derr, ok := r.(wasmDecodeError)
+			if !ok {
+				panic(r)
+			}
+			m, err = nil, derr
+		}
+	}()
+
+	r := &wasmReader{data: data}
+	if len(data) < 8 || string(data[:4]) != "\x00asm" {
+		return nil, errors.New("not a WebAssembly module")
+	}
+	if version := binary.LittleEndian.Uint32(data[4:8]); version != 1 {
+		return nil, fmt.Errorf("unsupported WebAssembly version %d", version)
+	}
+	r.pos = 8
+
+	m = &wasmModule{exports: make(map[string]wasmExport), start: -1}
+	codeCount := -1
+	for r.pos < len(r.data) {
+		id := r.byte()
+		sec := &wasmReader{data: r.bytes(r.u32())}
+		switch id {
+		case 0: // Custom section (names, producers...)
+			continue
+		case 1:
+			m.types = make([]wasmFuncType, sec.count())
+			for i := range m.types {
+				if form := sec.byte(); form != 0x60 {
+					sec.fail("unsupported type form 0x%02x", form)
+				}
+				m.types[i] = wasmFuncType{params: sec.valtypes(), results: sec.valtypes()}
+			}
+		case 2:
+			if n := sec.count(); n > 0 {
+				module, name := sec.name(), sec.name()
+				sec.fail("imports %s.%s, but scorers run without host functions and can't import anything", module, name)
+			}
+		case 3:
+			m.funcs = make([]wasmFunc, sec.count())
+			for i := range m.funcs {
+				if m.funcs[i].typ = sec.u32(); int(m.funcs[i].typ) >= len(m.types) {
+					sec.fail("function %d has unknown type %d", i, m.funcs[i].typ)
+				}
+			}
+		case 4:
+			for i := sec.count(); i > 0; i-- {
+				if m.hasTable {
+					sec.fail("more than one table")
+				}
+				sec.valtype()
+				m.hasTable = true
+				m.tableMin, _, _ = sec.limits()
+			}
+		case 5:
+			for i := sec.count(); i > 0; i-- {
+				if m.hasMemory {
+					sec.fail("more than one memory")
+				}
+				min, max, hasMax := sec.limits()
+				m.hasMemory, m.memMin, m.memMax = true, min, wasmMaxPages
+				if hasMax && max < wasmMaxPages {
+					m.memMax = max
+				}
+			}
+		case 6:
+			m.globals = make([]wasmGlobal, 0, sec.count())
+			for i := cap(m.globals); i > 0; i-- {
+				sec.valtype()
+				mutable := sec.byte() == 1
+				m.globals = append(m.globals, wasmGlobal{mutable: mutable, init: m.constExpr(sec)})
+			}
+		case 7:
+			for i := sec.count(); i > 0; i-- {
+				name := sec.name()
+				m.exports[name] = wasmExport{kind: sec.byte(), index: sec.u32()}
+			}
+		case 8:
+			m.start = int(sec.u32())
+		case 9:
+			m.elems = make([]wasmSegment, sec.count())
+			for i := range m.elems {
+				m.elems[i] = m.elemSegment(sec)
+			}
+		case 10:
+			codeCount = int(sec.count())
+			if codeCount != len(m.funcs) {
+				sec.fail("%d function bodies for %d functions", codeCount, len(m.funcs))
+			}
+			for i := range m.funcs {
+				body := &wasmReader{data: sec.bytes(sec.u32())}
+				m.compile(body, &m.funcs[i])
+			}
+		case 11:
+			m.data = make([]wasmSegment, sec.count())
+			for i := range m.data {
+				flags := sec.u32()
+				if flags > 2 {
+					sec.fail("unsupported data segment flags %d", flags)
+				}
+				if flags == 2 && sec.u32() != 0 {
+					sec.fail("data segment for a memory other than 0")
+				}
+				seg := wasmSegment{active: flags != 1}
+				if seg.active {
+					seg.offset = uint32(m.constExpr(sec))
+				}
+				seg.data = sec.bytes(sec.u32())
+				m.data[i] = seg
+			}
+		case 12: // Data count, only needed by single pass validators
+			sec.u32()
+		default:
+			r.fail("unknown section %d", id)
+		}
+		if sec.pos != len(sec.data) {
+			r.fail("section %d is longer than its contents", id)
+		}
+	}
+	if codeCount < 0 && len(m.funcs) > 0 {
+		r.fail("functions without a code section")
+	}
+	for name, export := range m.exports {
+		if export.kind == wasmExportFunc && int(export.index) >= len(m.funcs) {
+			r.fail("export %s refers to unknown function %d", name, export.index)
+		}
+	}
+	if m.start >= len(m.funcs) {
+		r.fail("unknown start function %d", m.start)
+	}
+	return m, nil
+}
+
+// constExpr evaluates an initializer expression: a constant, a global, or a function reference
+func (m *wasmModule) constExpr(r *wasmReader) uint64 {
+	var v uint64
+	for {
+		switch op := r.byte(); op {
+		case 0x0b:
+			return v
+		case 0x41:
+			v = uint64(uint32(int32(r.sleb(32))))
+		case 0x42:
+			v = uint64(r.sleb(64))
+		case 0x43:
+			v = uint64(binary.LittleEndian.Uint32(r.bytes(4)))
+		case 0x44:
+			v = binary.LittleEndian.Uint64(r.bytes(8))
+		case 0x23:
+			i := r.u32()
+			if int(i) >= len(m.globals) {
+				r.fail("unknown global %d", i)
+			}
+			v = m.globals[i].init
+		case 0xd0:
+			r.byte()
+			v = 0
+		case 0xd2:
+			v = uint64(r.u32()) + 1
+		default:
+			r.fail("unsupported instruction 0x%02x in a constant expression", op)
+		}
+	}
+}
+
+// elemSegment reads one of the eight element segment encodings, only table 0 is supported
+func (m *wasmModule) elemSegment(r *wasmReader) wasmSegment {
+	flags := r.u32()
+	if flags > 7 {
+		r.fail("unsupported element segment flags %d", flags)
+	}
+	seg := wasmSegment{active: flags&1 == 0}
+	if flags&3 == 2 && r.u32() != 0 {
+		r.fail("element segment for a table other than 0")
+	}
+	if seg.active {
+		seg.offset = uint32(m.constExpr(r))
+	}
+	if flags&3 != 0 {
+		r.byte() // Element kind or reference type
+	}
+	seg.refs = make([]uint32, r.count())
+	for i := range seg.refs {
+		if flags&4 != 0 {
+			seg.refs[i] = uint32(m.constExpr(r))
+		} else {
+			seg.refs[i] = r.u32() + 1
+		}
+	}
+	return seg
+}
+
+// blockType reads the type of a block as params<<32 | results
+func (m *wasmModule) blockType(r *wasmReader) uint64 {
+	switch r.peek() {
+	case 0x40:
+		r.pos++
+		return 0
+	case wasmI32, wasmI64, wasmF32, wasmF64, wasmFuncRef, wasmExternRef:
+		r.pos++
+		return 1
+	}
+	i := r.sleb(33)
+	if i < 0 || i >= int64(len(m.types)) {
+		r.fail("unknown block type %d", i)
+	}
+	t := m.types[i]
+	return uint64(len(t.params))<<32 | uint64(len(t.results))
+}
+
+// compile decodes a function body, resolving where every block ends
+func (m *wasmModule) compile(r *wasmReader, fn *wasmFunc) {
+	for groups := r.count(); groups > 0; groups-- {
+		n := r.u32()
+		r.valtype()
+		if fn.locals += int(n); fn.locals > wasmMaxLocals {
+			r.fail("more than %d locals", wasmMaxLocals)
+		}
+	}
+	numLocals := uint32(len(m.types[fn.typ].params) + fn.locals)
+
+	open := []int{-1} // Indexes of the enclosing block, loop and if instructions, -1 = the body
+	for len(open) > 0 {
+		in := wasmInstr{op: uint16(r.byte())}
+		switch op := in.op; {
+		case op == 0x02 || op == 0x03 || op == 0x04:
+			in.z = m.blockType(r)
+			open = append(open, len(fn.code))
+		case op == 0x05:
+			top := open[len(open)-1]
+			if top < 0 || fn.code[top].op != 0x04 || fn.code[top].y != 0 {
+				r.fail("else outside of an if")
+			}
+			fn.code[top].y = uint32(len(fn.code))
+		case op == 0x0b:
+			top := open[len(open)-1]
+			open = open[:len(open)-1]
+			if top >= 0 {
+				fn.code[top].x = uint32(len(fn.code))
+				if e := fn.code[top].y; e != 0 {
+					fn.code[e].x = uint32(len(fn.code))
+				}
+			}
+		case op == 0x0c || op == 0x0d:
+			in.x = r.u32()
+		case op == 0x0e:
+			in.table = make([]uint32, r.count())
+			for i := range in.table {
+				in.table[i] = r.u32()
+			}
+			in.x = r.u32()
+		case op == 0x10:
+			if in.x = r.u32(); int(in.x) >= len(m.funcs) {
+				r.fail("call to unknown function %d", in.x)
+			}
+		case op == 0x11:
+			if in.x, in.y = r.u32(), r.u32(); int(in.x) >= len(m.types) {
+				r.fail("call_indirect with unknown type %d", in.x)
+			}
+		case op == 0x1c: // select with types
+			for n := r.count(); n > 0; n-- {
+				r.valtype()
+			}
+			in.op = 0x1b
+		case op >= 0x20 && op <= 0x22:
+			if in.x = r.u32(); in.x >= numLocals {
+				r.fail("unknown local %d", in.x)
+			}
+		case op == 0x23 || op == 0x24:
+			if in.x = r.u32(); int(in.x) >= len(m.globals) {
+				r.fail("unknown global %d", in.x)
+			}
+			if op == 0x24 && !m.globals[in.x].mutable {
+				r.fail("global.set of immutable global %d", in.x)
+			}
+		case op == 0x25 || op == 0x26 || op == 0xd2:
+			in.x = r.u32()
+		case op >= 0x28 && op <= 0x3e:
+			r.u32() // Alignment, a hint
+			in.x = r.u32()
+		case op == 0x3f || op == 0x40 || op == 0xd0:
+			r.byte()
+		case op == 0x41:
+			in.z = uint64(uint32(int32(r.sleb(32))))
+		case op == 0x42:
+			in.z = uint64(r.sleb(64))
+		case op == 0x43:
+			in.z = uint64(binary.LittleEndian.Uint32(r.bytes(4)))
+		case op == 0x44:
+			in.z = binary.LittleEndian.Uint64(r.bytes(8))
+		case op == 0xfc:
+			sub := r.u32()
+			in.op = 0x100 + uint16(min(sub, 0xff))
+			switch sub {
+			case 0, 1, 2, 3, 4, 5, 6, 7: // Saturating truncation
+			case 8: // memory.init
+				in.x = r.u32()
+				r.byte()
+			case 9: // data.drop
+				in.x = r.u32()
+			case 10: // memory.copy
+				r.byte()
+				r.byte()
+			case 11: // memory.fill
+				r.byte()
+			default:
+				r.fail("unsupported instruction 0xfc %d", sub)
+			}
+		case op == 0x00 || op == 0x01 || op == 0x0f || op == 0x1a || op == 0x1b || op == 0xd1 || (op >= 0x45 && op <= 0xc4):
+		default:
+			r.fail("unsupported instruction 0x%02x", op)
+		}
+		fn.code = append(fn.code, in)
+	}
+	if r.pos != len(r.data) {
+		r.fail("bytes after the end of a function body")
+	}
+}
+
+// wasmInstance is a running module with its own memory, globals and table
+type wasmInstance struct {
+	m       *wasmModule
+	memory  []byte
+	globals []uint64
+	table   []uint32 // Function index + 1, 0 = null
+	dropped []bool   // Data segments released with data.drop
+	stack   []uint64 // Operand stack, values as their bits: i32 zero-extended, floats as IEEE 754
+	steps   int
+	depth   int
+	locals  int // Locals of all active calls, which count towards wasmMaxStack with the stack
+}
+
+// instantiate creates an instance: memory, globals, table and segments are set up and the start
+// function, if any, runs
+func (m *wasmModule) instantiate() (*wasmInstance, error) {
+	inst := &wasmInstance{m: m, dropped: make([]bool, len(m.data)), stack: make([]uint64, 0, 256)}
+	if m.hasMemory {
+		if m.memMin > m.memMax {
+			return nil, fmt.Errorf("module needs %d pages of memory, scorers can use %d", m.memMin, m.memMax)
+		}
+		inst.memory = make([]byte, int(m.memMin)*wasmPageSize)
+	}
+	if m.hasTable {
+		if m.tableMin > wasmMaxTable {
+			return nil, fmt.Errorf("module needs a table of %d entries, scorers can use %d", m.tableMin, wasmMaxTable)
+		}
+		inst.table = make([]uint32, m.tableMin)
+	}
+	inst.globals = make([]uint64, len(m.globals))
+	for i, g := range m.globals {
+		inst.globals[i] = g.init
+	}
+	for i, seg := range m.elems {
+		if !seg.active {
+			continue
+		}
+		if uint64(seg.offset)+uint64(len(seg.refs)) > uint64(len(inst.table)) {
+			return nil, fmt.Errorf("element segment %d is out of the table's bounds", i)
+		}
+		for _, ref := range seg.refs {
+			if ref > uint32(len(m.funcs)) {
+				return nil, fmt.Errorf("element segment %d refers to unknown function %d", i, ref-1)
+			}
+		}
+		copy(inst.table[seg.offset:], seg.refs)
+	}
+	for i, seg := range m.data {
+		if !seg.active {
+			continue
+		}
+		if uint64(seg.offset)+uint64(len(seg.data)) > uint64(len(inst.memory)) {
+			return nil, fmt.Errorf("data segment %d is out of memory bounds", i)
+		}
+		copy(inst.memory[seg.offset:], seg.data)
+		inst.dropped[i] = true
+	}
+	if m.start >= 0 {
+		if _, err := inst.call(uint32(m.start)); err != nil {
+			return nil, fmt.Errorf("start function: %w", err)
+		}
+	}
+	return inst, nil
+}
+
+// export returns the index of an exported function
+func (m *wasmModule) export(name string) (uint32, wasmFuncType, bool) {
+	export, ok := m.exports[name]
+	if !ok || export.kind != wasmExportFunc {
+		return 0, wasmFuncType{}, false
+	}
+	return export.index, m.types[m.funcs[export.index].typ], true
+}
+
+// call runs function f with args and returns its results
+// Traps, and malformed code the decoder let through, come back as errors
+func (inst *wasmInstance) call(f uint32, args ...uint64) (results []uint64, err error) {
+	t := inst.m.types[inst.m.funcs[f].typ]
+	if len(args) != len(t.params) {
+		return nil, fmt.Errorf("function %d takes %d arguments, got %d", f, len(t.params), len(args))
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			switch e := r.(type) {
+			case wasmTrap:
+				err = e
+			case runtime.Error:
+				err = fmt.Errorf("invalid WebAssembly code: %v", e)
+			default:
+				panic(r)
+			}
+			results = nil
+		}
+		inst.stack, inst.depth, inst.locals = inst.stack[:0], 0, 0
+	}()
+
+	inst.steps = 0
+	inst.stack = append(inst.stack[:0], args...)
+	inst.invoke(f)
+	if len(inst.stack) != len(t.results) {
+		return nil, errors.New("invalid WebAssembly code: unbalanced stack")
+	}
+	return append([]uint64(nil), inst.stack...), nil
+}
+
+// wasmLabel is the target of a branch
+type wasmLabel struct {
+	height int  // Stack height under the block's params
+	arity  int  // Values a branch carries: a loop's params, a block's results
+	cont   int  // Where execution continues after a branch
+	loop   bool // A branch re-enters the loop, whose label stays
+}
+
+// invoke runs function f with its arguments on the stack, leaving its results there
+func (inst *wasmInstance) invoke(f uint32) {
+	if inst.depth++; inst.depth > wasmMaxDepth {
+		panic(wasmTrap("call stack exhausted"))
+	}
+	fn := &inst.m.funcs[f]
+	t := &inst.m.types[fn.typ]
+	code := fn.code
+
+	s := inst.stack
+	np := len(t.params)
+	// Deep recursion through functions with many locals is bounded like a growing stack
+	if inst.locals += np + fn.locals; inst.locals+len(s) > wasmMaxStack {
+		panic(wasmTrap("call stack exhausted"))
+	}
+	locals := make([]uint64, np+fn.locals)
+	copy(locals, s[len(s)-np:])
+	s = s[:len(s)-np]
+	labels := make([]wasmLabel, 1, 8)
+	labels[0] = wasmLabel{height: len(s), arity: len(t.results), cont: len(code)}
+
+	// branch unwinds to the label at depth and returns the instruction before the one to run next
+	branch := func(depth uint32) int {
+		l := labels[len(labels)-1-int(depth)]
+		copy(s[l.height:], s[len(s)-l.arity:])
+		s = s[:l.height+l.arity]
+		if l.loop {
+			labels = labels[:len(labels)-int(depth)]
+			if len(s)+inst.locals > wasmMaxStack {
+				panic(wasmTrap("operand stack exhausted"))
+			}
+		} else {
+			labels = labels[:len(labels)-1-int(depth)]
+		}
+		return l.cont - 1
+	}
+
+	for pc := 0; pc < len(code); pc++ {
+		if inst.steps++; inst.steps > wasmMaxSteps {
+			panic(wasmTrap(fmt.Sprintf("more than %d steps", wasmMaxSteps)))
+		}
+		in := &code[pc]
+		top := len(s) - 1
+		switch in.op {
+		case 0x00:
+			panic(wasmTrap("unreachable"))
+		case 0x01:
+		case 0x02:
+			labels = append(labels, wasmLabel{height: len(s) - int(in.z>>32), arity: int(uint32(in.z)), cont: int(in.x) + 1})
+		case 0x03:
+			params := int(in.z >> 32)
+			labels = append(labels, wasmLabel{height: len(s) - params, arity: params, cont: pc + 1, loop: true})
+		case 0x04:
+			cond := s[top]
+			s = s[:top]
+			labels = append(labels, wasmLabel{height: len(s) - int(in.z>>32), arity: int(uint32(in.z)), cont: int(in.x) + 1})
+			if cond == 0 {
+				if in.y != 0 {
+					pc = int(in.y)
+				} else {
+					pc = int(in.x) - 1
+				}
+			}
+		case 0x05:
+			pc = int(in.x) - 1
+		case 0x0b:
+			labels = labels[:len(labels)-1]
+		case 0x0c:
+			pc = branch(in.x)
+		case 0x0d:
+			cond := s[top]
+			s = s[:top]
+			if cond != 0 {
+				pc = branch(in.x)
+			}
+		case 0x0e:
+			i := uint32(s[top])
+			s = s[:top]
+			depth := in.x
+			if i < uint32(len(in.table)) {
+				depth = in.table[i]
+			}
+			pc = branch(depth)
+		case 0x0f:
+			pc = branch(uint32(len(labels) - 1))
+		case 0x10:
+			inst.stack = s
+			inst.invoke(in.x)
+			s = inst.stack
+		case 0x11:
+			i := uint32(s[top])
+			s = s[:top]
+			if in.y != 0 || i >= uint32(len(inst.table)) {
+				panic(wasmTrap("undefined table element"))
+			}
+			ref := inst.table[i]
+			if ref == 0 {
+				panic(wasmTrap("uninitialized table element"))
+			}
+			if !inst.m.types[inst.m.funcs[ref-1].typ].equal(inst.m.types[in.x]) {
+				panic(wasmTrap("indirect call type mismatch"))
+			}
+			inst.stack = s
+			inst.invoke(ref - 1)
+			s = inst.stack
+		case 0x1a:
+			s = s[:top]
+		case 0x1b:
+			if s[top] == 0 {
+				s[top-2] = s[top-1]
+			}
+			s = s[:top-1]
+		case 0x20:
+			s = append(s, locals[in.x])
+		case 0x21:
+			locals[in.x] = s[top]
+			s = s[:top]
+		case 0x22:
+			locals[in.x] = s[top]
+		case 0x23:
+			s = append(s, inst.globals[in.x])
+		case 0x24:
+			inst.globals[in.x] = s[top]
+			s = s[:top]
+		case 0x25:
+			s[top] = inst.tableEntry(in.x, s[top])
+		case 0x26:
+			i := s[top-1]
+			inst.tableEntry(in.x, i)
+			inst.table[uint32(i)] = uint32(s[top])
+			s = s[:top-1]
+		case 0x28, 0x29, 0x2a, 0x2b, 0x2c, 0x2d, 0x2e, 0x2f, 0x30, 0x31, 0x32, 0x33, 0x34, 0x35:
+			s[top] = inst.load(in.op, s[top], in.x)
+		case 0x36, 0x37, 0x38, 0x39, 0x3a, 0x3b, 0x3c, 0x3d, 0x3e:
+			inst.store(in.op, s[top-1], in.x, s[top])
+			s = s[:top-1]
+		case 0x3f:
+			s = append(s, uint64(len(inst.memory)/wasmPageSize))
+		case 0x40:
+			s[top] = inst.grow(uint32(s[top]))
+		case 0x41, 0x42, 0x43, 0x44:
+			s = append(s, in.z)
+		case 0xd0:
+			s = append(s, 0)
+		case 0xd1:
+			s[top] = wasmBool(s[top] == 0)
+		case 0xd2:
+			s = append(s, uint64(in.x)+1)
+		case 0x108: // memory.init
+			if int(in.x) >= len(inst.m.data) {
+				panic(wasmTrap("unknown data segment"))
+			}
+			var seg []byte
+			if !inst.dropped[in.x] {
+				seg = inst.m.data[in.x].data
+			}
+			dst, src, n := s[top-2], uint32(s[top-1]), uint32(s[top])
+			if uint64(src)+uint64(n) > uint64(len(seg)) {
+				panic(wasmTrap("out of bounds memory access"))
+			}
+			copy(inst.bytes(dst, n), seg[src:])
+			s = s[:top-2]
+		case 0x109: // data.drop
+			if int(in.x) >= len(inst.dropped) {
+				panic(wasmTrap("unknown data segment"))
+			}
+			inst.dropped[in.x] = true
+		case 0x10a: // memory.copy
+			n := uint32(s[top])
+			src := inst.bytes(s[top-1], n)
+			copy(inst.bytes(s[top-2], n), src)
+			s = s[:top-2]
+		case 0x10b: // memory.fill
+			dst := inst.bytes(s[top-2], uint32(s[top]))
+			for i := range dst {
+				dst[i] = byte(s[top-1])
+			}
+			s = s[:top-2]
+		default:
+			if wasmUnaryOp(in.op) {
+				s[top] = wasmUnary(in.op, s[top])
+			} else {
+				s[top-1] = wasmBinary(in.op, s[top-1], s[top])
+				s = s[:top]
+			}
+		}
+	}
+	inst.stack = s
+	inst.depth--
+	inst.locals -= np + fn.locals
+}
+
+// tableEntry checks a table access and returns the entry at i
+func (inst *wasmInstance) tableEntry(table uint32, i uint64) uint64 {
+	if table != 0 || uint32(i) >= uint32(len(inst.table)) {
+		panic(wasmTrap("out of bounds table access"))
+	}
+	return uint64(inst.table[uint32(i)])
+}
+
+// bytes returns n bytes of memory at address base, trapping outside of memory
+func (inst *wasmInstance) bytes(base uint64, n uint32) []byte {
+	a := uint64(uint32(base))
+	if a+uint64(n) > uint64(len(inst.memory)) {
+		panic(wasmTrap("out of bounds memory access"))
+	}
+	return inst.memory[a : a+uint64(n)]
+}
+
+// access returns the size bytes a load or store at base+offset touches
+func (inst *wasmInstance) access(base uint64, offset uint32, size uint64) []byte {
+	a := uint64(uint32(base)) + uint64(offset)
+	if a+size > uint64(len(inst.memory)) {
+		panic(wasmTrap("out of bounds memory access"))
+	}
+	return inst.memory[a : a+size]
+}
+
+func (inst *wasmInstance) load(op uint16, base uint64, offset uint32) uint64 {
+	le := binary.LittleEndian
+	switch op {
+	case 0x28, 0x2a, 0x35: // i32.load, f32.load, i64.load32_u
+		return uint64(le.Uint32(inst.access(base, offset, 4)))
+	case 0x29, 0x2b: // i64.load, f64.load
+		return le.Uint64(inst.access(base, offset, 8))
+	case 0x2c:
+		return uint64(uint32(int32(int8(inst.access(base, offset, 1)[0]))))
+	case 0x2d, 0x31:
+		return uint64(inst.access(base, offset, 1)[0])
+	case 0x2e:
+		return uint64(uint32(int32(int16(le.Uint16(inst.access(base, offset, 2))))))
+	case 0x2f, 0x33:
+		return uint64(le.Uint16(inst.access(base, offset, 2)))
+	case 0x30:
+		return uint64(int64(int8(inst.access(base, offset, 1)[0])))
+	case 0x32:
+		return uint64(int64(int16(le.Uint16(inst.access(base, offset, 2)))))
+	default: // 0x34, i64.load32_s
+		return uint64(int64(int32(le.Uint32(inst.access(base, offset, 4)))))
+	}
+}
+
+func (inst *wasmInstance) store(op uint16, base uint64, offset uint32, v uint64) {
+	le := binary.LittleEndian
+	switch op {
+	case 0x36, 0x38, 0x3e: // i32.store, f32.store, i64.store32
+		le.PutUint32(inst.access(base, offset, 4), uint32(v))
+	case 0x37, 0x39:
+		le.PutUint64(inst.access(base, offset, 8), v)
+	case 0x3a, 0x3c:
+		inst.access(base, offset, 1)[0] = byte(v)
+	default: // 0x3b, 0x3d: 16 bit stores
+		le.PutUint16(inst.access(base, offset, 2), uint16(v))
+	}
+}
+
+// grow implements memory.grow: the old size in pages, or -1 past the sandbox's limit
+func (inst *wasmInstance) grow(pages uint32) uint64 {
+	old := uint32(len(inst.memory) / wasmPageSize)
+	if !inst.m.hasMemory || uint64(old)+uint64(pages) > uint64(inst.m.memMax) {
+		return uint64(math.MaxUint32)
+	}
+	inst.memory = append(inst.memory, make([]byte, int(pages)*wasmPageSize)...)
+	return uint64(old)
+}
+
+func wasmBool(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func f32bits(v uint64) float32  { return math.Float32frombits(uint32(v)) }
+func f32value(f float32) uint64 { return uint64(math.Float32bits(f)) }
+func f64bits(v uint64) float64  { return math.Float64frombits(v) }
+
+// wasmUnaryOp reports whether a numeric instruction takes one operand
+func wasmUnaryOp(op uint16) bool {
+	return op == 0x45 || op == 0x50 || (op >= 0x67 && op <= 0x69) || (op >= 0x79 && op <= 0x7b) ||
+		(op >= 0x8b && op <= 0x91) || (op >= 0x99 && op <= 0x9f) || (op >= 0xa7 && op <= 0xc4) || (op >= 0x100 && op <= 0x107)
+}
+
+// wasmTrunc truncates f towards zero, trapping when the result doesn't fit [lo, hi)
+func wasmTrunc(f, lo, hi float64) float64 {
+	if math.IsNaN(f) {
+		panic(wasmTrap("invalid conversion to integer"))
+	}
+	t := math.Trunc(f)
+	if t < lo || t >= hi {
+		panic(wasmTrap("integer overflow"))
+	}
+	return t
+}
+
+// Bounds of the integer types as floats, for conversions
+const (
+	twoTo31 = 1 << 31
+	twoTo32 = 1 << 32
+	twoTo63 = 1 << 63
+	twoTo64 = 1 << 64
+)
+
+// wasmTruncSat converts like the saturating truncations: NaN is 0, out of range values clamp
+func wasmTruncSat(f float64, signed bool, size int) uint64 {
+	switch {
+	case math.IsNaN(f):
+		return 0
+	case signed && size == 32:
+		return uint64(uint32(int32(max(min(f, twoTo31-1), -twoTo31))))
+	case size == 32:
+		return uint64(uint32(max(min(f, twoTo32-1), 0)))
+	case signed:
+		if f >= twoTo63 {
+			return math.MaxInt64
+		}
+		return uint64(int64(max(f, -twoTo63)))
+	default:
+		if f >= twoTo64 {
+			return math.MaxUint64
+		}
+		return uint64(max(f, 0))
+	}
+}
+
+func wasmUnary(op uint16, a uint64) uint64 {
+	switch op {
+	case 0x45, 0x50:
+		return wasmBool(a == 0)
+	case 0x67:
+		return uint64(bits.LeadingZeros32(uint32(a)))
+	case 0x68:
+		return uint64(bits.TrailingZeros32(uint32(a)))
+	case 0x69:
+		return uint64(bits.OnesCount32(uint32(a)))
+	case 0x79:
+		return uint64(bits.LeadingZeros64(a))
+	case 0x7a:
+		return uint64(bits.TrailingZeros64(a))
+	case 0x7b:
+		return uint64(bits.OnesCount64(a))
+	case 0x8b:
+		return a &^ (1 << 31)
+	case 0x8c:
+		return a ^ (1 << 31)
+	case 0x8d:
+		return f32value(float32(math.Ceil(float64(f32bits(a)))))
+	case 0x8e:
+		return f32value(float32(math.Floor(float64(f32bits(a)))))
+	case 0x8f:
+		return f32value(float32(math.Trunc(float64(f32bits(a)))))
+	case 0x90:
+		return f32value(float32(math.RoundToEven(float64(f32bits(a)))))
+	case 0x91:
+		return f32value(float32(math.Sqrt(float64(f32bits(a)))))
+	case 0x99:
+		return a &^ (1 << 63)
+	case 0x9a:
+		return a ^ (1 << 63)
+	case 0x9b:
+		return math.Float64bits(math.Ceil(f64bits(a)))
+	case 0x9c:
+		return math.Float64bits(math.Floor(f64bits(a)))
+	case 0x9d:
+		return math.Float64bits(math.Trunc(f64bits(a)))
+	case 0x9e:
+		return math.Float64bits(math.RoundToEven(f64bits(a)))
+	case 0x9f:
+		return math.Float64bits(math.Sqrt(f64bits(a)))
+	case 0xa7, 0xad: // i32.wrap_i64, i64.extend_i32_u
+		return uint64(uint32(a))
+	case 0xa8:
+		return uint64(uint32(int32(wasmTrunc(float64(f32bits(a)), -twoTo31, twoTo31))))
+	case 0xa9:
+		return uint64(uint32(wasmTrunc(float64(f32bits(a)), 0, twoTo32)))
+	case 0xaa:
+		return uint64(uint32(int32(wasmTrunc(f64bits(a), -twoTo31, twoTo31))))
+	case 0xab:
+		return uint64(uint32(wasmTrunc(f64bits(a), 0, twoTo32)))
+	case 0xac:
+		return uint64(int64(int32(a)))
+	case 0xae:
+		return uint64(int64(wasmTrunc(float64(f32bits(a)), -twoTo63, twoTo63)))
+	case 0xaf:
+		return uint64(wasmTrunc(float64(f32bits(a)), 0, twoTo64))
+	case 0xb0:
+		return uint64(int64(wasmTrunc(f64bits(a), -twoTo63, twoTo63)))
+	case 0xb1:
+		return uint64(wasmTrunc(f64bits(a), 0, twoTo64))
+	case 0xb2:
+		return f32value(float32(int32(a)))
+	case 0xb3:
+		return f32value(float32(uint32(a)))
+	case 0xb4:
+		return f32value(float32(int64(a)))
+	case 0xb5:
+		return f32value(float32(a))
+	case 0xb6:
+		return f32value(float32(f64bits(a)))
+	case 0xb7:
+		return math.Float64bits(float64(int32(a)))
+	case 0xb8:
+		return math.Float64bits(float64(uint32(a)))
+	case 0xb9:
+		return math.Float64bits(float64(int64(a)))
+	case 0xba:
+		return math.Float64bits(float64(a))
+	case 0xbb:
+		return math.Float64bits(float64(f32bits(a)))
+	case 0xbc, 0xbd, 0xbe, 0xbf: // Reinterpretations, values are kept as bits already
+		return a
+	case 0xc0:
+		return uint64(uint32(int32(int8(a))))
+	case 0xc1:
+		return uint64(uint32(int32(int16(a))))
+	case 0xc2:
+		return uint64(int64(int8(a)))
+	case 0xc3:
+		return uint64(int64(int16(a)))
+	case 0xc4:
+		return uint64(int64(int32(a)))
+	case 0x100:
+		return wasmTruncSat(float64(f32bits(a)), true, 32)
+	case 0x101:
+		return wasmTruncSat(float64(f32bits(a)), false, 32)
+	case 0x102:
+		return wasmTruncSat(f64bits(a), true, 32)
+	case 0x103:
+		return wasmTruncSat(f64bits(a), false, 32)
+	case 0x104:
+		return wasmTruncSat(float64(f32bits(a)), true, 64)
+	case 0x105:
+		return wasmTruncSat(float64(f32bits(a)), false, 64)
+	case 0x106:
+		return wasmTruncSat(f64bits(a), true, 64)
+	default: // 0x107
+		return wasmTruncSat(f64bits(a), false, 64)
+	}
+}
+
+func wasmBinary(op uint16, a, b uint64) uint64 {
+	x32, y32 := uint32(a), uint32(b)
+	fx32, fy32 := f32bits(a), f32bits(b)
+	fx64, fy64 := f64bits(a), f64bits(b)
+	switch op {
+	// Comparisons
+	case 0x46:
+		return wasmBool(x32 == y32)
+	case 0x47:
+		return wasmBool(x32 != y32)
+	case 0x48:
+		return wasmBool(int32(x32) < int32(y32))
+	case 0x49:
+		return wasmBool(x32 < y32)
+	case 0x4a:
+		return wasmBool(int32(x32) > int32(y32))
+	case 0x4b:
+		return wasmBool(x32 > y32)
+	case 0x4c:
+		return wasmBool(int32(x32) <= int32(y32))
+	case 0x4d:
+		return wasmBool(x32 <= y32)
+	case 0x4e:
+		return wasmBool(int32(x32) >= int32(y32))
+	case 0x4f:
+		return wasmBool(x32 >= y32)
+	case 0x51:
+		return wasmBool(a == b)
+	case 0x52:
+		return wasmBool(a != b)
+	case 0x53:
+		return wasmBool(int64(a) < int64(b))
+	case 0x54:
+		return wasmBool(a < b)
+	case 0x55:
+		return wasmBool(int64(a) > int64(b))
+	case 0x56:
+		return wasmBool(a > b)
+	case 0x57:
+		return wasmBool(int64(a) <= int64(b))
+	case 0x58:
+		return wasmBool(a <= b)
+	case 0x59:
+		return wasmBool(int64(a) >= int64(b))
+	case 0x5a:
+		return wasmBool(a >= b)
+	case 0x5b:
+		return wasmBool(fx32 == fy32)
+	case 0x5c:
+		return wasmBool(fx32 != fy32)
+	case 0x5d:
+		return wasmBool(fx32 < fy32)
+	case 0x5e:
+		return wasmBool(fx32 > fy32)
+	case 0x5f:
+		return wasmBool(fx32 <= fy32)
+	case 0x60:
+		return wasmBool(fx32 >= fy32)
+	case 0x61:
+		return wasmBool(fx64 == fy64)
+	case 0x62:
+		return wasmBool(fx64 != fy64)
+	case 0x63:
+		return wasmBool(fx64 < fy64)
+	case 0x64:
+		return wasmBool(fx64 > fy64)
+	case 0x65:
+		return wasmBool(fx64 <= fy64)
+	case 0x66:
+		return wasmBool(fx64 >= fy64)
+
+	// i32 arithmetic
+	case 0x6a:
+		return uint64(x32 + y32)
+	case 0x6b:
+		return uint64(x32 - y32)
+	case 0x6c:
+		return uint64(x32 * y32)
+	case 0x6d:
+		if y32 == 0 {
+			panic(wasmTrap("integer divide by zero"))
+		}
+		if int32(x32) == math.MinInt32 && int32(y32) == -1 {
+			panic(wasmTrap("integer overflow"))
+		}
+		return uint64(uint32(int32(x32) / int32(y32)))
+	case 0x6e:
+		if y32 == 0 {
+			panic(wasmTrap("integer divide by zero"))
+		}
+		return uint64(x32 / y32)
+	case 0x6f:
+		if y32 == 0 {
+			panic(wasmTrap("integer divide by zero"))
+		}
+		if int32(y32) == -1 {
+			return 0
+		}
+		return uint64(uint32(int32(x32) % int32(y32)))
+	case 0x70:
+		if y32 == 0 {
+			panic(wasmTrap("integer divide by zero"))
+		}
+		return uint64(x32 % y32)
+	case 0x71:
+		return uint64(x32 & y32)
+	case 0x72:
+		return uint64(x32 | y32)
+	case 0x73:
+		return uint64(x32 ^ y32)
+	case 0x74:
+		return uint64(x32 << (y32 & 31))
+	case 0x75:
+		return uint64(uint32(int32(x32) >> (y32 & 31)))
+	case 0x76:
+		return uint64(x32 >> (y32 & 31))
+	case 0x77:
+		return uint64(bits.RotateLeft32(x32, int(y32&31)))
+	case 0x78:
+		return uint64(bits.RotateLeft32(x32, -int(y32&31)))
+
+	// i64 arithmetic
+	case 0x7c:
+		return a + b
+	case 0x7d:
+		return a - b
+	case 0x7e:
+		return a * b
+	case 0x7f:
+		if b == 0 {
+			panic(wasmTrap("integer divide by zero"))
+		}
+		if int64(a) == math.MinInt64 && int64(b) == -1 {
+			panic(wasmTrap("integer overflow"))
+		}
+		return uint64(int64(a) / int64(b))
+	case 0x80:
+		if b == 0 {
+			panic(wasmTrap("integer divide by zero"))
+		}
+		return a / b
+	case 0x81:
+		if b == 0 {
+			panic(wasmTrap("integer divide by zero"))
+		}
+		if int64(b) == -1 {
+			return 0
+		}
+		return uint64(int64(a) % int64(b))
+	case 0x82:
+		if b == 0 {
+			panic(wasmTrap("integer divide by zero"))
+		}
+		return a % b
+	case 0x83:
+		return a & b
+	case 0x84:
+		return a | b
+	case 0x85:
+		return a ^ b
+	case 0x86:
+		return a << (b & 63)
+	case 0x87:
+		return uint64(int64(a) >> (b & 63))
+	case 0x88:
+		return a >> (b & 63)
+	case 0x89:
+		return bits.RotateLeft64(a, int(b&63))
+	case 0x8a:
+		return bits.RotateLeft64(a, -int(b&63))
+
+	// f32 arithmetic
+	case 0x92:
+		return f32value(fx32 + fy32)
+	case 0x93:
+		return f32value(fx32 - fy32)
+	case 0x94:
+		return f32value(fx32 * fy32)
+	case 0x95:
+		return f32value(fx32 / fy32)
+	case 0x96:
+		return f32value(float32(math.Min(float64(fx32), float64(fy32))))
+	case 0x97:
+		return f32value(float32(math.Max(float64(fx32), float64(fy32))))
+	case 0x98:
+		return a&^(1<<31) | b&(1<<31)
+
+	// f64 arithmetic
+	case 0xa0:
+		return math.Float64bits(fx64 + fy64)
+	case 0xa1:
+		return math.Float64bits(fx64 - fy64)
+	case 0xa2:
+		return math.Float64bits(fx64 * fy64)
+	case 0xa3:
+		return math.Float64bits(fx64 / fy64)
+	case 0xa4:
+		return math.Float64bits(math.Min(fx64, fy64))
+	case 0xa5:
+		return math.Float64bits(math.Max(fx64, fy64))
+	default: // 0xa6, f64.copysign
+		return a&^(1<<63) | b&(1<<63)
+	}
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+// testWasmFunc is a function of a hand-assembled test module
+type testWasmFunc struct {
+	params, results []byte
+	locals          []byte // One value type per local
+	body            []byte // Instructions, including the final end
+	export          string
+}
+
+func uleb(n int) []byte {
+	var out []byte
+	for {
+		b := byte(n & 0x7f)
+		n >>= 7
+		if n == 0 {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func wasmSection(id byte, items ...[]byte) []byte {
+	content := uleb(len(items))
+	for _, item := range items {
+		content = append(content, item...)
+	}
+	return append(append([]byte{id}, uleb(len(content))...), content...)
+}
+
+func wasmVec(b []byte) []byte {
+	return append(uleb(len(b)), b...)
+}
+
+// buildWasm assembles a module: function i has type i and sits at table slot i, memory (with
+// pages > 0) is exported as "memory" and data is copied to address 0
+func buildWasm(funcs []testWasmFunc, pages int, data string) []byte {
+	module := []byte("\x00asm\x01\x00\x00\x00")
+	var types, indexes, exports, code [][]byte
+	var refs []byte
+	for i, f := range funcs {
+		types = append(types, append(append([]byte{0x60}, wasmVec(f.params)...), wasmVec(f.results)...))
+		indexes = append(indexes, uleb(i))
+		refs = append(refs, uleb(i)...)
+		if f.export != "" {
+			exports = append(exports, append(append(wasmVec([]byte(f.export)), wasmExportFunc), uleb(i)...))
+		}
+		body := uleb(len(f.locals))
+		for _, t := range f.locals {
+			body = append(body, 1, t)
+		}
+		code = append(code, wasmVec(append(body, f.body...)))
+	}
+	module = append(module, wasmSection(1, types...)...)
+	module = append(module, wasmSection(3, indexes...)...)
+	module = append(module, wasmSection(4, append([]byte{wasmFuncRef, 0}, uleb(len(funcs))...))...)
+	if pages > 0 {
+		module = append(module, wasmSection(5, append([]byte{0}, uleb(pages)...))...)
+		exports = append(exports, append(wasmVec([]byte("memory")), wasmExportMemory, 0))
+	}
+	module = append(module, wasmSection(7, exports...)...)
+	elem := append([]byte{0, 0x41, 0, 0x0b}, uleb(len(funcs))...)
+	module = append(module, wasmSection(9, append(elem, refs...))...)
+	module = append(module, wasmSection(10, code...)...)
+	if data != "" {
+		module = append(module, wasmSection(11, append([]byte{0, 0x41, 0, 0x0b}, wasmVec([]byte(data))...))...)
+	}
+	return module
+}
+
+var (
+	i32 = []byte{wasmI32}
+	i64 = []byte{wasmI64}
+)
+
+// testWasmFuncs exercises control flow, calls, tables and memory
+var testWasmFuncs = []testWasmFunc{
+	// 0: br_table over nested blocks, 0 -> 10, 1 -> 20, anything else -> 30
+	{params: i32, results: i32, export: "classify", body: []byte{
+		0x02, 0x40, 0x02, 0x40, 0x02, 0x40, 0x20, 0x00, 0x0e, 0x02, 0x00, 0x01, 0x02, 0x0b,
+		0x41, 0x0a, 0x0f, 0x0b, 0x41, 0x14, 0x0f, 0x0b, 0x41, 0x1e, 0x0b}},
+	// 1: call_indirect of table slot 0
+	{params: i32, results: i32, export: "dispatch", body: []byte{0x20, 0x00, 0x41, 0x00, 0x11, 0x00, 0x00, 0x0b}},
+	// 2: if/else with a result
+	{params: i32, results: i32, export: "abs", body: []byte{
+		0x20, 0x00, 0x41, 0x00, 0x48, 0x04, 0x7f, 0x41, 0x00, 0x20, 0x00, 0x6b, 0x05, 0x20, 0x00, 0x0b, 0x0b}},
+	// 3: a block taking params, typed by index (this function's type)
+	{params: []byte{wasmI32, wasmI32}, results: i32, export: "add", body: []byte{0x20, 0x00, 0x20, 0x01, 0x02, 0x03, 0x6a, 0x0b, 0x0b}},
+	// 4: recursion
+	{params: i64, results: i64, export: "fac", body: []byte{
+		0x20, 0x00, 0x50, 0x04, 0x7e, 0x42, 0x01, 0x05, 0x20, 0x00, 0x20, 0x00, 0x42, 0x01, 0x7d, 0x10, 0x04, 0x7e, 0x0b, 0x0b}},
+	// 5: unbounded recursion
+	{export: "forever", body: []byte{0x10, 0x05, 0x0b}},
+	// 6: memory.grow
+	{params: i32, results: i32, export: "grow", body: []byte{0x20, 0x00, 0x40, 0x00, 0x0b}},
+	// 7: memory.fill 4 bytes of 7 at 0, memory.copy them to 8, load them
+	{results: i32, export: "fillcopy", body: []byte{
+		0x41, 0x00, 0x41, 0x07, 0x41, 0x04, 0xfc, 0x0b, 0x00,
+		0x41, 0x08, 0x41, 0x00, 0x41, 0x04, 0xfc, 0x0a, 0x00, 0x00,
+		0x41, 0x08, 0x28, 0x02, 0x00, 0x0b}},
+	// 8: load a byte at any address
+	{params: i32, results: i32, export: "peek", body: []byte{0x20, 0x00, 0x2d, 0x00, 0x00, 0x0b}},
+	// 9: an infinite loop
+	{export: "spin", body: []byte{0x03, 0x40, 0x0c, 0x00, 0x0b, 0x0b}},
+	// 10: i32.div_s
+	{params: []byte{wasmI32, wasmI32}, results: i32, export: "div", body: []byte{0x20, 0x00, 0x20, 0x01, 0x6d, 0x0b}},
+}
+
+func TestWasmInterpreter(t *testing.T) {
+	m, err := decodeWasm(buildWasm(testWasmFuncs, 1, "hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst, err := m.instantiate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := func(name string, args ...uint64) (uint64, error) {
+		t.Helper()
+		f, _, ok := m.export(name)
+		if !ok {
+			t.Fatalf("no export %s", name)
+		}
+		out, err := inst.call(f, args...)
+		if err != nil || len(out) == 0 {
+			return 0, err
+		}
+		return out[0], nil
+	}
+	minus5 := uint64(uint32(0xfffffffb))
+	for _, c := range []struct {
+		name string
+		args []uint64
+		want uint64
+	}{
+		{"classify", []uint64{0}, 10},
+		{"classify", []uint64{1}, 20},
+		{"classify", []uint64{7}, 30},
+		{"dispatch", []uint64{1}, 20},
+		{"abs", []uint64{minus5}, 5},
+		{"abs", []uint64{3}, 3},
+		{"add", []uint64{2, 3}, 5},
+		{"fac", []uint64{10}, 3628800},
+		{"peek", []uint64{1}, 'i'},
+		{"fillcopy", nil, 0x07070707},
+		{"grow", []uint64{1}, 1},
+		{"grow", []uint64{wasmMaxPages}, math.MaxUint32},
+		{"div", []uint64{minus5, 2}, uint64(uint32(0xfffffffe))},
+	} {
+		if got, err := call(c.name, c.args...); err != nil || got != c.want {
+			t.Errorf("%s%v = %d (%v), want %d", c.name, c.args, got, err, c.want)
+		}
+	}
+
+	// Traps leave the instance usable
+	for _, c := range []struct {
+		name string
+		args []uint64
+		trap string
+	}{
+		{"forever", nil, "call stack exhausted"},
+		{"peek", []uint64{2 * wasmPageSize}, "out of bounds memory access"},
+		{"div", []uint64{1, 0}, "integer divide by zero"},
+		{"div", []uint64{1 << 31, math.MaxUint32}, "integer overflow"},
+	} {
+		_, err := call(c.name, c.args...)
+		var trap wasmTrap
+		if !errors.As(err, &trap) || string(trap) != c.trap {
+			t.Errorf("%s%v: %v, want trap %q", c.name, c.args, err, c.trap)
+		}
+	}
+	if got, err := call("abs", 4); err != nil || got != 4 {
+		t.Errorf("after traps: %d, %v", got, err)
+	}
+}
+
+func TestWasmStepLimit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs 100M instructions")
+	}
+	m, err := decodeWasm(buildWasm(testWasmFuncs, 1, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst, _ := m.instantiate()
+	f, _, _ := m.export("spin")
+	if _, err := inst.call(f); err == nil || !strings.Contains(err.Error(), "steps") {
+		t.Errorf("spin = %v", err)
+	}
+}
+
+func TestWasmLocalsBudget(t *testing.T) {
+	// deep(n) recurses n times through frames of 40000 locals, each within wasmMaxLocals
+	deep := testWasmFunc{
+		params: []byte{0x7f},
+		locals: []byte(strings.Repeat("\x7f", 40000)),
+		body:   []byte{0x20, 0, 0x04, 0x40, 0x20, 0, 0x41, 1, 0x6b, 0x10, 0, 0x0b, 0x0b},
+		export: "deep",
+	}
+	m, err := decodeWasm(buildWasm([]testWasmFunc{deep}, 1, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst, _ := m.instantiate()
+	f, _, _ := m.export("deep")
+	if _, err := inst.call(f, 10); err != nil {
+		t.Errorf("deep(10) = %v", err)
+	}
+	// A thousand such frames would hold 40M locals, the budget of all frames stops it long before
+	var trap wasmTrap
+	if _, err := inst.call(f, wasmMaxDepth-1); !errors.As(err, &trap) || trap != "call stack exhausted" {
+		t.Errorf("deep(%d) = %v, want the call stack exhausted", wasmMaxDepth-1, err)
+	}
+	if _, err := inst.call(f, 10); err != nil {
+		t.Errorf("deep(10) after the trap = %v", err)
+	}
+}
+
+func TestDecodeWasmRejects(t *testing.T) {
+	valid := buildWasm(testWasmFuncs[:1], 1, "")
+	withImport := append([]byte("\x00asm\x01\x00\x00\x00"),
+		wasmSection(2, append(append(wasmVec([]byte("wasi")), wasmVec([]byte("fd_write"))...), 0, 0))...)
+	for name, data := range map[string][]byte{
+		"not wasm":  []byte("hello world"),
+		"truncated": valid[:len(valid)-3],
+		"import":    withImport,
+		"bad op":    buildWasm([]testWasmFunc{{body: []byte{0xfe, 0x0b}}}, 0, ""),
+		"bad local": buildWasm([]testWasmFunc{{body: []byte{0x20, 0x03, 0x1a, 0x0b}}}, 0, ""),
+	} {
+		if _, err := decodeWasm(data); err == nil {
+			t.Errorf("%s: decoded", name)
+		}
+	}
+	if _, err := decodeWasm(withImport); err == nil || !strings.Contains(err.Error(), "wasi.fd_write") {
+		t.Errorf("import error = %v", err)
+	}
+}
+
+func TestWasmNumerics(t *testing.T) {
+	f32 := func(f float32) uint64 { return f32value(f) }
+	f64 := math.Float64bits
+	trap := func(fn func() uint64) (msg string) {
+		defer func() {
+			if r := recover(); r != nil {
+				msg = string(r.(wasmTrap))
+			}
+		}()
+		fn()
+		return ""
+	}
+
+	for _, c := range []struct {
+		op      uint16
+		a, b    uint64
+		want    uint64
+		comment string
+	}{
+		{0x75, 0x80000000, 33, 0xc0000000, "shr_s masks the count"},
+		{0x77, 0x80000001, 1, 3, "rotl"},
+		{0x8a, 1, 1, 1 << 63, "i64.rotr"},
+		{0x6f, 0x80000000, math.MaxUint32, 0, "rem_s of MinInt32 by -1"},
+		{0x96, f32(0), f32(float32(math.Copysign(0, -1))), f32(float32(math.Copysign(0, -1))), "min(0, -0)"},
+		{0xa5, f64(1), f64(math.NaN()), f64(math.NaN()), "max with NaN"},
+		{0xa6, f64(2), f64(-1), f64(-2), "copysign"},
+		{0x49, math.MaxUint32, 1, 0, "lt_u"},
+		{0x48, math.MaxUint32, 1, 1, "lt_s"},
+	} {
+		got := wasmBinary(c.op, c.a, c.b)
+		if got != c.want && !(math.IsNaN(f64bits(got)) && math.IsNaN(f64bits(c.want))) {
+			t.Errorf("%s: %#x, want %#x", c.comment, got, c.want)
+		}
+	}
+
+	for _, c := range []struct {
+		op      uint16
+		a       uint64
+		want    uint64
+		comment string
+	}{
+		{0x67, 1, 31, "clz"},
+		{0x90, f32(2.5), f32(2), "nearest rounds to even"},
+		{0xaa, f64(-3.9), uint64(uint32(0xfffffffd)), "trunc_f64_s"},
+		{0xab, f64(-0.5), 0, "trunc_f64_u of a small negative"},
+		{0xb1, f64(1 << 63), 1 << 63, "i64.trunc_f64_u above MaxInt64"},
+		{0x102, f64(1e10), math.MaxInt32, "saturating trunc clamps"},
+		{0x103, f64(-1), 0, "saturating unsigned trunc clamps at 0"},
+		{0x106, f64(math.NaN()), 0, "saturating trunc of NaN"},
+		{0x107, f64(1e30), math.MaxUint64, "saturating u64"},
+		{0xc0, 0x80, 0xffffff80, "extend8_s"},
+		{0xc4, 0x80000000, 0xffffffff80000000, "i64.extend32_s"},
+		{0xba, math.MaxUint64, f64(1 << 64), "convert_i64_u"},
+	} {
+		if got := wasmUnary(c.op, c.a); got != c.want {
+			t.Errorf("%s: %#x, want %#x", c.comment, got, c.want)
+		}
+	}
+
+	if msg := trap(func() uint64 { return wasmUnary(0xaa, f64(3e9)) }); msg != "integer overflow" {
+		t.Errorf("trunc of 3e9 = %q", msg)
+	}
+	if msg := trap(func() uint64 { return wasmUnary(0xa8, f32(float32(math.NaN()))) }); msg != "invalid conversion to integer" {
+		t.Errorf("trunc of NaN = %q", msg)
+	}
+	if msg := trap(func() uint64 { return wasmUnary(0xab, f64(-1)) }); msg != "integer overflow" {
+		t.Errorf("unsigned trunc of -1 = %q", msg)
+	}
+}
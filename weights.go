@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// scoreWeights is the default weighting from --weights, nil = use combined as recorded
+var scoreWeights map[string]float64
+
+// parseWeights reads "faithfulness:2,accuracy:1" into a custom score -> weight map
+// An empty value means no reweighting
+func parseWeights(value string) (map[string]float64, error) {
+	weights := make(map[string]float64)
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, raw, ok := strings.Cut(pair, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid weight %q: expected score:weight", pair)
+		}
+		if name == "combined" {
+			return nil, fmt.Errorf("invalid weight %q: combined is the weighted result, not an input", pair)
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil || weight < 0 || math.IsInf(weight, 0) || math.IsNaN(weight) {
+			return nil, fmt.Errorf("invalid weight %q: must be a non-negative number", pair)
+		}
+		weights[name] = weight
+	}
+	if len(weights) == 0 {
+		return nil, nil
+	}
+	return weights, nil
+}
+
+// formatWeights is the inverse of parseWeights, sorted by score name
+func formatWeights(weights map[string]float64) string {
+	names := make([]string, 0, len(weights))
+	for name := range weights {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + ":" + strconv.FormatFloat(weights[name], 'g', -1, 64)
+	}
+	return strings.Join(pairs, ",")
+}
+
+// requestWeights returns the weights for a request: the weights URL parameter when present
+// ("off" disables the --weights default), otherwise --weights or the dashboard's weights
+func requestWeights(r *http.Request) (map[string]float64, error) {
+	if !r.URL.Query().Has("weights") {
+		return weightsFor(r.Context()), nil
+	}
+	value := r.URL.Query().Get("weights")
+	if value == "off" {
+		return nil, nil
+	}
+	return parseWeights(value)
+}
+
+// reweight returns copies of results whose combined score is the weighted mean of their
+// custom scores. Scores without a weight count as 0; a result with none of the weighted
+// scores (or only zero weights) keeps its recorded combined score
+func reweight(results []EvalResult, weights map[string]float64) []EvalResult {
+	if len(weights) == 0 {
+		return results
+	}
+	out := make([]EvalResult, len(results))
+	for i, result := range results {
+		out[i] = result
+		sum, total := 0.0, 0.0
+		for name, weight := range weights {
+			if score, ok := result.Scores.Custom[name]; ok {
+				sum += weight * score
+				total += weight
+			}
+		}
+		if total == 0 {
+			continue
+		}
+		out[i].Scores.Combined = sum / total
+		// The recomputed score is a view of the data, not an edit
+		out[i] = restampIfIntact(result, out[i])
+	}
+	return out
+}
+
+// reweightRequest applies requestWeights to results, answering 400 for invalid weights
+// Returns false when the response has already been written
+func reweightRequest(w http.ResponseWriter, r *http.Request, results []EvalResult) ([]EvalResult, bool) {
+	weights, err := requestWeights(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return reweight(results, weights), true
+}
+
+// RecipePart is one custom score's share of a combined score
+type RecipePart struct {
+	Name         string
+	Score        float64
+	Weight       float64 // Normalized so a recipe's weights sum to 1; 0 = not part of the formula
+	Contribution float64 // Weight × Score
+	Lost         float64 // Weight × (1 - Score), what the score cost the combined
+	Color        string  // Series class, see seriesColor
+}
+
+// ScoreRecipe explains a result's combined score as the weighted sum of its custom scores
+type ScoreRecipe struct {
+	Weighted  bool // Combined was recomputed with score weights; otherwise an equal-weight mean is assumed
+	Parts     []RecipePart
+	Explained float64 // Sum of contributions
+	Combined  float64
+	Gap       float64 // Combined - Explained, nonzero when the logged combined isn't a mean of the parts
+}
+
+// scoreRecipe breaks down the combined score of result (already reweighted with weights, see
+// reweight) into contributions, sorted by name
+func scoreRecipe(result EvalResult, weights map[string]float64) ScoreRecipe {
+	recipe := ScoreRecipe{Combined: result.Scores.Combined}
+	names := make([]string, 0, len(result.Scores.Custom))
+	total := 0.0
+	for name := range result.Scores.Custom {
+		names = append(names, name)
+		total += weights[name]
+	}
+	sort.Strings(names)
+	recipe.Weighted = total > 0 // Same rule as reweight: no weighted score present, combined as recorded
+
+	for i, name := range names {
+		part := RecipePart{Name: name, Score: result.Scores.Custom[name], Color: seriesColor(i)}
+		if recipe.Weighted {
+			part.Weight = weights[name] / total
+		} else {
+			part.Weight = 1 / float64(len(names))
+		}
+		part.Contribution = part.Weight * part.Score
+		part.Lost = part.Weight * (1 - part.Score)
+		recipe.Explained += part.Contribution
+		recipe.Parts = append(recipe.Parts, part)
+	}
+	recipe.Gap = recipe.Combined - recipe.Explained
+	if math.Abs(recipe.Gap) < 0.0005 {
+		recipe.Gap = 0 // Rounding in the logged score
+	}
+	return recipe
+}
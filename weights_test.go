@@ -0,0 +1,96 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseWeights(t *testing.T) {
+	weights, err := parseWeights(" faithfulness:2, accuracy:1 ,")
+	if err != nil || weights["faithfulness"] != 2 || weights["accuracy"] != 1 || len(weights) != 2 {
+		t.Fatalf("parseWeights = %v, %v", weights, err)
+	}
+	if got := formatWeights(weights); got != "accuracy:1,faithfulness:2" {
+		t.Errorf("formatWeights = %q", got)
+	}
+	if weights, err := parseWeights(""); weights != nil || err != nil {
+		t.Errorf("empty value = %v, %v, want no weights", weights, err)
+	}
+	for _, value := range []string{"accuracy", "accuracy:x", "accuracy:-1", ":1", "combined:1", "accuracy:NaN"} {
+		if _, err := parseWeights(value); err == nil {
+			t.Errorf("parseWeights(%q): expected error", value)
+		}
+	}
+}
+
+func TestReweight(t *testing.T) {
+	results := []EvalResult{
+		{TestID: "t1", Scores: ScoreBreakdown{Combined: 0.5, Custom: map[string]float64{"accuracy": 1.0, "faithfulness": 0.4, "fluency": 0.9}}},
+		{TestID: "t2", Scores: ScoreBreakdown{Combined: 0.7, Custom: map[string]float64{"fluency": 0.2}}}, // No weighted score
+		{TestID: "t3", Scores: ScoreBreakdown{Combined: 0.6, Custom: map[string]float64{"faithfulness": 0.3}}},
+	}
+	stampIntegrity(results)
+	results[2].Scores.Combined = 0.9 // Edited after ingest
+
+	got := reweight(results, map[string]float64{"faithfulness": 2, "accuracy": 1})
+	if want := (2*0.4 + 1.0) / 3; math.Abs(got[0].Scores.Combined-want) > 1e-9 {
+		t.Errorf("t1 combined = %v, want %v", got[0].Scores.Combined, want)
+	}
+	if got[1].Scores.Combined != 0.7 {
+		t.Errorf("t2 combined = %v, want recorded 0.7", got[1].Scores.Combined)
+	}
+	if got[2].Scores.Combined != 0.3 {
+		t.Errorf("t3 combined = %v, want 0.3", got[2].Scores.Combined)
+	}
+	if isTampered(got[0]) || !isTampered(got[2]) {
+		t.Error("reweighting must not flag intact results or clear the tampered flag")
+	}
+	if results[0].Scores.Combined != 0.5 {
+		t.Error("reweight modified its input")
+	}
+}
+
+func TestRequestWeights(t *testing.T) {
+	scoreWeights = map[string]float64{"accuracy": 1}
+	defer func() { scoreWeights = nil }()
+
+	for query, want := range map[string]int{
+		"/":                       1, // --weights default
+		"/?weights=off":           0,
+		"/?weights=fluency:2,a:1": 2,
+	} {
+		weights, err := requestWeights(httptest.NewRequest(http.MethodGet, query, nil))
+		if err != nil || len(weights) != want {
+			t.Errorf("requestWeights(%s) = %v, %v", query, weights, err)
+		}
+	}
+
+	useStore(t, NewMemoryStore([]Source{&fileSource{path: "evals.jsonl"}}))
+	rec := httptest.NewRecorder()
+	leaderboardAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/api/leaderboard?weights=accuracy:-1", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid weights: status %d, want 400", rec.Code)
+	}
+}
+
+func TestScoreRecipe(t *testing.T) {
+	result := EvalResult{Scores: ScoreBreakdown{Combined: 0.4, Custom: map[string]float64{"accuracy": 0.8, "fluency": 1}}}
+
+	// No weights: an equal-weight mean is assumed and the logged combined doesn't match it
+	recipe := scoreRecipe(result, nil)
+	if recipe.Weighted || len(recipe.Parts) != 2 || recipe.Parts[0].Name != "accuracy" || recipe.Parts[0].Weight != 0.5 {
+		t.Fatalf("recipe = %+v", recipe)
+	}
+	if math.Abs(recipe.Explained-0.9) > 1e-9 || math.Abs(recipe.Gap+0.5) > 1e-9 || math.Abs(recipe.Parts[0].Lost-0.1) > 1e-9 {
+		t.Errorf("explained %v, gap %v, lost %v", recipe.Explained, recipe.Gap, recipe.Parts[0].Lost)
+	}
+
+	// Weighted: contributions add up to the recomputed combined, unweighted scores get 0
+	weights := map[string]float64{"accuracy": 3, "relevance": 1}
+	recipe = scoreRecipe(reweight([]EvalResult{result}, weights)[0], weights)
+	if !recipe.Weighted || recipe.Parts[0].Weight != 1 || recipe.Parts[1].Weight != 0 || recipe.Gap != 0 || math.Abs(recipe.Combined-0.8) > 1e-9 {
+		t.Errorf("weighted recipe = %+v", recipe)
+	}
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// wsGUID is the fixed key suffix of the WebSocket handshake (RFC 6455 section 1.3)
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocket opcodes
+const (
+	wsText  = 0x1
+	wsClose = 0x8
+	wsPing  = 0x9
+	wsPong  = 0xA
+)
+
+const (
+	wsPingInterval = 30 * time.Second // Keepalive, also detects clients that vanished
+	wsWriteTimeout = 10 * time.Second
+	wsClientQueue  = 64 // Batches a client may fall behind before it is disconnected
+)
+
+// wsPollInterval is how often /api/ws checks the sources for new results
+var wsPollInterval = 2 * time.Second
+
+// wsOrigins are the browser origins besides the dashboard's own allowed to open /api/ws
+// (--ws-origin), e.g. https://grafana.example.com
+var wsOrigins stringList
+
+// wsOriginAllowed reports whether a browser page at the request's Origin may open the stream
+// Browsers can open WebSockets to any site, so without this check every page a viewer visits
+// could read the results; clients outside a browser send no Origin and are allowed
+func wsOriginAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range wsOrigins {
+		if strings.EqualFold(strings.TrimRight(allowed, "/"), origin) {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host != "" && strings.EqualFold(u.Host, r.Host)
+}
+
+// wsConn is a minimal server side WebSocket connection (RFC 6455)
+// Only what /api/ws needs: unfragmented text messages out, control frames in; data frames
+// from the client are read and ignored
+type wsConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	mu   sync.Mutex // Serializes frames, pongs are written from the read loop
+}
+
+// upgradeWebSocket checks the handshake and the Origin (see wsOriginAllowed) and takes over the
+// connection
+// On failure an HTTP error has already been written
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	headerHas := func(name, token string) bool {
+		for _, value := range strings.Split(r.Header.Get(name), ",") {
+			if strings.EqualFold(strings.TrimSpace(value), token) {
+				return true
+			}
+		}
+		return false
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	switch {
+	case r.Method != http.MethodGet:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return nil, errors.New("websocket: method not GET")
+	case !headerHas("Connection", "upgrade") || !headerHas("Upgrade", "websocket") || key == "":
+		w.Header().Set("Upgrade", "websocket")
+		http.Error(w, "Expected a WebSocket upgrade request", http.StatusUpgradeRequired)
+		return nil, errors.New("websocket: not an upgrade request")
+	case r.Header.Get("Sec-WebSocket-Version") != "13":
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "Unsupported WebSocket version", http.StatusUpgradeRequired)
+		return nil, errors.New("websocket: unsupported version")
+	case !wsOriginAllowed(r):
+		http.Error(w, "Origin not allowed", http.StatusForbidden)
+		return nil, fmt.Errorf("websocket: origin %q not allowed", r.Header.Get("Origin"))
+	}
+
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		http.Error(w, "WebSocket not supported", http.StatusInternalServerError)
+		return nil, fmt.Errorf("websocket: %w", err)
+	}
+	sum := sha1.Sum([]byte(key + wsGUID))
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n",
+		base64.StdEncoding.EncodeToString(sum[:]))
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &wsConn{conn: conn, r: rw.Reader}, nil
+}
+
+// writeFrame sends one unfragmented frame; servers don't mask
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	header := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if _, err := c.conn.Write(append(header, payload...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// WriteJSON sends v as a text message
+func (c *wsConn) WriteJSON(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.writeFrame(wsText, data)
+}
+
+// readFrame reads the next frame from the client and returns its opcode and (unmasked) payload
+// Data payloads are discarded unread, only control frames (at most 125 bytes) are returned
+func (c *wsConn) readFrame() (byte, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.r, head[:]); err != nil {
+		return 0, nil, err
+	}
+	opcode, masked := head[0]&0x0F, head[1]&0x80 != 0
+	if !masked {
+		return 0, nil, errors.New("websocket: client frame not masked")
+	}
+	length := uint64(head[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	var mask [4]byte
+	if _, err := io.ReadFull(c.r, mask[:]); err != nil {
+		return 0, nil, err
+	}
+
+	if opcode < wsClose {
+		_, err := io.CopyN(io.Discard, c.r, int64(length))
+		return opcode, nil, err
+	}
+	if length > 125 {
+		return 0, nil, errors.New("websocket: control frame too long")
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return opcode, payload, nil
+}
+
+// Close sends a normal closure frame and closes the connection
+func (c *wsConn) Close() error {
+	c.writeFrame(wsClose, binary.BigEndian.AppendUint16(nil, 1000))
+	return c.conn.Close()
+}
+
+// readLoop answers pings and returns once the client closes or the connection fails
+func (c *wsConn) readLoop() {
+	for {
+		opcode, payload, err := c.readFrame()
+		if err != nil {
+			return
+		}
+		switch opcode {
+		case wsPing:
+			if c.writeFrame(wsPong, payload) != nil {
+				return
+			}
+		case wsClose:
+			return
+		}
+	}
+}
+
+// wsHub shares one Store.Watch among the /api/ws clients of a dashboard, so every open
+// stream doesn't poll the sources on its own; it runs while the dashboard has clients
+type wsHub struct {
+	clients map[chan []EvalResult]bool
+	stop    context.CancelFunc
+}
+
+var (
+	wsHubsMu sync.Mutex
+	wsHubs   = make(map[*Dashboard]*wsHub) // By the dashboard serving the clients, nil for a single one
+)
+
+// subscribeWS returns the batches of new results of the dashboard serving ctx until unsubscribe
+// is called, closing the channel early when the client falls wsClientQueue batches behind
+// The first batch of a fresh watch holds every result present, like Store.Watch
+func subscribeWS(ctx context.Context) (batches <-chan []EvalResult, unsubscribe func()) {
+	d := dashboardFrom(ctx)
+	wsHubsMu.Lock()
+	defer wsHubsMu.Unlock()
+	hub := wsHubs[d]
+	if hub == nil {
+		watchCtx, stop := context.WithCancel(context.Background())
+		if d != nil {
+			watchCtx = context.WithValue(watchCtx, dashboardKey{}, d)
+		}
+		hub = &wsHub{clients: make(map[chan []EvalResult]bool), stop: stop}
+		wsHubs[d] = hub
+		go hub.run(store.Watch(watchCtx, wsPollInterval))
+	}
+	ch := make(chan []EvalResult, wsClientQueue)
+	hub.clients[ch] = true
+	return ch, func() {
+		wsHubsMu.Lock()
+		defer wsHubsMu.Unlock()
+		delete(hub.clients, ch)
+		if len(hub.clients) == 0 && wsHubs[d] == hub {
+			hub.stop()
+			delete(wsHubs, d)
+		}
+	}
+}
+
+// run hands every batch of the watch to the clients until the watch stops
+func (h *wsHub) run(updates <-chan []EvalResult) {
+	for batch := range updates {
+		wsHubsMu.Lock()
+		for ch := range h.clients {
+			select {
+			case ch <- batch:
+			default:
+				delete(h.clients, ch) // Too far behind, the handler disconnects it
+				close(ch)
+			}
+		}
+		wsHubsMu.Unlock()
+	}
+}
+
+// wsHandler streams newly ingested results as JSON messages, one EvalResult per message
+// Results already present when the client connects are skipped, unless ts is given: then those
+// after ts are sent first, so a client can reconnect without missing anything
+// Filters (model, run_id, test_id, source) work as on the dashboard
+func wsHandler(w http.ResponseWriter, r *http.Request) {
+	q := dashboardFilter(r)
+	since := r.URL.Query().Get("ts")
+	// Subscribed before reading what exists, so nothing stored in between is missed
+	batches, unsubscribe := subscribeWS(r.Context())
+	defer unsubscribe()
+	existing, err := store.Query(r.Context(), q)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading data: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	go func() {
+		conn.readLoop()
+		cancel()
+	}()
+
+	// The first batch can repeat existing results: a fresh watch replays everything present,
+	// and results stored while existing was read come again too
+	seen := make(map[string]bool, len(existing))
+	for _, result := range existing {
+		seen[resultKey(result)] = true
+		if since != "" && result.Timestamp > since {
+			if conn.WriteJSON(result) != nil {
+				return
+			}
+		}
+	}
+	existing = nil
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case batch, ok := <-batches:
+			if !ok {
+				log.Printf("WebSocket client %s: too far behind, disconnected", r.RemoteAddr)
+				return
+			}
+			for _, result := range batch {
+				if seen[resultKey(result)] || !q.Match(result) {
+					continue
+				}
+				if err := conn.WriteJSON(result); err != nil {
+					log.Printf("WebSocket client %s: %v", r.RemoteAddr, err)
+					return
+				}
+			}
+			seen = nil
+		case <-ping.C:
+			if conn.writeFrame(wsPing, nil) != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// dialWS performs a client handshake against server and returns the connection
+func dialWS(t *testing.T, server *httptest.Server, path string) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	io.WriteString(conn, "GET "+path+" HTTP/1.1\r\nHost: goevals\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n"+
+		"Sec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n")
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Accept value from the example in RFC 6455 section 1.3
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("handshake = %d %v", resp.StatusCode, resp.Header)
+	}
+	return conn, r
+}
+
+// readWSFrame reads one unmasked server frame
+func readWSFrame(t *testing.T, r *bufio.Reader) (byte, []byte) {
+	t.Helper()
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		t.Fatal(err)
+	}
+	length := int(head[1] & 0x7F)
+	if length == 126 {
+		var ext [2]byte
+		io.ReadFull(r, ext[:])
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		t.Fatal(err)
+	}
+	return head[0] & 0x0F, payload
+}
+
+func TestWSHandlerStreamsNewResults(t *testing.T) {
+	path := withStore(t, `{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"q1","scores":{"combined":0.5}}
+{"timestamp":"2025-01-01T10:01:00Z","model":"b","test_id":"q1","scores":{"combined":0.7}}`)
+	defer func(interval time.Duration) { wsPollInterval = interval }(wsPollInterval)
+	wsPollInterval = 20 * time.Millisecond
+	server := httptest.NewServer(http.HandlerFunc(wsHandler))
+	defer server.Close()
+
+	// Backlog after ts, then only new results of model a
+	conn, r := dialWS(t, server, "/api/ws?model=a&ts=2025-01-01T09:00:00Z")
+	_, payload := readWSFrame(t, r)
+	var result EvalResult
+	if json.Unmarshal(payload, &result); result.Timestamp != "2025-01-01T10:00:00Z" {
+		t.Fatalf("backlog message = %s", payload)
+	}
+
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	f.WriteString(`{"timestamp":"2025-01-01T10:02:00Z","model":"b","test_id":"q2","scores":{"combined":0.9}}
+{"timestamp":"2025-01-01T10:03:00Z","model":"a","test_id":"q2","scores":{"combined":0.8}}
+`)
+	f.Close()
+	opcode, payload := readWSFrame(t, r)
+	if json.Unmarshal(payload, &result); opcode != wsText || result.Timestamp != "2025-01-01T10:03:00Z" || result.Model != "a" {
+		t.Fatalf("live message = %d %s", opcode, payload)
+	}
+
+	// Masked ping with payload "hi" gets a pong, masked close gets a close
+	mask := []byte{1, 2, 3, 4}
+	conn.Write([]byte{0x80 | wsPing, 0x80 | 2, 1, 2, 3, 4, 'h' ^ mask[0], 'i' ^ mask[1]})
+	if opcode, payload := readWSFrame(t, r); opcode != wsPong || string(payload) != "hi" {
+		t.Errorf("ping answer = %d %q", opcode, payload)
+	}
+	conn.Write([]byte{0x80 | wsClose, 0x80, 1, 2, 3, 4})
+	if opcode, _ := readWSFrame(t, r); opcode != wsClose {
+		t.Errorf("close answer = %d", opcode)
+	}
+}
+
+// watchCounter counts the watches started on a store
+type watchCounter struct {
+	Store
+	watches atomic.Int32
+}
+
+func (s *watchCounter) Watch(ctx context.Context, interval time.Duration) <-chan []EvalResult {
+	s.watches.Add(1)
+	return s.Store.Watch(ctx, interval)
+}
+
+func TestWSHandlerSharesOneWatch(t *testing.T) {
+	path := withStore(t, `{"timestamp":"2025-01-01T10:00:00Z","model":"a","test_id":"q1","scores":{"combined":0.5}}`)
+	counter := &watchCounter{Store: store}
+	useStore(t, counter)
+	defer func(interval time.Duration) { wsPollInterval = interval }(wsPollInterval)
+	wsPollInterval = 20 * time.Millisecond
+	server := httptest.NewServer(http.HandlerFunc(wsHandler))
+	defer server.Close()
+
+	var conns []net.Conn
+	var readers []*bufio.Reader
+	for range 3 {
+		conn, r := dialWS(t, server, "/api/ws")
+		conns, readers = append(conns, conn), append(readers, r)
+	}
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	f.WriteString(`{"timestamp":"2025-01-01T10:01:00Z","model":"a","test_id":"q2","scores":{"combined":0.8}}
+`)
+	f.Close()
+	for i, r := range readers {
+		var result EvalResult
+		if _, payload := readWSFrame(t, r); json.Unmarshal(payload, &result) != nil || result.TestID != "q2" {
+			t.Errorf("client %d got %s, want only the new result", i, payload)
+		}
+	}
+	if n := counter.watches.Load(); n != 1 {
+		t.Errorf("%d watches for 3 clients", n)
+	}
+
+	// The watch stops with the last client
+	for _, conn := range conns {
+		conn.Write([]byte{0x80 | wsClose, 0x80, 1, 2, 3, 4})
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		wsHubsMu.Lock()
+		n := len(wsHubs)
+		wsHubsMu.Unlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("watch still running without clients")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWSHandlerRejectsPlainRequests(t *testing.T) {
+	useStore(t, NewMemoryStore(nil))
+	rec := httptest.NewRecorder()
+	wsHandler(rec, httptest.NewRequest(http.MethodGet, "/api/ws", nil))
+	if rec.Code != http.StatusUpgradeRequired {
+		t.Errorf("plain GET = %d", rec.Code)
+	}
+}
+
+func TestWSOriginAllowed(t *testing.T) {
+	defer func(origins stringList) { wsOrigins = origins }(wsOrigins)
+	wsOrigins = stringList{"https://grafana.example.com/"}
+	tests := map[string]bool{
+		"":                            true, // Not a browser
+		"http://goevals":              true,
+		"https://GOEVALS":             true,
+		"https://grafana.example.com": true,
+		"https://evil.example.com":    false,
+		"http://goevals.evil.com":     false,
+		"null":                        false,
+	}
+	for origin, want := range tests {
+		r := httptest.NewRequest(http.MethodGet, "http://goevals/api/ws", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		if got := wsOriginAllowed(r); got != want {
+			t.Errorf("Origin %q allowed = %v, want %v", origin, got, want)
+		}
+	}
+
+	useStore(t, NewMemoryStore(nil))
+	r := httptest.NewRequest(http.MethodGet, "http://goevals/api/ws", nil)
+	r.Header.Set("Connection", "Upgrade")
+	r.Header.Set("Upgrade", "websocket")
+	r.Header.Set("Sec-WebSocket-Version", "13")
+	r.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	r.Header.Set("Origin", "https://evil.example.com")
+	rec := httptest.NewRecorder()
+	wsHandler(rec, r)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("cross-site handshake = %d", rec.Code)
+	}
+}
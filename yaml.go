@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// yamlLine is one line of a YAML document, without its comment and indentation
+type yamlLine struct {
+	n      int // Line number, for errors
+	indent int
+	text   string
+}
+
+// parseYAML reads the block-style YAML subset config files need: nested mappings, sequences
+// ("- item", also of mappings) and flow sequences of scalars ([a, "b"]), with comments and
+// quoted strings. Scalars stay strings and empty values are nil, so the result marshals to the
+// JSON the same config would be written as. Like parseModelsYAML it rejects anything fancier
+// (anchors, flow mappings, multi-line strings) rather than misread it
+func parseYAML(doc string) (any, error) {
+	var lines []yamlLine
+	for n, raw := range strings.Split(doc, "\n") {
+		line := strings.TrimRight(stripYAMLComment(raw), " \r")
+		text := strings.TrimLeft(line, " ")
+		if text == "" || line == "---" {
+			continue
+		}
+		if text[0] == '\t' {
+			return nil, fmt.Errorf("line %d: indent with spaces, not tabs", n+1)
+		}
+		lines = append(lines, yamlLine{n: n + 1, indent: len(line) - len(text), text: text})
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+	p := &yamlParser{lines: lines}
+	v, err := p.node(lines[0].indent)
+	if err == nil && p.i < len(lines) {
+		err = fmt.Errorf("line %d: unexpected indentation", lines[p.i].n)
+	}
+	return v, err
+}
+
+// yamlParser reads a block of lines into nested maps, slices and strings
+type yamlParser struct {
+	lines []yamlLine
+	i     int // Next line to read
+}
+
+// isYAMLItem reports whether a line starts a sequence item
+func isYAMLItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// isYAMLKey reports whether text starts with a key, "key:" or "key: value"
+func isYAMLKey(text string) bool {
+	_, _, err := splitYAMLKey(text)
+	return err == nil
+}
+
+// node reads the sequence or mapping starting at the current line, which is at indent
+func (p *yamlParser) node(indent int) (any, error) {
+	if isYAMLItem(p.lines[p.i].text) {
+		return p.sequence(indent)
+	}
+	return p.mapping(indent)
+}
+
+// sequence reads "- item" lines at indent; an item can be a scalar, a mapping starting on the
+// item's line or a block below it
+func (p *yamlParser) sequence(indent int) (any, error) {
+	list := []any{}
+	for p.i < len(p.lines) && p.lines[p.i].indent == indent && isYAMLItem(p.lines[p.i].text) {
+		line := p.lines[p.i]
+		rest := strings.TrimLeft(line.text[1:], " ")
+		switch {
+		case rest == "":
+			p.i++
+			item, err := p.block(indent)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		case isYAMLKey(rest):
+			// "- name: x" starts a mapping whose keys line up with name
+			itemIndent := indent + len(line.text) - len(rest)
+			p.lines[p.i] = yamlLine{n: line.n, indent: itemIndent, text: rest}
+			item, err := p.mapping(itemIndent)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		default:
+			item, err := yamlValue(rest)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line.n, err)
+			}
+			list = append(list, item)
+			p.i++
+		}
+	}
+	return list, nil
+}
+
+// mapping reads "key: value" lines at indent, a key without a value takes the block below it
+func (p *yamlParser) mapping(indent int) (any, error) {
+	m := make(map[string]any)
+	for p.i < len(p.lines) && p.lines[p.i].indent == indent {
+		line := p.lines[p.i]
+		if isYAMLItem(line.text) {
+			return nil, fmt.Errorf("line %d: expected key: value, got a list item", line.n)
+		}
+		key, raw, err := splitYAMLKey(line.text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line.n, err)
+		}
+		if _, ok := m[key]; ok {
+			return nil, fmt.Errorf("line %d: duplicate key %q", line.n, key)
+		}
+		p.i++
+		if raw != "" {
+			if m[key], err = yamlValue(raw); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line.n, err)
+			}
+			continue
+		}
+		// A sequence may start at the key's own indentation
+		if p.i < len(p.lines) && p.lines[p.i].indent == indent && isYAMLItem(p.lines[p.i].text) {
+			m[key], err = p.sequence(indent)
+		} else {
+			m[key], err = p.block(indent)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// block reads the node below a line at indent, nil when the next line isn't indented deeper
+func (p *yamlParser) block(indent int) (any, error) {
+	if p.i >= len(p.lines) || p.lines[p.i].indent <= indent {
+		return nil, nil
+	}
+	return p.node(p.lines[p.i].indent)
+}
+
+// yamlValue reads a value written on the line of its key or item: a scalar or a flow sequence
+// of scalars
+func yamlValue(raw string) (any, error) {
+	if !strings.HasPrefix(raw, "[") {
+		if strings.ContainsRune("{&*|>!", rune(raw[0])) {
+			return nil, fmt.Errorf("only plain values and [lists] are supported, got %q", raw)
+		}
+		return yamlScalar(raw)
+	}
+	if !strings.HasSuffix(raw, "]") {
+		return nil, fmt.Errorf("unterminated list %q", raw)
+	}
+	list := []any{}
+	inner := strings.TrimSpace(raw[1 : len(raw)-1])
+	if inner == "" {
+		return list, nil
+	}
+	var quote byte
+	start := 0
+	for i := 0; i <= len(inner); i++ {
+		if i < len(inner) {
+			switch c := inner[i]; {
+			case quote != 0:
+				if c == quote {
+					quote = 0
+				}
+				continue
+			case c == '"' || c == '\'':
+				quote = c
+				continue
+			case c == '[' || c == '{':
+				return nil, fmt.Errorf("nested lists and mappings are not supported, got %q", raw)
+			case c != ',':
+				continue
+			}
+		}
+		item, err := yamlScalar(strings.TrimSpace(inner[start:i]))
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+		start = i + 1
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", raw)
+	}
+	return list, nil
+}
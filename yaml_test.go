@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	doc := `# Dashboards
+dashboards:
+  - name: Search   # Comment after a value
+    path: /search
+    sources: [search/*.jsonl, "redis://localhost:6379/evals"]
+    auth:
+      users:
+        alice: $SEARCH_PASSWORD
+  - name: 'Chat # 2'
+    path: /chat
+    sources:
+    - chat.jsonl
+    -
+      nested: value
+    theme:
+`
+	v, err := parseYAML(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := json.Marshal(v)
+	want := `{"dashboards":[{"auth":{"users":{"alice":"$SEARCH_PASSWORD"}},"name":"Search","path":"/search","sources":["search/*.jsonl","redis://localhost:6379/evals"]},{"name":"Chat # 2","path":"/chat","sources":["chat.jsonl",{"nested":"value"}],"theme":null}]}`
+	if string(got) != want {
+		t.Errorf("parseYAML =\n%s\nwant\n%s", got, want)
+	}
+
+	for _, bad := range []string{
+		"a: 1\n  b: 2",
+		"a:\n\tb: 2",
+		"a: {b: 1}",
+		"a: [b, [c]]",
+		"a: &anchor x",
+		"a: 1\na: 2",
+		"a:\n  - x\n  b: y",
+		"- x\n- y\nz: 1",
+	} {
+		if _, err := parseYAML(bad); err == nil {
+			t.Errorf("parseYAML accepted %q", bad)
+		}
+	}
+}